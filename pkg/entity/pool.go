@@ -22,6 +22,19 @@ type PoolToken struct {
 	Decimals  uint8  `json:"decimals,omitempty"`
 	Weight    uint   `json:"weight,omitempty"`
 	Swappable bool   `json:"swappable,omitempty"`
+	// TransferTax describes fee-on-transfer and rebasing tokens, nil for tokens transferring exact amounts.
+	TransferTax *TransferTax `json:"transferTax,omitempty"`
+}
+
+// TransferTax describes tokens not transferring the exact amount sent. Fee-on-transfer tokens tax transfers out of
+// pools (buys) and into pools (sells), and rebasing tokens transfer the shares worth the amount sent, rounded down.
+type TransferTax struct {
+	BuyTaxBps  int64 `json:"buyTaxBps,omitempty"`
+	SellTaxBps int64 `json:"sellTaxBps,omitempty"`
+	// TotalShares and TotalSupply are the total shares and tokens of rebasing tokens: transfers of amount move
+	// amount * TotalShares / TotalSupply shares, received as shares * TotalSupply / TotalShares tokens.
+	TotalShares string `json:"totalShares,omitempty"`
+	TotalSupply string `json:"totalSupply,omitempty"`
 }
 
 type PoolTokens []*PoolToken
@@ -37,6 +50,10 @@ func ClonePoolTokens(poolTokens []*PoolToken) []*PoolToken {
 			Weight:    poolToken.Weight,
 			Swappable: poolToken.Swappable,
 		}
+		if poolToken.TransferTax != nil {
+			transferTax := *poolToken.TransferTax
+			clonePoolToken.TransferTax = &transferTax
+		}
 		result[i] = clonePoolToken
 	}
 	return result
@@ -119,6 +136,7 @@ func (p *Pool) Clear() {
 			p.Tokens[i].Address = ""
 			p.Tokens[i].Symbol = ""
 			p.Tokens[i].Decimals = 0
+			p.Tokens[i].TransferTax = nil
 		}
 		p.Tokens = p.Tokens[:0]
 	}
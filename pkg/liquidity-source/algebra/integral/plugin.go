@@ -12,9 +12,12 @@ import (
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/algebra"
 )
 
+// TimepointStorage holds the timepoints of the volatility oracle. Writes to a fork leave its parent untouched, so that
+// simulated swaps never modify the timepoints shared by the pool and its clones.
 type TimepointStorage struct {
-	mu   sync.RWMutex
-	data map[uint16]Timepoint
+	mu     sync.RWMutex
+	data   map[uint16]Timepoint
+	parent *TimepointStorage
 }
 
 func NewTimepointStorage(data map[uint16]Timepoint) *TimepointStorage {
@@ -29,6 +32,8 @@ func (s *TimepointStorage) Get(index uint16) Timepoint {
 
 	if v, ok := s.data[index]; ok {
 		return v
+	} else if s.parent != nil {
+		return s.parent.Get(index)
 	}
 
 	return Timepoint{
@@ -41,6 +46,15 @@ func (s *TimepointStorage) Get(index uint16) Timepoint {
 		WindowStartIndex:     0,
 	}
 }
+
+// fork returns an empty storage reading through to s for the timepoints it does not hold.
+func (s *TimepointStorage) fork() *TimepointStorage {
+	return &TimepointStorage{
+		data:   make(map[uint16]Timepoint, 2),
+		parent: s,
+	}
+}
+
 func (s *TimepointStorage) set(index uint16, v Timepoint) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -416,7 +430,9 @@ func (s *TimepointStorage) binarySearchInternal(currentTime, target, left, right
 	}
 }
 
-func (p *PoolSimulator) calculateFeeFactors(currentTick, lastTick int32, priceChangeFactor uint16) (*SlidingFeeConfig, error) {
+// calculateFeeFactors returns a copy of slidingFee with the fee factors updated for the tick move from lastTick to
+// currentTick.
+func calculateFeeFactors(slidingFee *SlidingFeeConfig, currentTick, lastTick int32) (*SlidingFeeConfig, error) {
 	tickDelta := lo.Clamp(currentTick-lastTick, utils.MinTick, utils.MaxTick)
 
 	var sqrtPriceDelta v3Utils.Uint160
@@ -431,22 +447,23 @@ func (p *PoolSimulator) calculateFeeFactors(currentTick, lastTick int32, priceCh
 	}
 	priceChangeRatio := priceRatioSquared.Sub(priceRatioSquared, FEE_FACTOR_MULTIPLIER)
 
-	factor := uint256.NewInt(uint64(priceChangeFactor))
+	factor := uint256.NewInt(uint64(slidingFee.PriceChangeFactor))
 	feeFactorImpact := priceChangeRatio.Mul(priceChangeRatio, factor).Div(priceChangeRatio, FACTOR_DENOMINATOR)
 
-	feeFactors := p.slidingFee
-	newZeroToOneFeeFactor := feeFactors.ZeroToOneFeeFactor.Sub(feeFactors.ZeroToOneFeeFactor, feeFactorImpact)
+	feeFactors := *slidingFee
+	feeFactors.OneToZeroFeeFactor = slidingFee.OneToZeroFeeFactor.Clone()
+	newZeroToOneFeeFactor := new(uint256.Int).Sub(slidingFee.ZeroToOneFeeFactor, feeFactorImpact)
 	if 0 < newZeroToOneFeeFactor.Sign() && newZeroToOneFeeFactor.Cmp(DOUBLE_FEE_MULTIPLIER) < 0 {
 		feeFactors.ZeroToOneFeeFactor = newZeroToOneFeeFactor
 		feeFactors.OneToZeroFeeFactor.Add(feeFactors.OneToZeroFeeFactor, feeFactorImpact)
 	} else if newZeroToOneFeeFactor.Sign() <= 0 {
-		feeFactors.ZeroToOneFeeFactor.Clear()
+		feeFactors.ZeroToOneFeeFactor = newZeroToOneFeeFactor.Clear()
 		feeFactors.OneToZeroFeeFactor.Set(DOUBLE_FEE_MULTIPLIER)
 	} else {
-		feeFactors.ZeroToOneFeeFactor.Set(DOUBLE_FEE_MULTIPLIER)
+		feeFactors.ZeroToOneFeeFactor = newZeroToOneFeeFactor.Set(DOUBLE_FEE_MULTIPLIER)
 		feeFactors.OneToZeroFeeFactor.Clear()
 	}
-	return feeFactors, nil
+	return &feeFactors, nil
 }
 
 func getInputTokenDelta01(to, from, liquidity *uint256.Int) (*uint256.Int, error) {
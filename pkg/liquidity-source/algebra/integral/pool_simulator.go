@@ -5,8 +5,6 @@ import (
 	"math"
 	"math/big"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/KyberNetwork/int256"
 	"github.com/KyberNetwork/logger"
@@ -32,11 +30,10 @@ type PoolSimulator struct {
 	tickMax     int32
 	tickSpacing int
 
-	timepoints         *TimepointStorage
-	volatilityOracle   *VolatilityOraclePlugin
-	dynamicFee         *DynamicFeeConfig
-	slidingFee         *SlidingFeeConfig
-	writeTimePointOnce *sync.Once
+	timepoints       *TimepointStorage
+	volatilityOracle *VolatilityOraclePlugin
+	dynamicFee       *DynamicFeeConfig
+	slidingFee       *SlidingFeeConfig
 
 	useBasePluginV2 bool
 }
@@ -91,19 +88,18 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 	}
 
 	return &PoolSimulator{
-		Pool:               pool.Pool{Info: info},
-		globalState:        extra.GlobalState,
-		liquidity:          extra.Liquidity,
-		ticks:              ticks,
-		tickMin:            int32(tickMin),
-		tickMax:            int32(tickMax),
-		tickSpacing:        int(extra.TickSpacing),
-		timepoints:         timepoints,
-		volatilityOracle:   &extra.VolatilityOracle,
-		dynamicFee:         &extra.DynamicFee,
-		slidingFee:         &extra.SlidingFee,
-		writeTimePointOnce: new(sync.Once),
-		useBasePluginV2:    staticExtra.UseBasePluginV2,
+		Pool:             pool.Pool{Info: info},
+		globalState:      extra.GlobalState,
+		liquidity:        extra.Liquidity,
+		ticks:            ticks,
+		tickMin:          int32(tickMin),
+		tickMax:          int32(tickMax),
+		tickSpacing:      int(extra.TickSpacing),
+		timepoints:       timepoints,
+		volatilityOracle: &extra.VolatilityOracle,
+		dynamicFee:       &extra.DynamicFee,
+		slidingFee:       &extra.SlidingFee,
+		useBasePluginV2:  staticExtra.UseBasePluginV2,
 	}, nil
 }
 
@@ -115,7 +111,7 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		return nil, ErrInvalidAmountRequired
 	}
 
-	amtSpent, amtCalculated, fees, gas, stateUpdate, err := p.swap(tokenIn, tokenOut, amtRequired)
+	amtSpent, amtCalculated, fees, gas, stateUpdate, err := p.swap(tokenIn, tokenOut, amtRequired, uint32(param.Now()))
 	if err != nil {
 		return nil, err
 	} else if amtCalculated.IsZero() {
@@ -148,7 +144,7 @@ func (p *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 		return nil, ErrInvalidAmountRequired
 	}
 
-	amtSpent, amtCalculated, fees, gas, stateUpdate, err := p.swap(tokenIn, tokenOut, amtRequired.Neg(amtRequired))
+	amtSpent, amtCalculated, fees, gas, stateUpdate, err := p.swap(tokenIn, tokenOut, amtRequired.Neg(amtRequired), uint32(param.Now()))
 	if err != nil {
 		return nil, err
 	} else if amtCalculated.IsZero() {
@@ -173,7 +169,7 @@ func (p *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 	}, nil
 }
 
-func (p *PoolSimulator) swap(tokenIn, tokenOut string, amtRequired *uint256.Int) (amtSpent, amtCalculated *uint256.Int,
+func (p *PoolSimulator) swap(tokenIn, tokenOut string, amtRequired *uint256.Int, timestamp uint32) (amtSpent, amtCalculated *uint256.Int,
 	fees FeesAmount, gas int64, stateUpdate StateUpdate, err error) {
 	if !p.globalState.Unlocked {
 		err = ErrPoolLocked
@@ -187,11 +183,12 @@ func (p *PoolSimulator) swap(tokenIn, tokenOut string, amtRequired *uint256.Int)
 	}
 
 	zeroForOne := tokenInIndex == 0
-	overrideFee, pluginFee, err := lo.Ternary(p.useBasePluginV2 && p.slidingFee.FeeType,
-		p.beforeSwapV2, p.beforeSwapV1)(zeroForOne)
+	overrideFee, pluginFee, plugin, err := lo.Ternary(p.useBasePluginV2 && p.slidingFee.FeeType,
+		p.beforeSwapV2, p.beforeSwapV1)(zeroForOne, timestamp)
 	if err != nil {
 		return
 	}
+	lpFee := lo.Ternary(overrideFee != 0, overrideFee, uint32(plugin.lastFee))
 
 	priceLimit, err := p.getSqrtPriceLimit(zeroForOne)
 	if err != nil {
@@ -199,7 +196,7 @@ func (p *PoolSimulator) swap(tokenIn, tokenOut string, amtRequired *uint256.Int)
 	}
 
 	amtSpent, amtCalculated, currentPrice, currentTick, currentLiquidity, fees, ticksCrossed, err := p.calculateSwap(
-		overrideFee, pluginFee, zeroForOne, amtRequired, priceLimit)
+		lpFee, pluginFee, zeroForOne, amtRequired, priceLimit)
 	if err != nil {
 		return
 	}
@@ -208,6 +205,7 @@ func (p *PoolSimulator) swap(tokenIn, tokenOut string, amtRequired *uint256.Int)
 		Liquidity: currentLiquidity,
 		Price:     currentPrice,
 		Tick:      currentTick,
+		plugin:    plugin,
 	}, nil
 }
 
@@ -215,7 +213,6 @@ func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 	cloned := *p
 	cloned.liquidity = p.liquidity.Clone()
 	cloned.globalState.Price = p.globalState.Price.Clone()
-	cloned.writeTimePointOnce = new(sync.Once)
 	return &cloned
 }
 
@@ -229,6 +226,12 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	p.liquidity = si.Liquidity
 	p.globalState.Price = si.Price
 	p.globalState.Tick = si.Tick
+	if plugin := si.plugin; plugin != nil {
+		p.volatilityOracle = plugin.volatilityOracle
+		p.timepoints = plugin.timepoints
+		p.slidingFee = plugin.slidingFee
+		p.globalState.LastFee = plugin.lastFee
+	}
 }
 
 func (p *PoolSimulator) GetMetaInfo(tokenIn string, _ string) interface{} {
@@ -264,84 +267,96 @@ func (p *PoolSimulator) getSqrtPriceLimit(zeroForOne bool) (*uint256.Int, error)
 	return &sqrtPriceX96Limit, nil
 }
 
-var blockTimestamp = func() uint32 { return uint32(time.Now().Unix()) }
+// pluginState is the state of the pool plugin after its beforeSwap hook, applied to the pool on UpdateBalance.
+type pluginState struct {
+	volatilityOracle *VolatilityOraclePlugin
+	timepoints       *TimepointStorage
+	slidingFee       *SlidingFeeConfig
+	lastFee          uint16
+}
+
+func (p *PoolSimulator) pluginState() *pluginState {
+	volatilityOracle := *p.volatilityOracle
+	return &pluginState{
+		volatilityOracle: &volatilityOracle,
+		timepoints:       p.timepoints,
+		slidingFee:       p.slidingFee,
+		lastFee:          p.globalState.LastFee,
+	}
+}
 
-// writeTimepoint locks and writes timepoint only once, triggering onWrite only if said write happened.
-// By right we should re-update the timepoint every new second, but the difference should be small enough, and
-// new pool should have already been created and used in replacement of this pool.
-func (p *PoolSimulator) writeTimepoint(onWrite func() error) (err error) {
-	volatilityOracle := p.volatilityOracle
+// writeTimepoint writes the timepoint at the given block timestamp to a fork of the plugin timepoints, unless the last
+// timepoint is already at that timestamp. It reports whether the write happened.
+func (p *PoolSimulator) writeTimepoint(plugin *pluginState, timestamp uint32) (bool, error) {
+	volatilityOracle := plugin.volatilityOracle
 	if !volatilityOracle.IsInitialized {
-		return ErrNotInitialized
+		return false, ErrNotInitialized
+	} else if volatilityOracle.LastTimepointTimestamp == timestamp {
+		return false, nil
 	}
 
-	p.writeTimePointOnce.Do(func() {
-		volatilityOracle.LastTimepointTimestamp = blockTimestamp()
-		volatilityOracle.TimepointIndex, _, err = p.timepoints.write(
-			volatilityOracle.TimepointIndex, volatilityOracle.LastTimepointTimestamp, p.globalState.Tick)
-		if err != nil || onWrite == nil {
-			return
-		}
-		err = onWrite()
-	})
-	return err
+	timepoints := plugin.timepoints.fork()
+	timepointIndex, _, err := timepoints.write(volatilityOracle.TimepointIndex, timestamp, p.globalState.Tick)
+	if err != nil {
+		return false, err
+	}
+	volatilityOracle.TimepointIndex, volatilityOracle.LastTimepointTimestamp = timepointIndex, timestamp
+	plugin.timepoints = timepoints
+	return true, nil
 }
 
-func (p *PoolSimulator) beforeSwapV1(zeroForOne bool) (uint32, uint32, error) {
+func (p *PoolSimulator) beforeSwapV1(zeroForOne bool, timestamp uint32) (uint32, uint32, *pluginState, error) {
+	plugin := p.pluginState()
 	if p.globalState.PluginConfig&BEFORE_SWAP_FLAG == 0 {
-		return 0, 0, nil
+		return 0, 0, plugin, nil
 	}
-	return 0, 0, p.writeTimepoint(func() error {
-		volatilityLast, err := p.getAverageVolatilityLast()
-		if err != nil {
-			return err
-		}
-		var newFee uint16
-		if p.dynamicFee.ZeroToOne != 0 || p.dynamicFee.OneToZero != 0 {
-			// https://berascan.com/address/0x2393BcDBB298A4905f9885109B19834c50c8038F#code
-			newFee = lo.Ternary(zeroForOne, p.dynamicFee.ZeroToOne, p.dynamicFee.OneToZero)
-		} else if p.dynamicFee.Alpha1 == 0 && p.dynamicFee.Alpha2 == 0 {
-			newFee = p.dynamicFee.BaseFee
-		} else {
-			newFee = getFee(volatilityLast, p.dynamicFee)
-		}
-		p.globalState.LastFee = newFee
-		return nil
-	})
+	if written, err := p.writeTimepoint(plugin, timestamp); err != nil || !written {
+		return 0, 0, plugin, err
+	}
+
+	volatilityLast, err := p.getAverageVolatilityLast(plugin)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if p.dynamicFee.ZeroToOne != 0 || p.dynamicFee.OneToZero != 0 {
+		// https://berascan.com/address/0x2393BcDBB298A4905f9885109B19834c50c8038F#code
+		plugin.lastFee = lo.Ternary(zeroForOne, p.dynamicFee.ZeroToOne, p.dynamicFee.OneToZero)
+	} else if p.dynamicFee.Alpha1 == 0 && p.dynamicFee.Alpha2 == 0 {
+		plugin.lastFee = p.dynamicFee.BaseFee
+	} else {
+		plugin.lastFee = getFee(volatilityLast, p.dynamicFee)
+	}
+	return 0, 0, plugin, nil
 }
 
-func (p *PoolSimulator) beforeSwapV2(zeroToOne bool) (uint32, uint32, error) {
+func (p *PoolSimulator) beforeSwapV2(zeroToOne bool, timestamp uint32) (uint32, uint32, *pluginState, error) {
+	plugin := p.pluginState()
 	currentTick := p.globalState.Tick
 	lastTick := p.getLastTick()
 
-	newFee, err := p.getFeeAndUpdateFactors(zeroToOne, currentTick, lastTick)
+	newFee, err := p.getFeeAndUpdateFactors(plugin, zeroToOne, currentTick, lastTick)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, nil, err
 	}
 
-	if err := p.writeTimepoint(nil); err != nil {
-		return 0, 0, err
+	if _, err := p.writeTimepoint(plugin, timestamp); err != nil {
+		return 0, 0, nil, err
 	}
 
-	return uint32(newFee), 0, nil
+	return uint32(newFee), 0, plugin, nil
 }
 
-func (p *PoolSimulator) getFeeAndUpdateFactors(zeroToOne bool, currentTick, lastTick int32) (uint16, error) {
-	var currentFeeFactors *SlidingFeeConfig
-
+func (p *PoolSimulator) getFeeAndUpdateFactors(plugin *pluginState, zeroToOne bool, currentTick,
+	lastTick int32) (uint16, error) {
 	if currentTick != lastTick {
 		var err error
-		currentFeeFactors, err = p.calculateFeeFactors(currentTick, lastTick, p.slidingFee.PriceChangeFactor)
-		if err != nil {
+		if plugin.slidingFee, err = calculateFeeFactors(plugin.slidingFee, currentTick, lastTick); err != nil {
 			return 0, err
 		}
-
-		p.slidingFee = currentFeeFactors
-	} else {
-		currentFeeFactors = p.slidingFee
 	}
+	currentFeeFactors := plugin.slidingFee
 
-	adjustedFee := uint256.NewInt(uint64(p.slidingFee.BaseFee))
+	adjustedFee := uint256.NewInt(uint64(currentFeeFactors.BaseFee))
 	adjustedFee = adjustedFee.Rsh(
 		adjustedFee.Mul(adjustedFee,
 			lo.Ternary(zeroToOne, currentFeeFactors.ZeroToOneFeeFactor, currentFeeFactors.OneToZeroFeeFactor),
@@ -363,13 +378,14 @@ func (p *PoolSimulator) getLastTick() int32 {
 	return lastTimepoint.Tick
 }
 
-func (p *PoolSimulator) getAverageVolatilityLast() (*uint256.Int, error) {
-	currentTimestamp := p.volatilityOracle.LastTimepointTimestamp
+func (p *PoolSimulator) getAverageVolatilityLast(plugin *pluginState) (*uint256.Int, error) {
+	currentTimestamp := plugin.volatilityOracle.LastTimepointTimestamp
 	tick := p.globalState.Tick
-	lastTimepointIndex := p.volatilityOracle.TimepointIndex
-	oldestIndex := p.timepoints.getOldestIndex(lastTimepointIndex)
+	lastTimepointIndex := plugin.volatilityOracle.TimepointIndex
+	oldestIndex := plugin.timepoints.getOldestIndex(lastTimepointIndex)
 
-	volatilityAverage, err := p.timepoints.getAverageVolatility(currentTimestamp, tick, lastTimepointIndex, oldestIndex)
+	volatilityAverage, err := plugin.timepoints.getAverageVolatility(currentTimestamp, tick, lastTimepointIndex,
+		oldestIndex)
 	if err != nil {
 		return nil, err
 	}
@@ -377,7 +393,7 @@ func (p *PoolSimulator) getAverageVolatilityLast() (*uint256.Int, error) {
 	return volatilityAverage, nil
 }
 
-func (p *PoolSimulator) calculateSwap(overrideFee, pluginFee uint32, zeroToOne bool, amountRequired *uint256.Int,
+func (p *PoolSimulator) calculateSwap(lpFee, pluginFee uint32, zeroToOne bool, amountRequired *uint256.Int,
 	limitSqrtPrice *uint256.Int) (*uint256.Int, *uint256.Int, *uint256.Int, int32, *uint256.Int, FeesAmount, int64, error) {
 	if amountRequired.IsZero() {
 		return nil, nil, nil, 0, nil, FeesAmount{}, 0, ErrZeroAmountRequired
@@ -403,7 +419,7 @@ func (p *PoolSimulator) calculateSwap(overrideFee, pluginFee uint32, zeroToOne b
 	if pluginFee > 0 {
 		cache.pluginFee = uint256.NewInt(uint64(pluginFee))
 	}
-	cache.fee = uint64(lo.Ternary(overrideFee != 0, overrideFee, uint32(p.globalState.LastFee)) + pluginFee)
+	cache.fee = uint64(lpFee + pluginFee)
 	if cache.fee >= 1e6 {
 		return nil, nil, nil, 0, nil, FeesAmount{}, 0, ErrIncorrectPluginFee
 	}
@@ -2,7 +2,6 @@ package integral
 
 import (
 	"math/big"
-	"sync"
 	"testing"
 
 	"github.com/KyberNetwork/int256"
@@ -177,8 +176,7 @@ func TestCalcAmountOut(t *testing.T) {
 					VolumeGamma: mockVolumeGamma,
 					BaseFee:     mockBaseFee,
 				},
-				writeTimePointOnce: new(sync.Once),
-				useBasePluginV2:    false,
+				useBasePluginV2: false,
 			},
 			input: pool.CalcAmountOutParams{
 				TokenAmountIn: pool.TokenAmount{
@@ -254,8 +252,7 @@ func TestCalcAmountOut(t *testing.T) {
 					VolumeGamma: mockVolumeGamma,
 					BaseFee:     mockBaseFee,
 				},
-				writeTimePointOnce: new(sync.Once),
-				useBasePluginV2:    false,
+				useBasePluginV2: false,
 			},
 			input: pool.CalcAmountOutParams{
 				TokenAmountIn: pool.TokenAmount{
@@ -331,8 +328,7 @@ func TestCalcAmountOut(t *testing.T) {
 					VolumeGamma: mockVolumeGamma,
 					BaseFee:     mockBaseFee,
 				},
-				writeTimePointOnce: new(sync.Once),
-				useBasePluginV2:    false,
+				useBasePluginV2: false,
 			},
 			input: pool.CalcAmountOutParams{
 				TokenAmountIn: pool.TokenAmount{
@@ -408,8 +404,7 @@ func TestCalcAmountOut(t *testing.T) {
 					VolumeGamma: mockVolumeGamma,
 					BaseFee:     mockBaseFee,
 				},
-				writeTimePointOnce: new(sync.Once),
-				useBasePluginV2:    false,
+				useBasePluginV2: false,
 			},
 			input: pool.CalcAmountOutParams{
 				TokenAmountIn: pool.TokenAmount{
@@ -459,7 +454,9 @@ func TestCalcAmountOut(t *testing.T) {
 				expectedSwapInfo := tt.expectedResult.SwapInfo.(StateUpdate)
 				actualSwapInfo := result.SwapInfo.(StateUpdate)
 
-				assert.Equal(t, expectedSwapInfo, actualSwapInfo)
+				assert.Equal(t, expectedSwapInfo.Liquidity, actualSwapInfo.Liquidity)
+				assert.Equal(t, expectedSwapInfo.Price, actualSwapInfo.Price)
+				assert.Equal(t, expectedSwapInfo.Tick, actualSwapInfo.Tick)
 
 				require.NotEmpty(t, result.SwapInfo)
 				assert.Equal(t, tt.expectedResult.TokenAmountOut, result.TokenAmountOut)
@@ -500,14 +497,14 @@ var (
 )
 
 func TestCalcAmountOut_Ver_1_2(t *testing.T) {
-	blockTimestamp = func() uint32 { return 1737563754 }
 	res, err := testutil.MustConcurrentSafe(t, func() (*pool.CalcAmountOutResult, error) {
 		return thenaPS.CalcAmountOut(pool.CalcAmountOutParams{
 			TokenAmountIn: pool.TokenAmount{
 				Token:  "0xbb4cdb9cbd36b01bd1cbaebf2de08d9173bc095c",
 				Amount: big.NewInt(1e16),
 			},
-			TokenOut: "0x55d398326f99059ff775485246999027b3197955",
+			TokenOut:  "0x55d398326f99059ff775485246999027b3197955",
+			Timestamp: 1737563754,
 		})
 	})
 
@@ -518,3 +515,37 @@ func TestCalcAmountOut_Ver_1_2(t *testing.T) {
 func TestPoolSimulator_CalcAmountIn(t *testing.T) {
 	testutil.TestCalcAmountIn(t, ps)
 }
+
+func TestPoolSimulator_Timestamp(t *testing.T) {
+	calcAmountOut := func(t *testing.T, poolSim *PoolSimulator, timestamp int64) *pool.CalcAmountOutResult {
+		res, err := poolSim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{
+				Token:  "0x21be370d5312f44cb42ce377bc9b8a0cef1a4c83",
+				Amount: big.NewInt(100000000000000),
+			},
+			TokenOut:  "0xfe7eda5f2c56160d406869a8aa4b2f365d544c7b",
+			Timestamp: timestamp,
+		})
+		require.NoError(t, err)
+		return res
+	}
+	const timestamp = 1733225338
+
+	fresh := lo.Must(NewPoolSimulator(p))
+	expected := calcAmountOut(t, fresh, timestamp+3600)
+
+	poolSim := lo.Must(NewPoolSimulator(p))
+	first := calcAmountOut(t, poolSim, timestamp)
+	assert.Equal(t, expected.TokenAmountOut, calcAmountOut(t, poolSim, timestamp+3600).TokenAmountOut,
+		"quotes are not frozen at the timestamp of the first quote")
+	assert.Equal(t, first.TokenAmountOut, calcAmountOut(t, poolSim, timestamp).TokenAmountOut)
+	assert.EqualValues(t, 1712116096, poolSim.volatilityOracle.LastTimepointTimestamp,
+		"quotes do not write timepoints")
+
+	cloned := poolSim.CloneState().(*PoolSimulator)
+	poolSim.UpdateBalance(pool.UpdateBalanceParams{SwapInfo: first.SwapInfo})
+	assert.EqualValues(t, timestamp, poolSim.volatilityOracle.LastTimepointTimestamp)
+	assert.True(t, poolSim.timepoints.Get(1).Initialized)
+	assert.False(t, cloned.timepoints.Get(1).Initialized)
+	assert.Equal(t, expected.TokenAmountOut, calcAmountOut(t, cloned, timestamp+3600).TokenAmountOut)
+}
@@ -300,9 +300,22 @@ func (d *PoolTracker) getPluginData(ctx context.Context, p entity.Pool, plugin s
 		return nil, VolatilityOraclePlugin{}, DynamicFeeConfig{}, SlidingFeeConfig{}, err
 	}
 
+	timestampReq := d.EthrpcClient.NewRequest().SetContext(ctx)
+	if blockNumber != nil && blockNumber.Sign() > 0 {
+		timestampReq.SetBlockNumber(blockNumber)
+	}
+	blockTimestamp, err := timestampReq.GetCurrentBlockTimestamp()
+	if err != nil {
+		l.WithFields(logger.Fields{
+			"error": err,
+		}).Error("failed to fetch block timestamp")
+		return nil, VolatilityOraclePlugin{}, DynamicFeeConfig{}, SlidingFeeConfig{}, err
+	}
+
 	var extra ExtraTimepoint
 	_ = json.Unmarshal([]byte(p.Extra), &extra)
-	timepoints, err := d.getTimepoints(ctx, plugin, blockNumber, volatilityOracleData.TimepointIndex, extra.Timepoints)
+	timepoints, err := d.getTimepoints(ctx, plugin, blockNumber, uint32(blockTimestamp),
+		volatilityOracleData.TimepointIndex, extra.Timepoints)
 	if err != nil {
 		l.WithFields(logger.Fields{
 			"error": err,
@@ -414,13 +427,15 @@ func (d *PoolTracker) getSlidingFeeData(req *ethrpc.Request, pluginAddress strin
 	}
 }
 
+// getTimepoints fetches the timepoints of the plugin within the volatility window ending at blockTimestamp, the timestamp
+// of the block the pool state is fetched at.
 func (d *PoolTracker) getTimepoints(ctx context.Context, pluginAddress string, blockNumber *big.Int,
-	currentIndex uint16, timepoints map[uint16]Timepoint) (map[uint16]Timepoint, error) {
+	blockTimestamp uint32, currentIndex uint16, timepoints map[uint16]Timepoint) (map[uint16]Timepoint, error) {
 	return d.GetTimepoints(ctx, &ethrpc.Call{
 		ABI:    algebraBasePluginV2ABI,
 		Target: pluginAddress,
 		Method: votalityOraclePluginTimepointsMethod,
-	}, blockNumber, blockTimestamp()-WINDOW, currentIndex, timepoints)
+	}, blockNumber, blockTimestamp-WINDOW, currentIndex, timepoints)
 }
 
 func (d *PoolTracker) getPoolTicks(ctx context.Context, poolAddress string) ([]TickResp, error) {
@@ -214,6 +214,8 @@ type StateUpdate struct {
 	Liquidity *uint256.Int
 	Price     *uint256.Int
 	Tick      int32
+
+	plugin *pluginState
 }
 
 type PoolMeta struct {
@@ -32,6 +32,10 @@ type Config struct {
 
 	// The discriminator for pools of the same token pair. We assume that there is at most 1 pool for a token pair.
 	PoolIdx *big.Int `json:"poolIdx"`
+
+	// The number of tick-size steps on each side of the curve tick whose levels are fetched for simulation.
+	// Swaps crossing beyond this window are rejected by the PoolSimulator.
+	TickWindow int `json:"tickWindow"`
 }
 
 func (c *Config) Validate() error {
@@ -1,6 +1,8 @@
 package ambient
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -8,9 +10,27 @@ const (
 	DexTypeAmbient = "ambient"
 
 	defaultSubgraphLimit = 1000
+
+	// defaultTickWindow is the number of tick-size steps scanned on each side of the curve tick.
+	defaultTickWindow = 64
+
+	// defaultMulticallChunk is the number of level and knockout pivot queries aggregated in a multicall.
+	defaultMulticallChunk = 500
 )
 
 var (
 	// NativeTokenPlaceholderAddress is the address that Ambient uses to represent native token in pools.
 	NativeTokenPlaceholderAddress = common.HexToAddress("0x0")
+
+	defaultGas = Gas{BaseGas: 110000, CrossTickGas: 25000}
+)
+
+var (
+	ErrInvalidToken          = errors.New("invalid token")
+	ErrPairNotFound          = errors.New("token pair not found")
+	ErrInvalidAmountIn       = errors.New("invalid amount in")
+	ErrInvalidAmountOut      = errors.New("invalid amount out")
+	ErrTickOutOfRange        = errors.New("swap crosses beyond the tracked tick range")
+	ErrUint128Overflow       = errors.New("uint128 overflow")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
 )
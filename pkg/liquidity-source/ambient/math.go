@@ -0,0 +1,393 @@
+package ambient
+
+import (
+	"github.com/KyberNetwork/blockchain-toolkit/number"
+	v3utils "github.com/KyberNetwork/uniswapv3-sdk-uint256/utils"
+	"github.com/holiman/uint256"
+)
+
+// This file ports the parts of CrocSwap-Protocol's libraries (FixedPoint, CompoundMath, CurveMath, CurveRoll,
+// CurveAssimilate and TickMath) needed to simulate a swap. Functions keep their Solidity names where possible so
+// they can be audited side-by-side with the contracts. Reverting conditions (SafeCast) panic with
+// ErrUint128Overflow and are recovered by the PoolSimulator.
+
+const (
+	lotSizeBits = 10
+
+	feeBpMult      = 1000000
+	protoShareMult = 256
+
+	minTick = -665454
+	maxTick = 831818
+)
+
+var (
+	q48        = new(uint256.Int).Lsh(number.Number_1, 48)
+	q128       = new(uint256.Int).Lsh(number.Number_1, 128)
+	maxUint128 = new(uint256.Int).Sub(q128, number.Number_1)
+
+	minSqrtRatio = uint256.NewInt(65538)
+	maxSqrtRatio = uint256.MustFromDecimal("21267430153580247136652501917186561138")
+
+	knockoutFlagMask = number.Number_1
+)
+
+func toUint128(x *uint256.Int) *uint256.Int {
+	if x.Gt(maxUint128) {
+		panic(ErrUint128Overflow)
+	}
+	return x
+}
+
+func mulQ64(x, y *uint256.Int) *uint256.Int {
+	z := new(uint256.Int).Mul(x, y)
+	return z.Rsh(z, 64)
+}
+
+func divQ64(x, y *uint256.Int) *uint256.Int {
+	z := new(uint256.Int).Lsh(x, 64)
+	return z.Div(z, y)
+}
+
+func recipQ64(x *uint256.Int) *uint256.Int {
+	return new(uint256.Int).Div(q128, x)
+}
+
+func mulQ48(x *uint256.Int, y uint64) *uint256.Int {
+	z := new(uint256.Int).Mul(x, uint256.NewInt(y))
+	return z.Rsh(z, 48)
+}
+
+func inflateLiqSeed(seed *uint256.Int, growth uint64) *uint256.Int {
+	z := new(uint256.Int).Add(q48, uint256.NewInt(growth))
+	z.Mul(z, seed)
+	return toUint128(z.Rsh(z, 48))
+}
+
+func deflateLiqSeed(liq *uint256.Int, growth uint64) *uint256.Int {
+	num := new(uint256.Int).Lsh(liq, 48)
+	return toUint128(num.Div(num, new(uint256.Int).Add(q48, uint256.NewInt(growth))))
+}
+
+func compoundDivide(inflated, seed *uint256.Int) uint64 {
+	z := new(uint256.Int).Lsh(inflated, 48)
+	z.Div(z, seed).Sub(z, q48)
+	if z.Cmp(new(uint256.Int).Lsh(q48, 16)) >= 0 {
+		return ^uint64(0)
+	}
+	return z.Uint64()
+}
+
+func approxSqrtCompound(x uint64) uint64 {
+	xx := uint256.NewInt(x)
+	xSq := new(uint256.Int).Mul(xx, xx)
+	xSq.Rsh(xSq, 48)
+	linear := xx.Rsh(xx, 1)
+	quad := xSq.Rsh(xSq, 3)
+	return linear.Sub(linear, quad).Uint64()
+}
+
+func compoundPrice(price *uint256.Int, growth uint64, shiftUp bool) *uint256.Int {
+	multFactor := new(uint256.Int).Add(q48, uint256.NewInt(growth))
+	if shiftUp {
+		z := new(uint256.Int).Mul(price, multFactor)
+		z.Rsh(z, 48).AddUint64(z, 1)
+		return toUint128(z)
+	}
+	z := new(uint256.Int).Lsh(price, 48)
+	return z.Div(z, multFactor)
+}
+
+func compoundStack(x, y uint64) uint64 {
+	a := new(uint256.Int).Add(q48, uint256.NewInt(x))
+	b := new(uint256.Int).Add(q48, uint256.NewInt(y))
+	z := a.Mul(a, b)
+	z.Rsh(z, 48).Sub(z, q48)
+	if !z.IsUint64() || z.Uint64() == ^uint64(0) {
+		return ^uint64(0)
+	}
+	return z.Uint64()
+}
+
+func compoundShrink(val, deflator uint64) uint64 {
+	z := new(uint256.Int).Lsh(uint256.NewInt(val), 48)
+	return z.Div(z, new(uint256.Int).Add(q48, uint256.NewInt(deflator))).Uint64()
+}
+
+// lotsToLiquidity strips the knockout flag and converts lots to liquidity.
+func lotsToLiquidity(lots *uint256.Int) *uint256.Int {
+	z := new(uint256.Int).Rsh(lots, 1)
+	z.Lsh(z, 1)
+	return z.Lsh(z, lotSizeBits)
+}
+
+func hasKnockoutLiq(lots *uint256.Int) bool {
+	return !new(uint256.Int).And(lots, knockoutFlagMask).IsZero()
+}
+
+// getSqrtRatioAtTick returns the Q64.64 square root price of a tick. Croc computes the same Q128.128 ratio as
+// Uniswap V3 and rounds it up to 64 fractional bits, ceil(ceil(r/2^32)/2^32) == ceil(r/2^64).
+func getSqrtRatioAtTick(tick int32) *uint256.Int {
+	var sqrtPriceX96 v3utils.Uint160
+	if err := v3utils.GetSqrtRatioAtTickV2(int(tick), &sqrtPriceX96); err != nil {
+		panic(err)
+	}
+	rem := new(uint256.Int).And(&sqrtPriceX96, uint256.NewInt(1<<32-1))
+	z := new(uint256.Int).Rsh(&sqrtPriceX96, 32)
+	if !rem.IsZero() {
+		z.AddUint64(z, 1)
+	}
+	return z
+}
+
+func getTickAtSqrtRatio(price *uint256.Int) int32 {
+	sqrtPriceX96 := new(uint256.Int).Lsh(price, 32)
+	tick, err := v3utils.GetTickAtSqrtRatioV2(sqrtPriceX96)
+	if err != nil {
+		panic(err)
+	}
+	return int32(tick)
+}
+
+type curveState struct {
+	priceRoot    *uint256.Int
+	ambientSeeds *uint256.Int
+	concLiq      *uint256.Int
+	seedDeflator uint64
+	concGrowth   uint64
+}
+
+func (c *curveState) clone() *curveState {
+	return &curveState{
+		priceRoot:    c.priceRoot.Clone(),
+		ambientSeeds: c.ambientSeeds.Clone(),
+		concLiq:      c.concLiq.Clone(),
+		seedDeflator: c.seedDeflator,
+		concGrowth:   c.concGrowth,
+	}
+}
+
+func (c *curveState) activeLiquidity() *uint256.Int {
+	ambient := inflateLiqSeed(c.ambientSeeds, c.seedDeflator)
+	return toUint128(ambient.Add(ambient, c.concLiq))
+}
+
+func reserveAtPrice(liq, price *uint256.Int, inBaseQty bool) *uint256.Int {
+	if inBaseQty {
+		return toUint128(mulQ64(liq, price))
+	}
+	return toUint128(divQ64(liq, price))
+}
+
+func deltaBase(liq, priceX, priceY *uint256.Int) *uint256.Int {
+	priceDelta := new(uint256.Int)
+	if priceX.Gt(priceY) {
+		priceDelta.Sub(priceX, priceY)
+	} else {
+		priceDelta.Sub(priceY, priceX)
+	}
+	return reserveAtPrice(liq, priceDelta, true)
+}
+
+func deltaQuote(liq, price, limitPrice *uint256.Int) *uint256.Int {
+	if limitPrice.Gt(price) {
+		return calcQuoteDelta(liq, limitPrice, price)
+	}
+	return calcQuoteDelta(liq, price, limitPrice)
+}
+
+func calcQuoteDelta(liq, priceBig, priceSmall *uint256.Int) *uint256.Int {
+	priceDelta := new(uint256.Int).Sub(priceBig, priceSmall)
+	termOne := divQ64(liq, priceSmall)
+	termTwo := termOne.Mul(termOne, priceDelta)
+	return toUint128(termTwo.Div(termTwo, priceBig))
+}
+
+func (c *curveState) calcLimitFlows(swapQty *uint256.Int, inBaseQty bool, limitPrice *uint256.Int) *uint256.Int {
+	liq := c.activeLiquidity()
+	var limitFlow *uint256.Int
+	if inBaseQty {
+		limitFlow = deltaBase(liq, c.priceRoot, limitPrice)
+	} else {
+		limitFlow = deltaQuote(liq, c.priceRoot, limitPrice)
+	}
+	if limitFlow.Gt(swapQty) {
+		return swapQty.Clone()
+	}
+	return limitFlow
+}
+
+func (c *curveState) calcLimitCounter(swapQty *uint256.Int, inBaseQty bool, limitPrice *uint256.Int) *uint256.Int {
+	isBuy := limitPrice.Gt(c.priceRoot)
+	denomFlow := c.calcLimitFlows(swapQty, inBaseQty, limitPrice)
+	return invertFlow(c.activeLiquidity(), c.priceRoot, denomFlow, isBuy, inBaseQty)
+}
+
+func invertFlow(liq, price, denomFlow *uint256.Int, isBuy, inBaseQty bool) *uint256.Int {
+	if liq.IsZero() {
+		return new(uint256.Int)
+	}
+
+	invertReserve := reserveAtPrice(liq, price, !inBaseQty)
+	initReserve := reserveAtPrice(liq, price, inBaseQty)
+
+	endReserve := new(uint256.Int)
+	if isBuy == inBaseQty {
+		endReserve.Add(initReserve, denomFlow)
+	} else {
+		endReserve.Sub(initReserve, denomFlow)
+	}
+	if endReserve.IsZero() {
+		return maxUint128.Clone()
+	}
+
+	endInvert := new(uint256.Int).Mul(liq, liq)
+	endInvert.Div(endInvert, endReserve)
+	if endInvert.Gt(invertReserve) {
+		return toUint128(endInvert.Sub(endInvert, invertReserve))
+	}
+	return toUint128(endInvert.Sub(invertReserve, endInvert))
+}
+
+// priceToTokenPrecision is the amount of token collateral needed to buffer a one unit price rounding.
+func priceToTokenPrecision(liq, price *uint256.Int, inBase bool) *uint256.Int {
+	if inBase {
+		z := new(uint256.Int).Rsh(liq, 64)
+		return z.AddUint64(z, 1)
+	}
+	step := divQ64(liq, new(uint256.Int).SubUint64(price, 1))
+	start := divQ64(liq, price)
+	delta := step.Sub(step, start)
+	if delta.Cmp(maxUint128) >= 0 {
+		return maxUint128.Clone()
+	}
+	return delta.AddUint64(delta, 1)
+}
+
+// assimilateLiq folds the liquidity fees paid by a swap into the curve, see CurveAssimilate.sol.
+func (c *curveState) assimilateLiq(feesPaid *uint256.Int, isSwapInBase bool) {
+	liq := c.activeLiquidity()
+	if liq.IsZero() {
+		return
+	}
+
+	feesInBase := !isSwapInBase
+	feesToLiq := shaveForPrecision(liq, c.priceRoot, feesPaid, feesInBase)
+	inflator := calcLiqInflator(liq, c.priceRoot, feesToLiq, feesInBase)
+	if inflator > 0 {
+		c.stepToLiquidity(inflator, feesInBase)
+	}
+}
+
+func shaveForPrecision(liq, price, feesPaid *uint256.Int, isFeesInBase bool) *uint256.Int {
+	bufferTokens := priceToTokenPrecision(liq, price, isFeesInBase)
+	if !feesPaid.Gt(bufferTokens) {
+		return new(uint256.Int)
+	}
+	return bufferTokens.Sub(feesPaid, bufferTokens)
+}
+
+func calcLiqInflator(liq, price, feesPaid *uint256.Int, inBaseQty bool) uint64 {
+	reserve := reserveAtPrice(liq, price, inBaseQty)
+	return calcReserveInflator(reserve, feesPaid)
+}
+
+func calcReserveInflator(reserve, feesPaid *uint256.Int) uint64 {
+	if reserve.IsZero() || feesPaid.Gt(reserve) {
+		return 0
+	}
+
+	nextReserve := toUint128(new(uint256.Int).Add(reserve, feesPaid))
+	inflatorRoot := compoundDivide(nextReserve, reserve)
+	if inflatorRoot >= q48.Uint64() {
+		// approxSqrtCompound requires its input to be less than 1.0, unreachable as fees never exceed reserves
+		return q48.Uint64()
+	}
+	inflator := approxSqrtCompound(inflatorRoot)
+	if inflator >= q48.Uint64() {
+		inflator = q48.Uint64()
+	}
+	return inflator
+}
+
+func (c *curveState) stepToLiquidity(inflator uint64, feesInBase bool) {
+	c.priceRoot = compoundPrice(c.priceRoot, inflator, feesInBase)
+	c.seedDeflator = compoundStack(c.seedDeflator, inflator)
+
+	concRewards := compoundShrink(inflator, c.seedDeflator)
+	newAmbientSeeds := toUint128(mulQ48(c.concLiq, concRewards))
+	if concRewards > 0 {
+		c.concGrowth += concRewards - 1
+	}
+	c.ambientSeeds = toUint128(newAmbientSeeds.Add(newAmbientSeeds, c.ambientSeeds))
+}
+
+func calcFeeOverFlow(flow *uint256.Int, feeRate uint16, protoProp uint8) (liqFee, protoFee *uint256.Int) {
+	totalFee := new(uint256.Int).Mul(flow, uint256.NewInt(uint64(feeRate)))
+	totalFee.Div(totalFee, uint256.NewInt(feeBpMult))
+	protoFee = new(uint256.Int).Mul(totalFee, uint256.NewInt(uint64(protoProp)))
+	protoFee.Div(protoFee, uint256.NewInt(protoShareMult))
+	return totalFee.Sub(totalFee, protoFee), protoFee
+}
+
+func deriveFlowPrice(price, liq, flow *uint256.Int, inBaseQty, isBuy bool) *uint256.Int {
+	var curvePrice *uint256.Int
+	if inBaseQty {
+		curvePrice = calcBaseFlowPrice(price, liq, flow, isBuy)
+	} else {
+		curvePrice = calcQuoteFlowPrice(price, liq, flow, isBuy)
+	}
+
+	if curvePrice.Cmp(maxSqrtRatio) >= 0 {
+		return new(uint256.Int).SubUint64(maxSqrtRatio, 1)
+	}
+	if curvePrice.Lt(minSqrtRatio) {
+		return minSqrtRatio.Clone()
+	}
+	return curvePrice
+}
+
+func calcBaseFlowPrice(price, liq, flow *uint256.Int, isBuy bool) *uint256.Int {
+	if liq.IsZero() {
+		return maxUint128.Clone()
+	}
+
+	priceDelta := divQ64(flow, liq)
+	if priceDelta.Gt(maxUint128) {
+		return maxUint128.Clone()
+	}
+
+	if isBuy {
+		return priceDelta.Add(price, priceDelta)
+	}
+	if priceDelta.Cmp(price) >= 0 {
+		return new(uint256.Int)
+	}
+	priceDelta.AddUint64(priceDelta, 1)
+	return priceDelta.Sub(price, priceDelta)
+}
+
+func calcQuoteFlowPrice(price, liq, flow *uint256.Int, isBuy bool) *uint256.Int {
+	if liq.IsZero() {
+		return new(uint256.Int)
+	}
+
+	invPrice := recipQ64(price)
+	invDelta := toUint128(divQ64(flow, liq))
+
+	invLimit := new(uint256.Int)
+	if isBuy {
+		if invDelta.Cmp(invPrice) >= 0 {
+			return maxUint128.Clone()
+		}
+		invLimit.Sub(invPrice, invDelta)
+	} else {
+		invLimit.Add(invPrice, invDelta)
+	}
+	if invLimit.IsZero() {
+		return maxUint128.Clone()
+	}
+
+	z := recipQ64(invLimit)
+	return z.AddUint64(z, 1)
+}
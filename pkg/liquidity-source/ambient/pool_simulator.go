@@ -0,0 +1,341 @@
+package ambient
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+	"github.com/samber/lo"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+// PoolSimulator simulates swaps on every token pair of the CrocSwapDex contract. Each pair is an independent curve
+// with its own ambient and concentrated liquidity, only the contract balances (reserves) are shared.
+type PoolSimulator struct {
+	*NTokenPool
+
+	// states[i] and poolIdxs[i] correspond to pairs[i]
+	states   []*pairState
+	poolIdxs []*big.Int
+	gas      Gas
+}
+
+var _ = pool.RegisterFactory0(DexTypeAmbient, NewPoolSimulator)
+
+func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(entityPool.StaticExtra), &staticExtra); err != nil {
+		return nil, fmt.Errorf("could not unmarshal StaticExtra: %w", err)
+	}
+	var extra Extra
+	if err := json.Unmarshal([]byte(entityPool.Extra), &extra); err != nil {
+		return nil, fmt.Errorf("could not unmarshal Extra: %w", err)
+	}
+
+	allPairs := lo.Keys(extra.TokenPairs)
+	sort.Slice(allPairs, func(i, j int) bool { return allPairs[i].String() < allPairs[j].String() })
+
+	// only pairs with a valid curve state are exposed to CanSwapTo
+	var (
+		pairs    = make([]TokenPair, 0, len(allPairs))
+		states   = make([]*pairState, 0, len(allPairs))
+		poolIdxs = make([]*big.Int, 0, len(allPairs))
+	)
+	for _, pair := range allPairs {
+		info := extra.TokenPairs[pair]
+		state, err := newPairState(info)
+		if err != nil {
+			return nil, fmt.Errorf("invalid state of pair %s: %w", pair, err)
+		} else if state == nil {
+			continue
+		}
+		pairs = append(pairs, pair)
+		states = append(states, state)
+		poolIdxs = append(poolIdxs, info.PoolIdx)
+	}
+
+	info := pool.PoolInfo{
+		Address:     strings.ToLower(entityPool.Address),
+		ReserveUsd:  entityPool.ReserveUsd,
+		Exchange:    entityPool.Exchange,
+		Type:        entityPool.Type,
+		Tokens:      lo.Map(entityPool.Tokens, func(item *entity.PoolToken, _ int) string { return item.Address }),
+		Reserves:    lo.Map(entityPool.Reserves, func(item string, _ int) *big.Int { return bignumber.NewBig(item) }),
+		BlockNumber: entityPool.BlockNumber,
+	}
+
+	return &PoolSimulator{
+		NTokenPool: NewNTokenPool(pool.Pool{Info: info}, pairs, staticExtra.NativeTokenAddress),
+		states:     states,
+		poolIdxs:   poolIdxs,
+		gas:        defaultGas,
+	}, nil
+}
+
+func newPairState(info *TokenPairInfo) (*pairState, error) {
+	if info.Curve == nil || info.Params == nil || info.Curve.PriceRoot == nil {
+		return nil, nil
+	}
+
+	curve := &curveState{
+		seedDeflator: info.Curve.SeedDeflator,
+		concGrowth:   info.Curve.ConcGrowth,
+	}
+	var overflow bool
+	for _, v := range []struct {
+		dst **uint256.Int
+		src *big.Int
+	}{
+		{&curve.priceRoot, info.Curve.PriceRoot},
+		{&curve.ambientSeeds, info.Curve.AmbientSeeds},
+		{&curve.concLiq, info.Curve.ConcLiq},
+	} {
+		if v.src == nil {
+			*v.dst = new(uint256.Int)
+			continue
+		}
+		if *v.dst, overflow = uint256.FromBig(v.src); overflow {
+			return nil, ErrUint128Overflow
+		}
+	}
+	if curve.priceRoot.Lt(minSqrtRatio) || curve.priceRoot.Cmp(maxSqrtRatio) >= 0 {
+		return nil, nil
+	}
+
+	levels := make([]level, 0, len(info.Levels))
+	for _, lvl := range info.Levels {
+		levels = append(levels, level{
+			Tick:        lvl.Tick,
+			BidLots:     uint256.MustFromBig(lvl.BidLots),
+			AskLots:     uint256.MustFromBig(lvl.AskLots),
+			KnockoutBid: newKnockoutPivot(lvl.KnockoutBid),
+			KnockoutAsk: newKnockoutPivot(lvl.KnockoutAsk),
+		})
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Tick < levels[j].Tick })
+
+	return &pairState{
+		Curve:     curve,
+		Params:    *info.Params,
+		Levels:    levels,
+		TickLower: info.TickLower,
+		TickUpper: info.TickUpper,
+	}, nil
+}
+
+func newKnockoutPivot(pivot *KnockoutPivot) *knockoutPivot {
+	if pivot == nil || pivot.Lots == nil {
+		return nil
+	}
+	return &knockoutPivot{Lots: uint256.MustFromBig(pivot.Lots), Range: pivot.Range}
+}
+
+func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	idxIn, idxOut := s.GetTokenIndex(params.TokenAmountIn.Token), s.GetTokenIndex(params.TokenOut)
+	if idxIn < 0 || idxOut < 0 {
+		return nil, ErrInvalidToken
+	}
+	amountIn, overflow := uint256.FromBig(params.TokenAmountIn.Amount)
+	if overflow || amountIn.Sign() <= 0 || amountIn.Gt(maxUint128) {
+		return nil, ErrInvalidAmountIn
+	}
+
+	pairIdx, inBase, err := s.pairOf(params.TokenAmountIn.Token, params.TokenOut)
+	if err != nil {
+		return nil, err
+	}
+
+	// exact input: the fixed quantity is on the input side, and the user buys quote when paying base
+	res, err := s.swap(pairIdx, inBase, inBase, amountIn)
+	if err != nil {
+		return nil, err
+	}
+
+	amountOut, fee := res.flow.quoteFlow, res.flow.quoteFee
+	if !inBase {
+		amountOut, fee = res.flow.baseFlow, res.flow.baseFee
+	}
+	amountOut = new(int256.Int).Neg(amountOut)
+	if amountOut.Sign() <= 0 {
+		return nil, ErrInvalidAmountOut
+	}
+	if reserveOut := s.Info.Reserves[idxOut]; reserveOut != nil && reserveOut.Cmp(amountOut.ToBig()) < 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return &pool.CalcAmountOutResult{
+		TokenAmountOut: &pool.TokenAmount{Token: params.TokenOut, Amount: amountOut.ToBig()},
+		Fee:            &pool.TokenAmount{Token: params.TokenOut, Amount: fee.ToBig()},
+		Gas:            s.gas.BaseGas + s.gas.CrossTickGas*int64(len(res.crossedTicks)),
+		SwapInfo: SwapInfo{
+			Pair:         s.pairs[pairIdx],
+			NextCurve:    res.curve.toCurveState(),
+			CrossedTicks: res.crossedTicks,
+			IsBuy:        inBase,
+		},
+	}, nil
+}
+
+func (s *PoolSimulator) CalcAmountIn(params pool.CalcAmountInParams) (*pool.CalcAmountInResult, error) {
+	idxIn, idxOut := s.GetTokenIndex(params.TokenIn), s.GetTokenIndex(params.TokenAmountOut.Token)
+	if idxIn < 0 || idxOut < 0 {
+		return nil, ErrInvalidToken
+	}
+	amountOut, overflow := uint256.FromBig(params.TokenAmountOut.Amount)
+	if overflow || amountOut.Sign() <= 0 || amountOut.Gt(maxUint128) {
+		return nil, ErrInvalidAmountOut
+	}
+	if reserveOut := s.Info.Reserves[idxOut]; reserveOut != nil && reserveOut.Cmp(params.TokenAmountOut.Amount) < 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	pairIdx, inBase, err := s.pairOf(params.TokenIn, params.TokenAmountOut.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// exact output: the fixed quantity is on the output side
+	inBaseQty := !inBase
+	res, err := s.swap(pairIdx, inBase, inBaseQty, amountOut)
+	if err != nil {
+		return nil, err
+	}
+
+	amountIn, fee := res.flow.baseFlow, res.flow.baseFee
+	if inBaseQty {
+		amountIn, fee = res.flow.quoteFlow, res.flow.quoteFee
+	}
+	if amountIn.Sign() <= 0 {
+		return nil, ErrInvalidAmountIn
+	}
+
+	return &pool.CalcAmountInResult{
+		TokenAmountIn:           &pool.TokenAmount{Token: params.TokenIn, Amount: amountIn.ToBig()},
+		RemainingTokenAmountOut: &pool.TokenAmount{Token: params.TokenAmountOut.Token, Amount: res.qtyLeft.ToBig()},
+		Fee:                     &pool.TokenAmount{Token: params.TokenIn, Amount: fee.ToBig()},
+		Gas:                     s.gas.BaseGas + s.gas.CrossTickGas*int64(len(res.crossedTicks)),
+		SwapInfo: SwapInfo{
+			Pair:         s.pairs[pairIdx],
+			NextCurve:    res.curve.toCurveState(),
+			CrossedTicks: res.crossedTicks,
+			IsBuy:        inBase,
+		},
+	}, nil
+}
+
+func (s *PoolSimulator) swap(pairIdx int, isBuy, inBaseQty bool, qty *uint256.Int) (res *swapResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if recoveredError, ok := r.(error); ok {
+				err = recoveredError
+			} else {
+				err = fmt.Errorf("unexpected panic: %v", r)
+			}
+		}
+	}()
+
+	res, err = s.states[pairIdx].swap(isBuy, inBaseQty, qty)
+	if err != nil {
+		return nil, err
+	}
+	if !res.qtyLeft.IsZero() {
+		return nil, ErrInsufficientLiquidity
+	}
+	return res, nil
+}
+
+// pairOf returns the index of the swappable pair of tokenIn and tokenOut, and whether tokenIn is the base token.
+func (s *PoolSimulator) pairOf(tokenIn, tokenOut string) (int, bool, error) {
+	addrIn, addrOut := common.HexToAddress(tokenIn), common.HexToAddress(tokenOut)
+	pair, ok := s.GetPair(addrIn, addrOut)
+	if !ok {
+		return 0, false, ErrPairNotFound
+	}
+	pairIdx := lo.IndexOf(s.pairs, pair)
+	if pairIdx < 0 {
+		return 0, false, ErrPairNotFound
+	}
+	if addrIn == s.nativeTokenAddress {
+		addrIn = NativeTokenPlaceholderAddress
+	}
+	return pairIdx, addrIn == pair.Base, nil
+}
+
+func (s *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *s
+	// the embedded pool is a pointer: copy it so that the reserves of the clone are its own
+	cloned.NTokenPool = NewNTokenPool(s.Pool, s.pairs, s.nativeTokenAddress)
+	cloned.states = make([]*pairState, len(s.states))
+	copy(cloned.states, s.states)
+	cloned.Info.Reserves = lo.Map(s.Info.Reserves, func(v *big.Int, _ int) *big.Int { return new(big.Int).Set(v) })
+	return &cloned
+}
+
+func (s *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
+	swapInfo, ok := params.SwapInfo.(SwapInfo)
+	if !ok {
+		return
+	}
+	pairIdx := lo.IndexOf(s.pairs, swapInfo.Pair)
+	if pairIdx < 0 {
+		return
+	}
+
+	// states are shared with clones, so the updated state is always a new copy
+	state := s.states[pairIdx].clone()
+	state.Curve = newCurveState(swapInfo.NextCurve)
+	for _, tick := range swapInfo.CrossedTicks {
+		state.knockOut(tick, swapInfo.IsBuy)
+	}
+	s.states[pairIdx] = state
+
+	idxIn, idxOut := s.GetTokenIndex(params.TokenAmountIn.Token), s.GetTokenIndex(params.TokenAmountOut.Token)
+	if idxIn >= 0 {
+		s.Info.Reserves[idxIn] = new(big.Int).Add(s.Info.Reserves[idxIn], params.TokenAmountIn.Amount)
+	}
+	if idxOut >= 0 {
+		s.Info.Reserves[idxOut] = new(big.Int).Sub(s.Info.Reserves[idxOut], params.TokenAmountOut.Amount)
+	}
+}
+
+func (s *PoolSimulator) GetMetaInfo(tokenIn string, tokenOut string) interface{} {
+	pairIdx, _, err := s.pairOf(tokenIn, tokenOut)
+	if err != nil {
+		return MetaInfo{BlockNumber: s.Info.BlockNumber}
+	}
+	pair := s.pairs[pairIdx]
+	return MetaInfo{
+		Base:        strings.ToLower(pair.Base.Hex()),
+		Quote:       strings.ToLower(pair.Quote.Hex()),
+		PoolIdx:     s.poolIdxs[pairIdx],
+		BlockNumber: s.Info.BlockNumber,
+	}
+}
+
+func (c *curveState) toCurveState() *CurveState {
+	return &CurveState{
+		PriceRoot:    c.priceRoot.ToBig(),
+		AmbientSeeds: c.ambientSeeds.ToBig(),
+		ConcLiq:      c.concLiq.ToBig(),
+		SeedDeflator: c.seedDeflator,
+		ConcGrowth:   c.concGrowth,
+	}
+}
+
+func newCurveState(c *CurveState) *curveState {
+	return &curveState{
+		priceRoot:    uint256.MustFromBig(c.PriceRoot),
+		ambientSeeds: uint256.MustFromBig(c.AmbientSeeds),
+		concLiq:      uint256.MustFromBig(c.ConcLiq),
+		seedDeflator: c.SeedDeflator,
+		concGrowth:   c.ConcGrowth,
+	}
+}
@@ -0,0 +1,68 @@
+package ambient
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+// impactABI is the calcImpact method of CrocImpact, the lens contract previewing swaps on the CrocSwapDex state.
+var impactABI = func() abi.ABI {
+	impactABI, err := abi.JSON(strings.NewReader(`[{"name":"calcImpact","type":"function","stateMutability":"view",
+		"inputs":[{"name":"base","type":"address"},{"name":"quote","type":"address"},
+			{"name":"poolIdx","type":"uint256"},{"name":"isBuy","type":"bool"},{"name":"inBaseQty","type":"bool"},
+			{"name":"qty","type":"uint128"},{"name":"poolTip","type":"uint16"},{"name":"limitPrice","type":"uint128"}],
+		"outputs":[{"name":"baseFlow","type":"int128"},{"name":"quoteFlow","type":"int128"},
+			{"name":"finalPrice","type":"uint128"}]}]`))
+	if err != nil {
+		panic(err)
+	}
+	return impactABI
+}()
+
+// TestPoolSimulator_DiffEVM compares the simulator to the CrocImpact quotes on the state recorded in
+// testdata/diff.json: the CrocSwapDex storage read by calcImpact at the block the pool was tracked at, the CrocImpact
+// address being the fixture quoter.
+func TestPoolSimulator_DiffEVM(t *testing.T) {
+	fixture := testutil.LoadDiffFixture(t, "testdata/diff.json")
+	poolSim, err := NewPoolSimulator(fixture.Pool)
+	require.NoError(t, err)
+	evm, err := testutil.NewEVM(&fixture.EVM)
+	require.NoError(t, err)
+
+	testutil.TestDiffCalcAmountOut(t, poolSim, evm,
+		func(evm *testutil.EVM, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error) {
+			pairIdx, inBase, err := poolSim.pairOf(tokenIn, tokenOut)
+			if err != nil {
+				return nil, err
+			}
+			pair, limitPrice := poolSim.pairs[pairIdx], minSqrtRatio.ToBig()
+			if inBase {
+				limitPrice = new(big.Int).Sub(maxSqrtRatio.ToBig(), big.NewInt(1))
+			}
+			outputs, err := evm.CallMethod(fixture.Quoter, impactABI, "calcImpact", pair.Base, pair.Quote,
+				poolSim.poolIdxs[pairIdx], inBase, inBase, amountIn, uint16(0), limitPrice)
+			if err != nil {
+				return nil, err
+			}
+			flowOut := outputs[0].(*big.Int)
+			if inBase {
+				flowOut = outputs[1].(*big.Int)
+			}
+			return new(big.Int).Neg(flowOut), nil
+		}, testutil.DiffOptions{
+			Timestamp: int64(fixture.EVM.Timestamp),
+			// the reserves are the balances of the whole dex, and the simulator only tracks a window of ticks
+			MaxAmountIn: func(token string) *big.Int {
+				if i := poolSim.GetTokenIndex(token); i >= 0 && poolSim.Info.Reserves[i].Sign() > 0 {
+					return new(big.Int).Div(poolSim.Info.Reserves[i], big.NewInt(1000))
+				}
+				return big.NewInt(1e18)
+			},
+		})
+}
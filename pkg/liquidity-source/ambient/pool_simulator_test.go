@@ -0,0 +1,282 @@
+package ambient
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/msgpack/v5"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+const (
+	weth = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+	usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	usdt = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+
+	curveTick = 196250
+)
+
+// newTestPool builds an ETH/USDC curve around 1 ETH ~ 3000 USDC with ambient liquidity only, plus a concentrated
+// range starting just above the curve tick and a knockout bid range below it. The USDC/USDT pair has no
+// curve state.
+func newTestPool(t *testing.T, feeRate uint16, protocolTake uint8) *PoolSimulator {
+	ethUsdc := TokenPair{Base: NativeTokenPlaceholderAddress, Quote: common.HexToAddress(usdc)}
+	usdcUsdt := TokenPair{Base: common.HexToAddress(usdc), Quote: common.HexToAddress(usdt)}
+	extra := Extra{TokenPairs: map[TokenPair]*TokenPairInfo{
+		ethUsdc: {
+			PoolIdx: big.NewInt(420),
+			Curve: &CurveState{
+				PriceRoot:    getSqrtRatioAtTick(curveTick).ToBig(),
+				AmbientSeeds: bignumber.NewBig("50000000000000000"),
+				ConcLiq:      big.NewInt(0),
+			},
+			CurveTick: curveTick,
+			Params:    &PoolParams{FeeRate: feeRate, ProtocolTake: protocolTake, TickSize: 16},
+			Levels: []Level{
+				{
+					Tick:        curveTick - 250,
+					BidLots:     big.NewInt(20000000000001),
+					AskLots:     big.NewInt(0),
+					KnockoutBid: &KnockoutPivot{Lots: big.NewInt(20000000000000), Range: 16},
+				},
+				{Tick: curveTick - 234, BidLots: big.NewInt(0), AskLots: big.NewInt(20000000000000)},
+				{Tick: curveTick + 6, BidLots: big.NewInt(20000000000000), AskLots: big.NewInt(0)},
+				{Tick: curveTick + 1606, BidLots: big.NewInt(0), AskLots: big.NewInt(20000000000000)},
+			},
+			TickLower: curveTick - 4096,
+			TickUpper: curveTick + 4096,
+		},
+		usdcUsdt: {PoolIdx: big.NewInt(420)},
+	}}
+	staticExtra := StaticExtra{NativeTokenAddress: common.HexToAddress(weth)}
+
+	extraBytes, err := json.Marshal(extra)
+	require.NoError(t, err)
+	staticExtraBytes, err := json.Marshal(staticExtra)
+	require.NoError(t, err)
+
+	sim, err := NewPoolSimulator(entity.Pool{
+		Address:  "0xaaaaaaaaa24eeeb8d57d431224f73832bc34f688",
+		Exchange: DexTypeAmbient,
+		Type:     DexTypeAmbient,
+		Tokens: []*entity.PoolToken{
+			{Address: weth, Swappable: true},
+			{Address: usdc, Swappable: true},
+			{Address: usdt, Swappable: true},
+		},
+		Reserves:    []string{"10000000000000000000000", "100000000000000", "100000000000000"},
+		Extra:       string(extraBytes),
+		StaticExtra: string(staticExtraBytes),
+	})
+	require.NoError(t, err)
+	return sim
+}
+
+func TestPoolSimulator_CalcAmountOut(t *testing.T) {
+	sim := newTestPool(t, 0, 0)
+
+	t.Run("buy within the current range matches the closed form", func(t *testing.T) {
+		amountIn := bignumber.TenPowInt(15) // 0.001 ETH
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      usdc,
+		})
+		require.NoError(t, err)
+
+		// P' = P + dx / L, dy = L * (1/P - 1/P')
+		liq := new(big.Float).SetInt(inflateLiqSeed(sim.states[0].Curve.ambientSeeds, 0).ToBig())
+		price := new(big.Float).Quo(new(big.Float).SetInt(getSqrtRatioAtTick(curveTick).ToBig()),
+			new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), 64)))
+		nextPrice := new(big.Float).Add(price, new(big.Float).Quo(new(big.Float).SetInt(amountIn), liq))
+		expected := new(big.Float).Sub(new(big.Float).Quo(liq, price), new(big.Float).Quo(liq, nextPrice))
+		expectedF, _ := expected.Float64()
+		actualF, _ := res.TokenAmountOut.Amount.Float64()
+		assert.InEpsilon(t, expectedF, actualF, 1e-6)
+		assert.LessOrEqual(t, actualF, expectedF)
+		assert.Equal(t, defaultGas.BaseGas, res.Gas)
+	})
+
+	t.Run("fees are charged on the counter side", func(t *testing.T) {
+		feeSim := newTestPool(t, 2500, 64)
+		amountIn := bignumber.TenPowInt(15)
+		noFee, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      usdc,
+		})
+		require.NoError(t, err)
+		withFee, err := feeSim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      usdc,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, usdc, withFee.Fee.Token)
+		assert.Equal(t, noFee.TokenAmountOut.Amount,
+			new(big.Int).Add(withFee.TokenAmountOut.Amount, withFee.Fee.Amount))
+		noFeeF, _ := noFee.TokenAmountOut.Amount.Float64()
+		withFeeF, _ := withFee.TokenAmountOut.Amount.Float64()
+		assert.InEpsilon(t, noFeeF*(1-0.0025), withFeeF, 1e-6)
+	})
+
+	t.Run("crossing ticks adds concentrated liquidity and gas", func(t *testing.T) {
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: bignumber.TenPowInt(19)},
+			TokenOut:      usdc,
+		})
+		require.NoError(t, err)
+		swapInfo := res.SwapInfo.(SwapInfo)
+		assert.Equal(t, []int32{curveTick + 6}, swapInfo.CrossedTicks)
+		assert.Equal(t, defaultGas.BaseGas+defaultGas.CrossTickGas, res.Gas)
+		assert.Equal(t, lotsToLiquidity(sim.states[0].Levels[2].BidLots).ToBig(), swapInfo.NextCurve.ConcLiq)
+	})
+
+	t.Run("sell crosses the concentrated and knockout ranges below", func(t *testing.T) {
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: usdc, Amount: bignumber.NewBig("100000000000")},
+			TokenOut:      weth,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int32{curveTick - 234, curveTick - 250}, res.SwapInfo.(SwapInfo).CrossedTicks)
+		assert.Zero(t, res.SwapInfo.(SwapInfo).NextCurve.ConcLiq.Sign())
+	})
+
+	t.Run("swaps beyond the tracked tick range are rejected", func(t *testing.T) {
+		_, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: bignumber.TenPowInt(24)},
+			TokenOut:      usdc,
+		})
+		assert.ErrorIs(t, err, ErrTickOutOfRange)
+	})
+
+	t.Run("pairs without curve state are not swappable", func(t *testing.T) {
+		_, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: usdc, Amount: big.NewInt(1000000)},
+			TokenOut:      usdt,
+		})
+		assert.ErrorIs(t, err, ErrPairNotFound)
+	})
+}
+
+func TestPoolSimulator_CalcAmountIn(t *testing.T) {
+	testutil.TestCalcAmountIn(t, newTestPool(t, 2500, 64))
+}
+
+func TestPoolSimulator_UpdateBalance(t *testing.T) {
+	sim := newTestPool(t, 2500, 0)
+	cloned := sim.CloneState().(*PoolSimulator)
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: usdc, Amount: bignumber.NewBig("100000000000")},
+		TokenOut:      weth,
+	}
+	res, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	sim.UpdateBalance(pool.UpdateBalanceParams{
+		TokenAmountIn:  params.TokenAmountIn,
+		TokenAmountOut: *res.TokenAmountOut,
+		Fee:            *res.Fee,
+		SwapInfo:       res.SwapInfo,
+	})
+
+	// the knockout range is knocked out from both of its ends
+	assert.True(t, sim.states[0].Levels[0].BidLots.IsZero())
+	assert.True(t, sim.states[0].Levels[1].AskLots.IsZero())
+	assert.Nil(t, sim.states[0].Levels[0].KnockoutBid)
+
+	resAfter, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Negative(t, resAfter.TokenAmountOut.Amount.Cmp(res.TokenAmountOut.Amount))
+
+	// the clone is not affected
+	resCloned, err := cloned.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Equal(t, res.TokenAmountOut.Amount, resCloned.TokenAmountOut.Amount)
+	assert.NotNil(t, cloned.states[0].Levels[0].KnockoutBid)
+}
+
+func TestPoolSimulator_CloneState(t *testing.T) {
+	sim := newTestPool(t, 2500, 0)
+	reserves := lo.Map(sim.Info.Reserves, func(v *big.Int, _ int) string { return v.String() })
+	cloned := sim.CloneState().(*PoolSimulator)
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: usdc, Amount: bignumber.NewBig("100000000000")},
+		TokenOut:      weth,
+	}
+	res, err := cloned.CalcAmountOut(params)
+	require.NoError(t, err)
+	cloned.UpdateBalance(pool.UpdateBalanceParams{
+		TokenAmountIn:  params.TokenAmountIn,
+		TokenAmountOut: *res.TokenAmountOut,
+		Fee:            *res.Fee,
+		SwapInfo:       res.SwapInfo,
+	})
+	assert.NotEqual(t, reserves, lo.Map(cloned.Info.Reserves, func(v *big.Int, _ int) string { return v.String() }))
+
+	// the original is not affected
+	assert.Equal(t, reserves, lo.Map(sim.Info.Reserves, func(v *big.Int, _ int) string { return v.String() }))
+	assert.NotNil(t, sim.states[0].Levels[0].KnockoutBid)
+	resOriginal, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Equal(t, res.TokenAmountOut.Amount, resOriginal.TokenAmountOut.Amount)
+}
+
+func TestPoolSimulator_Msgpack(t *testing.T) {
+	sim := newTestPool(t, 2500, 64)
+	var buf bytes.Buffer
+	en := msgpack.NewEncoder(&buf)
+	en.IncludeUnexported(true)
+	en.SetForceAsArray(true)
+	require.NoError(t, en.Encode(sim))
+
+	var decoded PoolSimulator
+	de := msgpack.NewDecoder(&buf)
+	de.IncludeUnexported(true)
+	de.SetForceAsArray(true)
+	require.NoError(t, de.Decode(&decoded))
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: weth, Amount: bignumber.TenPowInt(20)},
+		TokenOut:      usdc,
+	}
+	expected, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	actual, err := decoded.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Equal(t, expected.TokenAmountOut, actual.TokenAmountOut)
+}
+
+func TestCurveMath(t *testing.T) {
+	// Q64.64 price of tick 0 is exactly 1.0
+	assert.Equal(t, new(big.Int).Lsh(big.NewInt(1), 64), getSqrtRatioAtTick(0).ToBig())
+	assert.Equal(t, int32(curveTick), getTickAtSqrtRatio(getSqrtRatioAtTick(curveTick)))
+	price := getSqrtRatioAtTick(curveTick)
+	assert.Equal(t, int32(curveTick-1), getTickAtSqrtRatio(price.SubUint64(price, 1)))
+
+	// a 1% reserve growth inflates liquidity by ~0.5%
+	inflator := calcReserveInflator(bignumber.NewUint256("1000000"), bignumber.NewUint256("10000"))
+	assert.InEpsilon(t, math.Sqrt(1.01)-1, float64(inflator)/float64(q48.Uint64()), 1e-4)
+}
+
+func TestFloorTick(t *testing.T) {
+	for _, tt := range []struct{ tick, tickSize, want int32 }{
+		{196250, 16, 196240},
+		{-196250, 16, -196256},
+		{-196240, 16, -196240},
+		{-1, 64, -64},
+		{0, 64, 0},
+	} {
+		assert.Equal(t, tt.want, floorTick(tt.tick, tt.tickSize), "floorTick(%d, %d)", tt.tick, tt.tickSize)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/KyberNetwork/logger"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
+	concpool "github.com/sourcegraph/conc/pool"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
@@ -75,6 +76,9 @@ func (t *PoolTracker) GetNewPoolState(
 		tokenPairs    = make([]TokenPair, len(extra.TokenPairs))
 		sqrtPriceX64s = make([]*big.Int, len(extra.TokenPairs)) // sqrtPriceX64s[i] is corresponding to tokenPairs[i]
 		liquidities   = make([]*big.Int, len(extra.TokenPairs)) // liquidities[i] is corresponding to tokenPairs[i]
+		curves        = make([]curveResp, len(extra.TokenPairs))
+		curveTicks    = make([]*big.Int, len(extra.TokenPairs))
+		poolParams    = make([]poolParamsResp, len(extra.TokenPairs))
 	)
 
 	rpcRequest := t.ethrpcClient.NewRequest()
@@ -120,10 +124,32 @@ func (t *PoolTracker) GetNewPoolState(
 			Params: []interface{}{pair.Base, pair.Quote, pairInfo.PoolIdx},
 		}, []interface{}{&liquidities[i]})
 
+		rpcRequest.AddCall(&ethrpc.Call{
+			ABI:    queryABI,
+			Target: queryAddress.Hex(),
+			Method: "queryCurve",
+			Params: []interface{}{pair.Base, pair.Quote, pairInfo.PoolIdx},
+		}, []interface{}{&curves[i]})
+
+		rpcRequest.AddCall(&ethrpc.Call{
+			ABI:    queryABI,
+			Target: queryAddress.Hex(),
+			Method: "queryCurveTick",
+			Params: []interface{}{pair.Base, pair.Quote, pairInfo.PoolIdx},
+		}, []interface{}{&curveTicks[i]})
+
+		rpcRequest.AddCall(&ethrpc.Call{
+			ABI:    queryABI,
+			Target: queryAddress.Hex(),
+			Method: "queryPoolParams",
+			Params: []interface{}{pair.Base, pair.Quote, pairInfo.PoolIdx},
+		}, []interface{}{&poolParams[i]})
+
 		i++
 	}
 
-	if _, err := rpcRequest.TryAggregate(); err != nil {
+	resp, err := rpcRequest.TryAggregate()
+	if err != nil {
 		logger.
 			WithFields(logger.Fields{"poolAddress": p.Address, "error": err}).
 			Error("failed to call multical contract TryAggregate")
@@ -145,6 +171,36 @@ func (t *PoolTracker) GetNewPoolState(
 				WithFields(logger.Fields{"poolAddress": p.Address}).
 				Warnf("could not fetch sqrtPriceX64 for pair %s", pair)
 		}
+
+		pairInfo := extra.TokenPairs[pair]
+		pairInfo.Curve, pairInfo.Params, pairInfo.Levels = nil, nil, nil
+		if curves[i].Curve.PriceRoot == nil || curveTicks[i] == nil || poolParams[i].Pool.TickSize == 0 {
+			logger.
+				WithFields(logger.Fields{"poolAddress": p.Address}).
+				Warnf("could not fetch curve state for pair %s", pair)
+			continue
+		}
+		pairInfo.Curve = &CurveState{
+			PriceRoot:    curves[i].Curve.PriceRoot,
+			AmbientSeeds: curves[i].Curve.AmbientSeeds,
+			ConcLiq:      curves[i].Curve.ConcLiq,
+			SeedDeflator: curves[i].Curve.SeedDeflator,
+			ConcGrowth:   curves[i].Curve.ConcGrowth,
+		}
+		pairInfo.CurveTick = int32(curveTicks[i].Int64())
+		pairInfo.Params = &PoolParams{
+			FeeRate:      poolParams[i].Pool.FeeRate,
+			ProtocolTake: poolParams[i].Pool.ProtocolTake,
+			TickSize:     poolParams[i].Pool.TickSize,
+			KnockoutBits: poolParams[i].Pool.KnockoutBits,
+		}
+	}
+
+	if err := t.fetchLevels(ctx, extra.TokenPairs, resp.BlockNumber); err != nil {
+		logger.
+			WithFields(logger.Fields{"poolAddress": p.Address, "error": err}).
+			Error("failed to fetch levels")
+		return p, err
 	}
 
 	encodedExtra, err := json.Marshal(extra)
@@ -166,3 +222,139 @@ func (t *PoolTracker) GetNewPoolState(
 
 	return p, nil
 }
+
+// fetchLevels fetches the book levels within the configured tick window around the curve tick of every pair, then
+// the knockout pivots of the levels that have knockout liquidity, at blockNumber so that they match the curve state.
+// Calls are aggregated by multicall in chunks, as the window spans hundreds of ticks per pair.
+func (t *PoolTracker) fetchLevels(ctx context.Context, tokenPairs map[TokenPair]*TokenPairInfo,
+	blockNumber *big.Int) error {
+	tickWindow := t.cfg.TickWindow
+	if tickWindow <= 0 {
+		tickWindow = defaultTickWindow
+	}
+
+	type levelCall struct {
+		pair     TokenPair
+		pairInfo *TokenPairInfo
+		tick     int32
+		resp     levelResp
+	}
+	var levelCalls []*levelCall
+
+	for pair, pairInfo := range tokenPairs {
+		if pairInfo.Curve == nil {
+			continue
+		}
+		tickSize := int32(pairInfo.Params.TickSize)
+		center := floorTick(pairInfo.CurveTick, tickSize)
+		lowest := floorTick(minTick, tickSize)
+		if lowest < minTick {
+			lowest += tickSize
+		}
+		pairInfo.TickLower = max(center-int32(tickWindow)*tickSize, lowest)
+		pairInfo.TickUpper = min(center+int32(tickWindow)*tickSize, floorTick(maxTick, tickSize))
+		for tick := pairInfo.TickLower; tick <= pairInfo.TickUpper; tick += tickSize {
+			levelCalls = append(levelCalls, &levelCall{pair: pair, pairInfo: pairInfo, tick: tick})
+		}
+	}
+	if err := t.aggregateChunks(ctx, blockNumber, len(levelCalls), func(req *ethrpc.Request, i int) {
+		call := levelCalls[i]
+		req.AddCall(&ethrpc.Call{
+			ABI:    queryABI,
+			Target: t.cfg.QueryContractAddress,
+			Method: "queryLevel",
+			Params: []interface{}{call.pair.Base, call.pair.Quote, call.pairInfo.PoolIdx, big.NewInt(int64(call.tick))},
+		}, []interface{}{&call.resp})
+	}); err != nil {
+		return err
+	}
+
+	for _, call := range levelCalls {
+		if call.resp.BidLots.Sign() == 0 && call.resp.AskLots.Sign() == 0 {
+			continue
+		}
+		call.pairInfo.Levels = append(call.pairInfo.Levels, Level{
+			Tick:    call.tick,
+			BidLots: call.resp.BidLots,
+			AskLots: call.resp.AskLots,
+		})
+	}
+
+	type knockoutCall struct {
+		pair     TokenPair
+		pairInfo *TokenPairInfo
+		levelIdx int
+		isBid    bool
+		resp     knockoutPivotResp
+	}
+	var knockoutCalls []*knockoutCall
+
+	for pair, pairInfo := range tokenPairs {
+		for levelIdx, lvl := range pairInfo.Levels {
+			for _, isBid := range []bool{true, false} {
+				lots := lvl.AskLots
+				if isBid {
+					lots = lvl.BidLots
+				}
+				if lots.Bit(0) == 0 {
+					continue
+				}
+				knockoutCalls = append(knockoutCalls,
+					&knockoutCall{pair: pair, pairInfo: pairInfo, levelIdx: levelIdx, isBid: isBid})
+			}
+		}
+	}
+	if err := t.aggregateChunks(ctx, blockNumber, len(knockoutCalls), func(req *ethrpc.Request, i int) {
+		call := knockoutCalls[i]
+		req.AddCall(&ethrpc.Call{
+			ABI:    queryABI,
+			Target: t.cfg.QueryContractAddress,
+			Method: "queryKnockoutPivot",
+			Params: []interface{}{call.pair.Base, call.pair.Quote, call.pairInfo.PoolIdx, call.isBid,
+				big.NewInt(int64(call.pairInfo.Levels[call.levelIdx].Tick))},
+		}, []interface{}{&call.resp})
+	}); err != nil {
+		return err
+	}
+	for _, call := range knockoutCalls {
+		if call.resp.Lots == nil || call.resp.Lots.Sign() == 0 {
+			continue
+		}
+		pivot := &KnockoutPivot{Lots: call.resp.Lots, Range: call.resp.Range}
+		if call.isBid {
+			call.pairInfo.Levels[call.levelIdx].KnockoutBid = pivot
+		} else {
+			call.pairInfo.Levels[call.levelIdx].KnockoutAsk = pivot
+		}
+	}
+
+	return nil
+}
+
+// aggregateChunks aggregates n calls added by addCall in multicalls of at most defaultMulticallChunk calls, run
+// concurrently at blockNumber.
+func (t *PoolTracker) aggregateChunks(ctx context.Context, blockNumber *big.Int, n int,
+	addCall func(req *ethrpc.Request, i int)) error {
+	g := concpool.New().WithContext(ctx)
+	for start := 0; start < n; start += defaultMulticallChunk {
+		end := min(start+defaultMulticallChunk, n)
+		g.Go(func(ctx context.Context) error {
+			req := t.ethrpcClient.NewRequest().SetContext(ctx).SetBlockNumber(blockNumber)
+			for i := start; i < end; i++ {
+				addCall(req, i)
+			}
+			_, err := req.Aggregate()
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+// floorTick rounds tick down to a multiple of tickSize, towards negative infinity.
+func floorTick(tick, tickSize int32) int32 {
+	floored := tick / tickSize * tickSize
+	if floored > tick {
+		floored -= tickSize
+	}
+	return floored
+}
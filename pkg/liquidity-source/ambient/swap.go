@@ -0,0 +1,307 @@
+package ambient
+
+import (
+	"sort"
+
+	"github.com/KyberNetwork/int256"
+	"github.com/holiman/uint256"
+)
+
+// pairState is the swappable state of a single Croc pool (base, quote, poolIdx).
+type pairState struct {
+	Curve  *curveState
+	Params PoolParams
+	// Levels are sorted by tick ascending.
+	Levels    []level
+	TickLower int32
+	TickUpper int32
+}
+
+type level struct {
+	Tick        int32
+	BidLots     *uint256.Int
+	AskLots     *uint256.Int
+	KnockoutBid *knockoutPivot
+	KnockoutAsk *knockoutPivot
+}
+
+type knockoutPivot struct {
+	Lots  *uint256.Int
+	Range uint16
+}
+
+// pairFlow mirrors Chaining.PairFlow, positive flows are paid by the user to the pool.
+type pairFlow struct {
+	baseFlow  *int256.Int
+	quoteFlow *int256.Int
+	baseFee   *uint256.Int
+	quoteFee  *uint256.Int
+}
+
+type swapResult struct {
+	flow         pairFlow
+	qtyLeft      *uint256.Int
+	curve        *curveState
+	crossedTicks []int32
+}
+
+func (s *pairState) levelIndex(tick int32) int {
+	i := sort.Search(len(s.Levels), func(i int) bool { return s.Levels[i].Tick >= tick })
+	if i < len(s.Levels) && s.Levels[i].Tick == tick {
+		return i
+	}
+	return -1
+}
+
+// nextBump returns the next tick where the swap has to stop: either an initialized level or the edge of the 256-tick
+// bitmap neighborhood, which is where TradeMatcher.sweepSwap splits its legs.
+func (s *pairState) nextBump(cursor int32, isBuy bool) (int32, error) {
+	if isBuy {
+		bump := ((cursor >> 8) + 1) << 8
+		i := sort.Search(len(s.Levels), func(i int) bool { return s.Levels[i].Tick > cursor })
+		if i < len(s.Levels) && s.Levels[i].Tick < bump {
+			bump = s.Levels[i].Tick
+		}
+		if bump > s.TickUpper {
+			return 0, ErrTickOutOfRange
+		}
+		return bump, nil
+	}
+
+	bump := (cursor >> 8) << 8
+	i := sort.Search(len(s.Levels), func(i int) bool { return s.Levels[i].Tick > cursor }) - 1
+	if i >= 0 && s.Levels[i].Tick > bump {
+		bump = s.Levels[i].Tick
+	}
+	if bump < s.TickLower {
+		return 0, ErrTickOutOfRange
+	}
+	return bump, nil
+}
+
+func determineLimit(bumpTick int32, isBuy bool) *uint256.Int {
+	if bumpTick <= minTick || bumpTick >= maxTick {
+		if isBuy {
+			return new(uint256.Int).SubUint64(maxSqrtRatio, 1)
+		}
+		return minSqrtRatio.Clone()
+	}
+	bumpPrice := getSqrtRatioAtTick(bumpTick)
+	if isBuy {
+		bumpPrice.SubUint64(bumpPrice, 1)
+		if bumpPrice.Cmp(maxSqrtRatio) >= 0 {
+			return new(uint256.Int).SubUint64(maxSqrtRatio, 1)
+		}
+	} else if bumpPrice.Lt(minSqrtRatio) {
+		return minSqrtRatio.Clone()
+	}
+	return bumpPrice
+}
+
+// swap simulates TradeMatcher.sweepSwap on a copy of the curve. The state of s is not modified.
+func (s *pairState) swap(isBuy, inBaseQty bool, qty *uint256.Int) (*swapResult, error) {
+	var (
+		curve = s.Curve.clone()
+		res   = &swapResult{
+			flow: pairFlow{
+				baseFlow:  new(int256.Int),
+				quoteFlow: new(int256.Int),
+				baseFee:   new(uint256.Int),
+				quoteFee:  new(uint256.Int),
+			},
+			qtyLeft: qty.Clone(),
+			curve:   curve,
+		}
+		cursor = getTickAtSqrtRatio(curve.priceRoot)
+	)
+
+	for !res.qtyLeft.IsZero() {
+		bumpTick, err := s.nextBump(cursor, isBuy)
+		if err != nil {
+			return nil, err
+		}
+
+		limitPrice := determineLimit(bumpTick, isBuy)
+		s.swapToLimit(curve, &res.flow, isBuy, inBaseQty, res.qtyLeft, limitPrice)
+		if res.qtyLeft.IsZero() {
+			break
+		}
+
+		if i := s.levelIndex(bumpTick); i >= 0 {
+			s.crossLevel(curve, &s.Levels[i], isBuy)
+			res.crossedTicks = append(res.crossedTicks, bumpTick)
+		}
+		if isBuy {
+			curve.priceRoot.AddUint64(curve.priceRoot, 1)
+			cursor = bumpTick
+		} else {
+			curve.priceRoot.SubUint64(curve.priceRoot, 1)
+			cursor = bumpTick - 1
+		}
+	}
+
+	return res, nil
+}
+
+// swapToLimit mirrors SwapCurve.swapToLimit. Fees are booked on the counter side of the fixed quantity before the
+// curve is rolled, qty is decremented in place.
+func (s *pairState) swapToLimit(curve *curveState, accum *pairFlow, isBuy, inBaseQty bool, qty,
+	limitPrice *uint256.Int) {
+	flow := curve.calcLimitCounter(qty, inBaseQty, limitPrice)
+	liqFee, protoFee := calcFeeOverFlow(flow, s.Params.FeeRate, s.Params.ProtocolTake)
+	totalFee := new(uint256.Int).Add(liqFee, protoFee)
+	if inBaseQty {
+		accum.quoteFlow.Add(accum.quoteFlow, int256.MustFromBig(totalFee.ToBig()))
+		accum.quoteFee.Add(accum.quoteFee, totalFee)
+	} else {
+		accum.baseFlow.Add(accum.baseFlow, int256.MustFromBig(totalFee.ToBig()))
+		accum.baseFee.Add(accum.baseFee, totalFee)
+	}
+	curve.assimilateLiq(liqFee, inBaseQty)
+
+	paidBase, paidQuote, qtyLeft := swapOverCurve(curve, inBaseQty, isBuy, qty, limitPrice)
+	accum.baseFlow.Add(accum.baseFlow, paidBase)
+	accum.quoteFlow.Add(accum.quoteFlow, paidQuote)
+	qty.Set(qtyLeft)
+}
+
+func swapOverCurve(curve *curveState, inBase, isBuy bool, swapQty, limitPrice *uint256.Int) (paidBase,
+	paidQuote *int256.Int, qtyLeft *uint256.Int) {
+	realFlows := curve.calcLimitFlows(swapQty, inBase, limitPrice)
+	if realFlows.Lt(swapQty) {
+		return curve.rollPrice(limitPrice, inBase, isBuy, swapQty)
+	}
+	return curve.rollFlow(realFlows, inBase, isBuy, swapQty)
+}
+
+func (c *curveState) rollFlow(flow *uint256.Int, inBaseQty, isBuy bool, swapQty *uint256.Int) (*int256.Int,
+	*int256.Int, *uint256.Int) {
+	liq := c.activeLiquidity()
+	nextPrice := deriveFlowPrice(c.priceRoot, liq, flow, inBaseQty, isBuy)
+	var counterFlow *uint256.Int
+	if !inBaseQty {
+		counterFlow = deltaBase(liq, c.priceRoot, nextPrice)
+	} else {
+		counterFlow = deltaQuote(liq, c.priceRoot, nextPrice)
+	}
+
+	paidFlow, paidCounter := signMagn(flow, counterFlow, inBaseQty, isBuy)
+	paidCounter.Add(paidCounter, int256.NewInt(1))
+	return c.setCurvePos(inBaseQty, isBuy, swapQty, nextPrice, paidFlow, paidCounter)
+}
+
+func (c *curveState) rollPrice(price *uint256.Int, inBaseQty, isBuy bool, swapQty *uint256.Int) (*int256.Int,
+	*int256.Int, *uint256.Int) {
+	liq := c.activeLiquidity()
+	baseFlow := deltaBase(liq, c.priceRoot, price)
+	quoteFlow := deltaQuote(liq, c.priceRoot, price)
+	flow, counterFlow := quoteFlow, baseFlow
+	if inBaseQty {
+		flow, counterFlow = baseFlow, quoteFlow
+	}
+
+	paidFlow, paidCounter := signMagn(flow, counterFlow, inBaseQty, isBuy)
+	paidFlow.Add(paidFlow, int256.NewInt(1))
+	paidCounter.Add(paidCounter, int256.NewInt(1))
+	return c.setCurvePos(inBaseQty, isBuy, swapQty, price, paidFlow, paidCounter)
+}
+
+func (c *curveState) setCurvePos(inBaseQty, isBuy bool, swapQty, price *uint256.Int, paidFlow,
+	paidCounter *int256.Int) (paidBase, paidQuote *int256.Int, qtyLeft *uint256.Int) {
+	spent := paidFlow.Clone()
+	if inBaseQty != isBuy {
+		spent.Neg(spent)
+	}
+	qtyLeft = new(uint256.Int)
+	if spent.Sign() > 0 {
+		if spentU := uint256.MustFromBig(spent.ToBig()); spentU.Lt(swapQty) {
+			qtyLeft.Sub(swapQty, spentU)
+		}
+	} else {
+		qtyLeft.Set(swapQty)
+	}
+
+	c.priceRoot = price.Clone()
+	if inBaseQty {
+		return paidFlow, paidCounter, qtyLeft
+	}
+	return paidCounter, paidFlow, qtyLeft
+}
+
+func signMagn(flowMagn, counterMagn *uint256.Int, inBaseQty, isBuy bool) (flow, counter *int256.Int) {
+	flow = int256.MustFromBig(flowMagn.ToBig())
+	counter = int256.MustFromBig(counterMagn.ToBig())
+	if inBaseQty == isBuy {
+		counter.Neg(counter)
+	} else {
+		flow.Neg(flow)
+	}
+	return flow, counter
+}
+
+// crossLevel applies the concentrated liquidity delta of crossing a level, see LevelBook.crossLevel.
+func (s *pairState) crossLevel(curve *curveState, lvl *level, isBuy bool) {
+	added, removed := lotsToLiquidity(lvl.BidLots), lotsToLiquidity(lvl.AskLots)
+	if !isBuy {
+		added, removed = removed, added
+	}
+	curve.concLiq.Add(curve.concLiq, added)
+	if curve.concLiq.Lt(removed) {
+		curve.concLiq.Clear()
+	} else {
+		curve.concLiq.Sub(curve.concLiq, removed)
+	}
+}
+
+// knockOut removes the knockout liquidity of a crossed level from both ends of its range, so that it is not
+// re-activated when the price moves back. Knockout liquidity leaving the curve is already accounted for by
+// crossLevel, so this only matters for the state kept after UpdateBalance.
+func (s *pairState) knockOut(tick int32, isBuy bool) {
+	i := s.levelIndex(tick)
+	if i < 0 {
+		return
+	}
+	lvl := &s.Levels[i]
+	if isBuy {
+		if lvl.KnockoutAsk == nil || !hasKnockoutLiq(lvl.AskLots) {
+			return
+		}
+		pivot := lvl.KnockoutAsk
+		lvl.AskLots = subLots(lvl.AskLots, pivot.Lots)
+		if j := s.levelIndex(tick - int32(pivot.Range)); j >= 0 {
+			s.Levels[j].BidLots = subLots(s.Levels[j].BidLots, pivot.Lots)
+		}
+		lvl.KnockoutAsk = nil
+		return
+	}
+
+	if lvl.KnockoutBid == nil || !hasKnockoutLiq(lvl.BidLots) {
+		return
+	}
+	pivot := lvl.KnockoutBid
+	lvl.BidLots = subLots(lvl.BidLots, pivot.Lots)
+	if j := s.levelIndex(tick + int32(pivot.Range)); j >= 0 {
+		s.Levels[j].AskLots = subLots(s.Levels[j].AskLots, pivot.Lots)
+	}
+	lvl.KnockoutBid = nil
+}
+
+// subLots removes knockout lots from a level and clears its knockout flag.
+func subLots(lots, knockoutLots *uint256.Int) *uint256.Int {
+	if lots.Lt(knockoutLots) {
+		return new(uint256.Int)
+	}
+	z := new(uint256.Int).Sub(lots, knockoutLots)
+	if hasKnockoutLiq(z) {
+		z.Sub(z, knockoutFlagMask)
+	}
+	return z
+}
+
+func (s *pairState) clone() *pairState {
+	cloned := *s
+	cloned.Curve = s.Curve.clone()
+	cloned.Levels = make([]level, len(s.Levels))
+	copy(cloned.Levels, s.Levels)
+	return &cloned
+}
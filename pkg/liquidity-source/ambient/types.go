@@ -24,6 +24,40 @@ type SubgraphPool struct {
 	PoolIdx     string `json:"poolIdx"`
 }
 
+type curveResp struct {
+	Curve struct {
+		PriceRoot    *big.Int
+		AmbientSeeds *big.Int
+		ConcLiq      *big.Int
+		SeedDeflator uint64
+		ConcGrowth   uint64
+	}
+}
+
+type poolParamsResp struct {
+	Pool struct {
+		Schema       uint8
+		FeeRate      uint16
+		ProtocolTake uint8
+		TickSize     uint16
+		JitThresh    uint8
+		KnockoutBits uint8
+		OracleFlags  uint8
+	}
+}
+
+type levelResp struct {
+	BidLots  *big.Int
+	AskLots  *big.Int
+	Odometer uint64
+}
+
+type knockoutPivotResp struct {
+	Lots  *big.Int
+	Pivot uint32
+	Range uint16
+}
+
 type PoolListUpdaterMetadata struct {
 	LastCreateTime uint64 `json:"lastCreateTime"`
 }
@@ -62,6 +96,67 @@ type TokenPairInfo struct {
 	Liquidity    string `json:"liquidity"`
 	// we assume that there is 1 pool per token pair
 	PoolIdx *big.Int `json:"poolIdx"`
+
+	// The fields below are used by the PoolSimulator, pairs without them are not swappable.
+	Curve     *CurveState `json:"curve,omitempty"`
+	CurveTick int32       `json:"curveTick,omitempty"`
+	Params    *PoolParams `json:"params,omitempty"`
+	// Levels are the initialized ticks within [TickLower, TickUpper], sorted by tick ascending.
+	Levels    []Level `json:"levels,omitempty"`
+	TickLower int32   `json:"tickLower,omitempty"`
+	TickUpper int32   `json:"tickUpper,omitempty"`
+}
+
+// CurveState mirrors CurveMath.CurveState.
+type CurveState struct {
+	PriceRoot    *big.Int `json:"priceRoot"`
+	AmbientSeeds *big.Int `json:"ambientSeeds"`
+	ConcLiq      *big.Int `json:"concLiq"`
+	SeedDeflator uint64   `json:"seedDeflator"`
+	ConcGrowth   uint64   `json:"concGrowth"`
+}
+
+// PoolParams is the subset of PoolSpecs.Pool that affects swaps.
+type PoolParams struct {
+	FeeRate      uint16 `json:"feeRate"`
+	ProtocolTake uint8  `json:"protocolTake"`
+	TickSize     uint16 `json:"tickSize"`
+	KnockoutBits uint8  `json:"knockoutBits"`
+}
+
+// Level mirrors LevelBook.BookLevel. Lots have the knockout flag encoded in the lowest bit.
+type Level struct {
+	Tick        int32          `json:"tick"`
+	BidLots     *big.Int       `json:"bidLots"`
+	AskLots     *big.Int       `json:"askLots"`
+	KnockoutBid *KnockoutPivot `json:"knockoutBid,omitempty"`
+	KnockoutAsk *KnockoutPivot `json:"knockoutAsk,omitempty"`
+}
+
+// KnockoutPivot is the knockout liquidity that is knocked out when the price crosses the level.
+type KnockoutPivot struct {
+	Lots  *big.Int `json:"lots"`
+	Range uint16   `json:"range"`
+}
+
+type Gas struct {
+	BaseGas      int64
+	CrossTickGas int64
+}
+
+type SwapInfo struct {
+	Pair      TokenPair   `json:"pair"`
+	NextCurve *CurveState `json:"-"`
+	// CrossedTicks are the levels crossed by the swap, in crossing order.
+	CrossedTicks []int32 `json:"-"`
+	IsBuy        bool    `json:"isBuy"`
+}
+
+type MetaInfo struct {
+	Base        string   `json:"base"`
+	Quote       string   `json:"quote"`
+	PoolIdx     *big.Int `json:"poolIdx"`
+	BlockNumber uint64   `json:"blockNumber"`
 }
 
 type Extra struct {
@@ -412,6 +412,27 @@ func (s *PoolSimulator) validateMaxOutRatio(tokenIndex int, amountOut *uint256.I
 	return nil
 }
 
+// SpotPrice returns (balanceOut / weightOut) / (balanceIn / weightIn) net of the swap fee. Scaling factors cancel out
+// as both the balances and the amounts are in wei.
+func (s *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if s.paused {
+		return nil, ErrPoolPaused
+	}
+	indexIn, indexOut := s.GetTokenIndex(tokenIn), s.GetTokenIndex(tokenOut)
+	if indexIn == -1 || indexOut == -1 {
+		return nil, ErrTokenNotRegistered
+	}
+	reserveIn, reserveOut := s.Info.Reserves[indexIn], s.Info.Reserves[indexOut]
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, ErrInvalidReserve
+	}
+
+	spotPrice := new(big.Float).SetInt(new(big.Int).Mul(reserveOut, s.normalizedWeights[indexIn].ToBig()))
+	spotPrice.Quo(spotPrice, new(big.Float).SetInt(new(big.Int).Mul(reserveIn, s.normalizedWeights[indexOut].ToBig())))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(math.FixedPoint.Complement(s.swapFeePercentage).ToBig()))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(bignumber.BONE)), nil
+}
+
 func (s *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	for idx, token := range s.Info.Tokens {
 		if token == params.TokenAmountIn.Token {
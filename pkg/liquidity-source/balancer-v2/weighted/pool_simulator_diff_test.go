@@ -0,0 +1,57 @@
+package weighted
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/balancer-v2/shared"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+type batchSwapStep struct {
+	PoolId        [32]byte
+	AssetInIndex  *big.Int
+	AssetOutIndex *big.Int
+	Amount        *big.Int
+	UserData      []byte
+}
+
+type fundManagement struct {
+	Sender              common.Address
+	FromInternalBalance bool
+	Recipient           common.Address
+	ToInternalBalance   bool
+}
+
+// TestPoolSimulator_DiffEVM compares the simulator to the vault queryBatchSwap on the state recorded in
+// testdata/diff.json.
+func TestPoolSimulator_DiffEVM(t *testing.T) {
+	fixture := testutil.LoadDiffFixture(t, "testdata/diff.json")
+	poolSim, err := NewPoolSimulator(fixture.Pool)
+	require.NoError(t, err)
+	evm, err := testutil.NewEVM(&fixture.EVM)
+	require.NoError(t, err)
+
+	poolID := [32]byte(common.FromHex(poolSim.poolID))
+	vault := common.HexToAddress(poolSim.vault)
+	testutil.TestDiffCalcAmountOut(t, poolSim, evm,
+		func(evm *testutil.EVM, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error) {
+			outputs, err := evm.CallMethod(vault, shared.VaultABI, "queryBatchSwap", uint8(0),
+				[]batchSwapStep{{
+					PoolId:        poolID,
+					AssetInIndex:  big.NewInt(0),
+					AssetOutIndex: big.NewInt(1),
+					Amount:        amountIn,
+					UserData:      []byte{},
+				}},
+				[]common.Address{common.HexToAddress(tokenIn), common.HexToAddress(tokenOut)},
+				fundManagement{})
+			if err != nil {
+				return nil, err
+			}
+			return new(big.Int).Neg(outputs[0].([]*big.Int)[1]), nil
+		}, testutil.DiffOptions{Timestamp: int64(fixture.EVM.Timestamp)})
+}
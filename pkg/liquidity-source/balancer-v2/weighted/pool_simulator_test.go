@@ -765,3 +765,36 @@ func TestPoolSimulator_CalcAmountIn(t *testing.T) {
 		})
 	}
 }
+
+func TestPoolSimulator_SpotPrice(t *testing.T) {
+	maxUint256 := new(uint256.Int).SetAllOne()
+	s := &PoolSimulator{
+		Pool: poolpkg.Pool{
+			Info: poolpkg.PoolInfo{
+				Tokens: []string{"0xa", "0xb"},
+				Reserves: []*big.Int{
+					new(big.Int).Mul(big.NewInt(4e6), big.NewInt(1e18)),
+					big.NewInt(3e12),
+				},
+			},
+		},
+		swapFeePercentage: uint256.NewInt(3e15),
+		scalingFactors: []*uint256.Int{
+			uint256.NewInt(1e18),
+			new(uint256.Int).Mul(uint256.NewInt(1e12), uint256.NewInt(1e18)),
+		},
+		normalizedWeights:        []*uint256.Int{uint256.NewInt(8e17), uint256.NewInt(2e17)},
+		totalAmountsIn:           []*uint256.Int{uint256.NewInt(0), uint256.NewInt(0)},
+		scaledMaxTotalAmountsIn:  []*uint256.Int{maxUint256, maxUint256},
+		totalAmountsOut:          []*uint256.Int{uint256.NewInt(0), uint256.NewInt(0)},
+		scaledMaxTotalAmountsOut: []*uint256.Int{maxUint256, maxUint256},
+		poolTypeVer:              3,
+	}
+
+	// pow approximation errors dominate the rates of small swaps
+	testutil.TestSpotPrice(t, s, 1e-4)
+
+	s.paused = true
+	_, err := s.SpotPrice("0xa", "0xb")
+	assert.ErrorIs(t, err, ErrPoolPaused)
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"errors"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-resty/resty/v2"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/bebop"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -41,15 +40,17 @@ var (
 
 type HTTPClient struct {
 	config *bebop.HTTPClientConfig
-	client *resty.Client
+	client *rfq.Client
 }
 
-func NewHTTPClient(config *bebop.HTTPClientConfig) *HTTPClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount).
-		SetHeader(headerSourceAuthKey, config.Authorization)
+func NewHTTPClient(config *bebop.HTTPClientConfig, opts ...rfq.Option) *HTTPClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          bebop.DexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, append([]rfq.Option{rfq.WithHeader(headerSourceAuthKey, config.Authorization)}, opts...)...)
 
 	return &HTTPClient{
 		config: config,
@@ -60,8 +61,7 @@ func NewHTTPClient(config *bebop.HTTPClientConfig) *HTTPClient {
 func (c *HTTPClient) QuoteSingleOrderResult(ctx context.Context,
 	params bebop.QuoteParams) (bebop.QuoteSingleOrderResult, error) {
 	// token address case-sensitive
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		// the SellTokens address must follow the HEX format
 		SetQueryParam(bebop.ParamsSellTokens, common.HexToAddress(params.SellTokens).Hex()).
 		// the BuyTokens address must follow the HEX format
@@ -76,18 +76,14 @@ func (c *HTTPClient) QuoteSingleOrderResult(ctx context.Context,
 
 	var result bebop.QuoteSingleOrderResult
 	var fail bebop.QuoteFail
-	resp, err := req.SetResult(&result).SetError(&fail).Get(pathQuote)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&fail), resty.MethodGet, pathQuote)
 	if err != nil {
 		return bebop.QuoteSingleOrderResult{}, err
 	}
 
 	if !resp.IsSuccess() || fail.Failed() {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": bebop.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("quote failed")
-		return bebop.QuoteSingleOrderResult{}, parseRFQError(fail.Error.ErrorCode)
+		return bebop.QuoteSingleOrderResult{}, c.client.Fail(ctx, resp, "quote failed",
+			parseRFQError(fail.Error.ErrorCode))
 	}
 
 	return result, nil
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/bebop"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.Bebop.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "single order",
+			Quote: rfqtest.Quote{AmountOut: "2500000000", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				result := res.Extra.(bebop.QuoteSingleOrderResult)
+				assert.EqualValues(t, 2e9, result.Expiry)
+				assert.Equal(t, bebop.OnchainOrderTypeSingleOrder, result.OnchainOrderType)
+			},
+		},
+		rfqtest.Case{
+			Name:  "maker error",
+			Quote: rfqtest.Quote{Error: "102"},
+			ErrIs: []error{ErrRFQInsufficientLiquidity, rfq.ErrBadRequest},
+		},
+		rfqtest.Case{
+			Name:  "rate limited",
+			Quote: rfqtest.Quote{Status: http.StatusTooManyRequests},
+			ErrIs: []error{ErrRFQFailed, rfq.ErrRateLimited},
+		},
+	)
+	rfqtest.Run(t, rfqtest.Bebop, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		config := &bebop.Config{HTTP: bebop.HTTPClientConfig{
+			BaseURL:    server.URL,
+			Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+			RetryCount: 1,
+			Name:       "kyberswap",
+		}}
+		h := bebop.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+
+		res, err := h.RFQ(context.Background(), pool.RFQParams{
+			RFQSender:    "0x3333333333333333333333333333333333333333",
+			RFQRecipient: "0x3333333333333333333333333333333333333333",
+			SwapInfo: bebop.SwapInfo{
+				BaseToken:        "0xC02aaA39b223fe8D0A0e5C4F27eAD9083C756Cc2",
+				BaseTokenAmount:  "1000000000000000000",
+				QuoteToken:       "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+				QuoteTokenAmount: "2500000000",
+			},
+			IndicativeAmountOut: indicative,
+		})
+		assert.Equal(t, "kyberswap", server.Requests()[0].Query.Get("source"))
+		return res, err
+	})
+}
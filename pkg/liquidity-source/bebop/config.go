@@ -1,11 +1,16 @@
 package bebop
 
-import "github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+import (
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+)
 
 type HTTPClientConfig struct {
-	BaseURL       string                `mapstructure:"base_url" json:"base_url"`
-	Timeout       durationjson.Duration `mapstructure:"timeout" json:"timeout"`
-	RetryCount    int                   `mapstructure:"retry_count" json:"retry_count"`
-	Name          string                `mapstructure:"name" json:"name"`
-	Authorization string                `mapstructure:"authorization" json:"authorization"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count"`
+	Name           string                   `mapstructure:"name" json:"name"`
+	Authorization  string                   `mapstructure:"authorization" json:"authorization"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
@@ -1,7 +1,7 @@
 package bebop
 
 import (
-	"errors"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 )
 
 const DexType = "bebop"
@@ -11,6 +11,6 @@ var (
 )
 
 var (
-	ErrEmptyPriceLevels      = errors.New("empty price levels")
-	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	ErrEmptyPriceLevels      = pricelevel.ErrEmptyPriceLevels
+	ErrInsufficientLiquidity = pricelevel.ErrInsufficientLiquidity
 )
@@ -1,7 +1,6 @@
 package bebop
 
 import (
-	"math"
 	"math/big"
 	"strings"
 
@@ -10,6 +9,7 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
@@ -17,8 +17,8 @@ type PoolSimulator struct {
 	pool.Pool
 	Token0               entity.PoolToken
 	Token1               entity.PoolToken
-	ZeroToOnePriceLevels []PriceLevel
-	OneToZeroPriceLevels []PriceLevel
+	ZeroToOnePriceLevels *pricelevel.Book
+	OneToZeroPriceLevels *pricelevel.Book
 	gas                  Gas
 }
 
@@ -30,6 +30,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		return nil, err
 	}
 
+	token0, token1 := *entityPool.Tokens[0], *entityPool.Tokens[1]
 	return &PoolSimulator{
 		Pool: pool.Pool{
 			Info: pool.PoolInfo{
@@ -43,10 +44,10 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 					func(item string, index int) *big.Int { return bignumber.NewBig(item) }),
 			},
 		},
-		Token0:               *entityPool.Tokens[0],
-		Token1:               *entityPool.Tokens[1],
-		ZeroToOnePriceLevels: extra.ZeroToOnePriceLevels,
-		OneToZeroPriceLevels: extra.OneToZeroPriceLevels,
+		Token0:               token0,
+		Token1:               token1,
+		ZeroToOnePriceLevels: newBook(extra.ZeroToOnePriceLevels, token0.Decimals, token1.Decimals),
+		OneToZeroPriceLevels: newBook(extra.OneToZeroPriceLevels, token1.Decimals, token0.Decimals),
 		gas:                  defaultGas,
 	}, nil
 }
@@ -63,14 +64,37 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	}
 }
 
+// SpotPrice returns the price of the best price level.
+func (p *PoolSimulator) SpotPrice(tokenIn, _ string) (*big.Float, error) {
+	spotPrice, err := p.book(tokenIn).SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth walks the price levels, filling each one whole while the average price stays within the impact, and the
+// part of the first one it does not where it reaches the impact.
+func (p *PoolSimulator) Depth(tokenIn, _ string, impactBps int64) (*pool.DepthPoint, error) {
+	amountIn, amountOut, err := p.book(tokenIn).Depth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
+func (p *PoolSimulator) book(tokenIn string) *pricelevel.Book {
+	if tokenIn == p.Info.Tokens[0] {
+		return p.ZeroToOnePriceLevels
+	}
+	return p.OneToZeroPriceLevels
+}
+
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
-	amtInF, _ := params.TokenAmountIn.Amount.Float64()
 	if params.TokenAmountIn.Token == p.Token0.Address {
-		amtInAfterDecimals := amtInF / math.Pow10(int(p.Token0.Decimals))
-		p.ZeroToOnePriceLevels = updatePriceLevelsState(amtInAfterDecimals, p.ZeroToOnePriceLevels)
+		p.ZeroToOnePriceLevels.Consume(params.TokenAmountIn.Amount)
 	} else {
-		amtInAfterDecimals := amtInF / math.Pow10(int(p.Token1.Decimals))
-		p.OneToZeroPriceLevels = updatePriceLevelsState(amtInAfterDecimals, p.OneToZeroPriceLevels)
+		p.OneToZeroPriceLevels.Consume(params.TokenAmountIn.Amount)
 	}
 
 	// to handle the "top levels of orderbook" issue
@@ -86,6 +110,13 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	)
 }
 
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.ZeroToOnePriceLevels = p.ZeroToOnePriceLevels.Clone()
+	cloned.OneToZeroPriceLevels = p.OneToZeroPriceLevels.Clone()
+	return &cloned
+}
+
 func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 	return nil
 }
@@ -95,16 +126,12 @@ func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 }
 
 func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.PoolToken,
-	priceLevel []PriceLevel) (*pool.CalcAmountOutResult, error) {
-	amtInF, _ := amountIn.Float64()
-	amtInAfterDecimals := amtInF / math.Pow10(int(baseToken.Decimals))
-	amtOutAfterDecimals, err := getAmountOut(amtInAfterDecimals, priceLevel)
+	book *pricelevel.Book) (*pool.CalcAmountOutResult, error) {
+	amountOut, err := book.AmountOut(amountIn)
 	if err != nil {
 		return nil, err
 	}
 
-	amtOutF := amtOutAfterDecimals * math.Pow10(int(quoteToken.Decimals))
-	amountOut, _ := big.NewFloat(amtOutF).Int(nil)
 	return &pool.CalcAmountOutResult{
 		TokenAmountOut: &pool.TokenAmount{Token: quoteToken.Address, Amount: amountOut},
 		Fee:            &pool.TokenAmount{Token: baseToken.Address, Amount: bignumber.ZeroBI},
@@ -118,37 +145,8 @@ func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.Poo
 	}, nil
 }
 
-func getAmountOut(amountIn float64, priceLevels []PriceLevel) (amountOut float64, err error) {
-	if len(priceLevels) == 0 {
-		return 0, ErrEmptyPriceLevels
-	} else if amountIn > lo.SumBy(priceLevels, func(pl PriceLevel) float64 { return pl.Quote }) {
-		return 0, ErrInsufficientLiquidity
-	}
-
-	for _, currentLevel := range priceLevels {
-		currentLevelAmount := min(currentLevel.Quote, amountIn)
-		amountOut += currentLevelAmount * currentLevel.Price
-		amountIn -= currentLevelAmount
-		if amountIn <= 0 {
-			break
-		}
-	}
-
-	return amountOut, nil
-}
-
-// updatePriceLevelsState MAY MUTATE priceLevels
-func updatePriceLevelsState(amountIn float64, priceLevels []PriceLevel) []PriceLevel {
-	for i, priceLevel := range priceLevels {
-		if quote := priceLevel.Quote; quote > amountIn {
-			priceLevels[i].Quote -= amountIn
-			return priceLevels[i:]
-		} else if quote == amountIn {
-			return priceLevels[i+1:]
-		} else {
-			amountIn -= quote
-		}
-	}
-
-	return nil
+func newBook(priceLevels []PriceLevel, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(lo.Map(priceLevels, func(priceLevel PriceLevel, _ int) pricelevel.Level {
+		return pricelevel.NewLevel(priceLevel.Price, priceLevel.Quote)
+	}), decimalsIn, decimalsOut)
 }
@@ -8,7 +8,9 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 )
 
 var (
@@ -76,12 +78,12 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 		{
 			name:              "it should return correct amountOut when swap in levels",
 			amountIn:          big.NewInt(3_000_000),
-			expectedAmountOut: bigIntFromString("3282719618942082560"),
+			expectedAmountOut: bigIntFromString("3282719618942083200"),
 		},
 		{
 			name:              "it should return correct amountOut when swap in all levels",
 			amountIn:          big.NewInt(152_000_000),
-			expectedAmountOut: bigIntFromString("166324460693065564160"),
+			expectedAmountOut: bigIntFromString("166324460693065548800"),
 		},
 	}
 
@@ -160,3 +162,21 @@ func bigIntFromString(s string) *big.Int {
 	value, _ := new(big.Int).SetString(s, 10)
 	return value
 }
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	poolSimulator, err := NewPoolSimulator(entityPool2)
+	assert.NoError(t, err)
+
+	tokenIn, tokenOut := entityPool2.Tokens[0].Address, entityPool2.Tokens[1].Address
+	spotPrice, err := poolSimulator.SpotPrice(tokenIn, tokenOut)
+	assert.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 0.07595710380734046e-12, spot, 1e-12)
+
+	for _, impactBps := range []int64{1, 10, 100} {
+		testutil.TestDepth(t, poolSimulator, swaplimit.NewSingleSwapLimit(""), tokenIn, tokenOut, impactBps, 1e-9)
+	}
+
+	_, err = poolSimulator.SpotPrice(tokenOut, tokenIn)
+	assert.ErrorIs(t, err, pricelevel.ErrEmptyPriceLevels)
+}
@@ -14,8 +14,9 @@ import (
 )
 
 type Config struct {
-	DexID string           `json:"dexId"`
-	HTTP  HTTPClientConfig `mapstructure:"http" json:"http"`
+	DexID      string             `json:"dexId"`
+	HTTP       HTTPClientConfig   `mapstructure:"http" json:"http"`
+	Validation pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -54,48 +55,54 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 		return nil, errors.WithMessage(err, "quote failed")
 	}
 
-	newAmountOut, err := getAmountOutFromToSign(result.OnchainOrderType, result.ToSign)
+	amountIn, newAmountOut, err := getAmountsFromToSign(result.OnchainOrderType, result.ToSign)
 	if err != nil {
 		return nil, errors.WithMessage(err, "get amount out failed")
 	}
+	if err = pool.ValidateRFQFill(swapInfo.BaseTokenAmount, amountIn.String()); err != nil {
+		return nil, err
+	}
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: newAmountOut,
+		Deadline:     int64(result.Expiry),
 		Extra:        result,
-	}, nil
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
-func getAmountOutFromToSign(onchainOrderType string, rawTxSign json.RawMessage) (*big.Int, error) {
+// getAmountsFromToSign returns the amounts in and out of the order to sign, the taker and maker amounts.
+func getAmountsFromToSign(onchainOrderType string, rawTxSign json.RawMessage) (*big.Int, *big.Int, error) {
 	switch onchainOrderType {
 	case OnchainOrderTypeSingleOrder:
-		return getAmountOutOfSingleOrderToSign(rawTxSign)
+		return getAmountsOfSingleOrderToSign(rawTxSign)
 	case OnchainOrderTypeAggregateOrder:
-		return getAmountOutOfAggregateOrderToSign(rawTxSign)
+		return getAmountsOfAggregateOrderToSign(rawTxSign)
 	case OnchainOrderTypeOrderWithPermit2:
-		return getAmountOutOfOrderWithPermit2ToSign(rawTxSign)
+		return getAmountsOfOrderWithPermit2ToSign(rawTxSign)
 	case OnchainOrderTypeOrderWithBatchPermit2:
-		return getAmountOutOfOrderWithBatchPermit2ToSign(rawTxSign)
+		return getAmountsOfOrderWithBatchPermit2ToSign(rawTxSign)
 	default:
-		return nil, fmt.Errorf("unsupported onchain order type: %s", onchainOrderType)
+		return nil, nil, fmt.Errorf("unsupported onchain order type: %s", onchainOrderType)
 	}
 }
 
-func getAmountOutOfSingleOrderToSign(rawTxSign json.RawMessage) (*big.Int, error) {
+func getAmountsOfSingleOrderToSign(rawTxSign json.RawMessage) (*big.Int, *big.Int, error) {
 	var toSign SingleOrderToSign
 	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
-		return nil, errors.WithMessage(err, "unmarshal single order result")
+		return nil, nil, errors.WithMessage(err, "unmarshal single order result")
 	}
-	amountOut, ok := new(big.Int).SetString(toSign.MakerAmount, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid maker amount: %s", toSign.MakerAmount)
-	}
-	return amountOut, nil
+	return parseAmounts(toSign.TakerAmount, toSign.MakerAmount)
 }
 
-func getAmountOutOfAggregateOrderToSign(rawTxSign json.RawMessage) (*big.Int, error) {
+func getAmountsOfAggregateOrderToSign(rawTxSign json.RawMessage) (*big.Int, *big.Int, error) {
 	var toSign AggregateOrderToSign
 	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
-		return nil, errors.WithMessage(err, "unmarshal aggregate order result")
+		return nil, nil, errors.WithMessage(err, "unmarshal aggregate order result")
 	}
 
 	// With the aggregate order, it has some fields with format:
@@ -108,55 +115,57 @@ func getAmountOutOfAggregateOrderToSign(rawTxSign json.RawMessage) (*big.Int, er
 	// With m is number of makers and n is number of swap token pairs.
 	// Because we currently only support swap 1-1 token pair so n is always 1.
 	// So we can simplify the check by only checking the first element of each field in the logic below.
+	return sumAmounts(toSign.TakerAmounts, toSign.MakerAmounts)
+}
 
-	totalAmountOut := big.NewInt(0)
+func getAmountsOfOrderWithPermit2ToSign(rawTxSign json.RawMessage) (*big.Int, *big.Int, error) {
+	var toSign OrderWithPermit2ToSign
+	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
+		return nil, nil, errors.WithMessage(err, "unmarshal order with permit2 result")
+	}
+	return parseAmounts(toSign.Witness.TakerAmount, toSign.Witness.MakerAmount)
+}
 
-	for _, amounts := range toSign.MakerAmounts {
-		if len(amounts) != 1 {
-			return nil, fmt.Errorf("invalid maker amounts: %v", amounts)
-		}
-		amountOut, ok := new(big.Int).SetString(amounts[0], 10)
-		if !ok {
-			return nil, fmt.Errorf("invalid maker amount: %s", amounts[0])
-		}
-		totalAmountOut.Add(totalAmountOut, amountOut)
+func getAmountsOfOrderWithBatchPermit2ToSign(rawTxSign json.RawMessage) (*big.Int, *big.Int, error) {
+	var toSign OrderWithBatchPermit2ToSign
+	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
+		return nil, nil, errors.WithMessage(err, "unmarshal order with batch permit2 result")
 	}
 
-	return totalAmountOut, nil
+	// logic here same as getAmountsOfAggregateOrderToSign
+	return sumAmounts(toSign.Witness.TakerAmounts, toSign.Witness.MakerAmounts)
 }
 
-func getAmountOutOfOrderWithPermit2ToSign(rawTxSign json.RawMessage) (*big.Int, error) {
-	var toSign OrderWithPermit2ToSign
-	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
-		return nil, errors.WithMessage(err, "unmarshal order with permit2 result")
+func parseAmounts(takerAmount, makerAmount string) (*big.Int, *big.Int, error) {
+	amountIn, ok := new(big.Int).SetString(takerAmount, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid taker amount: %s", takerAmount)
 	}
-	amountOut, ok := new(big.Int).SetString(toSign.Witness.MakerAmount, 10)
+	amountOut, ok := new(big.Int).SetString(makerAmount, 10)
 	if !ok {
-		return nil, fmt.Errorf("invalid maker amount: %s", toSign.Witness.MakerAmount)
+		return nil, nil, fmt.Errorf("invalid maker amount: %s", makerAmount)
 	}
-	return amountOut, nil
+	return amountIn, amountOut, nil
 }
 
-func getAmountOutOfOrderWithBatchPermit2ToSign(rawTxSign json.RawMessage) (*big.Int, error) {
-	var toSign OrderWithBatchPermit2ToSign
-	if err := json.Unmarshal(rawTxSign, &toSign); err != nil {
-		return nil, errors.WithMessage(err, "unmarshal order with batch permit2 result")
+// sumAmounts sums the taker and maker amounts of the makers of an aggregate order, each swapping a single pair.
+func sumAmounts(takerAmounts, makerAmounts [][]string) (*big.Int, *big.Int, error) {
+	if len(takerAmounts) != len(makerAmounts) {
+		return nil, nil, fmt.Errorf("invalid amounts: %v, %v", takerAmounts, makerAmounts)
 	}
-
-	// logic here same as getAmountOutOfAggregateOrderToSign
-	totalAmountOut := big.NewInt(0)
-	for _, amounts := range toSign.Witness.MakerAmounts {
-		if len(amounts) != 1 {
-			return nil, fmt.Errorf("invalid maker amounts: %v", amounts)
+	totalAmountIn, totalAmountOut := big.NewInt(0), big.NewInt(0)
+	for i, amounts := range makerAmounts {
+		if len(amounts) != 1 || len(takerAmounts[i]) != 1 {
+			return nil, nil, fmt.Errorf("invalid amounts: %v, %v", takerAmounts[i], amounts)
 		}
-		amountOut, ok := new(big.Int).SetString(amounts[0], 10)
-		if !ok {
-			return nil, fmt.Errorf("invalid maker amount: %s", amounts[0])
+		amountIn, amountOut, err := parseAmounts(takerAmounts[i][0], amounts[0])
+		if err != nil {
+			return nil, nil, err
 		}
+		totalAmountIn.Add(totalAmountIn, amountIn)
 		totalAmountOut.Add(totalAmountOut, amountOut)
 	}
-
-	return totalAmountOut, nil
+	return totalAmountIn, totalAmountOut, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
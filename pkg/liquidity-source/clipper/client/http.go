@@ -4,11 +4,10 @@ import (
 	"context"
 	"errors"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/go-resty/resty/v2"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/clipper"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -26,16 +25,19 @@ var (
 )
 
 type httpClient struct {
-	client *resty.Client
+	client *rfq.Client
 	config clipper.HTTPClientConfig
 }
 
-func NewHTTPClient(config clipper.HTTPClientConfig) *httpClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount).
-		SetHeader("Authorization", "Basic "+config.BasicAuthKey)
+func NewHTTPClient(config clipper.HTTPClientConfig, opts ...rfq.Option) *httpClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          clipper.DexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		TotalTimeout:   config.TotalTimeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, append([]rfq.Option{rfq.WithHeader("Authorization", "Basic "+config.BasicAuthKey)}, opts...)...)
 
 	return &httpClient{
 		client: client,
@@ -44,27 +46,22 @@ func NewHTTPClient(config clipper.HTTPClientConfig) *httpClient {
 }
 
 func (c *httpClient) RFQ(ctx context.Context, params clipper.QuoteParams) (clipper.SignResponse, error) {
-	// 1. Call quote endpoint
-	req := c.client.R().SetContext(ctx).SetBody(params)
+	// 1. Call quote endpoint, an indicative quote that can be requested again
+	req := c.client.RIdempotent(ctx).SetBody(params)
 
 	var quoteRes clipper.QuoteResponse
 	var failRes clipper.FailResponse
-	resp, err := req.SetResult(&quoteRes).SetError(&failRes).Post(quotePath)
+	resp, err := c.client.Execute(req.SetResult(&quoteRes).SetError(&failRes), resty.MethodPost, quotePath)
 	if err != nil {
 		return clipper.SignResponse{}, err
 	}
 
 	if !resp.IsSuccess() {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": clipper.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("quote failed")
-		return clipper.SignResponse{}, ErrQuoteFailed
+		return clipper.SignResponse{}, c.client.Fail(ctx, resp, "quote failed", ErrQuoteFailed)
 	}
 
-	// 2. Call sign endpoint with `quote_id` received from step 1
-	req = c.client.R().SetContext(ctx).SetBody(clipper.SignParams{
+	// 2. Call sign endpoint with `quote_id` received from step 1, firming the quote: it is never retried
+	req = c.client.R(ctx).SetBody(clipper.SignParams{
 		QuoteID:            quoteRes.ID,
 		DestinationAddress: params.DestinationAddress,
 		SenderAddress:      params.SenderAddress,
@@ -73,18 +70,13 @@ func (c *httpClient) RFQ(ctx context.Context, params clipper.QuoteParams) (clipp
 	})
 
 	var signRes clipper.SignResponse
-	resp, err = req.SetResult(&signRes).SetError(&failRes).Post(signPath)
+	resp, err = c.client.Execute(req.SetResult(&signRes).SetError(&failRes), resty.MethodPost, signPath)
 	if err != nil {
 		return clipper.SignResponse{}, err
 	}
 
 	if !resp.IsSuccess() {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": clipper.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("sign failed")
-		return clipper.SignResponse{}, parseSignError(failRes.ErrorMessage)
+		return clipper.SignResponse{}, c.client.Fail(ctx, resp, "sign failed", parseSignError(failRes.ErrorMessage))
 	}
 
 	return signRes, nil
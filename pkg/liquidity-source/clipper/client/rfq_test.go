@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/clipper"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.Clipper.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "signature",
+			Quote: rfqtest.Quote{AmountOut: "2500000000", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				extra := res.Extra.(clipper.RFQExtra)
+				assert.Equal(t, "2000000000", extra.GoodUntil)
+				assert.EqualValues(t, 27, extra.V)
+			},
+		},
+		rfqtest.Case{
+			Name:  "quote conflict",
+			Quote: rfqtest.Quote{Error: errQuoteConflictText},
+			ErrIs: []error{ErrQuoteConflict, rfq.ErrBadRequest},
+		},
+		rfqtest.Case{
+			Name:  "server error",
+			Quote: rfqtest.Quote{Status: http.StatusInternalServerError},
+			ErrIs: []error{ErrSignFailed, rfq.ErrServer},
+		},
+	)
+	rfqtest.Run(t, rfqtest.Clipper, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		config := &clipper.Config{HTTP: clipper.HTTPClientConfig{
+			BaseURL:      server.URL,
+			Timeout:      durationjson.Duration{Duration: 100 * time.Millisecond},
+			RetryCount:   1,
+			BasicAuthKey: "key",
+		}}
+		h := clipper.NewRFQHandler(config, NewHTTPClient(config.HTTP))
+
+		res, err := h.RFQ(context.Background(), pool.RFQParams{
+			Sender:       "0x3333333333333333333333333333333333333333",
+			RFQRecipient: "0x3333333333333333333333333333333333333333",
+			SwapInfo: clipper.SwapInfo{
+				ChainID:           1,
+				TimeInSeconds:     60,
+				InputAmount:       "1000000000000000000",
+				InputAssetSymbol:  "ETH",
+				OutputAssetSymbol: "USDC",
+			},
+			IndicativeAmountOut: indicative,
+		})
+		assert.Equal(t, "Basic key", server.Requests()[0].Header.Get("Authorization"))
+		return res, err
+	})
+}
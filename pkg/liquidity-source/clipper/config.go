@@ -1,10 +1,16 @@
 package clipper
 
-import "github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+import (
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+)
 
 type HTTPClientConfig struct {
-	BaseURL      string                `mapstructure:"base_url" json:"base_url"`
-	Timeout      durationjson.Duration `mapstructure:"timeout" json:"timeout"`
-	RetryCount   int                   `mapstructure:"retry_count" json:"retry_count"`
-	BasicAuthKey string                `mapstructure:"basic_auth_key" json:"basic_auth_key"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout"`
+	TotalTimeout   durationjson.Duration    `mapstructure:"total_timeout" json:"total_timeout,omitempty"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count"`
+	BasicAuthKey   string                   `mapstructure:"basic_auth_key" json:"basic_auth_key"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
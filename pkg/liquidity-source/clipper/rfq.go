@@ -3,6 +3,7 @@ package clipper
 import (
 	"context"
 	"math/big"
+	"strconv"
 
 	"github.com/goccy/go-json"
 	"github.com/pkg/errors"
@@ -11,8 +12,9 @@ import (
 )
 
 type Config struct {
-	DexID string           `json:"dexId"`
-	HTTP  HTTPClientConfig `mapstructure:"http" json:"http"`
+	DexID      string             `json:"dexId"`
+	HTTP       HTTPClientConfig   `mapstructure:"http" json:"http"`
+	Validation pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -58,16 +60,26 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 	}
 
 	newAmountOut, _ := new(big.Int).SetString(result.OutputAmount, 10)
+	goodUntil, err := strconv.ParseInt(result.GoodUntil, 10, 64)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid good until")
+	}
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: newAmountOut,
+		Deadline:     goodUntil,
 		Extra: RFQExtra{
 			V:         result.Signature.V,
 			R:         result.Signature.R,
 			S:         result.Signature.S,
 			GoodUntil: result.GoodUntil,
 		},
-	}, nil
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
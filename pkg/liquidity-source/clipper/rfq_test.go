@@ -0,0 +1,22 @@
+package clipper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+type signClient SignResponse
+
+func (c signClient) RFQ(context.Context, QuoteParams) (SignResponse, error) {
+	return SignResponse(c), nil
+}
+
+func TestRFQHandler_RFQ_InvalidGoodUntil(t *testing.T) {
+	h := NewRFQHandler(&Config{}, signClient{OutputAmount: "2500000000", GoodUntil: "soon"})
+	_, err := h.RFQ(context.Background(), pool.RFQParams{SwapInfo: SwapInfo{InputAmount: "1000000000000000000"}})
+	assert.ErrorContains(t, err, "invalid good until")
+}
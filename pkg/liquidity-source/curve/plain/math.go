@@ -1,8 +1,8 @@
 package plain
 
 import (
+	"math"
 	"math/big"
-	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/holiman/uint256"
@@ -14,10 +14,10 @@ import (
 // with some modifications to work with other variants (see pool_simulator.go for completed list)
 // also, some functions are modified to pass in the result pointer instead of allocating and returning result
 
-func (t *PoolSimulator) _A() *uint256.Int {
+func (t *PoolSimulator) _A(timestamp int64) *uint256.Int {
 	var t1 = t.extra.FutureATime
 	var a1 = t.extra.FutureA
-	var now = time.Now().Unix()
+	var now = timestamp
 	if t1 > now {
 		var t0 = t.extra.InitialATime
 		var a0 = t.extra.InitialA
@@ -154,6 +154,7 @@ func (t *PoolSimulator) getY(
 	x *uint256.Int,
 	xp []uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	y *uint256.Int,
 ) error {
 	if tokenIndexFrom == tokenIndexTo {
@@ -163,7 +164,7 @@ func (t *PoolSimulator) getY(
 		return ErrTokenIndexesOutOfRange
 	}
 
-	var a = t._A()
+	var a = t._A(timestamp)
 	if a == nil {
 		return ErrInvalidAValue
 	}
@@ -253,9 +254,10 @@ func (t *PoolSimulator) GetDy(
 	j int,
 	dx *big.Int,
 	dCached *big.Int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
 	var dy, fee uint256.Int
-	err := t.GetDyU256(i, j, number.SetFromBig(dx), number.SetFromBig(dCached), &dy, &fee)
+	err := t.GetDyU256(i, j, number.SetFromBig(dx), number.SetFromBig(dCached), timestamp, &dy, &fee)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -268,6 +270,7 @@ func (t *PoolSimulator) GetDyU256(
 	j int,
 	dx *uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	dy *uint256.Int,
 	fee *uint256.Int,
 ) error {
@@ -277,7 +280,7 @@ func (t *PoolSimulator) GetDyU256(
 
 	// y: uint256 = self.get_y(i, j, x, xp)
 	var y uint256.Int
-	var err = t.getY(i, j, x, xp, dCached, &y)
+	var err = t.getY(i, j, x, xp, dCached, timestamp, &y)
 	if err != nil {
 		return err
 	}
@@ -307,15 +310,42 @@ func (t *PoolSimulator) GetDyU256(
 	return nil
 }
 
+// Calculate the marginal rate of xp[j] per xp[i], before fees. Holding D constant, the invariant
+// Ann * S + D = Ann * D + D_P, with D_P = D^(N+1) / (N^N * prod(xp)), gives
+// -dxp[j] / dxp[i] = (Ann + D_P / xp[i]) / (Ann + D_P / xp[j]), Ann being A * N / A_PRECISION.
+func (t *PoolSimulator) getMarginalRate(i int, j int, xp []uint256.Int, timestamp int64) (*big.Float, error) {
+	var a = t._A(timestamp)
+	var d uint256.Int
+	if err := t.getD(xp, a, &d); err != nil {
+		return nil, err
+	}
+
+	dF := new(big.Float).SetInt(d.ToBig())
+	dP := new(big.Float).Set(dF)
+	for k := range xp {
+		dP.Mul(dP, dF)
+		dP.Quo(dP, new(big.Float).SetInt(xp[k].ToBig()))
+	}
+	dP.Quo(dP, new(big.Float).SetFloat64(math.Pow(float64(t.numTokens), float64(t.numTokens))))
+
+	ann := new(big.Float).SetInt(number.Mul(a, &t.numTokensU256).ToBig())
+	ann.Quo(ann, new(big.Float).SetInt(t.staticExtra.APrecision.ToBig()))
+
+	rateI := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[i].ToBig()))
+	rateJ := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[j].ToBig()))
+	return rateI.Quo(rateI.Add(rateI, ann), rateJ.Add(rateJ, ann)), nil
+}
+
 // need to keep big.Int for interface method, will be removed later
 func (t *PoolSimulator) GetDx(
 	i int,
 	j int,
 	dy *big.Int,
 	dCached *big.Int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
 	var dx, fee uint256.Int
-	err := t.GetDxU256(i, j, number.SetFromBig(dy), number.SetFromBig(dCached), &dx, &fee)
+	err := t.GetDxU256(i, j, number.SetFromBig(dy), number.SetFromBig(dCached), timestamp, &dx, &fee)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -339,6 +369,7 @@ func (t *PoolSimulator) GetDxU256(
 	j int,
 	dy *uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	dx *uint256.Int,
 	fee *uint256.Int,
 ) error {
@@ -373,7 +404,7 @@ func (t *PoolSimulator) GetDxU256(
 
 	// x: uint256 = self.get_y(j, i, y, xp)
 	var x uint256.Int
-	var err = t.getY(j, i, y, xp, dCached, &x)
+	var err = t.getY(j, i, y, xp, dCached, timestamp, &x)
 	if err != nil {
 		return err
 	}
@@ -458,9 +489,10 @@ func (t *PoolSimulator) getYD(
 func (t *PoolSimulator) CalculateWithdrawOneCoin(
 	tokenAmount *big.Int,
 	i int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
 	var dy, dyFee uint256.Int
-	err := t.CalculateWithdrawOneCoinU256(number.SetFromBig(tokenAmount), i, &dy, &dyFee)
+	err := t.CalculateWithdrawOneCoinU256(number.SetFromBig(tokenAmount), i, timestamp, &dy, &dyFee)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -470,11 +502,12 @@ func (t *PoolSimulator) CalculateWithdrawOneCoin(
 func (t *PoolSimulator) CalculateWithdrawOneCoinU256(
 	tokenAmount *uint256.Int,
 	i int,
+	timestamp int64,
 
 	// output
 	dy *uint256.Int, dyFee *uint256.Int,
 ) error {
-	var amp = t._A()
+	var amp = t._A(timestamp)
 	var xp = xpMem(t.extra.RateMultipliers, t.reserves)
 	var D0, newY, newYD uint256.Int
 	err := t.getD(xp, amp, &D0)
@@ -518,6 +551,7 @@ func (t *PoolSimulator) CalculateWithdrawOneCoinU256(
 func (t *PoolSimulator) CalculateTokenAmount(
 	amounts []*big.Int,
 	deposit bool,
+	timestamp int64,
 ) (*big.Int, error) {
 	amountsU256 := make([]uint256.Int, len(amounts))
 	for i, amount := range amounts {
@@ -525,7 +559,7 @@ func (t *PoolSimulator) CalculateTokenAmount(
 	}
 	var mintAmount uint256.Int
 	var feeAmounts [shared.MaxTokenCount]uint256.Int
-	err := t.CalculateTokenAmountU256(amountsU256, deposit, &mintAmount, feeAmounts[:t.numTokens])
+	err := t.CalculateTokenAmountU256(amountsU256, deposit, timestamp, &mintAmount, feeAmounts[:t.numTokens])
 	if err != nil {
 		return nil, err
 	}
@@ -535,13 +569,14 @@ func (t *PoolSimulator) CalculateTokenAmount(
 func (t *PoolSimulator) CalculateTokenAmountU256(
 	amounts []uint256.Int,
 	deposit bool,
+	timestamp int64,
 
 	// output
 	mintAmount *uint256.Int,
 	feeAmounts []uint256.Int,
 ) error {
 	var numTokens = len(t.Info.Tokens)
-	var a = t._A()
+	var a = t._A(timestamp)
 	var d0, d1, d2 uint256.Int
 	err := t.get_D_mem(t.extra.RateMultipliers, t.reserves, a, &d0)
 	if err != nil {
@@ -595,22 +630,22 @@ func (t *PoolSimulator) CalculateTokenAmountU256(
 }
 
 // need to keep big.Int for interface method, will be removed later
-func (t *PoolSimulator) AddLiquidity(amounts []*big.Int) (*big.Int, error) {
+func (t *PoolSimulator) AddLiquidity(amounts []*big.Int, timestamp int64) (*big.Int, error) {
 	amountsU256 := make([]uint256.Int, len(amounts))
 	for i, amount := range amounts {
 		amountsU256[i].SetFromBig(amount)
 	}
-	res, err := t.AddLiquidityU256(amountsU256)
+	res, err := t.AddLiquidityU256(amountsU256, timestamp)
 	if err != nil {
 		return nil, err
 	}
 	return res.ToBig(), err
 }
 
-func (t *PoolSimulator) AddLiquidityU256(amounts []uint256.Int) (*uint256.Int, error) {
+func (t *PoolSimulator) AddLiquidityU256(amounts []uint256.Int, timestamp int64) (*uint256.Int, error) {
 	var nCoins = len(amounts)
 	var nCoinsBi = uint256.NewInt(uint64(nCoins))
-	var amp = t._A()
+	var amp = t._A(timestamp)
 	var old_balances = make([]uint256.Int, nCoins)
 	for i := 0; i < nCoins; i += 1 {
 		old_balances[i].Set(&t.reserves[i])
@@ -676,17 +711,17 @@ func (t *PoolSimulator) ApplyAddLiquidity(amounts, feeAmounts []uint256.Int, min
 }
 
 // need to keep big.Int for interface method, will be removed later
-func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*big.Int, error) {
-	dy, err := t.RemoveLiquidityOneCoinU256(number.SetFromBig(tokenAmount), i)
+func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, error) {
+	dy, err := t.RemoveLiquidityOneCoinU256(number.SetFromBig(tokenAmount), i, timestamp)
 	if err != nil {
 		return nil, err
 	}
 	return dy.ToBig(), nil
 }
 
-func (t *PoolSimulator) RemoveLiquidityOneCoinU256(tokenAmount *uint256.Int, i int) (*uint256.Int, error) {
+func (t *PoolSimulator) RemoveLiquidityOneCoinU256(tokenAmount *uint256.Int, i int, timestamp int64) (*uint256.Int, error) {
 	var dy, dyFee uint256.Int
-	var err = t.CalculateWithdrawOneCoinU256(tokenAmount, i, &dy, &dyFee)
+	var err = t.CalculateWithdrawOneCoinU256(tokenAmount, i, timestamp, &dy, &dyFee)
 	if err != nil {
 		return nil, err
 	}
@@ -704,21 +739,21 @@ func (t *PoolSimulator) ApplyRemoveLiquidityOneCoinU256(i int, tokenAmount, dy,
 }
 
 // need to keep big.Int for interface method, will be removed later
-func (t *PoolSimulator) GetVirtualPrice() (*big.Int, *big.Int, error) {
+func (t *PoolSimulator) GetVirtualPrice(timestamp int64) (*big.Int, *big.Int, error) {
 	var vPrice, d uint256.Int
-	err := t.GetVirtualPriceU256(&vPrice, &d)
+	err := t.GetVirtualPriceU256(timestamp, &vPrice, &d)
 	if err != nil {
 		return nil, nil, err
 	}
 	return vPrice.ToBig(), d.ToBig(), err
 }
 
-func (t *PoolSimulator) GetVirtualPriceU256(vPrice, D *uint256.Int) error {
+func (t *PoolSimulator) GetVirtualPriceU256(timestamp int64, vPrice, D *uint256.Int) error {
 	if t.LpSupply.IsZero() {
 		return ErrDenominatorZero
 	}
 	var xp = xpMem(t.extra.RateMultipliers, t.reserves)
-	var A = t._A()
+	var A = t._A(timestamp)
 	var err = t.getD(xp, A, D)
 	if err != nil {
 		return err
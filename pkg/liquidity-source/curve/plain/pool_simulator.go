@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/goccy/go-json"
@@ -166,6 +167,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			tokenIndexTo,
 			&amount,
 			nil,
+			param.Now(),
 			&amountOut, &fee,
 		)
 		if err != nil {
@@ -207,6 +209,7 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 			tokenIndexTo,
 			&expectedAmountOut,
 			nil,
+			param.Now(),
 			&amountIn, &fee,
 		)
 		if err != nil {
@@ -233,6 +236,26 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 	return &pool.CalcAmountInResult{}, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", tokenIndexFrom, tokenIndexTo)
 }
 
+// SpotPrice returns the marginal rate of the invariant at the current balances, net of the swap fee.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	var i, j = t.Info.GetTokenIndex(tokenIn), t.Info.GetTokenIndex(tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", i, j)
+	}
+
+	var xp = xpMem(t.extra.RateMultipliers, t.reserves)
+	spotPrice, err := t.getMarginalRate(i, j, xp, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	// dy = dy_xp * (1 - fee) * PRECISION / rates[j], with dx_xp = dx * rates[i] / PRECISION
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(number.Sub(FeeDenominator, t.extra.SwapFee).ToBig()))
+	spotPrice.Quo(spotPrice, new(big.Float).SetInt(FeeDenominator.ToBig()))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(t.extra.RateMultipliers[i].ToBig()))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(t.extra.RateMultipliers[j].ToBig())), nil
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
 	var inputAmount = input.Amount
@@ -107,6 +107,16 @@ func TestCalcAmountOutPlain(t *testing.T) {
 			assert.Equal(t, tc.out, out.TokenAmountOut.Token)
 		})
 	}
+
+	t.Run("spot price", func(t *testing.T) {
+		for idx, p := range sims {
+			if idx == 0 || idx == 2 {
+				// the probed amounts out of their 2 and 6 decimals tokens are too small to compare rates
+				continue
+			}
+			testutil.TestSpotPrice(t, p, 1e-6)
+		}
+	})
 }
 
 func TestCalcAmountOutPlainError(t *testing.T) {
@@ -306,14 +316,14 @@ func TestGetDyVirtualPrice(t *testing.T) {
 	p, err := NewPoolSimulator(poolEntity)
 	require.Nil(t, err)
 
-	v, dCached, err := p.GetVirtualPrice()
+	v, dCached, err := p.GetVirtualPrice(poolEntity.Timestamp)
 	require.Nil(t, err)
 	assert.Equal(t, bignumber.NewBig10("1006923185919753102"), v)
 
 	for idx, tc := range testcases {
 		t.Run(fmt.Sprintf("test %d", idx), func(t *testing.T) {
 			dy, err := testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -321,7 +331,7 @@ func TestGetDyVirtualPrice(t *testing.T) {
 
 			// test using cached D
 			dy, err = testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -7,7 +7,7 @@ import (
 )
 
 func (t *PoolSimulator) GetDyUnderlying(
-	i int, j int, _dx *uint256.Int,
+	i int, j int, _dx *uint256.Int, timestamp int64,
 
 	// output
 	dy *uint256.Int,
@@ -90,7 +90,7 @@ func (t *PoolSimulator) GetDyUnderlying(
 		}
 		addLiquidityInfo.Amounts[base_i].Set(_dx)
 
-		if err := t.basePool.CalculateTokenAmountU256(addLiquidityInfo.Amounts[:baseNCoins], true, &addLiquidityInfo.MintAmount, addLiquidityInfo.FeeAmounts[:baseNCoins]); err != nil {
+		if err := t.basePool.CalculateTokenAmountU256(addLiquidityInfo.Amounts[:baseNCoins], true, timestamp, &addLiquidityInfo.MintAmount, addLiquidityInfo.FeeAmounts[:baseNCoins]); err != nil {
 			return err
 		}
 
@@ -103,7 +103,7 @@ func (t *PoolSimulator) GetDyUnderlying(
 	}
 
 	// perform normal swap at meta pool
-	err := t.PoolSimulator.GetDyByX(metaSwapInfo.TokenInIndex, metaSwapInfo.TokenOutIndex, x, xp, nil, &metaSwapInfo.AmountOut, &metaSwapInfo.AdminFee)
+	err := t.PoolSimulator.GetDyByX(metaSwapInfo.TokenInIndex, metaSwapInfo.TokenOutIndex, x, xp, nil, timestamp, &metaSwapInfo.AmountOut, &metaSwapInfo.AdminFee)
 	if err != nil {
 		return err
 	}
@@ -112,7 +112,7 @@ func (t *PoolSimulator) GetDyUnderlying(
 		// withdraw output from base pool using `dy` of LPtoken
 		withdrawInfo.TokenAmount.Set(&metaSwapInfo.AmountOut)
 		withdrawInfo.TokenIndex = base_j
-		err = t.basePool.CalculateWithdrawOneCoinU256(&withdrawInfo.TokenAmount, withdrawInfo.TokenIndex, &withdrawInfo.Dy, &withdrawInfo.DyFee)
+		err = t.basePool.CalculateWithdrawOneCoinU256(&withdrawInfo.TokenAmount, withdrawInfo.TokenIndex, timestamp, &withdrawInfo.Dy, &withdrawInfo.DyFee)
 		if err != nil {
 			return err
 		}
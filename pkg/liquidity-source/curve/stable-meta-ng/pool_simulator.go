@@ -2,6 +2,7 @@ package stablemetang
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/logger"
@@ -20,10 +21,10 @@ type ICurveBasePool interface {
 	GetInfo() pool.PoolInfo
 	GetTokenIndex(address string) int
 
-	GetVirtualPriceU256(vPrice *uint256.Int, D *uint256.Int) error
+	GetVirtualPriceU256(timestamp int64, vPrice *uint256.Int, D *uint256.Int) error
 
-	CalculateTokenAmountU256(amounts []uint256.Int, deposit bool, mintAmount *uint256.Int, feeAmounts []uint256.Int) error
-	CalculateWithdrawOneCoinU256(tokenAmount *uint256.Int, i int, dy *uint256.Int, dyFee *uint256.Int) error
+	CalculateTokenAmountU256(amounts []uint256.Int, deposit bool, timestamp int64, mintAmount *uint256.Int, feeAmounts []uint256.Int) error
+	CalculateWithdrawOneCoinU256(tokenAmount *uint256.Int, i int, timestamp int64, dy *uint256.Int, dyFee *uint256.Int) error
 
 	// ApplyRemoveLiquidityOneCoinU256 is similar to RemoveLiquidityOneCoinU256, but pass in result from CalculateWithdrawOneCoinU256
 	ApplyRemoveLiquidityOneCoinU256(i int, tokenAmount, dy, dyFee *uint256.Int) error
@@ -107,6 +108,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			tokenIndexFrom,
 			tokenIndexTo,
 			&amountIn,
+			param.Now(),
 			&amountOut,
 			&addLiquidityInfo, &metaswapInfo, &withdrawInfo,
 		)
@@ -141,6 +143,16 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 	return &pool.CalcAmountOutResult{}, fmt.Errorf("tokenIndexFrom %v or tokenIndexTo %v is not correct", tokenIndexFrom, tokenIndexTo)
 }
 
+// SpotPrice returns the spot price of the stable-ng pool for swaps between meta coins. Swaps from or to the base pool
+// coins also go through its liquidity, which is not priced natively: pool.ErrSpotPriceUnsupported is returned for
+// them.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if t.GetTokenIndex(tokenIn) < 0 || t.GetTokenIndex(tokenOut) < 0 {
+		return nil, pool.ErrSpotPriceUnsupported
+	}
+	return t.PoolSimulator.SpotPrice(tokenIn, tokenOut)
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
 	var inputIndex = t.GetTokenIndex(input.Token)
@@ -186,7 +198,7 @@ func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 
 	// the base pool has been updated, so we need to recalculate its vPrice (last component in stored_rates)
 	var dummyD uint256.Int
-	_ = t.basePool.GetVirtualPriceU256(&t.Extra.RateMultipliers[t.NumTokens-1], &dummyD)
+	_ = t.basePool.GetVirtualPriceU256(params.Now(), &t.Extra.RateMultipliers[t.NumTokens-1], &dummyD)
 }
 
 func (t *PoolSimulator) CanSwapFrom(address string) []string { return t.CanSwapTo(address) }
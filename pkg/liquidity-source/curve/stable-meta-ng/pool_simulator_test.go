@@ -2,6 +2,7 @@ package stablemetang
 
 import (
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/goccy/go-json"
@@ -167,6 +168,28 @@ func TestCalcAmountOut(t *testing.T) {
 			assert.Equal(t, tc.out, out.TokenAmountOut.Token)
 		})
 	}
+
+	t.Run("spot price", func(t *testing.T) {
+		for _, p := range sims {
+			tokenIn, tokenOut := p.Info.Tokens[0], p.Info.Tokens[1]
+			spotPrice, err := p.SpotPrice(tokenIn, tokenOut)
+			require.NoError(t, err)
+			amountIn := new(big.Int).Div(p.Info.Reserves[0], big.NewInt(100_000_000))
+			out, err := p.CalcAmountOut(pool.CalcAmountOutParams{
+				TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: amountIn},
+				TokenOut:      tokenOut,
+			})
+			require.NoError(t, err)
+			spot, _ := spotPrice.Float64()
+			rate, _ := new(big.Float).Quo(new(big.Float).SetInt(out.TokenAmountOut.Amount),
+				new(big.Float).SetInt(amountIn)).Float64()
+			assert.InEpsilon(t, spot, rate, 1e-6)
+
+			// swaps through the base pool are not priced natively
+			_, err = p.SpotPrice(p.GetBasePoolTokens()[0], tokenIn)
+			assert.ErrorIs(t, err, pool.ErrSpotPriceUnsupported)
+		}
+	})
 }
 
 func TestUpdateBalance(t *testing.T) {
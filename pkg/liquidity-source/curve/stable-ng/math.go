@@ -3,7 +3,7 @@ package stableng
 import (
 	"fmt"
 	"math"
-	"time"
+	"math/big"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/holiman/uint256"
@@ -34,10 +34,10 @@ func xpMem_inplace(
 	return numTokens
 }
 
-func (t *PoolSimulator) _A() *uint256.Int {
+func (t *PoolSimulator) _A(timestamp int64) *uint256.Int {
 	var t1 = t.Extra.FutureATime
 	var a1 = t.Extra.FutureA
-	var now = time.Now().Unix()
+	var now = timestamp
 	if t1 > now {
 		var t0 = t.Extra.InitialATime
 		var a0 = t.Extra.InitialA
@@ -148,6 +148,7 @@ func (t *PoolSimulator) GetY(
 	x *uint256.Int,
 	xp []uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	y *uint256.Int,
 ) error {
 	if tokenIndexFrom == tokenIndexTo {
@@ -157,7 +158,7 @@ func (t *PoolSimulator) GetY(
 		return ErrTokenIndexesOutOfRange
 	}
 
-	var a = t._A()
+	var a = t._A(timestamp)
 	if a == nil {
 		return ErrInvalidAValue
 	}
@@ -234,6 +235,7 @@ func (t *PoolSimulator) GetDy(
 	j int,
 	dx *uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	dy *uint256.Int,
 	adminFee *uint256.Int,
 ) error {
@@ -241,7 +243,7 @@ func (t *PoolSimulator) GetDy(
 	// x: uint256 = xp[i] + (dx * rates[i] / PRECISION)
 	var x = number.SafeAdd(&xp[i], number.Div(number.SafeMul(dx, &t.Extra.RateMultipliers[i]), Precision))
 
-	return t.GetDyByX(i, j, x, xp, dCached, dy, adminFee)
+	return t.GetDyByX(i, j, x, xp, dCached, timestamp, dy, adminFee)
 }
 
 // Calculate the current output dy if already have `x` input
@@ -251,12 +253,13 @@ func (t *PoolSimulator) GetDyByX(
 	x *uint256.Int,
 	xp []uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	dy *uint256.Int,
 	adminFee *uint256.Int,
 ) error {
 	// y: uint256 = self.get_y(i, j, x, xp)
 	var y uint256.Int
-	var err = t.GetY(i, j, x, xp, dCached, &y)
+	var err = t.GetY(i, j, x, xp, dCached, timestamp, &y)
 	if err != nil {
 		return err
 	}
@@ -304,6 +307,35 @@ func (t *PoolSimulator) GetDyByX(
 	return nil
 }
 
+// Calculate the marginal rate of xp[j] per xp[i], before fees. Holding D constant, the invariant
+// Ann * S + D = Ann * D + D_P, with D_P = D^(N+1) / (N^N * prod(xp)), gives
+// -dxp[j] / dxp[i] = (Ann + D_P / xp[i]) / (Ann + D_P / xp[j]), Ann being A * N / A_PRECISION.
+func (t *PoolSimulator) getMarginalRate(i int, j int, xp []uint256.Int, timestamp int64) (*big.Float, error) {
+	var a = t._A(timestamp)
+	if a == nil {
+		return nil, ErrInvalidAValue
+	}
+	var d uint256.Int
+	if err := t.getD(xp, a, &d); err != nil {
+		return nil, err
+	}
+
+	dF := new(big.Float).SetInt(d.ToBig())
+	dP := new(big.Float).Set(dF)
+	for k := range xp {
+		dP.Mul(dP, dF)
+		dP.Quo(dP, new(big.Float).SetInt(xp[k].ToBig()))
+	}
+	dP.Quo(dP, new(big.Float).SetFloat64(math.Pow(float64(t.NumTokens), float64(t.NumTokens))))
+
+	ann := new(big.Float).SetInt(number.Mul(a, &t.NumTokensU256).ToBig())
+	ann.Quo(ann, new(big.Float).SetInt(t.StaticExtra.APrecision.ToBig()))
+
+	rateI := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[i].ToBig()))
+	rateJ := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[j].ToBig()))
+	return rateI.Quo(rateI.Add(rateI, ann), rateJ.Add(rateJ, ann)), nil
+}
+
 // GetDx calculates the required input dx given output dy
 // https://github.com/curvefi/stableswap-ng/blob/12a0c7df1fc490ff8e5a977a0cbadf86f1351c8f/contracts/main/CurveStableSwapNGViews.vy#L44
 func (t *PoolSimulator) GetDx(
@@ -311,6 +343,7 @@ func (t *PoolSimulator) GetDx(
 	j int,
 	dy *uint256.Int,
 	dCached *uint256.Int,
+	timestamp int64,
 	dx *uint256.Int,
 	adminFee *uint256.Int,
 ) (err error) {
@@ -351,7 +384,7 @@ func (t *PoolSimulator) GetDx(
 
 	// x: uint256 = self.get_y(j, i, y, xp, amp, D, N_COINS)
 	var x uint256.Int
-	err = t.GetY(j, i, &y, xp, dCached, &x)
+	err = t.GetY(j, i, &y, xp, dCached, timestamp, &x)
 	if err != nil {
 		return err
 	}
@@ -394,12 +427,13 @@ func (t *PoolSimulator) DynamicFee(xpi *uint256.Int, xpj *uint256.Int, swapFee *
 func (t *PoolSimulator) CalculateTokenAmountU256(
 	amounts []uint256.Int,
 	deposit bool,
+	timestamp int64,
 
 	// output
 	mintAmount *uint256.Int,
 	feeAmounts []uint256.Int,
 ) error {
-	var a = t._A()
+	var a = t._A(timestamp)
 	var d0, d1, d2 uint256.Int
 	var xp = XpMem(t.Extra.RateMultipliers, t.Reserves)
 
@@ -491,8 +525,8 @@ func (t *PoolSimulator) CalculateTokenAmountU256(
 	return nil
 }
 
-func (t *PoolSimulator) CalculateWithdrawOneCoinU256(tokenAmount *uint256.Int, i int, dy *uint256.Int, dyFee *uint256.Int) error {
-	var amp = t._A()
+func (t *PoolSimulator) CalculateWithdrawOneCoinU256(tokenAmount *uint256.Int, i int, timestamp int64, dy *uint256.Int, dyFee *uint256.Int) error {
+	var amp = t._A(timestamp)
 	var xp = XpMem(t.Extra.RateMultipliers, t.Reserves)
 
 	// First, need to calculate
@@ -643,9 +677,9 @@ func (t *PoolSimulator) ApplyAddLiquidity(amounts, feeAmounts []uint256.Int, min
 	return nil
 }
 
-func (t *PoolSimulator) GetVirtualPriceU256(vPrice *uint256.Int, D *uint256.Int) error {
+func (t *PoolSimulator) GetVirtualPriceU256(timestamp int64, vPrice *uint256.Int, D *uint256.Int) error {
 	var xp = XpMem(t.Extra.RateMultipliers, t.Reserves)
-	var A = t._A()
+	var A = t._A(timestamp)
 	var err = t.getD(xp, A, D)
 	if err != nil {
 		return err
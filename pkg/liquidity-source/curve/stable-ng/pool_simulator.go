@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/goccy/go-json"
@@ -117,6 +118,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			tokenIndexTo,
 			&amount,
 			nil,
+			param.Now(),
 			&amountOut, &adminFee,
 		)
 		if err != nil {
@@ -157,6 +159,7 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 			tokenIndexTo,
 			&amountOut,
 			nil,
+			param.Now(),
 			&amountIn,
 			&adminFee,
 		)
@@ -184,6 +187,28 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 	return &pool.CalcAmountInResult{}, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", tokenIndexFrom, tokenIndexTo)
 }
 
+// SpotPrice returns the marginal rate of the invariant at the current balances, net of the dynamic fee.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	var i, j = t.Info.GetTokenIndex(tokenIn), t.Info.GetTokenIndex(tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", i, j)
+	}
+
+	var xp = XpMem(t.Extra.RateMultipliers, t.Reserves)
+	spotPrice, err := t.getMarginalRate(i, j, xp, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	// dy = dy_xp * (1 - fee) * PRECISION / rates[j], with dx_xp = dx * rates[i] / PRECISION
+	var dynamicFee uint256.Int
+	t.DynamicFee(&xp[i], &xp[j], t.Extra.SwapFee, &dynamicFee)
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(number.Sub(FeeDenominator, &dynamicFee).ToBig()))
+	spotPrice.Quo(spotPrice, new(big.Float).SetInt(FeeDenominator.ToBig()))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(t.Extra.RateMultipliers[i].ToBig()))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(t.Extra.RateMultipliers[j].ToBig())), nil
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
 	var inputAmount = input.Amount
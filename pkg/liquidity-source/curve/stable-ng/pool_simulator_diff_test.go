@@ -0,0 +1,31 @@
+package stableng
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+// TestPoolSimulator_DiffEVM compares the simulator to get_dy on the pool state recorded in testdata/diff.json.
+func TestPoolSimulator_DiffEVM(t *testing.T) {
+	fixture := testutil.LoadDiffFixture(t, "testdata/diff.json")
+	poolSim, err := NewPoolSimulator(fixture.Pool)
+	require.NoError(t, err)
+	evm, err := testutil.NewEVM(&fixture.EVM)
+	require.NoError(t, err)
+
+	testutil.TestDiffCalcAmountOut(t, poolSim, evm,
+		func(evm *testutil.EVM, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error) {
+			i, j := poolSim.GetTokenIndex(tokenIn), poolSim.GetTokenIndex(tokenOut)
+			outputs, err := evm.CallMethod(common.HexToAddress(poolSim.GetAddress()), curveStableNGABI, "get_dy",
+				big.NewInt(int64(i)), big.NewInt(int64(j)), amountIn)
+			if err != nil {
+				return nil, err
+			}
+			return outputs[0].(*big.Int), nil
+		}, testutil.DiffOptions{Timestamp: int64(fixture.EVM.Timestamp)})
+}
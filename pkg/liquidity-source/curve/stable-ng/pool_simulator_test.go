@@ -282,6 +282,16 @@ func TestUpdateBalance(t *testing.T) {
 	}
 }
 
+func TestSpotPrice(t *testing.T) {
+	// https://arbiscan.io/address/0x3adf984c937fa6846e5a24e0a68521bdaf767ce1#readContract
+	var poolEntity entity.Pool
+	require.NoError(t, json.Unmarshal([]byte("{\"address\":\"0x3adf984c937fa6846e5a24e0a68521bdaf767ce1\",\"exchange\":\"curve-stable-ng\",\"type\":\"curve-stable-ng\",\"timestamp\":1709287180,\"reserves\":[\"8994725349517509957774712\",\"1568153728639\",\"10550045569550900254909685\"],\"tokens\":[{\"address\":\"0x498bf2b1e120fed3ad3d42ea2165e9b73f99c1e5\",\"symbol\":\"crvUSD\",\"decimals\":18,\"swappable\":true},{\"address\":\"0xff970a61a04b1ca14834a43f5de4533ebddb5cc8\",\"symbol\":\"USDC.e\",\"decimals\":6,\"swappable\":true}],\"extra\":\"{\\\"InitialA\\\":\\\"100000\\\",\\\"FutureA\\\":\\\"100000\\\",\\\"InitialATime\\\":0,\\\"FutureATime\\\":0,\\\"SwapFee\\\":\\\"1000000\\\",\\\"AdminFee\\\":\\\"5000000000\\\",\\\"RateMultipliers\\\":[\\\"1000000000000000000\\\",\\\"1000000000000000000000000000000\\\"]}\",\"staticExtra\":\"{\\\"APrecision\\\":\\\"100\\\",\\\"OffpegFeeMultiplier\\\":\\\"50000000000\\\"}\",\"blockNumber\":185977087}"), &poolEntity))
+	p, err := NewPoolSimulator(poolEntity)
+	require.NoError(t, err)
+
+	testutil.TestSpotPrice(t, p, 1e-4)
+}
+
 func TestCalcAmountOutError(t *testing.T) {
 	pools := []string{
 		// zero balance: https://arbiscan.io/address/0x9097065db449a59ce30bec522e1e077292c0d8fc#readContract
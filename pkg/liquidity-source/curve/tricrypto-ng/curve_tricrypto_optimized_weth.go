@@ -1,8 +1,6 @@
 package tricryptong
 
 import (
-	"time"
-
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/holiman/uint256"
 )
@@ -26,7 +24,7 @@ func (t *PoolSimulator) FeeCalc(xp []uint256.Int, fee *uint256.Int) error {
 
 // GetDy https://github.com/curvefi/tricrypto-ng/blob/c4093cbda18ec8f3da21bf7e40a3f8d01c5c4bd3/contracts/main/CurveCryptoViews3Optimized.vy#L60
 func (t *PoolSimulator) GetDy(
-	i int, j int, dx *uint256.Int,
+	i int, j int, dx *uint256.Int, timestamp int64,
 
 	// output
 	dy, fee, K0 *uint256.Int, xp []uint256.Int,
@@ -53,7 +51,7 @@ func (t *PoolSimulator) GetDy(
 		)
 	}
 
-	A, gamma := t._A_gamma()
+	A, gamma := t._A_gamma(timestamp)
 	var y uint256.Int
 	var err = get_y(A, gamma, xp[:], t.Extra.D, j, &y, K0)
 	if err != nil {
@@ -90,14 +88,14 @@ func (t *PoolSimulator) GetDy(
 
 // GetDx https://github.com/curvefi/tricrypto-ng/blob/c4093cbda18ec8f3da21bf7e40a3f8d01c5c4bd3/contracts/main/CurveCryptoViews3Optimized.vy#L76
 func (t *PoolSimulator) GetDx(
-	i int, j int, dy *uint256.Int,
+	i int, j int, dy *uint256.Int, timestamp int64,
 
 	dx, feeDy, K0 *uint256.Int, xp []uint256.Int,
 ) error {
 	_dy := number.Set(dy)
 
 	for k := 0; k < 5; k += 1 {
-		var err = t._getDxFee(i, j, _dy, dx, K0, xp[:])
+		var err = t._getDxFee(i, j, _dy, timestamp, dx, K0, xp[:])
 		if err != nil {
 			return err
 		}
@@ -115,7 +113,7 @@ func (t *PoolSimulator) GetDx(
 
 // https://github.com/curvefi/tricrypto-ng/blob/c4093cbda18ec8f3da21bf7e40a3f8d01c5c4bd3/contracts/main/CurveCryptoViews3Optimized.vy#L184
 func (t *PoolSimulator) _getDxFee(
-	i int, j int, dy *uint256.Int,
+	i int, j int, dy *uint256.Int, timestamp int64,
 
 	// output
 	dx, K0 *uint256.Int, xp []uint256.Int,
@@ -130,7 +128,7 @@ func (t *PoolSimulator) _getDxFee(
 		return ErrExchange0Coins
 	}
 
-	A, gamma := t._A_gamma()
+	A, gamma := t._A_gamma(timestamp)
 	for k := 0; k < NumTokens; k += 1 {
 		xp[k].Set(&t.Reserves[k])
 	}
@@ -167,7 +165,7 @@ func (t *PoolSimulator) _getDxFee(
 
 // https://github.com/curvefi/tricrypto-ng/blob/c4093cbda18ec8f3da21bf7e40a3f8d01c5c4bd3/contracts/main/CurveTricryptoOptimizedWETH.vy#L964
 func (t *PoolSimulator) tweak_price(A, gamma *uint256.Int, _xp [NumTokens]uint256.Int,
-	new_D, K0_prev *uint256.Int) error {
+	new_D, K0_prev *uint256.Int, timestamp int64) error {
 	/*
 				@notice Tweaks price_oracle, last_price and conditionally adjusts
 		            price_scale. This is called whenever there is an unbalanced
@@ -185,7 +183,7 @@ func (t *PoolSimulator) tweak_price(A, gamma *uint256.Int, _xp [NumTokens]uint25
 	old_virtual_price := t.Extra.VirtualPrice
 	var last_prices_timestamp = t.Extra.LastPricesTimestamp
 
-	var blockTimestamp = time.Now().Unix()
+	var blockTimestamp = timestamp
 	var err error
 
 	if last_prices_timestamp < blockTimestamp {
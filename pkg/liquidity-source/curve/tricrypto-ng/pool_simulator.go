@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/logger"
@@ -107,6 +108,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		tokenIndexFrom,
 		tokenIndexTo,
 		&amount,
+		param.Now(),
 		&amountOut, &fee, &swapInfo.K0, swapInfo.Xp[:],
 	)
 	if err != nil {
@@ -148,6 +150,7 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 		tokenIndexFrom,
 		tokenIndexTo,
 		&amountOut,
+		param.Now(),
 		&amountIn,
 		&feeDy,
 		&swapInfo.K0,
@@ -175,6 +178,48 @@ func (t *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 	}, nil
 }
 
+// SpotPrice returns the marginal rate of the invariant at the current balances, computed with get_p as the pool
+// prices last_prices, net of the dynamic fee.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	var i, j = t.Info.GetTokenIndex(tokenIn), t.Info.GetTokenIndex(tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, fmt.Errorf("tokenIndexFrom %v or tokenIndexTo %v is not correct", i, j)
+	}
+
+	var xp [NumTokens]uint256.Int
+	number.SafeMulZ(&t.Reserves[0], &t.precisionMultipliers[0], &xp[0])
+	for k := 0; k < NumTokens-1; k += 1 {
+		xp[k+1].Div(
+			number.SafeMul(number.SafeMul(&t.Reserves[k+1], &t.Extra.PriceScale[k]), &t.precisionMultipliers[k+1]),
+			Precision,
+		)
+	}
+
+	A, gamma := t._A_gamma(time.Now().Unix())
+	var p [NumTokens - 1]uint256.Int
+	if err := get_p(xp, t.Extra.D, A, gamma, p[:]); err != nil {
+		return nil, err
+	}
+	var fee uint256.Int
+	if err := t.FeeCalc(xp[:], &fee); err != nil {
+		return nil, err
+	}
+
+	// price returns the price of the coin k in the coin 0, both with 18 decimals
+	price := func(k int) *big.Float {
+		if k == 0 {
+			return new(big.Float).SetInt(Precision.ToBig())
+		}
+		return new(big.Float).SetInt(number.Div(number.SafeMul(&p[k-1], &t.Extra.PriceScale[k-1]), Precision).ToBig())
+	}
+	// dy = dx * precisions[i] * price(i) / price(j) / precisions[j] * (1 - fee)
+	spotPrice := new(big.Float).Quo(price(i), price(j))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(t.precisionMultipliers[i].ToBig()))
+	spotPrice.Quo(spotPrice, new(big.Float).SetInt(t.precisionMultipliers[j].ToBig()))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(number.SafeSub(U_1e10, &fee).ToBig()))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(U_1e10.ToBig())), nil
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	swapInfo, ok := params.SwapInfo.(SwapInfo)
 	if !ok {
@@ -195,8 +240,8 @@ func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	t.Info.Reserves[outputIndex] = new(big.Int).Sub(t.Info.Reserves[outputIndex], outputAmount)
 	t.Reserves[outputIndex].Sub(&t.Reserves[outputIndex], number.SetFromBig(outputAmount))
 
-	A, gamma := t._A_gamma()
-	if err := t.tweak_price(A, gamma, swapInfo.Xp, nil, &swapInfo.K0); err != nil {
+	A, gamma := t._A_gamma(params.Now())
+	if err := t.tweak_price(A, gamma, swapInfo.Xp, nil, &swapInfo.K0, params.Now()); err != nil {
 		panic(fmt.Sprintf("failed to tweak price for curve-tricrypto-ng %v pool: %v", t.Info.Address, err))
 	}
 }
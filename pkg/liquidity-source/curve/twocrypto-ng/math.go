@@ -1,8 +1,6 @@
 package twocryptong
 
 import (
-	"time"
-
 	"github.com/KyberNetwork/blockchain-toolkit/i256"
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/int256"
@@ -724,18 +722,18 @@ func reductionCoefficient(x []uint256.Int, feeGamma *uint256.Int, K *uint256.Int
 	return nil
 }
 
-func (t *PoolSimulator) _A_gamma() (*uint256.Int, *uint256.Int) {
+func (t *PoolSimulator) _A_gamma(timestamp int64) (*uint256.Int, *uint256.Int) {
 	var A, gamma uint256.Int
-	t._A_gamma_inplace(&A, &gamma)
+	t._A_gamma_inplace(&A, &gamma, timestamp)
 	return &A, &gamma
 }
 
 // https://github.com/curvefi/twocrypto-ng/blob/d21b270/contracts/main/CurveTwocryptoOptimized.vy
-func (t *PoolSimulator) _A_gamma_inplace(A, gamma *uint256.Int) {
+func (t *PoolSimulator) _A_gamma_inplace(A, gamma *uint256.Int, timestamp int64) {
 	var t1 = t.Extra.FutureAGammaTime
 	A.Set(t.Extra.FutureA)
 	gamma.Set(t.Extra.FutureGamma)
-	var now = time.Now().Unix()
+	var now = timestamp
 	if now < t1 {
 		var A0 = t.Extra.InitialA
 		var gamma0 = t.Extra.InitialGamma
@@ -4,12 +4,11 @@ import (
 	"context"
 	"errors"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-resty/resty/v2"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/dexalot"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -27,15 +26,17 @@ var (
 
 type HTTPClient struct {
 	config *dexalot.HTTPClientConfig
-	client *resty.Client
+	client *rfq.Client
 }
 
-func NewHTTPClient(config *dexalot.HTTPClientConfig) *HTTPClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount).
-		SetHeader(headerApiKey, config.APIKey)
+func NewHTTPClient(config *dexalot.HTTPClientConfig, opts ...rfq.Option) *HTTPClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          dexalot.DexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, append([]rfq.Option{rfq.WithHeader(headerApiKey, config.APIKey)}, opts...)...)
 
 	return &HTTPClient{
 		config: config,
@@ -46,8 +47,7 @@ func NewHTTPClient(config *dexalot.HTTPClientConfig) *HTTPClient {
 func (c *HTTPClient) Quote(ctx context.Context, params dexalot.FirmQuoteParams,
 	upscalePercent int) (dexalot.FirmQuoteResult, error) {
 	// token address case-sensitive
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		// the SellTokens address must follow the HEX format
 		SetBody(map[string]any{
 			dexalot.ParamsChainID:     params.ChainID,
@@ -61,18 +61,13 @@ func (c *HTTPClient) Quote(ctx context.Context, params dexalot.FirmQuoteParams,
 
 	var result dexalot.FirmQuoteResult
 	var fail dexalot.FirmQuoteFail
-	resp, err := req.SetResult(&result).SetError(&fail).Post(pathQuote)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&fail), resty.MethodPost, pathQuote)
 	if err != nil {
 		return dexalot.FirmQuoteResult{}, err
 	}
 
 	if !resp.IsSuccess() || fail.Failed() {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": dexalot.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("quote failed")
-		return dexalot.FirmQuoteResult{}, parseRFQError(fail.ReasonCode)
+		return dexalot.FirmQuoteResult{}, c.client.Fail(ctx, resp, "quote failed", parseRFQError(fail.ReasonCode))
 	}
 
 	return result, nil
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/dexalot"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.Dexalot.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "upscaled order",
+			Quote: rfqtest.Quote{AmountOut: "2750000000", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				order := res.Extra.(dexalot.FirmQuoteResult).Order
+				// the taker amount is upscaled by 10%
+				assert.Equal(t, "1100000000000000000", order.TakerAmount)
+				assert.EqualValues(t, 2e9, order.Expiry)
+			},
+		},
+		rfqtest.Case{
+			// the maker fills less than upscaled, but the whole amount in
+			Name:      "upscaled order filled for the amount in",
+			Quote:     rfqtest.Quote{AmountIn: "1000000000000000000", AmountOut: "2500000000"},
+			AmountOut: "2500000000",
+		},
+		rfqtest.Case{
+			Name:  "blacklisted",
+			Quote: rfqtest.Quote{Error: ReasonCodeBlacklist},
+			ErrIs: []error{ErrRFQBlacklisted, rfq.ErrBadRequest},
+		},
+		rfqtest.Case{
+			Name:  "server error",
+			Quote: rfqtest.Quote{Status: http.StatusBadGateway},
+			ErrIs: []error{ErrRFQFailed, rfq.ErrServer},
+		},
+	)
+	rfqtest.Run(t, rfqtest.Dexalot, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		config := &dexalot.Config{
+			HTTP: dexalot.HTTPClientConfig{
+				BaseURL:    server.URL,
+				Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+				RetryCount: 1,
+				APIKey:     "key",
+			},
+			UpscalePercent: 10,
+		}
+		h := dexalot.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+
+		res, err := h.RFQ(context.Background(), pool.RFQParams{
+			NetworkID:    43114,
+			Sender:       "0x3333333333333333333333333333333333333333",
+			RFQRecipient: "0x3333333333333333333333333333333333333333",
+			SwapInfo: dexalot.SwapInfo{
+				BaseToken:          "0xb31f66aa3c1e785363f0875a1b74e27b85fd66c7",
+				BaseTokenAmount:    "1000000000000000000",
+				QuoteToken:         "0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e",
+				QuoteTokenAmount:   "2500000000",
+				BaseTokenOriginal:  "0x0000000000000000000000000000000000000000",
+				QuoteTokenOriginal: "0xB97EF9Ef8734C71904D8002F8b6Bc66Dd9c48a6E",
+				BaseTokenReserve:   "100000000000000000000",
+			},
+			IndicativeAmountOut: indicative,
+		})
+		assert.Equal(t, "key", server.Requests()[0].Header.Get(headerApiKey))
+		return res, err
+	})
+}
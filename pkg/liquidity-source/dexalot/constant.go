@@ -2,6 +2,8 @@ package dexalot
 
 import (
 	"errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 )
 
 const DexType = "dexalot"
@@ -11,7 +13,7 @@ var (
 )
 
 var (
-	ErrEmptyPriceLevels                       = errors.New("empty price levels")
+	ErrEmptyPriceLevels                       = pricelevel.ErrEmptyPriceLevels
 	ErrAmountInIsLessThanLowestPriceLevel     = errors.New("amountIn is less than lowest price level")
 	ErrAmountInIsGreaterThanHighestPriceLevel = errors.New("amountIn is greater than highest price level")
 	ErrNoSwapLimit                            = errors.New("swap limit is required for dexalot pools")
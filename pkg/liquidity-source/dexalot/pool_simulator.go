@@ -2,9 +2,7 @@ package dexalot
 
 import (
 	"errors"
-	"math"
 	"math/big"
-	"slices"
 	"strings"
 
 	"github.com/KyberNetwork/logger"
@@ -13,6 +11,7 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
@@ -20,8 +19,8 @@ type PoolSimulator struct {
 	pool.Pool
 	Token0               entity.PoolToken
 	Token1               entity.PoolToken
-	ZeroToOnePriceLevels []PriceLevel
-	OneToZeroPriceLevels []PriceLevel
+	ZeroToOnePriceLevels *pricelevel.Book
+	OneToZeroPriceLevels *pricelevel.Book
 	gas                  Gas
 	Token0Original       string
 	Token1Original       string
@@ -35,19 +34,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		return nil, err
 	}
 
-	zeroToOnePriceLevels := lo.Map(extra.ZeroToOnePriceLevels, func(item PriceLevelRaw, index int) PriceLevel {
-		return PriceLevel{
-			Quote: big.NewFloat(item.Quote),
-			Price: big.NewFloat(item.Price),
-		}
-	})
-	oneToZeroPriceLevels := lo.Map(extra.OneToZeroPriceLevels, func(item PriceLevelRaw, index int) PriceLevel {
-		return PriceLevel{
-			Quote: big.NewFloat(item.Quote),
-			Price: big.NewFloat(item.Price),
-		}
-	})
-
+	token0, token1 := *entityPool.Tokens[0], *entityPool.Tokens[1]
 	return &PoolSimulator{
 		Pool: pool.Pool{
 			Info: pool.PoolInfo{
@@ -61,12 +48,12 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 					func(item string, index int) *big.Int { return bignumber.NewBig(item) }),
 			},
 		},
-		Token0:               *entityPool.Tokens[0],
-		Token1:               *entityPool.Tokens[1],
+		Token0:               token0,
+		Token1:               token1,
 		Token0Original:       extra.Token0Address,
 		Token1Original:       extra.Token1Address,
-		ZeroToOnePriceLevels: zeroToOnePriceLevels,
-		OneToZeroPriceLevels: oneToZeroPriceLevels,
+		ZeroToOnePriceLevels: newBook(extra.ZeroToOnePriceLevels, token0.Decimals, token1.Decimals),
+		OneToZeroPriceLevels: newBook(extra.OneToZeroPriceLevels, token1.Decimals, token0.Decimals),
 		gas:                  defaultGas,
 	}, nil
 }
@@ -80,7 +67,7 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	if params.TokenAmountIn.Token == p.Info.Tokens[1] {
 		tokenIn, tokenOut, tokenInOriginal, tokenOutOriginal, levels = p.Token1, p.Token0, p.Token1Original, p.Token0Original, p.OneToZeroPriceLevels
 	}
-	result, _, err := p.swap(params.TokenAmountIn.Amount, tokenIn, tokenOut, tokenInOriginal, tokenOutOriginal, levels)
+	result, err := p.swap(params.TokenAmountIn.Amount, tokenIn, tokenOut, tokenInOriginal, tokenOutOriginal, levels)
 	if err != nil {
 		return nil, err
 	}
@@ -92,6 +79,32 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	return result, nil
 }
 
+// SpotPrice returns the price of the best price level, quoted for amounts up to its size.
+func (p *PoolSimulator) SpotPrice(tokenIn, _ string) (*big.Float, error) {
+	spotPrice, err := p.book(tokenIn).SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth returns the largest amount whose interpolated price is within the impact of the best one. The inventory of
+// the market maker is not accounted for.
+func (p *PoolSimulator) Depth(tokenIn, _ string, impactBps int64) (*pool.DepthPoint, error) {
+	amountIn, amountOut, err := p.book(tokenIn).InterpolatedDepth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
+func (p *PoolSimulator) book(tokenIn string) *pricelevel.Book {
+	if tokenIn == p.Info.Tokens[1] {
+		return p.OneToZeroPriceLevels
+	}
+	return p.ZeroToOnePriceLevels
+}
+
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	tokenIn, tokenOut := p.Token0, p.Token1
 	if params.TokenAmountIn.Token == p.Token1.Address {
@@ -108,22 +121,16 @@ func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 }
 
 func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.PoolToken,
-	baseOriginal, quoteOriginal string, priceLevel []PriceLevel) (*pool.CalcAmountOutResult, string, error) {
-
-	var amountInAfterDecimals, decimalsPow, amountInBF, amountOutBF big.Float
-
-	amountInBF.SetInt(amountIn)
-	decimalsPow.SetFloat64(math.Pow10(int(baseToken.Decimals)))
-	amountInAfterDecimals.Quo(&amountInBF, &decimalsPow)
-	var amountOutAfterDecimals big.Float
-	err := getAmountOut(&amountInAfterDecimals, priceLevel, &amountOutAfterDecimals)
-	if err != nil {
-		return nil, "", err
+	baseOriginal, quoteOriginal string, book *pricelevel.Book) (*pool.CalcAmountOutResult, error) {
+	amountOut, err := book.InterpolatedAmountOut(amountIn)
+	if errors.Is(err, pricelevel.ErrBelowLowestLevel) {
+		return nil, ErrAmountInIsLessThanLowestPriceLevel
+	} else if errors.Is(err, pricelevel.ErrInsufficientLiquidity) {
+		return nil, ErrAmountInIsGreaterThanHighestPriceLevel
+	} else if err != nil {
+		return nil, err
 	}
-	decimalsPow.SetFloat64(math.Pow10(int(quoteToken.Decimals)))
-	amountOutBF.Mul(&amountOutAfterDecimals, &decimalsPow)
 
-	amountOut, _ := amountOutBF.Int(nil)
 	var baseTokenReserve, quoteTokenReserve *big.Int
 	if strings.EqualFold(baseToken.Address, p.Info.Tokens[0]) {
 		baseTokenReserve = p.Info.Reserves[0]
@@ -146,43 +153,15 @@ func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.Poo
 			BaseTokenReserve:   baseTokenReserve.String(),
 			QuoteTokenReserve:  quoteTokenReserve.String(),
 		},
-	}, amountOutAfterDecimals.String(), nil
+	}, nil
 }
 
-func getAmountOut(amountIn *big.Float, priceLevels []PriceLevel, amountOut *big.Float) error {
-	if len(priceLevels) == 0 {
-		return ErrEmptyPriceLevels
-	} else if amountIn.Cmp(priceLevels[0].Quote) < 0 {
-		return ErrAmountInIsLessThanLowestPriceLevel
-	} else if amountIn.Cmp(priceLevels[len(priceLevels)-1].Quote) > 0 {
-		return ErrAmountInIsGreaterThanHighestPriceLevel
-	}
-
-	levelIdx, _ := slices.BinarySearchFunc(priceLevels, amountIn, func(p PriceLevel, amtIn *big.Float) int {
-		return p.Quote.Cmp(amtIn)
-	}) // should always be found due to checks above
-	level := priceLevels[levelIdx]
-
-	var price *big.Float
-	if amountIn.Cmp(level.Quote) == 0 {
-		price = priceLevels[levelIdx].Price
-	} else {
-		prevLevel := priceLevels[levelIdx-1]
-		var num, tmp big.Float
-		price = num.Add(
-			prevLevel.Price,
-			num.Quo(
-				num.Mul(
-					num.Sub(level.Price, prevLevel.Price),
-					tmp.Sub(amountIn, prevLevel.Quote),
-				),
-				tmp.Sub(level.Quote, prevLevel.Quote),
-			),
-		)
-	}
-
-	amountOut.Mul(amountIn, price)
-	return nil
+// newBook returns the Book of the price levels, which dexalot quotes by cumulative quote.
+func newBook(priceLevels []PriceLevelRaw, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(pricelevel.FromCumulative(lo.Map(priceLevels,
+		func(priceLevel PriceLevelRaw, _ int) pricelevel.Level {
+			return pricelevel.NewLevel(priceLevel.Price, priceLevel.Quote)
+		})), decimalsIn, decimalsOut)
 }
 
 func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
@@ -8,6 +8,9 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 )
 
 /*
@@ -98,17 +101,17 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 		{
 			name:              "[1to0] it should return correct amountOut when amountIn = levels[0].Quote",
 			amountIn:          big.NewInt(120000000),
-			expectedAmountOut: "2", // ask["60", "120"] | 1to0[0.01666666667, 120] -> 120usdc = 2ETH
+			expectedAmountOut: "2000000000400000000", // ask["60", "120"] | 1to0[0.01666666667, 120] -> 120usdc = 2.0000000004ETH
 		},
 		{
 			name:              "[1to0] it should return correct amountOut when amountIn = levels[1].Quote",
 			amountIn:          big.NewInt(320000000),
-			expectedAmountOut: "4", // ask["80" "320"] | 1to0[0.0125, 320] -> 320usdc = 4ETH
+			expectedAmountOut: "4000000000000000000", // ask["80" "320"] | 1to0[0.0125, 320] -> 320usdc = 4ETH
 		},
 		{
 			name:              "[1to0] it should return correct amountOut when amountIn between levels[0] and levels[1] quote",
 			amountIn:          big.NewInt(200000000),
-			expectedAmountOut: "3", // [0.01666666667, 120] [0.0125, 320] | [0.01666666667 + ((0.0125 - 0.01666666667) * (200-120) / (320-120))] * 200 = 0.015 * 200
+			expectedAmountOut: "3000000000400000000", // [0.01666666667, 120] [0.0125, 320] | [0.01666666667 + ((0.0125 - 0.01666666667) * (200-120) / (320-120))] * 200 = 0.015000000002 * 200
 		},
 		{
 			name:        "[1to0] it should return error when swap lower than level 0", //
@@ -136,11 +139,32 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 			if params.TokenAmountIn.Token == poolSimulator.Info.Tokens[1] {
 				tokenIn, tokenOut, levels = poolSimulator.Token1, poolSimulator.Token0, poolSimulator.OneToZeroPriceLevels
 			}
-			_, resultFloat, err := poolSimulator.swap(params.TokenAmountIn.Amount, tokenIn, tokenOut, "0x49d5c2bdffac6ce2bfdb6640f4f80f226bc10bab", "0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e", levels)
+			result, err := poolSimulator.swap(params.TokenAmountIn.Amount, tokenIn, tokenOut, "0x49d5c2bdffac6ce2bfdb6640f4f80f226bc10bab", "0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e", levels)
 			assert.Equal(t, tc.expectedErr, err)
 			if tc.expectedErr == nil {
-				assert.Equal(t, tc.expectedAmountOut, resultFloat)
+				assert.Equal(t, tc.expectedAmountOut, result.TokenAmountOut.Amount.String())
 			}
 		})
 	}
 }
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	poolSimulator, err := NewPoolSimulator(entityPool)
+	assert.NoError(t, err)
+
+	eth, usdc := entityPool.Tokens[0].Address, entityPool.Tokens[1].Address
+	limit := swaplimit.NewInventory(DexType, map[string]*big.Int{
+		eth: bignumber.TenPowInt(30), usdc: bignumber.TenPowInt(30),
+	})
+	spotPrice, err := poolSimulator.SpotPrice(usdc, eth)
+	assert.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 0.01666666667e12, spot, 1e-12)
+
+	for _, impactBps := range []int64{10, 1000, 2500} {
+		testutil.TestDepth(t, poolSimulator, limit, usdc, eth, impactBps, 1e-6)
+	}
+	for _, impactBps := range []int64{10, 1000, 5000} {
+		testutil.TestDepth(t, poolSimulator, limit, eth, usdc, impactBps, 1e-6)
+	}
+}
@@ -13,9 +13,10 @@ import (
 )
 
 type Config struct {
-	DexID          string           `json:"dexId"`
-	HTTP           HTTPClientConfig `mapstructure:"http" json:"http"`
-	UpscalePercent int              `mapstructure:"upscale_percent" json:"upscale_percent"`
+	DexID          string             `json:"dexId"`
+	HTTP           HTTPClientConfig   `mapstructure:"http" json:"http"`
+	UpscalePercent int                `mapstructure:"upscale_percent" json:"upscale_percent"`
+	Validation     pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -70,13 +71,23 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 	if err != nil {
 		return nil, fmt.Errorf("quote single order result: %w", err)
 	}
+	// the upscaled order is partially filled on chain, so it must fill at least the amount in
+	if err = pool.ValidateRFQFill(swapInfo.BaseTokenAmount, result.Order.TakerAmount); err != nil {
+		return nil, err
+	}
 
 	newAmountOut, _ := new(big.Int).SetString(result.Order.MakerAmount, 10)
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: newAmountOut,
+		Deadline:     int64(result.Order.Expiry),
 		Extra:        result,
-	}, nil
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
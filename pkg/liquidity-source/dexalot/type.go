@@ -1,8 +1,6 @@
 package dexalot
 
 import (
-	"math/big"
-
 	"github.com/KyberNetwork/logger"
 	"github.com/mitchellh/mapstructure"
 )
@@ -90,11 +88,6 @@ type (
 		Quote int64
 	}
 
-	PriceLevel struct {
-		Quote *big.Float
-		Price *big.Float
-	}
-
 	PriceLevelRaw struct {
 		Price float64 `json:"p"`
 		Quote float64 `json:"q"`
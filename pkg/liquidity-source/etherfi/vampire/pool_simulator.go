@@ -2,7 +2,6 @@ package etherfivampire
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
@@ -82,7 +81,7 @@ func (s *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		gasUsed += wstETHUnwrapGas
 	}
 
-	amountOut, dx, err := s.vampireDepositWithERC20StETH(amountIn)
+	amountOut, dx, err := s.vampireDepositWithERC20StETH(amountIn, param.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -131,7 +130,7 @@ func (s *PoolSimulator) wstETHUnwrap(amountIn *big.Int) *big.Int {
 		Div(amountIn, s.StETH.TotalShares)
 }
 
-func (s *PoolSimulator) vampireDepositWithERC20StETH(amountIn *big.Int) (*big.Int, *big.Int, error) {
+func (s *PoolSimulator) vampireDepositWithERC20StETH(amountIn *big.Int, timestamp int64) (*big.Int, *big.Int, error) {
 	// Step 1: vampire.quoteByDiscountedValue
 	// Assume with StETH, `isWhitelisted` is always true & `isL2Eth` is always false.
 
@@ -139,7 +138,7 @@ func (s *PoolSimulator) vampireDepositWithERC20StETH(amountIn *big.Int) (*big.In
 	var amount big.Int
 	amount.Set(amountIn)
 	if s.Vampire.QuoteStEthWithCurve {
-		quoteWithCurve, _, _ := s.curveStETHToETHSimulator.GetDy(1, 0, amountIn, nil)
+		quoteWithCurve, _, _ := s.curveStETHToETHSimulator.GetDy(1, 0, amountIn, nil, timestamp)
 		if quoteWithCurve.Cmp(&amount) < 0 {
 			amount.Set(quoteWithCurve)
 		}
@@ -156,7 +155,7 @@ func (s *PoolSimulator) vampireDepositWithERC20StETH(amountIn *big.Int) (*big.In
 	info := s.StETHTokenInfo
 	var totalDepositedThisPeriod big.Int
 	totalDepositedThisPeriod.Set(info.TotalDepositedThisPeriod)
-	if time.Now().Unix() >= int64(info.TimeBoundCapClockStartTime)+int64(s.Vampire.TimeBoundCapRefreshInterval) {
+	if timestamp >= int64(info.TimeBoundCapClockStartTime)+int64(s.Vampire.TimeBoundCapRefreshInterval) {
 		totalDepositedThisPeriod.SetUint64(0)
 	}
 
@@ -3,7 +3,6 @@ package dexT1
 import (
 	"errors"
 	"math/big"
-	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
@@ -101,7 +100,8 @@ func (s *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 	centerPrice := s.CenterPrice
 
 	tokenAmountOut, err := swapIn(swap0To1, amountInAfterFee, collateralReserves, debtReserves,
-		int64(tokenInDecimals), int64(tokenOutDecimals), dexLimits, centerPrice, syncTimestamp)
+		int64(tokenInDecimals), int64(tokenOutDecimals), dexLimits, centerPrice, syncTimestamp,
+		param.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -162,7 +162,8 @@ func (s *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcA
 	centerPrice := s.CenterPrice
 
 	tokenAmountIn, err := swapOut(swap0To1, param.TokenAmountOut.Amount, collateralReserves, debtReserves,
-		int64(tokenInDecimals), int64(tokenOutDecimals), dexLimits, centerPrice, syncTimestamp)
+		int64(tokenInDecimals), int64(tokenOutDecimals), dexLimits, centerPrice, syncTimestamp,
+		param.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -362,11 +363,12 @@ func verifyToken1Reserves(token0Reserves *big.Int, token1Reserves *big.Int, pric
  * @param {number} currentLimits.withdrawableToken1.expandDuration - duration for token1 available to grow to expandsTo
  * @param {number} centerPrice - current center price used to verify reserves ratio
  * @param {number} syncTime - timestamp in seconds when the limits were synced
+ * @param {number} currentTime - timestamp in seconds the swap is evaluated at
  * @returns {number} amountOut - The calculated output amount.
  * @returns {error} - An error object if the operation fails.
  */
 func swapInAdjusted(swap0To1 bool, amountToSwap *big.Int, colReserves CollateralReserves, debtReserves DebtReserves,
-	outDecimals int64, currentLimits DexLimits, centerPrice *big.Int, syncTime int64) (*big.Int, error) {
+	outDecimals int64, currentLimits DexLimits, centerPrice *big.Int, syncTime, currentTime int64) (*big.Int, error) {
 	var (
 		colIReserveIn, colIReserveOut, debtIReserveIn, debtIReserveOut *big.Int
 		colReserveIn, colReserveOut, debtReserveIn, debtReserveOut     *big.Int
@@ -382,8 +384,8 @@ func swapInAdjusted(swap0To1 bool, amountToSwap *big.Int, colReserves Collateral
 		debtReserveOut = debtReserves.Token1RealReserves
 		debtIReserveIn = debtReserves.Token0ImaginaryReserves
 		debtIReserveOut = debtReserves.Token1ImaginaryReserves
-		borrowable = getExpandedLimit(syncTime, currentLimits.BorrowableToken1)
-		withdrawable = getExpandedLimit(syncTime, currentLimits.WithdrawableToken1)
+		borrowable = getExpandedLimit(syncTime, currentTime, currentLimits.BorrowableToken1)
+		withdrawable = getExpandedLimit(syncTime, currentTime, currentLimits.WithdrawableToken1)
 	} else {
 		colReserveIn = colReserves.Token1RealReserves
 		colReserveOut = colReserves.Token0RealReserves
@@ -393,8 +395,8 @@ func swapInAdjusted(swap0To1 bool, amountToSwap *big.Int, colReserves Collateral
 		debtReserveOut = debtReserves.Token0RealReserves
 		debtIReserveIn = debtReserves.Token1ImaginaryReserves
 		debtIReserveOut = debtReserves.Token0ImaginaryReserves
-		borrowable = getExpandedLimit(syncTime, currentLimits.BorrowableToken0)
-		withdrawable = getExpandedLimit(syncTime, currentLimits.WithdrawableToken0)
+		borrowable = getExpandedLimit(syncTime, currentTime, currentLimits.BorrowableToken0)
+		withdrawable = getExpandedLimit(syncTime, currentTime, currentLimits.WithdrawableToken0)
 	}
 
 	// bring borrowable and withdrawable from token decimals to 1e12 decimals, same as amounts
@@ -587,6 +589,7 @@ func swapInAdjusted(swap0To1 bool, amountToSwap *big.Int, colReserves Collateral
  * @param {number} currentLimits.withdrawableToken1.expandDuration - duration for token1 available to grow to expandsTo
  * @param {number} centerPrice - current center price used to verify reserves ratio
  * @param {number} syncTime - timestamp in seconds when the limits were synced
+ * @param {number} currentTime - timestamp in seconds the swap is evaluated at
  * @returns {number} amountOut - The calculated output amount.
  * @returns {error} - An error object if the operation fails.
  */
@@ -600,6 +603,7 @@ func swapIn(
 	currentLimits DexLimits,
 	centerPrice *big.Int,
 	syncTime int64,
+	currentTime int64,
 ) (*big.Int, error) {
 	var amountInAdjusted *big.Int
 
@@ -614,7 +618,7 @@ func swapIn(
 	}
 
 	amountOut, err := swapInAdjusted(swap0To1, amountInAdjusted, colReserves, debtReserves, outDecimals, currentLimits,
-		centerPrice, syncTime)
+		centerPrice, syncTime, currentTime)
 
 	if err != nil {
 		return nil, err
@@ -663,6 +667,7 @@ func swapIn(
  * @param {number} currentLimits.withdrawableToken1.expandDuration - duration for token1 available to grow to expandsTo
  * @param {number} centerPrice - current center price used to verify reserves ratio
  * @param {number} syncTime - timestamp in seconds when the limits were synced
+ * @param {number} currentTime - timestamp in seconds the swap is evaluated at
  * @returns {number} amountIn - The calculated input amount required for the swap.
  * @returns {error} - An error object if the operation fails.
  */
@@ -675,6 +680,7 @@ func swapOutAdjusted(
 	currentLimits DexLimits,
 	centerPrice *big.Int,
 	syncTime int64,
+	currentTime int64,
 ) (*big.Int, error) {
 	var (
 		colIReserveIn, colIReserveOut, debtIReserveIn, debtIReserveOut *big.Int
@@ -691,8 +697,8 @@ func swapOutAdjusted(
 		debtReserveOut = debtReserves.Token1RealReserves
 		debtIReserveIn = debtReserves.Token0ImaginaryReserves
 		debtIReserveOut = debtReserves.Token1ImaginaryReserves
-		borrowable = getExpandedLimit(syncTime, currentLimits.BorrowableToken1)
-		withdrawable = getExpandedLimit(syncTime, currentLimits.WithdrawableToken1)
+		borrowable = getExpandedLimit(syncTime, currentTime, currentLimits.BorrowableToken1)
+		withdrawable = getExpandedLimit(syncTime, currentTime, currentLimits.WithdrawableToken1)
 	} else {
 		colReserveIn = colReserves.Token1RealReserves
 		colReserveOut = colReserves.Token0RealReserves
@@ -702,8 +708,8 @@ func swapOutAdjusted(
 		debtReserveOut = debtReserves.Token0RealReserves
 		debtIReserveIn = debtReserves.Token1ImaginaryReserves
 		debtIReserveOut = debtReserves.Token0ImaginaryReserves
-		borrowable = getExpandedLimit(syncTime, currentLimits.BorrowableToken0)
-		withdrawable = getExpandedLimit(syncTime, currentLimits.WithdrawableToken0)
+		borrowable = getExpandedLimit(syncTime, currentTime, currentLimits.BorrowableToken0)
+		withdrawable = getExpandedLimit(syncTime, currentTime, currentLimits.WithdrawableToken0)
 	}
 
 	// bring borrowable and withdrawable from token decimals to 1e12 decimals, same as amounts
@@ -895,6 +901,7 @@ func swapOutAdjusted(
  * @param {number} currentLimits.withdrawableToken1.expandDuration - duration for token1 available to grow to expandsTo
  * @param {number} centerPrice - current center price used to verify reserves ratio
  * @param {number} syncTime - timestamp in seconds when the limits were synced
+ * @param {number} currentTime - timestamp in seconds the swap is evaluated at
  * @returns {number} amountIn - The calculated input amount required for the swap.
  * @returns {error} - An error object if the operation fails.
  */
@@ -908,6 +915,7 @@ func swapOut(
 	currentLimits DexLimits,
 	centerPrice *big.Int,
 	syncTime int64,
+	currentTime int64,
 ) (*big.Int, error) {
 	var amountOutAdjusted *big.Int
 
@@ -920,7 +928,7 @@ func swapOut(
 	}
 
 	amountIn, err := swapOutAdjusted(swap0To1, amountOutAdjusted, colReserves, debtReserves, outDecimals, currentLimits,
-		centerPrice, syncTime)
+		centerPrice, syncTime, currentTime)
 
 	if err != nil {
 		return nil, err
@@ -935,9 +943,8 @@ func swapOut(
 	return amountIn, nil
 }
 
-// Calculates the currently available swappable amount for a token limit considering expansion since last syncTime.
-func getExpandedLimit(syncTime int64, limit TokenLimit) *big.Int {
-	currentTime := time.Now().Unix() // get current time in seconds
+// Calculates the swappable amount at currentTime for a token limit considering expansion since last syncTime.
+func getExpandedLimit(syncTime, currentTime int64, limit TokenLimit) *big.Int {
 	elapsedTime := currentTime - syncTime
 
 	expandedAmount := limit.Available
@@ -3,12 +3,11 @@ package client
 import (
 	"context"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 
 	hashflowv3 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/hashflow-v3"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -33,16 +32,18 @@ var (
 )
 
 type httpClient struct {
-	client *resty.Client
+	client *rfq.Client
 	config *hashflowv3.HTTPClientConfig
 }
 
-func NewHTTPClient(config *hashflowv3.HTTPClientConfig) *httpClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount).
-		SetHeader(authorizationHeaderKey, config.APIKey)
+func NewHTTPClient(config *hashflowv3.HTTPClientConfig, opts ...rfq.Option) *httpClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          hashflowv3.DexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, append([]rfq.Option{rfq.WithHeader(authorizationHeaderKey, config.APIKey)}, opts...)...)
 
 	return &httpClient{
 		client: client,
@@ -52,21 +53,16 @@ func NewHTTPClient(config *hashflowv3.HTTPClientConfig) *httpClient {
 
 func (c *httpClient) RFQ(ctx context.Context, params hashflowv3.QuoteParams) (hashflowv3.QuoteResult, error) {
 	params.Source = c.config.Source
-	req := c.client.R().SetContext(ctx).SetBody(params)
+	req := c.client.R(ctx).SetBody(params)
 
 	var result hashflowv3.QuoteResult
-	resp, err := req.SetResult(&result).SetError(&result).Post(rfqPath)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&result), resty.MethodPost, rfqPath)
 	if err != nil {
 		return hashflowv3.QuoteResult{}, err
 	}
 
 	if !resp.IsSuccess() || result.Status != "success" {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": hashflowv3.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("quote failed")
-		return hashflowv3.QuoteResult{}, parseRFQError(result.Error.Message)
+		return hashflowv3.QuoteResult{}, c.client.Fail(ctx, resp, "quote failed", parseRFQError(result.Error.Message))
 	}
 
 	return result, nil
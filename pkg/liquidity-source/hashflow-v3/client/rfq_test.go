@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	hashflowv3 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/hashflow-v3"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+const testRouter = "0x55084ee0fef03f14a305cd24286359a35d735151"
+
+func newTestRFQHandler(server *rfqtest.Server) *hashflowv3.RFQHandler {
+	config := &hashflowv3.Config{
+		HTTP: hashflowv3.HTTPClientConfig{
+			BaseURL:    server.URL,
+			Source:     "kyberswap",
+			APIKey:     "key",
+			Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+			RetryCount: 1,
+		},
+		Router: testRouter,
+	}
+	return hashflowv3.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+}
+
+func testRFQParams(baseTokenAmount string) pool.RFQParams {
+	return pool.RFQParams{
+		NetworkID:    1,
+		Recipient:    "0x3333333333333333333333333333333333333333",
+		RFQRecipient: "0x3333333333333333333333333333333333333333",
+		SwapInfo: hashflowv3.SwapInfo{
+			BaseToken:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+			BaseTokenAmount: baseTokenAmount,
+			QuoteToken:      "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		},
+	}
+}
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.HashflowV3.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "target contract",
+			Quote: rfqtest.Quote{AmountOut: "2500000000", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				quote := res.Extra.(hashflowv3.Quote)
+				assert.EqualValues(t, 2e9, quote.QuoteData.QuoteExpiry)
+				assert.Equal(t, testRouter, quote.TargetContract)
+			},
+		},
+		rfqtest.Case{
+			Name:  "maker error",
+			Quote: rfqtest.Quote{Error: errRFQMarketsTooVolatile},
+			ErrIs: []error{ErrRFQMarketsTooVolatile, rfq.ErrRejected},
+		},
+		rfqtest.Case{
+			Name:  "rate limited",
+			Quote: rfqtest.Quote{Error: errRFQRateLimitText, Status: http.StatusTooManyRequests},
+			ErrIs: []error{ErrRFQRateLimit, rfq.ErrRateLimited},
+		},
+	)
+	rfqtest.Run(t, rfqtest.HashflowV3, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		params := testRFQParams("1000000000000000000")
+		params.IndicativeAmountOut = indicative
+		res, err := newTestRFQHandler(server).RFQ(context.Background(), params)
+		assert.Equal(t, "key", server.Requests()[0].Header.Get(authorizationHeaderKey))
+		return res, err
+	})
+}
+
+func TestRFQHandler_BatchRFQ(t *testing.T) {
+	server := rfqtest.NewServer(t, rfqtest.HashflowV3,
+		rfqtest.Quote{AmountOut: "2500000000"}, rfqtest.Quote{AmountOut: "5000000000"})
+	h := newTestRFQHandler(server)
+	res, err := h.BatchRFQ(context.Background(), []pool.RFQParams{
+		testRFQParams("1000000000000000000"),
+		testRFQParams("2000000000000000000"),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "2500000000", res[0].NewAmountOut.String())
+	assert.Equal(t, "5000000000", res[1].NewAmountOut.String())
+
+	var params hashflowv3.QuoteParams
+	require.NoError(t, server.Requests()[0].Decode(&params))
+	assert.Equal(t, "kyberswap", params.Source)
+	assert.Len(t, params.RFQs, 2)
+}
+
+func TestRFQHandler_BatchRFQ_Drift(t *testing.T) {
+	h := newTestRFQHandler(rfqtest.NewServer(t, rfqtest.HashflowV3,
+		rfqtest.Quote{AmountOut: "2500000000"}, rfqtest.Quote{AmountOut: "4000000000"}))
+	params := []pool.RFQParams{
+		testRFQParams("1000000000000000000"),
+		testRFQParams("2000000000000000000"),
+	}
+	params[0].IndicativeAmountOut = big.NewInt(2500000000)
+	params[1].IndicativeAmountOut = big.NewInt(5000000000)
+
+	_, err := h.BatchRFQ(context.Background(), params)
+	assert.ErrorIs(t, err, pool.ErrRFQAmountOutDeviates)
+}
@@ -13,10 +13,11 @@ import (
 const rfqDefaultChainType = "evm"
 
 type Config struct {
-	DexID               string           `json:"dexId"`
-	ExcludeMarketMakers []string         `mapstructure:"excludeMarketMakers" json:"excludeMarketMakers"`
-	HTTP                HTTPClientConfig `mapstructure:"http" json:"http"`
-	Router              string           `mapstructure:"router" json:"router"`
+	DexID               string             `json:"dexId"`
+	ExcludeMarketMakers []string           `mapstructure:"excludeMarketMakers" json:"excludeMarketMakers"`
+	HTTP                HTTPClientConfig   `mapstructure:"http" json:"http"`
+	Router              string             `mapstructure:"router" json:"router"`
+	Validation          pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -96,17 +97,25 @@ func (h *RFQHandler) BatchRFQ(ctx context.Context, paramsSlice []pool.RFQParams)
 	}
 
 	var results []*pool.RFQResult
-	for _, quote := range quoteResult.Quotes {
+	for i, quote := range quoteResult.Quotes {
+		if err = pool.ValidateRFQFill(quoteParams.RFQs[i].BaseTokenAmount, quote.QuoteData.BaseTokenAmount); err != nil {
+			return nil, errors.WithMessagef(err, "rfq %d", i)
+		}
 		newAmountOut, _ := new(big.Int).SetString(quote.QuoteData.QuoteTokenAmount, 10)
 		if quote.TargetContract == "" {
 			quote.TargetContract = h.config.Router
 		}
 		results = append(results, &pool.RFQResult{
 			NewAmountOut: newAmountOut,
+			Deadline:     quote.QuoteData.QuoteExpiry,
 			Extra:        quote,
 		})
 	}
 
+	if err = h.config.Validation.ValidateBatch(paramsSlice, results); err != nil {
+		return nil, err
+	}
+
 	return results, nil
 }
 
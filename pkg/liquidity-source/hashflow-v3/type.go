@@ -4,14 +4,17 @@ import (
 	"math/big"
 
 	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 type HTTPClientConfig struct {
-	BaseURL    string                `mapstructure:"base_url" json:"base_url"`
-	Source     string                `mapstructure:"source" json:"source"`
-	APIKey     string                `mapstructure:"api_key" json:"api_key"`
-	Timeout    durationjson.Duration `mapstructure:"timeout" json:"timeout"`
-	RetryCount int                   `mapstructure:"retry_count" json:"retry_count"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url"`
+	Source         string                   `mapstructure:"source" json:"source"`
+	APIKey         string                   `mapstructure:"api_key" json:"api_key"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
 
 type QuoteParams struct {
@@ -3,11 +3,10 @@ package client
 import (
 	"context"
 
-	"github.com/KyberNetwork/logger"
 	"github.com/go-resty/resty/v2"
-	"github.com/pkg/errors"
 
 	kyberpmm "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/kyber-pmm"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -19,15 +18,19 @@ const (
 )
 
 type httpClient struct {
-	client *resty.Client
+	client *rfq.Client
 	config *kyberpmm.HTTPConfig
 }
 
-func NewHTTPClient(config *kyberpmm.HTTPConfig) *httpClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount)
+func NewHTTPClient(config *kyberpmm.HTTPConfig, opts ...rfq.Option) *httpClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          kyberpmm.DexTypeKyberPMM,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		TotalTimeout:   config.TotalTimeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, opts...)
 
 	return &httpClient{
 		client: client,
@@ -36,101 +39,91 @@ func NewHTTPClient(config *kyberpmm.HTTPConfig) *httpClient {
 }
 
 func (c *httpClient) ListTokens(ctx context.Context) (map[string]kyberpmm.TokenItem, error) {
-	req := c.client.R().
-		SetContext(ctx)
+	req := c.client.RIdempotent(ctx)
 
 	var result kyberpmm.ListTokensResult
-	resp, err := req.SetResult(&result).Get(listTokensEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result), resty.MethodGet, listTokensEndpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	if !resp.IsSuccess() {
-		return nil, errors.WithMessagef(ErrListTokensFailed, "[kyberPMM] response status: %v, response error: %v", resp.Status(), resp.Error())
+		return nil, c.client.Fail(ctx, resp, "list tokens failed", ErrListTokensFailed)
 	}
 
 	return result.Tokens, nil
 }
 
 func (c *httpClient) ListPairs(ctx context.Context) (map[string]kyberpmm.PairItem, error) {
-	req := c.client.R().
-		SetContext(ctx)
+	req := c.client.RIdempotent(ctx)
 
 	var result kyberpmm.ListPairsResult
-	resp, err := req.SetResult(&result).Get(listPairsEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result), resty.MethodGet, listPairsEndpoint)
 	if err != nil {
 		return nil, err
 	}
 
 	if !resp.IsSuccess() {
-		return nil, errors.WithMessagef(ErrListPairsFailed, "[kyberPMM] response status: %v, response error: %v", resp.Status(), resp.Error())
+		return nil, c.client.Fail(ctx, resp, "list pairs failed", ErrListPairsFailed)
 	}
 
 	return result.Pairs, nil
 }
 
 func (c *httpClient) ListPriceLevels(ctx context.Context) (kyberpmm.ListPriceLevelsResult, error) {
-	req := c.client.R().
-		SetContext(ctx)
+	req := c.client.RIdempotent(ctx)
 
 	var result kyberpmm.ListPriceLevelsResult
-	resp, err := req.SetResult(&result).Get(listPricesEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result), resty.MethodGet, listPricesEndpoint)
 	if err != nil {
 		return result, err
 	}
 
 	if !resp.IsSuccess() {
-		return result, errors.WithMessagef(ErrListPriceLevelsFailed, "[kyberPMM] response status: %v, response error: %v", resp.Status(), resp.Error())
+		return result, c.client.Fail(ctx, resp, "list price levels failed", ErrListPriceLevelsFailed)
 	}
 
 	return result, nil
 }
 
 func (c *httpClient) Firm(ctx context.Context, params kyberpmm.FirmRequestParams) (kyberpmm.FirmResult, error) {
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		SetBody(params)
 
 	var result kyberpmm.FirmResult
-	resp, err := req.SetResult(&result).Post(firmEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result), resty.MethodPost, firmEndpoint)
 	if err != nil {
 		return kyberpmm.FirmResult{}, err
 	}
 
 	if !resp.IsSuccess() {
-		return kyberpmm.FirmResult{}, errors.WithMessagef(ErrFirmQuoteFailed, "[kyberPMM] response status: %v, response error: %v", resp.Status(), resp.Error())
+		return kyberpmm.FirmResult{}, c.client.Fail(ctx, resp, "firm quote failed", ErrFirmQuoteFailed)
 	}
 
 	if result.Error != "" {
-		parsedErr := parseFirmQuoteError(result.Error)
-		logger.Errorf("firm quote failed with error: %v", result.Error)
-
-		return kyberpmm.FirmResult{}, parsedErr
+		return kyberpmm.FirmResult{}, c.client.Fail(ctx, resp, "firm quote failed", parseFirmQuoteError(result.Error))
 	}
 
 	return result, nil
 }
 
 func (c *httpClient) MultiFirm(ctx context.Context, params kyberpmm.MultiFirmRequestParams) (kyberpmm.MultiFirmResult, error) {
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		SetBody(params)
 
 	var result kyberpmm.MultiFirmResult
-	resp, err := req.SetResult(&result).SetError(&result).Post(multiFirmEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&result), resty.MethodPost, multiFirmEndpoint)
 	if err != nil {
 		return kyberpmm.MultiFirmResult{}, err
 	}
 
 	if !resp.IsSuccess() {
-		return kyberpmm.MultiFirmResult{}, errors.WithMessagef(ErrFirmQuoteFailed, "[kyberPMM] response status: %v, response error: %v", resp.Status(), resp.Error())
+		return kyberpmm.MultiFirmResult{}, c.client.Fail(ctx, resp, "firm quote failed", ErrFirmQuoteFailed)
 	}
 
 	if result.Error != "" {
-		parsedErr := parseFirmQuoteError(result.Error)
-		logger.Errorf("firm quote failed with error: %v", result.Error)
-
-		return kyberpmm.MultiFirmResult{}, parsedErr
+		return kyberpmm.MultiFirmResult{}, c.client.Fail(ctx, resp, "firm quote failed",
+			parseFirmQuoteError(result.Error))
 	}
 
 	return result, nil
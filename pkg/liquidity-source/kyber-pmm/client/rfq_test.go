@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	kyberpmm "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/kyber-pmm"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+const (
+	testMakerAsset = "0xaf88d065e77c8cc2239327c5edb3a432268e5831"
+	testTakerAsset = "0x82af49447d8a07e3bd95bd0d56f35241523fbab1"
+	testRFQSender  = "0x3333333333333333333333333333333333333333"
+)
+
+func newTestRFQHandler(server *rfqtest.Server) *kyberpmm.RFQHandler {
+	config := &kyberpmm.Config{
+		RFQContractAddress: "0x7a819fa46734a49d0112796f9377e024c350fb26",
+		HTTP: kyberpmm.HTTPConfig{
+			BaseURL:    server.URL,
+			Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+			RetryCount: 1,
+		},
+	}
+	return kyberpmm.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+}
+
+func testRFQParams(takingAmount, makingAmount string) pool.RFQParams {
+	return pool.RFQParams{
+		RFQSender: testRFQSender,
+		Recipient: testRFQSender,
+		Slippage:  100,
+		RequestID: "request",
+		SwapInfo: kyberpmm.SwapExtra{
+			TakerAsset:   testTakerAsset,
+			TakingAmount: takingAmount,
+			MakerAsset:   testMakerAsset,
+			MakingAmount: makingAmount,
+		},
+	}
+}
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.KyberPMM.Cases("1000", "1000"),
+		rfqtest.Case{
+			Name:  "signed order",
+			Quote: rfqtest.Quote{AmountOut: "995", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				extra := res.Extra.(kyberpmm.RFQExtra)
+				assert.Equal(t, "1000", extra.TakerAmount)
+				assert.EqualValues(t, 2e9, extra.Expiry)
+				assert.Equal(t, testRFQSender, extra.AllowedSender)
+			},
+		},
+		rfqtest.Case{
+			Name:  "maker error",
+			Quote: rfqtest.Quote{Error: ErrFirmQuoteInsufficientLiquidityText},
+			ErrIs: []error{ErrFirmQuoteInsufficientLiquidity, rfq.ErrRejected},
+		},
+		rfqtest.Case{
+			Name:  "server error",
+			Quote: rfqtest.Quote{Status: http.StatusServiceUnavailable},
+			ErrIs: []error{ErrFirmQuoteFailed, rfq.ErrServer},
+		},
+	)
+	rfqtest.Run(t, rfqtest.KyberPMM, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		params := testRFQParams("1000", "1000")
+		// beyond the requotes of the shared cases, for them to be checked against the indicative amount out
+		params.Slippage = 300
+		params.IndicativeAmountOut = indicative
+		return newTestRFQHandler(server).RFQ(context.Background(), params)
+	})
+}
+
+func TestRFQHandler_RFQ_Slippage(t *testing.T) {
+	server := rfqtest.NewServer(t, rfqtest.KyberPMM, rfqtest.Quote{AmountOut: "990"})
+	res, err := newTestRFQHandler(server).RFQ(context.Background(), testRFQParams("1000", "1000"))
+	require.NoError(t, err)
+	assert.Equal(t, "990", res.NewAmountOut.String())
+
+	server.Script(rfqtest.Quote{AmountOut: "989"})
+	_, err = newTestRFQHandler(server).RFQ(context.Background(), testRFQParams("1000", "1000"))
+	assert.ErrorIs(t, err, kyberpmm.ErrMakerAmountTooLow)
+}
+
+func TestRFQHandler_BatchRFQ(t *testing.T) {
+	server := rfqtest.NewServer(t, rfqtest.KyberPMM, rfqtest.Quote{AmountOut: "995"}, rfqtest.Quote{AmountOut: "1990"})
+	h := newTestRFQHandler(server)
+	res, err := h.BatchRFQ(context.Background(), []pool.RFQParams{
+		testRFQParams("1000", "1000"),
+		testRFQParams("2000", "2000"),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "995", res[0].NewAmountOut.String())
+	assert.Equal(t, "1990", res[1].NewAmountOut.String())
+	require.Len(t, server.Requests(), 1)
+
+	var params kyberpmm.MultiFirmRequestParams
+	require.NoError(t, server.Requests()[0].Decode(&params))
+	assert.Equal(t, "990", params.Orders[0].MinMakerAmount)
+	assert.Equal(t, "1980", params.Orders[1].MinMakerAmount)
+
+	server.Script(rfqtest.Quote{AmountOut: "995"}, rfqtest.Quote{Error: ErrFirmQuoteMarketConditionText})
+	_, err = h.BatchRFQ(context.Background(), []pool.RFQParams{
+		testRFQParams("1000", "1000"),
+		testRFQParams("2000", "2000"),
+	})
+	assert.ErrorContains(t, err, "order 1 error: "+ErrFirmQuoteMarketConditionText)
+
+	server.Script(rfqtest.Quote{AmountOut: "995"}, rfqtest.Quote{AmountIn: "1000", AmountOut: "995"})
+	_, err = h.BatchRFQ(context.Background(), []pool.RFQParams{
+		testRFQParams("1000", "1000"),
+		testRFQParams("2000", "2000"),
+	})
+	assert.ErrorIs(t, err, pool.ErrRFQPartialFill)
+	assert.ErrorContains(t, err, "order 1")
+}
@@ -2,20 +2,26 @@ package kyberpmm
 
 import (
 	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 type Config struct {
-	DexID                        string            `json:"dexID,omitempty"`
-	RFQContractAddress           string            `mapstructure:"rfq_contract_address" json:"rfq_contract_address,omitempty"`
-	HTTP                         HTTPConfig        `mapstructure:"http" json:"http,omitempty"`
-	MemoryCache                  MemoryCacheConfig `mapstructure:"memory_cache" json:"memory_cache,omitempty"`
-	IgnoreCheckReturnMakerAmount bool              `mapstructure:"ignore_check_return_amount" json:"ignore_check_return_amount,omitempty"`
+	DexID                        string             `json:"dexID,omitempty"`
+	RFQContractAddress           string             `mapstructure:"rfq_contract_address" json:"rfq_contract_address,omitempty"`
+	HTTP                         HTTPConfig         `mapstructure:"http" json:"http,omitempty"`
+	MemoryCache                  MemoryCacheConfig  `mapstructure:"memory_cache" json:"memory_cache,omitempty"`
+	IgnoreCheckReturnMakerAmount bool               `mapstructure:"ignore_check_return_amount" json:"ignore_check_return_amount,omitempty"`
+	Validation                   pool.RFQValidation `mapstructure:"validation" json:"validation,omitempty"`
 }
 
 type HTTPConfig struct {
-	BaseURL    string                `mapstructure:"base_url" json:"base_url,omitempty"`
-	Timeout    durationjson.Duration `mapstructure:"timeout" json:"timeout,omitempty"`
-	RetryCount int                   `mapstructure:"retry_count" json:"retry_count,omitempty"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url,omitempty"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout,omitempty"`
+	TotalTimeout   durationjson.Duration    `mapstructure:"total_timeout" json:"total_timeout,omitempty"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count,omitempty"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
 
 type MemoryCacheConfig struct {
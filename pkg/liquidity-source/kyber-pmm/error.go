@@ -1,12 +1,16 @@
 package kyberpmm
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
+)
 
 var (
 	ErrTokenNotFound          = errors.New("token not found")
 	ErrNoPriceLevelsForPool   = errors.New("no price levels for pool")
-	ErrEmptyPriceLevels       = errors.New("empty price levels")
-	ErrInsufficientLiquidity  = errors.New("insufficient liquidity")
+	ErrEmptyPriceLevels       = pricelevel.ErrEmptyPriceLevels
+	ErrInsufficientLiquidity  = pricelevel.ErrInsufficientLiquidity
 	ErrInvalidFirmQuoteParams = errors.New("invalid firm quote params")
 	ErrNoSwapLimit            = errors.New("swap limit is required for PMM pools")
 	ErrNotEnoughInventoryIn   = errors.New("not enough inventory in")
@@ -13,6 +13,7 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
@@ -20,11 +21,16 @@ type PoolSimulator struct {
 	pool.Pool
 	baseToken   entity.PoolToken
 	quoteTokens []entity.PoolToken
-	priceLevels []BaseQuotePriceLevels
+	books       []pairBooks // price levels of each quote token
 	gas         Gas
 	timestamp   int64
 }
 
+type pairBooks struct {
+	baseToQuote *pricelevel.Book
+	quoteToBase *pricelevel.Book
+}
+
 var _ = pool.RegisterFactory0(DexTypeKyberPMM, NewPoolSimulator)
 
 func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
@@ -48,7 +54,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 	var (
 		baseToken           entity.PoolToken
 		quoteTokens         = make([]entity.PoolToken, 0, numTokens-1)
-		priceLevels         = make([]BaseQuotePriceLevels, 0, numTokens-1)
+		books               = make([]pairBooks, 0, numTokens-1)
 		quoteAddresessesMap = make(map[string]struct{}, len(staticExtra.QuoteTokenAddresses))
 	)
 	for _, qAddr := range staticExtra.QuoteTokenAddresses {
@@ -72,11 +78,11 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		reserves[i] = amount
 	}
 	for _, qToken := range quoteTokens {
-		bqPriceLevel, exist := extra.PriceLevels[fmt.Sprintf("%s/%s", baseToken.Symbol, qToken.Symbol)]
-		if !exist {
-			continue
-		}
-		priceLevels = append(priceLevels, bqPriceLevel)
+		bqPriceLevel := extra.PriceLevels[fmt.Sprintf("%s/%s", baseToken.Symbol, qToken.Symbol)]
+		books = append(books, pairBooks{
+			baseToQuote: newBook(bqPriceLevel.BaseToQuotePriceLevels, baseToken.Decimals, qToken.Decimals),
+			quoteToBase: newBook(bqPriceLevel.QuoteToBasePriceLevels, qToken.Decimals, baseToken.Decimals),
+		})
 	}
 
 	return &PoolSimulator{
@@ -94,7 +100,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		},
 		baseToken:   baseToken,
 		quoteTokens: quoteTokens,
-		priceLevels: priceLevels,
+		books:       books,
 		gas:         DefaultGas,
 		timestamp:   entityPool.Timestamp,
 	}, nil
@@ -116,46 +122,11 @@ func (p *PoolSimulator) CalcAmountOut(
 			inventoryLimitIn.String(), param.TokenAmountIn.Amount.String())
 	}
 
-	var (
-		inToken, outToken entity.PoolToken
-		priceLevels       []PriceLevel
-		isBaseToQuote     bool
-		quoteToken        string
-	)
-
-	if strings.EqualFold(param.TokenAmountIn.Token, p.baseToken.Address) {
-		quoteToken = param.TokenOut
-		isBaseToQuote = true
-		inToken = p.baseToken
-	} else {
-		quoteToken = param.TokenAmountIn.Token
-		isBaseToQuote = false
-		outToken = p.baseToken
-	}
-
-	for i := range p.quoteTokens {
-		if !strings.EqualFold(p.quoteTokens[i].Address, quoteToken) {
-			continue
-		}
-		if isBaseToQuote {
-			priceLevels = p.priceLevels[i].BaseToQuotePriceLevels
-			outToken = p.quoteTokens[i]
-		} else {
-			priceLevels = p.priceLevels[i].QuoteToBasePriceLevels
-			inToken = p.quoteTokens[i]
-		}
-		break
-	}
-
-	amountInAfterDecimals := amountAfterDecimals(param.TokenAmountIn.Amount, inToken.Decimals)
-	amountOutAfterDecimals, err := getAmountOut(amountInAfterDecimals, priceLevels)
+	inToken, outToken, book := p.getBook(param.TokenAmountIn.Token, param.TokenOut)
+	amountOut, err := book.AmountOut(param.TokenAmountIn.Amount)
 	if err != nil {
 		return nil, err
 	}
-	amountOut, _ := amountOutAfterDecimals.Mul(
-		amountOutAfterDecimals,
-		bignumber.TenPowDecimals(outToken.Decimals),
-	).Int(nil)
 
 	if amountOut.Cmp(inventoryLimitOut) > 0 {
 		return nil, errors.New("not enough inventory out")
@@ -174,23 +145,37 @@ func (p *PoolSimulator) CalcAmountOut(
 	}, nil
 }
 
+// SpotPrice returns the price of the best price level.
+func (p *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	_, _, book := p.getBook(tokenIn, tokenOut)
+	spotPrice, err := book.SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth walks the price levels, filling each one whole while the average price stays within the impact, and the
+// part of the first one it does not where it reaches the impact. The inventory of the market maker is not accounted
+// for.
+func (p *PoolSimulator) Depth(tokenIn, tokenOut string, impactBps int64) (*pool.DepthPoint, error) {
+	_, _, book := p.getBook(tokenIn, tokenOut)
+	amountIn, amountOut, err := book.Depth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	// remove related base levels
 	if strings.EqualFold(params.TokenAmountIn.Token, p.baseToken.Address) {
-		baseAmountAfterDecimals := amountAfterDecimals(params.TokenAmountIn.Amount, p.baseToken.Decimals)
-		for i := range p.priceLevels {
-			p.priceLevels[i].BaseToQuotePriceLevels = getNewPriceLevelsStateByAmountIn(
-				baseAmountAfterDecimals,
-				p.priceLevels[i].BaseToQuotePriceLevels,
-			)
+		for _, books := range p.books {
+			books.baseToQuote.Consume(params.TokenAmountIn.Amount)
 		}
 	} else {
-		baseAmountAfterDecimals := amountAfterDecimals(params.TokenAmountOut.Amount, p.baseToken.Decimals)
-		for i := range p.priceLevels {
-			p.priceLevels[i].QuoteToBasePriceLevels = getNewPriceLevelsStateByAmountOut(
-				baseAmountAfterDecimals,
-				p.priceLevels[i].QuoteToBasePriceLevels,
-			)
+		for _, books := range p.books {
+			books.quoteToBase.ConsumeOut(params.TokenAmountOut.Amount)
 		}
 	}
 
@@ -201,6 +186,15 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	}
 }
 
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.books = make([]pairBooks, len(p.books))
+	for i, books := range p.books {
+		cloned.books[i] = pairBooks{baseToQuote: books.baseToQuote.Clone(), quoteToBase: books.quoteToBase.Clone()}
+	}
+	return &cloned
+}
+
 func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 	return RFQMeta{
 		Timestamp: p.timestamp,
@@ -239,108 +233,41 @@ func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 	return pmmInventory
 }
 
-func getAmountOut(amountIn *big.Float, priceLevels []PriceLevel) (*big.Float, error) {
-	if len(priceLevels) == 0 {
-		return nil, ErrEmptyPriceLevels
-	}
-
-	var availableAmountBF big.Float
-	availableAmountBF.SetFloat64(lo.SumBy(priceLevels, func(priceLevel PriceLevel) float64 {
-		return priceLevel.Amount
-	}))
-
-	if amountIn.Cmp(&availableAmountBF) > 0 {
-		return nil, ErrInsufficientLiquidity
-	}
-
-	amountOut := new(big.Float)
-	amountInLeft := availableAmountBF.Set(amountIn)
-	var tmp, price big.Float
-	for _, priceLevel := range priceLevels {
-		swappableAmount := tmp.SetFloat64(priceLevel.Amount)
-		if swappableAmount.Cmp(amountInLeft) > 0 {
-			swappableAmount = amountInLeft
-		}
-
-		amountOut = amountOut.Add(
-			amountOut,
-			price.Mul(swappableAmount, price.SetFloat64(priceLevel.Price)),
-		)
-
-		if amountInLeft.Cmp(swappableAmount) == 0 {
-			break
-		}
-
-		amountInLeft = amountInLeft.Sub(amountInLeft, swappableAmount)
-	}
-
-	return amountOut, nil
-}
-
-func getNewPriceLevelsStateByAmountIn(amountIn *big.Float, priceLevels []PriceLevel) []PriceLevel {
-	if len(priceLevels) == 0 {
-		return priceLevels
-	}
-
-	var tmp, amountInLeft big.Float
-	amountInLeft.Set(amountIn)
-	for currentLevelIdx, priceLevel := range priceLevels {
-		swappableAmount := tmp.SetFloat64(priceLevel.Amount)
-		if cmp := swappableAmount.Cmp(&amountInLeft); cmp < 0 {
-			amountInLeft.Sub(&amountInLeft, swappableAmount)
-			continue
-		} else if cmp == 0 { // fully filled
-			return priceLevels[currentLevelIdx+1:]
-		}
-
-		// partially filled. Must clone so as not to mutate old price level
-		priceLevels = slices.Clone(priceLevels[currentLevelIdx:])
-		priceLevels[0].Amount, _ = swappableAmount.Sub(swappableAmount, &amountInLeft).Float64()
-		return priceLevels
-	}
-
-	return nil
-}
+// getBook returns the tokens and the price levels to swap tokenIn to tokenOut with.
+func (p *PoolSimulator) getBook(tokenIn, tokenOut string) (inToken, outToken entity.PoolToken, book *pricelevel.Book) {
+	var (
+		isBaseToQuote bool
+		quoteToken    string
+	)
 
-func getNewPriceLevelsStateByAmountOut(amountOut *big.Float, priceLevels []PriceLevel) []PriceLevel {
-	if len(priceLevels) == 0 {
-		return priceLevels
+	if strings.EqualFold(tokenIn, p.baseToken.Address) {
+		quoteToken = tokenOut
+		isBaseToQuote = true
+		inToken = p.baseToken
+	} else {
+		quoteToken = tokenIn
+		isBaseToQuote = false
+		outToken = p.baseToken
 	}
 
-	var tmpAmt, tmpPrice, amountOutLeft big.Float
-	amountOutLeft.Set(amountOut)
-	for currentLevelIdx, priceLevel := range priceLevels {
-		swappableAmount := tmpAmt.SetFloat64(priceLevel.Amount)
-		swappableAmount.Mul(swappableAmount, tmpPrice.SetFloat64(priceLevel.Price))
-
-		cmp := swappableAmount.Cmp(&amountOutLeft)
-		// full filled 1 level
-		if cmp < 0 {
-			amountOutLeft.Sub(&amountOutLeft, swappableAmount)
+	for i := range p.quoteTokens {
+		if !strings.EqualFold(p.quoteTokens[i].Address, quoteToken) {
 			continue
 		}
-
-		// fully filled amount out
-		if cmp == 0 {
-			return priceLevels[currentLevelIdx+1:]
+		if isBaseToQuote {
+			book = p.books[i].baseToQuote
+			outToken = p.quoteTokens[i]
+		} else {
+			book = p.books[i].quoteToBase
+			inToken = p.quoteTokens[i]
 		}
-
-		// partially filled. Must clone so as not to mutate old price level
-		priceLevels = slices.Clone(priceLevels[currentLevelIdx:])
-		swappableAmount.Sub(swappableAmount, &amountOutLeft)
-		swappableAmount.Quo(swappableAmount, tmpPrice.SetFloat64(priceLevels[0].Price))
-
-		priceLevels[0].Amount, _ = swappableAmount.Float64()
-		return priceLevels
+		return inToken, outToken, book
 	}
-
-	return nil
+	return inToken, outToken, pricelevel.NewBook(nil, inToken.Decimals, outToken.Decimals)
 }
 
-func amountAfterDecimals(amount *big.Int, decimals uint8) *big.Float {
-	ret := new(big.Float)
-	return ret.Quo(
-		ret.SetInt(amount),
-		bignumber.TenPowDecimals(decimals),
-	)
+func newBook(priceLevels []PriceLevel, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(lo.Map(priceLevels, func(priceLevel PriceLevel, _ int) pricelevel.Level {
+		return pricelevel.NewLevel(priceLevel.Price, priceLevel.Amount)
+	}), decimalsIn, decimalsOut)
 }
@@ -2,293 +2,174 @@ package kyberpmm
 
 import (
 	"math/big"
-	"slices"
 	"testing"
 
 	"github.com/goccy/go-json"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 )
 
+// priceLevels returns the price levels of a book, as kyber-pmm quotes them.
+func priceLevels(book *pricelevel.Book) []PriceLevel {
+	return lo.Map(book.Levels(), func(level pricelevel.Level, _ int) PriceLevel {
+		price, amount := level.Float64()
+		return PriceLevel{Price: price, Amount: amount}
+	})
+}
+
 func TestPoolSimulator_getAmountOut(t *testing.T) {
-	type args struct {
-		amountIn    *big.Float
-		priceLevels []PriceLevel
-	}
 	tests := []struct {
 		name              string
-		args              args
-		expectedAmountOut *big.Float
+		amountIn          int64
+		priceLevels       []PriceLevel
+		expectedAmountOut string
 		expectedErr       error
 	}{
 		{
-			name: "it should return error when price levels is empty",
-			args: args{
-				amountIn:    new(big.Float).SetFloat64(1),
-				priceLevels: []PriceLevel{},
-			},
-			expectedAmountOut: nil,
-			expectedErr:       ErrEmptyPriceLevels,
+			name:        "it should return error when price levels is empty",
+			amountIn:    1,
+			priceLevels: []PriceLevel{},
+			expectedErr: ErrEmptyPriceLevels,
 		},
 		{
-			name: "it should return insufficient liquidity error when the requested amount is greater than available amount in price levels",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(4),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
-			expectedAmountOut: nil,
-			expectedErr:       ErrInsufficientLiquidity,
+			name:        "it should return insufficient liquidity error when the requested amount is greater than available amount in price levels",
+			amountIn:    4,
+			priceLevels: []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
+			expectedErr: ErrInsufficientLiquidity,
 		},
 		{
-			name: "it should return correct amount out when fully filled",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(1),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-				},
-			},
-			expectedAmountOut: new(big.Float).SetFloat64(100),
-			expectedErr:       nil,
+			name:              "it should return correct amount out when fully filled",
+			amountIn:          1,
+			priceLevels:       []PriceLevel{{Price: 100, Amount: 1}},
+			expectedAmountOut: "100",
 		},
 		{
-			name: "it should return correct amount out when partially filled",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(2),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
-			expectedAmountOut: new(big.Float).SetFloat64(199),
-			expectedErr:       nil,
+			name:              "it should return correct amount out when partially filled",
+			amountIn:          2,
+			priceLevels:       []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
+			expectedAmountOut: "199",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			amountOut, err := testutil.MustConcurrentSafe[*big.Float](t, func() (*big.Float, error) {
-				return getAmountOut(tt.args.amountIn, tt.args.priceLevels)
+			book := newBook(tt.priceLevels, 0, 0)
+			amountOut, err := testutil.MustConcurrentSafe[*big.Int](t, func() (*big.Int, error) {
+				return book.AmountOut(big.NewInt(tt.amountIn))
 			})
-			assert.Equal(t, tt.expectedErr, err)
-
-			if amountOut != nil {
-				assert.Equal(t, tt.expectedAmountOut.Cmp(amountOut), 0)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.expectedAmountOut, amountOut.String())
 			}
 		})
 	}
 }
 
 func TestPoolSimulator_getNewPriceLevelsStateByAmountIn(t *testing.T) {
-	type args struct {
-		amountIn    *big.Float
-		priceLevels []PriceLevel
-	}
 	tests := []struct {
 		name                string
-		args                args
+		amountIn            int64
+		priceLevels         []PriceLevel
 		expectedPriceLevels []PriceLevel
 	}{
 		{
-			name: "it should do nothing when price levels is empty",
-			args: args{
-				amountIn:    new(big.Float).SetFloat64(1),
-				priceLevels: []PriceLevel{},
-			},
+			name:                "it should do nothing when price levels is empty",
+			amountIn:            1,
+			priceLevels:         []PriceLevel{},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when fully filled",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(1),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when fully filled",
+			amountIn:            1,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when the amountIn is greater than the amount available in the single price level",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(2),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when the amountIn is greater than the amount available in the single price level",
+			amountIn:            2,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when the amountIn is greater than the amount available in the all price levels",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(5),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when the amountIn is greater than the amount available in the all price levels",
+			amountIn:            5,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when partially filled",
-			args: args{
-				amountIn: new(big.Float).SetFloat64(2),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
-			expectedPriceLevels: []PriceLevel{
-				{
-					Price:  99,
-					Amount: 1,
-				},
-			},
+			name:                "it should return correct new price levels when partially filled",
+			amountIn:            2,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
+			expectedPriceLevels: []PriceLevel{{Price: 99, Amount: 1}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			oldPriceLevels := slices.Clone(tt.args.priceLevels)
-			newPriceLevels := getNewPriceLevelsStateByAmountIn(tt.args.amountIn, tt.args.priceLevels)
+			book := newBook(tt.priceLevels, 0, 0)
+			cloned := book.Clone()
+			book.Consume(big.NewInt(tt.amountIn))
 
-			assert.ElementsMatch(t, tt.expectedPriceLevels, newPriceLevels)
-			assert.ElementsMatch(t, oldPriceLevels, tt.args.priceLevels)
+			assert.ElementsMatch(t, tt.expectedPriceLevels, priceLevels(book))
+			assert.ElementsMatch(t, tt.priceLevels, priceLevels(cloned))
 		})
 	}
 }
 
 func TestPoolSimulator_getNewPriceLevelsStateByAmountOut(t *testing.T) {
-	type args struct {
-		amountOut   *big.Float
-		priceLevels []PriceLevel
-	}
 	tests := []struct {
 		name                string
-		args                args
+		amountOut           int64
+		priceLevels         []PriceLevel
 		expectedPriceLevels []PriceLevel
 	}{
 		{
-			name: "it should do nothing when price levels is empty",
-			args: args{
-				amountOut:   new(big.Float).SetFloat64(1),
-				priceLevels: []PriceLevel{},
-			},
+			name:                "it should do nothing when price levels is empty",
+			amountOut:           1,
+			priceLevels:         []PriceLevel{},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when fully filled",
-			args: args{
-				amountOut: new(big.Float).SetFloat64(100),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when fully filled",
+			amountOut:           100,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when the amountIn is greater than the amount available in the single price level",
-			args: args{
-				amountOut: new(big.Float).SetFloat64(200),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when the amountOut is greater than the amount available in the single price level",
+			amountOut:           200,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when the amountIn is greater than the amount available in the all price levels",
-			args: args{
-				amountOut: new(big.Float).SetFloat64(500),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
+			name:                "it should return correct new price levels when the amountOut is greater than the amount available in the all price levels",
+			amountOut:           500,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
 			expectedPriceLevels: []PriceLevel{},
 		},
 		{
-			name: "it should return correct new price levels when partially filled",
-			args: args{
-				amountOut: new(big.Float).SetFloat64(199),
-				priceLevels: []PriceLevel{
-					{
-						Price:  100,
-						Amount: 1,
-					},
-					{
-						Price:  99,
-						Amount: 2,
-					},
-				},
-			},
-			expectedPriceLevels: []PriceLevel{
-				{
-					Price:  99,
-					Amount: 1,
-				},
-			},
+			name:                "it should return correct new price levels when partially filled",
+			amountOut:           199,
+			priceLevels:         []PriceLevel{{Price: 100, Amount: 1}, {Price: 99, Amount: 2}},
+			expectedPriceLevels: []PriceLevel{{Price: 99, Amount: 1}},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			oldPriceLevels := slices.Clone(tt.args.priceLevels)
-			newPriceLevels := getNewPriceLevelsStateByAmountOut(tt.args.amountOut, tt.args.priceLevels)
+			book := newBook(tt.priceLevels, 0, 0)
+			cloned := book.Clone()
+			book.ConsumeOut(big.NewInt(tt.amountOut))
 
-			assert.ElementsMatch(t, tt.expectedPriceLevels, newPriceLevels)
-			assert.ElementsMatch(t, oldPriceLevels, tt.args.priceLevels)
+			assert.ElementsMatch(t, tt.expectedPriceLevels, priceLevels(book))
+			assert.ElementsMatch(t, tt.priceLevels, priceLevels(cloned))
 		})
 	}
 }
@@ -511,3 +392,47 @@ func jsonify(data any) []byte {
 
 	return v
 }
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	const (
+		knc  = "0xdefa4e8a7bcba345f687a2f1456f5edd9ce97202"
+		usdt = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	)
+	ps, err := NewPoolSimulator(entity.Pool{
+		Tokens: []*entity.PoolToken{
+			{Address: knc, Decimals: 18, Symbol: "KNC"},
+			{Address: usdt, Decimals: 6, Symbol: "USDT"},
+		},
+		StaticExtra: string(jsonify(StaticExtra{BaseTokenAddress: knc, QuoteTokenAddresses: []string{usdt}})),
+		Reserves:    entity.PoolReserves{"10000000000000000000000", "10000000000"},
+		Extra: string(jsonify(Extra{PriceLevels: map[string]BaseQuotePriceLevels{
+			"KNC/USDT": {
+				BaseToQuotePriceLevels: []PriceLevel{{Price: 0.6, Amount: 10}, {Price: 0.5, Amount: 10}},
+				QuoteToBasePriceLevels: []PriceLevel{{Price: 1, Amount: 1}, {Price: 2, Amount: 10}},
+			},
+		}})),
+	})
+	require.NoError(t, err)
+
+	spotPrice, err := pool.SpotPrice(ps, pool.SpotPriceParams{TokenIn: knc, TokenOut: usdt})
+	require.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 0.6e-12, spot, 1e-12)
+
+	curve, err := pool.DepthCurve(ps, pool.DepthCurveParams{
+		TokenIn:    knc,
+		TokenOut:   usdt,
+		ImpactsBps: []int64{100, 500, 1000, 5000},
+	})
+	require.NoError(t, err)
+	// 1%: x of the 0.5 level such that (6 + 0.5x) / (10 + x) = 0.594
+	// 5%: x of the 0.5 level such that (6 + 0.5x) / (10 + x) = 0.57
+	// 10% and 50%: both levels, averaging 0.55
+	for i, expected := range [][2]float64{{10 + 0.06/0.094, 6 + 0.03/0.094}, {10 + 0.3/0.07, 6 + 0.15/0.07},
+		{20, 11}, {20, 11}} {
+		amountIn, _ := new(big.Float).SetInt(curve[i].AmountIn).Float64()
+		amountOut, _ := new(big.Float).SetInt(curve[i].AmountOut).Float64()
+		assert.InEpsilon(t, expected[0]*1e18, amountIn, 1e-9, "%d bps", curve[i].ImpactBps)
+		assert.InEpsilon(t, expected[1]*1e6, amountOut, 1e-6, "%d bps", curve[i].ImpactBps)
+	}
+}
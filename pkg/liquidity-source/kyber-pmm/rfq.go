@@ -119,6 +119,10 @@ func (h *RFQHandler) BatchRFQ(ctx context.Context, paramsList []pool.RFQParams)
 			return nil, fmt.Errorf("order %d error: %s", i, order.Error)
 		}
 
+		if err = pool.ValidateRFQFill(orders[i].TakerAmount, order.TakerAmount); err != nil {
+			return nil, fmt.Errorf("order %d: %w", i, err)
+		}
+
 		actualMakerAmount, _ := new(big.Int).SetString(order.MakerAmount, 10)
 		minMakerAmount, _ := new(big.Int).SetString(orders[i].MinMakerAmount, 10)
 
@@ -147,6 +151,7 @@ func (h *RFQHandler) BatchRFQ(ctx context.Context, paramsList []pool.RFQParams)
 			NewAmountOut:  actualMakerAmount,
 			AlphaFee:      alphaFee,
 			AlphaFeeAsset: order.MakerAsset,
+			Deadline:      result.Expiry,
 			Extra: RFQExtra{
 				RFQContractAddress: h.config.RFQContractAddress,
 				Info:               order.Info,
@@ -166,6 +171,10 @@ func (h *RFQHandler) BatchRFQ(ctx context.Context, paramsList []pool.RFQParams)
 		})
 	}
 
+	if err = h.config.Validation.ValidateBatch(paramsList, rfqResult); err != nil {
+		return nil, err
+	}
+
 	return rfqResult, nil
 }
 
@@ -0,0 +1,17 @@
+package lo1inch
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var LimitOrderProtocolABI abi.ABI
+
+func init() {
+	var err error
+	LimitOrderProtocolABI, err = abi.JSON(bytes.NewReader(limitOrderProtocolABIJson))
+	if err != nil {
+		panic(err)
+	}
+}
@@ -7,6 +7,9 @@ const (
 
 	PoolIDPrefix    = "lo1inch"
 	PoolIDSeparator = "_"
+
+	limitOrderProtocolMethodEpoch                  = "epoch"
+	limitOrderProtocolMethodBitInvalidatorForOrder = "bitInvalidatorForOrder"
 )
 
 var (
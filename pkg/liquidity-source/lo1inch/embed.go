@@ -0,0 +1,6 @@
+package lo1inch
+
+import _ "embed"
+
+//go:embed abis/LimitOrderProtocol.json
+var limitOrderProtocolABIJson []byte
@@ -6,4 +6,11 @@ var (
 	ErrTokenInNotSupported   = errors.New("tokenIn is not supported")
 	ErrNoOrderAvailable      = errors.New("no order available")
 	ErrCannotFulfillAmountIn = errors.New("cannot fulfill amountIn")
+	ErrInvalidRouterAddress  = errors.New("invalid router address")
+	ErrInvalidSalt           = errors.New("invalid salt")
+	ErrInvalidOrderAmounts   = errors.New("invalid order amounts")
+	ErrMissingChainID        = errors.New("missing chain id to verify orders")
+	ErrMissingEthClient      = errors.New("missing eth client to verify orders of maker contracts")
+	ErrNoDeadline            = errors.New("context without deadline to verify orders of maker contracts")
+	ErrUnverifiedSignature   = errors.New("signature of maker contract not verified through ERC-1271")
 )
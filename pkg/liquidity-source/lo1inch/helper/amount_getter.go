@@ -0,0 +1,213 @@
+package helper
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrUnsupportedAmountGetter = errors.New("unsupported amount getter")
+	ErrInvalidAmountGetterData = errors.New("invalid amount getter data")
+)
+
+var (
+	low128Mask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	e18        = big.NewInt(1e18)
+)
+
+// amountGetter computes the amounts of an order, like the IAmountGetter contracts of the extension of the order.
+type amountGetter interface {
+	getMakingAmount(o *LimitOrderV4, takingAmount, remainingMakingAmount *big.Int, timestamp int64) *big.Int
+	getTakingAmount(o *LimitOrderV4, makingAmount, remainingMakingAmount *big.Int, timestamp int64) *big.Int
+}
+
+// AmountGetters are the addresses of the amount getter contracts whose amounts are computed off-chain, the
+// DutchAuctionCalculator and RangeAmountCalculator deployments of the chain of the orders. The zero address matches no
+// getter.
+type AmountGetters struct {
+	DutchAuctionCalculator common.Address
+	RangeAmountCalculator  common.Address
+}
+
+// AmountCalculator computes the making amount of an order for a taking amount and conversely, with the amount getters
+// of the order extension if any, otherwise proportionally to the order amounts.
+type AmountCalculator struct {
+	order        *LimitOrderV4
+	makingGetter amountGetter
+	takingGetter amountGetter
+}
+
+// NewAmountCalculator returns the AmountCalculator of order with extension ext. The amount getters of ext are
+// recognized by their address among getters, then checked against their extra data: 3 words (start and end time, start
+// and end taking amount) for the DutchAuctionCalculator, and 2 words (start and end price) for the
+// RangeAmountCalculator. Other amount getters depend on contracts state and return ErrUnsupportedAmountGetter.
+func NewAmountCalculator(order *LimitOrderV4, ext *Extension, getters AmountGetters) (*AmountCalculator, error) {
+	makingGetter, err := parseAmountGetter(ext.MakingAmountData, getters)
+	if err != nil {
+		return nil, err
+	}
+	takingGetter, err := parseAmountGetter(ext.TakingAmountData, getters)
+	if err != nil {
+		return nil, err
+	}
+	return &AmountCalculator{
+		order:        order,
+		makingGetter: makingGetter,
+		takingGetter: takingGetter,
+	}, nil
+}
+
+// HasAmountGetters returns whether the amounts are computed by amount getters rather than proportionally.
+func (c *AmountCalculator) HasAmountGetters() bool {
+	return c.makingGetter != nil || c.takingGetter != nil
+}
+
+// MakingAmount returns the making amount for takingAmount at timestamp, remainingMakingAmount of the order being
+// left to fill.
+func (c *AmountCalculator) MakingAmount(takingAmount, remainingMakingAmount *big.Int, timestamp int64) *big.Int {
+	if c.makingGetter == nil {
+		// order.makingAmount * takingAmount / order.takingAmount
+		amount := new(big.Int).Mul(c.order.MakingAmount, takingAmount)
+		return amount.Quo(amount, c.order.TakingAmount)
+	}
+	return c.makingGetter.getMakingAmount(c.order, takingAmount, remainingMakingAmount, timestamp)
+}
+
+// TakingAmount returns the taking amount for makingAmount at timestamp, remainingMakingAmount of the order being
+// left to fill.
+func (c *AmountCalculator) TakingAmount(makingAmount, remainingMakingAmount *big.Int, timestamp int64) *big.Int {
+	if c.takingGetter == nil {
+		// (order.takingAmount * makingAmount).ceilDiv(order.makingAmount)
+		return ceilDiv(new(big.Int).Mul(c.order.TakingAmount, makingAmount), c.order.MakingAmount)
+	}
+	return c.takingGetter.getTakingAmount(c.order, makingAmount, remainingMakingAmount, timestamp)
+}
+
+// parseAmountGetter parses the amount getter data of an extension, the getter address followed by its extra data.
+// Empty data, for proportional amounts, returns nil.
+func parseAmountGetter(data string, getters AmountGetters) (amountGetter, error) {
+	raw := common.FromHex(data)
+	if len(raw) == 0 {
+		return nil, nil
+	} else if len(raw) < common.AddressLength {
+		return nil, ErrInvalidAmountGetterData
+	}
+
+	getter, extraData := common.BytesToAddress(raw[:common.AddressLength]), raw[common.AddressLength:]
+	if getter == (common.Address{}) {
+		return nil, ErrUnsupportedAmountGetter
+	}
+	words := make([]*big.Int, len(extraData)/common.HashLength)
+	for i := range words {
+		words[i] = new(big.Int).SetBytes(extraData[i*common.HashLength : (i+1)*common.HashLength])
+	}
+	switch getter {
+	case getters.DutchAuctionCalculator:
+		if len(extraData) != 3*common.HashLength {
+			return nil, ErrInvalidAmountGetterData
+		}
+		startTime, endTime := new(big.Int).Rsh(words[0], 128), new(big.Int).And(words[0], low128Mask)
+		if startTime.Cmp(endTime) >= 0 {
+			return nil, ErrInvalidAmountGetterData
+		}
+		return &dutchAuctionGetter{
+			startTime:         startTime,
+			endTime:           endTime,
+			takingAmountStart: words[1],
+			takingAmountEnd:   words[2],
+		}, nil
+	case getters.RangeAmountCalculator:
+		if len(extraData) != 2*common.HashLength {
+			return nil, ErrInvalidAmountGetterData
+		}
+		if words[1].Cmp(words[0]) < 0 || words[1].Sign() == 0 {
+			return nil, ErrInvalidAmountGetterData
+		}
+		return &rangeGetter{priceStart: words[0], priceEnd: words[1]}, nil
+	default:
+		return nil, ErrUnsupportedAmountGetter
+	}
+}
+
+// dutchAuctionGetter is the DutchAuctionCalculator, whose taking amount moves linearly from takingAmountStart to
+// takingAmountEnd between startTime and endTime.
+type dutchAuctionGetter struct {
+	startTime, endTime                 *big.Int
+	takingAmountStart, takingAmountEnd *big.Int
+}
+
+func (g *dutchAuctionGetter) getMakingAmount(o *LimitOrderV4, takingAmount, _ *big.Int, timestamp int64) *big.Int {
+	// order.makingAmount * takingAmount / calculatedTakingAmount
+	amount := new(big.Int).Mul(o.MakingAmount, takingAmount)
+	return amount.Quo(amount, g.auctionTakingAmount(timestamp))
+}
+
+func (g *dutchAuctionGetter) getTakingAmount(o *LimitOrderV4, makingAmount, _ *big.Int, timestamp int64) *big.Int {
+	// (calculatedTakingAmount * makingAmount).ceilDiv(order.makingAmount)
+	return ceilDiv(new(big.Int).Mul(g.auctionTakingAmount(timestamp), makingAmount), o.MakingAmount)
+}
+
+func (g *dutchAuctionGetter) auctionTakingAmount(timestamp int64) *big.Int {
+	// currentTime = max(startTime, min(endTime, block.timestamp))
+	currentTime := big.NewInt(timestamp)
+	if currentTime.Cmp(g.endTime) > 0 {
+		currentTime.Set(g.endTime)
+	}
+	if currentTime.Cmp(g.startTime) < 0 {
+		currentTime.Set(g.startTime)
+	}
+	// (takingAmountStart * (endTime - currentTime) + takingAmountEnd * (currentTime - startTime)) /
+	// (endTime - startTime)
+	amount := new(big.Int).Mul(g.takingAmountStart, new(big.Int).Sub(g.endTime, currentTime))
+	amount.Add(amount, new(big.Int).Mul(g.takingAmountEnd, new(big.Int).Sub(currentTime, g.startTime)))
+	return amount.Quo(amount, new(big.Int).Sub(g.endTime, g.startTime))
+}
+
+// rangeGetter is the RangeAmountCalculator, whose price, scaled by 1e18, moves linearly from priceStart to priceEnd
+// as the order is filled.
+type rangeGetter struct {
+	priceStart, priceEnd *big.Int
+}
+
+func (g *rangeGetter) getTakingAmount(o *LimitOrderV4, makingAmount, remainingMakingAmount *big.Int,
+	_ int64) *big.Int {
+	// ((priceEnd - priceStart) * (2 * alreadyFilledMakingAmount + makingAmount) / orderMakingAmount +
+	// 2 * priceStart) * makingAmount / 2e18
+	alreadyFilled := new(big.Int).Sub(o.MakingAmount, remainingMakingAmount)
+	amount := new(big.Int).Add(new(big.Int).Lsh(alreadyFilled, 1), makingAmount)
+	amount.Mul(amount, new(big.Int).Sub(g.priceEnd, g.priceStart))
+	amount.Quo(amount, o.MakingAmount)
+	amount.Add(amount, new(big.Int).Lsh(g.priceStart, 1))
+	amount.Mul(amount, makingAmount)
+	return amount.Quo(amount, new(big.Int).Lsh(e18, 1))
+}
+
+func (g *rangeGetter) getMakingAmount(o *LimitOrderV4, takingAmount, remainingMakingAmount *big.Int,
+	_ int64) *big.Int {
+	priceDiff := new(big.Int).Sub(g.priceEnd, g.priceStart)
+	if priceDiff.Sign() == 0 {
+		// takingAmount * 1e18 / priceStart
+		amount := new(big.Int).Mul(takingAmount, e18)
+		return amount.Quo(amount, g.priceStart)
+	}
+	// the making amount m solves getTakingAmount(m) = takingAmount:
+	// m = sqrt(b^2 + 2e18 * takingAmount * orderMakingAmount / priceDiff) - b,
+	// with b = priceStart * orderMakingAmount / priceDiff + alreadyFilledMakingAmount
+	alreadyFilled := new(big.Int).Sub(o.MakingAmount, remainingMakingAmount)
+	b := new(big.Int).Mul(g.priceStart, o.MakingAmount)
+	b.Quo(b, priceDiff).Add(b, alreadyFilled)
+	d := new(big.Int).Mul(takingAmount, o.MakingAmount)
+	d.Mul(d, new(big.Int).Lsh(e18, 1)).Quo(d, priceDiff)
+	d.Add(d, new(big.Int).Mul(b, b))
+	return d.Sqrt(d).Sub(d, b)
+}
+
+func ceilDiv(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() > 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	return q
+}
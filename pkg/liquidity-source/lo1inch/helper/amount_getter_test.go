@@ -0,0 +1,131 @@
+package helper
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAmountGetters are the amount getters the tests deploy.
+var testAmountGetters = AmountGetters{
+	DutchAuctionCalculator: common.HexToAddress("0xda"),
+	RangeAmountCalculator:  common.HexToAddress("0x4a"),
+}
+
+// amountGetterData returns the amount getter data of an extension, the getter address followed by words.
+func amountGetterData(getter common.Address, words ...*big.Int) string {
+	data := getter.Bytes()
+	for _, word := range words {
+		data = append(data, common.BigToHash(word).Bytes()...)
+	}
+	return hexutil.Encode(data)
+}
+
+func newTestAmountCalculator(t *testing.T, makingAmount, takingAmount int64, getterData string) *AmountCalculator {
+	order := newTestLimitOrderV4(common.Address{})
+	order.MakingAmount, order.TakingAmount = big.NewInt(makingAmount), big.NewInt(takingAmount)
+	ext := DefaultExtension()
+	ext.MakingAmountData, ext.TakingAmountData = getterData, getterData
+	calculator, err := NewAmountCalculator(order, ext, testAmountGetters)
+	require.NoError(t, err)
+	return calculator
+}
+
+func TestAmountCalculator_DutchAuction(t *testing.T) {
+	times := new(big.Int).Lsh(big.NewInt(1000), 128)
+	times.Or(times, big.NewInt(2000))
+	calculator := newTestAmountCalculator(t, 100, 2000,
+		amountGetterData(testAmountGetters.DutchAuctionCalculator, times, big.NewInt(2000), big.NewInt(1000)))
+	require.True(t, calculator.HasAmountGetters())
+
+	tests := []struct {
+		name              string
+		timestamp         int64
+		wantTakingAmount  int64
+		wantMakingForHalf int64
+	}{
+		{"before start", 500, 2000, 50},
+		{"start", 1000, 2000, 50},
+		{"middle", 1500, 1500, 66},
+		{"end", 2000, 1000, 100},
+		{"after end", 3000, 1000, 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := big.NewInt(100)
+			assert.Equal(t, big.NewInt(tt.wantTakingAmount),
+				calculator.TakingAmount(big.NewInt(100), remaining, tt.timestamp))
+			assert.Equal(t, big.NewInt(100),
+				calculator.MakingAmount(big.NewInt(tt.wantTakingAmount), remaining, tt.timestamp))
+			assert.Equal(t, big.NewInt(tt.wantMakingForHalf),
+				calculator.MakingAmount(big.NewInt(1000), remaining, tt.timestamp))
+		})
+	}
+}
+
+func TestAmountCalculator_Range(t *testing.T) {
+	e18 := big.NewInt(1e18)
+	calculator := newTestAmountCalculator(t, 1000, 1500,
+		amountGetterData(testAmountGetters.RangeAmountCalculator, e18, new(big.Int).Mul(e18, big.NewInt(2))))
+
+	tests := []struct {
+		name         string
+		remaining    int64
+		makingAmount int64
+		takingAmount int64
+	}{
+		{"fill the whole order", 1000, 1000, 1500},
+		{"fill the first half", 1000, 500, 625},
+		{"fill the second half", 500, 500, 875},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := big.NewInt(tt.remaining)
+			assert.Equal(t, big.NewInt(tt.takingAmount),
+				calculator.TakingAmount(big.NewInt(tt.makingAmount), remaining, 0))
+			assert.Equal(t, big.NewInt(tt.makingAmount),
+				calculator.MakingAmount(big.NewInt(tt.takingAmount), remaining, 0))
+		})
+	}
+}
+
+func TestNewAmountCalculator(t *testing.T) {
+	calculator := newTestAmountCalculator(t, 3, 10, ZX)
+	assert.False(t, calculator.HasAmountGetters())
+	assert.Equal(t, big.NewInt(1), calculator.MakingAmount(big.NewInt(5), big.NewInt(3), 0))
+	assert.Equal(t, big.NewInt(4), calculator.TakingAmount(big.NewInt(1), big.NewInt(3), 0))
+
+	endBeforeStart := new(big.Int).Lsh(big.NewInt(2), 128)
+	endBeforeStart.Or(endBeforeStart, big.NewInt(1))
+
+	order := newTestLimitOrderV4(common.Address{})
+	for name, tt := range map[string]struct {
+		data    string
+		wantErr error
+	}{
+		"auction with 1 word": {amountGetterData(testAmountGetters.DutchAuctionCalculator, big.NewInt(1)),
+			ErrInvalidAmountGetterData},
+		"unknown getter with auction data": {amountGetterData(common.HexToAddress("0x1234"), big.NewInt(1),
+			big.NewInt(2), big.NewInt(1)), ErrUnsupportedAmountGetter},
+		"zero getter": {amountGetterData(common.Address{}, big.NewInt(1), big.NewInt(2)),
+			ErrUnsupportedAmountGetter},
+		"short getter": {"0x1234", ErrInvalidAmountGetterData},
+		"auction end < start": {amountGetterData(testAmountGetters.DutchAuctionCalculator, endBeforeStart,
+			big.NewInt(2), big.NewInt(1)), ErrInvalidAmountGetterData},
+		"range with auction data": {amountGetterData(testAmountGetters.RangeAmountCalculator, big.NewInt(1),
+			big.NewInt(2), big.NewInt(1)), ErrInvalidAmountGetterData},
+		"decreasing range": {amountGetterData(testAmountGetters.RangeAmountCalculator, big.NewInt(2), big.NewInt(1)),
+			ErrInvalidAmountGetterData},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ext := DefaultExtension()
+			ext.TakingAmountData = tt.data
+			_, err := NewAmountCalculator(order, ext, testAmountGetters)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
@@ -24,7 +24,7 @@ func (b *BytesIter) NextUint256() string {
 }
 
 func (b *BytesIter) NextBytes(n int) string {
-	if b.pos+n*2 > len(b.data) {
+	if n < 0 || b.pos+n*2 > len(b.data) {
 		return ""
 	}
 	result := b.data[b.pos : b.pos+n*2]
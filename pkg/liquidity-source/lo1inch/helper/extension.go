@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -163,9 +164,9 @@ func (e *Extension) IsEmpty() bool {
 }
 
 func (e *Extension) Keccak256() *big.Int {
-	encoded := e.Encode()
+	encoded := common.FromHex(e.Encode())
 	hash := sha3.NewLegacyKeccak256()
-	hash.Write([]byte(encoded))
+	hash.Write(encoded)
 	result := hash.Sum(nil)
 	value := new(big.Int).SetBytes(result)
 	return value
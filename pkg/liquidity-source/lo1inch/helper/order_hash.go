@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/eth"
+)
+
+const (
+	// EIP-712 domain of the 1inch Aggregation Router v6, which hosts the Limit Order Protocol v4
+	limitOrderV4DomainName    = "1inch Aggregation Router"
+	limitOrderV4DomainVersion = "6"
+)
+
+var limitOrderV4TypeHash = crypto.Keccak256Hash([]byte("Order(uint256 salt,address maker,address receiver," +
+	"address makerAsset,address takerAsset,uint256 makingAmount,uint256 takingAmount,uint256 makerTraits)"))
+
+var uint160Mask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+
+var (
+	ErrMissingOrderExtension    = errors.New("missing order extension")
+	ErrUnexpectedOrderExtension = errors.New("unexpected order extension")
+	ErrInvalidExtensionHash     = errors.New("invalid extension hash")
+	ErrInvalidOrderHash         = errors.New("invalid order hash")
+	ErrInvalidOrderSignature    = errors.New("invalid order signature")
+)
+
+// LimitOrderV4 is an order of the 1inch Limit Order Protocol v4 as signed by its maker.
+type LimitOrderV4 struct {
+	Salt         *big.Int
+	Maker        common.Address
+	Receiver     common.Address
+	MakerAsset   common.Address
+	TakerAsset   common.Address
+	MakingAmount *big.Int
+	TakingAmount *big.Int
+	MakerTraits  *MakerTraits
+}
+
+// Hash returns the EIP-712 hash of the order, for the router deployed at routerAddress on chainID.
+func (o *LimitOrderV4) Hash(chainID uint64, routerAddress common.Address) (common.Hash, error) {
+	domainSeparator, err := eth.EIP712DomainSeparator(limitOrderV4DomainName, limitOrderV4DomainVersion, chainID,
+		routerAddress)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	structHash, err := eth.EIP712StructHash(limitOrderV4TypeHash,
+		o.Salt, o.Maker, o.Receiver, o.MakerAsset, o.TakerAsset, o.MakingAmount, o.TakingAmount,
+		o.MakerTraits.Build())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return eth.EIP712Hash(domainSeparator, structHash), nil
+}
+
+// ValidateExtension checks the extension of the order against its maker traits and salt, the lowest 160 bits of which
+// must be those of the extension hash, as OrderLib.isValidExtension does.
+func (o *LimitOrderV4) ValidateExtension(extension string) error {
+	hasExtension := len(common.FromHex(extension)) > 0
+	if !o.MakerTraits.HasExtension() {
+		if hasExtension {
+			return ErrUnexpectedOrderExtension
+		}
+		return nil
+	} else if !hasExtension {
+		return ErrMissingOrderExtension
+	}
+
+	ext, err := DecodeExtension(extension)
+	if err != nil {
+		return err
+	}
+	extHash := new(big.Int).And(ext.Keccak256(), uint160Mask)
+	if extHash.Cmp(new(big.Int).And(o.Salt, uint160Mask)) != 0 {
+		return ErrInvalidExtensionHash
+	}
+	return nil
+}
+
+// VerifySignature checks that signature of orderHash was produced by the order maker: recovered from its compact
+// form as the router does for EOA makers, or accepted by the maker contract through ERC-1271, called with caller.
+func (o *LimitOrderV4) VerifySignature(ctx context.Context, caller ethereum.ContractCaller, isMakerContract bool,
+	orderHash common.Hash, signature string) error {
+	if isMakerContract {
+		ok, err := eth.IsValidERC1271Signature(ctx, caller, o.Maker, orderHash, common.FromHex(signature))
+		if err != nil {
+			return err
+		} else if !ok {
+			return ErrInvalidOrderSignature
+		}
+		return nil
+	}
+
+	sig, err := LO1inchParseSignature(signature)
+	if err != nil {
+		return err
+	}
+	if !eth.IsValidECDSASignature(o.Maker, orderHash, sig.GetCompactedSignatureBytes()) {
+		return ErrInvalidOrderSignature
+	}
+	return nil
+}
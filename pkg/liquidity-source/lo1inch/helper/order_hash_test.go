@@ -0,0 +1,113 @@
+package helper
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var routerAddress = common.HexToAddress("0x111111125421ca6dc452d289314280a0f8842a65")
+
+func newTestLimitOrderV4(maker common.Address) *LimitOrderV4 {
+	return &LimitOrderV4{
+		Salt:         big.NewInt(54304030),
+		Maker:        maker,
+		MakerAsset:   common.HexToAddress("0xdac17f958d2ee523a2206206994597c13d831ec7"),
+		TakerAsset:   common.HexToAddress("0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"),
+		MakingAmount: big.NewInt(10000),
+		TakingAmount: big.NewInt(101),
+		MakerTraits:  DefaultMakerTraits(),
+	}
+}
+
+func TestLimitOrderV4_VerifySignature(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("signature of an order from the 1inch API", func(t *testing.T) {
+		order := newTestLimitOrderV4(common.HexToAddress("0xdf4039a454d58868dfd43f076ee46c92a35fdfd9"))
+		orderHash := common.HexToHash("0x177af74e4d3880743ac6603323a9a50f6999968e499f44966dd00d642e933285")
+		signature := "0x3f31467bce6bb134944a8c3c57a8c2786ffadf31a7c39cb22a9c51cceb7e3c0f7ed91bba74a8227aae8933fa72cc8c6e3796bd4c4e734fcbe22bf5061ef9e8971c"
+
+		assert.NoError(t, order.VerifySignature(ctx, nil, false, orderHash, signature))
+		assert.ErrorIs(t, order.VerifySignature(ctx, nil, false, common.Hash{1}, signature), ErrInvalidOrderSignature)
+	})
+
+	t.Run("signature of the order hash", func(t *testing.T) {
+		key, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		order := newTestLimitOrderV4(crypto.PubkeyToAddress(key.PublicKey))
+		orderHash, err := order.Hash(1, routerAddress)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(orderHash[:], key)
+		require.NoError(t, err)
+		signature := hexutil.Encode(sig)
+
+		assert.NoError(t, order.VerifySignature(ctx, nil, false, orderHash, signature))
+
+		order.TakingAmount = big.NewInt(100)
+		assert.NotEqual(t, orderHash, lo.Must(order.Hash(1, routerAddress)))
+		assert.ErrorIs(t, order.VerifySignature(ctx, nil, false, lo.Must(order.Hash(1, routerAddress)), signature),
+			ErrInvalidOrderSignature)
+		assert.NotEqual(t, orderHash, lo.Must(newTestLimitOrderV4(order.Maker).Hash(137, routerAddress)))
+	})
+
+	t.Run("contract maker without caller", func(t *testing.T) {
+		order := newTestLimitOrderV4(common.HexToAddress("0x1234"))
+		assert.Error(t, order.VerifySignature(ctx, nil, true, lo.Must(order.Hash(1, routerAddress)), "0x"))
+	})
+}
+
+func TestLimitOrderV4_ValidateExtension(t *testing.T) {
+	ext, err := NewExtension(ExtensionData{
+		MakerAssetSuffix: ZX,
+		TakerAssetSuffix: ZX,
+		MakingAmountData: ZX,
+		TakingAmountData: ZX,
+		Predicate:        "0x04",
+		MakerPermit:      ZX,
+		PreInteraction:   ZX,
+		PostInteraction:  ZX,
+		CustomData:       ZX,
+	})
+	require.NoError(t, err)
+	extension := ext.Encode()
+
+	extHash := ext.Keccak256()
+	assert.Equal(t, crypto.Keccak256(common.FromHex(extension)), common.LeftPadBytes(extHash.Bytes(), 32))
+
+	// the salt keeps the lowest 160 bits of the extension hash
+	salt := new(big.Int).And(extHash, uint160Mask)
+	salt.Or(salt, new(big.Int).Lsh(big.NewInt(42), 160))
+
+	tests := []struct {
+		name         string
+		salt         *big.Int
+		hasExtension bool
+		extension    string
+		wantErr      error
+	}{
+		{"no extension", salt, false, ZX, nil},
+		{"empty extension", salt, false, "", nil},
+		{"valid extension", salt, true, extension, nil},
+		{"unexpected extension", salt, false, extension, ErrUnexpectedOrderExtension},
+		{"missing extension", salt, true, ZX, ErrMissingOrderExtension},
+		{"invalid extension hash", big.NewInt(54304030), true, extension, ErrInvalidExtensionHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newTestLimitOrderV4(common.Address{})
+			order.Salt = tt.salt
+			if tt.hasExtension {
+				order.MakerTraits.WithExtension()
+			}
+			assert.ErrorIs(t, order.ValidateExtension(tt.extension), tt.wantErr)
+		})
+	}
+}
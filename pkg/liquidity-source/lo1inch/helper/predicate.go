@@ -0,0 +1,288 @@
+package helper
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+var (
+	ErrUnsupportedPredicate = errors.New("unsupported predicate")
+	ErrInvalidPredicate     = errors.New("invalid predicate")
+	ErrUnknownEpoch         = errors.New("unknown maker epoch")
+)
+
+// PredicateContext is the chain state predicates are evaluated at.
+type PredicateContext struct {
+	Timestamp   int64
+	BlockNumber uint64
+	// Epoch returns the current epoch of a maker for a series, as returned by the router epoch(address,uint96). It
+	// returns false if the epoch is unknown.
+	Epoch func(maker common.Address, series *big.Int) (*big.Int, bool)
+}
+
+// predicateMethod is a view method of the router, or of a multicall contract through arbitraryStaticCall, that
+// predicates can static call.
+type predicateMethod struct {
+	args abi.Arguments
+	eval func(ctx *PredicateContext, args []any) (*big.Int, error)
+	// calls returns the nested calls of the method, if any
+	calls func(args []any) ([][]byte, error)
+}
+
+// MakerSeries identifies the epoch of a maker for a series.
+type MakerSeries struct {
+	Maker  common.Address
+	Series *big.Int
+}
+
+var (
+	uint256Ty, _ = abi.NewType("uint256", "", nil)
+	uint96Ty, _  = abi.NewType("uint96", "", nil)
+	addressTy, _ = abi.NewType("address", "", nil)
+	bytesTy, _   = abi.NewType("bytes", "", nil)
+
+	predicateMethods map[[4]byte]*predicateMethod
+
+	epochMethodID       = abiutil.GenMethodID("epoch", []string{"address", "uint96"})
+	epochEqualsMethodID = abiutil.GenMethodID("epochEquals", []string{"address", "uint256", "uint256"})
+	// envMethods are the methods of the block environment that can be called through arbitraryStaticCall, whatever
+	// the target, like those of Multicall3.
+	envMethods map[[4]byte]func(ctx *PredicateContext) *big.Int
+)
+
+func init() {
+	predicateMethods = map[[4]byte]*predicateMethod{
+		abiutil.GenMethodID("or", []string{"uint256", "bytes"}): {
+			args: abi.Arguments{{Type: uint256Ty}, {Type: bytesTy}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				return evalOffsets(ctx, args[0].(*big.Int), args[1].([]byte), true)
+			},
+			calls: offsetCalls,
+		},
+		abiutil.GenMethodID("and", []string{"uint256", "bytes"}): {
+			args: abi.Arguments{{Type: uint256Ty}, {Type: bytesTy}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				return evalOffsets(ctx, args[0].(*big.Int), args[1].([]byte), false)
+			},
+			calls: offsetCalls,
+		},
+		abiutil.GenMethodID("not", []string{"bytes"}): {
+			args: abi.Arguments{{Type: bytesTy}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				res, err := evalCall(ctx, args[0].([]byte))
+				if err != nil {
+					return nil, err
+				}
+				return boolToInt(res.Sign() == 0), nil
+			},
+			calls: func(args []any) ([][]byte, error) {
+				return [][]byte{args[0].([]byte)}, nil
+			},
+		},
+		abiutil.GenMethodID("eq", []string{"uint256", "bytes"}): compareMethod(func(res, value *big.Int) bool {
+			return res.Cmp(value) == 0
+		}),
+		abiutil.GenMethodID("lt", []string{"uint256", "bytes"}): compareMethod(func(res, value *big.Int) bool {
+			return res.Cmp(value) < 0
+		}),
+		abiutil.GenMethodID("gt", []string{"uint256", "bytes"}): compareMethod(func(res, value *big.Int) bool {
+			return res.Cmp(value) > 0
+		}),
+		abiutil.GenMethodID("timestampBelow", []string{"uint256"}): {
+			args: abi.Arguments{{Type: uint256Ty}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				return boolToInt(big.NewInt(ctx.Timestamp).Cmp(args[0].(*big.Int)) < 0), nil
+			},
+		},
+		epochMethodID: {
+			args: abi.Arguments{{Type: addressTy}, {Type: uint96Ty}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				return getEpoch(ctx, args[0].(common.Address), args[1].(*big.Int))
+			},
+		},
+		epochEqualsMethodID: {
+			args: abi.Arguments{{Type: addressTy}, {Type: uint256Ty}, {Type: uint256Ty}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				epoch, err := getEpoch(ctx, args[0].(common.Address), args[1].(*big.Int))
+				if err != nil {
+					return nil, err
+				}
+				return boolToInt(epoch.Cmp(args[2].(*big.Int)) == 0), nil
+			},
+		},
+		abiutil.GenMethodID("arbitraryStaticCall", []string{"address", "bytes"}): {
+			args: abi.Arguments{{Type: addressTy}, {Type: bytesTy}},
+			eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+				data := args[1].([]byte)
+				if len(data) != 4 {
+					return nil, ErrUnsupportedPredicate
+				}
+				envMethod, ok := envMethods[[4]byte(data)]
+				if !ok {
+					return nil, ErrUnsupportedPredicate
+				}
+				return envMethod(ctx), nil
+			},
+		},
+	}
+
+	envMethods = map[[4]byte]func(ctx *PredicateContext) *big.Int{
+		abiutil.GenMethodID("getCurrentBlockTimestamp", nil): func(ctx *PredicateContext) *big.Int {
+			return big.NewInt(ctx.Timestamp)
+		},
+		abiutil.GenMethodID("getBlockNumber", nil): func(ctx *PredicateContext) *big.Int {
+			return new(big.Int).SetUint64(ctx.BlockNumber)
+		},
+	}
+}
+
+// EvaluatePredicate returns whether predicate, the calldata of a static call to the router returning 1 for fillable
+// orders, holds in ctx. It interprets the boolean and comparison helpers of the router, timestamp and epoch checks,
+// and block timestamp and number getters called through arbitraryStaticCall. Other calls, whose result depends on
+// contracts state, return ErrUnsupportedPredicate.
+func EvaluatePredicate(ctx *PredicateContext, predicate string) (bool, error) {
+	res, err := evalCall(ctx, common.FromHex(predicate))
+	if err != nil {
+		return false, err
+	}
+	return res.Cmp(big.NewInt(1)) == 0, nil
+}
+
+// evalCall returns the uint256 result of the static call of data to the router.
+func evalCall(ctx *PredicateContext, data []byte) (*big.Int, error) {
+	if len(data) < 4 {
+		return nil, ErrInvalidPredicate
+	}
+	method, ok := predicateMethods[[4]byte(data)]
+	if !ok {
+		return nil, ErrUnsupportedPredicate
+	}
+	args, err := method.args.Unpack(data[4:])
+	if err != nil {
+		return nil, ErrInvalidPredicate
+	}
+	return method.eval(ctx, args)
+}
+
+// evalOffsets evaluates the calls packed in data, each ending at the next uint32 of offsets until a zero one, as the
+// or and and predicates: or holds when any call returns 1, and when all do.
+func evalOffsets(ctx *PredicateContext, offsets *big.Int, data []byte, or bool) (*big.Int, error) {
+	offsets = new(big.Int).Set(offsets)
+	mask := new(big.Int).SetUint64(UINT_32_MAX)
+	previous := uint64(0)
+	for ; ; offsets.Rsh(offsets, 32) {
+		current := new(big.Int).And(offsets, mask).Uint64()
+		if current == 0 {
+			break
+		} else if current < previous || current > uint64(len(data)) {
+			return nil, ErrInvalidPredicate
+		}
+		res, err := evalCall(ctx, data[previous:current])
+		if err != nil {
+			return nil, err
+		}
+		if isOne := res.Cmp(big.NewInt(1)) == 0; isOne == or {
+			return boolToInt(or), nil
+		}
+		previous = current
+	}
+	return boolToInt(!or), nil
+}
+
+func compareMethod(cmp func(res, value *big.Int) bool) *predicateMethod {
+	return &predicateMethod{
+		args: abi.Arguments{{Type: uint256Ty}, {Type: bytesTy}},
+		eval: func(ctx *PredicateContext, args []any) (*big.Int, error) {
+			res, err := evalCall(ctx, args[1].([]byte))
+			if err != nil {
+				return nil, err
+			}
+			return boolToInt(cmp(res, args[0].(*big.Int))), nil
+		},
+		calls: func(args []any) ([][]byte, error) {
+			return [][]byte{args[1].([]byte)}, nil
+		},
+	}
+}
+
+// PredicateEpochs returns the epochs predicate depends on, in all its branches, for them to be known to
+// EvaluatePredicate.
+func PredicateEpochs(predicate string) ([]MakerSeries, error) {
+	var epochs []MakerSeries
+	err := walkCall(common.FromHex(predicate), func(methodID [4]byte, args []any) {
+		if methodID == epochMethodID || methodID == epochEqualsMethodID {
+			epochs = append(epochs, MakerSeries{Maker: args[0].(common.Address), Series: args[1].(*big.Int)})
+		}
+	})
+	return epochs, err
+}
+
+// walkCall visits the static call of data to the router and its nested calls.
+func walkCall(data []byte, visit func(methodID [4]byte, args []any)) error {
+	if len(data) < 4 {
+		return ErrInvalidPredicate
+	}
+	method, ok := predicateMethods[[4]byte(data)]
+	if !ok {
+		return ErrUnsupportedPredicate
+	}
+	args, err := method.args.Unpack(data[4:])
+	if err != nil {
+		return ErrInvalidPredicate
+	}
+	visit([4]byte(data), args)
+	if method.calls == nil {
+		return nil
+	}
+	calls, err := method.calls(args)
+	if err != nil {
+		return err
+	}
+	for _, call := range calls {
+		if err = walkCall(call, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offsetCalls returns the calls packed in the data of the or and and predicates, see evalOffsets.
+func offsetCalls(args []any) ([][]byte, error) {
+	offsets, data := new(big.Int).Set(args[0].(*big.Int)), args[1].([]byte)
+	mask := new(big.Int).SetUint64(UINT_32_MAX)
+	var calls [][]byte
+	previous := uint64(0)
+	for ; ; offsets.Rsh(offsets, 32) {
+		current := new(big.Int).And(offsets, mask).Uint64()
+		if current == 0 {
+			return calls, nil
+		} else if current < previous || current > uint64(len(data)) {
+			return nil, ErrInvalidPredicate
+		}
+		calls = append(calls, data[previous:current])
+		previous = current
+	}
+}
+
+func getEpoch(ctx *PredicateContext, maker common.Address, series *big.Int) (*big.Int, error) {
+	if ctx.Epoch == nil {
+		return nil, ErrUnknownEpoch
+	}
+	epoch, ok := ctx.Epoch(maker, series)
+	if !ok {
+		return nil, ErrUnknownEpoch
+	}
+	return epoch, nil
+}
+
+func boolToInt(b bool) *big.Int {
+	if b {
+		return big.NewInt(1)
+	}
+	return big.NewInt(0)
+}
@@ -0,0 +1,128 @@
+package helper
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+// packCall returns the calldata of the router method name with args of types.
+func packCall(t *testing.T, name string, types []string, args ...any) []byte {
+	arguments := make(abi.Arguments, len(types))
+	for i, typ := range types {
+		abiType, err := abi.NewType(typ, "", nil)
+		require.NoError(t, err)
+		arguments[i] = abi.Argument{Type: abiType}
+	}
+	packed, err := arguments.Pack(args...)
+	require.NoError(t, err)
+	methodID := abiutil.GenMethodID(name, types)
+	return append(methodID[:], packed...)
+}
+
+// packJoin returns the calldata of the router or or and method of calls.
+func packJoin(t *testing.T, name string, calls ...[]byte) []byte {
+	offsets, end := new(big.Int), 0
+	var data []byte
+	for i, call := range calls {
+		end += len(call)
+		offsets.Or(offsets, new(big.Int).Lsh(big.NewInt(int64(end)), uint(32*i)))
+		data = append(data, call...)
+	}
+	return packCall(t, name, []string{"uint256", "bytes"}, offsets, data)
+}
+
+func TestEvaluatePredicate(t *testing.T) {
+	maker := common.HexToAddress("0xdf4039a454d58868dfd43f076ee46c92a35fdfd9")
+	ctx := &PredicateContext{
+		Timestamp:   1732175620,
+		BlockNumber: 21234567,
+		Epoch: func(m common.Address, series *big.Int) (*big.Int, bool) {
+			if m != maker || series.Sign() != 0 {
+				return nil, false
+			}
+			return big.NewInt(3), true
+		},
+	}
+
+	timestampBelow := func(timestamp int64) []byte {
+		return packCall(t, "timestampBelow", []string{"uint256"}, big.NewInt(timestamp))
+	}
+	getBlockNumberID := abiutil.GenMethodID("getBlockNumber", nil)
+	getBlockNumber := packCall(t, "arbitraryStaticCall", []string{"address", "bytes"}, common.Address{},
+		getBlockNumberID[:])
+	unsupported := packCall(t, "arbitraryStaticCall", []string{"address", "bytes"}, common.Address{},
+		packCall(t, "balanceOf", []string{"address"}, maker))
+
+	tests := []struct {
+		name      string
+		predicate []byte
+		want      bool
+		wantErr   error
+	}{
+		{"timestamp below", timestampBelow(1732175621), true, nil},
+		{"timestamp not below", timestampBelow(1732175620), false, nil},
+		{"not", packCall(t, "not", []string{"bytes"}, timestampBelow(1732175620)), true, nil},
+		{"and", packJoin(t, "and", timestampBelow(1732175621), timestampBelow(1732175620)), false, nil},
+		{"or", packJoin(t, "or", timestampBelow(1732175620), timestampBelow(1732175621)), true, nil},
+		{"block number lt", packCall(t, "lt", []string{"uint256", "bytes"}, big.NewInt(21234568), getBlockNumber),
+			true, nil},
+		{"block number gt", packCall(t, "gt", []string{"uint256", "bytes"}, big.NewInt(21234567), getBlockNumber),
+			false, nil},
+		{"block number eq", packCall(t, "eq", []string{"uint256", "bytes"}, big.NewInt(21234567), getBlockNumber),
+			true, nil},
+		{"epoch equals",
+			packCall(t, "epochEquals", []string{"address", "uint256", "uint256"}, maker, big.NewInt(0), big.NewInt(3)),
+			true, nil},
+		{"epoch not equals",
+			packCall(t, "epochEquals", []string{"address", "uint256", "uint256"}, maker, big.NewInt(0), big.NewInt(2)),
+			false, nil},
+		{"unknown epoch",
+			packCall(t, "epochEquals", []string{"address", "uint256", "uint256"}, maker, big.NewInt(1), big.NewInt(0)),
+			false, ErrUnknownEpoch},
+		{"unsupported static call", unsupported, false, ErrUnsupportedPredicate},
+		{"unsupported in or", packJoin(t, "or", unsupported, timestampBelow(1732175621)), false,
+			ErrUnsupportedPredicate},
+		{"short-circuit or", packJoin(t, "or", timestampBelow(1732175621), unsupported), true, nil},
+		{"invalid", []byte{0x01, 0x02}, false, ErrInvalidPredicate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EvaluatePredicate(ctx, hexutil.Encode(tt.predicate))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPredicateEpochs(t *testing.T) {
+	maker := common.HexToAddress("0xdf4039a454d58868dfd43f076ee46c92a35fdfd9")
+	timestampBelow := packCall(t, "timestampBelow", []string{"uint256"}, big.NewInt(1732175621))
+	epochEquals := packCall(t, "epochEquals", []string{"address", "uint256", "uint256"}, maker, big.NewInt(1),
+		big.NewInt(3))
+	epoch := packCall(t, "epoch", []string{"address", "uint96"}, maker, big.NewInt(2))
+
+	epochs, err := PredicateEpochs(hexutil.Encode(packJoin(t, "or", timestampBelow,
+		packCall(t, "not", []string{"bytes"}, epochEquals),
+		packCall(t, "eq", []string{"uint256", "bytes"}, big.NewInt(3), epoch))))
+	require.NoError(t, err)
+	assert.Equal(t, []MakerSeries{{maker, big.NewInt(1)}, {maker, big.NewInt(2)}}, epochs)
+
+	epochs, err = PredicateEpochs(hexutil.Encode(timestampBelow))
+	require.NoError(t, err)
+	assert.Empty(t, epochs)
+
+	_, err = PredicateEpochs("0x0102")
+	assert.ErrorIs(t, err, ErrInvalidPredicate)
+}
@@ -0,0 +1,141 @@
+package lo1inch
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/holiman/uint256"
+
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
+)
+
+// fillableOrder is an order that can be filled at the time of a swap, with the amount getters of its extension if any.
+type fillableOrder struct {
+	*Order
+
+	// amounts computes the amounts of orders with amount getters, nil for orders with proportional amounts
+	amounts *helper1inch.AmountCalculator
+}
+
+// makerEpochKey returns the key of the epoch of maker for series in Extra.MakerEpochs.
+func makerEpochKey(maker string, series *big.Int) string {
+	return strings.ToLower(maker) + ":" + series.String()
+}
+
+// makerBitInvalidatorKey returns the key of the invalidator of the nonces of maker in slot in
+// Extra.MakerBitInvalidators.
+func makerBitInvalidatorKey(maker string, slot *big.Int) string {
+	return strings.ToLower(maker) + ":" + slot.String()
+}
+
+// isNonceInvalidated returns whether nonce of maker is known to be invalidated, by a fill or a cancellation.
+func (p *PoolSimulator) isNonceInvalidated(maker string, nonce *big.Int) bool {
+	invalidator, ok := p.makerBitInvalidators[makerBitInvalidatorKey(maker, new(big.Int).Rsh(nonce, 8))]
+	if !ok || invalidator == nil {
+		return false
+	}
+	bit := uint(nonce.Uint64() & 0xff)
+	return new(uint256.Int).Rsh(invalidator, bit).Uint64()&1 == 1
+}
+
+// hasExtension returns whether order has an extension.
+func hasExtension(order *Order) bool {
+	return order.Extension != "" && order.Extension != helper1inch.ZX
+}
+
+// extension returns the decoded extension of order, or nil if it is invalid. Extensions are decoded once by setOrders,
+// and only decoded here for pools encoded before they were cached.
+func (p *PoolSimulator) extension(order *Order) *helper1inch.Extension {
+	if ext, ok := p.extensions[order.OrderHash]; ok {
+		return ext
+	}
+	ext, _ := helper1inch.DecodeExtension(order.Extension)
+	return ext
+}
+
+func (p *PoolSimulator) epoch(maker common.Address, series *big.Int) (*big.Int, bool) {
+	epoch, ok := p.makerEpochs[makerEpochKey(maker.Hex(), series)]
+	if !ok || epoch == nil {
+		return nil, false
+	}
+	return epoch.ToBig(), true
+}
+
+// fillableOrders returns the orders fillable at timestamp: orders neither expired, nor invalidated by an epoch
+// increase of their maker or by their nonce, and whose predicate holds. Orders with a predicate or amount getters that cannot be
+// evaluated off-chain, including getters other than the amount getters of the pool, are skipped. The epoch of orders whose maker epoch is unknown is not checked.
+func (p *PoolSimulator) fillableOrders(orders []*Order, timestamp int64) []fillableOrder {
+	predicateCtx := &helper1inch.PredicateContext{
+		Timestamp:   timestamp,
+		BlockNumber: p.Info.BlockNumber,
+		Epoch:       p.epoch,
+	}
+
+	fillable := make([]fillableOrder, 0, len(orders))
+	for _, order := range orders {
+		makerTraits := helper1inch.NewMakerTraits(order.MakerTraits)
+		// Filter out expired orders
+		// Note: This is different from pool-service, we don't have any buffer here because when we simulate the order, real-time is important
+		if makerTraits.IsExpired(timestamp) {
+			continue
+		}
+
+		if makerTraits.IsEpochManagerEnabled() {
+			epoch, ok := p.epoch(common.HexToAddress(order.Maker), makerTraits.Series())
+			if ok && epoch.Cmp(makerTraits.NonceOrEpoch()) != 0 {
+				continue
+			}
+		} else if makerTraits.IsBitInvalidatorMode() && p.isNonceInvalidated(order.Maker, makerTraits.NonceOrEpoch()) {
+			continue
+		}
+
+		if !hasExtension(order) {
+			fillable = append(fillable, fillableOrder{Order: order})
+			continue
+		}
+
+		ext := p.extension(order)
+		if ext == nil {
+			continue
+		}
+		if ext.HasPredicate() {
+			if ok, err := helper1inch.EvaluatePredicate(predicateCtx, ext.Predicate); err != nil || !ok {
+				continue
+			}
+		}
+
+		limitOrder, err := toLimitOrderV4(order)
+		if err != nil {
+			continue
+		}
+		amounts, err := helper1inch.NewAmountCalculator(limitOrder, ext, p.amountGetters)
+		if err != nil {
+			continue
+		}
+		if !amounts.HasAmountGetters() {
+			amounts = nil
+		}
+		fillable = append(fillable, fillableOrder{Order: order, amounts: amounts})
+	}
+
+	return fillable
+}
+
+// takingAmount returns the taking amount of the order for makingAmount at timestamp.
+func (o fillableOrder) takingAmount(makingAmount *uint256.Int, timestamp int64) (*uint256.Int, bool) {
+	if o.amounts == nil {
+		// order.TakingAmount * makingAmount / order.MakingAmount
+		return new(uint256.Int).MulDivOverflow(makingAmount, o.TakingAmount, o.MakingAmount)
+	}
+	return uint256.FromBig(o.amounts.TakingAmount(makingAmount.ToBig(), o.RemainingMakerAmount.ToBig(), timestamp))
+}
+
+// makingAmount returns the making amount of the order for takingAmount at timestamp.
+func (o fillableOrder) makingAmount(takingAmount *uint256.Int, timestamp int64) (*uint256.Int, bool) {
+	if o.amounts == nil {
+		// order.MakingAmount * takingAmount / order.TakingAmount
+		return new(uint256.Int).MulDivOverflow(takingAmount, o.MakingAmount, o.TakingAmount)
+	}
+	return uint256.FromBig(o.amounts.MakingAmount(takingAmount.ToBig(), o.RemainingMakerAmount.ToBig(), timestamp))
+}
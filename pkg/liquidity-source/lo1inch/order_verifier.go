@@ -0,0 +1,122 @@
+package lo1inch
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	"github.com/KyberNetwork/logger"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/samber/lo"
+
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
+)
+
+// orderVerifier recomputes the EIP-712 hash of the orders returned by the order API and checks their extension and
+// maker signature, so that altered orders are not routed.
+type orderVerifier struct {
+	chainID       uint64
+	routerAddress common.Address
+	caller        ethereum.ContractCaller
+}
+
+// verifyOffline checks the hash and extension of order and, unless its maker is a contract, its ECDSA signature. It
+// returns whether the signature is left to check through ERC-1271 with verifyERC1271.
+func (v *orderVerifier) verifyOffline(order *Order) (bool, error) {
+	limitOrder, err := toLimitOrderV4(order)
+	if err != nil {
+		return false, err
+	}
+	if err = limitOrder.ValidateExtension(order.Extension); err != nil {
+		return false, err
+	}
+	orderHash, err := limitOrder.Hash(v.chainID, v.routerAddress)
+	if err != nil {
+		return false, err
+	} else if orderHash != common.HexToHash(order.OrderHash) {
+		return false, helper1inch.ErrInvalidOrderHash
+	} else if order.IsMakerContract {
+		return true, nil
+	}
+	return false, limitOrder.VerifySignature(context.Background(), nil, false, orderHash, order.Signature)
+}
+
+// verifyERC1271 checks that the maker contract of order, verified offline, accepts its signature, calling its ERC-1271
+// isValidSignature.
+func (v *orderVerifier) verifyERC1271(ctx context.Context, order *Order) error {
+	limitOrder, err := toLimitOrderV4(order)
+	if err != nil {
+		return err
+	}
+	return limitOrder.VerifySignature(ctx, v.caller, true, common.HexToHash(order.OrderHash), order.Signature)
+}
+
+// unverifiedOrders holds the orders of a PoolSimulator built by NewERC1271VerifiedPoolSimulator until the signatures of
+// their maker contracts are checked through ERC-1271 by VerifyContractSignatures.
+type unverifiedOrders struct {
+	verifier *orderVerifier
+	// the orders verified offline, in the order of the order API, to route the verified ones in the same order
+	takeToken0Orders, takeToken1Orders []*Order
+	// orders of maker contracts, to check through ERC-1271
+	pending []*Order
+}
+
+// verifyOffline returns the orders verified offline, adding those of maker contracts to the pending ones if their
+// signature is checked through ERC-1271, and logging the others.
+func (u *unverifiedOrders) verifyOffline(orders []*Order) []*Order {
+	return lo.Filter(orders, func(order *Order, _ int) bool {
+		pending, err := u.verifier.verifyOffline(order)
+		if err == nil && pending && u.verifier.caller == nil {
+			err = ErrUnverifiedSignature
+		}
+		if err != nil {
+			logSkippedOrder(order, err)
+			return false
+		} else if pending {
+			u.pending = append(u.pending, order)
+		}
+		return true
+	})
+}
+
+// routable returns orders without the pending ones.
+func (u *unverifiedOrders) routable(orders []*Order) []*Order {
+	return lo.Without(orders, u.pending...)
+}
+
+func logSkippedOrder(order *Order, err error) {
+	logger.WithFields(logger.Fields{
+		"orderHash": order.OrderHash,
+		"maker":     order.Maker,
+		"error":     err,
+	}).Warn("skip lo1inch order failing verification")
+}
+
+func toLimitOrderV4(order *Order) (*helper1inch.LimitOrderV4, error) {
+	salt, ok := parseUint(order.Salt)
+	if !ok {
+		return nil, ErrInvalidSalt
+	}
+	if order.MakingAmount == nil || order.TakingAmount == nil {
+		return nil, ErrInvalidOrderAmounts
+	}
+	return &helper1inch.LimitOrderV4{
+		Salt:         salt,
+		Maker:        common.HexToAddress(order.Maker),
+		Receiver:     common.HexToAddress(order.Receiver),
+		MakerAsset:   common.HexToAddress(order.MakerAsset),
+		TakerAsset:   common.HexToAddress(order.TakerAsset),
+		MakingAmount: order.MakingAmount.ToBig(),
+		TakingAmount: order.TakingAmount.ToBig(),
+		MakerTraits:  helper1inch.NewMakerTraits(order.MakerTraits),
+	}, nil
+}
+
+// parseUint parses a decimal or 0x-prefixed hexadecimal unsigned integer.
+func parseUint(s string) (*big.Int, bool) {
+	if hex, ok := strings.CutPrefix(s, "0x"); ok {
+		return new(big.Int).SetString(hex, 16)
+	}
+	return new(big.Int).SetString(s, 10)
+}
@@ -1,22 +1,29 @@
 package lo1inch
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
-	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/integer"
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/logger"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
 	"github.com/holiman/uint256"
+	"github.com/samber/lo"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
 	utils "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/big256"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
 )
 
 type PoolSimulator struct {
@@ -38,11 +45,108 @@ type PoolSimulator struct {
 	minBalanceAllowanceByMakerAndAsset map[makerAndAsset]*uint256.Int
 
 	routerAddress string
+
+	// current epochs of makers by makerEpochKey, for epoch manager orders and epoch predicates
+	makerEpochs map[string]*uint256.Int
+
+	// current nonce invalidators of makers by makerBitInvalidatorKey, for bit invalidator orders
+	makerBitInvalidators map[string]*uint256.Int
+
+	// decoded extensions of the routed orders by order hash, nil for the orders whose extension is invalid
+	extensions map[string]*helper1inch.Extension
+
+	// amount getters whose amounts are computed off-chain, the orders with other getters being skipped
+	amountGetters helper1inch.AmountGetters
+
+	// orders of maker contracts to verify with VerifyContractSignatures before they are routed, not encoded
+	unverified *unverifiedOrders `msgpack:"-"`
 }
 
-var _ = pool.RegisterFactory0(DexType, NewPoolSimulator)
+var _ = pool.RegisterFactory(DexType, NewVerifiedPoolSimulator)
 
+// NewPoolSimulator returns a PoolSimulator trusting the orders of entityPool as returned by the order API.
 func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
+	return newPoolSimulator(entityPool, nil)
+}
+
+// NewVerifiedPoolSimulator returns a PoolSimulator with only the orders of params.EntityPool whose hash, extension and
+// ECDSA maker signature are valid for the router on params.ChainID, checked offline. The orders of maker contracts,
+// whose signature can only be checked on-chain, are skipped: callers opt in to route them by building pools with
+// NewERC1271VerifiedPoolSimulator instead.
+func NewVerifiedPoolSimulator(params pool.FactoryParams) (*PoolSimulator, error) {
+	return newVerifiedPoolSimulator(params, nil)
+}
+
+// NewERC1271VerifiedPoolSimulator returns a PoolSimulator verifying orders as NewVerifiedPoolSimulator, except that the
+// orders of maker contracts are routed once their signature is accepted by VerifyContractSignatures, which calls them
+// with params.EthClient.
+func NewERC1271VerifiedPoolSimulator(params pool.FactoryParams) (*PoolSimulator, error) {
+	if params.EthClient == nil {
+		return nil, ErrMissingEthClient
+	}
+	return newVerifiedPoolSimulator(params, params.EthClient)
+}
+
+// newVerifiedPoolSimulator returns a PoolSimulator with the orders of params.EntityPool verified offline, checking the
+// signatures of maker contracts with caller, or skipping their orders if it is nil.
+func newVerifiedPoolSimulator(params pool.FactoryParams, caller ethereum.ContractCaller) (*PoolSimulator, error) {
+	if params.ChainID == 0 {
+		return nil, ErrMissingChainID
+	}
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(params.EntityPool.StaticExtra), &staticExtra); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(staticExtra.RouterAddress) {
+		return nil, ErrInvalidRouterAddress
+	}
+	return newPoolSimulator(params.EntityPool, &orderVerifier{
+		chainID:       uint64(params.ChainID),
+		routerAddress: common.HexToAddress(staticExtra.RouterAddress),
+		caller:        caller,
+	})
+}
+
+// VerifyContractSignatures checks through ERC-1271 the signatures of the orders of maker contracts verified offline by
+// NewERC1271VerifiedPoolSimulator, routing those accepted and dropping the others. ctx must have a deadline, bounding the
+// calls to the maker contracts. The orders whose check fails stay unrouted, and can be checked again. It must be
+// called before the PoolSimulator is used to route.
+func (p *PoolSimulator) VerifyContractSignatures(ctx context.Context) error {
+	if p.unverified == nil {
+		return nil
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return ErrNoDeadline
+	}
+
+	var errs []error
+	var rejected []*Order
+	p.unverified.pending = lo.Filter(p.unverified.pending, func(order *Order, _ int) bool {
+		err := p.unverified.verifier.verifyERC1271(ctx, order)
+		switch {
+		case err == nil:
+			return false
+		case errors.Is(err, helper1inch.ErrInvalidOrderSignature):
+			logSkippedOrder(order, err)
+			rejected = append(rejected, order)
+			return false
+		default:
+			errs = append(errs, fmt.Errorf("order %s: %w", order.OrderHash, err))
+			return true
+		}
+	})
+
+	p.unverified.takeToken0Orders = lo.Without(p.unverified.takeToken0Orders, rejected...)
+	p.unverified.takeToken1Orders = lo.Without(p.unverified.takeToken1Orders, rejected...)
+	p.setOrders(p.unverified.routable(p.unverified.takeToken0Orders),
+		p.unverified.routable(p.unverified.takeToken1Orders))
+	if len(p.unverified.pending) == 0 {
+		p.unverified = nil
+	}
+	return errors.Join(errs...)
+}
+
+func newPoolSimulator(entityPool entity.Pool, verifier *orderVerifier) (*PoolSimulator, error) {
 	var numTokens = len(entityPool.Tokens)
 	var tokens = make([]string, numTokens)
 	var reserves = make([]*big.Int, numTokens)
@@ -63,49 +167,80 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 	if err := json.Unmarshal([]byte(entityPool.Extra), &extra); err != nil {
 		return nil, err
 	}
+	var unverified *unverifiedOrders
+	if verifier != nil {
+		unverified = &unverifiedOrders{verifier: verifier}
+		unverified.takeToken0Orders = unverified.verifyOffline(extra.TakeToken0Orders)
+		unverified.takeToken1Orders = unverified.verifyOffline(extra.TakeToken1Orders)
+		extra.TakeToken0Orders = unverified.routable(unverified.takeToken0Orders)
+		extra.TakeToken1Orders = unverified.routable(unverified.takeToken1Orders)
+		if len(unverified.pending) == 0 {
+			unverified = nil
+		}
+	}
 
-	takeToken0OrdersMapping := make(map[string]int, len(extra.TakeToken0Orders))
-	takeToken1OrdersMapping := make(map[string]int, len(extra.TakeToken1Orders))
+	p := &PoolSimulator{
+		Pool: pool.Pool{
+			Info: pool.PoolInfo{
+				Address:     strings.ToLower(entityPool.Address),
+				ReserveUsd:  entityPool.ReserveUsd,
+				SwapFee:     integer.Zero(),
+				Exchange:    entityPool.Exchange,
+				Type:        entityPool.Type,
+				Tokens:      tokens,
+				Reserves:    reserves,
+				Checked:     false,
+				BlockNumber: entityPool.BlockNumber,
+			},
+		},
+		token0:               staticExtra.Token0,
+		token1:               staticExtra.Token1,
+		routerAddress:        staticExtra.RouterAddress,
+		makerEpochs:          extra.MakerEpochs,
+		makerBitInvalidators: extra.MakerBitInvalidators,
+		amountGetters: helper1inch.AmountGetters{
+			DutchAuctionCalculator: common.HexToAddress(staticExtra.DutchAuctionCalculator),
+			RangeAmountCalculator:  common.HexToAddress(staticExtra.RangeAmountCalculator),
+		},
+		unverified: unverified,
+	}
+	p.setOrders(extra.TakeToken0Orders, extra.TakeToken1Orders)
+	return p, nil
+}
+
+// setOrders sets the orders routed by the pool.
+func (p *PoolSimulator) setOrders(takeToken0Orders, takeToken1Orders []*Order) {
+	takeToken0OrdersMapping := make(map[string]int, len(takeToken0Orders))
+	takeToken1OrdersMapping := make(map[string]int, len(takeToken1Orders))
 
-	numOrders := len(extra.TakeToken0Orders) + len(extra.TakeToken1Orders)
+	numOrders := len(takeToken0Orders) + len(takeToken1Orders)
 	minBalanceAllowanceByMakerAndAsset := make(map[makerAndAsset]*uint256.Int, numOrders)
 
-	for i, takeToken0Order := range extra.TakeToken0Orders {
+	for i, takeToken0Order := range takeToken0Orders {
 		takeToken0OrdersMapping[takeToken0Order.OrderHash] = i
 
 		// get min(balance, allowance) for this maker:makerAsset pair
 		minBalanceAllowanceByMakerAndAsset[newMakerAndAsset(takeToken0Order.Maker, takeToken0Order.MakerAsset)] = utils.Min(takeToken0Order.MakerBalance, takeToken0Order.MakerAllowance)
 	}
 
-	for i, takeToken1Order := range extra.TakeToken1Orders {
+	for i, takeToken1Order := range takeToken1Orders {
 		takeToken1OrdersMapping[takeToken1Order.OrderHash] = i
 
 		// get min(balance, allowance) for this maker:makerAsset pair
 		minBalanceAllowanceByMakerAndAsset[newMakerAndAsset(takeToken1Order.Maker, takeToken1Order.MakerAsset)] = utils.Min(takeToken1Order.MakerBalance, takeToken1Order.MakerAllowance)
 	}
 
-	return &PoolSimulator{
-		Pool: pool.Pool{
-			Info: pool.PoolInfo{
-				Address:    strings.ToLower(entityPool.Address),
-				ReserveUsd: entityPool.ReserveUsd,
-				SwapFee:    integer.Zero(),
-				Exchange:   entityPool.Exchange,
-				Type:       entityPool.Type,
-				Tokens:     tokens,
-				Reserves:   reserves,
-				Checked:    false,
-			},
-		},
-		token0:                             staticExtra.Token0,
-		token1:                             staticExtra.Token1,
-		takeToken0Orders:                   extra.TakeToken0Orders,
-		takeToken1Orders:                   extra.TakeToken1Orders,
-		takeToken0OrdersMapping:            takeToken0OrdersMapping,
-		takeToken1OrdersMapping:            takeToken1OrdersMapping,
-		minBalanceAllowanceByMakerAndAsset: minBalanceAllowanceByMakerAndAsset,
-		routerAddress:                      staticExtra.RouterAddress,
-	}, nil
+	extensions := make(map[string]*helper1inch.Extension, numOrders)
+	for _, order := range slices.Concat(takeToken0Orders, takeToken1Orders) {
+		if hasExtension(order) {
+			extensions[order.OrderHash], _ = helper1inch.DecodeExtension(order.Extension)
+		}
+	}
+
+	p.takeToken0Orders, p.takeToken1Orders = takeToken0Orders, takeToken1Orders
+	p.extensions = extensions
+	p.takeToken0OrdersMapping, p.takeToken1OrdersMapping = takeToken0OrdersMapping, takeToken1OrdersMapping
+	p.minBalanceAllowanceByMakerAndAsset = minBalanceAllowanceByMakerAndAsset
 }
 
 func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
@@ -122,6 +257,12 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		return nil, ErrNoOrderAvailable
 	}
 
+	// calculate current time once so we don't have to re-calculate it for each order
+	currentTime := param.Now()
+
+	// skip orders that cannot be filled at currentTime, and price those with amount getters at currentTime
+	fillableOrders := p.fillableOrders(orders, currentTime)
+
 	totalAmountOut := number.Set(number.Zero)
 	remainingAmountIn := number.SetFromBig(tokenAmountIn.Amount)
 
@@ -142,17 +283,7 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 
 	totalMakingAmount := number.Set(number.Zero)
 
-	// calculate current time once so we don't have to re-calculate it for each order
-	currentTime := time.Now().Unix()
-
-	for i, order := range orders {
-		makerTraits := helper1inch.NewMakerTraits(order.MakerTraits)
-		// Filter out expired orders
-		// Note: This is different from pool-service, we don't have any buffer here because when we simulate the order, real-time is important
-		if makerTraits.IsExpired(currentTime) {
-			continue
-		}
-
+	for i, order := range fillableOrders {
 		orderRemainingMakingAmount := order.RemainingMakerAmount
 
 		// the actual available balance might be less than `order.RemainingMakerAmount`
@@ -172,25 +303,26 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		}
 
 		// calculate order's remaining taking amount
-		// orderRemainingTakingAmount = order.TakingAmount * orderRemainingMakingAmount / order.MakingAmount
-		orderRemainingTakingAmount := number.Set(order.TakingAmount)
-		orderRemainingTakingAmount.Mul(orderRemainingTakingAmount, orderRemainingMakingAmount)
-		orderRemainingTakingAmount.Div(orderRemainingTakingAmount, order.MakingAmount)
+		orderRemainingTakingAmount, overflow := order.takingAmount(orderRemainingMakingAmount, currentTime)
+		if overflow {
+			continue
+		}
 
 		totalMakingAmount.Add(totalMakingAmount, orderRemainingMakingAmount)
 
 		// Case 1: This order can fulfill the remaining amount in
 		if orderRemainingTakingAmount.Cmp(remainingAmountIn) >= 0 {
-			orderAmountOut, overflow := new(uint256.Int).MulDivOverflow(
-				remainingAmountIn,
-				order.MakingAmount,
-				order.TakingAmount,
-			)
-
+			orderAmountOut, overflow := order.makingAmount(remainingAmountIn, currentTime)
 			if overflow {
 				continue
 			}
 
+			// amount getters round the making amount independently of the taking amount, keep it in the order's
+			// remaining making amount
+			if orderAmountOut.Gt(orderRemainingMakingAmount) {
+				orderAmountOut = number.Set(orderRemainingMakingAmount)
+			}
+
 			// order too small
 			if orderAmountOut.Sign() <= 0 {
 				continue
@@ -201,7 +333,7 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			orderFilledMakingAmount := number.Set(orderAmountOut)
 			orderFilledTakingAmount := number.Set(remainingAmountIn)
 			filledOrderInfo := newFilledOrderInfo(
-				order,
+				order.Order,
 				orderFilledMakingAmount,
 				orderFilledTakingAmount,
 			)
@@ -221,12 +353,12 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			// From that, the estimated amount out and filled orders are not correct. So we need to add more "backup" orders when sending to SC to the executor.
 			// In this case, we will send some orders util total MakingAmount(remainMakingAmount)/estimated amountOut >= 1.3 (130%)
 			totalAmountOutBF := new(big.Float).SetInt(totalAmountOut.ToBig())
-			for j := i + 1; j < len(orders); j++ {
+			for j := i + 1; j < len(fillableOrders); j++ {
 				if new(big.Float).SetInt(totalMakingAmount.ToBig()).Cmp(new(big.Float).Mul(totalAmountOutBF, FallbackPercentageOfTotalMakingAmount)) >= 0 {
 					break
 				}
 
-				order := orders[j]
+				order := fillableOrders[j]
 
 				orderRemainingMakingAmount := number.Set(order.RemainingMakerAmount)
 				if makerRemainingBalance := getMakerRemainingBalance(
@@ -244,7 +376,7 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 
 				totalMakingAmount.Add(totalMakingAmount, orderRemainingMakingAmount)
 				filledOrderInfo := newFilledOrderInfo(
-					order,
+					order.Order,
 					utils.ZeroBI,
 					utils.ZeroBI,
 				)
@@ -270,7 +402,7 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		orderFilledTakingAmount := orderRemainingTakingAmount // because this order is fully filled
 		totalAmountOut = number.Add(totalAmountOut, orderFilledMakingAmount)
 		filledOrderInfo := newFilledOrderInfo(
-			order,
+			order.Order,
 			orderFilledMakingAmount,
 			orderFilledTakingAmount,
 		)
@@ -402,7 +534,7 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 		if params.SwapLimit != nil {
 			_, _, _ = params.SwapLimit.UpdateLimit(
 				newMakerAndAsset(order.Maker, order.MakerAsset),
-				newMakerAndAsset(order.Maker, order.TakerAsset),
+				newMakerAndAsset(order.receiver(), order.TakerAsset),
 				filledOrderInfo.FilledMakingAmount.ToBig(),
 				filledOrderInfo.FilledTakingAmount.ToBig(),
 			)
@@ -449,12 +581,42 @@ func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 
 // Inventory Limit
 
+// receiver returns the receiver of the taker asset of the order: its Receiver, or its maker if unset.
+func (o *Order) receiver() string {
+	if len(o.Receiver) == 0 || strings.EqualFold(o.Receiver, valueobject.ZeroAddress) {
+		return o.Maker
+	}
+	return o.Receiver
+}
+
 type makerAndAsset = string
 
 func newMakerAndAsset(maker, makerAsset string) makerAndAsset {
 	return fmt.Sprintf("%v:%v", maker, makerAsset)
 }
 
+var _ swaplimit.MakerAssetPool = (*PoolSimulator)(nil)
+
+// MakerAssets returns the maker assets of the orders of the pool, spent by the router, whose balances and allowances
+// swaplimit.MakerBalanceTracker refreshes.
+func (p *PoolSimulator) MakerAssets() []swaplimit.MakerAsset {
+	seen := make(map[string]struct{}, len(p.minBalanceAllowanceByMakerAndAsset))
+	res := make([]swaplimit.MakerAsset, 0, len(p.minBalanceAllowanceByMakerAndAsset))
+	for _, order := range slices.Concat(p.takeToken0Orders, p.takeToken1Orders) {
+		key := swaplimit.MakerAssetKey(order.Maker, order.MakerAsset)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, swaplimit.MakerAsset{
+			Maker:    order.Maker,
+			Asset:    order.MakerAsset,
+			Spenders: []string{p.routerAddress},
+		})
+	}
+	return res
+}
+
 func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 	count := len(p.minBalanceAllowanceByMakerAndAsset)
 	if count == 0 {
@@ -1,10 +1,19 @@
 package lo1inch
 
 import (
+	"context"
 	"fmt"
+	"maps"
 	"math/big"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/goccy/go-json"
 	"github.com/holiman/uint256"
 	"github.com/samber/lo"
@@ -13,8 +22,10 @@ import (
 
 	"github.com/KyberNetwork/blockchain-toolkit/integer"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
 )
 
 func TestPoolSimulator_CalcAmountOut_RealPool(t *testing.T) {
@@ -708,3 +719,337 @@ func TestPoolSimulator_Inventory(t *testing.T) {
 		}
 	}
 }
+
+func TestPoolSimulator_UpdateBalance_Receiver(t *testing.T) {
+	newOrder := func(hash, receiver string) *Order {
+		return &Order{
+			OrderHash:            hash,
+			Maker:                "maker",
+			Receiver:             receiver,
+			MakerAsset:           "B",
+			TakerAsset:           "A",
+			MakingAmount:         uint256.NewInt(100),
+			TakingAmount:         uint256.NewInt(1000),
+			RemainingMakerAmount: uint256.NewInt(100),
+			MakerBalance:         uint256.NewInt(1000),
+			MakerAllowance:       uint256.NewInt(1000),
+		}
+	}
+	extra, err := json.Marshal(Extra{TakeToken0Orders: []*Order{
+		newOrder("receiver", "receiver"),
+		newOrder("maker", "0x0000000000000000000000000000000000000000"),
+	}})
+	require.NoError(t, err)
+	sim, err := NewPoolSimulator(entity.Pool{
+		Tokens:      []*entity.PoolToken{{Address: "A"}, {Address: "B"}},
+		Reserves:    entity.PoolReserves{"0", "0"},
+		StaticExtra: `{"token0":"A","token1":"B"}`,
+		Extra:       string(extra),
+	})
+	require.NoError(t, err)
+
+	limit := swaplimit.NewInventory("", sim.CalculateLimit())
+	tokenAmountIn := pool.TokenAmount{Token: "A", Amount: big.NewInt(1500)}
+	res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{TokenAmountIn: tokenAmountIn, TokenOut: "B", Limit: limit})
+	require.NoError(t, err)
+	sim.UpdateBalance(pool.UpdateBalanceParams{
+		TokenAmountIn:  tokenAmountIn,
+		TokenAmountOut: *res.TokenAmountOut,
+		Fee:            *res.Fee,
+		SwapInfo:       res.SwapInfo,
+		SwapLimit:      limit,
+	})
+
+	// the taker asset of each order goes to its receiver
+	assert.Equal(t, big.NewInt(1000), limit.GetLimit(newMakerAndAsset("receiver", "A")))
+	assert.Equal(t, big.NewInt(500), limit.GetLimit(newMakerAndAsset("maker", "A")))
+	assert.Equal(t, big.NewInt(850), limit.GetLimit(newMakerAndAsset("maker", "B")))
+}
+
+type erc1271Caller struct {
+	contract common.Address
+	err      error
+}
+
+func (c *erc1271Caller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+// CallContract accepts any signature for c.contract, as a multisig maker contract approving all its orders would, or
+// fails with c.err if set.
+func (c *erc1271Caller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	result := make([]byte, common.HashLength)
+	if *msg.To == c.contract {
+		copy(result, msg.Data[:4])
+	}
+	return result, nil
+}
+
+func TestNewVerifiedPoolSimulator(t *testing.T) {
+	const routerAddress = "0x111111125421ca6dc452d289314280a0f8842a65"
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	maker := crypto.PubkeyToAddress(key.PublicKey)
+	makerContract := common.HexToAddress("0x1234")
+	orderHash := func(order *Order) common.Hash {
+		limitOrder, err := toLimitOrderV4(order)
+		require.NoError(t, err)
+		return lo.Must(limitOrder.Hash(1, common.HexToAddress(routerAddress)))
+	}
+
+	newOrder := func(salt string, maker common.Address) *Order {
+		order := &Order{
+			Salt:                 salt,
+			Maker:                strings.ToLower(maker.Hex()),
+			Receiver:             "0x0000000000000000000000000000000000000000",
+			MakerAsset:           "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			TakerAsset:           "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			MakingAmount:         uint256.NewInt(10000),
+			TakingAmount:         uint256.NewInt(101),
+			RemainingMakerAmount: uint256.NewInt(10000),
+			MakerBalance:         uint256.NewInt(10000),
+			MakerAllowance:       uint256.NewInt(10000),
+		}
+		hash := orderHash(order)
+		sig, err := crypto.Sign(hash[:], key)
+		require.NoError(t, err)
+		order.OrderHash = hash.Hex()
+		order.Signature = hexutil.Encode(sig)
+		return order
+	}
+
+	valid := newOrder("1", maker)
+	tamperedAmount := newOrder("2", maker)
+	tamperedAmount.TakingAmount = uint256.NewInt(1)
+	tamperedHash := newOrder("3", maker)
+	tamperedHash.MakingAmount = uint256.NewInt(20000)
+	tamperedHash.OrderHash = orderHash(tamperedHash).Hex()
+	wrongSigner := newOrder("4", maker)
+	wrongSigner.Maker = "0xdf4039a454d58868dfd43f076ee46c92a35fdfd9"
+	contractMaker := newOrder("5", makerContract)
+	contractMaker.IsMakerContract = true
+	rejectingContractMaker := newOrder("6", common.HexToAddress("0x5678"))
+	rejectingContractMaker.IsMakerContract = true
+
+	extra, err := json.Marshal(Extra{
+		TakeToken0Orders: []*Order{tamperedAmount, valid, tamperedHash},
+		TakeToken1Orders: []*Order{wrongSigner, contractMaker, rejectingContractMaker},
+	})
+	require.NoError(t, err)
+	entityPool := entity.Pool{
+		Address:  "lo1inch_0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48_0xdac17f958d2ee523a2206206994597c13d831ec7",
+		Exchange: "lo1inch",
+		Type:     DexType,
+		Tokens: []*entity.PoolToken{
+			{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+			{Address: "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		},
+		Reserves: entity.PoolReserves{"0", "0"},
+		StaticExtra: `{"token0":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",` +
+			`"token1":"0xdac17f958d2ee523a2206206994597c13d831ec7","routerAddress":"` + routerAddress + `"}`,
+		Extra: string(extra),
+	}
+	ethClient := &erc1271Caller{contract: makerContract}
+	orderHashes := func(orders []*Order) []string {
+		return lo.Map(orders, func(o *Order, _ int) string { return o.OrderHash })
+	}
+
+	trusted, err := NewPoolSimulator(entityPool)
+	require.NoError(t, err)
+	assert.Len(t, trusted.takeToken0Orders, 3)
+	assert.Len(t, trusted.takeToken1Orders, 3)
+
+	t.Run("offline", func(t *testing.T) {
+		// the registered factory verifies orders offline, skipping those of maker contracts
+		poolSim, err := pool.Factory(DexType)(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		require.NoError(t, err)
+		verified, ok := poolSim.(*PoolSimulator)
+		require.True(t, ok)
+		assert.Equal(t, []string{valid.OrderHash}, orderHashes(verified.takeToken0Orders))
+		assert.Equal(t, map[string]int{valid.OrderHash: 0}, verified.takeToken0OrdersMapping)
+		assert.Empty(t, verified.takeToken1Orders)
+		assert.Nil(t, verified.unverified)
+		assert.NoError(t, verified.VerifyContractSignatures(context.Background()))
+
+		otherChain, err := NewVerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, ChainID: 137})
+		require.NoError(t, err)
+		assert.Empty(t, otherChain.takeToken0Orders)
+	})
+
+	t.Run("contract signatures", func(t *testing.T) {
+		verified, err := NewERC1271VerifiedPoolSimulator(pool.FactoryParams{
+			EntityPool: entityPool,
+			ChainID:    1,
+			EthClient:  ethClient,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{valid.OrderHash}, orderHashes(verified.takeToken0Orders))
+		assert.Empty(t, verified.takeToken1Orders)
+		assert.ErrorIs(t, verified.VerifyContractSignatures(context.Background()), ErrNoDeadline)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, verified.VerifyContractSignatures(ctx))
+		assert.Equal(t, []string{valid.OrderHash}, orderHashes(verified.takeToken0Orders))
+		assert.Equal(t, []string{contractMaker.OrderHash}, orderHashes(verified.takeToken1Orders))
+		assert.Equal(t, map[string]int{contractMaker.OrderHash: 0}, verified.takeToken1OrdersMapping)
+		assert.Nil(t, verified.unverified)
+	})
+
+	t.Run("failing contract calls", func(t *testing.T) {
+		failingClient := &erc1271Caller{contract: makerContract, err: context.DeadlineExceeded}
+		verified, err := NewERC1271VerifiedPoolSimulator(pool.FactoryParams{
+			EntityPool: entityPool,
+			ChainID:    1,
+			EthClient:  failingClient,
+		})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.ErrorIs(t, verified.VerifyContractSignatures(ctx), context.DeadlineExceeded)
+		assert.Empty(t, verified.takeToken1Orders)
+
+		// the orders whose check failed are checked again
+		failingClient.err = nil
+		require.NoError(t, verified.VerifyContractSignatures(ctx))
+		assert.Equal(t, []string{contractMaker.OrderHash}, orderHashes(verified.takeToken1Orders))
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := NewVerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool})
+		assert.ErrorIs(t, err, ErrMissingChainID)
+		_, err = NewERC1271VerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, EthClient: ethClient})
+		assert.ErrorIs(t, err, ErrMissingChainID)
+		_, err = NewERC1271VerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		assert.ErrorIs(t, err, ErrMissingEthClient)
+
+		entityPool := entityPool
+		entityPool.StaticExtra = `{"token0":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",` +
+			`"token1":"0xdac17f958d2ee523a2206206994597c13d831ec7"}`
+		_, err = NewVerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		assert.ErrorIs(t, err, ErrInvalidRouterAddress)
+	})
+}
+
+func TestPoolSimulator_CalcAmountOut_Extensions(t *testing.T) {
+	const maker = "0xdf4039a454d58868dfd43f076ee46c92a35fdfd9"
+	newOrder := func(hash string, takingAmount uint64, makerTraits *helper1inch.MakerTraits,
+		extension helper1inch.ExtensionData) *Order {
+		ext, err := helper1inch.NewExtension(extension)
+		require.NoError(t, err)
+		if !ext.IsEmpty() {
+			makerTraits.WithExtension()
+		}
+		return &Order{
+			OrderHash:            hash,
+			Salt:                 "1",
+			Maker:                maker,
+			MakerAsset:           "B",
+			TakerAsset:           "A",
+			MakingAmount:         uint256.NewInt(100),
+			TakingAmount:         uint256.NewInt(takingAmount),
+			RemainingMakerAmount: uint256.NewInt(100),
+			MakerBalance:         uint256.NewInt(1000),
+			MakerAllowance:       uint256.NewInt(1000),
+			MakerTraits:          "0x" + makerTraits.Build().Text(16),
+			Extension:            ext.Encode(),
+		}
+	}
+	noExtension := func() helper1inch.ExtensionData {
+		return helper1inch.ExtensionData{
+			MakerAssetSuffix: helper1inch.ZX, TakerAssetSuffix: helper1inch.ZX,
+			MakingAmountData: helper1inch.ZX, TakingAmountData: helper1inch.ZX,
+			Predicate: helper1inch.ZX, MakerPermit: helper1inch.ZX,
+			PreInteraction: helper1inch.ZX, PostInteraction: helper1inch.ZX, CustomData: helper1inch.ZX,
+		}
+	}
+
+	// proportional order fillable before 1200
+	timestampBelowID := abiutil.GenMethodID("timestampBelow", []string{"uint256"})
+	predicateExt := noExtension()
+	predicateExt.Predicate = hexutil.Encode(append(timestampBelowID[:], common.BigToHash(big.NewInt(1200)).Bytes()...))
+	beforeDeadline := newOrder("predicate", 1000, helper1inch.DefaultMakerTraits(), predicateExt)
+
+	// order of epoch 1, the maker being at epoch 2
+	invalidated := newOrder("epoch", 100, helper1inch.DefaultMakerTraits().AllowMultipleFills().
+		WithEpoch(big.NewInt(0), big.NewInt(1)), noExtension())
+
+	// order of nonce 257, invalidated by a fill or cancellation
+	filled := newOrder("nonce", 100, helper1inch.DefaultMakerTraits().WithNonce(big.NewInt(257)), noExtension())
+
+	// auction from 2000 at 1000 to 1000 at 2000
+	auctionTimes := new(big.Int).Lsh(big.NewInt(1000), 128)
+	auctionTimes.Or(auctionTimes, big.NewInt(2000))
+	const dutchAuctionCalculator = "0x00000000000000000000000000000000000000da"
+	auctionData := hexutil.Encode(slices.Concat(common.HexToAddress(dutchAuctionCalculator).Bytes(),
+		common.BigToHash(auctionTimes).Bytes(), common.BigToHash(big.NewInt(2000)).Bytes(),
+		common.BigToHash(big.NewInt(1000)).Bytes()))
+	auctionExt := noExtension()
+	auctionExt.MakingAmountData, auctionExt.TakingAmountData = auctionData, auctionData
+	auction := newOrder("auction", 2000, helper1inch.DefaultMakerTraits(), auctionExt)
+
+	extra, err := json.Marshal(Extra{
+		TakeToken0Orders:     []*Order{beforeDeadline, invalidated, filled, auction},
+		MakerEpochs:          map[string]*uint256.Int{makerEpochKey(maker, big.NewInt(0)): uint256.NewInt(2)},
+		MakerBitInvalidators: map[string]*uint256.Int{makerBitInvalidatorKey(maker, big.NewInt(1)): uint256.NewInt(2)},
+	})
+	require.NoError(t, err)
+	entityPool := entity.Pool{
+		Tokens:      []*entity.PoolToken{{Address: "A"}, {Address: "B"}},
+		Reserves:    entity.PoolReserves{"0", "0"},
+		StaticExtra: `{"token0":"A","token1":"B","dutchAuctionCalculator":"` + dutchAuctionCalculator + `"}`,
+		Extra:       string(extra),
+	}
+	sim, err := NewPoolSimulator(entityPool)
+	require.NoError(t, err)
+	// extensions are decoded once
+	assert.Equal(t, []string{"auction", "predicate"}, slices.Sorted(maps.Keys(sim.extensions)))
+
+	tests := []struct {
+		name           string
+		timestamp      int64
+		amountIn       int64
+		expAmountOut   int64
+		expOrderHashes []string
+	}{
+		{"predicate holds", 1100, 500, 50, []string{"predicate"}},
+		{"fill both orders", 1100, 2000, 152, []string{"predicate", "auction"}}, // auction at 1900 for 100
+		{"predicate fails, auction in progress", 1500, 750, 50, []string{"auction"}},
+		{"auction ended", 2500, 500, 50, []string{"auction"}},
+		{"auction cannot fill", 2500, 1001, 0, nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+				TokenAmountIn: pool.TokenAmount{Token: "A", Amount: big.NewInt(tc.amountIn)},
+				TokenOut:      "B",
+				Limit:         swaplimit.NewInventory("", sim.CalculateLimit()),
+				Timestamp:     tc.timestamp,
+			})
+			if tc.expOrderHashes == nil {
+				assert.ErrorIs(t, err, ErrCannotFulfillAmountIn)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, big.NewInt(tc.expAmountOut), res.TokenAmountOut.Amount)
+			assert.Equal(t, tc.expOrderHashes, lo.Map(res.SwapInfo.(SwapInfo).FilledOrders,
+				func(o *FilledOrderInfo, _ int) string { return o.OrderHash }))
+		})
+	}
+
+	t.Run("unknown amount getter", func(t *testing.T) {
+		entityPool.StaticExtra = `{"token0":"A","token1":"B"}`
+		sim, err := NewPoolSimulator(entityPool)
+		require.NoError(t, err)
+		_, err = sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: "A", Amount: big.NewInt(500)},
+			TokenOut:      "B",
+			Limit:         swaplimit.NewInventory("", sim.CalculateLimit()),
+			Timestamp:     2500,
+		})
+		assert.ErrorIs(t, err, ErrCannotFulfillAmountIn)
+	})
+}
@@ -0,0 +1,165 @@
+package lo1inch
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/KyberNetwork/ethrpc"
+	"github.com/KyberNetwork/logger"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
+)
+
+// PoolTracker tracks the router state the orders of a pool depend on: the epochs of the makers of epoch manager orders
+// and of epoch predicates, and the nonce invalidators of the makers of bit invalidator orders.
+type PoolTracker struct {
+	ethrpcClient *ethrpc.Client
+}
+
+var _ = pooltrack.RegisterFactoryE0(DexType, NewPoolTracker)
+
+func NewPoolTracker(ethrpcClient *ethrpc.Client) *PoolTracker {
+	return &PoolTracker{
+		ethrpcClient: ethrpcClient,
+	}
+}
+
+// makerSlot is a slot of the nonce invalidators of a maker.
+type makerSlot struct {
+	Maker common.Address
+	Slot  *big.Int
+}
+
+func (t *PoolTracker) GetNewPoolState(
+	ctx context.Context,
+	p entity.Pool,
+	_ pool.GetNewPoolStateParams,
+) (entity.Pool, error) {
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(p.StaticExtra), &staticExtra); err != nil {
+		return p, err
+	}
+	if !common.IsHexAddress(staticExtra.RouterAddress) {
+		return p, ErrInvalidRouterAddress
+	}
+
+	var extra Extra
+	if err := json.Unmarshal([]byte(p.Extra), &extra); err != nil {
+		return p, err
+	}
+
+	epochs, slots := makerStateKeys(slices.Concat(extra.TakeToken0Orders, extra.TakeToken1Orders))
+	epochValues := make([]*big.Int, len(epochs))
+	invalidatorValues := make([]*big.Int, len(slots))
+
+	req := t.ethrpcClient.NewRequest().SetContext(ctx)
+	for i, epoch := range epochs {
+		req.AddCall(&ethrpc.Call{
+			ABI:    LimitOrderProtocolABI,
+			Target: staticExtra.RouterAddress,
+			Method: limitOrderProtocolMethodEpoch,
+			Params: []any{epoch.Maker, epoch.Series},
+		}, []any{&epochValues[i]})
+	}
+	for i, slot := range slots {
+		req.AddCall(&ethrpc.Call{
+			ABI:    LimitOrderProtocolABI,
+			Target: staticExtra.RouterAddress,
+			Method: limitOrderProtocolMethodBitInvalidatorForOrder,
+			Params: []any{slot.Maker, slot.Slot},
+		}, []any{&invalidatorValues[i]})
+	}
+
+	var blockNumber uint64
+	if len(req.Calls) > 0 {
+		resp, err := req.Aggregate()
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"poolAddress": p.Address,
+				"error":       err,
+			}).Errorf("failed to get maker epochs and bit invalidators")
+			return p, err
+		}
+		if resp.BlockNumber != nil {
+			blockNumber = resp.BlockNumber.Uint64()
+		}
+	}
+
+	extra.MakerEpochs = make(map[string]*uint256.Int, len(epochs))
+	for i, epoch := range epochs {
+		extra.MakerEpochs[makerEpochKey(epoch.Maker.Hex(), epoch.Series)] = uint256.MustFromBig(epochValues[i])
+	}
+	extra.MakerBitInvalidators = make(map[string]*uint256.Int, len(slots))
+	for i, slot := range slots {
+		extra.MakerBitInvalidators[makerBitInvalidatorKey(slot.Maker.Hex(), slot.Slot)] =
+			uint256.MustFromBig(invalidatorValues[i])
+	}
+
+	extraBytes, err := json.Marshal(extra)
+	if err != nil {
+		return p, err
+	}
+
+	p.Extra = string(extraBytes)
+	if blockNumber > 0 {
+		p.BlockNumber = blockNumber
+	}
+	p.Timestamp = time.Now().Unix()
+
+	return p, nil
+}
+
+// makerStateKeys returns the maker epochs and nonce invalidator slots orders depend on, without duplicates. Orders
+// whose extension is invalid are skipped, as they are not routed.
+func makerStateKeys(orders []*Order) ([]helper1inch.MakerSeries, []makerSlot) {
+	var epochs []helper1inch.MakerSeries
+	var slots []makerSlot
+	seenEpochs, seenSlots := map[string]struct{}{}, map[string]struct{}{}
+	addEpoch := func(epoch helper1inch.MakerSeries) {
+		key := makerEpochKey(epoch.Maker.Hex(), epoch.Series)
+		if _, ok := seenEpochs[key]; !ok {
+			seenEpochs[key] = struct{}{}
+			epochs = append(epochs, epoch)
+		}
+	}
+
+	for _, order := range orders {
+		maker := common.HexToAddress(order.Maker)
+		makerTraits := helper1inch.NewMakerTraits(order.MakerTraits)
+		if makerTraits.IsEpochManagerEnabled() {
+			addEpoch(helper1inch.MakerSeries{Maker: maker, Series: makerTraits.Series()})
+		} else if makerTraits.IsBitInvalidatorMode() {
+			slot := new(big.Int).Rsh(makerTraits.NonceOrEpoch(), 8)
+			key := makerBitInvalidatorKey(order.Maker, slot)
+			if _, ok := seenSlots[key]; !ok {
+				seenSlots[key] = struct{}{}
+				slots = append(slots, makerSlot{Maker: maker, Slot: slot})
+			}
+		}
+
+		if !hasExtension(order) {
+			continue
+		}
+		ext, err := helper1inch.DecodeExtension(order.Extension)
+		if err != nil || !ext.HasPredicate() {
+			continue
+		}
+		predicateEpochs, err := helper1inch.PredicateEpochs(ext.Predicate)
+		if err != nil {
+			continue
+		}
+		for _, epoch := range predicateEpochs {
+			addEpoch(epoch)
+		}
+	}
+
+	return epochs, slots
+}
@@ -0,0 +1,54 @@
+package lo1inch
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+func TestMakerStateKeys(t *testing.T) {
+	maker0 := common.HexToAddress("0xdf4039a454d58868dfd43f076ee46c92a35fdfd9")
+	maker1 := common.HexToAddress("0x0000000000000000000000000000000000001234")
+	newOrder := func(maker common.Address, makerTraits *helper1inch.MakerTraits, predicate string) *Order {
+		order := &Order{Maker: maker.Hex(), MakerTraits: "0x" + makerTraits.Build().Text(16)}
+		if predicate != "" {
+			ext := helper1inch.DefaultExtension()
+			ext.Predicate = predicate
+			order.Extension = ext.Encode()
+		}
+		return order
+	}
+
+	epochID := abiutil.GenMethodID("epoch", []string{"address", "uint96"})
+	epochPredicate := hexutil.Encode(append(append(append([]byte{}, epochID[:]...),
+		common.LeftPadBytes(maker1[:], 32)...), common.LeftPadBytes([]byte{7}, 32)...))
+	epochManager := func(series int64) *helper1inch.MakerTraits {
+		return helper1inch.DefaultMakerTraits().AllowMultipleFills().WithEpoch(big.NewInt(series), big.NewInt(1))
+	}
+
+	epochs, slots := makerStateKeys([]*Order{
+		newOrder(maker0, epochManager(1), ""),
+		newOrder(maker0, epochManager(1), ""),
+		newOrder(maker0, epochManager(2), epochPredicate),
+		newOrder(maker0, helper1inch.DefaultMakerTraits().WithNonce(big.NewInt(257)), ""),
+		newOrder(maker0, helper1inch.DefaultMakerTraits().WithNonce(big.NewInt(258)), ""),
+		newOrder(maker1, helper1inch.DefaultMakerTraits().WithNonce(big.NewInt(3)), epochPredicate),
+		// neither epoch manager nor bit invalidator
+		newOrder(maker1, helper1inch.DefaultMakerTraits().AllowMultipleFills(), ""),
+	})
+	assert.Equal(t, []helper1inch.MakerSeries{
+		{Maker: maker0, Series: big.NewInt(1)},
+		{Maker: maker0, Series: big.NewInt(2)},
+		{Maker: maker1, Series: big.NewInt(7)},
+	}, epochs)
+	assert.Equal(t, []string{makerBitInvalidatorKey(maker0.Hex(), big.NewInt(1)),
+		makerBitInvalidatorKey(maker1.Hex(), big.NewInt(0))},
+		lo.Map(slots, func(slot makerSlot, _ int) string { return makerBitInvalidatorKey(slot.Maker.Hex(), slot.Slot) }))
+}
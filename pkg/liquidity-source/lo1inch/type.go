@@ -43,11 +43,21 @@ type StaticExtra struct {
 	Token0        string `json:"token0"`
 	Token1        string `json:"token1"`
 	RouterAddress string `json:"routerAddress"`
+	// DutchAuctionCalculator and RangeAmountCalculator are the amount getter deployments of the chain of the pool. The
+	// orders with amount getters are only routed if their getter is one of them.
+	DutchAuctionCalculator string `json:"dutchAuctionCalculator,omitempty"`
+	RangeAmountCalculator  string `json:"rangeAmountCalculator,omitempty"`
 }
 
 type Extra struct {
 	TakeToken0Orders []*Order `json:"takeToken0Orders"`
 	TakeToken1Orders []*Order `json:"takeToken1Orders"`
+
+	// MakerEpochs are the current epochs of the makers of epoch manager orders, keyed by makerEpochKey
+	MakerEpochs map[string]*uint256.Int `json:"makerEpochs,omitempty"`
+	// MakerBitInvalidators are the current invalidators of the nonces of bit invalidator orders, keyed by
+	// makerBitInvalidatorKey
+	MakerBitInvalidators map[string]*uint256.Int `json:"makerBitInvalidators,omitempty"`
 }
 
 type SwapInfo struct {
@@ -0,0 +1,133 @@
+package maverickv2
+
+import (
+	"slices"
+
+	"github.com/holiman/uint256"
+	"github.com/samber/lo"
+)
+
+// applySwap writes the ticks touched by a swap back to the pool, then updates the time-weighted average log price
+// and moves the movable bins the same way MaverickV2Pool.swap does at the given block timestamp.
+func (p *PoolSimulator) applySwap(swapInfo SwapInfo, timestamp int64) {
+	for tick, t := range swapInfo.Ticks {
+		p.ticks[tick] = t
+	}
+
+	// the average is updated with the log price from before the swap, so bins only follow prices that lasted
+	newTwaD8 := p.twa(timestamp)
+	lastTwaTick, newTwaTick := floorD8(p.lastTwaD8), floorD8(newTwaD8)
+	p.lastTwaD8, p.lastTimestamp = newTwaD8, timestamp
+	p.activeTick, p.lastLogPriceD8 = swapInfo.ActiveTick, swapInfo.LastLogPriceD8
+
+	p.moveBins(lastTwaTick, newTwaTick)
+}
+
+// twa returns the time-weighted average log price at timestamp: the last average moves linearly towards the last
+// log price and reaches it once lookback seconds have passed.
+func (p *PoolSimulator) twa(timestamp int64) int64 {
+	elapsed := timestamp - p.lastTimestamp
+	if elapsed <= 0 {
+		return p.lastTwaD8
+	}
+	if elapsed >= p.lookback {
+		return p.lastLogPriceD8
+	}
+	return p.lastTwaD8 + (p.lastLogPriceD8-p.lastTwaD8)*elapsed/p.lookback
+}
+
+// moveBins moves bins that follow the price to the tick of the new average price. When the average moves right,
+// right and both kind bins left behind in [lastTwaTick, newTwaTick) are moved up; when it moves left, left and both
+// kind bins in (newTwaTick, lastTwaTick] are moved down. Static bins never move.
+func (p *PoolSimulator) moveBins(lastTwaTick, newTwaTick int32) {
+	if lastTwaTick == newTwaTick {
+		return
+	}
+
+	var (
+		from, to int32
+		kinds    []uint8
+	)
+	if newTwaTick > lastTwaTick {
+		from, to, kinds = lastTwaTick, newTwaTick-1, []uint8{KindRight, KindBoth}
+	} else {
+		from, to, kinds = newTwaTick+1, lastTwaTick, []uint8{KindLeft, KindBoth}
+	}
+
+	var binIDs []uint32
+	start, _ := slices.BinarySearch(p.sortedTicks, from)
+	for _, tick := range p.sortedTicks[start:] {
+		if tick > to {
+			break
+		}
+		for _, kind := range kinds {
+			if binID := p.ticks[tick].BinIDs[kind]; binID != 0 {
+				binIDs = append(binIDs, binID)
+			}
+		}
+	}
+	if len(binIDs) == 0 {
+		return
+	}
+
+	for _, binID := range binIDs {
+		p.moveBin(binID, newTwaTick)
+	}
+
+	p.sortedTicks = lo.Keys(p.ticks)
+	slices.Sort(p.sortedTicks)
+}
+
+// moveBin removes the share of a bin from its tick and adds the corresponding reserves to the target tick, merging
+// it into the bin of the same kind already living there if any.
+func (p *PoolSimulator) moveBin(binID uint32, target int32) {
+	bin, ok := p.bins[binID]
+	if !ok {
+		return
+	}
+	src, ok := p.ticks[bin.Tick]
+	if !ok || src.TotalSupply.IsZero() {
+		return
+	}
+	lower, upper, err := tickSqrtPrices(p.tickSpacing, target)
+	if err != nil {
+		return
+	}
+
+	reserveA := mulDiv(src.ReserveA, bin.TickBalance, src.TotalSupply, false)
+	reserveB := mulDiv(src.ReserveB, bin.TickBalance, src.TotalSupply, false)
+	src.ReserveA = clip(src.ReserveA, reserveA)
+	src.ReserveB = clip(src.ReserveB, reserveB)
+	src.TotalSupply = clip(src.TotalSupply, bin.TickBalance)
+	src.BinIDs[bin.Kind] = 0
+	if src.TotalSupply.IsZero() {
+		delete(p.ticks, bin.Tick)
+	} else {
+		p.ticks[bin.Tick] = src
+	}
+
+	dst, ok := p.ticks[target]
+	if !ok {
+		dst = Tick{ReserveA: new(uint256.Int), ReserveB: new(uint256.Int), TotalSupply: new(uint256.Int)}
+	}
+	liquidityBefore := getTickL(dst.ReserveA, dst.ReserveB, lower, upper)
+	dst.ReserveA = new(uint256.Int).Add(dst.ReserveA, reserveA)
+	dst.ReserveB = new(uint256.Int).Add(dst.ReserveB, reserveB)
+	deltaSupply := clip(getTickL(dst.ReserveA, dst.ReserveB, lower, upper), liquidityBefore)
+	if !dst.TotalSupply.IsZero() && !liquidityBefore.IsZero() {
+		deltaSupply = mulDiv(dst.TotalSupply, deltaSupply, liquidityBefore, false)
+	}
+	dst.TotalSupply = new(uint256.Int).Add(dst.TotalSupply, deltaSupply)
+
+	if mergeID := dst.BinIDs[bin.Kind]; mergeID != 0 && mergeID != binID {
+		merged := p.bins[mergeID]
+		merged.TickBalance = new(uint256.Int).Add(merged.TickBalance, deltaSupply)
+		p.bins[mergeID] = merged
+		delete(p.bins, binID)
+	} else {
+		bin.Tick, bin.TickBalance = target, deltaSupply
+		p.bins[binID] = bin
+		dst.BinIDs[bin.Kind] = binID
+	}
+	p.ticks[target] = dst
+}
@@ -1,5 +1,9 @@
 package maverickv2
 
+import (
+	"github.com/holiman/uint256"
+)
+
 const (
 	DexType = "maverick-v2"
 )
@@ -7,7 +11,63 @@ const (
 const (
 	factoryMethodLookup = "lookup"
 
-	poolMethodTokenA   = "tokenA"
-	poolMethodTokenB   = "tokenB"
-	poolMethodGetState = "getState"
+	poolMethodTokenA      = "tokenA"
+	poolMethodTokenB      = "tokenB"
+	poolMethodGetState    = "getState"
+	poolMethodFee         = "fee"
+	poolMethodTickSpacing = "tickSpacing"
+	poolMethodLookback    = "lookback"
+	poolMethodKinds       = "kinds"
+	poolMethodGetBin      = "getBin"
+	poolMethodGetTick     = "getTick"
+
+	defaultChunk = 500
+)
+
+const (
+	// MaxTick is the largest tick index times tick spacing supported by TickMath.tickSqrtPrice.
+	MaxTick = 322378
+	// maxSwapIterations bounds the number of ticks a single swap may walk through.
+	maxSwapIterations = 150
+
+	Kinds = 4
+
+	KindStatic = 0
+	KindRight  = 1
+	KindLeft   = 2
+	KindBoth   = 3
+
+	logPriceScale = 1e8
+)
+
+var (
+	defaultGas = Gas{BaseGas: 100000, CrossTickGas: 20000}
+
+	maxUint256 = new(uint256.Int).SetAllOne()
+
+	logPriceScaleU = uint256.NewInt(logPriceScale)
+
+	tickRatioMultipliers = [...]*uint256.Int{
+		uint256.MustFromHex("0xfff97272373d41fd789c8cb37ffcaa1c"),
+		uint256.MustFromHex("0xfff2e50f5f656ac9229c67059486f389"),
+		uint256.MustFromHex("0xffe5caca7e10e81259b3cddc7a064941"),
+		uint256.MustFromHex("0xffcb9843d60f67b19e8887e0bd251eb7"),
+		uint256.MustFromHex("0xff973b41fa98cd2e57b660be99eb2c4a"),
+		uint256.MustFromHex("0xff2ea16466c9838804e327cb417cafcb"),
+		uint256.MustFromHex("0xfe5dee046a99d51e2cc356c2f617dbe0"),
+		uint256.MustFromHex("0xfcbe86c7900aecf64236ab31f1f9dcb5"),
+		uint256.MustFromHex("0xf987a7253ac4d9194200696907cf2e37"),
+		uint256.MustFromHex("0xf3392b0822b88206f8abe8a3b44dd9be"),
+		uint256.MustFromHex("0xe7159475a2c578ef4f1d17b2b235d480"),
+		uint256.MustFromHex("0xd097f3bdfd254ee83bdd3f248e7e785e"),
+		uint256.MustFromHex("0xa9f746462d8f7dd10e744d913d033333"),
+		uint256.MustFromHex("0x70d869a156ddd32a39e257bc3f50aa9b"),
+		uint256.MustFromHex("0x31be135f97da6e09a19dc367e3b6da40"),
+		uint256.MustFromHex("0x9aa508b5b7e5a9780b0cc4e25d61a56"),
+		uint256.MustFromHex("0x5d6af8dedbcb3a6ccb7ce618d14225"),
+		uint256.MustFromHex("0x2216e584f630389b2052b8db590e"),
+		uint256.MustFromHex("0x48a1703920644d4030024fe"),
+	}
+	tickRatioOdd  = uint256.MustFromHex("0xfffcb933bd6fad9d3af5f0b9f25db4d6")
+	tickRatioEven = uint256.MustFromHex("0x100000000000000000000000000000000")
 )
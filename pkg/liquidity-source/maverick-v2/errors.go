@@ -0,0 +1,16 @@
+package maverickv2
+
+import "errors"
+
+var (
+	ErrInvalidToken          = errors.New("invalid token")
+	ErrInvalidAmountIn       = errors.New("invalid amount in")
+	ErrInvalidAmountOut      = errors.New("invalid amount out")
+	ErrOverflow              = errors.New("overflow")
+	ErrDividedByZero         = errors.New("divided by zero")
+	ErrTickOutOfRange        = errors.New("tick is out of range")
+	ErrEmptyTicks            = errors.New("maverick v2 pool has no tick")
+	ErrInvalidTickSpacing    = errors.New("invalid tick spacing")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	ErrTooManyTicksCrossed   = errors.New("too many ticks crossed")
+)
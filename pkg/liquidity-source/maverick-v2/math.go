@@ -0,0 +1,176 @@
+package maverickv2
+
+import (
+	"github.com/holiman/uint256"
+
+	bignumber "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/big256"
+)
+
+// tickSqrtPrice mirrors TickMath.tickSqrtPrice and returns sqrt(1.0001^(tick*tickSpacing)) with 18 decimals.
+func tickSqrtPrice(tickSpacing uint32, tick int32) (*uint256.Int, error) {
+	absTick := int64(tick)
+	if absTick < 0 {
+		absTick = -absTick
+	}
+	absTick *= int64(tickSpacing)
+	if absTick > MaxTick {
+		return nil, ErrTickOutOfRange
+	}
+
+	ratio := new(uint256.Int)
+	if absTick&1 != 0 {
+		ratio.Set(tickRatioOdd)
+	} else {
+		ratio.Set(tickRatioEven)
+	}
+	for i, multiplier := range tickRatioMultipliers {
+		if absTick&(2<<i) != 0 {
+			ratio.Mul(ratio, multiplier).Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Div(maxUint256, ratio)
+	}
+
+	return ratio.Mul(ratio, bignumber.BONE).Rsh(ratio, 128), nil
+}
+
+// tickSqrtPrices returns the sqrt prices at the lower and upper edges of a tick.
+func tickSqrtPrices(tickSpacing uint32, tick int32) (lower, upper *uint256.Int, err error) {
+	if lower, err = tickSqrtPrice(tickSpacing, tick); err != nil {
+		return nil, nil, err
+	}
+	if upper, err = tickSqrtPrice(tickSpacing, tick+1); err != nil {
+		return nil, nil, err
+	}
+	return lower, upper, nil
+}
+
+// getTickL mirrors TickMath.getTickL, the liquidity of a tick holding reserveA and reserveB between its edge prices.
+func getTickL(reserveA, reserveB, sqrtLowerTickPrice, sqrtUpperTickPrice *uint256.Int) *uint256.Int {
+	precisionBump := uint(0)
+	var tmp uint256.Int
+	if tmp.Rsh(reserveA, 60).IsZero() && tmp.Rsh(reserveB, 60).IsZero() {
+		precisionBump = 40
+		reserveA = new(uint256.Int).Lsh(reserveA, precisionBump)
+		reserveB = new(uint256.Int).Lsh(reserveB, precisionBump)
+	}
+
+	diff := new(uint256.Int).Sub(sqrtUpperTickPrice, sqrtLowerTickPrice)
+	b := divDown(reserveA, sqrtUpperTickPrice)
+	b.Add(b, mulDown(reserveB, sqrtLowerTickPrice))
+
+	var liquidity *uint256.Int
+	if reserveA.IsZero() || reserveB.IsZero() {
+		liquidity = mulDiv(b, sqrtUpperTickPrice, diff, false)
+	} else {
+		b.Rsh(b, 1)
+		inner := mulDiv(new(uint256.Int).Mul(reserveA, reserveB), diff, sqrtUpperTickPrice, false)
+		inner.Add(inner, new(uint256.Int).Mul(b, b))
+		liquidity = mulDiv(b.Add(b, inner.Sqrt(inner)), sqrtUpperTickPrice, diff, false)
+	}
+
+	return liquidity.Rsh(liquidity, precisionBump)
+}
+
+// getSqrtPrice mirrors TickMath.getSqrtPrice, the price inside a tick implied by its reserves and liquidity.
+func getSqrtPrice(reserveA, reserveB, sqrtLowerTickPrice, sqrtUpperTickPrice, liquidity *uint256.Int) *uint256.Int {
+	if reserveA.IsZero() {
+		return sqrtLowerTickPrice.Clone()
+	}
+	if reserveB.IsZero() {
+		return sqrtUpperTickPrice.Clone()
+	}
+
+	numerator := mulDown(liquidity, sqrtLowerTickPrice)
+	numerator.Add(numerator, reserveA)
+	denominator := divDown(liquidity, sqrtUpperTickPrice)
+	denominator.Add(denominator, reserveB)
+	sqrtPrice := sqrtD18(divDown(numerator, denominator))
+
+	// the rounded price must stay inside the tick
+	if sqrtPrice.Lt(sqrtLowerTickPrice) {
+		return sqrtLowerTickPrice.Clone()
+	} else if sqrtPrice.Gt(sqrtUpperTickPrice) {
+		return sqrtUpperTickPrice.Clone()
+	}
+	return sqrtPrice
+}
+
+// logPriceD8 returns the tick position of a price in 8 decimals, the unit of lastLogPriceD8 and lastTwaD8.
+func logPriceD8(tick int32, sqrtPrice, sqrtLowerTickPrice, sqrtUpperTickPrice *uint256.Int) int64 {
+	fractional := new(uint256.Int).Sub(sqrtPrice, sqrtLowerTickPrice)
+	fractional = mulDiv(fractional, logPriceScaleU, new(uint256.Int).Sub(sqrtUpperTickPrice, sqrtLowerTickPrice), false)
+	return int64(tick)*logPriceScale + int64(fractional.Uint64())
+}
+
+// floorD8 returns the tick containing a log price in 8 decimals.
+func floorD8(logPrice int64) int32 {
+	tick := logPrice / logPriceScale
+	if logPrice%logPriceScale < 0 {
+		tick--
+	}
+	return int32(tick)
+}
+
+func scaleFromAmount(amount *uint256.Int, decimals uint8) *uint256.Int {
+	if decimals == 18 {
+		return amount.Clone()
+	} else if decimals > 18 {
+		return new(uint256.Int).Div(amount, bignumber.TenPowInt(decimals-18))
+	}
+	return mulChecked(amount, bignumber.TenPowInt(18-decimals))
+}
+
+func scaleToAmount(amount *uint256.Int, decimals uint8, roundUp bool) *uint256.Int {
+	if decimals == 18 {
+		return amount.Clone()
+	} else if decimals > 18 {
+		return mulChecked(amount, bignumber.TenPowInt(decimals-18))
+	}
+	return mulDiv(amount, uint256.NewInt(1), bignumber.TenPowInt(18-decimals), roundUp)
+}
+
+// mulDiv computes a*b/c with a 512-bit intermediate, it panics with ErrOverflow or ErrDividedByZero.
+func mulDiv(a, b, c *uint256.Int, roundUp bool) *uint256.Int {
+	if c.IsZero() {
+		panic(ErrDividedByZero)
+	}
+	z, overflow := new(uint256.Int).MulDivOverflow(a, b, c)
+	if overflow {
+		panic(ErrOverflow)
+	}
+	if roundUp {
+		var rem uint256.Int
+		if !rem.MulMod(a, b, c).IsZero() {
+			z.AddUint64(z, 1)
+		}
+	}
+	return z
+}
+
+func mulChecked(a, b *uint256.Int) *uint256.Int {
+	z, overflow := new(uint256.Int).MulOverflow(a, b)
+	if overflow {
+		panic(ErrOverflow)
+	}
+	return z
+}
+
+func mulDown(a, b *uint256.Int) *uint256.Int { return mulDiv(a, b, bignumber.BONE, false) }
+func mulUp(a, b *uint256.Int) *uint256.Int   { return mulDiv(a, b, bignumber.BONE, true) }
+func divDown(a, b *uint256.Int) *uint256.Int { return mulDiv(a, bignumber.BONE, b, false) }
+func divUp(a, b *uint256.Int) *uint256.Int   { return mulDiv(a, bignumber.BONE, b, true) }
+
+// sqrtD18 is the square root of a number with 18 decimals.
+func sqrtD18(x *uint256.Int) *uint256.Int {
+	z := mulChecked(x, bignumber.BONE)
+	return z.Sqrt(z)
+}
+
+func clip(x, y *uint256.Int) *uint256.Int {
+	if x.Lt(y) {
+		return new(uint256.Int)
+	}
+	return new(uint256.Int).Sub(x, y)
+}
@@ -0,0 +1,206 @@
+package maverickv2
+
+import (
+	"maps"
+	"math/big"
+	"slices"
+
+	"github.com/KyberNetwork/logger"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+	"github.com/samber/lo"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	bignumber "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+type PoolSimulator struct {
+	pool.Pool
+	decimals []uint8
+
+	feeAIn             uint64
+	feeBIn             uint64
+	protocolFeeRatioD3 uint8
+	tickSpacing        uint32
+	lookback           int64
+
+	activeTick     int32
+	lastTwaD8      int64
+	lastLogPriceD8 int64
+	lastTimestamp  int64
+
+	ticks       map[int32]Tick
+	sortedTicks []int32
+	bins        map[uint32]Bin
+
+	gas Gas
+}
+
+type PoolMeta struct {
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+var _ = pool.RegisterFactory0(DexType, NewPoolSimulator)
+
+func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
+	var extra Extra
+	if err := json.Unmarshal([]byte(entityPool.Extra), &extra); err != nil {
+		return nil, err
+	}
+	if len(extra.Ticks) == 0 {
+		return nil, ErrEmptyTicks
+	}
+
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(entityPool.StaticExtra), &staticExtra); err != nil {
+		return nil, err
+	}
+	if staticExtra.TickSpacing == 0 {
+		return nil, ErrInvalidTickSpacing
+	}
+
+	sortedTicks := lo.Keys(extra.Ticks)
+	slices.Sort(sortedTicks)
+
+	return &PoolSimulator{
+		Pool: pool.Pool{
+			Info: pool.PoolInfo{
+				Address:     entityPool.Address,
+				Exchange:    entityPool.Exchange,
+				Type:        entityPool.Type,
+				Tokens:      []string{entityPool.Tokens[0].Address, entityPool.Tokens[1].Address},
+				Reserves:    []*big.Int{bignumber.NewBig10(entityPool.Reserves[0]), bignumber.NewBig10(entityPool.Reserves[1])},
+				BlockNumber: entityPool.BlockNumber,
+			},
+		},
+		decimals:           []uint8{entityPool.Tokens[0].Decimals, entityPool.Tokens[1].Decimals},
+		feeAIn:             extra.FeeAIn,
+		feeBIn:             extra.FeeBIn,
+		protocolFeeRatioD3: extra.ProtocolFeeRatioD3,
+		tickSpacing:        staticExtra.TickSpacing,
+		lookback:           staticExtra.Lookback,
+		activeTick:         extra.ActiveTick,
+		lastTwaD8:          extra.LastTwaD8,
+		lastLogPriceD8:     extra.LastLogPriceD8,
+		lastTimestamp:      extra.LastTimestamp,
+		ticks:              extra.Ticks,
+		sortedTicks:        sortedTicks,
+		bins:               extra.Bins,
+		gas:                defaultGas,
+	}, nil
+}
+
+func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	tokenAmountIn, tokenOut := param.TokenAmountIn, param.TokenOut
+	tokenInIndex, tokenOutIndex := p.GetTokenIndex(tokenAmountIn.Token), p.GetTokenIndex(tokenOut)
+	if tokenInIndex < 0 || tokenOutIndex < 0 || tokenInIndex == tokenOutIndex {
+		return nil, ErrInvalidToken
+	}
+	if tokenAmountIn.Amount == nil || tokenAmountIn.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmountIn
+	}
+	amountIn, overflow := uint256.FromBig(tokenAmountIn.Amount)
+	if overflow {
+		return nil, ErrOverflow
+	}
+
+	res, err := p.swapSafe(scaleFromAmount(amountIn, p.decimals[tokenInIndex]), tokenInIndex == 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	amountOut := scaleToAmount(res.amountOut, p.decimals[tokenOutIndex], false)
+	if amountOut.IsZero() {
+		return nil, ErrInvalidAmountOut
+	}
+
+	return &pool.CalcAmountOutResult{
+		TokenAmountOut: &pool.TokenAmount{Token: tokenOut, Amount: amountOut.ToBig()},
+		Fee: &pool.TokenAmount{
+			Token:  tokenAmountIn.Token,
+			Amount: scaleToAmount(res.fee, p.decimals[tokenInIndex], false).ToBig(),
+		},
+		Gas:      p.gas.BaseGas + p.gas.CrossTickGas*int64(res.ticksCrossed),
+		SwapInfo: res.swapInfo,
+	}, nil
+}
+
+func (p *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcAmountInResult, error) {
+	tokenIn, tokenAmountOut := param.TokenIn, param.TokenAmountOut
+	tokenInIndex, tokenOutIndex := p.GetTokenIndex(tokenIn), p.GetTokenIndex(tokenAmountOut.Token)
+	if tokenInIndex < 0 || tokenOutIndex < 0 || tokenInIndex == tokenOutIndex {
+		return nil, ErrInvalidToken
+	}
+	if tokenAmountOut.Amount == nil || tokenAmountOut.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmountOut
+	}
+	amountOut, overflow := uint256.FromBig(tokenAmountOut.Amount)
+	if overflow {
+		return nil, ErrOverflow
+	}
+
+	res, err := p.swapSafe(scaleFromAmount(amountOut, p.decimals[tokenOutIndex]), tokenInIndex == 0, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pool.CalcAmountInResult{
+		TokenAmountIn: &pool.TokenAmount{
+			Token:  tokenIn,
+			Amount: scaleToAmount(res.amountIn, p.decimals[tokenInIndex], true).ToBig(),
+		},
+		Fee: &pool.TokenAmount{
+			Token:  tokenIn,
+			Amount: scaleToAmount(res.fee, p.decimals[tokenInIndex], true).ToBig(),
+		},
+		Gas:      p.gas.BaseGas + p.gas.CrossTickGas*int64(res.ticksCrossed),
+		SwapInfo: res.swapInfo,
+	}, nil
+}
+
+// swapSafe recovers from the overflow and division by zero panics raised by the fixed point helpers.
+func (p *PoolSimulator) swapSafe(amount *uint256.Int, tokenAIn, exactOutput bool) (res *swapResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+				return
+			}
+			panic(r)
+		}
+	}()
+	return p.swap(amount, tokenAIn, exactOutput)
+}
+
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.Info.Reserves = slices.Clone(p.Info.Reserves)
+	// ticks and bins are copy-on-write: their *uint256.Int fields are replaced and never mutated in place
+	cloned.ticks = maps.Clone(p.ticks)
+	cloned.sortedTicks = slices.Clone(p.sortedTicks)
+	cloned.bins = maps.Clone(p.bins)
+	return &cloned
+}
+
+func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
+	swapInfo, ok := params.SwapInfo.(SwapInfo)
+	if !ok {
+		logger.Warn("failed to UpdateBalance for Maverick V2 pool, wrong swapInfo type")
+		return
+	}
+
+	tokenInIndex, tokenOutIndex := p.GetTokenIndex(params.TokenAmountIn.Token), p.GetTokenIndex(params.TokenAmountOut.Token)
+	if tokenInIndex >= 0 && tokenOutIndex >= 0 {
+		p.Info.Reserves[tokenInIndex] = new(big.Int).Add(p.Info.Reserves[tokenInIndex], params.TokenAmountIn.Amount)
+		p.Info.Reserves[tokenOutIndex] = new(big.Int).Sub(p.Info.Reserves[tokenOutIndex], params.TokenAmountOut.Amount)
+	}
+
+	p.applySwap(swapInfo, params.Now())
+}
+
+func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
+	return PoolMeta{
+		BlockNumber: p.Info.BlockNumber,
+	}
+}
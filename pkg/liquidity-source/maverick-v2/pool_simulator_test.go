@@ -0,0 +1,322 @@
+package maverickv2
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/KyberNetwork/msgpack/v5"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+const (
+	tokenA = "0x6b175474e89094c44da98b954eedeac495271d0f" // 18 decimals
+	tokenB = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48" // 6 decimals
+
+	testTimestamp = 1730000000
+)
+
+// newTestPool builds a stable pair with 1M (18 decimals internal) of liquidity in each tick from -5 to 5. Ticks below
+// the active tick 0 only hold A, ticks above only hold B. Every tick has a static bin, tick -1 also has a right bin
+// and tick 1 a left bin, each doubling the reserves of its tick.
+func newTestPool(t *testing.T, feeD18 uint64, protocolFeeRatioD3 uint8) *PoolSimulator {
+	reserve := bignumber.NewBig("1000000000000000000000000")
+	ticks := make(map[int32]Tick)
+	bins := make(map[uint32]Bin)
+	for tick := int32(-5); tick <= 5; tick++ {
+		binID := uint32(tick + 6)
+		reserveA, reserveB := new(uint256.Int), new(uint256.Int)
+		switch {
+		case tick < 0:
+			reserveA = uint256.MustFromBig(reserve)
+		case tick > 0:
+			reserveB = uint256.MustFromBig(reserve)
+		default:
+			reserveA = new(uint256.Int).Rsh(uint256.MustFromBig(reserve), 1)
+			reserveB = reserveA.Clone()
+		}
+		ticks[tick] = Tick{
+			ReserveA:    reserveA,
+			ReserveB:    reserveB,
+			TotalSupply: uint256.NewInt(1000),
+			BinIDs:      [Kinds]uint32{KindStatic: binID},
+		}
+		bins[binID] = Bin{Tick: tick, Kind: KindStatic, TickBalance: uint256.NewInt(1000)}
+	}
+	for _, movable := range []struct {
+		binID uint32
+		tick  int32
+		kind  uint8
+	}{{20, -1, KindRight}, {21, 1, KindLeft}} {
+		tick := ticks[movable.tick]
+		tick.TotalSupply = uint256.NewInt(2000)
+		tick.ReserveA = new(uint256.Int).Lsh(tick.ReserveA, 1)
+		tick.ReserveB = new(uint256.Int).Lsh(tick.ReserveB, 1)
+		tick.BinIDs[movable.kind] = movable.binID
+		ticks[movable.tick] = tick
+		bins[movable.binID] = Bin{Tick: movable.tick, Kind: movable.kind, TickBalance: uint256.NewInt(1000)}
+	}
+
+	extraBytes, err := json.Marshal(Extra{
+		FeeAIn:             feeD18,
+		FeeBIn:             feeD18,
+		ProtocolFeeRatioD3: protocolFeeRatioD3,
+		ActiveTick:         0,
+		LastTwaD8:          50000000,
+		LastLogPriceD8:     50000000,
+		LastTimestamp:      testTimestamp,
+		Ticks:              ticks,
+		Bins:               bins,
+	})
+	require.NoError(t, err)
+	staticExtraBytes, err := json.Marshal(StaticExtra{TickSpacing: 10, Lookback: 3600, Kinds: 15})
+	require.NoError(t, err)
+
+	sim, err := NewPoolSimulator(entity.Pool{
+		Address:  "0x31373595f40ea48a7aab6cbcb0d377c6066e2dca",
+		Exchange: DexType,
+		Type:     DexType,
+		Tokens: []*entity.PoolToken{
+			{Address: tokenA, Decimals: 18, Swappable: true},
+			{Address: tokenB, Decimals: 6, Swappable: true},
+		},
+		Reserves:    []string{"6500000000000000000000000", "6500000000000"},
+		Extra:       string(extraBytes),
+		StaticExtra: string(staticExtraBytes),
+	})
+	require.NoError(t, err)
+	return sim
+}
+
+func TestPoolSimulator_CalcAmountOut(t *testing.T) {
+	sim := newTestPool(t, 0, 0)
+
+	t.Run("swap within the active tick matches the closed form", func(t *testing.T) {
+		amountIn := bignumber.TenPowInt(21) // 1000 A
+		res, err := testutil.MustConcurrentSafe(t, func() (*pool.CalcAmountOutResult, error) {
+			return sim.CalcAmountOut(pool.CalcAmountOutParams{
+				TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: amountIn},
+				TokenOut:      tokenB,
+			})
+		})
+		require.NoError(t, err)
+
+		// sqrtP' = sqrtP + in/L, out = L * (1/sqrtP - 1/sqrtP')
+		lower, upper, err := tickSqrtPrices(10, 0)
+		require.NoError(t, err)
+		t0 := sim.ticks[0]
+		liquidity := getTickL(t0.ReserveA, t0.ReserveB, lower, upper)
+		sqrtPrice := getSqrtPrice(t0.ReserveA, t0.ReserveB, lower, upper, liquidity)
+		l, s := toFloat(liquidity), toFloat(sqrtPrice)/1e18
+		in, _ := amountIn.Float64()
+		expected := l * (1/s - 1/(s+in/l)) / 1e12
+		actual, _ := res.TokenAmountOut.Amount.Float64()
+		assert.InEpsilon(t, expected, actual, 1e-6)
+		assert.Equal(t, defaultGas.BaseGas, res.Gas)
+		assert.Equal(t, int32(0), res.SwapInfo.(SwapInfo).ActiveTick)
+	})
+
+	t.Run("crossing ticks moves the active tick and costs gas", func(t *testing.T) {
+		// the active tick holds 0.5M B, tick 1 2M B and tick 2 1M B
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: bignumber.NewBig("3000000000000000000000000")},
+			TokenOut:      tokenB,
+		})
+		require.NoError(t, err)
+		swapInfo := res.SwapInfo.(SwapInfo)
+		assert.Equal(t, int32(2), swapInfo.ActiveTick)
+		assert.Equal(t, defaultGas.BaseGas+2*defaultGas.CrossTickGas, res.Gas)
+		assert.True(t, swapInfo.Ticks[1].ReserveB.IsZero())
+		assert.Greater(t, res.TokenAmountOut.Amount.Cmp(big.NewInt(2500000000000)), 0)
+
+		res, err = sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenB, Amount: big.NewInt(3000000000000)},
+			TokenOut:      tokenA,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, int32(-2), res.SwapInfo.(SwapInfo).ActiveTick)
+	})
+
+	t.Run("fees are charged on the input token", func(t *testing.T) {
+		feeSim := newTestPool(t, 1e15, 0) // 0.1%
+		params := pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenB, Amount: big.NewInt(1000000000)},
+			TokenOut:      tokenA,
+		}
+		noFee, err := sim.CalcAmountOut(params)
+		require.NoError(t, err)
+		withFee, err := feeSim.CalcAmountOut(params)
+		require.NoError(t, err)
+
+		assert.Equal(t, tokenB, withFee.Fee.Token)
+		assert.Equal(t, big.NewInt(1000000), withFee.Fee.Amount)
+		noFeeF, _ := noFee.TokenAmountOut.Amount.Float64()
+		withFeeF, _ := withFee.TokenAmountOut.Amount.Float64()
+		assert.InEpsilon(t, noFeeF*0.999, withFeeF, 1e-6)
+	})
+
+	t.Run("swaps exhausting the liquidity fail", func(t *testing.T) {
+		_, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenB, Amount: big.NewInt(1e13)},
+			TokenOut:      tokenA,
+		})
+		assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	})
+}
+
+func TestPoolSimulator_CalcAmountIn(t *testing.T) {
+	testutil.TestCalcAmountIn(t, newTestPool(t, 1e14, 200))
+}
+
+func TestPoolSimulator_UpdateBalance(t *testing.T) {
+	sim := newTestPool(t, 1e14, 200)
+	cloned := sim.CloneState().(*PoolSimulator)
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: bignumber.NewBig("1000000000000000000000000")},
+		TokenOut:      tokenB,
+	}
+	res, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	sim.UpdateBalance(pool.UpdateBalanceParams{
+		TokenAmountIn:  params.TokenAmountIn,
+		TokenAmountOut: *res.TokenAmountOut,
+		Fee:            *res.Fee,
+		SwapInfo:       res.SwapInfo,
+	})
+
+	assert.Equal(t, int32(1), sim.activeTick)
+	assert.True(t, sim.ticks[0].ReserveB.IsZero())
+	resAfter, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Negative(t, resAfter.TokenAmountOut.Amount.Cmp(res.TokenAmountOut.Amount))
+
+	// the clone is not affected
+	resCloned, err := cloned.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Equal(t, res.TokenAmountOut.Amount, resCloned.TokenAmountOut.Amount)
+	assert.Equal(t, int32(0), cloned.activeTick)
+}
+
+func TestPoolSimulator_MoveBins(t *testing.T) {
+	swapUp := func(t *testing.T, sim *PoolSimulator) SwapInfo {
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: bignumber.NewBig("2000000000000000000000000")},
+			TokenOut:      tokenB,
+		})
+		require.NoError(t, err)
+		return res.SwapInfo.(SwapInfo)
+	}
+
+	t.Run("bins do not move within the lookback of a price change", func(t *testing.T) {
+		sim := newTestPool(t, 0, 0)
+		sim.applySwap(swapUp(t, sim), testTimestamp+60)
+		// the average only moved with the log price from before the swap
+		assert.Equal(t, int64(50000000), sim.lastTwaD8)
+
+		sim.applySwap(SwapInfo{ActiveTick: sim.activeTick, LastLogPriceD8: sim.lastLogPriceD8}, testTimestamp+1860)
+		assert.Equal(t, int32(1), floorD8(sim.lastTwaD8))
+		assert.Equal(t, int32(-1), sim.bins[20].Tick)
+	})
+
+	t.Run("right bins follow the average price up", func(t *testing.T) {
+		sim := newTestPool(t, 0, 0)
+		sim.lastTwaD8 = -150000000 // tick -2
+		sim.lastLogPriceD8 = 250000000
+		sim.lastTimestamp = testTimestamp - 3600
+		reserveABefore := sim.ticks[-1].ReserveA
+
+		sim.applySwap(SwapInfo{ActiveTick: 0, LastLogPriceD8: 50000000}, testTimestamp)
+
+		assert.Equal(t, int64(250000000), sim.lastTwaD8)
+		// the right bin of tick -1 moved to tick 2, the left bin of tick 1 and static bins stay
+		assert.Equal(t, int32(2), sim.bins[20].Tick)
+		assert.Equal(t, uint32(20), sim.ticks[2].BinIDs[KindRight])
+		assert.Zero(t, sim.ticks[-1].BinIDs[KindRight])
+		assert.Equal(t, new(uint256.Int).Rsh(reserveABefore, 1), sim.ticks[-1].ReserveA)
+		assert.Equal(t, new(uint256.Int).Rsh(reserveABefore, 1), sim.ticks[2].ReserveA)
+		assert.Equal(t, int32(1), sim.bins[21].Tick)
+		assert.Equal(t, int32(-1), sim.bins[5].Tick)
+	})
+
+	t.Run("left bins follow the average price down and merge", func(t *testing.T) {
+		sim := newTestPool(t, 0, 0)
+		sim.bins[22] = Bin{Tick: -3, Kind: KindLeft, TickBalance: uint256.NewInt(1000)}
+		tick := sim.ticks[-3]
+		tick.BinIDs[KindLeft] = 22
+		tick.TotalSupply = uint256.NewInt(2000)
+		tick.ReserveA = new(uint256.Int).Lsh(tick.ReserveA, 1)
+		sim.ticks[-3] = tick
+		sim.lastTwaD8 = 150000000 // tick 1
+		sim.lastLogPriceD8 = -250000000
+		sim.lastTimestamp = testTimestamp - 3600
+
+		sim.applySwap(SwapInfo{ActiveTick: 0, LastLogPriceD8: 50000000}, testTimestamp)
+
+		assert.Equal(t, int32(-3), floorD8(sim.lastTwaD8))
+		_, ok := sim.bins[21]
+		assert.False(t, ok, "the left bin of tick 1 is merged into the one of tick -3")
+		assert.Equal(t, uint32(22), sim.ticks[-3].BinIDs[KindLeft])
+		assert.Equal(t, int32(-1), sim.bins[20].Tick)
+		assert.Greater(t, sim.bins[22].TickBalance.Uint64(), uint64(1000))
+		assert.Equal(t, sim.ticks[1].TotalSupply, uint256.NewInt(1000))
+	})
+}
+
+func TestPoolSimulator_Msgpack(t *testing.T) {
+	sim := newTestPool(t, 1e14, 200)
+	var buf bytes.Buffer
+	en := msgpack.NewEncoder(&buf)
+	en.IncludeUnexported(true)
+	en.SetForceAsArray(true)
+	require.NoError(t, en.Encode(sim))
+
+	var decoded PoolSimulator
+	de := msgpack.NewDecoder(&buf)
+	de.IncludeUnexported(true)
+	de.SetForceAsArray(true)
+	require.NoError(t, de.Decode(&decoded))
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: bignumber.NewBig("1500000000000000000000000")},
+		TokenOut:      tokenB,
+	}
+	expected, err := sim.CalcAmountOut(params)
+	require.NoError(t, err)
+	actual, err := decoded.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.Equal(t, expected.TokenAmountOut, actual.TokenAmountOut)
+}
+
+func TestTickSqrtPrice(t *testing.T) {
+	price, err := tickSqrtPrice(10, 0)
+	require.NoError(t, err)
+	assert.Equal(t, bignumber.TenPowInt(18), price.ToBig())
+
+	for _, tick := range []int32{1, 7, 1234, 32237} {
+		price, err := tickSqrtPrice(10, tick)
+		require.NoError(t, err)
+		assert.InEpsilon(t, math.Pow(1.0001, float64(tick*10)/2), toFloat(price)/1e18, 1e-9)
+
+		inverse, err := tickSqrtPrice(10, -tick)
+		require.NoError(t, err)
+		assert.InEpsilon(t, 1e18, toFloat(inverse)*toFloat(price)/1e18, 1e-10)
+	}
+
+	_, err = tickSqrtPrice(10, 32238)
+	assert.ErrorIs(t, err, ErrTickOutOfRange)
+}
+
+func toFloat(x *uint256.Int) float64 {
+	f, _ := x.ToBig().Float64()
+	return f
+}
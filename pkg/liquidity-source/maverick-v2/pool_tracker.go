@@ -3,39 +3,25 @@ package maverickv2
 import (
 	"context"
 	"math/big"
+	"slices"
 	"time"
 
 	"github.com/KyberNetwork/ethrpc"
 	"github.com/KyberNetwork/logger"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+	"github.com/samber/lo"
+	"github.com/sourcegraph/conc/pool"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	sourcePool "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
 )
 
-type (
-	PoolTracker struct {
-		config       *Config
-		ethrpcClient *ethrpc.Client
-	}
-
-	GetStateResult struct {
-		ReserveA           *big.Int `json:"reserveA"`
-		ReserveB           *big.Int `json:"reserveB"`
-		LastTwaD8          int64    `json:"lastTwaD8"`
-		LastLogPriceD8     int64    `json:"lastLogPriceD8"`
-		LastTimestamp      *big.Int `json:"lastTimestamp"`
-		ActiveTick         int32    `json:"activeTick"`
-		IsLocked           bool     `json:"isLocked"`
-		BinCounter         uint32   `json:"binCounter"`
-		ProtocolFeeRatioD3 uint8    `json:"protocolFeeRatioD3"`
-	}
-
-	// because the result is a tuple with internal type = struct IMaverickV2Pool.State, we need to wrap it in a struct like this
-	GetStateResultWrapper struct {
-		GetStateResult
-	}
-)
+type PoolTracker struct {
+	config       *Config
+	ethrpcClient *ethrpc.Client
+}
 
 var _ = pooltrack.RegisterFactoryCE(DexType, NewPoolTracker)
 
@@ -52,13 +38,20 @@ func NewPoolTracker(
 func (t *PoolTracker) GetNewPoolState(
 	ctx context.Context,
 	p entity.Pool,
-	params pool.GetNewPoolStateParams,
+	params sourcePool.GetNewPoolStateParams,
 ) (entity.Pool, error) {
 	startTime := time.Now()
 
 	logger.WithFields(logger.Fields{"pool_id": p.Address}).Info("Started getting new pool state")
 
-	state, blockNumber, err := t.getState(ctx, p.Address)
+	var staticExtra StaticExtra
+	if len(p.StaticExtra) > 0 {
+		if err := json.Unmarshal([]byte(p.StaticExtra), &staticExtra); err != nil {
+			return p, err
+		}
+	}
+
+	state, extra, binCounter, blockNumber, err := t.getState(ctx, p.Address, &staticExtra)
 	if err != nil {
 		return p, err
 	}
@@ -76,6 +69,14 @@ func (t *PoolTracker) GetNewPoolState(
 		return p, nil
 	}
 
+	if extra.Bins, extra.Ticks, err = t.getBinsAndTicks(ctx, p.Address, binCounter, blockNumber); err != nil {
+		logger.WithFields(logger.Fields{
+			"pool_id": p.Address,
+			"error":   err,
+		}).Error("failed to get bins and ticks")
+		return p, err
+	}
+
 	logger.WithFields(
 		logger.Fields{
 			"pool_id":          p.Address,
@@ -87,11 +88,19 @@ func (t *PoolTracker) GetNewPoolState(
 		},
 	).Info("Finished getting new pool state")
 
-	return t.updatePool(p, state, blockNumber)
+	return t.updatePool(p, state, extra, staticExtra, blockNumber)
 }
 
-func (t *PoolTracker) getState(ctx context.Context, poolAddress string) (State, *big.Int, error) {
-	var getStateResult GetStateResultWrapper
+// getState fetches the pool state and fees. Immutable parameters are only fetched when staticExtra is missing them,
+// which is the case for pools listed before they were stored.
+func (t *PoolTracker) getState(ctx context.Context, poolAddress string,
+	staticExtra *StaticExtra) (State, Extra, uint32, *big.Int, error) {
+	var (
+		getStateResult        GetStateResultWrapper
+		feeAIn, feeBIn        *big.Int
+		tickSpacing, lookback *big.Int
+		kinds                 uint8
+	)
 
 	getStateRequest := t.ethrpcClient.NewRequest().SetContext(ctx).SetRequireSuccess(true)
 
@@ -100,27 +109,169 @@ func (t *PoolTracker) getState(ctx context.Context, poolAddress string) (State,
 		Target: poolAddress,
 		Method: poolMethodGetState,
 		Params: nil,
-	}, []interface{}{
-		&getStateResult,
-	})
+	}, []interface{}{&getStateResult})
+	getStateRequest.AddCall(&ethrpc.Call{
+		ABI:    maverickV2PoolABI,
+		Target: poolAddress,
+		Method: poolMethodFee,
+		Params: []interface{}{true},
+	}, []interface{}{&feeAIn})
+	getStateRequest.AddCall(&ethrpc.Call{
+		ABI:    maverickV2PoolABI,
+		Target: poolAddress,
+		Method: poolMethodFee,
+		Params: []interface{}{false},
+	}, []interface{}{&feeBIn})
+
+	fetchStatic := staticExtra.TickSpacing == 0
+	if fetchStatic {
+		getStateRequest.AddCall(&ethrpc.Call{
+			ABI:    maverickV2PoolABI,
+			Target: poolAddress,
+			Method: poolMethodTickSpacing,
+			Params: nil,
+		}, []interface{}{&tickSpacing})
+		getStateRequest.AddCall(&ethrpc.Call{
+			ABI:    maverickV2PoolABI,
+			Target: poolAddress,
+			Method: poolMethodLookback,
+			Params: nil,
+		}, []interface{}{&lookback})
+		getStateRequest.AddCall(&ethrpc.Call{
+			ABI:    maverickV2PoolABI,
+			Target: poolAddress,
+			Method: poolMethodKinds,
+			Params: nil,
+		}, []interface{}{&kinds})
+	}
 
 	resp, err := getStateRequest.TryBlockAndAggregate()
 	if err != nil {
-		return State{}, nil, err
+		return State{}, Extra{}, 0, nil, err
+	}
+
+	if fetchStatic {
+		staticExtra.TickSpacing = uint32(tickSpacing.Uint64())
+		staticExtra.Lookback = lookback.Int64()
+		staticExtra.Kinds = kinds
 	}
 
 	return State{
-		ReserveA:      getStateResult.ReserveA,
-		ReserveB:      getStateResult.ReserveB,
-		LastTimestamp: getStateResult.LastTimestamp.Int64(),
-	}, resp.BlockNumber, nil
+			ReserveA:           getStateResult.ReserveA,
+			ReserveB:           getStateResult.ReserveB,
+			LastTwaD8:          getStateResult.LastTwaD8,
+			LastLogPriceD8:     getStateResult.LastLogPriceD8,
+			LastTimestamp:      getStateResult.LastTimestamp.Int64(),
+			ActiveTick:         getStateResult.ActiveTick,
+			ProtocolFeeRatioD3: getStateResult.ProtocolFeeRatioD3,
+		}, Extra{
+			FeeAIn:             feeAIn.Uint64(),
+			FeeBIn:             feeBIn.Uint64(),
+			ProtocolFeeRatioD3: getStateResult.ProtocolFeeRatioD3,
+			ActiveTick:         getStateResult.ActiveTick,
+			LastTwaD8:          getStateResult.LastTwaD8,
+			LastLogPriceD8:     getStateResult.LastLogPriceD8,
+			LastTimestamp:      getStateResult.LastTimestamp.Int64(),
+		},
+		getStateResult.BinCounter, resp.BlockNumber, nil
 }
 
-func (t *PoolTracker) updatePool(pool entity.Pool, state State, blockNumber *big.Int) (entity.Pool, error) {
+// getBinsAndTicks fetches bins 1..binCounter, then the ticks holding the bins that are not merged. Calls are split
+// into chunks as pools can have thousands of bins.
+func (t *PoolTracker) getBinsAndTicks(ctx context.Context, poolAddress string, binCounter uint32,
+	blockNumber *big.Int) (map[uint32]Bin, map[int32]Tick, error) {
+	binIDs := make([]uint32, binCounter)
+	for i := range binIDs {
+		binIDs[i] = uint32(i + 1)
+	}
+	binResults := make([]GetBinResultWrapper, len(binIDs))
+	if err := t.aggregateChunks(ctx, blockNumber, len(binIDs), func(req *ethrpc.Request, i int) {
+		req.AddCall(&ethrpc.Call{
+			ABI:    maverickV2PoolABI,
+			Target: poolAddress,
+			Method: poolMethodGetBin,
+			Params: []interface{}{binIDs[i]},
+		}, []interface{}{&binResults[i]})
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	bins := make(map[uint32]Bin)
+	for i, binResult := range binResults {
+		if binResult.MergeId != 0 || binResult.TickBalance == nil || binResult.TickBalance.Sign() == 0 {
+			continue
+		}
+		bins[binIDs[i]] = Bin{
+			Tick:        binResult.Tick,
+			Kind:        binResult.Kind,
+			TickBalance: uint256.MustFromBig(binResult.TickBalance),
+		}
+	}
+
+	tickIndexes := lo.Uniq(lo.MapToSlice(bins, func(_ uint32, bin Bin) int32 { return bin.Tick }))
+	slices.Sort(tickIndexes)
+	tickResults := make([]GetTickResultWrapper, len(tickIndexes))
+	if err := t.aggregateChunks(ctx, blockNumber, len(tickIndexes), func(req *ethrpc.Request, i int) {
+		req.AddCall(&ethrpc.Call{
+			ABI:    maverickV2PoolABI,
+			Target: poolAddress,
+			Method: poolMethodGetTick,
+			Params: []interface{}{tickIndexes[i]},
+		}, []interface{}{&tickResults[i]})
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	ticks := make(map[int32]Tick, len(tickIndexes))
+	for i, tickResult := range tickResults {
+		if tickResult.TotalSupply == nil || tickResult.TotalSupply.Sign() == 0 {
+			continue
+		}
+		ticks[tickIndexes[i]] = Tick{
+			ReserveA:    uint256.MustFromBig(tickResult.ReserveA),
+			ReserveB:    uint256.MustFromBig(tickResult.ReserveB),
+			TotalSupply: uint256.MustFromBig(tickResult.TotalSupply),
+			BinIDs:      tickResult.BinIdsByTick,
+		}
+	}
+
+	return bins, ticks, nil
+}
+
+func (t *PoolTracker) aggregateChunks(ctx context.Context, blockNumber *big.Int, n int,
+	addCall func(req *ethrpc.Request, i int)) error {
+	g := pool.New().WithContext(ctx)
+	for start := 0; start < n; start += defaultChunk {
+		end := min(start+defaultChunk, n)
+		g.Go(func(ctx context.Context) error {
+			req := t.ethrpcClient.NewRequest().SetContext(ctx).SetBlockNumber(blockNumber).SetRequireSuccess(true)
+			for i := start; i < end; i++ {
+				addCall(req, i)
+			}
+			_, err := req.Aggregate()
+			return err
+		})
+	}
+	return g.Wait()
+}
+
+func (t *PoolTracker) updatePool(pool entity.Pool, state State, extra Extra, staticExtra StaticExtra,
+	blockNumber *big.Int) (entity.Pool, error) {
+	extraBytes, err := json.Marshal(extra)
+	if err != nil {
+		return pool, err
+	}
+	staticExtraBytes, err := json.Marshal(staticExtra)
+	if err != nil {
+		return pool, err
+	}
+
 	pool.Reserves = entity.PoolReserves{
 		state.ReserveA.String(),
 		state.ReserveB.String(),
 	}
+	pool.Extra = string(extraBytes)
+	pool.StaticExtra = string(staticExtraBytes)
 	pool.BlockNumber = blockNumber.Uint64()
 	pool.Timestamp = state.LastTimestamp
 
@@ -0,0 +1,221 @@
+package maverickv2
+
+import (
+	"slices"
+
+	"github.com/holiman/uint256"
+
+	bignumber "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/big256"
+)
+
+type swapResult struct {
+	amountIn     *uint256.Int
+	amountOut    *uint256.Int
+	fee          *uint256.Int
+	ticksCrossed int
+	swapInfo     SwapInfo
+}
+
+// tickDelta is the outcome of swapping inside a single tick, see MaverickV2Pool._computeSwapExactIn/Out.
+type tickDelta struct {
+	deltaInErc *uint256.Int // amount paid by the user including fees
+	feeBasis   *uint256.Int
+	deltaOut   *uint256.Int
+	excess     *uint256.Int
+}
+
+// swap walks the ticks from the active tick in the swap direction without modifying the pool. All amounts have 18
+// decimals. Token A in moves the price (and active tick) up, token B in moves it down.
+func (p *PoolSimulator) swap(amount *uint256.Int, tokenAIn, exactOutput bool) (*swapResult, error) {
+	fee := uint256.NewInt(p.feeBIn)
+	if tokenAIn {
+		fee.SetUint64(p.feeAIn)
+	}
+
+	var (
+		res = &swapResult{
+			amountIn:  new(uint256.Int),
+			amountOut: new(uint256.Int),
+			fee:       new(uint256.Int),
+			swapInfo:  SwapInfo{Ticks: make(map[int32]Tick)},
+		}
+		excess = amount.Clone()
+		tick   = p.activeTick
+		pos    = p.tickPosition(tick, tokenAIn)
+	)
+
+	for iterations := 0; ; iterations++ {
+		if iterations > maxSwapIterations {
+			return nil, ErrTooManyTicksCrossed
+		}
+
+		if t, ok := p.ticks[tick]; ok {
+			lower, upper, err := tickSqrtPrices(p.tickSpacing, tick)
+			if err != nil {
+				return nil, err
+			}
+
+			var delta *tickDelta
+			if exactOutput {
+				delta = computeSwapExactOut(t, lower, upper, excess, fee, tokenAIn)
+			} else {
+				delta = computeSwapExactIn(t, lower, upper, excess, fee, tokenAIn)
+			}
+
+			if delta != nil {
+				res.amountIn.Add(res.amountIn, delta.deltaInErc)
+				res.amountOut.Add(res.amountOut, delta.deltaOut)
+				res.fee.Add(res.fee, delta.feeBasis)
+				excess = delta.excess
+				res.swapInfo.Ticks[tick] = t.applyDelta(delta, tokenAIn, p.protocolFeeRatioD3)
+			}
+		}
+
+		if excess.IsZero() {
+			break
+		}
+
+		// move on to the next tick holding liquidity in the swap direction
+		if tokenAIn {
+			if pos++; pos >= len(p.sortedTicks) {
+				return nil, ErrInsufficientLiquidity
+			}
+		} else {
+			if pos--; pos < 0 {
+				return nil, ErrInsufficientLiquidity
+			}
+		}
+		tick = p.sortedTicks[pos]
+		res.ticksCrossed++
+	}
+
+	res.swapInfo.ActiveTick = tick
+	res.swapInfo.LastLogPriceD8 = p.lastLogPriceD8
+	if t, ok := res.swapInfo.Ticks[tick]; ok {
+		lower, upper, err := tickSqrtPrices(p.tickSpacing, tick)
+		if err != nil {
+			return nil, err
+		}
+		sqrtPrice := getSqrtPrice(t.ReserveA, t.ReserveB, lower, upper, getTickL(t.ReserveA, t.ReserveB, lower, upper))
+		res.swapInfo.LastLogPriceD8 = logPriceD8(tick, sqrtPrice, lower, upper)
+	}
+
+	return res, nil
+}
+
+// tickPosition returns the index in sortedTicks where the walk starts. If the active tick is not tracked, it is the
+// position right before the next tracked tick in the swap direction.
+func (p *PoolSimulator) tickPosition(tick int32, tokenAIn bool) int {
+	pos, found := slices.BinarySearch(p.sortedTicks, tick)
+	if found || !tokenAIn {
+		return pos
+	}
+	return pos - 1
+}
+
+func computeSwapExactIn(t Tick, lower, upper, amountIn, fee *uint256.Int, tokenAIn bool) *tickDelta {
+	reserveOut := t.ReserveA
+	if tokenAIn {
+		reserveOut = t.ReserveB
+	}
+	liquidity := getTickL(t.ReserveA, t.ReserveB, lower, upper)
+	if reserveOut.IsZero() || liquidity.IsZero() {
+		return nil
+	}
+	sqrtPrice := getSqrtPrice(t.ReserveA, t.ReserveB, lower, upper, liquidity)
+
+	// amount needed to push the price to the tick edge
+	var binAmountIn *uint256.Int
+	if tokenAIn {
+		binAmountIn = mulUp(liquidity, new(uint256.Int).Sub(upper, sqrtPrice))
+	} else {
+		binAmountIn = clip(divUp(liquidity, lower), divDown(liquidity, sqrtPrice))
+	}
+
+	oneMinusFee := new(uint256.Int).Sub(bignumber.BONE, fee)
+	delta := &tickDelta{excess: new(uint256.Int)}
+	if mulDown(amountIn, oneMinusFee).Cmp(binAmountIn) >= 0 {
+		delta.feeBasis = mulDiv(binAmountIn, fee, oneMinusFee, true)
+		delta.deltaInErc = bignumber.Min(new(uint256.Int).Add(binAmountIn, delta.feeBasis), amountIn).Clone()
+		delta.deltaOut = reserveOut.Clone()
+		delta.excess = new(uint256.Int).Sub(amountIn, delta.deltaInErc)
+		return delta
+	}
+
+	binAmountIn = mulDown(amountIn, oneMinusFee)
+	delta.deltaInErc = amountIn.Clone()
+	delta.feeBasis = new(uint256.Int).Sub(amountIn, binAmountIn)
+
+	// A in: sqrtP' = sqrtP + in/L and out = in / (sqrtP * sqrtP'),
+	// B in: 1/sqrtP' = 1/sqrtP + in/L and out = in * sqrtP * sqrtP'
+	var endSqrtPrice, startFactor *uint256.Int
+	if tokenAIn {
+		startFactor = divDown(bignumber.BONE, sqrtPrice)
+		endSqrtPrice = new(uint256.Int).Add(sqrtPrice, divDown(binAmountIn, liquidity))
+	} else {
+		startFactor = sqrtPrice
+		endSqrtPrice = new(uint256.Int).Add(divDown(bignumber.BONE, sqrtPrice), divDown(binAmountIn, liquidity))
+	}
+	delta.deltaOut = bignumber.Min(reserveOut, mulDiv(binAmountIn, startFactor, endSqrtPrice, false)).Clone()
+
+	return delta
+}
+
+func computeSwapExactOut(t Tick, lower, upper, amountOut, fee *uint256.Int, tokenAIn bool) *tickDelta {
+	reserveOut := t.ReserveA
+	if tokenAIn {
+		reserveOut = t.ReserveB
+	}
+	liquidity := getTickL(t.ReserveA, t.ReserveB, lower, upper)
+	if reserveOut.IsZero() || liquidity.IsZero() {
+		return nil
+	}
+	sqrtPrice := getSqrtPrice(t.ReserveA, t.ReserveB, lower, upper, liquidity)
+
+	delta := &tickDelta{
+		deltaOut: bignumber.Min(amountOut, reserveOut).Clone(),
+		excess:   clip(amountOut, reserveOut),
+	}
+
+	// A in: 1/sqrtP' = 1/sqrtP - out/L and in = out * sqrtP * sqrtP',
+	// B in: sqrtP' = sqrtP - out/L and in = out / (sqrtP * sqrtP')
+	var startFactor, endSqrtPrice, edge *uint256.Int
+	outPerLiquidity := divUp(delta.deltaOut, liquidity)
+	if tokenAIn {
+		startFactor = sqrtPrice
+		endSqrtPrice = clip(divDown(bignumber.BONE, sqrtPrice), outPerLiquidity)
+		edge = divDown(bignumber.BONE, upper)
+	} else {
+		startFactor = divUp(bignumber.BONE, sqrtPrice)
+		endSqrtPrice = clip(sqrtPrice, outPerLiquidity)
+		edge = lower
+	}
+	if endSqrtPrice.Lt(edge) {
+		endSqrtPrice = edge
+	}
+	binAmountIn := mulDiv(delta.deltaOut, startFactor, endSqrtPrice, true)
+
+	oneMinusFee := new(uint256.Int).Sub(bignumber.BONE, fee)
+	delta.feeBasis = mulDiv(binAmountIn, fee, oneMinusFee, true)
+	delta.deltaInErc = new(uint256.Int).Add(binAmountIn, delta.feeBasis)
+
+	return delta
+}
+
+// applyDelta credits the amount paid minus the protocol fee to the tick and debits the amount sent out.
+func (t Tick) applyDelta(delta *tickDelta, tokenAIn bool, protocolFeeRatioD3 uint8) Tick {
+	deltaInBin := delta.deltaInErc
+	if protocolFeeRatioD3 != 0 {
+		protocolFee := mulDiv(delta.feeBasis, uint256.NewInt(uint64(protocolFeeRatioD3)), uint256.NewInt(1000), false)
+		deltaInBin = clip(deltaInBin, protocolFee)
+	}
+
+	if tokenAIn {
+		t.ReserveA = new(uint256.Int).Add(t.ReserveA, deltaInBin)
+		t.ReserveB = clip(t.ReserveB, delta.deltaOut)
+	} else {
+		t.ReserveB = new(uint256.Int).Add(t.ReserveB, deltaInBin)
+		t.ReserveA = clip(t.ReserveA, delta.deltaOut)
+	}
+	return t
+}
@@ -1,9 +1,109 @@
 package maverickv2
 
-import "math/big"
+import (
+	"math/big"
+
+	"github.com/holiman/uint256"
+)
 
 type State struct {
-	ReserveA      *big.Int `json:"reserveA"`
-	ReserveB      *big.Int `json:"reserveB"`
-	LastTimestamp int64    `json:"lastTimestamp"`
+	ReserveA           *big.Int `json:"reserveA"`
+	ReserveB           *big.Int `json:"reserveB"`
+	LastTwaD8          int64    `json:"lastTwaD8"`
+	LastLogPriceD8     int64    `json:"lastLogPriceD8"`
+	LastTimestamp      int64    `json:"lastTimestamp"`
+	ActiveTick         int32    `json:"activeTick"`
+	ProtocolFeeRatioD3 uint8    `json:"protocolFeeRatioD3"`
+}
+
+type Extra struct {
+	FeeAIn             uint64         `json:"feeAIn"` // 18 decimals
+	FeeBIn             uint64         `json:"feeBIn"` // 18 decimals
+	ProtocolFeeRatioD3 uint8          `json:"protocolFeeRatioD3"`
+	ActiveTick         int32          `json:"activeTick"`
+	LastTwaD8          int64          `json:"lastTwaD8"`
+	LastLogPriceD8     int64          `json:"lastLogPriceD8"`
+	LastTimestamp      int64          `json:"lastTimestamp"`
+	Ticks              map[int32]Tick `json:"ticks"`
+	Bins               map[uint32]Bin `json:"bins"`
+}
+
+type StaticExtra struct {
+	TickSpacing uint32 `json:"tickSpacing"`
+	Lookback    int64  `json:"lookback"` // seconds
+	Kinds       uint8  `json:"kinds"`
 }
+
+// Tick holds the internal (18 decimals) reserves of all bins living in a tick. Bins own a share of the tick
+// reserves proportional to their TickBalance over TotalSupply.
+type Tick struct {
+	ReserveA    *uint256.Int  `json:"rA"`
+	ReserveB    *uint256.Int  `json:"rB"`
+	TotalSupply *uint256.Int  `json:"tS"`
+	BinIDs      [Kinds]uint32 `json:"bI"`
+}
+
+// Bin is an active (not merged) bin of the pool.
+type Bin struct {
+	Tick        int32        `json:"t"`
+	Kind        uint8        `json:"k"`
+	TickBalance *uint256.Int `json:"tB"`
+}
+
+type Gas struct {
+	BaseGas      int64
+	CrossTickGas int64
+}
+
+// SwapInfo carries the ticks touched by a swap and the resulting pool position, applied by UpdateBalance.
+type SwapInfo struct {
+	// ActiveTick is the active tick of the pool after the swap.
+	ActiveTick int32 `json:"activeTick"`
+	// LastLogPriceD8 is the log price of the pool after the swap, that the lookback-based bin movement moves towards.
+	LastLogPriceD8 int64 `json:"lastLogPriceD8"`
+	// Ticks are the ticks whose reserves the swap changed, with their reserves after the swap.
+	Ticks map[int32]Tick `json:"-"`
+}
+
+type (
+	GetStateResult struct {
+		ReserveA           *big.Int `json:"reserveA"`
+		ReserveB           *big.Int `json:"reserveB"`
+		LastTwaD8          int64    `json:"lastTwaD8"`
+		LastLogPriceD8     int64    `json:"lastLogPriceD8"`
+		LastTimestamp      *big.Int `json:"lastTimestamp"`
+		ActiveTick         int32    `json:"activeTick"`
+		IsLocked           bool     `json:"isLocked"`
+		BinCounter         uint32   `json:"binCounter"`
+		ProtocolFeeRatioD3 uint8    `json:"protocolFeeRatioD3"`
+	}
+
+	// because the result is a tuple with internal type = struct IMaverickV2Pool.State, we need to wrap it in a struct like this
+	GetStateResultWrapper struct {
+		GetStateResult
+	}
+
+	GetBinResult struct {
+		MergeBinBalance *big.Int `json:"mergeBinBalance"`
+		TickBalance     *big.Int `json:"tickBalance"`
+		TotalSupply     *big.Int `json:"totalSupply"`
+		Kind            uint8    `json:"kind"`
+		Tick            int32    `json:"tick"`
+		MergeId         uint32   `json:"mergeId"`
+	}
+
+	GetBinResultWrapper struct {
+		GetBinResult
+	}
+
+	GetTickResult struct {
+		ReserveA     *big.Int  `json:"reserveA"`
+		ReserveB     *big.Int  `json:"reserveB"`
+		TotalSupply  *big.Int  `json:"totalSupply"`
+		BinIdsByTick [4]uint32 `json:"binIdsByTick"`
+	}
+
+	GetTickResultWrapper struct {
+		GetTickResult
+	}
+)
@@ -4,11 +4,10 @@ import (
 	"context"
 	"errors"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/go-resty/resty/v2"
 
 	mxtrading "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mx-trading"
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -24,15 +23,18 @@ var (
 )
 
 type HTTPClient struct {
-	client *resty.Client
+	client *rfq.Client
 	config *mxtrading.HTTPClientConfig
 }
 
-func NewHTTPClient(config *mxtrading.HTTPClientConfig) *HTTPClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount)
+func NewHTTPClient(config *mxtrading.HTTPClientConfig, opts ...rfq.Option) *HTTPClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          mxtrading.DexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, opts...)
 
 	return &HTTPClient{
 		config: config,
@@ -41,22 +43,17 @@ func NewHTTPClient(config *mxtrading.HTTPClientConfig) *HTTPClient {
 }
 
 func (c HTTPClient) Quote(ctx context.Context, params mxtrading.OrderParams) (mxtrading.SignedOrderResult, error) {
-	req := c.client.R().SetContext(ctx).SetBody(params)
+	req := c.client.R(ctx).SetBody(params)
 
 	var result mxtrading.SignedOrderResult
 	var errResult string
-	resp, err := req.SetResult(&result).SetError(&errResult).Post(orderEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&errResult), resty.MethodPost, orderEndpoint)
 	if err != nil {
 		return mxtrading.SignedOrderResult{}, err
 	}
 
 	if !resp.IsSuccess() {
-		klog.WithFields(ctx, klog.Fields{
-			"rfq.client": mxtrading.DexType,
-			"rfq.resp":   util.MaxBytesToString(resp.Body(), 256),
-			"rfq.status": resp.StatusCode(),
-		}).Error("quote failed")
-		return mxtrading.SignedOrderResult{}, parseOrderError(errResult)
+		return mxtrading.SignedOrderResult{}, c.client.Fail(ctx, resp, "quote failed", parseOrderError(errResult))
 	}
 
 	return result, nil
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+
+	mxtrading "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mx-trading"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.MXTrading.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "signed order",
+			Quote: rfqtest.Quote{AmountOut: "2500000000", Expiry: 2e9},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				extra := res.Extra.(mxtrading.RFQExtra)
+				assert.Equal(t, "0x6131b5fae19ea4f9d964eac0408e4408b66337b5", extra.Router)
+				assert.Equal(t, rfqtest.MakerTraitsExpiring(2e9).String(), extra.Order.MakerTraits)
+			},
+		},
+		rfqtest.Case{
+			Name:  "order too small",
+			Quote: rfqtest.Quote{Error: errMsgOrderIsTooSmall},
+			ErrIs: []error{ErrOrderIsTooSmall, rfq.ErrBadRequest},
+		},
+		rfqtest.Case{
+			Name:  "server error",
+			Quote: rfqtest.Quote{Status: http.StatusInternalServerError},
+			ErrIs: []error{ErrRFQFailed, rfq.ErrServer},
+		},
+	)
+	rfqtest.Run(t, rfqtest.MXTrading, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		config := &mxtrading.Config{
+			Router: "0x6131b5fae19ea4f9d964eac0408e4408b66337b5",
+			HTTP: mxtrading.HTTPClientConfig{
+				BaseURL:    server.URL,
+				Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+				RetryCount: 1,
+			},
+		}
+		h := mxtrading.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+
+		return h.RFQ(context.Background(), pool.RFQParams{
+			RFQSender: "0x3333333333333333333333333333333333333333",
+			SwapInfo: mxtrading.SwapInfo{
+				BaseToken:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				BaseTokenAmount: "1000000000000000000",
+				QuoteToken:      "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			},
+			IndicativeAmountOut: indicative,
+		})
+	})
+}
@@ -1,9 +1,14 @@
 package mxtrading
 
-import "github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+import (
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+)
 
 type HTTPClientConfig struct {
-	BaseURL    string                `mapstructure:"base_url" json:"base_url"`
-	Timeout    durationjson.Duration `mapstructure:"timeout" json:"timeout"`
-	RetryCount int                   `mapstructure:"retry_count" json:"retry_count"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
@@ -2,6 +2,8 @@ package mxtrading
 
 import (
 	"errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 )
 
 const DexType = "mx-trading"
@@ -11,7 +13,7 @@ var (
 )
 
 var (
-	ErrEmptyPriceLevels                    = errors.New("empty price levels")
+	ErrEmptyPriceLevels                    = pricelevel.ErrEmptyPriceLevels
 	ErrAmountInIsLessThanLowestPriceLevel  = errors.New("amountIn is less than lowest price level")
 	ErrAmountInIsGreaterThanTotalLevelSize = errors.New("amountIn is greater than total level size")
 	ErrAmountOutIsGreaterThanInventory     = errors.New("amountOut is greater than inventory")
@@ -1,7 +1,7 @@
 package mxtrading
 
 import (
-	"math"
+	"errors"
 	"math/big"
 	"strings"
 
@@ -11,14 +11,15 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
 type PoolSimulator struct {
 	pool.Pool
 
-	ZeroToOnePriceLevels []PriceLevel `json:"0to1"`
-	OneToZeroPriceLevels []PriceLevel `json:"1to0"`
+	ZeroToOnePriceLevels *pricelevel.Book
+	OneToZeroPriceLevels *pricelevel.Book
 
 	token0, token1 entity.PoolToken
 
@@ -34,6 +35,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		return nil, err
 	}
 
+	token0, token1 := *entityPool.Tokens[0], *entityPool.Tokens[1]
 	return &PoolSimulator{
 		Pool: pool.Pool{
 			Info: pool.PoolInfo{
@@ -47,11 +49,11 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 					func(item string, index int) *big.Int { return bignumber.NewBig(item) }),
 			},
 		},
-		ZeroToOnePriceLevels: extra.ZeroToOnePriceLevels,
-		OneToZeroPriceLevels: extra.OneToZeroPriceLevels,
+		ZeroToOnePriceLevels: newBook(extra.ZeroToOnePriceLevels, token0.Decimals, token1.Decimals),
+		OneToZeroPriceLevels: newBook(extra.OneToZeroPriceLevels, token1.Decimals, token0.Decimals),
 
-		token0:    *entityPool.Tokens[0],
-		token1:    *entityPool.Tokens[1],
+		token0:    token0,
+		token1:    token1,
 		timestamp: entityPool.Timestamp,
 		gas:       defaultGas,
 	}, nil
@@ -69,21 +71,43 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	}
 }
 
+// SpotPrice returns the price of the best price level, whose size is the minimum amount in.
+func (p *PoolSimulator) SpotPrice(tokenIn, _ string) (*big.Float, error) {
+	spotPrice, err := p.book(tokenIn).SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth walks the price levels, filling each one whole while the average price stays within the impact, and the
+// part of the first one it does not where it reaches the impact. The inventory of the market maker is not accounted
+// for.
+func (p *PoolSimulator) Depth(tokenIn, _ string, impactBps int64) (*pool.DepthPoint, error) {
+	amountIn, amountOut, err := p.book(tokenIn).Depth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
+func (p *PoolSimulator) book(tokenIn string) *pricelevel.Book {
+	if tokenIn == p.token0.Address {
+		return p.ZeroToOnePriceLevels
+	}
+	return p.OneToZeroPriceLevels
+}
+
 func (p *PoolSimulator) swap(
 	amountIn *big.Int,
 	baseToken, quoteToken entity.PoolToken,
 	inventoryLimit *big.Int,
-	priceLevel []PriceLevel,
+	book *pricelevel.Book,
 ) (*pool.CalcAmountOutResult, error) {
-	amountInF, _ := amountIn.Float64()
-	amountInAfterDecimalsF := amountInF / math.Pow10(int(baseToken.Decimals))
-	fillPrice, err := findFillPrice(amountInAfterDecimalsF, priceLevel)
+	amountOut, err := getAmountOut(amountIn, book)
 	if err != nil {
 		return nil, err
 	}
-	amountOutAfterDecimalsF := amountInAfterDecimalsF * fillPrice
-	amountOutF := amountOutAfterDecimalsF * math.Pow10(int(quoteToken.Decimals))
-	amountOut, _ := big.NewFloat(amountOutF).Int(nil)
 
 	if amountOut.Cmp(inventoryLimit) > 0 {
 		return nil, ErrAmountOutIsGreaterThanInventory
@@ -104,14 +128,11 @@ func (p *PoolSimulator) swap(
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	tokenIn, tokenOut := params.TokenAmountIn.Token, params.TokenAmountOut.Token
 	amountIn, amountOut := params.TokenAmountIn.Amount, params.TokenAmountOut.Amount
-	amountInF, _ := amountIn.Float64()
 
 	if tokenIn == p.token0.Address {
-		amountInAfterDecimalsF := amountInF / math.Pow10(int(p.token0.Decimals))
-		p.ZeroToOnePriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.ZeroToOnePriceLevels)
+		p.ZeroToOnePriceLevels.Consume(amountIn)
 	} else {
-		amountInAfterDecimalsF := amountInF / math.Pow10(int(p.token1.Decimals))
-		p.OneToZeroPriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.OneToZeroPriceLevels)
+		p.OneToZeroPriceLevels.Consume(amountIn)
 	}
 
 	if _, _, err := params.SwapLimit.UpdateLimit(tokenOut, tokenIn, amountOut, amountIn); err != nil {
@@ -119,6 +140,13 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	}
 }
 
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.ZeroToOnePriceLevels = p.ZeroToOnePriceLevels.Clone()
+	cloned.OneToZeroPriceLevels = p.OneToZeroPriceLevels.Clone()
+	return &cloned
+}
+
 func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 	tokens, reserves := p.GetTokens(), p.GetReserves()
 	inventory := make(map[string]*big.Int, len(tokens))
@@ -137,44 +165,24 @@ func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 	return MetaInfo{Timestamp: p.timestamp}
 }
 
-func findFillPrice(amountInF float64, levels []PriceLevel) (float64, error) {
-	if len(levels) == 0 {
-		return 0, ErrEmptyPriceLevels
+func getAmountOut(amountIn *big.Int, book *pricelevel.Book) (*big.Int, error) {
+	if book.Len() == 0 {
+		return nil, ErrEmptyPriceLevels
 	}
 
-	if amountInF < levels[0].Size {
-		return 0, ErrAmountInIsLessThanLowestPriceLevel
+	if amountIn.Cmp(book.FirstLevelSize()) < 0 {
+		return nil, ErrAmountInIsLessThanLowestPriceLevel
 	}
 
-	var sizeFilled, price float64
-	for _, level := range levels {
-		partFillSize := amountInF - sizeFilled
-		if level.Size >= partFillSize {
-			price += (level.Price * partFillSize) / amountInF
-			sizeFilled += partFillSize
-			break
-		}
-
-		price += level.Price * level.Size / amountInF
-		sizeFilled += level.Size
+	amountOut, err := book.AmountOut(amountIn)
+	if errors.Is(err, pricelevel.ErrInsufficientLiquidity) {
+		return nil, ErrAmountInIsGreaterThanTotalLevelSize
 	}
-
-	if sizeFilled == amountInF {
-		return price, nil
-	}
-
-	return 0, ErrAmountInIsGreaterThanTotalLevelSize
+	return amountOut, err
 }
 
-func getNewPriceLevelsState(amountIn float64, priceLevels []PriceLevel) []PriceLevel {
-	for i, priceLevel := range priceLevels {
-		if amountIn < priceLevel.Size {
-			priceLevel.Size -= amountIn
-			priceLevels[i] = priceLevel
-			return priceLevels[i:]
-		}
-		amountIn -= priceLevel.Size
-	}
-
-	return nil
+func newBook(priceLevels []PriceLevel, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(lo.Map(priceLevels, func(priceLevel PriceLevel, _ int) pricelevel.Level {
+		return pricelevel.NewLevel(priceLevel.Price, priceLevel.Size)
+	}), decimalsIn, decimalsOut)
 }
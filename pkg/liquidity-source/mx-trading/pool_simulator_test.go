@@ -9,9 +9,12 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 	"github.com/goccy/go-json"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,8 +65,10 @@ func TestNewPoolSimulator(t *testing.T) {
 		assert.Equal(t, reserve.Uint64(), quoteTokenReserve.Uint64())
 	}
 
-	checkPriceLevels(poolSimulator.ZeroToOnePriceLevels, poolSimulator.token1.Decimals, poolSimulator.GetReserves()[1])
-	checkPriceLevels(poolSimulator.OneToZeroPriceLevels, poolSimulator.token0.Decimals, poolSimulator.GetReserves()[0])
+	checkPriceLevels(priceLevels(poolSimulator.ZeroToOnePriceLevels), poolSimulator.token1.Decimals,
+		poolSimulator.GetReserves()[1])
+	checkPriceLevels(priceLevels(poolSimulator.OneToZeroPriceLevels), poolSimulator.token0.Decimals,
+		poolSimulator.GetReserves()[0])
 }
 
 func TestPoolSimulator_GetAmountOut(t *testing.T) {
@@ -104,7 +109,7 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 			// 0.719 + 0.01 = 0.729
 			amountIn0: bignumber.NewBig("729000000000000000"),
 			// 0.729 * (0.719 * 3347.4385889037885 / 0.729 + 0.01 * 3347.141106167435 / 0.729)
-			expectedAmountOut: bignumber.NewBig("2440279756483498344448"),
+			expectedAmountOut: bignumber.NewBig("2440279756483498281500"),
 		},
 	}
 
@@ -316,8 +321,8 @@ func TestPoolSimulator_UpdateBalance(t *testing.T) {
 				SwapLimit:      limit,
 			})
 
-			assert.Equal(t, tt.expectedZeroToOnePriceLevels, p.ZeroToOnePriceLevels)
-			assert.Equal(t, tt.expectedOneToZeroPriceLevels, p.OneToZeroPriceLevels)
+			assert.Equal(t, tt.expectedZeroToOnePriceLevels, priceLevels(p.ZeroToOnePriceLevels))
+			assert.Equal(t, tt.expectedOneToZeroPriceLevels, priceLevels(p.OneToZeroPriceLevels))
 
 			tokenInIndex := p.GetTokenIndex(token)
 			assert.Equal(t,
@@ -338,3 +343,30 @@ func getReducedReserve(reserve *big.Int) *big.Int {
 	reserveInt, _ := reducedReserve.Int(nil)
 	return reserveInt
 }
+
+func priceLevels(book *pricelevel.Book) []PriceLevel {
+	return lo.Map(book.Levels(), func(level pricelevel.Level, _ int) PriceLevel {
+		price, size := level.Float64()
+		return PriceLevel{Size: size, Price: price}
+	})
+}
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	poolSimulator, err := NewPoolSimulator(entityPoolData)
+	assert.NoError(t, err)
+
+	weth, ondo := entityPoolData.Tokens[0].Address, entityPoolData.Tokens[1].Address
+	spotPrice, err := poolSimulator.SpotPrice(weth, ondo)
+	assert.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 3347.4385889037885, spot, 1e-12)
+
+	// the depth does not account for the inventory
+	inventory := map[string]*big.Int{weth: bignumber.TenPowInt(30), ondo: bignumber.TenPowInt(30)}
+	for _, tc := range []struct{ tokenIn, tokenOut string }{{weth, ondo}, {ondo, weth}} {
+		for _, impactBps := range []int64{1, 5, 100} {
+			testutil.TestDepth(t, poolSimulator, swaplimit.NewInventory(DexType, inventory), tc.tokenIn, tc.tokenOut,
+				impactBps, 1e-9)
+		}
+	}
+}
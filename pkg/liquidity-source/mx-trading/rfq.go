@@ -4,15 +4,17 @@ import (
 	"context"
 	"math/big"
 
+	helper1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch/helper"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	"github.com/KyberNetwork/logger"
 	"github.com/goccy/go-json"
 )
 
 type Config struct {
-	DexID  string           `json:"dexId"`
-	Router string           `json:"router"`
-	HTTP   HTTPClientConfig `mapstructure:"http" json:"http"`
+	DexID      string             `json:"dexId"`
+	Router     string             `json:"router"`
+	HTTP       HTTPClientConfig   `mapstructure:"http" json:"http"`
+	Validation pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -55,15 +57,27 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 		return nil, err
 	}
 
+	if err = pool.ValidateRFQFill(swapInfo.BaseTokenAmount, result.Order.TakingAmount); err != nil {
+		return nil, err
+	}
+
 	newAmountOut, _ := new(big.Int).SetString(result.Order.MakingAmount, 10)
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: newAmountOut,
 		Extra: RFQExtra{
 			Router:            h.config.Router,
 			SignedOrderResult: result,
 		},
-	}, nil
+	}
+	if expiration := helper1inch.NewMakerTraits(result.Order.MakerTraits).Expiration(); expiration != nil {
+		rfqResult.Deadline = expiration.Int64()
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
@@ -3,12 +3,11 @@ package client
 import (
 	"context"
 
-	"github.com/KyberNetwork/kutils/klog"
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
 
-	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/clipper"
 	nativev1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/native-v1"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
@@ -34,15 +33,18 @@ var (
 
 type HTTPClient struct {
 	config *nativev1.HTTPClientConfig
-	client *resty.Client
+	client *rfq.Client
 }
 
-func NewHTTPClient(config *nativev1.HTTPClientConfig) *HTTPClient {
-	client := resty.New().
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount).
-		SetHeaderVerbatim(headerApiKey, config.APIKey)
+func NewHTTPClient(config *nativev1.HTTPClientConfig, opts ...rfq.Option) *HTTPClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:           nativev1.DexType,
+		BaseURL:         config.BaseURL,
+		Timeout:         config.Timeout,
+		RetryCount:      config.RetryCount,
+		CircuitBreaker:  config.CircuitBreaker,
+		RequestIDHeader: headerRequestId,
+	}, append([]rfq.Option{rfq.WithHeaderVerbatim(headerApiKey, config.APIKey)}, opts...)...)
 
 	return &HTTPClient{
 		config: config,
@@ -51,23 +53,17 @@ func NewHTTPClient(config *nativev1.HTTPClientConfig) *HTTPClient {
 }
 
 func (c *HTTPClient) Quote(ctx context.Context, params nativev1.QuoteParams) (nativev1.QuoteResult, error) {
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		SetQueryParams(params.ToMap())
 
 	var result nativev1.QuoteResult
-	resp, err := req.SetResult(&result).SetError(&result).Get(pathFirmQuote)
+	resp, err := c.client.Execute(req.SetResult(&result).SetError(&result), resty.MethodGet, pathFirmQuote)
 	if err != nil {
 		return nativev1.QuoteResult{}, err
 	}
 
 	if !resp.IsSuccess() {
-		klog.WithFields(ctx, klog.Fields{
-			"client":        clipper.DexType,
-			"response":      result,
-			headerRequestId: resp.Header().Get(headerRequestId),
-		}).Error("quote failed")
-		return nativev1.QuoteResult{}, parseRFQError(result.Message)
+		return nativev1.QuoteResult{}, c.client.Fail(ctx, resp, "quote failed", parseRFQError(result.Message))
 	}
 
 	return result, nil
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+
+	nativev1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/native-v1"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq/rfqtest"
+)
+
+func TestRFQHandler_RFQ(t *testing.T) {
+	cases := append(rfqtest.NativeV1.Cases("1000000000000000000", "2500000000"),
+		rfqtest.Case{
+			Name:  "calldata offsets",
+			Quote: rfqtest.Quote{AmountOut: "2500000000"},
+			Check: func(t *testing.T, res *pool.RFQResult) {
+				assert.Equal(t, 36, res.Extra.(nativev1.QuoteResult).AmountOutMinimumOffset)
+			},
+		},
+		rfqtest.Case{
+			Name:  "maker error",
+			Quote: rfqtest.Quote{Error: errMsgAllPricerFailed},
+			ErrIs: []error{ErrRFQAllPricerFailed, rfq.ErrBadRequest},
+		},
+		rfqtest.Case{
+			Name:  "throttled",
+			Quote: rfqtest.Quote{Error: errMsgThrottled, Status: http.StatusTooManyRequests},
+			ErrIs: []error{ErrRFQRateLimit, rfq.ErrRateLimited},
+		},
+	)
+	rfqtest.Run(t, rfqtest.NativeV1, cases, func(t *testing.T, server *rfqtest.Server,
+		indicative *big.Int) (*pool.RFQResult, error) {
+		config := &nativev1.Config{HTTP: nativev1.HTTPClientConfig{
+			BaseURL:    server.URL,
+			Timeout:    durationjson.Duration{Duration: 100 * time.Millisecond},
+			RetryCount: 1,
+			APIKey:     "key",
+		}}
+		h := nativev1.NewRFQHandler(config, NewHTTPClient(&config.HTTP))
+
+		res, err := h.RFQ(context.Background(), pool.RFQParams{
+			NetworkID:    1,
+			Sender:       "0x3333333333333333333333333333333333333333",
+			RFQSender:    "0x3333333333333333333333333333333333333333",
+			RFQRecipient: "0x3333333333333333333333333333333333333333",
+			Slippage:     50,
+			SwapInfo: nativev1.SwapInfo{
+				BaseToken:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				BaseTokenAmount: "1000000000000000000",
+				QuoteToken:      "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				ExpirySecs:      30,
+			},
+			IndicativeAmountOut: indicative,
+		})
+		req := server.Requests()[0]
+		assert.Equal(t, "key", req.Header.Get(headerApiKey))
+		assert.Equal(t, "30", req.Query.Get("expiry_time"))
+		assert.Equal(t, "0.50", req.Query.Get("slippage"))
+		return res, err
+	})
+}
@@ -1,10 +1,15 @@
 package nativev1
 
-import "github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+import (
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+)
 
 type HTTPClientConfig struct {
-	BaseURL    string                `mapstructure:"base_url" json:"base_url"`
-	Timeout    durationjson.Duration `mapstructure:"timeout" json:"timeout"`
-	RetryCount int                   `mapstructure:"retry_count" json:"retry_count"`
-	APIKey     string                `mapstructure:"api_key" json:"api_key"`
+	BaseURL        string                   `mapstructure:"base_url" json:"base_url"`
+	Timeout        durationjson.Duration    `mapstructure:"timeout" json:"timeout"`
+	RetryCount     int                      `mapstructure:"retry_count" json:"retry_count"`
+	APIKey         string                   `mapstructure:"api_key" json:"api_key"`
+	CircuitBreaker rfq.CircuitBreakerConfig `mapstructure:"circuit_breaker" json:"circuit_breaker,omitempty"`
 }
@@ -3,14 +3,18 @@ package nativev1
 import (
 	"errors"
 
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
 )
 
-const DexType = "native-v1"
+const (
+	DexType = "native-v1"
+
+	bps = 10000
+)
 
 var (
-	defaultGas         = Gas{Quote: 300000}
-	bps        float64 = 10000
+	defaultGas = Gas{Quote: 300000}
 
 	chainById = map[valueobject.ChainID]string{
 		valueobject.ChainIDArbitrumOne:     "arbitrum",
@@ -26,7 +30,7 @@ var (
 )
 
 var (
-	ErrEmptyPriceLevels                       = errors.New("empty price levels")
+	ErrEmptyPriceLevels                       = pricelevel.ErrEmptyPriceLevels
 	ErrAmountInIsLessThanLowestPriceLevel     = errors.New("amountIn is less than lowest price level")
 	ErrAmountInIsGreaterThanHighestPriceLevel = errors.New("amountIn is greater than highest price level")
 	ErrAmountOutIsGreaterThanInventory        = errors.New("amountOut is greater than inventory")
@@ -1,7 +1,7 @@
 package nativev1
 
 import (
-	"math"
+	"errors"
 	"math/big"
 	"strings"
 
@@ -11,6 +11,7 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
@@ -20,8 +21,8 @@ type PoolSimulator struct {
 	MarketMaker          string
 	Token0               entity.PoolToken
 	Token1               entity.PoolToken
-	ZeroToOnePriceLevels []PriceLevel
-	OneToZeroPriceLevels []PriceLevel
+	ZeroToOnePriceLevels *pricelevel.Book
+	OneToZeroPriceLevels *pricelevel.Book
 	MinIn0, MinIn1       float64
 
 	timestamp      int64
@@ -38,6 +39,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		return nil, err
 	}
 
+	token0, token1 := *entityPool.Tokens[0], *entityPool.Tokens[1]
 	return &PoolSimulator{
 		Pool: pool.Pool{
 			Info: pool.PoolInfo{
@@ -51,10 +53,10 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 					func(item string, index int) *big.Int { return bignumber.NewBig(item) }),
 			},
 		},
-		Token0:               *entityPool.Tokens[0],
-		Token1:               *entityPool.Tokens[1],
-		ZeroToOnePriceLevels: extra.ZeroToOnePriceLevels,
-		OneToZeroPriceLevels: extra.OneToZeroPriceLevels,
+		Token0:               token0,
+		Token1:               token1,
+		ZeroToOnePriceLevels: newBook(extra.ZeroToOnePriceLevels, token0.Decimals, token1.Decimals),
+		OneToZeroPriceLevels: newBook(extra.OneToZeroPriceLevels, token1.Decimals, token0.Decimals),
 		MinIn0:               extra.MinIn0,
 		MinIn1:               extra.MinIn1,
 
@@ -75,19 +77,55 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	}
 }
 
+// SpotPrice returns the price of the best price level, net of the price tolerance.
+func (p *PoolSimulator) SpotPrice(tokenIn, _ string) (*big.Float, error) {
+	book, _ := p.book(tokenIn)
+	spotPrice, err := book.SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	spotPrice.Mul(spotPrice, big.NewRat(bps-int64(p.priceTolerance), bps))
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth walks the price levels, filling each one whole while the average price stays within the impact, and the
+// part of the first one it does not where it reaches the impact. It is zero if that amount is less than the minimum
+// amount in. The inventory of the market maker is not accounted for.
+func (p *PoolSimulator) Depth(tokenIn, _ string, impactBps int64) (*pool.DepthPoint, error) {
+	book, minIn := p.book(tokenIn)
+	amountIn, amountOut, err := book.Depth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	tokenInDecimals := p.Token0.Decimals
+	if tokenIn != p.Token0.Address {
+		tokenInDecimals = p.Token1.Decimals
+	}
+	if new(big.Rat).SetFrac(amountIn, bignumber.TenPowInt(tokenInDecimals)).Cmp(pricelevel.Decimal(minIn)) < 0 {
+		return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: new(big.Int), AmountOut: new(big.Int)}, nil
+	}
+	amountOut.Mul(amountOut, big.NewInt(bps-int64(p.priceTolerance)))
+	amountOut.Quo(amountOut, big.NewInt(bps))
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
+func (p *PoolSimulator) book(tokenIn string) (*pricelevel.Book, float64) {
+	if tokenIn == p.Token0.Address {
+		return p.ZeroToOnePriceLevels, p.MinIn0
+	}
+	return p.OneToZeroPriceLevels, p.MinIn1
+}
+
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	amtIn, amtOut := params.TokenAmountIn.Amount, params.TokenAmountOut.Amount
-	amtInF, _ := amtIn.Float64()
 	if params.TokenAmountIn.Token == p.Token0.Address {
-		amountInAfterDecimalsF := amtInF / math.Pow10(int(p.Token0.Decimals))
-		p.ZeroToOnePriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.ZeroToOnePriceLevels)
+		p.ZeroToOnePriceLevels.Consume(amtIn)
 		_, _, err := params.SwapLimit.UpdateLimit(p.Token1.Address, p.Token0.Address, amtOut, amtIn)
 		if err != nil {
 			logger.Errorf("unable to update native limit, error: %v", err)
 		}
 	} else {
-		amountInAfterDecimalsF := amtInF / math.Pow10(int(p.Token1.Decimals))
-		p.OneToZeroPriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.OneToZeroPriceLevels)
+		p.OneToZeroPriceLevels.Consume(amtIn)
 		_, _, err := params.SwapLimit.UpdateLimit(p.Token0.Address, p.Token1.Address, amtOut, amtIn)
 		if err != nil {
 			logger.Errorf("unable to update native limit, error: %v", err)
@@ -95,6 +133,13 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	}
 }
 
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.ZeroToOnePriceLevels = p.ZeroToOnePriceLevels.Clone()
+	cloned.OneToZeroPriceLevels = p.OneToZeroPriceLevels.Clone()
+	return &cloned
+}
+
 func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 	tokens, reserves := p.GetTokens(), p.GetReserves()
 	nativeTreasury := make(map[string]*big.Int, len(tokens))
@@ -109,18 +154,13 @@ func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 }
 
 func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.PoolToken, minBase float64,
-	inventoryLimit *big.Int, priceLevel []PriceLevel) (*pool.CalcAmountOutResult, error) {
-	amountInF, _ := amountIn.Float64()
-	amountInAfterDecimalsF := amountInF / math.Pow10(int(baseToken.Decimals))
-	maxQuoteF, _ := inventoryLimit.Float64()
-	maxQuoteAfterDecimalsF := maxQuoteF / math.Pow10(int(quoteToken.Decimals))
-	amountOutAfterDecimalsF, err := getAmountOut(amountInAfterDecimalsF, minBase, maxQuoteAfterDecimalsF, priceLevel)
+	inventoryLimit *big.Int, book *pricelevel.Book) (*pool.CalcAmountOutResult, error) {
+	amountOut, err := getAmountOut(amountIn, bignumber.TenPowInt(baseToken.Decimals), minBase, inventoryLimit, book)
 	if err != nil {
 		return nil, err
 	}
-	amountOutF := amountOutAfterDecimalsF * math.Pow10(int(quoteToken.Decimals))
-	amountOutF = amountOutF * (1 - float64(p.priceTolerance)/bps)
-	amountOut, _ := big.NewFloat(amountOutF).Int(nil)
+	amountOut.Mul(amountOut, big.NewInt(bps-int64(p.priceTolerance)))
+	amountOut.Quo(amountOut, big.NewInt(bps))
 
 	return &pool.CalcAmountOutResult{
 		TokenAmountOut: &pool.TokenAmount{Token: quoteToken.Address, Amount: amountOut},
@@ -137,42 +177,30 @@ func (p *PoolSimulator) swap(amountIn *big.Int, baseToken, quoteToken entity.Poo
 	}, nil
 }
 
-func getAmountOut(amtIn, minAmtIn, maxAmtOut float64, priceLevels []PriceLevel) (amountOut float64, err error) {
-	if len(priceLevels) == 0 {
-		return 0, ErrEmptyPriceLevels
-	}
-
-	if amtIn < minAmtIn {
-		return 0, ErrAmountInIsLessThanLowestPriceLevel
+func getAmountOut(amountIn, unitIn *big.Int, minAmountIn float64, maxAmountOut *big.Int,
+	book *pricelevel.Book) (*big.Int, error) {
+	if book.Len() == 0 {
+		return nil, ErrEmptyPriceLevels
 	}
 
-	for _, priceLevel := range priceLevels {
-		if amtIn <= priceLevel.Quote {
-			amountOut += amtIn * priceLevel.Price
-			if amountOut > maxAmtOut {
-				return 0, ErrAmountOutIsGreaterThanInventory
-			}
-			return amountOut, nil
-		}
-		amountOut += priceLevel.Quote * priceLevel.Price
-		amtIn -= priceLevel.Quote
+	if new(big.Rat).SetFrac(amountIn, unitIn).Cmp(pricelevel.Decimal(minAmountIn)) < 0 {
+		return nil, ErrAmountInIsLessThanLowestPriceLevel
 	}
-	return 0, ErrAmountInIsGreaterThanHighestPriceLevel
-}
 
-func getNewPriceLevelsState(amountIn float64, priceLevels []PriceLevel) []PriceLevel {
-	if len(priceLevels) == 0 {
-		return priceLevels
+	amountOut, err := book.AmountOut(amountIn)
+	if errors.Is(err, pricelevel.ErrInsufficientLiquidity) {
+		return nil, ErrAmountInIsGreaterThanHighestPriceLevel
+	} else if err != nil {
+		return nil, err
 	}
-
-	for i, priceLevel := range priceLevels {
-		if amountIn < priceLevel.Quote {
-			priceLevel.Quote -= amountIn
-			priceLevels[i] = priceLevel
-			return priceLevels[i:]
-		}
-		amountIn -= priceLevel.Quote
+	if amountOut.Cmp(maxAmountOut) > 0 {
+		return nil, ErrAmountOutIsGreaterThanInventory
 	}
+	return amountOut, nil
+}
 
-	return nil
+func newBook(priceLevels []PriceLevel, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(lo.Map(priceLevels, func(priceLevel PriceLevel, _ int) pricelevel.Level {
+		return pricelevel.NewLevel(priceLevel.Price, priceLevel.Quote)
+	}), decimalsIn, decimalsOut)
 }
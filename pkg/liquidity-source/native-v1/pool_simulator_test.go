@@ -4,11 +4,15 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 )
 
 var entityPool = entity.Pool{
@@ -70,7 +74,7 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 		{
 			name:              "it should return correct amountOut when swap in levels",
 			amountIn1:         big.NewInt(3_000_000),
-			expectedAmountOut: bigIntFromString("3282719618942082560"),
+			expectedAmountOut: bigIntFromString("3282719618942083200"),
 		},
 		{
 			name:              "it should return correct amountOut when swap from token0",
@@ -80,7 +84,7 @@ func TestPoolSimulator_GetAmountOut(t *testing.T) {
 		{
 			name:              "it should return correct amountOut when swap in all levels",
 			amountIn1:         big.NewInt(152_000_000),
-			expectedAmountOut: bigIntFromString("166324460693065564160"),
+			expectedAmountOut: bigIntFromString("166324460693065548800"),
 		},
 		{
 			name:        "it should return error when swap more than inventory",
@@ -163,7 +167,7 @@ func TestPoolSimulator_UpdateBalance(t *testing.T) {
 				{Quote: 4.659919497201971, Price: 0.91245042136692},
 				{Quote: 4.66001949720197, Price: 0.90924546691228}},
 			expectedOneToZeroPriceLevels: []PriceLevel{
-				{Quote: 8277.528175741083, Price: 1.0942398729806944},
+				{Quote: 8277.528175741085, Price: 1.0942398729806944},
 				{Quote: 25244.263002363805, Price: 1.0939119116852096},
 				{Quote: 32092.9359692824, Price: 1.0937921053280593},
 				{Quote: 33219.273417201824, Price: 1.0936723252106664},
@@ -190,8 +194,41 @@ func TestPoolSimulator_UpdateBalance(t *testing.T) {
 				TokenAmountOut: *amountOut.TokenAmountOut,
 				SwapLimit:      limit,
 			})
-			assert.Equal(t, tt.expectedZeroToOnePriceLevels, p.ZeroToOnePriceLevels)
-			assert.Equal(t, tt.expectedOneToZeroPriceLevels, p.OneToZeroPriceLevels)
+			assert.Equal(t, tt.expectedZeroToOnePriceLevels, priceLevels(p.ZeroToOnePriceLevels))
+			assert.Equal(t, tt.expectedOneToZeroPriceLevels, priceLevels(p.OneToZeroPriceLevels))
 		})
 	}
 }
+
+func priceLevels(book *pricelevel.Book) []PriceLevel {
+	return lo.Map(book.Levels(), func(level pricelevel.Level, _ int) PriceLevel {
+		price, quote := level.Float64()
+		return PriceLevel{Quote: quote, Price: price}
+	})
+}
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	poolSimulator, err := NewPoolSimulator(entityPool)
+	assert.NoError(t, err)
+	poolSimulator.priceTolerance = 10
+
+	wmatic, usdt := entityPool.Tokens[0].Address, entityPool.Tokens[1].Address
+	spotPrice, err := poolSimulator.SpotPrice(wmatic, usdt)
+	assert.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 0.91245042136692e-12*0.999, spot, 1e-12)
+
+	// the depth does not account for the inventory
+	inventory := map[string]*big.Int{wmatic: bignumber.TenPowInt(30), usdt: bignumber.TenPowInt(30)}
+	for _, tc := range []struct{ tokenIn, tokenOut string }{{wmatic, usdt}, {usdt, wmatic}} {
+		for _, impactBps := range []int64{1, 10, 100} {
+			testutil.TestDepth(t, poolSimulator, swaplimit.NewInventory("", inventory), tc.tokenIn, tc.tokenOut,
+				impactBps, 1e-6)
+		}
+	}
+
+	poolSimulator.MinIn0 = 100
+	point, err := poolSimulator.Depth(wmatic, usdt, 10)
+	assert.NoError(t, err)
+	assert.Zero(t, point.AmountIn.Sign(), "the depth is less than the minimum amount in")
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type Config struct {
-	DexID string           `json:"dexId"`
-	HTTP  HTTPClientConfig `mapstructure:"http" json:"http"`
+	DexID      string             `json:"dexId"`
+	HTTP       HTTPClientConfig   `mapstructure:"http" json:"http"`
+	Validation pool.RFQValidation `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -58,10 +59,15 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 
 	newAmountOut, _ := new(big.Int).SetString(result.AmountOut, 10)
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: newAmountOut,
 		Extra:        result,
-	}, nil
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
@@ -0,0 +1,34 @@
+package quoter
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	DexType = "quoter"
+
+	defaultMethod         = "quoteExactIn"
+	defaultGas      int64 = 200000
+	callTimeout           = 5 * time.Second
+	maxCachedQuotes       = 10000
+
+	ArgPool     = "pool"
+	ArgTokenIn  = "tokenIn"
+	ArgTokenOut = "tokenOut"
+	ArgAmountIn = "amountIn"
+)
+
+var (
+	defaultArgs = []string{ArgPool, ArgTokenIn, ArgTokenOut, ArgAmountIn}
+
+	ErrNoEthClient          = errors.New("quoter pool requires an eth client")
+	ErrInvalidQuoter        = errors.New("invalid quoter address")
+	ErrMethodNotFound       = errors.New("quoter method not found")
+	ErrInvalidArg           = errors.New("invalid quoter method argument")
+	ErrInvalidToken         = errors.New("invalid token")
+	ErrInvalidAmountIn      = errors.New("invalid amount in")
+	ErrInvalidAmountOut     = errors.New("invalid amount out")
+	ErrQuoteFailed          = errors.New("quote call failed")
+	ErrOverridesUnsupported = errors.New("eth client does not support state overrides")
+)
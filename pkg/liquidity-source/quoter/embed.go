@@ -0,0 +1,6 @@
+package quoter
+
+import _ "embed"
+
+//go:embed abis/quoter.json
+var quoterABIData []byte
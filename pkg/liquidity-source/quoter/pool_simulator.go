@@ -0,0 +1,240 @@
+package quoter
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/goccy/go-json"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+// PoolSimulator prices swaps by calling a quoter contract at the pool block instead of simulating the pool in Go. It
+// makes pools routable before their math is ported, and serves as a ground truth for the ported simulators.
+//
+// Uncached quotes are blocking eth_calls: routers should bound them with pool.CalcAmountOutParams.Ctx, callTimeout
+// applying otherwise.
+//
+// Quotes do not reflect swaps applied with UpdateBalance, so CloneState is not implemented and the pool can only be
+// used once per route. The eth client is not serialized: a decoded PoolSimulator returns ErrNoEthClient.
+type PoolSimulator struct {
+	pool.Pool
+	staticExtra StaticExtra
+
+	client    ethereum.ContractCaller                        `msgpack:"-"`
+	method    *abi.Method                                    `msgpack:"-"`
+	overrides *map[common.Address]gethclient.OverrideAccount `msgpack:"-"`
+	cache     *quoteCache                                    `msgpack:"-"`
+}
+
+var _ = pool.RegisterFactory(DexType, NewPoolSimulator)
+
+func NewPoolSimulator(params pool.FactoryParams) (*PoolSimulator, error) {
+	entityPool := params.EntityPool
+	if params.EthClient == nil {
+		return nil, ErrNoEthClient
+	}
+
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(entityPool.StaticExtra), &staticExtra); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(staticExtra.Quoter) {
+		return nil, ErrInvalidQuoter
+	}
+	var extra Extra
+	if len(entityPool.Extra) > 0 {
+		if err := json.Unmarshal([]byte(entityPool.Extra), &extra); err != nil {
+			return nil, err
+		}
+	}
+
+	method, err := parseMethod(&staticExtra)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PoolSimulator{
+		Pool: pool.Pool{
+			Info: pool.PoolInfo{
+				Address:  strings.ToLower(entityPool.Address),
+				Exchange: entityPool.Exchange,
+				Type:     entityPool.Type,
+				Tokens: lo.Map(entityPool.Tokens, func(token *entity.PoolToken, _ int) string {
+					return token.Address
+				}),
+				Reserves: lo.Map(entityPool.Reserves, func(reserve string, _ int) *big.Int {
+					return bignumber.NewBig10(reserve)
+				}),
+				BlockNumber: entityPool.BlockNumber,
+			},
+		},
+		staticExtra: staticExtra,
+		client:      params.EthClient,
+		method:      method,
+		overrides:   toGethOverrides(extra.Overrides),
+		cache:       &quoteCache{quotes: make(map[quoteKey]*big.Int)},
+	}, nil
+}
+
+// parseMethod resolves the quoter method and checks its arguments, filling in the defaults.
+func parseMethod(staticExtra *StaticExtra) (*abi.Method, error) {
+	quoter := quoterABI
+	if staticExtra.ABI != "" {
+		var err error
+		if quoter, err = abi.JSON(strings.NewReader(staticExtra.ABI)); err != nil {
+			return nil, err
+		}
+	}
+	if staticExtra.Method == "" {
+		staticExtra.Method = defaultMethod
+	}
+	if len(staticExtra.Args) == 0 {
+		staticExtra.Args = defaultArgs
+	}
+	if staticExtra.Gas == 0 {
+		staticExtra.Gas = defaultGas
+	}
+
+	method, ok := quoter.Methods[staticExtra.Method]
+	if !ok || len(method.Outputs) == 0 {
+		return nil, errors.WithMessage(ErrMethodNotFound, staticExtra.Method)
+	}
+	if len(method.Inputs) != len(staticExtra.Args) {
+		return nil, errors.WithMessagef(ErrInvalidArg, "%s takes %d arguments", method.Name, len(method.Inputs))
+	}
+	for _, arg := range staticExtra.Args {
+		if arg != ArgPool && arg != ArgTokenIn && arg != ArgTokenOut && arg != ArgAmountIn {
+			return nil, errors.WithMessage(ErrInvalidArg, arg)
+		}
+	}
+	return &method, nil
+}
+
+func toGethOverrides(overrides map[common.Address]AccountOverride) *map[common.Address]gethclient.OverrideAccount {
+	if len(overrides) == 0 {
+		return nil
+	}
+	res := make(map[common.Address]gethclient.OverrideAccount, len(overrides))
+	for address, override := range overrides {
+		res[address] = gethclient.OverrideAccount{
+			Code:      override.Code,
+			Balance:   override.Balance.ToInt(),
+			StateDiff: override.StateDiff,
+		}
+	}
+	return &res
+}
+
+func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	if p.client == nil {
+		return nil, ErrNoEthClient
+	}
+	tokenAmountIn, tokenOut := param.TokenAmountIn, param.TokenOut
+	if p.GetTokenIndex(tokenAmountIn.Token) < 0 || p.GetTokenIndex(tokenOut) < 0 || tokenAmountIn.Token == tokenOut {
+		return nil, ErrInvalidToken
+	}
+	if tokenAmountIn.Amount == nil || tokenAmountIn.Amount.Sign() <= 0 {
+		return nil, ErrInvalidAmountIn
+	}
+
+	amountOut, err := p.quote(param.Context(), tokenAmountIn.Token, tokenOut, tokenAmountIn.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if amountOut.Sign() <= 0 {
+		return nil, ErrInvalidAmountOut
+	}
+
+	return &pool.CalcAmountOutResult{
+		TokenAmountOut: &pool.TokenAmount{Token: tokenOut, Amount: amountOut},
+		Fee:            &pool.TokenAmount{Token: tokenAmountIn.Token, Amount: bignumber.ZeroBI},
+		Gas:            p.staticExtra.Gas,
+	}, nil
+}
+
+// quote calls the quoter at the pool block. Quotes are cached unless the pool has no block number, in which case
+// the latest block is used. The call is bounded by ctx, and by callTimeout if ctx has no deadline.
+func (p *PoolSimulator) quote(ctx context.Context, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error) {
+	var blockNumber *big.Int
+	key := quoteKey{tokenIn: tokenIn, tokenOut: tokenOut, amountIn: amountIn.String()}
+	if p.Info.BlockNumber > 0 {
+		blockNumber = new(big.Int).SetUint64(p.Info.BlockNumber)
+		if amountOut, ok := p.cache.get(key); ok {
+			return new(big.Int).Set(amountOut), nil
+		}
+	}
+
+	args := make([]any, len(p.staticExtra.Args))
+	for i, arg := range p.staticExtra.Args {
+		switch arg {
+		case ArgPool:
+			args[i] = common.HexToAddress(p.Info.Address)
+		case ArgTokenIn:
+			args[i] = common.HexToAddress(tokenIn)
+		case ArgTokenOut:
+			args[i] = common.HexToAddress(tokenOut)
+		case ArgAmountIn:
+			args[i] = amountIn
+		}
+	}
+	input, err := p.method.Inputs.Pack(args...)
+	if err != nil {
+		return nil, errors.WithMessage(ErrInvalidArg, err.Error())
+	}
+
+	quoter := common.HexToAddress(p.staticExtra.Quoter)
+	msg := ethereum.CallMsg{To: &quoter, Data: append(slices.Clone(p.method.ID), input...)}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, callTimeout)
+		defer cancel()
+	}
+
+	var output []byte
+	if p.overrides != nil {
+		overrideCaller, ok := p.client.(OverrideCaller)
+		if !ok {
+			return nil, ErrOverridesUnsupported
+		}
+		output, err = overrideCaller.CallContractWithOverrides(ctx, msg, blockNumber, p.overrides)
+	} else {
+		output, err = p.client.CallContract(ctx, msg, blockNumber)
+	}
+	if err != nil {
+		return nil, errors.WithMessage(ErrQuoteFailed, err.Error())
+	}
+
+	outputs, err := p.method.Outputs.Unpack(output)
+	if err != nil {
+		return nil, errors.WithMessage(ErrQuoteFailed, err.Error())
+	}
+	amountOut, ok := outputs[0].(*big.Int)
+	if !ok {
+		return nil, ErrInvalidAmountOut
+	}
+
+	if blockNumber != nil {
+		p.cache.set(key, new(big.Int).Set(amountOut))
+	}
+	return amountOut, nil
+}
+
+func (p *PoolSimulator) UpdateBalance(_ pool.UpdateBalanceParams) {}
+
+func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
+	return PoolMeta{
+		Quoter:      p.staticExtra.Quoter,
+		BlockNumber: p.Info.BlockNumber,
+	}
+}
@@ -0,0 +1,181 @@
+package quoter
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
+)
+
+const (
+	testQuoter = "0x00000000000000000000000000000000000a11ce"
+	tokenA     = "0x000000000000000000000000000000000000000a"
+	tokenB     = "0x000000000000000000000000000000000000000b"
+)
+
+// doubleQuoterCode returns twice the 4th argument of any call: PUSH1 0x64 CALLDATALOAD PUSH1 2 MUL PUSH1 0 MSTORE
+// PUSH1 0x20 PUSH1 0 RETURN.
+var doubleQuoterCode = common.FromHex("0x60643560020260005260206000f3")
+
+func testEntityPool(blockNumber uint64, extra string) entity.Pool {
+	return entity.Pool{
+		Address:     "0x00000000000000000000000000000000000000AB",
+		Exchange:    "test",
+		Type:        DexType,
+		Reserves:    entity.PoolReserves{"0", "0"},
+		Tokens:      []*entity.PoolToken{{Address: tokenA}, {Address: tokenB}},
+		StaticExtra: `{"quoter":"` + testQuoter + `"}`,
+		Extra:       extra,
+		BlockNumber: blockNumber,
+	}
+}
+
+func TestPoolSimulator_EVM(t *testing.T) {
+	evm, err := testutil.NewEVM(&testutil.EVMFixture{Accounts: map[common.Address]testutil.EVMAccount{
+		common.HexToAddress(testQuoter): {Code: doubleQuoterCode},
+	}})
+	require.NoError(t, err)
+
+	p, err := NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(0, ""), EthClient: evm})
+	require.NoError(t, err)
+
+	res, err := p.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(12345)},
+		TokenOut:      tokenB,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "24690", res.TokenAmountOut.Amount.String())
+	assert.Equal(t, defaultGas, res.Gas)
+
+	_, err = p.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(1)},
+		TokenOut:      tokenA,
+	})
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+type fakeCaller struct {
+	calls     atomic.Int32
+	overrides *map[common.Address]gethclient.OverrideAccount
+}
+
+func (c *fakeCaller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	c.calls.Add(1)
+	// echo the amount in as the amount out
+	return msg.Data[4+96:], nil
+}
+
+func (c *fakeCaller) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int,
+	overrides *map[common.Address]gethclient.OverrideAccount) ([]byte, error) {
+	c.overrides = overrides
+	return c.CallContract(ctx, msg, blockNumber)
+}
+
+func TestPoolSimulator_CachePerBlock(t *testing.T) {
+	caller := &fakeCaller{}
+	p, err := NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(100, ""), EthClient: caller})
+	require.NoError(t, err)
+
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(1000)},
+		TokenOut:      tokenB,
+	}
+	for range 3 {
+		res, err := p.CalcAmountOut(params)
+		require.NoError(t, err)
+		assert.Equal(t, "1000", res.TokenAmountOut.Amount.String())
+	}
+	assert.EqualValues(t, 1, caller.calls.Load())
+
+	params.TokenAmountIn.Amount = big.NewInt(2000)
+	_, err = p.CalcAmountOut(params)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, caller.calls.Load())
+
+	latest, err := NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(0, ""), EthClient: caller})
+	require.NoError(t, err)
+	for range 2 {
+		_, err = latest.CalcAmountOut(params)
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 4, caller.calls.Load(), "quotes at the latest block must not be cached")
+}
+
+func TestPoolSimulator_Overrides(t *testing.T) {
+	extra := `{"overrides":{"` + testQuoter + `":{"code":"0x6000","stateDiff":{"0x0000000000000000000000000000000000000000000000000000000000000001":"0x0000000000000000000000000000000000000000000000000000000000000002"}}}}`
+	caller := &fakeCaller{}
+	p, err := NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(100, extra), EthClient: caller})
+	require.NoError(t, err)
+
+	_, err = p.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(1000)},
+		TokenOut:      tokenB,
+	})
+	require.NoError(t, err)
+	require.NotNil(t, caller.overrides)
+	override := (*caller.overrides)[common.HexToAddress(testQuoter)]
+	assert.Equal(t, []byte{0x60, 0x00}, override.Code)
+	assert.Len(t, override.StateDiff, 1)
+
+	evm, err := testutil.NewEVM(&testutil.EVMFixture{})
+	require.NoError(t, err)
+	p, err = NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(100, extra), EthClient: evm})
+	require.NoError(t, err)
+	_, err = p.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(1000)},
+		TokenOut:      tokenB,
+	})
+	assert.ErrorIs(t, err, ErrOverridesUnsupported)
+}
+
+func TestNewPoolSimulator_InvalidConfig(t *testing.T) {
+	entityPool := testEntityPool(0, "")
+	_, err := NewPoolSimulator(pool.FactoryParams{EntityPool: entityPool})
+	assert.ErrorIs(t, err, ErrNoEthClient)
+
+	entityPool.StaticExtra = `{"quoter":"` + testQuoter + `","method":"quote"}`
+	_, err = NewPoolSimulator(pool.FactoryParams{EntityPool: entityPool, EthClient: &fakeCaller{}})
+	assert.ErrorIs(t, err, ErrMethodNotFound)
+
+	entityPool.StaticExtra = `{"quoter":"` + testQuoter + `","args":["tokenIn","amountIn"]}`
+	_, err = NewPoolSimulator(pool.FactoryParams{EntityPool: entityPool, EthClient: &fakeCaller{}})
+	assert.ErrorIs(t, err, ErrInvalidArg)
+
+	entityPool.StaticExtra = `{"quoter":"0x1234"}`
+	_, err = NewPoolSimulator(pool.FactoryParams{EntityPool: entityPool, EthClient: &fakeCaller{}})
+	assert.ErrorIs(t, err, ErrInvalidQuoter)
+}
+
+type blockingCaller struct{}
+
+func (blockingCaller) CallContract(ctx context.Context, _ ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPoolSimulator_CallerContext(t *testing.T) {
+	p, err := NewPoolSimulator(pool.FactoryParams{EntityPool: testEntityPool(100, ""), EthClient: blockingCaller{}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = p.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenA, Amount: big.NewInt(1000)},
+		TokenOut:      tokenB,
+		Ctx:           ctx,
+	})
+	assert.ErrorIs(t, err, ErrQuoteFailed)
+	assert.Less(t, time.Since(start), callTimeout, "the call is bounded by the caller context")
+}
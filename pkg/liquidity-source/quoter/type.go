@@ -0,0 +1,95 @@
+package quoter
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// StaticExtra configures the quoter contract called to price swaps.
+type StaticExtra struct {
+	// Quoter is the address of the quoter contract.
+	Quoter string `json:"quoter"`
+	// ABI is the JSON ABI of the quoter, defaults to the quoteExactIn(pool, tokenIn, tokenOut, amountIn) interface.
+	ABI string `json:"abi,omitempty"`
+	// Method is the quoter method to call, its first output being the amount out. Defaults to quoteExactIn.
+	Method string `json:"method,omitempty"`
+	// Args lists the method arguments among pool, tokenIn, tokenOut and amountIn. Defaults to all four in this order.
+	Args []string `json:"args,omitempty"`
+	// Gas is the gas reported for a swap, defaults to defaultGas.
+	Gas int64 `json:"gas,omitempty"`
+}
+
+// Extra holds the state overrides applied to quote calls, for instance to quote against a state the chain has not
+// reached yet.
+type Extra struct {
+	Overrides map[common.Address]AccountOverride `json:"overrides,omitempty"`
+}
+
+type AccountOverride struct {
+	Code      hexutil.Bytes               `json:"code,omitempty"`
+	Balance   *hexutil.Big                `json:"balance,omitempty"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff,omitempty"`
+}
+
+// OverrideCaller is implemented by eth clients able to apply state overrides to eth_call, such as Caller.
+type OverrideCaller interface {
+	CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int,
+		overrides *map[common.Address]gethclient.OverrideAccount) ([]byte, error)
+}
+
+// Caller is an ethereum.ContractCaller also implementing OverrideCaller, to be passed as pool.FactoryParams.EthClient.
+type Caller struct {
+	*ethclient.Client
+	geth *gethclient.Client
+}
+
+func NewCaller(client *rpc.Client) *Caller {
+	return &Caller{
+		Client: ethclient.NewClient(client),
+		geth:   gethclient.New(client),
+	}
+}
+
+func (c *Caller) CallContractWithOverrides(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int,
+	overrides *map[common.Address]gethclient.OverrideAccount) ([]byte, error) {
+	return c.geth.CallContract(ctx, msg, blockNumber, overrides)
+}
+
+type PoolMeta struct {
+	Quoter      string `json:"quoter"`
+	BlockNumber uint64 `json:"blockNumber"`
+}
+
+type quoteKey struct {
+	tokenIn, tokenOut, amountIn string
+}
+
+// quoteCache caches the quotes of a pool at its block. It is shared by the clones of the pool.
+type quoteCache struct {
+	mu     sync.RWMutex
+	quotes map[quoteKey]*big.Int
+}
+
+func (c *quoteCache) get(key quoteKey) (*big.Int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	amountOut, ok := c.quotes[key]
+	return amountOut, ok
+}
+
+func (c *quoteCache) set(key quoteKey, amountOut *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.quotes) >= maxCachedQuotes {
+		clear(c.quotes)
+	}
+	c.quotes[key] = amountOut
+}
@@ -2,7 +2,6 @@ package ezeth
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
@@ -100,7 +99,7 @@ func (s *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			return nil, err
 		}
 	} else {
-		amountOut, err = s.deposit(param.TokenAmountIn.Token, param.TokenAmountIn.Amount)
+		amountOut, err = s.deposit(param.TokenAmountIn.Token, param.TokenAmountIn.Amount, param.Now())
 		if err != nil {
 			return nil, err
 		}
@@ -154,13 +153,13 @@ func (s *PoolSimulator) depositETH(amountIn *big.Int) (*big.Int, error) {
 	return s.calculateMintAmount(s.totalTVL, amountIn, s.totalSupply)
 }
 
-func (s *PoolSimulator) deposit(collateralToken string, amount *big.Int) (*big.Int, error) {
+func (s *PoolSimulator) deposit(collateralToken string, amount *big.Int, timestamp int64) (*big.Int, error) {
 	tokenIndex, ok := s.collateralTokenIndex[collateralToken]
 	if !ok {
 		return nil, ErrInvalidCollateral
 	}
 
-	collateralTokenValue, err := s.lookupTokenValue(collateralToken, amount)
+	collateralTokenValue, err := s.lookupTokenValue(collateralToken, amount, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -223,10 +222,11 @@ func (s *PoolSimulator) calculateMintAmount(
 	return mintAmount, nil
 }
 
-// lookupTokenValue: renzoOracle.lookupTokenValue
+// lookupTokenValue: renzoOracle.lookupTokenValue, evaluated at the given block timestamp
 func (s *PoolSimulator) lookupTokenValue(
 	token string,
 	value *big.Int,
+	blockTimestamp int64,
 ) (*big.Int, error) {
 	oracle, ok := s.tokenOracleLookup[token]
 	if !ok {
@@ -235,7 +235,7 @@ func (s *PoolSimulator) lookupTokenValue(
 
 	price, timestamp := oracle.LatestRoundData()
 
-	if timestamp.Int64() < time.Now().Unix()-MAX_TIME_WINDOW {
+	if timestamp.Int64() < blockTimestamp-MAX_TIME_WINDOW {
 		return nil, ErrOracleExpired
 	}
 
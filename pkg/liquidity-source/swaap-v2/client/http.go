@@ -5,9 +5,13 @@ import (
 
 	"github.com/go-resty/resty/v2"
 	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
 )
 
 const (
+	dexType = "swaap-v2"
+
 	quoteEndpoint = "v1/rfq/quote"
 
 	headerAPIKey = "X-API-KEY"
@@ -19,15 +23,17 @@ var (
 
 type HTTPClient struct {
 	config *HTTPClientConfig
-	client *resty.Client
+	client *rfq.Client
 }
 
-func NewHTTPClient(config *HTTPClientConfig) *HTTPClient {
-	client := resty.New().
-		SetHeader(headerAPIKey, config.APIKey).
-		SetBaseURL(config.BaseURL).
-		SetTimeout(config.Timeout.Duration).
-		SetRetryCount(config.RetryCount)
+func NewHTTPClient(config *HTTPClientConfig, opts ...rfq.Option) *HTTPClient {
+	client := rfq.NewClient(rfq.Config{
+		Maker:          dexType,
+		BaseURL:        config.BaseURL,
+		Timeout:        config.Timeout,
+		RetryCount:     config.RetryCount,
+		CircuitBreaker: config.CircuitBreaker,
+	}, append([]rfq.Option{rfq.WithHeader(headerAPIKey, config.APIKey)}, opts...)...)
 
 	return &HTTPClient{
 		config: config,
@@ -36,22 +42,21 @@ func NewHTTPClient(config *HTTPClientConfig) *HTTPClient {
 }
 
 func (c *HTTPClient) Quote(ctx context.Context, params QuoteParams) (QuoteResult, error) {
-	req := c.client.R().
-		SetContext(ctx).
+	req := c.client.R(ctx).
 		SetBody(params)
 
 	var result QuoteResult
-	resp, err := req.SetResult(&result).Post(quoteEndpoint)
+	resp, err := c.client.Execute(req.SetResult(&result), resty.MethodPost, quoteEndpoint)
 	if err != nil {
 		return QuoteResult{}, err
 	}
 
 	if !resp.IsSuccess() {
-		return QuoteResult{}, errors.WithMessagef(ErrQuoteFailed, "[swaap-v2] status code(%d), body(%s)", resp.StatusCode(), resp.Body())
+		return QuoteResult{}, c.client.Fail(ctx, resp, "quote failed", ErrQuoteFailed)
 	}
 
 	if !result.Success {
-		return QuoteResult{}, ErrQuoteFailed
+		return QuoteResult{}, c.client.Fail(ctx, resp, "quote failed", ErrQuoteFailed)
 	}
 
 	return result, nil
@@ -2,10 +2,10 @@ package swaapv2
 
 const (
 	DexType = "swaap-v2"
+
+	priceToleranceBps = 10000
 )
 
 var (
 	DefaultGas = Gas{Swap: 100000}
-
-	priceToleranceBps = 10000.
 )
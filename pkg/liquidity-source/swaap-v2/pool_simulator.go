@@ -2,7 +2,6 @@ package swaapv2
 
 import (
 	"errors"
-	"math"
 	"math/big"
 	"strings"
 
@@ -12,12 +11,13 @@ import (
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
 var (
-	ErrEmptyPriceLevels      = errors.New("empty price levels")
-	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	ErrEmptyPriceLevels      = pricelevel.ErrEmptyPriceLevels
+	ErrInsufficientLiquidity = pricelevel.ErrInsufficientLiquidity
 	ErrPoolSwapped           = errors.New("pool swapped")
 	ErrOutOfLiquidity        = errors.New("out of liquidity")
 )
@@ -29,10 +29,10 @@ type (
 		isQuoteSwapped         bool
 		baseToken              entity.PoolToken
 		quoteToken             entity.PoolToken
-		baseToQuotePriceLevels []PriceLevel
-		quoteToBasePriceLevels []PriceLevel
+		baseToQuotePriceLevels *pricelevel.Book
+		quoteToBasePriceLevels *pricelevel.Book
 		timestamp              int64
-		priceTolerance         float64
+		priceTolerance         int64
 		gas                    Gas
 	}
 
@@ -70,6 +70,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		return nil, err
 	}
 
+	baseToken, quoteToken := *entityPool.Tokens[0], *entityPool.Tokens[1]
 	return &PoolSimulator{
 		Pool: pool.Pool{
 			Info: pool.PoolInfo{
@@ -83,12 +84,12 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		},
 		isBaseSwapped:          false,
 		isQuoteSwapped:         false,
-		baseToken:              *entityPool.Tokens[0],
-		quoteToken:             *entityPool.Tokens[1],
-		baseToQuotePriceLevels: extra.BaseToQuotePriceLevels,
-		quoteToBasePriceLevels: extra.QuoteToBasePriceLevels,
+		baseToken:              baseToken,
+		quoteToken:             quoteToken,
+		baseToQuotePriceLevels: newBook(extra.BaseToQuotePriceLevels, baseToken.Decimals, quoteToken.Decimals),
+		quoteToBasePriceLevels: newBook(extra.QuoteToBasePriceLevels, quoteToken.Decimals, baseToken.Decimals),
 		timestamp:              entityPool.Timestamp,
-		priceTolerance:         float64(extra.PriceTolerance),
+		priceTolerance:         int64(extra.PriceTolerance),
 		gas:                    DefaultGas,
 	}, nil
 }
@@ -101,23 +102,70 @@ func (p *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	return p.swapQuoteToBase(params.TokenAmountIn.Amount)
 }
 
+// SpotPrice returns the price of the best price level, net of the price tolerance.
+func (p *PoolSimulator) SpotPrice(tokenIn, _ string) (*big.Float, error) {
+	book, err := p.book(tokenIn)
+	if err != nil {
+		return nil, err
+	}
+	spotPrice, err := book.SpotPrice()
+	if err != nil {
+		return nil, err
+	}
+	spotPrice.Mul(spotPrice, big.NewRat(priceToleranceBps-p.priceTolerance, priceToleranceBps))
+	return new(big.Float).SetRat(spotPrice), nil
+}
+
+// Depth walks the price levels, filling each one whole while the average price stays within the impact, and the
+// part of the first one it does not where it reaches the impact.
+func (p *PoolSimulator) Depth(tokenIn, _ string, impactBps int64) (*pool.DepthPoint, error) {
+	book, err := p.book(tokenIn)
+	if err != nil {
+		return nil, err
+	}
+	amountIn, amountOut, err := book.Depth(impactBps)
+	if err != nil {
+		return nil, err
+	}
+	amountOut.Mul(amountOut, big.NewInt(priceToleranceBps-p.priceTolerance))
+	amountOut.Quo(amountOut, big.NewInt(priceToleranceBps))
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut}, nil
+}
+
+// book returns the price levels of swapping tokenIn, or ErrPoolSwapped if the pool was swapped in that direction.
+func (p *PoolSimulator) book(tokenIn string) (*pricelevel.Book, error) {
+	if tokenIn == p.baseToken.Address {
+		if p.isBaseSwapped {
+			return nil, ErrPoolSwapped
+		}
+		return p.baseToQuotePriceLevels, nil
+	}
+	if p.isQuoteSwapped {
+		return nil, ErrPoolSwapped
+	}
+	return p.quoteToBasePriceLevels, nil
+}
+
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	// if params.TokenAmountIn.Token == p.baseToken.Address {
 	// 	p.isBaseSwapped = true
 	// 	return
 	// }
 	// p.isQuoteSwapped = true
-	amtIn, _ := params.TokenAmountIn.Amount, params.TokenAmountOut.Amount
-	amtInF, _ := amtIn.Float64()
 	if params.TokenAmountIn.Token == p.baseToken.Address {
-		amountInAfterDecimalsF := amtInF / math.Pow10(int(p.baseToken.Decimals))
-		p.baseToQuotePriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.baseToQuotePriceLevels)
+		p.baseToQuotePriceLevels.Consume(params.TokenAmountIn.Amount)
 	} else {
-		amountInAfterDecimalsF := amtInF / math.Pow10(int(p.quoteToken.Decimals))
-		p.quoteToBasePriceLevels = getNewPriceLevelsState(amountInAfterDecimalsF, p.quoteToBasePriceLevels)
+		p.quoteToBasePriceLevels.Consume(params.TokenAmountIn.Amount)
 	}
 }
 
+func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
+	cloned := *p
+	cloned.baseToQuotePriceLevels = p.baseToQuotePriceLevels.Clone()
+	cloned.quoteToBasePriceLevels = p.quoteToBasePriceLevels.Clone()
+	return &cloned
+}
+
 func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 	return MetaInfo{
 		Timestamp: p.timestamp,
@@ -129,19 +177,11 @@ func (p *PoolSimulator) swapBaseToQuote(amountIn *big.Int) (*pool.CalcAmountOutR
 		return nil, ErrPoolSwapped
 	}
 
-	amountInFl, _ := amountIn.Float64()
-	amountInAfterDecimals := amountInFl / math.Pow10(int(p.baseToken.Decimals))
-
-	amountOutAfterDecimals, err := getAmountOut(amountInAfterDecimals, p.baseToQuotePriceLevels)
+	amountOut, err := p.getAmountOut(amountIn, p.baseToQuotePriceLevels)
 	if err != nil {
 		return nil, err
 	}
 
-	amountOutFl := amountOutAfterDecimals * math.Pow10(int(p.quoteToken.Decimals))
-	amountOutFl = amountOutFl * (priceToleranceBps - p.priceTolerance) / priceToleranceBps
-
-	amountOut, _ := new(big.Float).SetFloat64(amountOutFl).Int(nil)
-
 	return &pool.CalcAmountOutResult{
 		TokenAmountOut: &pool.TokenAmount{Token: p.quoteToken.Address, Amount: amountOut},
 		Fee:            &pool.TokenAmount{Token: p.quoteToken.Address, Amount: integer.Zero()},
@@ -159,19 +199,11 @@ func (p *PoolSimulator) swapQuoteToBase(amountIn *big.Int) (*pool.CalcAmountOutR
 		return nil, ErrPoolSwapped
 	}
 
-	amountInFl, _ := amountIn.Float64()
-	amountInAfterDecimals := amountInFl / math.Pow10(int(p.quoteToken.Decimals))
-
-	amountOutAfterDecimals, err := getAmountOut(amountInAfterDecimals, p.quoteToBasePriceLevels)
+	amountOut, err := p.getAmountOut(amountIn, p.quoteToBasePriceLevels)
 	if err != nil {
 		return nil, err
 	}
 
-	amountOutFl := amountOutAfterDecimals * math.Pow10(int(p.baseToken.Decimals))
-	amountOutFl = amountOutFl * (priceToleranceBps - p.priceTolerance) / priceToleranceBps
-
-	amountOut, _ := new(big.Float).SetFloat64(amountOutFl).Int(nil)
-
 	return &pool.CalcAmountOutResult{
 		TokenAmountOut: &pool.TokenAmount{Token: p.baseToken.Address, Amount: amountOut},
 		Fee:            &pool.TokenAmount{Token: p.baseToken.Address, Amount: integer.Zero()},
@@ -184,60 +216,23 @@ func (p *PoolSimulator) swapQuoteToBase(amountIn *big.Int) (*pool.CalcAmountOutR
 	}, nil
 }
 
-func getAmountOut(amountIn float64, priceLevels []PriceLevel) (float64, error) {
-	if len(priceLevels) == 0 {
-		return 0, ErrEmptyPriceLevels
-	}
-
-	if amountIn > priceLevels[len(priceLevels)-1].Level {
-		return 0, ErrOutOfLiquidity
-	}
-
-	var (
-		amountOut    = float64(0)
-		amountInLeft = amountIn
-		levelIdx     = 0
-	)
-
-	for {
-		availableAmount := priceLevels[levelIdx].Level
-		if levelIdx > 0 {
-			availableAmount -= priceLevels[levelIdx-1].Level
-		}
-		swappableAmount := math.Min(availableAmount, amountInLeft)
-		amountOut += swappableAmount * priceLevels[levelIdx].Price
-		amountInLeft -= swappableAmount
-		levelIdx += 1
-		if amountInLeft == 0 || levelIdx >= len(priceLevels) {
-			break
-		}
+// getAmountOut walks the price levels and deducts the price tolerance from the amount out.
+func (p *PoolSimulator) getAmountOut(amountIn *big.Int, book *pricelevel.Book) (*big.Int, error) {
+	amountOut, err := book.AmountOut(amountIn)
+	if errors.Is(err, pricelevel.ErrInsufficientLiquidity) {
+		return nil, ErrOutOfLiquidity
+	} else if err != nil {
+		return nil, err
 	}
 
-	return amountOut, nil
+	amountOut.Mul(amountOut, big.NewInt(priceToleranceBps-p.priceTolerance))
+	return amountOut.Quo(amountOut, big.NewInt(priceToleranceBps)), nil
 }
 
-func getNewPriceLevelsState(amountIn float64, priceLevels []PriceLevel) []PriceLevel {
-	if len(priceLevels) == 0 {
-		return priceLevels
-	}
-
-	var newPriceLevels []PriceLevel
-
-	// Reduce every price level by amountIn
-	for idx, priceLevel := range priceLevels {
-		if priceLevel.Level <= amountIn {
-			continue
-		}
-
-		if newPriceLevels == nil {
-			newPriceLevels = make([]PriceLevel, 0, len(priceLevels)-idx)
-		}
-
-		newPriceLevels = append(newPriceLevels, PriceLevel{
-			Price: priceLevel.Price,
-			Level: priceLevel.Level - amountIn,
-		})
-	}
-
-	return newPriceLevels
+// newBook returns the Book of the price levels, which swaap-v2 quotes by cumulative level.
+func newBook(priceLevels []PriceLevel, decimalsIn, decimalsOut uint8) *pricelevel.Book {
+	return pricelevel.NewBook(pricelevel.FromCumulative(lo.Map(priceLevels,
+		func(priceLevel PriceLevel, _ int) pricelevel.Level {
+			return pricelevel.NewLevel(priceLevel.Price, priceLevel.Level)
+		})), decimalsIn, decimalsOut)
 }
@@ -1,7 +1,6 @@
 package swaapv2
 
 import (
-	"math"
 	"math/big"
 	"testing"
 
@@ -11,6 +10,7 @@ import (
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	poolpkg "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 )
 
@@ -157,36 +157,24 @@ func TestPoolSimulator_UpdateBalance(t *testing.T) {
 				amountIn:     "258610248702336200",
 				tokenInIndex: 0,
 				priceLevels: []PriceLevel{
-					{
-						Price: 3765.6575174450813,
-						Level: 0.2786102487023362 - (258610248702336200 / math.Pow10(18)),
-					},
-					{
-						Price: 3765.3730881244423,
-						Level: 0.33433231857185164 - (258610248702336200 / math.Pow10(18)),
-					},
+					{Price: 3765.6575174450813, Level: 0.02},
+					{Price: 3765.3730881244423, Level: 0.07572206986951544},
 				},
 			},
 			{
 				amountIn:     "278610248702336200",
 				tokenInIndex: 0,
-				priceLevels: []PriceLevel{{
-					Price: 3765.3730881244423,
-					Level: 0.33433231857185164 - (278610248702336200 / math.Pow10(18)),
-				}},
+				priceLevels:  []PriceLevel{{Price: 3765.3730881244423, Level: 0.05572206986951544}},
 			},
 			{
 				amountIn:     "333332318571851640",
 				tokenInIndex: 0,
-				priceLevels: []PriceLevel{{
-					Price: 3765.3730881244423,
-					Level: 0.33433231857185164 - (333332318571851640 / math.Pow10(18)),
-				}},
+				priceLevels:  []PriceLevel{{Price: 3765.3730881244423, Level: 0.001}},
 			},
 			{
 				amountIn:     "334332318571851640",
 				tokenInIndex: 0,
-				priceLevels:  nil,
+				priceLevels:  []PriceLevel{},
 			},
 		}
 		for _, tt := range cases {
@@ -201,11 +189,58 @@ func TestPoolSimulator_UpdateBalance(t *testing.T) {
 				TokenAmountIn: pool.TokenAmount{Token: entityPool.Tokens[tt.tokenInIndex].Address, Amount: amountIn},
 			})
 			if tt.tokenInIndex == 0 {
-				assert.Equal(t, tt.priceLevels, simulator.baseToQuotePriceLevels)
+				assert.Equal(t, tt.priceLevels, cumulativeLevels(simulator.baseToQuotePriceLevels))
 			} else {
-				assert.Equal(t, tt.priceLevels, simulator.quoteToBasePriceLevels)
+				assert.Equal(t, tt.priceLevels, cumulativeLevels(simulator.quoteToBasePriceLevels))
 			}
 		}
 	})
 
 }
+
+// cumulativeLevels returns the levels left in the book by cumulative level, as swaap-v2 quotes them.
+func cumulativeLevels(book *pricelevel.Book) []PriceLevel {
+	priceLevels := make([]PriceLevel, 0, book.Len())
+	level := new(big.Rat)
+	for _, l := range book.Levels() {
+		level.Add(level, l.Size)
+		price, _ := l.Price.Float64()
+		cumulative, _ := level.Float64()
+		priceLevels = append(priceLevels, PriceLevel{Price: price, Level: cumulative})
+	}
+	return priceLevels
+}
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	weth, usdc := "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	simulator, err := NewPoolSimulator(entity.Pool{
+		Address:  "swaap_v2_" + weth + "_" + usdc,
+		Exchange: DexType,
+		Type:     DexType,
+		Reserves: entity.PoolReserves{"952034231656045615", "1259118739"},
+		Tokens:   []*entity.PoolToken{{Address: weth, Decimals: 18}, {Address: usdc, Decimals: 6}},
+		Extra: `{"baseToQuotePriceLevels":[{"price":3766.8762085558155,"level":0},` +
+			`{"price":3766.8762085558155,"level":0.0022288821657478614},` +
+			`{"price":3766.8490507666365,"level":0.01114440978035138},` +
+			`{"price":3765.3730881244423,"level":0.33433231857185164}],` +
+			`"quoteToBasePriceLevels":[{"price":0.00026532281648942546,"level":0},` +
+			`{"price":0.00026532281648942546,"level":17.941056366984068},` +
+			`{"price":0.0002650316307629569,"level":3590.1837399765}],"priceTolerance":10}`,
+	})
+	assert.NoError(t, err)
+
+	spotPrice, err := simulator.SpotPrice(weth, usdc)
+	assert.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 3766.8762085558155e-12*0.999, spot, 1e-12)
+
+	for _, tc := range []struct{ tokenIn, tokenOut string }{{weth, usdc}, {usdc, weth}} {
+		for _, impactBps := range []int64{1, 3, 100} {
+			testutil.TestDepth(t, simulator, nil, tc.tokenIn, tc.tokenOut, impactBps, 1e-6)
+		}
+	}
+
+	simulator.isBaseSwapped = true
+	_, err = simulator.Depth(weth, usdc, 10)
+	assert.ErrorIs(t, err, ErrPoolSwapped)
+}
@@ -12,8 +12,9 @@ import (
 )
 
 type Config struct {
-	DexID string                  `json:"dexId"`
-	HTTP  client.HTTPClientConfig `mapstructure:"http" json:"http"`
+	DexID      string                  `json:"dexId"`
+	HTTP       client.HTTPClientConfig `mapstructure:"http" json:"http"`
+	Validation pool.RFQValidation      `mapstructure:"validation" json:"validation"`
 }
 
 type IClient interface {
@@ -61,10 +62,16 @@ func (h *RFQHandler) RFQ(ctx context.Context, params pool.RFQParams) (*pool.RFQR
 
 	amount, _ := new(big.Int).SetString(result.Amount, 10)
 
-	return &pool.RFQResult{
+	rfqResult := &pool.RFQResult{
 		NewAmountOut: amount,
+		Deadline:     result.Expiration,
 		Extra:        result,
-	}, nil
+	}
+	if err = h.config.Validation.Validate(params, rfqResult); err != nil {
+		return nil, err
+	}
+
+	return rfqResult, nil
 }
 
 func (h *RFQHandler) BatchRFQ(context.Context, []pool.RFQParams) ([]*pool.RFQResult, error) {
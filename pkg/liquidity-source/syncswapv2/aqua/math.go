@@ -2,7 +2,6 @@ package syncswapv2aqua
 
 import (
 	"errors"
-	"time"
 
 	"github.com/holiman/uint256"
 
@@ -347,7 +346,7 @@ func newtonY(ann *uint256.Int, gamma *uint256.Int, x []*uint256.Int, D *uint256.
 	return nil, errors.New("did not converge")
 }
 
-func (t *PoolSimulator) GetDy(i int, j int, dx *uint256.Int) (*uint256.Int, *uint256.Int, error) {
+func (t *PoolSimulator) GetDy(i int, j int, dx *uint256.Int, timestamp int64) (*uint256.Int, *uint256.Int, error) {
 	var priceScale = new(uint256.Int).Mul(t.PriceScalePacked, t.Precisions[1])
 	var xp = []*uint256.Int{uint256.MustFromBig(t.Pool.Info.Reserves[0]), uint256.MustFromBig(t.Pool.Info.Reserves[1])} // xp: uint256[N_COINS] = self.balances
 	xp[i] = new(uint256.Int).Add(xp[i], dx)
@@ -355,7 +354,7 @@ func (t *PoolSimulator) GetDy(i int, j int, dx *uint256.Int) (*uint256.Int, *uin
 	xp[1] = new(uint256.Int).Div(new(uint256.Int).Mul(xp[1], priceScale), Precision)
 
 	var aGamma = t.aGamma()
-	D, err1 := t.aD(xp)
+	D, err1 := t.aD(xp, timestamp)
 	if err1 != nil {
 		return nil, nil, err1
 	}
@@ -394,7 +393,7 @@ func (t *PoolSimulator) GetDy(i int, j int, dx *uint256.Int) (*uint256.Int, *uin
 	return amountOutAfterFee, amountFee, nil
 }
 
-func (t *PoolSimulator) Exchange(i int, j int, dx *uint256.Int) (*uint256.Int, error) {
+func (t *PoolSimulator) Exchange(i int, j int, dx *uint256.Int, timestamp int64) (*uint256.Int, error) {
 	var nCoins = len(t.Info.Tokens)
 	if i == j {
 		return nil, errors.New("i = j")
@@ -431,7 +430,7 @@ func (t *PoolSimulator) Exchange(i int, j int, dx *uint256.Int) (*uint256.Int, e
 		)
 	}
 	var aGamma = t.aGamma()
-	D, err1 := t.aD(xp)
+	D, err1 := t.aD(xp, timestamp)
 	if err1 != nil {
 		return nil, err1
 	}
@@ -482,11 +481,11 @@ func (t *PoolSimulator) Exchange(i int, j int, dx *uint256.Int) (*uint256.Int, e
 			ix = i
 		}
 	}
-	err = t.tweakPrice(aGamma, xp, ix, p, constant.ZeroBI)
+	err = t.tweakPrice(aGamma, xp, ix, p, constant.ZeroBI, timestamp)
 	return dy, err
 }
 
-func (t *PoolSimulator) tweakPrice(AGamma []*uint256.Int, _xp []*uint256.Int, i int, pI *uint256.Int, newD *uint256.Int) error {
+func (t *PoolSimulator) tweakPrice(AGamma []*uint256.Int, _xp []*uint256.Int, i int, pI *uint256.Int, newD *uint256.Int, timestamp int64) error {
 	var nCoins = len(_xp)
 	var nCoinsBi = uint256.NewInt(uint64(nCoins))
 	var priceOracle = make([]*uint256.Int, nCoins-1)
@@ -506,7 +505,7 @@ func (t *PoolSimulator) tweakPrice(AGamma []*uint256.Int, _xp []*uint256.Int, i
 		lastPrices[k] = new(uint256.Int).And(packedPrices, PriceMask)
 		packedPrices = new(uint256.Int).Rsh(packedPrices, PriceSize)
 	}
-	var blockTimestamp = time.Now().Unix()
+	var blockTimestamp = timestamp
 	if lastPricesTimestamp < blockTimestamp {
 		var maHalfTime = t.MaHalfTime
 		var alpha, _ = halfpow(
@@ -750,10 +749,10 @@ func (t *PoolSimulator) aGamma() []*uint256.Int {
 	return []*uint256.Int{t.A, t.Gamma}
 }
 
-func (t *PoolSimulator) aD(xp []*uint256.Int) (*uint256.Int, error) {
+func (t *PoolSimulator) aD(xp []*uint256.Int, timestamp int64) (*uint256.Int, error) {
 	D := t.D
 	// https://gist.github.com/0xnakato/3785ba596c6fa661a5bc56f045360bf6#file-syncswaphelper-ts-L1365
-	if t.FutureTime > time.Now().Unix() {
+	if t.FutureTime > timestamp {
 		temp, err := newtonD(t.A, t.Gamma, xp)
 		if err != nil {
 			return nil, err
@@ -119,6 +119,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		tokenIndexFrom,
 		tokenIndexTo,
 		uint256.MustFromBig(tokenAmountIn.Amount),
+		param.Now(),
 	)
 	if err != nil {
 		return &pool.CalcAmountOutResult{}, err
@@ -144,17 +145,18 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
-	_, _, _, _ = t.Swap(input, output.Token)
+	_, _, _, _ = t.Swap(input, output.Token, params.Now())
 }
 
 func (t *PoolSimulator) Swap(
 	tokenAmountIn pool.TokenAmount,
 	tokenOut string,
+	timestamp int64,
 ) (*pool.TokenAmount, *pool.TokenAmount, int64, error) {
 	var inputAmount = tokenAmountIn.Amount
 	var inputIndex = t.GetTokenIndex(tokenAmountIn.Token)
 	var outputIndex = t.GetTokenIndex(tokenOut)
-	amountOut, err := t.Exchange(inputIndex, outputIndex, uint256.MustFromBig(inputAmount))
+	amountOut, err := t.Exchange(inputIndex, outputIndex, uint256.MustFromBig(inputAmount), timestamp)
 	if err != nil {
 		return nil, nil, 0, err
 	}
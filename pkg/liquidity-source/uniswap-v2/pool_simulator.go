@@ -212,6 +212,61 @@ func (s *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} {
 	}
 }
 
+// SpotPrice returns reserveOut / reserveIn net of the swap fee.
+func (s *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	reserveIn, reserveOut, err := s.pairReserves(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+
+	spotPrice := new(big.Float).SetInt(new(big.Int).Mul(reserveOut,
+		new(big.Int).Sub(s.feePrecision.ToBig(), s.fee.ToBig())))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(new(big.Int).Mul(reserveIn, s.feePrecision.ToBig()))), nil
+}
+
+// Depth solves amountOut / (amountIn * spotPrice) = 1 - impact, the ratio being
+// reserveIn * feePrecision / (reserveIn * feePrecision + amountIn * (feePrecision - fee)).
+func (s *PoolSimulator) Depth(tokenIn, tokenOut string, impactBps int64) (*pool.DepthPoint, error) {
+	reserveIn, reserveOut, err := s.pairReserves(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	if s.fee.Cmp(s.feePrecision) >= 0 {
+		return nil, ErrInsufficientOutputAmount
+	}
+
+	amountIn := new(big.Int).Mul(reserveIn, s.feePrecision.ToBig())
+	amountIn.Mul(amountIn, big.NewInt(impactBps))
+	amountIn.Div(amountIn, new(big.Int).Mul(big.NewInt(pool.BasisPoint-impactBps),
+		new(big.Int).Sub(s.feePrecision.ToBig(), s.fee.ToBig())))
+	amountInU256, overflow := uint256.FromBig(amountIn)
+	if overflow {
+		return nil, ErrInvalidAmountIn
+	}
+	reserveInU256, overflow := uint256.FromBig(reserveIn)
+	if overflow {
+		return nil, ErrInvalidReserve
+	}
+	reserveOutU256, overflow := uint256.FromBig(reserveOut)
+	if overflow {
+		return nil, ErrInvalidReserve
+	}
+	amountOut := s.getAmountOut(amountInU256, reserveInU256, reserveOutU256)
+	return &pool.DepthPoint{ImpactBps: impactBps, AmountIn: amountIn, AmountOut: amountOut.ToBig()}, nil
+}
+
+func (s *PoolSimulator) pairReserves(tokenIn, tokenOut string) (*big.Int, *big.Int, error) {
+	indexIn, indexOut := s.GetTokenIndex(tokenIn), s.GetTokenIndex(tokenOut)
+	if indexIn < 0 || indexOut < 0 {
+		return nil, nil, ErrInvalidToken
+	}
+	reserveIn, reserveOut := s.Pool.Info.Reserves[indexIn], s.Pool.Info.Reserves[indexOut]
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil, nil, ErrInsufficientLiquidity
+	}
+	return reserveIn, reserveOut, nil
+}
+
 func (s *PoolSimulator) getAmountOut(amountIn, reserveIn, reserveOut *uint256.Int) *uint256.Int {
 	amountInWithFee := new(uint256.Int).Mul(amountIn, new(uint256.Int).Sub(s.feePrecision, s.fee))
 	numerator := new(uint256.Int).Mul(amountInWithFee, reserveOut)
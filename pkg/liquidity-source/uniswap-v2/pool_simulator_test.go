@@ -297,6 +297,38 @@ func TestPoolSimulator_getAmountIn(t *testing.T) {
 	}
 }
 
+func TestPoolSimulator_SpotPrice(t *testing.T) {
+	testutil.TestSpotPrice(t, poolSim, 1e-6)
+}
+
+func TestPoolSimulator_Depth(t *testing.T) {
+	tokens := poolSim.GetTokens()
+	for _, pair := range [][2]string{{tokens[0], tokens[1]}, {tokens[1], tokens[0]}} {
+		spotPrice, err := poolSim.SpotPrice(pair[0], pair[1])
+		assert.NoError(t, err)
+		spot, _ := spotPrice.Float64()
+
+		curve, err := poolpkg.DepthCurve(poolSim, poolpkg.DepthCurveParams{
+			TokenIn:    pair[0],
+			TokenOut:   pair[1],
+			ImpactsBps: []int64{1, 30, 100, 5000},
+		})
+		assert.NoError(t, err)
+		for _, point := range curve {
+			res, err := poolSim.CalcAmountOut(poolpkg.CalcAmountOutParams{
+				TokenAmountIn: poolpkg.TokenAmount{Token: pair[0], Amount: point.AmountIn},
+				TokenOut:      pair[1],
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, res.TokenAmountOut.Amount, point.AmountOut)
+
+			rate, _ := new(big.Float).Quo(new(big.Float).SetInt(point.AmountOut),
+				new(big.Float).SetInt(point.AmountIn)).Float64()
+			assert.InDelta(t, float64(point.ImpactBps)/poolpkg.BasisPoint, 1-rate/spot, 1e-6)
+		}
+	}
+}
+
 func BenchmarkPoolSimulatorCalcAmountOut(b *testing.B) {
 	testCases := []struct {
 		name              string
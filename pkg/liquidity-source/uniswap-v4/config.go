@@ -1,5 +1,7 @@
 package uniswapv4
 
+import "github.com/ethereum/go-ethereum/common"
+
 type Config struct {
 	ChainID                int    `json:"chainID"`
 	DexID                  string `json:"dexID"`
@@ -12,6 +14,18 @@ type Config struct {
 	AllowSubgraphError     bool   `json:"allowSubgraphError"`
 
 	FetchTickFromStateView bool // instead of fetching from subgraph
+
+	// Hooks configures the simulation of hook contracts with swap permissions that are not registered by address,
+	// keyed by hook address. Pools using other hooks with swap permissions are not simulated.
+	Hooks map[common.Address]HookConfig `json:"hooks"`
+}
+
+// HookConfig configures the simulation of a hook contract by one of the registered hook kinds, see HookKindDynamicFee,
+// HookKindFeeTaking and HookKindSpecifiedFee.
+type HookConfig struct {
+	Kind string `json:"kind"`
+	// FeePips is the share of the swapped amounts taken by fee-taking hooks, in pips.
+	FeePips uint32 `json:"feePips,omitempty"`
 }
 
 func (c *Config) IsAllowSubgraphError() bool {
@@ -16,13 +16,25 @@ const (
 	graphFirstLimit = 1000
 
 	maxChangedTicks = 10
+
+	// DynamicFeeFlag is the fee of pools whose LP fee is set by their hook.
+	DynamicFeeFlag = 0x800000
+	// OverrideFeeFlag marks the LP fee returned by beforeSwap as an override of the pool LP fee.
+	OverrideFeeFlag = 0x400000
+
+	hookGas = 30000
 )
 
 var (
 	// NativeTokenAddress is the address that UniswapV4 uses to represent native token in pools.
-	NativeTokenAddress = common.Address{}
-	Q96                = new(big.Int).Lsh(bignumber.One, 96)
-	ErrUnsupportedHook = errors.New("unsupported hook")
+	NativeTokenAddress  = common.Address{}
+	Q96                 = new(big.Int).Lsh(bignumber.One, 96)
+	ErrUnsupportedHook  = errors.New("unsupported hook")
+	ErrInvalidHookDelta = errors.New("invalid hook delta")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrLpFeeTooLarge    = errors.New("lp fee too large")
+
+	maxLpFee = big.NewInt(1_000_000)
 
 	ErrTooManyChangedTickes = errors.New("too many changed ticks")
 )
@@ -0,0 +1,133 @@
+package uniswapv4
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// HookKindDynamicFee is the kind of the hooks simulated by DynamicFeeHook.
+	HookKindDynamicFee = "dynamic-fee"
+	// HookKindFeeTaking is the kind of the hooks simulated by FeeTakingHook.
+	HookKindFeeTaking = "fee-taking"
+	// HookKindSpecifiedFee is the kind of the hooks simulated by SpecifiedFeeHook.
+	HookKindSpecifiedFee = "specified-fee"
+)
+
+func init() {
+	RegisterHookKindFactory(HookKindDynamicFee, NewDynamicFeeHook)
+	RegisterHookKindFactory(HookKindFeeTaking, NewFeeTakingHook)
+	RegisterHookKindFactory(HookKindSpecifiedFee, NewSpecifiedFeeHook)
+}
+
+// feeHookExtra is the HookExtra of fee-taking hooks configured in Config.Hooks.
+type feeHookExtra struct {
+	FeePips uint32 `json:"feePips"`
+}
+
+// hookFeePips returns the fee of the hook of param: from Config.Hooks when tracking, else from the HookExtra it
+// tracked.
+func hookFeePips(param *HookParam) (uint32, error) {
+	if param.Cfg != nil {
+		hookConfig, ok := param.Cfg.Hooks[param.HookAddress]
+		if !ok {
+			return 0, fmt.Errorf("%w: hook %s is not configured", ErrUnsupportedHook, param.HookAddress)
+		}
+		return hookConfig.FeePips, nil
+	}
+	var extra feeHookExtra
+	if err := json.Unmarshal([]byte(param.HookExtra), &extra); err != nil {
+		return 0, fmt.Errorf("unmarshal hook extra: %w", err)
+	}
+	return extra.FeePips, nil
+}
+
+func trackFeePips(feePips uint32) (string, error) {
+	extraBytes, err := json.Marshal(feeHookExtra{FeePips: feePips})
+	return string(extraBytes), err
+}
+
+// DynamicFeeHook simulates hooks of dynamic fee pools that only update the LP fee stored in the pool slot0, which is
+// tracked with the pool state.
+type DynamicFeeHook struct {
+	BaseHook
+}
+
+func NewDynamicFeeHook(*HookParam) (Hook, error) {
+	return &DynamicFeeHook{}, nil
+}
+
+// FeeTakingHook simulates hooks taking a fixed share, in pips, of the unspecified amount of every swap in afterSwap:
+// the output of exact input swaps or the input of exact output swaps.
+type FeeTakingHook struct {
+	BaseHook
+	FeePips uint32
+}
+
+func NewFeeTakingHookFactory(feePips uint32) HookFactory {
+	return func(*HookParam) (Hook, error) {
+		return &FeeTakingHook{FeePips: feePips}, nil
+	}
+}
+
+// NewFeeTakingHook creates the FeeTakingHook of a hook configured in Config.Hooks.
+func NewFeeTakingHook(param *HookParam) (Hook, error) {
+	feePips, err := hookFeePips(param)
+	if err != nil {
+		return nil, err
+	}
+	return &FeeTakingHook{FeePips: feePips}, nil
+}
+
+func (h *FeeTakingHook) Track(context.Context, *HookParam) (string, error) {
+	return trackFeePips(h.FeePips)
+}
+
+func (h *FeeTakingHook) AfterSwap(param *AfterSwapParams) (*AfterSwapResult, error) {
+	unspecified := lo.Ternary(param.ExactIn, param.AmountOut, param.AmountIn)
+	return &AfterSwapResult{
+		DeltaUnspecified: mulPips(unspecified, h.FeePips),
+	}, nil
+}
+
+// SpecifiedFeeHook simulates hooks taking a fixed share, in pips, of the specified amount of every swap in
+// beforeSwap, returning it as a BeforeSwapDelta so that the pool only swaps the remainder.
+type SpecifiedFeeHook struct {
+	BaseHook
+	FeePips uint32
+}
+
+func NewSpecifiedFeeHookFactory(feePips uint32) HookFactory {
+	return func(*HookParam) (Hook, error) {
+		return &SpecifiedFeeHook{FeePips: feePips}, nil
+	}
+}
+
+// NewSpecifiedFeeHook creates the SpecifiedFeeHook of a hook configured in Config.Hooks.
+func NewSpecifiedFeeHook(param *HookParam) (Hook, error) {
+	feePips, err := hookFeePips(param)
+	if err != nil {
+		return nil, err
+	}
+	return &SpecifiedFeeHook{FeePips: feePips}, nil
+}
+
+func (h *SpecifiedFeeHook) Track(context.Context, *HookParam) (string, error) {
+	return trackFeePips(h.FeePips)
+}
+
+func (h *SpecifiedFeeHook) BeforeSwap(param *BeforeSwapParams) (*BeforeSwapResult, error) {
+	return &BeforeSwapResult{
+		DeltaSpecified:   mulPips(param.AmountSpecified, h.FeePips),
+		DeltaUnspecified: new(big.Int),
+	}, nil
+}
+
+func mulPips(amount *big.Int, pips uint32) *big.Int {
+	fee := new(big.Int).Mul(amount, big.NewInt(int64(pips)))
+	return fee.Quo(fee, maxLpFee)
+}
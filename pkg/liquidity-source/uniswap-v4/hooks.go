@@ -1,7 +1,13 @@
 package uniswapv4
 
 import (
+	"context"
+	"math/big"
+
+	"github.com/KyberNetwork/ethrpc"
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 )
 
 // HookOption represents different hook operation types
@@ -38,3 +44,102 @@ func HasSwapPermissions(address common.Address) bool {
 	// This implicitly encapsulates swap delta permissions
 	return hasPermission(address, BeforeSwap) || hasPermission(address, AfterSwap)
 }
+
+// Hook simulates the swap callbacks of a hook contract. Deltas follow the PoolManager convention from the hook point
+// of view: a positive delta is an amount taken by the hook from the swapper, a negative one is paid to the swapper.
+// Hooks are immutable once created: their state is only refreshed by the tracker, so they are shared between clones.
+type Hook interface {
+	// Track fetches the hook state needed for simulation, stored as Extra.HookExtra.
+	Track(ctx context.Context, param *HookParam) (string, error)
+	BeforeSwap(param *BeforeSwapParams) (*BeforeSwapResult, error)
+	AfterSwap(param *AfterSwapParams) (*AfterSwapResult, error)
+}
+
+// HookParam is passed to hook factories. RpcClient and Cfg are only set when the hook is created by the tracker.
+type HookParam struct {
+	Cfg         *Config
+	RpcClient   *ethrpc.Client
+	Pool        *entity.Pool
+	HookAddress common.Address
+	// HookKind is the kind of the hook, see Config.Hooks, for hooks that are not registered by address.
+	HookKind    string
+	HookExtra   string
+	BlockNumber *big.Int
+}
+
+type BeforeSwapParams struct {
+	ZeroForOne      bool
+	ExactIn         bool
+	AmountSpecified *big.Int
+}
+
+type BeforeSwapResult struct {
+	DeltaSpecified   *big.Int
+	DeltaUnspecified *big.Int
+	// LpFeeOverride replaces the LP fee of dynamic fee pools for this swap when it has OverrideFeeFlag set
+	LpFeeOverride uint32
+}
+
+type AfterSwapParams struct {
+	ZeroForOne bool
+	ExactIn    bool
+	AmountIn   *big.Int
+	AmountOut  *big.Int
+}
+
+type AfterSwapResult struct {
+	DeltaUnspecified *big.Int
+}
+
+type HookFactory func(param *HookParam) (Hook, error)
+
+var (
+	hookFactories     = map[common.Address]HookFactory{}
+	hookKindFactories = map[string]HookFactory{}
+)
+
+// RegisterHooksFactory registers the simulation of the hook contracts deployed at addresses. Pools using a hook with
+// swap permissions are only simulated when their hook is registered, by address or by kind.
+func RegisterHooksFactory(factory HookFactory, addresses ...common.Address) bool {
+	for _, address := range addresses {
+		hookFactories[address] = factory
+	}
+	return true
+}
+
+// RegisterHookKindFactory registers the simulation of a kind of hooks, used for the hook contracts that Config.Hooks
+// configures with this kind.
+func RegisterHookKindFactory(kind string, factory HookFactory) bool {
+	hookKindFactories[kind] = factory
+	return true
+}
+
+// GetHook creates the hook of param.HookAddress, or else of param.HookKind, returning false when no factory is
+// registered for either.
+func GetHook(param *HookParam) (Hook, bool, error) {
+	factory, ok := hookFactories[param.HookAddress]
+	if !ok {
+		if factory, ok = hookKindFactories[param.HookKind]; !ok {
+			return nil, false, nil
+		}
+	}
+	hook, err := factory(param)
+	return hook, true, err
+}
+
+// BaseHook does not alter swaps. It is meant to be embedded by hooks only implementing some callbacks.
+type BaseHook struct{}
+
+var _ Hook = (*BaseHook)(nil)
+
+func (h *BaseHook) Track(context.Context, *HookParam) (string, error) {
+	return "", nil
+}
+
+func (h *BaseHook) BeforeSwap(*BeforeSwapParams) (*BeforeSwapResult, error) {
+	return &BeforeSwapResult{DeltaSpecified: new(big.Int), DeltaUnspecified: new(big.Int)}, nil
+}
+
+func (h *BaseHook) AfterSwap(*AfterSwapParams) (*AfterSwapResult, error) {
+	return &AfterSwapResult{DeltaUnspecified: new(big.Int)}, nil
+}
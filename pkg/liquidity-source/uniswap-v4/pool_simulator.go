@@ -3,7 +3,9 @@ package uniswapv4
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 
+	"github.com/KyberNetwork/uniswapv3-sdk-uint256/constants"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
@@ -19,6 +21,7 @@ var (
 type PoolSimulator struct {
 	*uniswapv3.PoolSimulator
 	staticExtra StaticExtra
+	hook        Hook
 }
 
 var _ = pool.RegisterFactory1(DexType, NewPoolSimulator)
@@ -29,8 +32,31 @@ func NewPoolSimulator(entityPool entity.Pool, chainID valueobject.ChainID) (*Poo
 		return nil, fmt.Errorf("unmarshal static extra: %w", err)
 	}
 
+	var extra Extra
+	if err := json.Unmarshal([]byte(entityPool.Extra), &extra); err != nil {
+		return nil, fmt.Errorf("unmarshal extra: %w", err)
+	}
+
+	var hook Hook
 	if HasSwapPermissions(staticExtra.HooksAddress) {
-		return nil, ErrUnsupportedHook
+		var ok bool
+		var err error
+		hook, ok, err = GetHook(&HookParam{
+			Pool:        &entityPool,
+			HookAddress: staticExtra.HooksAddress,
+			HookKind:    extra.HookKind,
+			HookExtra:   extra.HookExtra,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("init hook: %w", err)
+		} else if !ok {
+			return nil, ErrUnsupportedHook
+		}
+	}
+
+	if staticExtra.Fee == DynamicFeeFlag {
+		// the LP fee of dynamic fee pools is the one last set by their hook
+		entityPool.SwapFee = float64(extra.LpFee)
 	}
 
 	v3PoolSimulator, err := uniswapv3.NewPoolSimulator(entityPool, chainID)
@@ -46,15 +72,180 @@ func NewPoolSimulator(entityPool entity.Pool, chainID valueobject.ChainID) (*Poo
 	return &PoolSimulator{
 		PoolSimulator: v3PoolSimulator,
 		staticExtra:   staticExtra,
+		hook:          hook,
 	}, nil
 }
 
+func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	if p.hook == nil {
+		return p.PoolSimulator.CalcAmountOut(param)
+	}
+
+	tokenAmountIn, tokenOut := param.TokenAmountIn, param.TokenOut
+	tokenInIndex, tokenOutIndex := p.GetTokenIndex(tokenAmountIn.Token), p.GetTokenIndex(tokenOut)
+	if tokenInIndex < 0 || tokenOutIndex < 0 {
+		return nil, fmt.Errorf("tokenInIndex %v or tokenOutIndex %v is not correct", tokenInIndex, tokenOutIndex)
+	}
+	zeroForOne := tokenInIndex == 0
+
+	beforeSwap, err := p.hook.BeforeSwap(&BeforeSwapParams{
+		ZeroForOne:      zeroForOne,
+		ExactIn:         true,
+		AmountSpecified: tokenAmountIn.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	amountToSwap := new(big.Int).Sub(tokenAmountIn.Amount, beforeSwap.DeltaSpecified)
+	if amountToSwap.Sign() < 0 {
+		return nil, ErrInvalidHookDelta
+	}
+
+	result := &pool.CalcAmountOutResult{
+		TokenAmountOut:         &pool.TokenAmount{Token: tokenOut, Amount: new(big.Int)},
+		RemainingTokenAmountIn: &pool.TokenAmount{Token: tokenAmountIn.Token, Amount: new(big.Int)},
+		Fee:                    &pool.TokenAmount{Token: tokenAmountIn.Token},
+		Gas:                    p.Gas.BaseGas,
+	}
+	// the PoolManager skips the swap when the hook takes the whole specified amount
+	if amountToSwap.Sign() > 0 {
+		v3Pool, err := p.v3PoolWithFee(beforeSwap.LpFeeOverride)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = v3Pool.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tokenAmountIn.Token, Amount: amountToSwap},
+			TokenOut:      tokenOut,
+			Limit:         param.Limit,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	afterSwap, err := p.hook.AfterSwap(&AfterSwapParams{
+		ZeroForOne: zeroForOne,
+		ExactIn:    true,
+		AmountIn:   amountToSwap,
+		AmountOut:  result.TokenAmountOut.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	amountOut := new(big.Int).Sub(result.TokenAmountOut.Amount, beforeSwap.DeltaUnspecified)
+	if amountOut.Sub(amountOut, afterSwap.DeltaUnspecified).Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	result.TokenAmountOut = &pool.TokenAmount{Token: tokenOut, Amount: amountOut}
+	result.Gas += hookGas
+	return result, nil
+}
+
+func (p *PoolSimulator) CalcAmountIn(param pool.CalcAmountInParams) (*pool.CalcAmountInResult, error) {
+	if p.hook == nil {
+		return p.PoolSimulator.CalcAmountIn(param)
+	}
+
+	tokenIn, tokenAmountOut := param.TokenIn, param.TokenAmountOut
+	tokenInIndex, tokenOutIndex := p.GetTokenIndex(tokenIn), p.GetTokenIndex(tokenAmountOut.Token)
+	if tokenInIndex < 0 || tokenOutIndex < 0 {
+		return nil, fmt.Errorf("tokenInIndex %v or tokenOutIndex %v is not correct", tokenInIndex, tokenOutIndex)
+	}
+	zeroForOne := tokenInIndex == 0
+
+	beforeSwap, err := p.hook.BeforeSwap(&BeforeSwapParams{
+		ZeroForOne:      zeroForOne,
+		ExactIn:         false,
+		AmountSpecified: tokenAmountOut.Amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	amountToSwap := new(big.Int).Add(tokenAmountOut.Amount, beforeSwap.DeltaSpecified)
+	if amountToSwap.Sign() < 0 {
+		return nil, ErrInvalidHookDelta
+	}
+
+	result := &pool.CalcAmountInResult{
+		TokenAmountIn: &pool.TokenAmount{Token: tokenIn, Amount: new(big.Int)},
+		Fee:           &pool.TokenAmount{Token: tokenIn},
+		Gas:           p.Gas.BaseGas,
+	}
+	if amountToSwap.Sign() > 0 {
+		v3Pool, err := p.v3PoolWithFee(beforeSwap.LpFeeOverride)
+		if err != nil {
+			return nil, err
+		}
+		if result, err = v3Pool.CalcAmountIn(pool.CalcAmountInParams{
+			TokenAmountOut: pool.TokenAmount{Token: tokenAmountOut.Token, Amount: amountToSwap},
+			TokenIn:        tokenIn,
+			Limit:          param.Limit,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	afterSwap, err := p.hook.AfterSwap(&AfterSwapParams{
+		ZeroForOne: zeroForOne,
+		ExactIn:    false,
+		AmountIn:   result.TokenAmountIn.Amount,
+		AmountOut:  amountToSwap,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	amountIn := new(big.Int).Add(result.TokenAmountIn.Amount, beforeSwap.DeltaUnspecified)
+	if amountIn.Add(amountIn, afterSwap.DeltaUnspecified).Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	result.TokenAmountIn = &pool.TokenAmount{Token: tokenIn, Amount: amountIn}
+	result.Gas += hookGas
+	return result, nil
+}
+
+// v3PoolWithFee returns the pool to swap with, using the LP fee returned by beforeSwap for dynamic fee pools.
+func (p *PoolSimulator) v3PoolWithFee(lpFeeOverride uint32) (*uniswapv3.PoolSimulator, error) {
+	if p.staticExtra.Fee != DynamicFeeFlag || lpFeeOverride&OverrideFeeFlag == 0 {
+		return p.PoolSimulator, nil
+	}
+	fee := constants.FeeAmount(lpFeeOverride &^ OverrideFeeFlag)
+	if fee >= constants.FeeMax {
+		return nil, ErrLpFeeTooLarge
+	} else if fee == p.V3Pool.Fee {
+		return p.PoolSimulator, nil
+	}
+
+	v3PoolSimulator := *p.PoolSimulator
+	v3Pool := *v3PoolSimulator.V3Pool
+	v3Pool.Fee = fee
+	v3PoolSimulator.V3Pool = &v3Pool
+	return &v3PoolSimulator, nil
+}
+
+// SpotPrice returns the spot price of the underlying v3 pool math. Pools with swap hooks are probed, as their hooks
+// may take deltas or override the LP fee.
+func (p *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if p.hook != nil {
+		return nil, pool.ErrSpotPriceUnsupported
+	}
+	return p.PoolSimulator.SpotPrice(tokenIn, tokenOut)
+}
+
 func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 	cloned := *p
 	cloned.PoolSimulator = p.PoolSimulator.CloneState().(*uniswapv3.PoolSimulator)
 	return &cloned
 }
 
+func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
+	// swaps fully handled by the hook do not touch the pool
+	if params.SwapInfo == nil {
+		return
+	}
+	p.PoolSimulator.UpdateBalance(params)
+}
+
 // GetMetaInfo
 // adapt from https://github.com/KyberNetwork/kyberswap-dex-lib-private/blob/c1877a8c19759faeb7d82b6902ed335f0657ce3e/pkg/liquidity-source/uniswap-v4/pool_simulator.go#L201
 func (p *PoolSimulator) GetMetaInfo(tokenIn string, tokenOut string) interface{} {
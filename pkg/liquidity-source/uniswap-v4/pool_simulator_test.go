@@ -1,15 +1,20 @@
 package uniswapv4
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
+	"math/big"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	utils "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/testutil"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
 )
 
@@ -38,3 +43,205 @@ func TestPoolSimulator(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, utils.NewBig10("415003200864711604166794"), got.TokenAmountOut.Amount)
 }
+
+func TestPoolSimulator_SpotPrice(t *testing.T) {
+	const (
+		weth = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+		brig = "0xbeab712832112bd7664226db7cd025b153d3af55"
+	)
+	var (
+		afterSwapHook        = common.HexToAddress("0x00000000000000000000000000000000000a0040")
+		hookFeePips   uint32 = 3000
+	)
+	RegisterHooksFactory(NewFeeTakingHookFactory(hookFeePips), afterSwapHook)
+
+	base, err := newHookedPoolSimulator(t, common.Address{}, 10000, 0)
+	require.NoError(t, err)
+	testutil.TestSpotPrice(t, base, 1e-6)
+	baseSpotPrice, err := base.SpotPrice(weth, brig)
+	require.NoError(t, err)
+
+	hooked, err := newHookedPoolSimulator(t, afterSwapHook, 10000, 0)
+	require.NoError(t, err)
+	_, err = hooked.SpotPrice(weth, brig)
+	assert.ErrorIs(t, err, pool.ErrSpotPriceUnsupported)
+
+	spotPrice, err := pool.SpotPrice(hooked, pool.SpotPriceParams{TokenIn: weth, TokenOut: brig})
+	require.NoError(t, err)
+	expected, _ := baseSpotPrice.Float64()
+	actual, _ := spotPrice.Float64()
+	assert.InEpsilon(t, expected*(1-float64(hookFeePips)/1e6), actual, 1e-5)
+}
+
+func newHookedPoolSimulator(t *testing.T, hooks common.Address, fee, lpFee uint32) (*PoolSimulator, error) {
+	var poolEnt entity.Pool
+	require.NoError(t, json.Unmarshal([]byte(poolData), &poolEnt))
+
+	var staticExtra StaticExtra
+	require.NoError(t, json.Unmarshal([]byte(poolEnt.StaticExtra), &staticExtra))
+	staticExtra.HooksAddress, staticExtra.Fee = hooks, fee
+	staticExtraBytes, err := json.Marshal(staticExtra)
+	require.NoError(t, err)
+	poolEnt.StaticExtra = string(staticExtraBytes)
+
+	var extra Extra
+	require.NoError(t, json.Unmarshal([]byte(poolEnt.Extra), &extra))
+	extra.LpFee = lpFee
+	extraBytes, err := json.Marshal(extra)
+	require.NoError(t, err)
+	poolEnt.Extra = string(extraBytes)
+
+	return NewPoolSimulator(poolEnt, valueobject.ChainID(1))
+}
+
+func TestPoolSimulator_Hooks(t *testing.T) {
+	const (
+		weth = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+		brig = "0xbeab712832112bd7664226db7cd025b153d3af55"
+	)
+	var (
+		afterSwapHook          = common.HexToAddress("0x00000000000000000000000000000000000a0040")
+		beforeSwapHook         = common.HexToAddress("0x00000000000000000000000000000000000b0080")
+		dynamicFeeHook         = common.HexToAddress("0x00000000000000000000000000000000000c0080")
+		unsupportedHook        = common.HexToAddress("0x00000000000000000000000000000000000d00c0")
+		amountIn               = utils.NewBig10("1000000000000000000")
+		amountOut              = utils.NewBig10("100000000000000000000000")
+		hookFeePips     uint32 = 3000
+	)
+	RegisterHooksFactory(NewFeeTakingHookFactory(hookFeePips), afterSwapHook)
+	RegisterHooksFactory(NewSpecifiedFeeHookFactory(hookFeePips), beforeSwapHook)
+	RegisterHooksFactory(NewDynamicFeeHook, dynamicFeeHook)
+
+	calcAmountOut := func(t *testing.T, sim *PoolSimulator, amountIn *big.Int) *big.Int {
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      brig,
+		})
+		require.NoError(t, err)
+		return res.TokenAmountOut.Amount
+	}
+	calcAmountIn := func(t *testing.T, sim *PoolSimulator, amountOut *big.Int) *big.Int {
+		res, err := sim.CalcAmountIn(pool.CalcAmountInParams{
+			TokenAmountOut: pool.TokenAmount{Token: brig, Amount: amountOut},
+			TokenIn:        weth,
+		})
+		require.NoError(t, err)
+		return res.TokenAmountIn.Amount
+	}
+
+	base, err := newHookedPoolSimulator(t, common.Address{}, 10000, 0)
+	require.NoError(t, err)
+	baseOut, baseIn := calcAmountOut(t, base, amountIn), calcAmountIn(t, base, amountOut)
+
+	t.Run("unsupported hook", func(t *testing.T) {
+		_, err := newHookedPoolSimulator(t, unsupportedHook, 10000, 0)
+		assert.ErrorIs(t, err, ErrUnsupportedHook)
+	})
+
+	t.Run("after swap fee", func(t *testing.T) {
+		sim, err := newHookedPoolSimulator(t, afterSwapHook, 10000, 0)
+		require.NoError(t, err)
+
+		assert.Equal(t, new(big.Int).Sub(baseOut, mulPips(baseOut, hookFeePips)), calcAmountOut(t, sim, amountIn))
+		assert.Equal(t, new(big.Int).Add(baseIn, mulPips(baseIn, hookFeePips)), calcAmountIn(t, sim, amountOut))
+	})
+
+	t.Run("before swap delta", func(t *testing.T) {
+		sim, err := newHookedPoolSimulator(t, beforeSwapHook, 10000, 0)
+		require.NoError(t, err)
+
+		swapped := new(big.Int).Sub(amountIn, mulPips(amountIn, hookFeePips))
+		assert.Equal(t, calcAmountOut(t, base, swapped), calcAmountOut(t, sim, amountIn))
+		grossOut := new(big.Int).Add(amountOut, mulPips(amountOut, hookFeePips))
+		assert.Equal(t, calcAmountIn(t, base, grossOut), calcAmountIn(t, sim, amountOut))
+	})
+
+	t.Run("dynamic fee", func(t *testing.T) {
+		sim, err := newHookedPoolSimulator(t, dynamicFeeHook, DynamicFeeFlag, 10000)
+		require.NoError(t, err)
+		assert.Equal(t, baseOut, calcAmountOut(t, sim, amountIn))
+
+		cheaper, err := newHookedPoolSimulator(t, dynamicFeeHook, DynamicFeeFlag, 3000)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calcAmountOut(t, cheaper, amountIn).Cmp(baseOut))
+	})
+
+	t.Run("update balance", func(t *testing.T) {
+		sim, err := newHookedPoolSimulator(t, afterSwapHook, 10000, 0)
+		require.NoError(t, err)
+		cloned := sim.CloneState().(*PoolSimulator)
+
+		res, err := sim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      brig,
+		})
+		require.NoError(t, err)
+		sim.UpdateBalance(pool.UpdateBalanceParams{
+			TokenAmountIn:  pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenAmountOut: *res.TokenAmountOut,
+			SwapInfo:       res.SwapInfo,
+		})
+
+		assert.Equal(t, 1, res.TokenAmountOut.Amount.Cmp(calcAmountOut(t, sim, amountIn)))
+		assert.Equal(t, res.TokenAmountOut.Amount, calcAmountOut(t, cloned, amountIn))
+	})
+}
+
+func TestPoolSimulator_ConfiguredHooks(t *testing.T) {
+	const (
+		weth = "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"
+		brig = "0xbeab712832112bd7664226db7cd025b153d3af55"
+	)
+	var (
+		configuredHook        = common.HexToAddress("0x00000000000000000000000000000000000e0040")
+		hookFeePips    uint32 = 3000
+		amountIn              = utils.NewBig10("1000000000000000000")
+	)
+	tracker := &PoolTracker{config: &Config{
+		Hooks: map[common.Address]HookConfig{configuredHook: {Kind: HookKindFeeTaking, FeePips: hookFeePips}},
+	}}
+
+	newPool := func(t *testing.T, hooks common.Address) pool.IPoolSimulator {
+		var poolEnt entity.Pool
+		require.NoError(t, json.Unmarshal([]byte(poolData), &poolEnt))
+		var staticExtra StaticExtra
+		require.NoError(t, json.Unmarshal([]byte(poolEnt.StaticExtra), &staticExtra))
+		staticExtra.HooksAddress = hooks
+		staticExtraBytes, err := json.Marshal(staticExtra)
+		require.NoError(t, err)
+		poolEnt.StaticExtra = string(staticExtraBytes)
+
+		var extra Extra
+		require.NoError(t, json.Unmarshal([]byte(poolEnt.Extra), &extra))
+		extra.HookKind, extra.HookExtra, err = tracker.trackHook(context.Background(), &poolEnt, nil)
+		require.NoError(t, err)
+		extraBytes, err := json.Marshal(extra)
+		require.NoError(t, err)
+		poolEnt.Extra = string(extraBytes)
+
+		poolSim, err := pool.Factory(DexType)(pool.FactoryParams{
+			EntityPool: poolEnt,
+			ChainID:    valueobject.ChainIDEthereum,
+		})
+		require.NoError(t, err)
+		return poolSim
+	}
+	calcAmountOut := func(t *testing.T, poolSim pool.IPoolSimulator) *big.Int {
+		res, err := poolSim.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: weth, Amount: amountIn},
+			TokenOut:      brig,
+		})
+		require.NoError(t, err)
+		return res.TokenAmountOut.Amount
+	}
+
+	baseOut := calcAmountOut(t, newPool(t, common.Address{}))
+	hooked := newPool(t, configuredHook)
+	assert.Equal(t, &FeeTakingHook{FeePips: hookFeePips}, hooked.(*PoolSimulator).hook)
+	assert.Equal(t, new(big.Int).Sub(baseOut, mulPips(baseOut, hookFeePips)), calcAmountOut(t, hooked))
+
+	_, _, err := tracker.trackHook(context.Background(), &entity.Pool{
+		StaticExtra: `{"hooks":"0x00000000000000000000000000000000000f0040"}`,
+	}, nil)
+	assert.NoError(t, err, "pools with unconfigured hooks are tracked without hook state")
+}
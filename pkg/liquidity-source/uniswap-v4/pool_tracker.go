@@ -17,6 +17,7 @@ import (
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
 	poolpkg "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/uniswapv3"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/eth"
 	graphqlpkg "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/graphql"
@@ -154,12 +155,25 @@ func (t *PoolTracker) GetNewPoolState(
 		ticks = append(ticks, tick)
 	}
 
+	hookKind, hookExtra, err := t.trackHook(ctx, &p, big.NewInt(int64(blockNumber)))
+	if err != nil {
+		l.WithFields(logger.Fields{
+			"error": err,
+		}).Error("failed to track hook")
+		return entity.Pool{}, err
+	}
+
 	extraBytes, err := json.Marshal(Extra{
-		Liquidity:    rpcData.Liquidity,
-		TickSpacing:  uint64(rpcData.TickSpacing),
-		SqrtPriceX96: rpcData.Slot0.SqrtPriceX96,
-		Tick:         rpcData.Slot0.Tick,
-		Ticks:        ticks,
+		Extra: uniswapv3.Extra{
+			Liquidity:    rpcData.Liquidity,
+			TickSpacing:  uint64(rpcData.TickSpacing),
+			SqrtPriceX96: rpcData.Slot0.SqrtPriceX96,
+			Tick:         rpcData.Slot0.Tick,
+			Ticks:        ticks,
+		},
+		LpFee:     uint32(rpcData.Slot0.LpFee.Uint64()),
+		HookKind:  hookKind,
+		HookExtra: hookExtra,
 	})
 	if err != nil {
 		l.WithFields(logger.Fields{
@@ -186,6 +200,38 @@ func (t *PoolTracker) GetNewPoolState(
 	return p, nil
 }
 
+// trackHook fetches the state of the pool hook if it has swap permissions and is supported, and returns its kind if it
+// is configured in Config.Hooks.
+func (t *PoolTracker) trackHook(ctx context.Context, p *entity.Pool, blockNumber *big.Int) (string, string, error) {
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(p.StaticExtra), &staticExtra); err != nil {
+		return "", "", err
+	}
+	if !HasSwapPermissions(staticExtra.HooksAddress) {
+		return "", "", nil
+	}
+
+	var extra Extra
+	if len(p.Extra) > 0 {
+		_ = json.Unmarshal([]byte(p.Extra), &extra)
+	}
+	param := &HookParam{
+		Cfg:         t.config,
+		RpcClient:   t.ethrpcClient,
+		Pool:        p,
+		HookAddress: staticExtra.HooksAddress,
+		HookKind:    t.config.Hooks[staticExtra.HooksAddress].Kind,
+		HookExtra:   extra.HookExtra,
+		BlockNumber: blockNumber,
+	}
+	hook, ok, err := GetHook(param)
+	if err != nil || !ok {
+		return "", "", err
+	}
+	hookExtra, err := hook.Track(ctx, param)
+	return param.HookKind, hookExtra, err
+}
+
 // getPoolTicks
 func (t *PoolTracker) getPoolTicks(ctx context.Context, poolAddress string) ([]ticklens.TickResp, error) {
 	l := logger.WithFields(logger.Fields{
@@ -33,7 +33,16 @@ type StaticExtra struct {
 	Multicall3Address      common.Address `json:"mc3"`
 }
 
-type Extra = uniswapv3.Extra
+type Extra struct {
+	uniswapv3.Extra
+	// LpFee is the current LP fee of the pool, which differs from StaticExtra.Fee for dynamic fee pools
+	LpFee uint32 `json:"lpFee,omitempty"`
+	// HookKind is the kind of the hook configured in Config.Hooks, if it is not registered by address
+	HookKind string `json:"hookKind,omitempty"`
+	// HookExtra is the hook state fetched by Hook.Track
+	HookExtra string `json:"hookExtra,omitempty"`
+}
+
 type ExtraTickU256 = uniswapv3.ExtraTickU256
 
 type Slot0Data struct {
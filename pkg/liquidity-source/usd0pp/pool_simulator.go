@@ -2,7 +2,6 @@ package usd0pp
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
@@ -61,10 +60,7 @@ func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 		return nil, ErrorInvalidTokenInAmount
 	}
 
-	// assume block.timestamp is current time
-	blockTimestamp := time.Now().Unix()
-
-	var amountOut, err = s.mint(params.TokenAmountIn.Amount, blockTimestamp)
+	var amountOut, err = s.mint(params.TokenAmountIn.Amount, params.Now())
 	if err != nil {
 		return nil, err
 	}
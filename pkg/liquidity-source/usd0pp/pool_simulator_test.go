@@ -91,6 +91,32 @@ func TestPoolSimulator_CalcAmountOut(t *testing.T) {
 			},
 			expectedError: ErrBondEnded,
 		},
+		{
+			name: "it should evaluate the bond window at the given timestamp",
+			poolSimulator: &PoolSimulator{
+				Pool: poolpkg.Pool{
+					Info: poolpkg.PoolInfo{
+						Tokens: []string{USD0, USD0PP},
+						Reserves: []*big.Int{
+							bignumber.NewBig("40654517980271452478787"),
+							bignumber.NewBig("40654517980271452478787"),
+						},
+					},
+				},
+				paused:    false,
+				startTime: 1718105400,
+				endTime:   1718105410,
+			},
+			param: poolpkg.CalcAmountOutParams{
+				TokenAmountIn: poolpkg.TokenAmount{
+					Amount: bignumber.NewBig("10610010000000000"),
+					Token:  USD0,
+				},
+				TokenOut:  USD0PP,
+				Timestamp: 1718105405,
+			},
+			expectedAmountOut: bignumber.NewBig("10610010000000000"),
+		},
 		{
 			name: "it should return error when tokenIn is invalid",
 			poolSimulator: &PoolSimulator{
@@ -3,7 +3,6 @@ package woofiv2
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/logger"
@@ -116,7 +115,7 @@ func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 	}
 	if tokenAmountIn.Token == s.quoteToken {
 		var newPrice *uint256.Int
-		amountOut, swapFee, newPrice, err = s._sellQuote(tokenOut, amountIn)
+		amountOut, swapFee, newPrice, err = s._sellQuote(tokenOut, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
@@ -126,7 +125,7 @@ func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 		}
 	} else if tokenOut == s.quoteToken {
 		var newPrice *uint256.Int
-		amountOut, swapFee, newPrice, err = s._sellBase(tokenAmountIn.Token, amountIn)
+		amountOut, swapFee, newPrice, err = s._sellBase(tokenAmountIn.Token, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
@@ -136,7 +135,7 @@ func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 		}
 	} else {
 		var newBase1Price, newBase2Price *uint256.Int
-		amountOut, swapFee, newBase1Price, newBase2Price, err = s._swapBaseToBase(tokenAmountIn.Token, tokenOut, amountIn)
+		amountOut, swapFee, newBase1Price, newBase2Price, err = s._swapBaseToBase(tokenAmountIn.Token, tokenOut, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
@@ -184,12 +183,13 @@ func (s *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} { return nil
 func (s *PoolSimulator) _sellBase(
 	baseToken string,
 	baseAmount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *uint256.Int, error) {
 	if baseToken == s.quoteToken {
 		return nil, nil, nil, ErrBaseTokenIsQuoteToken
 	}
 
-	state := s._wooracleV2State(baseToken)
+	state := s._wooracleV2State(baseToken, timestamp)
 
 	quoteAmount, newPrice, err := s._calcQuoteAmountSellBase(baseToken, baseAmount, state)
 	if err != nil {
@@ -219,6 +219,7 @@ func (s *PoolSimulator) _sellBase(
 func (s *PoolSimulator) _sellQuote(
 	baseToken string,
 	quoteAmount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *uint256.Int, error) {
 	if baseToken == s.quoteToken {
 		return nil, nil, nil, ErrBaseTokenIsQuoteToken
@@ -234,7 +235,7 @@ func (s *PoolSimulator) _sellQuote(
 
 	quoteAmount = new(uint256.Int).Sub(quoteAmount, swapFee)
 
-	state := s._wooracleV2State(baseToken)
+	state := s._wooracleV2State(baseToken, timestamp)
 
 	baseAmount, newPrice, err := s._calcBaseAmountSellQuote(baseToken, quoteAmount, state)
 	if err != nil {
@@ -255,9 +256,10 @@ func (s *PoolSimulator) _swapBaseToBase(
 	baseToken1 string,
 	baseToken2 string,
 	base1Amount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *uint256.Int, *uint256.Int, error) {
-	state1 := s._wooracleV2State(baseToken1)
-	state2 := s._wooracleV2State(baseToken2)
+	state1 := s._wooracleV2State(baseToken1, timestamp)
+	state2 := s._wooracleV2State(baseToken2, timestamp)
 
 	var spread uint64
 	if state1.Spread > state2.Spread {
@@ -473,9 +475,9 @@ func (s *PoolSimulator) updateBalanceSellBase(params pool.UpdateBalanceParams) {
 
 // WooracleV2.state
 // https://github.com/woonetwork/WooPoolV2/blob/fb94e2bf4882f51340c66357e8c566edc2a767a9/contracts/wooracle/WooracleV2.sol#L281-L285
-func (s *PoolSimulator) _wooracleV2State(base string) State {
+func (s *PoolSimulator) _wooracleV2State(base string, timestamp int64) State {
 	info := s.wooracle.States[base]
-	basePrice, feasible := s._wooracleV2Price(base)
+	basePrice, feasible := s._wooracleV2Price(base, timestamp)
 	return State{
 		Price:      basePrice,
 		Spread:     info.Spread,
@@ -486,12 +488,12 @@ func (s *PoolSimulator) _wooracleV2State(base string) State {
 
 // WooracleV2.price
 // https://github.com/woonetwork/WooPoolV2/blob/fb94e2bf4882f51340c66357e8c566edc2a767a9/contracts/wooracle/WooracleV2.sol#L223-L240
-func (s *PoolSimulator) _wooracleV2Price(base string) (*uint256.Int, bool) {
+func (s *PoolSimulator) _wooracleV2Price(base string, timestamp int64) (*uint256.Int, bool) {
 	woPrice := s.wooracle.States[base].Price
 
 	cloPrice, _ := s._wooracleCloPriceInQuote(base, s.quoteToken)
 
-	woFeasible := !woPrice.Eq(number.Zero) && time.Now().Unix() <= s.wooracle.Timestamp+s.wooracle.StaleDuration
+	woFeasible := !woPrice.Eq(number.Zero) && timestamp <= s.wooracle.Timestamp+s.wooracle.StaleDuration
 
 	bound := uint256.NewInt(s.wooracle.Bound)
 	priceLowerBound := new(uint256.Int).Div(
@@ -3,7 +3,6 @@ package woofiv21
 import (
 	"errors"
 	"fmt"
-	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/number"
 	"github.com/KyberNetwork/logger"
@@ -119,17 +118,17 @@ func (s *PoolSimulator) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.Ca
 		return nil, ErrInvalidAmountIn
 	}
 	if tokenAmountIn.Token == s.quoteToken {
-		amountOut, swapFee, swapInfo, err = s._sellQuote(tokenOut, amountIn)
+		amountOut, swapFee, swapInfo, err = s._sellQuote(tokenOut, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
 	} else if tokenOut == s.quoteToken {
-		amountOut, swapFee, swapInfo, err = s._sellBase(tokenAmountIn.Token, amountIn)
+		amountOut, swapFee, swapInfo, err = s._sellBase(tokenAmountIn.Token, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
 	} else {
-		amountOut, swapFee, swapInfo, err = s._swapBaseToBase(tokenAmountIn.Token, tokenOut, amountIn)
+		amountOut, swapFee, swapInfo, err = s._swapBaseToBase(tokenAmountIn.Token, tokenOut, amountIn, params.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
@@ -172,6 +171,7 @@ func (s *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} { return nil
 func (s *PoolSimulator) _sellQuote(
 	baseToken string,
 	quoteAmount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *woofiV2SwapInfo, error) {
 	if baseToken == s.quoteToken {
 		return nil, nil, nil, ErrBaseTokenIsQuoteToken
@@ -188,7 +188,7 @@ func (s *PoolSimulator) _sellQuote(
 
 	quoteAmount = quoteAmount.Sub(quoteAmount, swapFee)
 
-	state := s._wooracleV2State(baseToken)
+	state := s._wooracleV2State(baseToken, timestamp)
 
 	baseAmount, swapInfo, err := s._calcBaseAmountSellQuote(baseToken, quoteAmount, state)
 	if err != nil {
@@ -208,12 +208,13 @@ func (s *PoolSimulator) _sellQuote(
 func (s *PoolSimulator) _sellBase(
 	baseToken string,
 	baseAmount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *woofiV2SwapInfo, error) {
 	if baseToken == s.quoteToken {
 		return nil, nil, nil, ErrBaseTokenIsQuoteToken
 	}
 
-	state := s._wooracleV2State(baseToken)
+	state := s._wooracleV2State(baseToken, timestamp)
 
 	quoteAmount, swapInfo, err := s._calcQuoteAmountSellBase(baseToken, baseAmount, state)
 	if err != nil {
@@ -245,9 +246,10 @@ func (s *PoolSimulator) _swapBaseToBase(
 	baseToken1 string,
 	baseToken2 string,
 	base1Amount *uint256.Int,
+	timestamp int64,
 ) (*uint256.Int, *uint256.Int, *woofiV2SwapInfo, error) {
-	state1 := s._wooracleV2State(baseToken1)
-	state2 := s._wooracleV2State(baseToken2)
+	state1 := s._wooracleV2State(baseToken1, timestamp)
+	state2 := s._wooracleV2State(baseToken2, timestamp)
 
 	var spread uint64
 	if state1.Spread > state2.Spread {
@@ -464,9 +466,9 @@ func (s *PoolSimulator) decimalInfo(baseToken string) DecimalInfo {
 
 // WooracleV2.state
 // https://arbiscan.io/address/0xCf4EA1688bc23DD93D933edA535F8B72FC8934Ec#code#F1#L325
-func (s *PoolSimulator) _wooracleV2State(base string) State {
+func (s *PoolSimulator) _wooracleV2State(base string, timestamp int64) State {
 	info := s.wooracle.States[base]
-	basePrice, feasible := s._wooracleV2Price(base)
+	basePrice, feasible := s._wooracleV2Price(base, timestamp)
 	return State{
 		Price:      basePrice,
 		Spread:     info.Spread,
@@ -477,12 +479,12 @@ func (s *PoolSimulator) _wooracleV2State(base string) State {
 
 // WooracleV2.price
 // https://arbiscan.io/address/0xCf4EA1688bc23DD93D933edA535F8B72FC8934Ec#code#F1#L272
-func (s *PoolSimulator) _wooracleV2Price(base string) (*uint256.Int, bool) {
+func (s *PoolSimulator) _wooracleV2Price(base string, timestamp int64) (*uint256.Int, bool) {
 	woPrice := s.wooracle.States[base].Price
 
 	cloPrice, _ := s._wooracleCloPriceInQuote(base, s.quoteToken)
 
-	woFeasible := woPrice.Sign() != 0 && time.Now().Unix() <= s.wooracle.Timestamp+s.wooracle.StaleDuration
+	woFeasible := woPrice.Sign() != 0 && timestamp <= s.wooracle.Timestamp+s.wooracle.StaleDuration
 
 	bound := uint256.NewInt(s.wooracle.Bound)
 	priceLowerBound := new(uint256.Int)
@@ -0,0 +1,172 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+
+	"github.com/KyberNetwork/msgpack/v5/msgpcode"
+)
+
+var errTruncated = errors.New("truncated msgpack encoding")
+
+// canonicalize returns the msgpack encoding data with the entries of its maps, at any depth, sorted by the encoding of
+// their keys, so that equal values have equal encodings whatever the key type of their maps and the order they were
+// iterated in. The encoder only sorts string keyed maps, see msgpack.Encoder.SetSortMapKeys.
+func canonicalize(data []byte) ([]byte, error) {
+	dst := make([]byte, 0, len(data))
+	dst, n, err := appendCanonical(dst, data)
+	if err != nil {
+		return nil, err
+	} else if n != len(data) {
+		return nil, errors.New("trailing bytes after msgpack encoding")
+	}
+	return dst, nil
+}
+
+// appendCanonical appends the canonical encoding of the value encoded at the start of src to dst, and returns the
+// length of its encoding in src.
+func appendCanonical(dst, src []byte) ([]byte, int, error) {
+	if len(src) == 0 {
+		return nil, 0, errTruncated
+	}
+	code := src[0]
+	switch {
+	case msgpcode.IsFixedMap(code):
+		return appendCanonicalMap(dst, src, 1, int(code&msgpcode.FixedMapMask))
+	case code == msgpcode.Map16 || code == msgpcode.Map32:
+		n, header, err := readLen(src, code == msgpcode.Map16)
+		if err != nil {
+			return nil, 0, err
+		}
+		return appendCanonicalMap(dst, src, header, n)
+	case msgpcode.IsFixedArray(code):
+		return appendCanonicalValues(dst, src, 1, int(code&msgpcode.FixedArrayMask))
+	case code == msgpcode.Array16 || code == msgpcode.Array32:
+		n, header, err := readLen(src, code == msgpcode.Array16)
+		if err != nil {
+			return nil, 0, err
+		}
+		return appendCanonicalValues(dst, src, header, n)
+	case code == msgpcode.FixExt1 && len(src) > 1 && src[1] == legacyTaggedInterfaceExtID:
+		// a tagged interface: the ext header, the tag then the value, see msgpack.RegisterConcreteType
+		return appendCanonicalValues(dst, src, 2, 2)
+	}
+
+	n, err := scalarLen(src)
+	if err != nil {
+		return nil, 0, err
+	}
+	return append(dst, src[:n]...), n, nil
+}
+
+// appendCanonicalValues appends the header of src then the canonical encodings of the n values following it.
+func appendCanonicalValues(dst, src []byte, header, n int) ([]byte, int, error) {
+	dst = append(dst, src[:header]...)
+	pos := header
+	for range n {
+		var m int
+		var err error
+		if dst, m, err = appendCanonical(dst, src[pos:]); err != nil {
+			return nil, 0, err
+		}
+		pos += m
+	}
+	return dst, pos, nil
+}
+
+// appendCanonicalMap appends the header of src then the n key value pairs following it, sorted by the canonical
+// encodings of their keys.
+func appendCanonicalMap(dst, src []byte, header, n int) ([]byte, int, error) {
+	if n > len(src) {
+		return nil, 0, errTruncated
+	}
+	dst = append(dst, src[:header]...)
+	entries := make([][]byte, n)
+	pos := header
+	for i := range entries {
+		// the key then the value
+		entry, m, err := appendCanonicalValues(nil, src[pos:], 0, 2)
+		if err != nil {
+			return nil, 0, err
+		}
+		entries[i] = entry
+		pos += m
+	}
+	// msgpack encodings are prefix-free, so the entries of distinct keys compare as their keys
+	slices.SortFunc(entries, bytes.Compare)
+	for _, entry := range entries {
+		dst = append(dst, entry...)
+	}
+	return dst, pos, nil
+}
+
+// readLen returns the length following the 16 or 32 bit length code at the start of src, and the header length.
+func readLen(src []byte, is16 bool) (int, int, error) {
+	if is16 {
+		if len(src) < 3 {
+			return 0, 0, errTruncated
+		}
+		return int(binary.BigEndian.Uint16(src[1:])), 3, nil
+	}
+	if len(src) < 5 {
+		return 0, 0, errTruncated
+	}
+	return int(binary.BigEndian.Uint32(src[1:])), 5, nil
+}
+
+// scalarLen returns the length of the encoding of the value at the start of src, which is neither a map, an array
+// nor a tagged interface.
+func scalarLen(src []byte) (int, error) {
+	code := src[0]
+	var n int
+	switch {
+	case msgpcode.IsFixedNum(code), code == msgpcode.Nil, code == msgpcode.False, code == msgpcode.True:
+		n = 1
+	case msgpcode.IsFixedString(code):
+		n = 1 + int(code&msgpcode.FixedStrMask)
+	case code == msgpcode.Uint8, code == msgpcode.Int8:
+		n = 2
+	case code == msgpcode.Uint16, code == msgpcode.Int16:
+		n = 3
+	case code == msgpcode.Uint32, code == msgpcode.Int32, code == msgpcode.Float:
+		n = 5
+	case code == msgpcode.Uint64, code == msgpcode.Int64, code == msgpcode.Double:
+		n = 9
+	case code == msgpcode.FixExt1:
+		n = 3
+	case code == msgpcode.FixExt2:
+		n = 4
+	case code == msgpcode.FixExt4:
+		n = 6
+	case code == msgpcode.FixExt8:
+		n = 10
+	case code == msgpcode.FixExt16:
+		n = 18
+	case code == msgpcode.Str8, code == msgpcode.Bin8, code == msgpcode.Ext8:
+		if len(src) < 2 {
+			return 0, errTruncated
+		}
+		n = 2 + int(src[1])
+	case code == msgpcode.Str16, code == msgpcode.Bin16, code == msgpcode.Ext16:
+		if len(src) < 3 {
+			return 0, errTruncated
+		}
+		n = 3 + int(binary.BigEndian.Uint16(src[1:]))
+	case code == msgpcode.Str32, code == msgpcode.Bin32, code == msgpcode.Ext32:
+		if len(src) < 5 {
+			return 0, errTruncated
+		}
+		n = 5 + int(binary.BigEndian.Uint32(src[1:]))
+	default:
+		return 0, errors.New("unexpected msgpack code")
+	}
+	if code == msgpcode.Ext8 || code == msgpcode.Ext16 || code == msgpcode.Ext32 {
+		n++ // the ext type
+	}
+	if len(src) < n {
+		return 0, errTruncated
+	}
+	return n, nil
+}
@@ -2,14 +2,21 @@ package msgpack
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	"github.com/KyberNetwork/msgpack/v5"
+	"github.com/KyberNetwork/msgpack/v5/msgpcode"
 	"github.com/klauspost/compress/snappy"
 )
 
+// legacyTaggedInterfaceExtID is the ext ID msgpack encodes tagged interfaces with.
+const legacyTaggedInterfaceExtID = 127
+
 var decoderPool = sync.Pool{
 	New: func() any {
 		de := msgpack.NewDecoder(nil)
@@ -30,13 +37,108 @@ func PutDecoder(en *msgpack.Decoder) {
 }
 
 // DecodePoolSimulatorsMap decodes an encoded and Snappy compressed map from pool ID to IPoolSimulator
+// DecodePoolSimulatorsMap decodes a map encoded by EncodePoolSimulatorsMap, migrating pools encoded with an older
+// schema version. It also decodes maps encoded by EncodeIndexedPoolSimulatorsMap, and the legacy encoding, without
+// envelope nor schema versions, whose pools are at version 0.
 func DecodePoolSimulatorsMap(encoded []byte) (map[string]pool.IPoolSimulator, error) {
-	poolsMap := make(map[string]pool.IPoolSimulator)
-	zw := snappy.NewReader(bytes.NewReader(encoded))
-	de := NewDecoder(zw)
+	if bytes.HasPrefix(encoded, indexedMagic) {
+		lazyMap, err := DecodeLazyPoolSimulatorsMap(encoded)
+		if err != nil {
+			return nil, err
+		}
+		return lazyMap.DecodeAll()
+	}
+	if !bytes.HasPrefix(encoded, versionedMagic) {
+		return decodeLegacyPoolSimulatorsMap(encoded)
+	}
+	encoded = encoded[len(versionedMagic):]
+	if len(encoded) == 0 || encoded[0] != envelopeVersion {
+		return nil, ErrUnsupportedEnvelope
+	}
+
+	var encodedPools map[string]encodedPool
+	de := NewDecoder(snappy.NewReader(bytes.NewReader(encoded[1:])))
 	defer PutDecoder(de)
-	if err := de.Decode(&poolsMap); err != nil {
+	if err := de.Decode(&encodedPools); err != nil {
 		return nil, err
 	}
+
+	poolsMap := make(map[string]pool.IPoolSimulator, len(encodedPools))
+	for address, encodedPool := range encodedPools {
+		poolSim, err := decodePool(encodedPool)
+		if err != nil {
+			return nil, fmt.Errorf("decode pool %s: %w", address, err)
+		}
+		poolsMap[address] = poolSim
+	}
+	return poolsMap, nil
+}
+
+// decodeLegacyPoolSimulatorsMap decodes a map of pools encoded as tagged interfaces: a FixExt1 code, the tagged
+// interface ext ID, the "[*]<package path> <type name>" tag then the pool.
+func decodeLegacyPoolSimulatorsMap(encoded []byte) (map[string]pool.IPoolSimulator, error) {
+	de := NewDecoder(snappy.NewReader(bytes.NewReader(encoded)))
+	defer PutDecoder(de)
+
+	n, err := de.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	poolsMap := make(map[string]pool.IPoolSimulator, max(n, 0))
+	for range n {
+		address, err := de.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		if code, err := de.PeekCode(); err != nil {
+			return nil, err
+		} else if code == msgpcode.Nil {
+			if err := de.DecodeNil(); err != nil {
+				return nil, err
+			}
+			poolsMap[address] = nil
+			continue
+		}
+
+		var header [2]byte
+		if err := de.ReadFull(header[:]); err != nil {
+			return nil, err
+		}
+		if header[0] != msgpcode.FixExt1 || header[1] != legacyTaggedInterfaceExtID {
+			return nil, fmt.Errorf("decode pool %s: %w", address, ErrUnregisteredPoolType)
+		}
+		tag, err := de.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		name := strings.Replace(strings.TrimPrefix(tag, "*"), " ", ".", 1)
+		registered, ok := poolTypes[name]
+		if !ok {
+			return nil, fmt.Errorf("decode pool %s: %w: %s", address, ErrUnregisteredPoolType, name)
+		}
+
+		var poolSim pool.IPoolSimulator
+		if len(registered.migrations) == 0 {
+			value := reflect.New(registered.typ)
+			if err := de.DecodeValue(value); err != nil {
+				return nil, fmt.Errorf("decode pool %s: %w", address, err)
+			}
+			poolSim = value.Interface().(pool.IPoolSimulator)
+		} else {
+			var fields []any
+			if err := de.Decode(&fields); err != nil {
+				return nil, fmt.Errorf("decode pool %s: %w", address, err)
+			}
+			data, err := migrate(fields, registered.migrations)
+			if err != nil {
+				return nil, fmt.Errorf("decode pool %s: %w: %s from version 0: %v", address, ErrMigrationFailed, name,
+					err)
+			}
+			if poolSim, err = registered.decode(data); err != nil {
+				return nil, fmt.Errorf("decode pool %s: %w", address, err)
+			}
+		}
+		poolsMap[address] = poolSim
+	}
 	return poolsMap, nil
 }
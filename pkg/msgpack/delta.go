@@ -0,0 +1,179 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+var ErrDeltaBaseMismatch = errors.New("delta base checksum does not match the pools map checksum")
+
+// PoolDigests holds the digest of the encoding of each pool of a pool simulators map, keyed by pool address. It is the
+// base EncodePoolSimulatorsMapDelta computes deltas against.
+type PoolDigests map[string]uint64
+
+// Checksum identifies the pools map the digests were computed from. The checksum of nil digests identifies an empty
+// pools map.
+func (d PoolDigests) Checksum() uint64 {
+	addresses := make([]string, 0, len(d))
+	for address := range d {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	h := xxhash.New()
+	var digest [8]byte
+	for _, address := range addresses {
+		_, _ = h.WriteString(address)
+		binary.BigEndian.PutUint64(digest[:], d[address])
+		_, _ = h.Write(digest[:])
+	}
+	return h.Sum64()
+}
+
+// DigestPoolSimulatorsMap computes the digests of the pools of poolsMap.
+func DigestPoolSimulatorsMap(poolsMap map[string]pool.IPoolSimulator) (PoolDigests, error) {
+	digests := make(PoolDigests, len(poolsMap))
+	for address, poolSim := range poolsMap {
+		encoded, err := encodePool(poolSim)
+		if err != nil {
+			return nil, fmt.Errorf("encode pool %s: %w", address, err)
+		}
+		digests[address] = encoded.digest()
+	}
+	return digests, nil
+}
+
+// PoolSimulatorsMapDelta holds the pools added, updated and removed between two pool simulators maps, identified by
+// their checksums.
+type PoolSimulatorsMapDelta struct {
+	BaseChecksum uint64
+	Checksum     uint64
+	// Updated holds the added and updated pools keyed by address.
+	Updated map[string]pool.IPoolSimulator
+	Removed []string
+}
+
+type encodedDelta struct {
+	BaseChecksum uint64
+	Checksum     uint64
+	Updated      map[string]encodedPool
+	Removed      []string
+}
+
+// EncodePoolSimulatorsMapDelta encodes the pools of poolsMap whose encoding changed since base, along with the
+// addresses of the base pools no longer in poolsMap. It returns the digests of poolsMap, to be used as the base of the
+// next delta. With nil base, the delta holds every pool and applies to an empty map.
+//
+// Every pool is encoded canonically to detect changes, only the changed ones being compressed and shipped.
+func EncodePoolSimulatorsMapDelta(base PoolDigests,
+	poolsMap map[string]pool.IPoolSimulator) ([]byte, PoolDigests, error) {
+	delta := encodedDelta{
+		BaseChecksum: base.Checksum(),
+		Updated:      make(map[string]encodedPool),
+	}
+	digests := make(PoolDigests, len(poolsMap))
+	for address, poolSim := range poolsMap {
+		encoded, err := encodePool(poolSim)
+		if err != nil {
+			return nil, nil, fmt.Errorf("encode pool %s: %w", address, err)
+		}
+		digest := encoded.digest()
+		digests[address] = digest
+		if baseDigest, ok := base[address]; !ok || baseDigest != digest {
+			delta.Updated[address] = encoded
+		}
+	}
+	for address := range base {
+		if _, ok := poolsMap[address]; !ok {
+			delta.Removed = append(delta.Removed, address)
+		}
+	}
+	slices.Sort(delta.Removed)
+	delta.Checksum = digests.Checksum()
+
+	var (
+		buf bytes.Buffer
+		zw  = snappy.NewBufferedWriter(&buf)
+	)
+	en := NewEncoder(zw)
+	defer PutEncoder(en)
+	if err := en.Encode(&delta); err != nil {
+		return nil, nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), digests, nil
+}
+
+// DecodePoolSimulatorsMapDelta decodes a delta encoded by EncodePoolSimulatorsMapDelta.
+func DecodePoolSimulatorsMapDelta(encoded []byte) (*PoolSimulatorsMapDelta, error) {
+	var delta encodedDelta
+	de := NewDecoder(snappy.NewReader(bytes.NewReader(encoded)))
+	defer PutDecoder(de)
+	if err := de.Decode(&delta); err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]pool.IPoolSimulator, len(delta.Updated))
+	for address, encoded := range delta.Updated {
+		poolSim, err := decodePool(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode pool %s: %w", address, err)
+		}
+		updated[address] = poolSim
+	}
+
+	return &PoolSimulatorsMapDelta{
+		BaseChecksum: delta.BaseChecksum,
+		Checksum:     delta.Checksum,
+		Updated:      updated,
+		Removed:      delta.Removed,
+	}, nil
+}
+
+// Apply applies the delta to poolsMap, whose checksum is checksum, and returns the checksum of the updated map.
+// poolsMap is left untouched if checksum is not the delta base checksum, in which case the replica is out of sync and
+// should reload a full snapshot.
+func (d *PoolSimulatorsMapDelta) Apply(poolsMap map[string]pool.IPoolSimulator, checksum uint64) (uint64, error) {
+	if checksum != d.BaseChecksum {
+		return checksum, ErrDeltaBaseMismatch
+	}
+	for _, address := range d.Removed {
+		delete(poolsMap, address)
+	}
+	for address, poolSim := range d.Updated {
+		poolsMap[address] = poolSim
+	}
+	return d.Checksum, nil
+}
+
+// ApplyPoolSimulatorsMapDelta decodes a delta encoded by EncodePoolSimulatorsMapDelta and applies it to poolsMap, see
+// PoolSimulatorsMapDelta.Apply.
+func ApplyPoolSimulatorsMapDelta(poolsMap map[string]pool.IPoolSimulator, checksum uint64,
+	encoded []byte) (uint64, error) {
+	delta, err := DecodePoolSimulatorsMapDelta(encoded)
+	if err != nil {
+		return checksum, err
+	}
+	return delta.Apply(poolsMap, checksum)
+}
+
+// digest hashes the type, schema version and encoding of the pool.
+func (p encodedPool) digest() uint64 {
+	h := xxhash.New()
+	_, _ = h.WriteString(p.Type)
+	var version [8]byte
+	binary.BigEndian.PutUint64(version[:], uint64(p.Version))
+	_, _ = h.Write(version[:])
+	_, _ = h.Write(p.Data)
+	return h.Sum64()
+}
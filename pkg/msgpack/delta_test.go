@@ -0,0 +1,138 @@
+package msgpack
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	maverickv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/maverick-v2"
+	uniswapv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/uniswap-v2"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+func newUniswapV2Pool(t *testing.T, address string, reserve0 int64) pool.IPoolSimulator {
+	t.Helper()
+	poolSim, err := uniswapv2.NewPoolSimulator(entity.Pool{
+		Address:  address,
+		Exchange: "uniswap",
+		Type:     uniswapv2.DexType,
+		Reserves: entity.PoolReserves{fmt.Sprint(reserve0), fmt.Sprint(reserve0 * 31)},
+		Tokens:   []*entity.PoolToken{{Address: "a" + address}, {Address: "b" + address}},
+		Extra:    `{"fee":3,"feePrecision":1000}`,
+	})
+	require.NoError(t, err)
+	return poolSim
+}
+
+func TestPoolSimulatorsMapDelta(t *testing.T) {
+	poolsMap := make(map[string]pool.IPoolSimulator)
+	for i := range 100 {
+		address := fmt.Sprintf("pool%d", i)
+		poolsMap[address] = newUniswapV2Pool(t, address, int64(i+1)*7919)
+	}
+
+	full, digests, err := EncodePoolSimulatorsMapDelta(nil, poolsMap)
+	require.NoError(t, err)
+	replica := make(map[string]pool.IPoolSimulator)
+	checksum, err := ApplyPoolSimulatorsMapDelta(replica, PoolDigests(nil).Checksum(), full)
+	require.NoError(t, err)
+	assert.Equal(t, digests.Checksum(), checksum)
+	assert.Len(t, replica, 100)
+
+	next := make(map[string]pool.IPoolSimulator, len(poolsMap))
+	for address, poolSim := range poolsMap {
+		next[address] = poolSim
+	}
+	next["pool0"] = newUniswapV2Pool(t, "pool0", 2000000)
+	next["pool100"] = newUniswapV2Pool(t, "pool100", 1000000)
+	delete(next, "pool1")
+
+	encoded, nextDigests, err := EncodePoolSimulatorsMapDelta(digests, next)
+	require.NoError(t, err)
+	assert.Less(t, len(encoded)*10, len(full))
+
+	delta, err := DecodePoolSimulatorsMapDelta(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, checksum, delta.BaseChecksum)
+	assert.Len(t, delta.Updated, 2)
+	assert.Equal(t, []string{"pool1"}, delta.Removed)
+
+	checksum, err = delta.Apply(replica, checksum)
+	require.NoError(t, err)
+	assert.Equal(t, nextDigests.Checksum(), checksum)
+	require.Len(t, replica, 100)
+	assert.NotContains(t, replica, "pool1")
+	assert.Equal(t, big.NewInt(2000000), replica["pool0"].GetReserves()[0])
+	replicaDigests, err := DigestPoolSimulatorsMap(replica)
+	require.NoError(t, err)
+	assert.Equal(t, nextDigests, replicaDigests)
+
+	t.Run("base mismatch", func(t *testing.T) {
+		stale := map[string]pool.IPoolSimulator{"pool0": poolsMap["pool0"]}
+		staleChecksum := PoolDigests{"pool0": digests["pool0"]}.Checksum()
+		got, err := ApplyPoolSimulatorsMapDelta(stale, staleChecksum, encoded)
+		assert.ErrorIs(t, err, ErrDeltaBaseMismatch)
+		assert.Equal(t, staleChecksum, got)
+		assert.Equal(t, map[string]pool.IPoolSimulator{"pool0": poolsMap["pool0"]}, stale)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		encoded, digests, err := EncodePoolSimulatorsMapDelta(nextDigests, next)
+		require.NoError(t, err)
+		assert.Equal(t, nextDigests, digests)
+		delta, err := DecodePoolSimulatorsMapDelta(encoded)
+		require.NoError(t, err)
+		assert.Empty(t, delta.Updated)
+		assert.Empty(t, delta.Removed)
+		assert.Equal(t, delta.BaseChecksum, delta.Checksum)
+	})
+}
+
+// newMaverickV2Pool returns a maverick-v2 pool, holding maps of integer keyed ticks and bins.
+func newMaverickV2Pool(t *testing.T) pool.IPoolSimulator {
+	t.Helper()
+	var ticks, bins []string
+	for i := range 64 {
+		ticks = append(ticks, fmt.Sprintf(`"%d":{"rA":"%d","rB":"%d","tS":"1000","bI":[%d,0,0,0]}`, i-32, 1000+i,
+			2000+i, i+1))
+		bins = append(bins, fmt.Sprintf(`"%d":{"t":%d,"k":0,"tB":"1000"}`, i+1, i-32))
+	}
+	poolSim, err := maverickv2.NewPoolSimulator(entity.Pool{
+		Address:     "maverick",
+		Exchange:    "maverick-v2",
+		Type:        maverickv2.DexType,
+		Reserves:    entity.PoolReserves{"100000", "200000"},
+		Tokens:      []*entity.PoolToken{{Address: "a", Decimals: 18}, {Address: "b", Decimals: 18}},
+		StaticExtra: `{"tickSpacing":10,"lookback":3600,"kinds":1}`,
+		Extra: fmt.Sprintf(`{"feeAIn":1000000000000000,"feeBIn":1000000000000000,"ticks":{%s},"bins":{%s}}`,
+			strings.Join(ticks, ","), strings.Join(bins, ",")),
+	})
+	require.NoError(t, err)
+	return poolSim
+}
+
+func TestDigestPoolSimulatorsMap_Canonical(t *testing.T) {
+	for name, newPool := range map[string]func(*testing.T) pool.IPoolSimulator{
+		"maverick-v2": newMaverickV2Pool,
+		"lo1inch":     func(t *testing.T) pool.IPoolSimulator { return newLO1inchPool(t) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			poolSim := newPool(t)
+			digests, err := DigestPoolSimulatorsMap(map[string]pool.IPoolSimulator{name: poolSim})
+			require.NoError(t, err)
+			for range 10 {
+				// the same pool, and equal pools built with maps iterated in other orders
+				for _, other := range []pool.IPoolSimulator{poolSim, newPool(t)} {
+					otherDigests, err := DigestPoolSimulatorsMap(map[string]pool.IPoolSimulator{name: other})
+					require.NoError(t, err)
+					assert.Equal(t, digests, otherDigests)
+				}
+			}
+		})
+	}
+}
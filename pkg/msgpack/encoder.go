@@ -2,11 +2,15 @@ package msgpack
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
 	"github.com/KyberNetwork/msgpack/v5"
+	"github.com/KyberNetwork/msgpack/v5/msgpcode"
 	"github.com/klauspost/compress/snappy"
 )
 
@@ -29,15 +33,52 @@ func PutEncoder(en *msgpack.Encoder) {
 	encoderPool.Put(en)
 }
 
-// EncodePoolSimulatorsMap encode a map from pool ID to IPoolSimulator with Snappy compression
+// versionedMagic prefixes the pool simulators maps encoded with schema versions, followed by envelopeVersion. Legacy
+// encodings are bare snappy streams, starting with the 0xff stream identifier chunk.
+var versionedMagic = []byte{0x00, 'k', 's', 'v'}
+
+const envelopeVersion byte = 1
+
+var writeLegacyFormat atomic.Bool
+
+// SetWriteLegacyFormat sets whether EncodePoolSimulatorsMap writes the legacy format, without envelope nor schema
+// versions, instead of the versioned one. Readers predating the versioned envelope only decode the legacy format, so
+// writers must keep it until every reader is upgraded:
+//  1. upgrade the writers with SetWriteLegacyFormat(true), their output being unchanged;
+//  2. upgrade the readers, which decode both formats;
+//  3. call SetWriteLegacyFormat(false), or drop the call, on the writers.
+//
+// Pools registered with a schema migration are written at version 0 with their legacy migration, see
+// RegisterLegacyMigration. Those without one, and pools wrapped with pool.NewTransferTaxPoolSimulator, fail to encode
+// with ErrLegacySchema while the legacy format is written.
+func SetWriteLegacyFormat(legacy bool) {
+	writeLegacyFormat.Store(legacy)
+}
+
+// EncodePoolSimulatorsMap encode a map from pool ID to IPoolSimulator with Snappy compression. Each pool is encoded
+// with its type and schema version, see RegisterSchema, so that a later version of the library can still decode it.
+// The legacy format is written instead if set with SetWriteLegacyFormat.
 func EncodePoolSimulatorsMap(poolsMap map[string]pool.IPoolSimulator) ([]byte, error) {
-	var (
-		buf bytes.Buffer
-		zw  = snappy.NewBufferedWriter(&buf)
-	)
+	if writeLegacyFormat.Load() {
+		return encodeLegacyPoolSimulatorsMap(poolsMap)
+	}
+
+	encodedPools := make(map[string]encodedPool, len(poolsMap))
+	for address, poolSim := range poolsMap {
+		encoded, err := encodePool(poolSim)
+		if err != nil {
+			return nil, fmt.Errorf("encode pool %s: %w", address, err)
+		}
+		encodedPools[address] = encoded
+	}
+
+	var buf bytes.Buffer
+	buf.Write(versionedMagic)
+	buf.WriteByte(envelopeVersion)
+	zw := snappy.NewBufferedWriter(&buf)
 	en := NewEncoder(zw)
 	defer PutEncoder(en)
-	if err := en.Encode(poolsMap); err != nil {
+	if err := en.Encode(encodedPools); err != nil {
 		return nil, err
 	}
 	if err := zw.Close(); err != nil {
@@ -45,3 +86,80 @@ func EncodePoolSimulatorsMap(poolsMap map[string]pool.IPoolSimulator) ([]byte, e
 	}
 	return buf.Bytes(), nil
 }
+
+// encodeLegacyPoolSimulatorsMap encodes poolsMap as a map of tagged interfaces, as done before schema versioning. The
+// pools registered with a schema migration are converted to version 0 with their legacy migration.
+func encodeLegacyPoolSimulatorsMap(poolsMap map[string]pool.IPoolSimulator) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := snappy.NewBufferedWriter(&buf)
+	en := NewEncoder(zw)
+	defer PutEncoder(en)
+	if err := en.EncodeMapLen(len(poolsMap)); err != nil {
+		return nil, err
+	}
+	for address, poolSim := range poolsMap {
+		if err := en.EncodeString(address); err != nil {
+			return nil, err
+		}
+		if err := encodeLegacyPool(en, poolSim); err != nil {
+			return nil, fmt.Errorf("encode pool %s: %w", address, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeLegacyPool encodes poolSim as a tagged interface with en, converting its fields to version 0 if its type is
+// registered with a schema migration.
+func encodeLegacyPool(en *msgpack.Encoder, poolSim pool.IPoolSimulator) error {
+	if poolSim == nil {
+		return en.EncodeNil()
+	} else if _, ok := poolSim.(transferTaxWrapper); ok {
+		return fmt.Errorf("%w: %s", ErrLegacySchema, transferTaxPoolType)
+	}
+	typ := reflect.TypeOf(poolSim)
+	if typ.Kind() != reflect.Pointer {
+		return ErrInvalidPoolType
+	}
+	name := poolTypeName(typ.Elem())
+	registered, ok := poolTypes[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnregisteredPoolType, name)
+	} else if len(registered.migrations) == 0 {
+		return en.EncodeValue(reflect.ValueOf(&poolSim).Elem())
+	} else if registered.legacy == nil {
+		return fmt.Errorf("%w: %s", ErrLegacySchema, name)
+	}
+
+	fields, err := legacyFields(poolSim, registered.legacy)
+	if err != nil {
+		return fmt.Errorf("%w: %s to version 0: %v", ErrMigrationFailed, name, err)
+	}
+	if _, err := en.Writer().Write([]byte{msgpcode.FixExt1, legacyTaggedInterfaceExtID}); err != nil {
+		return err
+	}
+	if err := en.EncodeString(fmt.Sprintf("*%s %s", typ.Elem().PkgPath(), typ.Elem().Name())); err != nil {
+		return err
+	}
+	return en.Encode(fields)
+}
+
+// legacyFields returns the generic decoding of poolSim converted to version 0 by legacy.
+func legacyFields(poolSim pool.IPoolSimulator, legacy Migration) ([]any, error) {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	err := en.Encode(poolSim)
+	PutEncoder(en)
+	if err != nil {
+		return nil, err
+	}
+	var fields []any
+	de := NewDecoder(&buf)
+	defer PutDecoder(de)
+	if err := de.Decode(&fields); err != nil {
+		return nil, err
+	}
+	return legacy(fields)
+}
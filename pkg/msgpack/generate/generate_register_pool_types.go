@@ -60,7 +60,7 @@ func main() {
 		if name, ok := irregularPoolSimNameByPackageName[pkgName]; ok {
 			poolSimName = name
 		}
-		emitf(outFileBuf, "\tmsgpack.RegisterConcreteType(&%s.%s{})\n", pkgName, poolSimName)
+		emitf(outFileBuf, "\tmustNotError(RegisterPoolType(&%s.%s{}))\n", pkgName, poolSimName)
 	}
 	emitf(outFileBuf, "}\n")
 }
@@ -74,8 +74,6 @@ func emitImports(outFileBuf io.Writer, pkgNames, importPaths []string) {
 	emitf(outFileBuf, "\n")
 
 	emitf(outFileBuf, "import (\n")
-	emitf(outFileBuf, "\t\"github.com/KyberNetwork/msgpack/v5\"\n")
-	emitf(outFileBuf, "\n")
 	for i, dexName := range pkgNames {
 		emitf(outFileBuf, "\t%s \"%s\"\n", dexName, importPaths[i])
 	}
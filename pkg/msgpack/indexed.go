@@ -0,0 +1,268 @@
+package msgpack
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+var (
+	ErrPoolNotFound  = errors.New("pool not found")
+	ErrCorruptedData = errors.New("corrupted pool simulators map")
+)
+
+// DefaultMaxDecodedPools is the number of decoded pools a LazyPoolSimulatorsMap keeps by default, see SetMaxDecoded.
+const DefaultMaxDecodedPools = 10000
+
+// indexedMagic prefixes the pool simulators maps encoded by EncodeIndexedPoolSimulatorsMap, followed by
+// envelopeVersion.
+var indexedMagic = []byte{0x00, 'k', 's', 'i'}
+
+// indexEntry locates the snappy compressed encoding of a pool in the data section of an indexed map.
+type indexEntry struct {
+	Address string
+	Type    string
+	Version int
+	Offset  uint64
+	Length  uint64
+}
+
+// EncodeIndexedPoolSimulatorsMap encodes a map from pool ID to IPoolSimulator so that each pool can be decoded on its
+// own, see DecodeLazyPoolSimulatorsMap. The encoding is the magic and envelope version, the uvarint length of the
+// snappy compressed index of the pools, the index, then the snappy compressed pools one after the other.
+//
+// Pools are compressed individually, so the encoding is larger than the one of EncodePoolSimulatorsMap.
+func EncodeIndexedPoolSimulatorsMap(poolsMap map[string]pool.IPoolSimulator) ([]byte, error) {
+	addresses := make([]string, 0, len(poolsMap))
+	for address := range poolsMap {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+
+	index := make([]indexEntry, 0, len(addresses))
+	var data []byte
+	for _, address := range addresses {
+		encoded, err := encodePool(poolsMap[address])
+		if err != nil {
+			return nil, fmt.Errorf("encode pool %s: %w", address, err)
+		}
+		offset := len(data)
+		data = append(data, snappy.Encode(nil, encoded.Data)...)
+		index = append(index, indexEntry{
+			Address: address,
+			Type:    encoded.Type,
+			Version: encoded.Version,
+			Offset:  uint64(offset),
+			Length:  uint64(len(data) - offset),
+		})
+	}
+
+	var indexBuf bytes.Buffer
+	en := NewEncoder(&indexBuf)
+	defer PutEncoder(en)
+	if err := en.Encode(index); err != nil {
+		return nil, err
+	}
+	compressedIndex := snappy.Encode(nil, indexBuf.Bytes())
+
+	out := make([]byte, 0, len(indexedMagic)+1+binary.MaxVarintLen64+len(compressedIndex)+len(data))
+	out = append(out, indexedMagic...)
+	out = append(out, envelopeVersion)
+	out = binary.AppendUvarint(out, uint64(len(compressedIndex)))
+	out = append(out, compressedIndex...)
+	return append(out, data...), nil
+}
+
+// LazyPoolSimulatorsMap is a pool simulators map decoded from EncodeIndexedPoolSimulatorsMap, whose pools are only
+// decoded when first accessed. Like the values of a map, the returned pools are shared by all callers: they must be
+// cloned before being updated. It is safe for concurrent use.
+//
+// Decoded pools are kept for the next accesses, up to SetMaxDecoded pools: the least recently used ones are evicted
+// beyond, and decoded again when accessed. Callers can also evict the pools they are done with with Drop.
+type LazyPoolSimulatorsMap struct {
+	index map[string]indexEntry
+	data  []byte
+
+	mu         sync.Mutex
+	maxDecoded int
+	// decoded holds the elements of recent, whose values are the decodedPool, keyed by address. The most recently
+	// used pools are at the front of recent.
+	decoded map[string]*list.Element
+	recent  *list.List
+}
+
+type decodedPool struct {
+	address string
+	poolSim pool.IPoolSimulator
+}
+
+// DecodeLazyPoolSimulatorsMap decodes the index of a map encoded by EncodeIndexedPoolSimulatorsMap. encoded is
+// retained and must not be modified.
+func DecodeLazyPoolSimulatorsMap(encoded []byte) (*LazyPoolSimulatorsMap, error) {
+	if !bytes.HasPrefix(encoded, indexedMagic) {
+		return nil, fmt.Errorf("%w: not an indexed map", ErrCorruptedData)
+	}
+	encoded = encoded[len(indexedMagic):]
+	if len(encoded) == 0 || encoded[0] != envelopeVersion {
+		return nil, ErrUnsupportedEnvelope
+	}
+	encoded = encoded[1:]
+
+	indexLen, n := binary.Uvarint(encoded)
+	if n <= 0 || indexLen > uint64(len(encoded)-n) {
+		return nil, fmt.Errorf("%w: invalid index length", ErrCorruptedData)
+	}
+	rawIndex, err := snappy.Decode(nil, encoded[n:n+int(indexLen)])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedData, err)
+	}
+	var entries []indexEntry
+	de := NewDecoder(bytes.NewReader(rawIndex))
+	defer PutDecoder(de)
+	if err := de.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorruptedData, err)
+	}
+
+	data := encoded[n+int(indexLen):]
+	index := make(map[string]indexEntry, len(entries))
+	for _, entry := range entries {
+		if entry.Offset > uint64(len(data)) || entry.Length > uint64(len(data))-entry.Offset {
+			return nil, fmt.Errorf("%w: pool %s out of bounds", ErrCorruptedData, entry.Address)
+		}
+		index[entry.Address] = entry
+	}
+
+	return &LazyPoolSimulatorsMap{
+		index:      index,
+		data:       data,
+		maxDecoded: DefaultMaxDecodedPools,
+		decoded:    make(map[string]*list.Element),
+		recent:     list.New(),
+	}, nil
+}
+
+// SetMaxDecoded sets the number of decoded pools kept, evicting the least recently used ones beyond it. A value of 0
+// or less keeps every decoded pool.
+func (m *LazyPoolSimulatorsMap) SetMaxDecoded(maxDecoded int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxDecoded = maxDecoded
+	m.evict()
+}
+
+// Drop evicts the decoded pools at addresses, which are decoded again when next accessed.
+func (m *LazyPoolSimulatorsMap) Drop(addresses ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, address := range addresses {
+		if element, ok := m.decoded[address]; ok {
+			m.recent.Remove(element)
+			delete(m.decoded, address)
+		}
+	}
+}
+
+// evict drops the least recently used pools beyond maxDecoded. It must be called with mu held.
+func (m *LazyPoolSimulatorsMap) evict() {
+	for m.maxDecoded > 0 && m.recent.Len() > m.maxDecoded {
+		oldest := m.recent.Back()
+		m.recent.Remove(oldest)
+		delete(m.decoded, oldest.Value.(*decodedPool).address)
+	}
+}
+
+// Len returns the number of pools in the map.
+func (m *LazyPoolSimulatorsMap) Len() int {
+	return len(m.index)
+}
+
+// Has reports whether the map holds a pool at address, without decoding it.
+func (m *LazyPoolSimulatorsMap) Has(address string) bool {
+	_, ok := m.index[address]
+	return ok
+}
+
+// Addresses returns the sorted addresses of the pools of the map.
+func (m *LazyPoolSimulatorsMap) Addresses() []string {
+	addresses := make([]string, 0, len(m.index))
+	for address := range m.index {
+		addresses = append(addresses, address)
+	}
+	slices.Sort(addresses)
+	return addresses
+}
+
+// Get returns the pool at address, decoding it on first access. It returns ErrPoolNotFound if there is none.
+func (m *LazyPoolSimulatorsMap) Get(address string) (pool.IPoolSimulator, error) {
+	m.mu.Lock()
+	element, ok := m.decoded[address]
+	if ok {
+		m.recent.MoveToFront(element)
+	}
+	m.mu.Unlock()
+	if ok {
+		return element.Value.(*decodedPool).poolSim, nil
+	}
+
+	entry, ok := m.index[address]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPoolNotFound, address)
+	}
+	data, err := snappy.Decode(nil, m.data[entry.Offset:entry.Offset+entry.Length])
+	if err != nil {
+		return nil, fmt.Errorf("decode pool %s: %w: %v", address, ErrCorruptedData, err)
+	}
+	poolSim, err := decodePool(encodedPool{Type: entry.Type, Version: entry.Version, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("decode pool %s: %w", address, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	// another goroutine may have decoded the pool meanwhile, keep its instance so that all callers share one
+	if element, ok := m.decoded[address]; ok {
+		m.recent.MoveToFront(element)
+		return element.Value.(*decodedPool).poolSim, nil
+	}
+	m.decoded[address] = m.recent.PushFront(&decodedPool{address: address, poolSim: poolSim})
+	m.evict()
+	return poolSim, nil
+}
+
+// GetMany returns the pools at addresses keyed by address, as expected by pool.CalcPathAmountOut for instance. Unknown
+// addresses are skipped.
+func (m *LazyPoolSimulatorsMap) GetMany(addresses ...string) (map[string]pool.IPoolSimulator, error) {
+	poolsMap := make(map[string]pool.IPoolSimulator, len(addresses))
+	for _, address := range addresses {
+		if !m.Has(address) {
+			continue
+		}
+		poolSim, err := m.Get(address)
+		if err != nil {
+			return nil, err
+		}
+		poolsMap[address] = poolSim
+	}
+	return poolsMap, nil
+}
+
+// DecodeAll decodes every pool and returns them as a map, like DecodePoolSimulatorsMap.
+func (m *LazyPoolSimulatorsMap) DecodeAll() (map[string]pool.IPoolSimulator, error) {
+	poolsMap := make(map[string]pool.IPoolSimulator, len(m.index))
+	for address := range m.index {
+		poolSim, err := m.Get(address)
+		if err != nil {
+			return nil, err
+		}
+		poolsMap[address] = poolSim
+	}
+	return poolsMap, nil
+}
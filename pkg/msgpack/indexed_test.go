@@ -0,0 +1,113 @@
+package msgpack
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+func TestLazyPoolSimulatorsMap(t *testing.T) {
+	poolsMap := loadCompatPools(t)
+	for i := range 50 {
+		address := fmt.Sprintf("pool%d", i)
+		poolsMap[address] = newUniswapV2Pool(t, address, int64(i+1)*7919)
+	}
+
+	encoded, err := EncodeIndexedPoolSimulatorsMap(poolsMap)
+	require.NoError(t, err)
+	lazyMap, err := DecodeLazyPoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+
+	assert.Equal(t, len(poolsMap), lazyMap.Len())
+	assert.True(t, lazyMap.Has("pool7"))
+	assert.False(t, lazyMap.Has("unknown"))
+	assert.Len(t, lazyMap.Addresses(), len(poolsMap))
+	assert.Empty(t, lazyMap.decoded)
+
+	poolSim, err := lazyMap.Get("pool7")
+	require.NoError(t, err)
+	assert.Equal(t, poolsMap["pool7"].GetReserves(), poolSim.GetReserves())
+	assert.Len(t, lazyMap.decoded, 1)
+	again, err := lazyMap.Get("pool7")
+	require.NoError(t, err)
+	assert.Same(t, poolSim, again)
+
+	_, err = lazyMap.Get("unknown")
+	assert.ErrorIs(t, err, ErrPoolNotFound)
+
+	subset, err := lazyMap.GetMany("pool1", "pool2", "unknown")
+	require.NoError(t, err)
+	assert.Len(t, subset, 2)
+	assert.Len(t, lazyMap.decoded, 3)
+
+	all, err := DecodePoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	require.Len(t, all, len(poolsMap))
+	for address, expected := range poolsMap {
+		assert.Equal(t, expected.GetReserves(), all[address].GetReserves(), address)
+	}
+}
+
+func TestLazyPoolSimulatorsMap_Evict(t *testing.T) {
+	poolsMap := make(map[string]pool.IPoolSimulator)
+	for i := range 5 {
+		address := fmt.Sprintf("pool%d", i)
+		poolsMap[address] = newUniswapV2Pool(t, address, int64(i+1)*7919)
+	}
+	encoded, err := EncodeIndexedPoolSimulatorsMap(poolsMap)
+	require.NoError(t, err)
+	lazyMap, err := DecodeLazyPoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	lazyMap.SetMaxDecoded(2)
+
+	pool0, err := lazyMap.Get("pool0")
+	require.NoError(t, err)
+	_, err = lazyMap.GetMany("pool1", "pool0", "pool2")
+	require.NoError(t, err)
+	assert.Len(t, lazyMap.decoded, 2)
+	assert.Contains(t, lazyMap.decoded, "pool0", "pool0 was used more recently than pool1")
+	assert.Contains(t, lazyMap.decoded, "pool2")
+	again, err := lazyMap.Get("pool0")
+	require.NoError(t, err)
+	assert.Same(t, pool0, again)
+
+	lazyMap.Drop("pool0", "unknown")
+	assert.Len(t, lazyMap.decoded, 1)
+	again, err = lazyMap.Get("pool0")
+	require.NoError(t, err)
+	assert.NotSame(t, pool0, again, "dropped pools are decoded again")
+	assert.Equal(t, pool0.GetReserves(), again.GetReserves())
+
+	all, err := lazyMap.DecodeAll()
+	require.NoError(t, err)
+	assert.Len(t, all, len(poolsMap))
+	assert.Len(t, lazyMap.decoded, 2)
+	assert.Equal(t, lazyMap.recent.Len(), len(lazyMap.decoded))
+
+	lazyMap.SetMaxDecoded(0)
+	_, err = lazyMap.DecodeAll()
+	require.NoError(t, err)
+	assert.Len(t, lazyMap.decoded, len(poolsMap))
+}
+
+func TestDecodeLazyPoolSimulatorsMap_Corrupted(t *testing.T) {
+	encoded, err := EncodeIndexedPoolSimulatorsMap(map[string]pool.IPoolSimulator{
+		"pool0": newUniswapV2Pool(t, "pool0", 1000),
+		"pool1": newUniswapV2Pool(t, "pool1", 2000),
+	})
+	require.NoError(t, err)
+
+	_, err = DecodeLazyPoolSimulatorsMap(encoded[:len(encoded)-1])
+	assert.ErrorIs(t, err, ErrCorruptedData)
+	_, err = DecodeLazyPoolSimulatorsMap(encoded[:len(indexedMagic)+3])
+	assert.ErrorIs(t, err, ErrCorruptedData)
+
+	unversioned, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{})
+	require.NoError(t, err)
+	_, err = DecodeLazyPoolSimulatorsMap(unversioned)
+	assert.ErrorIs(t, err, ErrCorruptedData)
+}
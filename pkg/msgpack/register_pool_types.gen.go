@@ -4,10 +4,9 @@ package msgpack
 //go:generate go run ./generate
 
 import (
-	"github.com/KyberNetwork/msgpack/v5"
-
 	pkg_liquiditysource_algebra_integral "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/algebra/integral"
 	pkg_liquiditysource_algebra_v1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/algebra/v1"
+	pkg_liquiditysource_ambient "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/ambient"
 	pkg_liquiditysource_balancerv1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/balancer-v1"
 	pkg_liquiditysource_balancerv2_composablestable "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/balancer-v2/composable-stable"
 	pkg_liquiditysource_balancerv2_stable "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/balancer-v2/stable"
@@ -57,6 +56,7 @@ import (
 	pkg_liquiditysource_maker_savingsdai "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/maker/savingsdai"
 	pkg_liquiditysource_maker_skypsm "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/maker/sky-psm"
 	pkg_liquiditysource_mantle_meth "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mantle/meth"
+	pkg_liquiditysource_maverickv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/maverick-v2"
 	pkg_liquiditysource_mkrsky "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mkr-sky"
 	pkg_liquiditysource_mxtrading "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mx-trading"
 	pkg_liquiditysource_nativev1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/native-v1"
@@ -66,6 +66,7 @@ import (
 	pkg_liquiditysource_pandafun "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/pandafun"
 	pkg_liquiditysource_primeeth "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/primeeth"
 	pkg_liquiditysource_puffer_pufeth "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/puffer/pufeth"
+	pkg_liquiditysource_quoter "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/quoter"
 	pkg_liquiditysource_renzo_ezeth "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/renzo/ezeth"
 	pkg_liquiditysource_ringswap "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/ringswap"
 	pkg_liquiditysource_rocketpool_reth "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/rocketpool/reth"
@@ -141,136 +142,139 @@ import (
 )
 
 func init() {
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_algebra_integral.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_algebra_v1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv2_composablestable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv2_stable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv2_weighted.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv3_stable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_balancerv3_weighted.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_bancorv21.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_bancorv3.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_bebop.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_bedrock_unieth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_beetsss.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_clipper.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_llamma.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_plain.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_stablemetang.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_stableng.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_tricryptong.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_curve_twocryptong.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_daiusds.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_deltaswapv1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_dexalot.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_dodo_classical.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_dodo_dpp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_dodo_dsp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_dodo_dvm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_ethena_susde.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_ethervista.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_etherfi_ebtc.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_etherfi_eeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_etherfi_vampire.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_etherfi_weeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_fluid_dext1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_fluid_vaultt1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_frax_sfrxeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_frax_sfrxethconvertor.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_genericsimplerate.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_gyroscope_2clp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_gyroscope_3clp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_gyroscope_eclp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_hashflowv3.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_honey.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_integral.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_kelp_rseth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_kyberpmm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_litepsm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_lo1inch.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_maker_savingsdai.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_maker_skypsm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_mantle_meth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_mkrsky.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_mxtrading.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_nativev1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_nomiswap_nomiswapstable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_ondousdy.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_overnightusdp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_pandafun.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_primeeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_puffer_pufeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_renzo_ezeth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_ringswap.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_rocketpool_reth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_solidlyv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_staderethx.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_swaapv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_swell_rsweth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_swell_sweth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_syncswapv2_aqua.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_syncswapv2_classic.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_syncswapv2_stable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv4.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_usd0pp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_velocorev2_cpmm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_velocorev2_wombatstable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_velodromev1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_velodromev2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_virtualfun.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_woofiv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_liquiditysource_woofiv21.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_camelot.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_aave.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_base.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_compound.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_meta.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_plainoracle.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_tricrypto.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_curve_two.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_dmm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_elastic.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_equalizer.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_fraxswap.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_fulcrom.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_fxdx.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_gmx.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_gmxglp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_iziswap.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_kokonutcrypto.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_lido.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_lidosteth.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_limitorder.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_liquiditybookv20.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_liquiditybookv21.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_madmex.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_makerpsm.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_mantisswap.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_maverickv1.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_metavault.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_nuriv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_pancakev3.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_platypus.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_polmatic.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_quickperps.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_ramsesv2.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_saddle.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_slipstream.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_smardex.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_solidlyv3.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_swapbasedperp.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_syncswap_syncswapclassic.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_syncswap_syncswapstable.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_synthetix.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_uniswap.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_uniswapv3.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_usdfi.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_velocimeter.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_vooi.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_wombat_wombatlsd.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_wombat_wombatmain.PoolSimulator{})
-	msgpack.RegisterConcreteType(&pkg_source_zkerafinance.PoolSimulator{})
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_algebra_integral.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_algebra_v1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_ambient.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv2_composablestable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv2_stable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv2_weighted.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv3_stable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_balancerv3_weighted.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_bancorv21.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_bancorv3.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_bebop.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_bedrock_unieth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_beetsss.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_clipper.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_llamma.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_plain.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_stablemetang.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_stableng.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_tricryptong.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_curve_twocryptong.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_daiusds.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_deltaswapv1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_dexalot.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_dodo_classical.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_dodo_dpp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_dodo_dsp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_dodo_dvm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_ethena_susde.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_ethervista.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_etherfi_ebtc.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_etherfi_eeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_etherfi_vampire.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_etherfi_weeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_fluid_dext1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_fluid_vaultt1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_frax_sfrxeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_frax_sfrxethconvertor.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_genericsimplerate.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_gyroscope_2clp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_gyroscope_3clp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_gyroscope_eclp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_hashflowv3.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_honey.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_integral.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_kelp_rseth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_kyberpmm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_litepsm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_lo1inch.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_maker_savingsdai.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_maker_skypsm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_mantle_meth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_maverickv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_mkrsky.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_mxtrading.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_nativev1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_nomiswap_nomiswapstable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_ondousdy.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_overnightusdp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_pandafun.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_primeeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_puffer_pufeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_quoter.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_renzo_ezeth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_ringswap.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_rocketpool_reth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_solidlyv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_staderethx.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_swaapv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_swell_rsweth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_swell_sweth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_syncswapv2_aqua.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_syncswapv2_classic.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_syncswapv2_stable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_uniswapv1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_uniswapv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_uniswapv4.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_usd0pp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_velocorev2_cpmm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_velocorev2_wombatstable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_velodromev1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_velodromev2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_virtualfun.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_woofiv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_liquiditysource_woofiv21.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_camelot.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_aave.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_base.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_compound.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_meta.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_plainoracle.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_tricrypto.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_curve_two.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_dmm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_elastic.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_equalizer.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_fraxswap.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_fulcrom.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_fxdx.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_gmx.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_gmxglp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_iziswap.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_kokonutcrypto.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_lido.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_lidosteth.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_limitorder.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_liquiditybookv20.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_liquiditybookv21.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_madmex.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_makerpsm.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_mantisswap.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_maverickv1.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_metavault.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_nuriv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_pancakev3.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_platypus.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_polmatic.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_quickperps.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_ramsesv2.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_saddle.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_slipstream.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_smardex.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_solidlyv3.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_swapbasedperp.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_syncswap_syncswapclassic.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_syncswap_syncswapstable.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_synthetix.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_uniswap.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_uniswapv3.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_usdfi.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_velocimeter.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_vooi.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_wombat_wombatlsd.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_wombat_wombatmain.PoolSimulator{}))
+	mustNotError(RegisterPoolType(&pkg_source_zkerafinance.PoolSimulator{}))
 }
@@ -0,0 +1,479 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	pkg_liquiditysource_bebop "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/bebop"
+	pkg_liquiditysource_dexalot "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/dexalot"
+	pkg_liquiditysource_kyberpmm "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/kyber-pmm"
+	pkg_liquiditysource_lo1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch"
+	pkg_liquiditysource_mxtrading "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/mx-trading"
+	pkg_liquiditysource_nativev1 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/native-v1"
+	pkg_liquiditysource_swaapv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/swaap-v2"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
+)
+
+func init() {
+	mustNotError(RegisterSchema(&pkg_liquiditysource_bebop.PoolSimulator{},
+		migratePriceLevels(bebopV0{}, "Token0", "Token1", "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(l pkg_liquiditysource_bebop.PriceLevel) (pricelevel.Level, error) {
+				return newLevelV0(l.Price, l.Quote)
+			})))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_bebop.PoolSimulator{},
+		legacyPriceLevels(bebopV0{}, "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(price, size float64) pkg_liquiditysource_bebop.PriceLevel {
+				return pkg_liquiditysource_bebop.PriceLevel{Price: price, Quote: size}
+			})))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_dexalot.PoolSimulator{},
+		migratePriceLevels(dexalotV0{}, "Token0", "Token1", "ZeroToOnePriceLevels", "OneToZeroPriceLevels", true,
+			newDexalotLevelV0)))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_dexalot.PoolSimulator{},
+		legacyPriceLevels(dexalotV0{}, "ZeroToOnePriceLevels", "OneToZeroPriceLevels", true, newDexalotPriceLevelV0)))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_kyberpmm.PoolSimulator{}, migrateKyberPMMPriceLevels))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_kyberpmm.PoolSimulator{}, legacyKyberPMMPriceLevels))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_lo1inch.PoolSimulator{}, migrateLO1inchMakerEpochs,
+		migrateLO1inchBitInvalidators, migrateLO1inchAmountGetters))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_lo1inch.PoolSimulator{}, legacyLO1inch))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_mxtrading.PoolSimulator{},
+		migratePriceLevels(mxTradingV0{}, "token0", "token1", "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(l pkg_liquiditysource_mxtrading.PriceLevel) (pricelevel.Level, error) {
+				return newLevelV0(l.Price, l.Size)
+			})))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_mxtrading.PoolSimulator{},
+		legacyPriceLevels(mxTradingV0{}, "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(price, size float64) pkg_liquiditysource_mxtrading.PriceLevel {
+				return pkg_liquiditysource_mxtrading.PriceLevel{Price: price, Size: size}
+			})))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_nativev1.PoolSimulator{},
+		migratePriceLevels(nativeV1V0{}, "Token0", "Token1", "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(l pkg_liquiditysource_nativev1.PriceLevel) (pricelevel.Level, error) {
+				return newLevelV0(l.Price, l.Quote)
+			})))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_nativev1.PoolSimulator{},
+		legacyPriceLevels(nativeV1V0{}, "ZeroToOnePriceLevels", "OneToZeroPriceLevels", false,
+			func(price, size float64) pkg_liquiditysource_nativev1.PriceLevel {
+				return pkg_liquiditysource_nativev1.PriceLevel{Price: price, Quote: size}
+			})))
+	mustNotError(RegisterSchema(&pkg_liquiditysource_swaapv2.PoolSimulator{}, migrateSwaapV2PriceLevels))
+	mustNotError(RegisterLegacyMigration(&pkg_liquiditysource_swaapv2.PoolSimulator{}, legacySwaapV2PriceLevels))
+}
+
+// The layouts of the pool simulators at schema version 0, before their price levels were migrated to pricelevel.Book.
+// Migrations address the fields by name in them, see fieldIndexes, so they must not change.
+type (
+	bebopV0 struct {
+		pool.Pool
+		Token0               entity.PoolToken
+		Token1               entity.PoolToken
+		ZeroToOnePriceLevels []pkg_liquiditysource_bebop.PriceLevel
+		OneToZeroPriceLevels []pkg_liquiditysource_bebop.PriceLevel
+		gas                  pkg_liquiditysource_bebop.Gas
+	}
+
+	dexalotV0 struct {
+		pool.Pool
+		Token0               entity.PoolToken
+		Token1               entity.PoolToken
+		ZeroToOnePriceLevels []dexalotPriceLevelV0
+		OneToZeroPriceLevels []dexalotPriceLevelV0
+		gas                  pkg_liquiditysource_dexalot.Gas
+		Token0Original       string
+		Token1Original       string
+	}
+
+	// dexalotPriceLevelV0 is the version 0 layout of the price levels of dexalot.
+	dexalotPriceLevelV0 struct {
+		Quote *big.Float
+		Price *big.Float
+	}
+
+	kyberPMMV0 struct {
+		pool.Pool
+		baseToken   entity.PoolToken
+		quoteTokens []entity.PoolToken
+		priceLevels []pkg_liquiditysource_kyberpmm.BaseQuotePriceLevels
+		gas         pkg_liquiditysource_kyberpmm.Gas
+		timestamp   int64
+	}
+
+	mxTradingV0 struct {
+		pool.Pool
+		ZeroToOnePriceLevels []pkg_liquiditysource_mxtrading.PriceLevel
+		OneToZeroPriceLevels []pkg_liquiditysource_mxtrading.PriceLevel
+		token0, token1       entity.PoolToken
+		timestamp            int64
+		gas                  pkg_liquiditysource_mxtrading.Gas
+	}
+
+	nativeV1V0 struct {
+		pool.Pool
+		MarketMaker          string
+		Token0               entity.PoolToken
+		Token1               entity.PoolToken
+		ZeroToOnePriceLevels []pkg_liquiditysource_nativev1.PriceLevel
+		OneToZeroPriceLevels []pkg_liquiditysource_nativev1.PriceLevel
+		MinIn0, MinIn1       float64
+		timestamp            int64
+		priceTolerance       uint
+		expirySecs           uint
+		gas                  pkg_liquiditysource_nativev1.Gas
+	}
+
+	swaapV2V0 struct {
+		pool.Pool
+		isBaseSwapped          bool
+		isQuoteSwapped         bool
+		baseToken              entity.PoolToken
+		quoteToken             entity.PoolToken
+		baseToQuotePriceLevels []pkg_liquiditysource_swaapv2.PriceLevel
+		quoteToBasePriceLevels []pkg_liquiditysource_swaapv2.PriceLevel
+		timestamp              int64
+		priceTolerance         float64
+		gas                    pkg_liquiditysource_swaapv2.Gas
+	}
+)
+
+// migratePriceLevels migrates the version 0 float price levels of a pool between two tokens to pricelevel.Book. The
+// fields are named in layout, the layout of the pool at version 0: token0 and token1 are the tokens, levels0 and
+// levels1 the levels of type []L from the first to the second token and from the second to the first. cumulative
+// levels are sized by the total up to them.
+func migratePriceLevels[L any](layout any, token0, token1, levels0, levels1 string, cumulative bool,
+	toLevel func(L) (pricelevel.Level, error)) Migration {
+	return func(fields []any) ([]any, error) {
+		idx, err := fieldIndexes(layout, fields, token0, token1, levels0, levels1)
+		if err != nil {
+			return nil, err
+		}
+		var tokenIn, tokenOut entity.PoolToken
+		if err := convertField(fields[idx[0]], &tokenIn); err != nil {
+			return nil, err
+		}
+		if err := convertField(fields[idx[1]], &tokenOut); err != nil {
+			return nil, err
+		}
+
+		zeroToOne, err := migrateBook(fields[idx[2]], toLevel, cumulative, tokenIn, tokenOut)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", levels0, err)
+		}
+		oneToZero, err := migrateBook(fields[idx[3]], toLevel, cumulative, tokenOut, tokenIn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", levels1, err)
+		}
+		fields[idx[2]], fields[idx[3]] = zeroToOne, oneToZero
+		return fields, nil
+	}
+}
+
+// migrateSwaapV2PriceLevels also migrates the price tolerance of swaap-v2 from float64 to int64, failing if it is not
+// an integer.
+func migrateSwaapV2PriceLevels(fields []any) ([]any, error) {
+	fields, err := migratePriceLevels(swaapV2V0{}, "baseToken", "quoteToken", "baseToQuotePriceLevels",
+		"quoteToBasePriceLevels", true, func(l pkg_liquiditysource_swaapv2.PriceLevel) (pricelevel.Level, error) {
+			return newLevelV0(l.Price, l.Level)
+		})(fields)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := fieldIndexes(swaapV2V0{}, fields, "priceTolerance")
+	if err != nil {
+		return nil, err
+	}
+	var priceTolerance float64
+	if err := convertField(fields[idx[0]], &priceTolerance); err != nil {
+		return nil, err
+	}
+	if priceTolerance != math.Trunc(priceTolerance) || math.Abs(priceTolerance) > math.MaxInt64 {
+		return nil, fmt.Errorf("priceTolerance %v is not an int64", priceTolerance)
+	}
+	fields[idx[0]] = int64(priceTolerance)
+	return fields, nil
+}
+
+// migrateKyberPMMPriceLevels migrates the price levels of each quote token of kyber-pmm to a pair of pricelevel.Book.
+// Version 0 skipped the quote tokens without price levels, which can only be recovered at the end of the list.
+func migrateKyberPMMPriceLevels(fields []any) ([]any, error) {
+	idx, err := fieldIndexes(kyberPMMV0{}, fields, "baseToken", "quoteTokens", "priceLevels")
+	if err != nil {
+		return nil, err
+	}
+	var (
+		baseToken   entity.PoolToken
+		quoteTokens []entity.PoolToken
+		priceLevels []pkg_liquiditysource_kyberpmm.BaseQuotePriceLevels
+	)
+	if err := convertField(fields[idx[0]], &baseToken); err != nil {
+		return nil, err
+	}
+	if err := convertField(fields[idx[1]], &quoteTokens); err != nil {
+		return nil, err
+	}
+	if err := convertField(fields[idx[2]], &priceLevels); err != nil {
+		return nil, err
+	}
+
+	toLevel := func(l pkg_liquiditysource_kyberpmm.PriceLevel) (pricelevel.Level, error) {
+		return newLevelV0(l.Price, l.Amount)
+	}
+	books := make([]any, len(quoteTokens))
+	for i, quoteToken := range quoteTokens {
+		var bqPriceLevels pkg_liquiditysource_kyberpmm.BaseQuotePriceLevels
+		if i < len(priceLevels) {
+			bqPriceLevels = priceLevels[i]
+		}
+		baseToQuote, err := newBookV1(bqPriceLevels.BaseToQuotePriceLevels, toLevel, false, baseToken, quoteToken)
+		if err != nil {
+			return nil, err
+		}
+		quoteToBase, err := newBookV1(bqPriceLevels.QuoteToBasePriceLevels, toLevel, false, quoteToken, baseToken)
+		if err != nil {
+			return nil, err
+		}
+		books[i] = []any{baseToQuote, quoteToBase}
+	}
+	fields[idx[2]] = books
+	return fields, nil
+}
+
+// migrateLO1inchMakerEpochs appends the maker epochs of lo1inch, unknown for pools encoded before they were tracked.
+func migrateLO1inchMakerEpochs(fields []any) ([]any, error) {
+	return append(fields, nil), nil
+}
+
+// migrateLO1inchBitInvalidators appends the maker nonce invalidators of lo1inch, unknown for pools encoded before they
+// were tracked, and the decoded order extensions, decoded on the fly for such pools.
+func migrateLO1inchBitInvalidators(fields []any) ([]any, error) {
+	return append(fields, nil, nil), nil
+}
+
+// legacyPriceLevels converts the pricelevel.Book fields levels0 and levels1 of a pool back to the version 0 float price
+// levels of type []L, the fields being named in layout, the layout of the pool at version 0. cumulative levels are sized
+// by the total up to them. It is the inverse of migratePriceLevels, up to the float64 rounding of the levels.
+func legacyPriceLevels[L any](layout any, levels0, levels1 string, cumulative bool,
+	fromLevel func(price, size float64) L) Migration {
+	return func(fields []any) ([]any, error) {
+		idx, err := fieldIndexes(layout, fields, levels0, levels1)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range idx {
+			var book *pricelevel.Book
+			if err := convertField(fields[i], &book); err != nil {
+				return nil, err
+			}
+			fields[i] = legacyLevels(book, cumulative, fromLevel)
+		}
+		return fields, nil
+	}
+}
+
+// legacySwaapV2PriceLevels also converts the price tolerance of swaap-v2 back to float64.
+func legacySwaapV2PriceLevels(fields []any) ([]any, error) {
+	fields, err := legacyPriceLevels(swaapV2V0{}, "baseToQuotePriceLevels", "quoteToBasePriceLevels", true,
+		func(price, size float64) pkg_liquiditysource_swaapv2.PriceLevel {
+			return pkg_liquiditysource_swaapv2.PriceLevel{Price: price, Level: size}
+		})(fields)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := fieldIndexes(swaapV2V0{}, fields, "priceTolerance")
+	if err != nil {
+		return nil, err
+	}
+	var priceTolerance int64
+	if err := convertField(fields[idx[0]], &priceTolerance); err != nil {
+		return nil, err
+	}
+	fields[idx[0]] = float64(priceTolerance)
+	return fields, nil
+}
+
+// legacyKyberPMMPriceLevels converts the pairs of pricelevel.Book of kyber-pmm back to the price levels of each quote
+// token.
+func legacyKyberPMMPriceLevels(fields []any) ([]any, error) {
+	idx, err := fieldIndexes(kyberPMMV0{}, fields, "priceLevels")
+	if err != nil {
+		return nil, err
+	}
+	var books []struct{ BaseToQuote, QuoteToBase *pricelevel.Book }
+	if err := convertField(fields[idx[0]], &books); err != nil {
+		return nil, err
+	}
+
+	fromLevel := func(price, size float64) pkg_liquiditysource_kyberpmm.PriceLevel {
+		return pkg_liquiditysource_kyberpmm.PriceLevel{Price: price, Amount: size}
+	}
+	priceLevels := make([]pkg_liquiditysource_kyberpmm.BaseQuotePriceLevels, len(books))
+	for i, pair := range books {
+		priceLevels[i] = pkg_liquiditysource_kyberpmm.BaseQuotePriceLevels{
+			BaseToQuotePriceLevels: legacyLevels(pair.BaseToQuote, false, fromLevel),
+			QuoteToBasePriceLevels: legacyLevels(pair.QuoteToBase, false, fromLevel),
+		}
+	}
+	fields[idx[0]] = priceLevels
+	return fields, nil
+}
+
+// legacyLevels returns the version 0 levels of type []L of book.
+func legacyLevels[L any](book *pricelevel.Book, cumulative bool, fromLevel func(price, size float64) L) []L {
+	if book == nil {
+		return nil
+	}
+	levels := make([]L, book.Len())
+	cumulativeSize := new(big.Rat)
+	for i, level := range book.Levels() {
+		if cumulative {
+			cumulativeSize.Add(cumulativeSize, level.Size)
+			level.Size = cumulativeSize
+		}
+		levels[i] = fromLevel(level.Float64())
+	}
+	return levels
+}
+
+// migrateLO1inchAmountGetters appends the amount getters of lo1inch, unknown for pools encoded before they were
+// checked, whose orders with amount getters are then skipped.
+func migrateLO1inchAmountGetters(fields []any) ([]any, error) {
+	return append(fields, nil), nil
+}
+
+// legacyLO1inch drops the maker epochs, the maker nonce invalidators, the decoded order extensions and the amount
+// getters of lo1inch, appended since version 0.
+func legacyLO1inch(fields []any) ([]any, error) {
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected number of fields: %d", len(fields))
+	}
+	return fields[:len(fields)-4], nil
+}
+
+// migrateBook returns the pricelevel.Book of the generic decoding of version 0 levels of type []L.
+func migrateBook[L any](field any, toLevel func(L) (pricelevel.Level, error), cumulative bool,
+	tokenIn, tokenOut entity.PoolToken) (*pricelevel.Book, error) {
+	var levels []L
+	if err := convertField(field, &levels); err != nil {
+		return nil, err
+	}
+	return newBookV1(levels, toLevel, cumulative, tokenIn, tokenOut)
+}
+
+func newBookV1[L any](levels []L, toLevel func(L) (pricelevel.Level, error), cumulative bool,
+	tokenIn, tokenOut entity.PoolToken) (*pricelevel.Book, error) {
+	bookLevels := make([]pricelevel.Level, 0, len(levels))
+	for _, l := range levels {
+		level, err := toLevel(l)
+		if err != nil {
+			return nil, err
+		}
+		bookLevels = append(bookLevels, level)
+	}
+	if cumulative {
+		bookLevels = pricelevel.FromCumulative(bookLevels)
+	}
+	return pricelevel.NewBook(bookLevels, tokenIn.Decimals, tokenOut.Decimals), nil
+}
+
+// newLevelV0 returns the level of a version 0 float64 price and size, as the pools build them from the levels quoted
+// by the makers, see pricelevel.NewLevel. Non-finite values, which pricelevel.Decimal would turn to 0, are rejected.
+func newLevelV0(price, size float64) (pricelevel.Level, error) {
+	for _, f := range [...]float64{price, size} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return pricelevel.Level{}, fmt.Errorf("non-finite price level %v", f)
+		}
+	}
+	return pricelevel.NewLevel(price, size), nil
+}
+
+// newDexalotLevelV0 returns the level of a version 0 dexalot price level, exactly as quoted by the maker.
+func newDexalotLevelV0(l dexalotPriceLevelV0) (pricelevel.Level, error) {
+	price, err := bigFloatDecimal(l.Price)
+	if err != nil {
+		return pricelevel.Level{}, err
+	}
+	quote, err := bigFloatDecimal(l.Quote)
+	if err != nil {
+		return pricelevel.Level{}, err
+	}
+	return pricelevel.Level{Price: price, Size: quote}, nil
+}
+
+// newDexalotPriceLevelV0 returns the version 0 dexalot price level of a price and a quote.
+func newDexalotPriceLevelV0(price, quote float64) dexalotPriceLevelV0 {
+	return dexalotPriceLevelV0{Quote: big.NewFloat(quote), Price: big.NewFloat(price)}
+}
+
+// bigFloatDecimal returns f as the shortest decimal formatting to it at its precision, as pricelevel.Decimal does for
+// float64, without rounding it to a float64.
+func bigFloatDecimal(f *big.Float) (*big.Rat, error) {
+	if f == nil {
+		return new(big.Rat), nil
+	}
+	r, ok := new(big.Rat).SetString(f.Text('g', -1))
+	if !ok {
+		return nil, fmt.Errorf("non-finite price level %v", f)
+	}
+	return r, nil
+}
+
+// fieldIndexes returns the positions of the fields named names in fields, the generic decoding of a pool simulator
+// laid out as layout. Pool simulators are encoded as arrays of their fields in the order the encoder lists them, which
+// is read back from the map encoding of layout.
+func fieldIndexes(layout any, fields []any, names ...string) ([]int, error) {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	en.SetForceAsArray(false)
+	err := en.Encode(layout)
+	PutEncoder(en)
+	if err != nil {
+		return nil, err
+	}
+
+	de := NewDecoder(&buf)
+	defer PutDecoder(de)
+	n, err := de.DecodeMapLen()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) != n {
+		return nil, fmt.Errorf("unexpected number of fields: %d, %T has %d", len(fields), layout, n)
+	}
+	positions := make(map[string]int, n)
+	for i := range n {
+		name, err := de.DecodeString()
+		if err != nil {
+			return nil, err
+		}
+		if err = de.Skip(); err != nil {
+			return nil, err
+		}
+		positions[name] = i
+	}
+
+	indexes := make([]int, len(names))
+	for i, name := range names {
+		var ok bool
+		if indexes[i], ok = positions[name]; !ok {
+			return nil, fmt.Errorf("%T has no field %s", layout, name)
+		}
+	}
+	return indexes, nil
+}
+
+// convertField decodes the generic decoding of a field into v, of the type it had at its schema version.
+func convertField(field, v any) error {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	err := en.Encode(field)
+	PutEncoder(en)
+	if err != nil {
+		return err
+	}
+
+	de := NewDecoder(&buf)
+	defer PutDecoder(de)
+	return de.Decode(v)
+}
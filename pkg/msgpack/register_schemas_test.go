@@ -0,0 +1,130 @@
+package msgpack
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	pkg_liquiditysource_lo1inch "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/lo1inch"
+	pkg_liquiditysource_swaapv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/swaap-v2"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/pricelevel"
+)
+
+// genericFields returns the generic decoding of v, as migrations get it.
+func genericFields(t *testing.T, v any) []any {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	require.NoError(t, en.Encode(v))
+	PutEncoder(en)
+
+	var fields []any
+	de := NewDecoder(&buf)
+	require.NoError(t, de.Decode(&fields))
+	PutDecoder(de)
+	return fields
+}
+
+func TestFieldIndexes(t *testing.T) {
+	fields := genericFields(t, swaapV2V0{})
+
+	idx, err := fieldIndexes(swaapV2V0{}, fields, "baseToken", "quoteToBasePriceLevels", "priceTolerance")
+	require.NoError(t, err)
+	assert.Equal(t, []int{3, 6, 8}, idx)
+
+	_, err = fieldIndexes(swaapV2V0{}, fields, "PriceTolerance")
+	assert.ErrorContains(t, err, "has no field PriceTolerance")
+
+	_, err = fieldIndexes(swaapV2V0{}, fields[:8], "baseToken")
+	assert.ErrorContains(t, err, "unexpected number of fields")
+}
+
+func TestMigrateSwaapV2PriceLevels(t *testing.T) {
+	v0 := swaapV2V0{
+		baseToken:  entity.PoolToken{Address: "base", Decimals: 18},
+		quoteToken: entity.PoolToken{Address: "quote", Decimals: 6},
+		baseToQuotePriceLevels: []pkg_liquiditysource_swaapv2.PriceLevel{
+			{Price: 2000, Level: 1},
+			{Price: 1990, Level: 3},
+		},
+		priceTolerance: 100,
+	}
+	fields, err := migrateSwaapV2PriceLevels(genericFields(t, v0))
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), fields[8])
+	book := fields[5].(*pricelevel.Book)
+	assert.Equal(t, []pricelevel.Level{pricelevel.NewLevel(2000, 1), pricelevel.NewLevel(1990, 2)}, book.Levels())
+
+	v0.priceTolerance = 100.5
+	_, err = migrateSwaapV2PriceLevels(genericFields(t, v0))
+	assert.ErrorContains(t, err, "priceTolerance 100.5 is not an int64")
+}
+
+func TestMigrateDexalotPriceLevels(t *testing.T) {
+	// more digits than a float64 holds
+	price, _, err := big.ParseFloat("0.016666666666666666667", 10, 200, big.ToNearestEven)
+	require.NoError(t, err)
+	v0 := dexalotV0{
+		Token0:               entity.PoolToken{Address: "weth", Decimals: 18},
+		Token1:               entity.PoolToken{Address: "usdc", Decimals: 6},
+		OneToZeroPriceLevels: []dexalotPriceLevelV0{{Quote: big.NewFloat(120), Price: price}},
+	}
+	migration := migratePriceLevels(dexalotV0{}, "Token0", "Token1", "ZeroToOnePriceLevels", "OneToZeroPriceLevels",
+		true, newDexalotLevelV0)
+	fields, err := migration(genericFields(t, v0))
+	require.NoError(t, err)
+
+	levels := fields[4].(*pricelevel.Book).Levels()
+	require.Len(t, levels, 1)
+	assert.Equal(t, "16666666666666666667/1000000000000000000000", levels[0].Price.String())
+	assert.Equal(t, "120/1", levels[0].Size.String())
+}
+
+func TestNewLevelV0_NonFinite(t *testing.T) {
+	_, err := newLevelV0(1, math.Inf(1))
+	assert.ErrorContains(t, err, "non-finite price level")
+}
+
+// newLO1inchPool returns a lo1inch pool with the orders of several makers and their epochs.
+func newLO1inchPool(t *testing.T) *pkg_liquiditysource_lo1inch.PoolSimulator {
+	poolSim, err := pkg_liquiditysource_lo1inch.NewPoolSimulator(entity.Pool{
+		Address:     "lo1inch_a_b",
+		Exchange:    "lo1inch",
+		Type:        pkg_liquiditysource_lo1inch.DexType,
+		Reserves:    entity.PoolReserves{"300", "2000"},
+		Tokens:      []*entity.PoolToken{{Address: "a"}, {Address: "b"}},
+		StaticExtra: `{"token0":"a","token1":"b","routerAddress":"0x111111125421ca6dc452d289314280a0f8842a65"}`,
+		Extra: `{"takeToken0Orders":[
+{"orderHash":"0x01","maker":"0x03","makerAsset":"a","takerAsset":"b","makingAmount":"100","takingAmount":"1000",
+"remainingMakerAmount":"100","makerBalance":"100","makerAllowance":"90"},
+{"orderHash":"0x02","maker":"0x02","makerAsset":"a","takerAsset":"b","makingAmount":"200","takingAmount":"1000",
+"remainingMakerAmount":"200","makerBalance":"300","makerAllowance":"300"}],
+"takeToken1Orders":[
+{"orderHash":"0x03","maker":"0x01","makerAsset":"b","takerAsset":"a","makingAmount":"2000","takingAmount":"100",
+"remainingMakerAmount":"2000","makerBalance":"2000","makerAllowance":"5000"}],
+"makerEpochs":{"0x03:1":"2","0x01:0":"1"}}`,
+	})
+	require.NoError(t, err)
+	return poolSim
+}
+
+func TestLegacyLO1inch(t *testing.T) {
+	SetWriteLegacyFormat(true)
+	t.Cleanup(func() { SetWriteLegacyFormat(false) })
+
+	expected := newLO1inchPool(t)
+	encoded, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{"lo1inch": expected})
+	require.NoError(t, err)
+	poolsMap, err := DecodePoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	require.IsType(t, &pkg_liquiditysource_lo1inch.PoolSimulator{}, poolsMap["lo1inch"])
+	decoded := poolsMap["lo1inch"].(*pkg_liquiditysource_lo1inch.PoolSimulator)
+	assert.Equal(t, expected.GetTokens(), decoded.GetTokens())
+	assert.Equal(t, expected.CalculateLimit(), decoded.CalculateLimit())
+	assert.Equal(t, expected.MakerAssets(), decoded.MakerAssets())
+}
@@ -6,6 +6,7 @@ import (
 	uniswapv3uint256_entities "github.com/KyberNetwork/uniswapv3-sdk-uint256/entities"
 	uniswapv3_entities "github.com/daoleno/uniswapv3-sdk/entities"
 
+	pkg_liquiditysource_uniswapv4 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/uniswap-v4"
 	pkg_source_gmx "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/gmx"
 	pkg_source_gmxglp "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/gmx-glp"
 	pkg_source_madmex "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/madmex"
@@ -51,4 +52,9 @@ func init() {
 
 	mustNotError(msgpack.RegisterConcreteType(&pkg_source_zkerafinance.FastPriceFeedV1{}))
 	mustNotError(msgpack.RegisterConcreteType(&pkg_source_zkerafinance.FastPriceFeedV2{}))
+
+	mustNotError(msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv4.BaseHook{}))
+	mustNotError(msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv4.DynamicFeeHook{}))
+	mustNotError(msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv4.FeeTakingHook{}))
+	mustNotError(msgpack.RegisterConcreteType(&pkg_liquiditysource_uniswapv4.SpecifiedFeeHook{}))
 }
@@ -0,0 +1,252 @@
+package msgpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/KyberNetwork/msgpack/v5"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+var (
+	ErrInvalidPoolType      = errors.New("pool simulator type must be a pointer to a struct")
+	ErrUnregisteredPoolType = errors.New("pool simulator type is not registered")
+	ErrUnsupportedSchema    = errors.New("pool simulator schema version is newer than the registered one")
+	ErrUnsupportedEnvelope  = errors.New("pool simulators map envelope version is not supported")
+	ErrMigrationFailed      = errors.New("pool simulator schema migration failed")
+	// ErrLegacySchema is returned when writing with the legacy format a pool whose schema changed since versioning
+	// was introduced without a legacy migration, as readers of the legacy format would decode its fields at the wrong
+	// positions, or a pool wrapped with pool.NewTransferTaxPoolSimulator, which they cannot decode.
+	ErrLegacySchema = errors.New("pool simulator schema is newer than the legacy format")
+)
+
+// Migration converts the generic decoding of a pool simulator at a schema version to its generic encoding at the next
+// version. Pool simulators are encoded as arrays, so fields is the list of the struct fields in declaration order,
+// embedded structs being inlined and nested structs being arrays of their own fields.
+type Migration func(fields []any) ([]any, error)
+
+type poolType struct {
+	typ        reflect.Type
+	migrations []Migration
+	// legacy converts the generic decoding of a pool at the registered version to version 0, see
+	// RegisterLegacyMigration.
+	legacy Migration
+}
+
+// poolTypes holds the registered pool simulator types keyed by poolTypeName.
+var poolTypes = map[string]*poolType{}
+
+func poolTypeName(typ reflect.Type) string {
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+// RegisterPoolType registers the concrete type of v, a pointer to a pool simulator struct, to be encoded in pool
+// simulators maps. Its schema version is 0 until set with RegisterSchema.
+func RegisterPoolType(v pool.IPoolSimulator) error {
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Pointer || typ.Elem().Kind() != reflect.Struct {
+		return ErrInvalidPoolType
+	}
+	if err := msgpack.RegisterConcreteType(v); err != nil {
+		return err
+	}
+	name := poolTypeName(typ.Elem())
+	if _, ok := poolTypes[name]; !ok {
+		poolTypes[name] = &poolType{typ: typ.Elem()}
+	}
+	return nil
+}
+
+// RegisterSchema sets the schema version of the concrete type of v to len(migrations), migrations[i] migrating the
+// encodings of version i to version i+1. Adding, removing or reordering a field of a pool simulator must come with a
+// new migration, so that pools encoded by the previous versions of the library still decode. Pools encoded without
+// schema version, before versioning was introduced, are at version 0.
+func RegisterSchema(v pool.IPoolSimulator, migrations ...Migration) error {
+	if err := RegisterPoolType(v); err != nil {
+		return err
+	}
+	registered := poolTypes[poolTypeName(reflect.TypeOf(v).Elem())]
+	registered.migrations, registered.legacy = migrations, nil
+	return nil
+}
+
+// RegisterLegacyMigration sets the migration converting the generic decodings of the pools of the concrete type of v,
+// at its registered schema version, to version 0, so that they are written in the legacy format while readers predating
+// schema versioning are upgraded, see SetWriteLegacyFormat. Fields added since version 0 are dropped, converted fields
+// converted back, possibly with a loss of precision. RegisterSchema unsets it, as it converts from the registered version.
+func RegisterLegacyMigration(v pool.IPoolSimulator, migration Migration) error {
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return ErrInvalidPoolType
+	}
+	registered, ok := poolTypes[poolTypeName(typ.Elem())]
+	if !ok {
+		return ErrUnregisteredPoolType
+	}
+	registered.legacy = migration
+	return nil
+}
+
+// SchemaVersion returns the registered schema version of the concrete type of v.
+func SchemaVersion(v pool.IPoolSimulator) (int, error) {
+	typ := reflect.TypeOf(v)
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return 0, ErrInvalidPoolType
+	}
+	registered, ok := poolTypes[poolTypeName(typ.Elem())]
+	if !ok {
+		return 0, ErrUnregisteredPoolType
+	}
+	return len(registered.migrations), nil
+}
+
+// encodedPool is a pool simulator encoded with its type and schema version.
+type encodedPool struct {
+	Type    string
+	Version int
+	Data    []byte
+}
+
+// transferTaxPool is the encoding of a pool.TransferTaxPoolSimulator: the wrapped pool, encoded with its own type and
+// schema version so that it migrates as if it were not wrapped, and the transfer taxes to wrap it with again.
+type transferTaxPool struct {
+	Pool  encodedPool
+	Taxes map[string]*entity.TransferTax
+}
+
+// transferTaxWrapper is implemented by the pool.TransferTaxPoolSimulator wrappers, supporting exact out or not.
+type transferTaxWrapper interface {
+	Unwrap() pool.IPoolSimulator
+	TransferTaxes() map[string]*entity.TransferTax
+}
+
+// transferTaxPoolType is the type of the encoded transferTaxPool.
+var transferTaxPoolType = poolTypeName(reflect.TypeOf(pool.TransferTaxPoolSimulator{}))
+
+// encodePool encodes poolSim canonically, see canonicalize, so that equal pools have equal encodings.
+func encodePool(poolSim pool.IPoolSimulator) (encodedPool, error) {
+	if wrapper, ok := poolSim.(transferTaxWrapper); ok {
+		return encodeTransferTaxPool(wrapper)
+	}
+	typ := reflect.TypeOf(poolSim)
+	if typ == nil || typ.Kind() != reflect.Pointer {
+		return encodedPool{}, ErrInvalidPoolType
+	}
+	name := poolTypeName(typ.Elem())
+	registered, ok := poolTypes[name]
+	if !ok {
+		return encodedPool{}, fmt.Errorf("%w: %s", ErrUnregisteredPoolType, name)
+	}
+
+	data, err := encodeCanonical(poolSim)
+	if err != nil {
+		return encodedPool{}, err
+	}
+	return encodedPool{Type: name, Version: len(registered.migrations), Data: data}, nil
+}
+
+func encodeTransferTaxPool(wrapper transferTaxWrapper) (encodedPool, error) {
+	inner, err := encodePool(wrapper.Unwrap())
+	if err != nil {
+		return encodedPool{}, err
+	}
+	data, err := encodeCanonical(transferTaxPool{Pool: inner, Taxes: wrapper.TransferTaxes()})
+	if err != nil {
+		return encodedPool{}, err
+	}
+	return encodedPool{Type: transferTaxPoolType, Data: data}, nil
+}
+
+// encodeCanonical returns the canonical encoding of v.
+func encodeCanonical(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	err := en.Encode(v)
+	PutEncoder(en)
+	if err != nil {
+		return nil, err
+	}
+	return canonicalize(buf.Bytes())
+}
+
+// decodePool decodes a pool encoded by encodePool, migrating it to the registered schema version if it is older.
+func decodePool(encoded encodedPool) (pool.IPoolSimulator, error) {
+	if encoded.Type == transferTaxPoolType {
+		return decodeTransferTaxPool(encoded.Data)
+	}
+	registered, ok := poolTypes[encoded.Type]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnregisteredPoolType, encoded.Type)
+	}
+	version := len(registered.migrations)
+	if encoded.Version > version {
+		return nil, fmt.Errorf("%w: %s version %d > %d", ErrUnsupportedSchema, encoded.Type, encoded.Version, version)
+	}
+
+	data := encoded.Data
+	if encoded.Version < version {
+		var fields []any
+		de := NewDecoder(bytes.NewReader(data))
+		err := de.Decode(&fields)
+		PutDecoder(de)
+		if err != nil {
+			return nil, err
+		}
+		if data, err = migrate(fields, registered.migrations[encoded.Version:]); err != nil {
+			return nil, fmt.Errorf("%w: %s from version %d: %v", ErrMigrationFailed, encoded.Type,
+				encoded.Version, err)
+		}
+	}
+	return registered.decode(data)
+}
+
+func decodeTransferTaxPool(data []byte) (pool.IPoolSimulator, error) {
+	var encoded transferTaxPool
+	de := NewDecoder(bytes.NewReader(data))
+	err := de.Decode(&encoded)
+	PutDecoder(de)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := decodePool(encoded.Pool)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]*entity.PoolToken, 0, len(encoded.Taxes))
+	for token, tax := range encoded.Taxes {
+		tokens = append(tokens, &entity.PoolToken{Address: token, TransferTax: tax})
+	}
+	return pool.NewTransferTaxPoolSimulator(inner, tokens)
+}
+
+func (t *poolType) decode(data []byte) (pool.IPoolSimulator, error) {
+	poolSim := reflect.New(t.typ)
+	de := NewDecoder(bytes.NewReader(data))
+	defer PutDecoder(de)
+	if err := de.DecodeValue(poolSim); err != nil {
+		return nil, err
+	}
+	return poolSim.Interface().(pool.IPoolSimulator), nil
+}
+
+// migrate applies migrations in order to the generic decoding of a pool and encodes the result.
+func migrate(fields []any, migrations []Migration) ([]byte, error) {
+	for _, migration := range migrations {
+		var err error
+		if fields, err = migration(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	defer PutEncoder(en)
+	if err := en.Encode(fields); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,390 @@
+package msgpack
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/klauspost/compress/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/swaplimit"
+)
+
+var updateCompat = flag.Bool("update-compat", false,
+	"write the encodings of testdata/compat/pools.json with the current envelope version")
+
+const compatDir = "testdata/compat"
+
+func loadCompatPools(t *testing.T) map[string]pool.IPoolSimulator {
+	return loadPools(t, compatDir)
+}
+
+func loadPools(t *testing.T, dir string) map[string]pool.IPoolSimulator {
+	data, err := os.ReadFile(filepath.Join(dir, "pools.json"))
+	require.NoError(t, err)
+	var entityPools []entity.Pool
+	require.NoError(t, json.Unmarshal(data, &entityPools))
+
+	poolsMap := make(map[string]pool.IPoolSimulator, len(entityPools))
+	for _, entityPool := range entityPools {
+		factory := pool.Factory(entityPool.Type)
+		require.NotNil(t, factory, entityPool.Type)
+		poolSim, err := factory(pool.FactoryParams{EntityPool: entityPool})
+		require.NoError(t, err)
+		poolsMap[entityPool.Address] = poolSim
+	}
+	return poolsMap
+}
+
+// TestDecodePoolSimulatorsMap_Compat decodes the pools of testdata/compat/pools.json encoded by previous versions of
+// the library, and checks they quote as the pools built from the entities. legacy.bin was encoded before schema
+// versioning, envelope-v<N>.bin and indexed-v<N>.bin files with envelope version N. Run with -update-compat after
+// bumping envelopeVersion to record the new envelope, and keep the older files.
+func TestDecodePoolSimulatorsMap_Compat(t *testing.T) {
+	expected := loadCompatPools(t)
+
+	if *updateCompat {
+		encoded, err := EncodePoolSimulatorsMap(expected)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(compatDir, fmt.Sprintf("envelope-v%d.bin", envelopeVersion)),
+			encoded, 0o644))
+		encoded, err = EncodeIndexedPoolSimulatorsMap(expected)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(compatDir, fmt.Sprintf("indexed-v%d.bin", envelopeVersion)),
+			encoded, 0o644))
+	}
+
+	fixtures, err := filepath.Glob(filepath.Join(compatDir, "*.bin"))
+	require.NoError(t, err)
+	require.NotEmpty(t, fixtures)
+	for _, fixture := range fixtures {
+		t.Run(filepath.Base(fixture), func(t *testing.T) {
+			encoded, err := os.ReadFile(fixture)
+			require.NoError(t, err)
+			decoded, err := DecodePoolSimulatorsMap(encoded)
+			require.NoError(t, err)
+			require.Len(t, decoded, len(expected))
+
+			for address, expectedSim := range expected {
+				decodedSim := decoded[address]
+				require.NotNil(t, decodedSim, address)
+				assert.Equal(t, reflect.TypeOf(expectedSim), reflect.TypeOf(decodedSim))
+				assert.Equal(t, expectedSim.GetReserves(), decodedSim.GetReserves())
+				for _, tokenIn := range expectedSim.GetTokens() {
+					amountIn := new(big.Int).Div(expectedSim.GetReserves()[expectedSim.GetTokenIndex(tokenIn)],
+						big.NewInt(1000))
+					for _, tokenOut := range expectedSim.CanSwapFrom(tokenIn) {
+						params := pool.CalcAmountOutParams{
+							TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: amountIn},
+							TokenOut:      tokenOut,
+						}
+						expectedRes, err := expectedSim.CalcAmountOut(params)
+						require.NoError(t, err)
+						decodedRes, err := decodedSim.CalcAmountOut(params)
+						require.NoError(t, err)
+						assert.Equal(t, expectedRes.TokenAmountOut.Amount, decodedRes.TokenAmountOut.Amount,
+							"%s %s -> %s", address, tokenIn, tokenOut)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestDecodePoolSimulatorsMap_PriceLevels decodes the PMM and RFQ pools of testdata/pricelevel/pools.json encoded
+// with float price levels, before they were migrated to pricelevel.Book, and checks they quote as the pools built from
+// the entities.
+func TestDecodePoolSimulatorsMap_PriceLevels(t *testing.T) {
+	expected := loadPools(t, "testdata/pricelevel")
+	encoded, err := os.ReadFile("testdata/pricelevel/envelope-v1.bin")
+	require.NoError(t, err)
+	decoded, err := DecodePoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	assertPriceLevelPools(t, expected, decoded)
+}
+
+// TestEncodePoolSimulatorsMap_WriteLegacyPriceLevels writes the PMM and RFQ pools of testdata/pricelevel/pools.json
+// in the legacy format, with their float price levels, and checks they quote as the pools built from the entities once
+// decoded.
+func TestEncodePoolSimulatorsMap_WriteLegacyPriceLevels(t *testing.T) {
+	SetWriteLegacyFormat(true)
+	t.Cleanup(func() { SetWriteLegacyFormat(false) })
+
+	expected := loadPools(t, "testdata/pricelevel")
+	encoded, err := EncodePoolSimulatorsMap(expected)
+	require.NoError(t, err)
+	assert.False(t, bytes.HasPrefix(encoded, versionedMagic))
+	decoded, err := DecodePoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	assertPriceLevelPools(t, expected, decoded)
+}
+
+// assertPriceLevelPools checks the decoded PMM and RFQ pools quote as the expected ones.
+func assertPriceLevelPools(t *testing.T, expected, decoded map[string]pool.IPoolSimulator) {
+	require.Len(t, decoded, len(expected))
+	for address, expectedSim := range expected {
+		decodedSim := decoded[address]
+		require.NotNil(t, decodedSim, address)
+		assert.Equal(t, reflect.TypeOf(expectedSim), reflect.TypeOf(decodedSim))
+		for _, tokenIn := range expectedSim.GetTokens() {
+			reserve := expectedSim.GetReserves()[expectedSim.GetTokenIndex(tokenIn)]
+			for _, tokenOut := range expectedSim.CanSwapFrom(tokenIn) {
+				for _, divisor := range []int64{1000, 100, 10} {
+					amountIn := new(big.Int).Div(reserve, big.NewInt(divisor))
+					expectedRes, expectedErr := expectedSim.CalcAmountOut(calcAmountOutParams(expectedSim, tokenIn,
+						tokenOut, amountIn))
+					decodedRes, err := decodedSim.CalcAmountOut(calcAmountOutParams(decodedSim, tokenIn, tokenOut,
+						amountIn))
+					require.Equal(t, expectedErr, err, "%s %s -> %s", address, tokenIn, tokenOut)
+					if err == nil {
+						assert.Equal(t, expectedRes.TokenAmountOut.Amount, decodedRes.TokenAmountOut.Amount,
+							"%s %s -> %s", address, tokenIn, tokenOut)
+					}
+				}
+			}
+		}
+	}
+}
+
+func calcAmountOutParams(poolSim pool.IPoolSimulator, tokenIn, tokenOut string,
+	amountIn *big.Int) pool.CalcAmountOutParams {
+	params := pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: amountIn},
+		TokenOut:      tokenOut,
+	}
+	if limiter, ok := poolSim.(interface{ CalculateLimit() map[string]*big.Int }); ok {
+		if inventory := limiter.CalculateLimit(); inventory != nil {
+			params.Limit = swaplimit.NewInventory("", inventory)
+		}
+	}
+	return params
+}
+
+type migratedPool struct {
+	pool.Pool
+	Fee  uint64
+	Name string
+}
+
+// migratedPoolV0 is the version 0 layout of migratedPool, without Fee.
+type migratedPoolV0 struct {
+	pool.Pool
+	Name string
+}
+
+func (p *migratedPool) CalcAmountOut(pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	return nil, nil
+}
+
+func (p *migratedPool) UpdateBalance(pool.UpdateBalanceParams) {}
+
+func (p *migratedPool) GetMetaInfo(string, string) any { return nil }
+
+func init() {
+	mustNotError(RegisterSchema(&migratedPool{}, func(fields []any) ([]any, error) {
+		name := fields[len(fields)-1]
+		return append(fields[:len(fields)-1], uint64(30), name), nil
+	}))
+}
+
+func migratedPoolV0Encoding(t *testing.T) []byte {
+	var buf bytes.Buffer
+	en := NewEncoder(&buf)
+	defer PutEncoder(en)
+	require.NoError(t, en.Encode(&migratedPoolV0{
+		Pool: pool.Pool{Info: pool.PoolInfo{Address: "migrated", Tokens: []string{"a", "b"}}},
+		Name: "v0",
+	}))
+	return buf.Bytes()
+}
+
+func assertMigratedPool(t *testing.T, poolSim pool.IPoolSimulator) {
+	require.IsType(t, &migratedPool{}, poolSim)
+	migrated := poolSim.(*migratedPool)
+	assert.Equal(t, "migrated", migrated.Info.Address)
+	assert.Equal(t, []string{"a", "b"}, migrated.Info.Tokens)
+	assert.EqualValues(t, 30, migrated.Fee)
+	assert.Equal(t, "v0", migrated.Name)
+}
+
+func TestRegisterSchema(t *testing.T) {
+	version, err := SchemaVersion(&migratedPool{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+	name := poolTypeName(reflect.TypeOf(migratedPool{}))
+
+	t.Run("migrate envelope", func(t *testing.T) {
+		poolSim, err := decodePool(encodedPool{Type: name, Version: 0, Data: migratedPoolV0Encoding(t)})
+		require.NoError(t, err)
+		assertMigratedPool(t, poolSim)
+	})
+
+	t.Run("migrate legacy", func(t *testing.T) {
+		var raw bytes.Buffer
+		en := NewEncoder(&raw)
+		require.NoError(t, en.EncodeMapLen(1))
+		require.NoError(t, en.EncodeString("migrated"))
+		raw.Write([]byte{0xd4, legacyTaggedInterfaceExtID})
+		require.NoError(t, en.EncodeString("*"+strings.Replace(name, ".", " ", 1)))
+		PutEncoder(en)
+		raw.Write(migratedPoolV0Encoding(t))
+
+		var buf bytes.Buffer
+		zw := snappy.NewBufferedWriter(&buf)
+		_, err := zw.Write(raw.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		poolsMap, err := DecodePoolSimulatorsMap(buf.Bytes())
+		require.NoError(t, err)
+		assertMigratedPool(t, poolsMap["migrated"])
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		encoded, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{
+			"migrated": &migratedPool{Pool: pool.Pool{Info: pool.PoolInfo{Address: "migrated"}}, Fee: 5, Name: "v1"},
+		})
+		require.NoError(t, err)
+		poolsMap, err := DecodePoolSimulatorsMap(encoded)
+		require.NoError(t, err)
+		assert.EqualValues(t, 5, poolsMap["migrated"].(*migratedPool).Fee)
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		_, err := decodePool(encodedPool{Type: name, Version: 2})
+		assert.ErrorIs(t, err, ErrUnsupportedSchema)
+	})
+
+	t.Run("unregistered type", func(t *testing.T) {
+		_, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{"p": &struct{ migratedPool }{}})
+		assert.ErrorIs(t, err, ErrUnregisteredPoolType)
+	})
+
+	t.Run("unsupported envelope", func(t *testing.T) {
+		_, err := DecodePoolSimulatorsMap(append(append([]byte(nil), versionedMagic...), envelopeVersion+1))
+		assert.ErrorIs(t, err, ErrUnsupportedEnvelope)
+	})
+}
+
+func TestEncodePoolSimulatorsMap_WriteLegacyFormat(t *testing.T) {
+	SetWriteLegacyFormat(true)
+	t.Cleanup(func() { SetWriteLegacyFormat(false) })
+
+	expected := loadCompatPools(t)
+	encoded, err := EncodePoolSimulatorsMap(expected)
+	require.NoError(t, err)
+	assert.False(t, bytes.HasPrefix(encoded, versionedMagic))
+
+	// readers predating the versioned envelope decode the map as tagged interfaces
+	var decoded map[string]pool.IPoolSimulator
+	de := NewDecoder(snappy.NewReader(bytes.NewReader(encoded)))
+	require.NoError(t, de.Decode(&decoded))
+	PutDecoder(de)
+	require.Len(t, decoded, len(expected))
+	for address, expectedSim := range expected {
+		assert.Equal(t, expectedSim.GetReserves(), decoded[address].GetReserves())
+	}
+
+	current, err := DecodePoolSimulatorsMap(encoded)
+	require.NoError(t, err)
+	assert.Len(t, current, len(expected))
+
+	_, err = EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{"migrated": &migratedPool{}})
+	assert.ErrorIs(t, err, ErrLegacySchema)
+
+	t.Run("legacy migration", func(t *testing.T) {
+		require.NoError(t, RegisterLegacyMigration(&migratedPool{}, func(fields []any) ([]any, error) {
+			return slices.Delete(fields, len(fields)-2, len(fields)-1), nil
+		}))
+		t.Cleanup(func() { mustNotError(RegisterLegacyMigration(&migratedPool{}, nil)) })
+
+		encoded, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{
+			"migrated": &migratedPool{
+				Pool: pool.Pool{Info: pool.PoolInfo{Address: "migrated", Tokens: []string{"a", "b"}}},
+				Fee:  5,
+				Name: "v0",
+			},
+			"nil": nil,
+		})
+		require.NoError(t, err)
+		poolsMap, err := DecodePoolSimulatorsMap(encoded)
+		require.NoError(t, err)
+		// the fee, added since version 0, is migrated to its default
+		assertMigratedPool(t, poolsMap["migrated"])
+		assert.Contains(t, poolsMap, "nil")
+	})
+}
+
+func TestEncodePoolSimulatorsMap_TransferTax(t *testing.T) {
+	taxes := map[string]*entity.TransferTax{
+		"a": {BuyTaxBps: 100, SellTaxBps: 200},
+		"b": {TotalShares: "3", TotalSupply: "4"},
+	}
+	wrap := func(poolSim pool.IPoolSimulator) pool.IPoolSimulator {
+		tokens := make([]*entity.PoolToken, 0, len(taxes))
+		for token, tax := range taxes {
+			tokens = append(tokens, &entity.PoolToken{Address: token, TransferTax: tax})
+		}
+		wrapped, err := pool.NewTransferTaxPoolSimulator(poolSim, tokens)
+		require.NoError(t, err)
+		return wrapped
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		encoded, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{
+			"migrated": wrap(&migratedPool{Pool: pool.Pool{Info: pool.PoolInfo{Address: "migrated"}}, Fee: 5}),
+		})
+		require.NoError(t, err)
+		poolsMap, err := DecodePoolSimulatorsMap(encoded)
+		require.NoError(t, err)
+		require.IsType(t, &pool.TransferTaxPoolSimulator{}, poolsMap["migrated"])
+		wrapped := poolsMap["migrated"].(*pool.TransferTaxPoolSimulator)
+		assert.Equal(t, taxes, wrapped.TransferTaxes())
+		assert.EqualValues(t, 5, wrapped.Unwrap().(*migratedPool).Fee)
+	})
+
+	t.Run("migrate wrapped pool", func(t *testing.T) {
+		var buf bytes.Buffer
+		en := NewEncoder(&buf)
+		require.NoError(t, en.Encode(transferTaxPool{
+			Pool: encodedPool{
+				Type:    poolTypeName(reflect.TypeOf(migratedPool{})),
+				Version: 0,
+				Data:    migratedPoolV0Encoding(t),
+			},
+			Taxes: taxes,
+		}))
+		PutEncoder(en)
+
+		poolSim, err := decodePool(encodedPool{Type: transferTaxPoolType, Data: buf.Bytes()})
+		require.NoError(t, err)
+		require.IsType(t, &pool.TransferTaxPoolSimulator{}, poolSim)
+		assertMigratedPool(t, poolSim.(*pool.TransferTaxPoolSimulator).Unwrap())
+	})
+
+	t.Run("legacy format", func(t *testing.T) {
+		SetWriteLegacyFormat(true)
+		t.Cleanup(func() { SetWriteLegacyFormat(false) })
+		for address, poolSim := range loadCompatPools(t) {
+			if _, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{address: poolSim}); err != nil {
+				continue // only the pools the legacy format supports unwrapped
+			}
+			_, err := EncodePoolSimulatorsMap(map[string]pool.IPoolSimulator{address: wrap(poolSim)})
+			assert.ErrorIs(t, err, ErrLegacySchema)
+			return
+		}
+		t.Fatal("no compat pool supports the legacy format")
+	})
+}
@@ -15,9 +15,7 @@ import (
 
 func TestPoolFactory(t *testing.T) {
 	excludedPoolTypes := []string{
-		"ambient",     // aevm
-		"maverick-v2", // aevm
-		"uniswap-v4",  // aevm
+		"uniswap-v4", // aevm
 	}
 	var poolTypesMap map[string]string
 	assert.NoError(t, mapstructure.Decode(PoolTypes, &poolTypesMap))
@@ -4,7 +4,6 @@ import (
 
 	// "errors"
 	"math/big"
-	"time"
 
 	constant "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 	utils "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
@@ -55,10 +54,11 @@ func _getAPrecise(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 ) *big.Int {
 	var t1 = futureATime
 	var a1 = futureA
-	var now = time.Now().Unix()
+	var now = timestamp
 	if t1 > now {
 		var t0 = initialATime
 		var a0 = initialA
@@ -157,6 +157,7 @@ func getY(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	tokenIndexFrom int,
 	tokenIndexTo int,
 	x *big.Int,
@@ -171,7 +172,7 @@ func getY(
 		return nil, ErrTokenIndexesOutOfRange
 	}
 	var numTokensBI = big.NewInt(int64(numTokens))
-	var a = _getAPrecise(futureATime, futureA, initialATime, initialA)
+	var a = _getAPrecise(futureATime, futureA, initialATime, initialA, timestamp)
 	d := dCached
 	if d == nil {
 		var err error
@@ -248,6 +249,7 @@ func _calculateSwap(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	tokenIndexFrom int,
 	tokenIndexTo int,
@@ -258,7 +260,7 @@ func _calculateSwap(
 		return nil, nil, err
 	}
 	var x = new(big.Int).Add(new(big.Int).Mul(dx, tokenPrecisionMultipliers[tokenIndexFrom]), xp[tokenIndexFrom])
-	y, err := getY(futureATime, futureA, initialATime, initialA, tokenIndexFrom, tokenIndexTo, x, xp, nil)
+	y, err := getY(futureATime, futureA, initialATime, initialA, timestamp, tokenIndexFrom, tokenIndexTo, x, xp, nil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -276,6 +278,7 @@ func CalculateSwap(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	tokenIndexFrom int,
 	tokenIndexTo int,
@@ -288,6 +291,7 @@ func CalculateSwap(
 		futureA,
 		initialATime,
 		initialA,
+		timestamp,
 		swapFee,
 		tokenIndexFrom,
 		tokenIndexTo,
@@ -412,6 +416,7 @@ func calculateWithdrawOneTokenDy(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	lpSupply *big.Int,
 	tokenIndex int,
@@ -425,7 +430,7 @@ func calculateWithdrawOneTokenDy(
 	if err != nil {
 		return nil, nil, err
 	}
-	var preciseA = _getAPrecise(futureATime, futureA, initialATime, initialA)
+	var preciseA = _getAPrecise(futureATime, futureA, initialATime, initialA, timestamp)
 	d0, err := getD(xp, preciseA)
 	if err != nil {
 		return nil, nil, err
@@ -493,6 +498,7 @@ func calculateWithdrawOneToken(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	withdrawFee *big.Int,
 	lpSupply *big.Int,
@@ -506,6 +512,7 @@ func calculateWithdrawOneToken(
 		futureA,
 		initialATime,
 		initialA,
+		timestamp,
 		swapFee,
 		lpSupply,
 		tokenIndex,
@@ -535,6 +542,7 @@ func CalculateRemoveLiquidityOneToken(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	withdrawFee *big.Int,
 	lpSupply *big.Int,
@@ -548,6 +556,7 @@ func CalculateRemoveLiquidityOneToken(
 		futureA,
 		initialATime,
 		initialA,
+		timestamp,
 		swapFee,
 		withdrawFee,
 		lpSupply,
@@ -577,13 +586,14 @@ func calculateTokenAmount(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	withdrawFee *big.Int,
 	lpSupply *big.Int,
 	amounts []*big.Int,
 	deposit bool,
 ) (*big.Int, error) {
 	var numTokens = len(balances)
-	var a = _getAPrecise(futureATime, futureA, initialATime, initialA)
+	var a = _getAPrecise(futureATime, futureA, initialATime, initialA, timestamp)
 	xp, err := _xp(balances, tokenPrecisionMultipliers)
 	if err != nil {
 		return nil, err
@@ -635,6 +645,7 @@ func CalculateAddLiquidityOneToken(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	withdrawFee *big.Int,
 	lpSupply *big.Int,
 	tokenIndex int,
@@ -653,6 +664,7 @@ func CalculateAddLiquidityOneToken(
 		futureA,
 		initialATime,
 		initialA,
+		timestamp,
 		withdrawFee,
 		lpSupply,
 		amounts,
@@ -697,6 +709,7 @@ func GetDyUnderlying(
 	futureA *big.Int,
 	initialATime int64,
 	initialA *big.Int,
+	timestamp int64,
 	swapFee *big.Int,
 	offPegFeeMultiplier *big.Int,
 	tokenIndexFrom int,
@@ -713,6 +726,7 @@ func GetDyUnderlying(
 		futureA,
 		initialATime,
 		initialA,
+		timestamp,
 		tokenIndexFrom,
 		tokenIndexTo,
 		x,
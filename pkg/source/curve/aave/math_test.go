@@ -91,6 +91,7 @@ func TestCalculateWithdrawOneToken(t *testing.T) {
 		big.NewInt(80000),
 		0,
 		big.NewInt(80000),
+		0,
 		bignumber.NewBig10("2000000"),
 		bignumber.NewBig10("5000000"),
 		bignumber.NewBig10("8580021119487881426822908"),
@@ -123,6 +124,7 @@ func TestCalculateTokenAmount(t *testing.T) {
 		big.NewInt(80000),
 		0,
 		big.NewInt(80000),
+		0,
 		bignumber.NewBig10("5000000"),
 		bignumber.NewBig10("8580021119487881426822908"),
 		[]*big.Int{
@@ -163,6 +165,7 @@ func TestGetDyUnderlying(t *testing.T) {
 		big.NewInt(200000),
 		1620408998,
 		big.NewInt(100000),
+		1621013782,
 		big.NewInt(3000000),
 		big.NewInt(20000000000),
 		tokenIndexFrom,
@@ -94,6 +94,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			t.FutureA,
 			t.InitialATime,
 			t.InitialA,
+			param.Now(),
 			t.Info.SwapFee,
 			t.OffpegFeeMultiplier,
 			tokenIndexFrom,
@@ -169,10 +170,10 @@ func (t *PoolSimulator) getDPrecision(xp []*big.Int, a *big.Int) (*big.Int, erro
 	return getD(_xp, a)
 }
 
-func (t *PoolSimulator) AddLiquidity(amounts []*big.Int) (*big.Int, error) {
+func (t *PoolSimulator) AddLiquidity(amounts []*big.Int, timestamp int64) (*big.Int, error) {
 	var nCoins = len(amounts)
 	var nCoinsBi = big.NewInt(int64(nCoins))
-	var amp = _getAPrecise(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA)
+	var amp = _getAPrecise(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA, timestamp)
 	var old_balances = make([]*big.Int, nCoins)
 	for i := 0; i < nCoins; i += 1 {
 		old_balances[i] = t.Info.Reserves[i]
@@ -224,12 +225,13 @@ func (t *PoolSimulator) AddLiquidity(amounts []*big.Int) (*big.Int, error) {
 	return mint_amount, nil
 }
 
-func (t *PoolSimulator) CalculateTokenAmount(amounts []*big.Int, deposit bool) (*big.Int, error) {
+func (t *PoolSimulator) CalculateTokenAmount(amounts []*big.Int, deposit bool, timestamp int64) (*big.Int, error) {
 	return calculateTokenAmount(
 		t.Info.Reserves,
 		t.Multipliers,
 		t.FutureATime, t.FutureA,
 		t.InitialATime, t.InitialA,
+		timestamp,
 		bignumber.ZeroBI, // withdraw fee not used in deposit case
 		t.LpSupply,
 		amounts,
@@ -237,12 +239,13 @@ func (t *PoolSimulator) CalculateTokenAmount(amounts []*big.Int, deposit bool) (
 	)
 }
 
-func (t *PoolSimulator) CalculateWithdrawOneCoin(tokenAmount *big.Int, i int) (*big.Int, *big.Int, error) {
+func (t *PoolSimulator) CalculateWithdrawOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, *big.Int, error) {
 	return calculateWithdrawOneTokenDy(
 		t.Info.Reserves,
 		t.Multipliers,
 		t.FutureATime, t.FutureA,
 		t.InitialATime, t.InitialA,
+		timestamp,
 		t.Info.SwapFee,
 		t.LpSupply,
 		i,
@@ -250,8 +253,8 @@ func (t *PoolSimulator) CalculateWithdrawOneCoin(tokenAmount *big.Int, i int) (*
 	)
 }
 
-func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*big.Int, error) {
-	var dy, dy_fee, err = t.CalculateWithdrawOneCoin(tokenAmount, i)
+func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, error) {
+	var dy, dy_fee, err = t.CalculateWithdrawOneCoin(tokenAmount, i, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -263,7 +266,7 @@ func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*bi
 	return dy, nil
 }
 
-func (t *PoolSimulator) GetDy(i int, j int, dx *big.Int, dCached *big.Int) (*big.Int, *big.Int, error) {
+func (t *PoolSimulator) GetDy(i int, j int, dx *big.Int, dCached *big.Int, timestamp int64) (*big.Int, *big.Int, error) {
 	var nTokens = len(t.Info.Tokens)
 	xp := make([]*big.Int, nTokens)
 	for _i := 0; _i < nTokens; _i += 1 {
@@ -274,7 +277,7 @@ func (t *PoolSimulator) GetDy(i int, j int, dx *big.Int, dCached *big.Int) (*big
 	var x = new(big.Int).Add(xp[i], new(big.Int).Mul(dx, t.Multipliers[i]))
 
 	// y: uint256 = self.get_y(i, j, x, xp)
-	var y, err = getY(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA, i, j, x, xp, dCached)
+	var y, err = getY(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA, timestamp, i, j, x, xp, dCached)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -298,8 +301,8 @@ func (t *PoolSimulator) GetDy(i int, j int, dx *big.Int, dCached *big.Int) (*big
 	return dy, fee, nil
 }
 
-func (t *PoolSimulator) GetVirtualPrice() (*big.Int, *big.Int, error) {
-	var A = _getAPrecise(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA)
+func (t *PoolSimulator) GetVirtualPrice(timestamp int64) (*big.Int, *big.Int, error) {
+	var A = _getAPrecise(t.FutureATime, t.FutureA, t.InitialATime, t.InitialA, timestamp)
 	D, err := t.getDPrecision(t.Info.Reserves, A)
 	if err != nil {
 		return nil, nil, err
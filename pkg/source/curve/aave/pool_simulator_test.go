@@ -94,7 +94,7 @@ func TestAddLiquidity(t *testing.T) {
 
 	for idx, tc := range testcases {
 		t.Run(fmt.Sprintf("test %d", idx), func(t *testing.T) {
-			res, err := p.AddLiquidity(lo.Map(tc.amounts, func(s string, _ int) *big.Int { return utils.NewBig10(s) }))
+			res, err := p.AddLiquidity(lo.Map(tc.amounts, func(s string, _ int) *big.Int { return utils.NewBig10(s) }), 0)
 			require.Nil(t, err)
 			assert.Equal(t, utils.NewBig10(tc.expectedLp), res)
 			fmt.Println(p.Info.Reserves)
@@ -130,14 +130,14 @@ func TestGetDyVirtualPrice(t *testing.T) {
 	})
 	require.Nil(t, err)
 
-	v, dCached, err := p.GetVirtualPrice()
+	v, dCached, err := p.GetVirtualPrice(0)
 	require.Nil(t, err)
 	assert.Equal(t, utils.NewBig10("1077638023314146944"), v)
 
 	for idx, tc := range testcases {
 		t.Run(fmt.Sprintf("test %d", idx), func(t *testing.T) {
 			dy, err := testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, utils.NewBig10(tc.dx), nil)
+				dy, _, err := p.GetDy(tc.i, tc.j, utils.NewBig10(tc.dx), nil, 0)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -145,7 +145,7 @@ func TestGetDyVirtualPrice(t *testing.T) {
 
 			// test using cached D
 			dy, err = testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, utils.NewBig10(tc.dx), dCached)
+				dy, _, err := p.GetDy(tc.i, tc.j, utils.NewBig10(tc.dx), dCached, 0)
 				return dy, err
 			})
 			require.Nil(t, err)
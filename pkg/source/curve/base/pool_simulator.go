@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/goccy/go-json"
 
@@ -106,6 +107,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			tokenIndexTo,
 			tokenAmountIn.Amount,
 			nil,
+			param.Now(),
 		)
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
@@ -128,6 +130,25 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 	return &pool.CalcAmountOutResult{}, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", tokenIndexFrom, tokenIndexTo)
 }
 
+// SpotPrice returns the marginal rate of the invariant at the current balances, net of the swap fee.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	var i, j = t.Info.GetTokenIndex(tokenIn), t.Info.GetTokenIndex(tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, fmt.Errorf("tokenIndexFrom %v or TokenOutIndex %v is not correct", i, j)
+	}
+
+	spotPrice, err := t.getMarginalRate(i, j, t._xp(), time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	// dy = dy_xp * (1 - fee) * PRECISION / rates[j], with dx_xp = dx * rates[i] / PRECISION
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(new(big.Int).Sub(FeeDenominator, t.Info.SwapFee)))
+	spotPrice.Quo(spotPrice, new(big.Float).SetInt(FeeDenominator))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(t.Rates[i]))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(t.Rates[j])), nil
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
 	var inputAmount = input.Amount
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math/big"
 	"testing"
-	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/stretchr/testify/assert"
@@ -68,10 +67,25 @@ func TestCalcAmountOut(t *testing.T) {
 	}
 }
 
+func TestSpotPrice(t *testing.T) {
+	p, err := NewPoolSimulator(entity.Pool{
+		Reserves: entity.PoolReserves{"101940884000000000000000000", "107546110000000000000000000",
+			"208092128367874420986000000"},
+		Tokens: []*entity.PoolToken{{Address: "A"}, {Address: "B"}},
+		Extra: fmt.Sprintf("{\"swapFee\": \"%v\", \"adminFee\": \"%v\", \"initialA\": \"%v\", \"futureA\": \"%v\"}",
+			"3000000", "5000000000", 150000, 150000),
+		StaticExtra: fmt.Sprintf("{\"lpToken\": \"LP\", \"aPrecision\": \"%v\", \"precisionMultipliers\": [\"%v\", \"%v\"], \"rates\": [\"%v\", \"%v\"]}",
+			"100", "1", "1", "1000000000000000000", "1000000000000000000"),
+	})
+	require.Nil(t, err)
+
+	testutil.TestSpotPrice(t, p, 1e-6)
+}
+
 func TestCalcAmountOut_interpolate_from_initialA_and_futureA(t *testing.T) {
 	// if A is getting ramped up then it should interpolate A correctly
 	// 100k at zero to 200k at now*2, so now should be 150k, so the same as the contract above -> get expected output from contract get_dy
-	now := time.Now().Unix()
+	now := int64(1705393976)
 	p, err := NewPoolSimulator(entity.Pool{
 		Exchange: "",
 		Type:     "",
@@ -94,6 +108,7 @@ func TestCalcAmountOut_interpolate_from_initialA_and_futureA(t *testing.T) {
 			TokenAmountIn: pool.TokenAmount{Token: "A", Amount: big.NewInt(510000)},
 			TokenOut:      "B",
 			Limit:         nil,
+			Timestamp:     now,
 		})
 	})
 	require.Nil(t, err)
@@ -166,14 +181,14 @@ func TestGetDyVirtualPrice(t *testing.T) {
 	p, err := NewPoolSimulator(poolEntity)
 	require.Nil(t, err)
 
-	v, dCached, err := p.GetVirtualPrice()
+	v, dCached, err := p.GetVirtualPrice(poolEntity.Timestamp)
 	require.Nil(t, err)
 	assert.Equal(t, bignumber.NewBig10("1006923185919753102"), v)
 
 	for idx, tc := range testcases {
 		t.Run(fmt.Sprintf("test %d", idx), func(t *testing.T) {
 			dy, err := testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -181,7 +196,7 @@ func TestGetDyVirtualPrice(t *testing.T) {
 
 			// test using cached D
 			dy, err = testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
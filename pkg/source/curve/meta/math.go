@@ -2,7 +2,6 @@ package meta
 
 import (
 	"math/big"
-	"time"
 
 	constant "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
@@ -67,10 +66,10 @@ func (t *PoolSimulator) _get_D(xp []*big.Int, a *big.Int) (*big.Int, error) {
 //	return t._get_D(xp, amp)
 //}
 
-func (t *PoolSimulator) _A() *big.Int {
+func (t *PoolSimulator) _A(timestamp int64) *big.Int {
 	var t1 = t.FutureATime
 	var a1 = t.FutureA
-	var now = time.Now().Unix()
+	var now = timestamp
 	if t1 > now {
 		var t0 = t.InitialATime
 		var a0 = t.InitialA
@@ -101,13 +100,13 @@ func (t *PoolSimulator) _A() *big.Int {
 	return a1
 }
 
-func (t *PoolSimulator) A() *big.Int {
-	var a = t._A()
+func (t *PoolSimulator) A(timestamp int64) *big.Int {
+	var a = t._A(timestamp)
 	return new(big.Int).Div(a, t.APrecision)
 }
 
-func (t *PoolSimulator) APrecise() *big.Int {
-	return t._A()
+func (t *PoolSimulator) APrecise(timestamp int64) *big.Int {
+	return t._A(timestamp)
 }
 
 func (t *PoolSimulator) _get_y(
@@ -115,6 +114,7 @@ func (t *PoolSimulator) _get_y(
 	j int,
 	x *big.Int,
 	xp []*big.Int,
+	timestamp int64,
 ) (*big.Int, error) {
 	var numTokens = len(xp)
 	if i == j {
@@ -124,7 +124,7 @@ func (t *PoolSimulator) _get_y(
 		return nil, ErrTokenIndexesOutOfRange
 	}
 	var nCoins = big.NewInt(int64(numTokens))
-	var a = t._A()
+	var a = t._A(timestamp)
 	var d, err = t._get_D(xp, a)
 	if err != nil {
 		return nil, err
@@ -170,8 +170,8 @@ func (t *PoolSimulator) _get_y(
 	return nil, ErrAmountOutNotConverge
 }
 
-func (t *PoolSimulator) _get_dy_mem(i int, j int, _dx *big.Int, _balances []*big.Int) (*big.Int, *big.Int, error) {
-	vPrice, _, err := t.BasePool.GetVirtualPrice()
+func (t *PoolSimulator) _get_dy_mem(i int, j int, _dx *big.Int, _balances []*big.Int, timestamp int64) (*big.Int, *big.Int, error) {
+	vPrice, _, err := t.BasePool.GetVirtualPrice(timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -181,7 +181,7 @@ func (t *PoolSimulator) _get_dy_mem(i int, j int, _dx *big.Int, _balances []*big
 		return nil, nil, err
 	}
 	var x = new(big.Int).Add(xp[i], new(big.Int).Div(new(big.Int).Mul(_dx, rates[i]), Precision))
-	y, err := t._get_y(i, j, x, xp)
+	y, err := t._get_y(i, j, x, xp, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -195,15 +195,16 @@ func (t *PoolSimulator) GetDy(
 	i int,
 	j int,
 	dx *big.Int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
-	return t._get_dy_mem(i, j, dx, t.Info.Reserves)
+	return t._get_dy_mem(i, j, dx, t.Info.Reserves, timestamp)
 }
 
-func (t *PoolSimulator) GetDyUnderlying(i int, j int, _dx *big.Int) (*big.Int, *big.Int, error) {
+func (t *PoolSimulator) GetDyUnderlying(i int, j int, _dx *big.Int, timestamp int64) (*big.Int, *big.Int, error) {
 	var nCoins = len(t.Info.Tokens)
 	var maxCoin = nCoins - 1
 	var baseNCoins = len(t.BasePool.GetInfo().Tokens)
-	vPrice, D, err := t.BasePool.GetVirtualPrice()
+	vPrice, D, err := t.BasePool.GetVirtualPrice(timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -233,7 +234,7 @@ func (t *PoolSimulator) GetDyUnderlying(i int, j int, _dx *big.Int) (*big.Int, *
 				base_inputs[k] = constant.ZeroBI
 			}
 			base_inputs[base_i] = _dx
-			var temp, err = t.BasePool.CalculateTokenAmount(base_inputs, true)
+			var temp, err = t.BasePool.CalculateTokenAmount(base_inputs, true, timestamp)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -241,10 +242,10 @@ func (t *PoolSimulator) GetDyUnderlying(i int, j int, _dx *big.Int) (*big.Int, *
 			x = new(big.Int).Sub(x, new(big.Int).Div(new(big.Int).Mul(x, t.BasePool.GetInfo().SwapFee), new(big.Int).Mul(constant.Two, FeeDenominator)))
 			x = new(big.Int).Add(x, xp[maxCoin])
 		} else {
-			return t.BasePool.GetDy(base_i, base_j, _dx, D)
+			return t.BasePool.GetDy(base_i, base_j, _dx, D, timestamp)
 		}
 	}
-	y, err := t._get_y(meta_i, meta_j, x, xp)
+	y, err := t._get_y(meta_i, meta_j, x, xp, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -257,14 +258,14 @@ func (t *PoolSimulator) GetDyUnderlying(i int, j int, _dx *big.Int) (*big.Int, *
 		dy = new(big.Int).Div(new(big.Int).Mul(dy, Precision), rates[j])
 		dy_fee = new(big.Int).Div(new(big.Int).Mul(dy_fee, Precision), rates[j])
 	} else {
-		dy, dy_fee, err = t.BasePool.CalculateWithdrawOneCoin(new(big.Int).Div(new(big.Int).Mul(dy, Precision), rates[maxCoin]), base_j)
+		dy, dy_fee, err = t.BasePool.CalculateWithdrawOneCoin(new(big.Int).Div(new(big.Int).Mul(dy, Precision), rates[maxCoin]), base_j, timestamp)
 	}
 	return dy, dy_fee, err
 }
 
-func (t *PoolSimulator) Exchange(i int, j int, dx *big.Int) (*big.Int, error) {
+func (t *PoolSimulator) Exchange(i int, j int, dx *big.Int, timestamp int64) (*big.Int, error) {
 	var nCoins = len(t.Info.Tokens)
-	vPrice, _, err := t.BasePool.GetVirtualPrice()
+	vPrice, _, err := t.BasePool.GetVirtualPrice(timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -281,7 +282,7 @@ func (t *PoolSimulator) Exchange(i int, j int, dx *big.Int) (*big.Int, error) {
 		return nil, err
 	}
 	var x = new(big.Int).Add(xp[i], new(big.Int).Div(new(big.Int).Mul(dx, rates[i]), Precision))
-	y, err := t._get_y(i, j, x, xp)
+	y, err := t._get_y(i, j, x, xp, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -295,11 +296,11 @@ func (t *PoolSimulator) Exchange(i int, j int, dx *big.Int) (*big.Int, error) {
 	return dy, nil
 }
 
-func (t *PoolSimulator) ExchangeUnderlying(i int, j int, dx *big.Int) (*big.Int, error) {
+func (t *PoolSimulator) ExchangeUnderlying(i int, j int, dx *big.Int, timestamp int64) (*big.Int, error) {
 	var nCoins = len(t.Info.Tokens)
 	var maxCoins = nCoins - 1
 	var baseNCoins = len(t.BasePool.GetInfo().Tokens)
-	vPrice, _, err := t.BasePool.GetVirtualPrice()
+	vPrice, _, err := t.BasePool.GetVirtualPrice(timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -336,7 +337,7 @@ func (t *PoolSimulator) ExchangeUnderlying(i int, j int, dx *big.Int) (*big.Int,
 				base_inputs[k] = constant.ZeroBI
 			}
 			base_inputs[base_i] = dx
-			var temp, err = t.BasePool.AddLiquidity(base_inputs)
+			var temp, err = t.BasePool.AddLiquidity(base_inputs, timestamp)
 			if err != nil {
 				return nil, err
 			}
@@ -344,7 +345,7 @@ func (t *PoolSimulator) ExchangeUnderlying(i int, j int, dx *big.Int) (*big.Int,
 			x = new(big.Int).Div(new(big.Int).Mul(dx, rates[maxCoins]), Precision)
 			x = new(big.Int).Add(x, xp[maxCoins])
 		}
-		y, err := t._get_y(meta_i, meta_j, x, xp)
+		y, err := t._get_y(meta_i, meta_j, x, xp, timestamp)
 		if err != nil {
 			return nil, err
 		}
@@ -357,7 +358,7 @@ func (t *PoolSimulator) ExchangeUnderlying(i int, j int, dx *big.Int) (*big.Int,
 		t.Info.Reserves[meta_j] = new(big.Int).Sub(new(big.Int).Sub(old_balances[meta_j], dy), dy_admin_fee)
 
 		if base_j >= 0 {
-			return t.BasePool.RemoveLiquidityOneCoin(dy, base_j)
+			return t.BasePool.RemoveLiquidityOneCoin(dy, base_j, timestamp)
 		}
 	} else {
 		return nil, ErrBasePoolExchangeNotSupported
@@ -25,13 +25,13 @@ type ICurveBasePool interface {
 	GetInfo() pool.PoolInfo
 	GetTokenIndex(address string) int
 	// GetVirtualPrice returns both vPrice and D
-	GetVirtualPrice() (vPrice *big.Int, D *big.Int, err error)
+	GetVirtualPrice(timestamp int64) (vPrice *big.Int, D *big.Int, err error)
 	// GetDy recalculates `dCached` if it is nil
-	GetDy(i int, j int, dx *big.Int, dCached *big.Int) (*big.Int, *big.Int, error)
-	CalculateTokenAmount(amounts []*big.Int, deposit bool) (*big.Int, error)
-	CalculateWithdrawOneCoin(tokenAmount *big.Int, i int) (*big.Int, *big.Int, error)
-	AddLiquidity(amounts []*big.Int) (*big.Int, error)
-	RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*big.Int, error)
+	GetDy(i int, j int, dx *big.Int, dCached *big.Int, timestamp int64) (*big.Int, *big.Int, error)
+	CalculateTokenAmount(amounts []*big.Int, deposit bool, timestamp int64) (*big.Int, error)
+	CalculateWithdrawOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, *big.Int, error)
+	AddLiquidity(amounts []*big.Int, timestamp int64) (*big.Int, error)
+	RemoveLiquidityOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, error)
 }
 
 type PoolSimulator struct {
@@ -127,6 +127,7 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 			tokenIndexFrom,
 			tokenIndexTo,
 			tokenAmountIn.Amount,
+			param.Now(),
 		)
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
@@ -160,7 +161,8 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		amountOut, fee, err := t.GetDyUnderlying(
 			tokenIndexFrom,
 			tokenIndexTo,
-			tokenAmountIn.Amount)
+			tokenAmountIn.Amount,
+			param.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
@@ -191,7 +193,7 @@ func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	var outputIndex = t.GetTokenIndex(output.Token)
 	if inputIndex >= 0 && outputIndex >= 0 {
 		// exchange
-		_, _ = t.Exchange(inputIndex, outputIndex, inputAmount)
+		_, _ = t.Exchange(inputIndex, outputIndex, inputAmount, params.Now())
 		return
 	}
 	// check exchange_underlying
@@ -206,7 +208,7 @@ func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	}
 	if inputIndex >= 0 && outputIndex >= 0 {
 		// exchange_underlying
-		_, _ = t.ExchangeUnderlying(inputIndex, outputIndex, inputAmount)
+		_, _ = t.ExchangeUnderlying(inputIndex, outputIndex, inputAmount, params.Now())
 	}
 }
 
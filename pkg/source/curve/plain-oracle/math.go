@@ -2,7 +2,6 @@ package plainoracle
 
 import (
 	"math/big"
-	"time"
 
 	constant "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
@@ -43,10 +42,10 @@ func (t *PoolSimulator) get_D_mem(balances []*big.Int, amp *big.Int) (*big.Int,
 	return t.getD(xp, amp)
 }
 
-func (t *PoolSimulator) _A() *big.Int {
+func (t *PoolSimulator) _A(timestamp int64) *big.Int {
 	var t1 = t.FutureATime
 	var a1 = t.FutureA
-	var now = time.Now().Unix()
+	var now = timestamp
 	if t1 > now {
 		var t0 = t.InitialATime
 		var a0 = t.InitialA
@@ -125,6 +124,7 @@ func (t *PoolSimulator) getY(
 	x *big.Int,
 	xp []*big.Int,
 	dCached *big.Int,
+	timestamp int64,
 ) (*big.Int, error) {
 	var numTokens = len(xp)
 	if tokenIndexFrom == tokenIndexTo {
@@ -134,7 +134,7 @@ func (t *PoolSimulator) getY(
 		return nil, ErrTokenIndexesOutOfRange
 	}
 	var numTokensBI = big.NewInt(int64(numTokens))
-	var a = t._A()
+	var a = t._A(timestamp)
 	if a == nil {
 		return nil, ErrInvalidAValue
 	}
@@ -199,13 +199,14 @@ func (t *PoolSimulator) GetDy(
 	j int,
 	dx *big.Int,
 	dCached *big.Int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
 	var xp = t._xp()
 	// x: uint256 = xp[i] + (dx * rates[i] / PRECISION)
 	var x = new(big.Int).Add(xp[i], new(big.Int).Div(new(big.Int).Mul(dx, t.Rates[i]), Precision))
 
 	// y: uint256 = self.get_y(i, j, x, xp)
-	var y, err = t.getY(i, j, x, xp, dCached)
+	var y, err = t.getY(i, j, x, xp, dCached, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -224,6 +225,37 @@ func (t *PoolSimulator) GetDy(
 	return dy, fee, nil
 }
 
+// getMarginalRate returns the marginal rate of xp[j] per xp[i], before fees. Holding D constant, the invariant
+// Ann * S + D = Ann * D + D_P, with D_P = D^(N+1) / (N^N * prod(xp)), gives
+// -dxp[j] / dxp[i] = (Ann + D_P / xp[i]) / (Ann + D_P / xp[j]), Ann being A * N / A_PRECISION.
+func (t *PoolSimulator) getMarginalRate(i int, j int, xp []*big.Int, timestamp int64) (*big.Float, error) {
+	var numTokens = big.NewInt(int64(len(xp)))
+	for _, x := range xp {
+		if x.Sign() <= 0 {
+			return nil, ErrZero
+		}
+	}
+	var a = t._A(timestamp)
+	d, err := t.getD(xp, a)
+	if err != nil {
+		return nil, err
+	}
+
+	dF := new(big.Float).SetInt(d)
+	dP := new(big.Float).Set(dF)
+	for _, x := range xp {
+		dP.Mul(dP, dF)
+		dP.Quo(dP, new(big.Float).SetInt(new(big.Int).Mul(x, numTokens)))
+	}
+
+	ann := new(big.Float).SetInt(new(big.Int).Mul(a, numTokens))
+	ann.Quo(ann, new(big.Float).SetInt(t.APrecision))
+
+	rateI := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[i]))
+	rateJ := new(big.Float).Quo(dP, new(big.Float).SetInt(xp[j]))
+	return rateI.Quo(rateI.Add(rateI, ann), rateJ.Add(rateJ, ann)), nil
+}
+
 func (t *PoolSimulator) getYD(
 	a *big.Int,
 	tokenIndex int,
@@ -285,8 +317,9 @@ func (t *PoolSimulator) getYD(
 func (t *PoolSimulator) CalculateWithdrawOneCoin(
 	tokenAmount *big.Int,
 	i int,
+	timestamp int64,
 ) (*big.Int, *big.Int, error) {
-	var amp = t._A()
+	var amp = t._A(timestamp)
 	var xp = t._xp()
 	D0, err := t.getD(xp, amp)
 	if err != nil {
@@ -324,9 +357,10 @@ func (t *PoolSimulator) CalculateWithdrawOneCoin(
 func (t *PoolSimulator) CalculateTokenAmount(
 	amounts []*big.Int,
 	deposit bool,
+	timestamp int64,
 ) (*big.Int, error) {
 	var numTokens = len(t.Info.Tokens)
-	var a = t._A()
+	var a = t._A(timestamp)
 	d0, err := t.get_D_mem(t.Info.Reserves, a)
 	if err != nil {
 		return nil, err
@@ -356,10 +390,10 @@ func (t *PoolSimulator) CalculateTokenAmount(
 	return new(big.Int).Div(new(big.Int).Mul(diff, totalSupply), d0), nil
 }
 
-func (t *PoolSimulator) AddLiquidity(amounts []*big.Int) (*big.Int, error) {
+func (t *PoolSimulator) AddLiquidity(amounts []*big.Int, timestamp int64) (*big.Int, error) {
 	var nCoins = len(amounts)
 	var nCoinsBi = big.NewInt(int64(nCoins))
-	var amp = t._A()
+	var amp = t._A(timestamp)
 	var old_balances = make([]*big.Int, nCoins)
 	for i := 0; i < nCoins; i += 1 {
 		old_balances[i] = t.Info.Reserves[i]
@@ -410,8 +444,8 @@ func (t *PoolSimulator) AddLiquidity(amounts []*big.Int) (*big.Int, error) {
 	return mint_amount, nil
 }
 
-func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*big.Int, error) {
-	var dy, dy_fee, err = t.CalculateWithdrawOneCoin(tokenAmount, i)
+func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int, timestamp int64) (*big.Int, error) {
+	var dy, dy_fee, err = t.CalculateWithdrawOneCoin(tokenAmount, i, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -420,9 +454,9 @@ func (t *PoolSimulator) RemoveLiquidityOneCoin(tokenAmount *big.Int, i int) (*bi
 	return dy, nil
 }
 
-func (t *PoolSimulator) GetVirtualPrice() (*big.Int, *big.Int, error) {
+func (t *PoolSimulator) GetVirtualPrice(timestamp int64) (*big.Int, *big.Int, error) {
 	var xp = t._xp()
-	var A = t._A()
+	var A = t._A(timestamp)
 	var D, err = t.getD(xp, A)
 	if err != nil {
 		return nil, nil, err
@@ -61,6 +61,8 @@ func TestCalcAmountOut(t *testing.T) {
 			assert.Equal(t, tc.out, out.TokenAmountOut.Token)
 		})
 	}
+
+	testutil.TestSpotPrice(t, p, 1e-6)
 }
 
 func TestGetDyVirtualPrice(t *testing.T) {
@@ -102,14 +104,14 @@ func TestGetDyVirtualPrice(t *testing.T) {
 	p, err := NewPoolSimulator(poolEntity)
 	require.Nil(t, err)
 
-	v, dCached, err := p.GetVirtualPrice()
+	v, dCached, err := p.GetVirtualPrice(poolEntity.Timestamp)
 	require.Nil(t, err)
 	assert.Equal(t, bignumber.NewBig10("1042437950645007280"), v)
 
 	for idx, tc := range testcases {
 		t.Run(fmt.Sprintf("test %d", idx), func(t *testing.T) {
 			dy, err := testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), nil, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -117,7 +119,7 @@ func TestGetDyVirtualPrice(t *testing.T) {
 
 			// test using cached D
 			dy, err = testutil.MustConcurrentSafe(t, func() (*big.Int, error) {
-				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached)
+				dy, _, err := p.GetDy(tc.i, tc.j, bignumber.NewBig10(tc.dx), dCached, poolEntity.Timestamp)
 				return dy, err
 			})
 			require.Nil(t, err)
@@ -54,6 +54,22 @@ func TestCalcAmountOut(t *testing.T) {
 			assert.Equal(t, tc.out, out.TokenAmountOut.Token)
 		})
 	}
+
+	// the 6 and 8 decimals coins make the swaps testutil.TestSpotPrice probes too coarse, probe 1e-6 of the reserves
+	for _, tc := range []struct{ in, out string }{{"A", "B"}, {"A", "C"}, {"B", "A"}, {"B", "C"}, {"C", "A"}, {"C", "B"}} {
+		spotPrice, err := p.SpotPrice(tc.in, tc.out)
+		require.NoError(t, err)
+		amountIn := new(big.Int).Div(p.GetReserves()[p.GetTokenIndex(tc.in)], big.NewInt(1_000_000))
+		out, err := p.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tc.in, Amount: amountIn},
+			TokenOut:      tc.out,
+		})
+		require.NoError(t, err)
+		spot, _ := spotPrice.Float64()
+		rate, _ := new(big.Float).Quo(new(big.Float).SetInt(out.TokenAmountOut.Amount),
+			new(big.Float).SetInt(amountIn)).Float64()
+		assert.InEpsilon(t, spot, rate, 1e-5, "%s -> %s", tc.in, tc.out)
+	}
 }
 
 func TestUpdateBalance(t *testing.T) {
@@ -3,6 +3,7 @@ package two
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"time"
 
@@ -480,6 +481,45 @@ func (t *PoolSimulator) GetDy(i int, j int, dx *big.Int) (*big.Int, *big.Int, er
 	return dy, fee, nil
 }
 
+// getP returns the marginal prices -dxp[0] / dxp[k] of the invariant at xp, for k > 0, as later versions of the pool
+// compute last_prices: p_k = x_0 * (GK0 + NNAG2 * x_k / D * K0) / x_k / (GK0 + NNAG2 * x_0 / D * K0), with
+// K0 = N^N * prod(x) / D^N, GK0 = 2 * K0^3 + (gamma + 1)^2 - K0^2 * (2 * gamma + 3) and NNAG2 = ANN / A_MULTIPLIER *
+// gamma^2, gamma being scaled down to 1.
+func getP(xp []*big.Int, D, ANN, gamma *big.Int) []*big.Float {
+	one := big.NewFloat(1)
+	dF := new(big.Float).SetInt(D)
+	gammaF := new(big.Float).Quo(new(big.Float).SetInt(gamma), new(big.Float).SetInt(Precision))
+
+	k0 := new(big.Float).SetInt64(int64(math.Pow(float64(len(xp)), float64(len(xp)))))
+	for _, x := range xp {
+		k0.Mul(k0, new(big.Float).SetInt(x))
+		k0.Quo(k0, dF)
+	}
+	k02 := new(big.Float).Mul(k0, k0)
+	gk0 := new(big.Float).Mul(big.NewFloat(2), new(big.Float).Mul(k02, k0))
+	gammaPlus1 := new(big.Float).Add(gammaF, one)
+	gk0.Add(gk0, new(big.Float).Mul(gammaPlus1, gammaPlus1))
+	gk0.Sub(gk0, new(big.Float).Mul(k02, new(big.Float).Add(new(big.Float).Mul(big.NewFloat(2), gammaF),
+		big.NewFloat(3))))
+	nnag2 := new(big.Float).Quo(new(big.Float).SetInt(ANN), new(big.Float).SetInt(AMultiplier))
+	nnag2.Mul(nnag2, new(big.Float).Mul(gammaF, gammaF))
+
+	// term returns GK0 + NNAG2 * x / D * K0
+	term := func(x *big.Int) *big.Float {
+		t := new(big.Float).Mul(nnag2, new(big.Float).SetInt(x))
+		t.Quo(t, dF)
+		return t.Add(gk0, t.Mul(t, k0))
+	}
+	denominator := term(xp[0])
+	p := make([]*big.Float, len(xp)-1)
+	for k := 1; k < len(xp); k++ {
+		p[k-1] = new(big.Float).Mul(new(big.Float).SetInt(xp[0]), term(xp[k]))
+		p[k-1].Quo(p[k-1], new(big.Float).SetInt(xp[k]))
+		p[k-1].Quo(p[k-1], denominator)
+	}
+	return p
+}
+
 func (t *PoolSimulator) Exchange(i int, j int, dx *big.Int) (*big.Int, error) {
 	var nCoins = len(t.Info.Tokens)
 	if i == j {
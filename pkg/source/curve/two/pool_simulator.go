@@ -153,6 +153,47 @@ func (t *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 	)
 }
 
+// SpotPrice returns the marginal rate of the invariant at the current balances, computed as the later versions of the
+// pool compute last_prices, net of the dynamic fee.
+func (t *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	var i, j = t.Info.GetTokenIndex(tokenIn), t.Info.GetTokenIndex(tokenOut)
+	if i < 0 || j < 0 || i == j {
+		return nil, fmt.Errorf("tokenIndexFrom %v or tokenIndexTo %v is not correct", i, j)
+	}
+
+	var priceScale = new(big.Int).Mul(t.PriceScalePacked, t.Precisions[1])
+	var xp = []*big.Int{
+		new(big.Int).Mul(t.Info.Reserves[0], t.Precisions[0]),
+		new(big.Int).Div(new(big.Int).Mul(t.Info.Reserves[1], priceScale), Precision),
+	}
+	if xp[0].Sign() <= 0 || xp[1].Sign() <= 0 {
+		return nil, fmt.Errorf("pool has no balance")
+	}
+	var aGamma = t.aGamma()
+	var p = getP(xp, t.D, aGamma[0], aGamma[1])
+
+	// scale converts an amount of the coin k to xp
+	scale := func(k int) *big.Float {
+		if k == 0 {
+			return new(big.Float).SetInt(t.Precisions[0])
+		}
+		return new(big.Float).Quo(new(big.Float).SetInt(priceScale), new(big.Float).SetInt(Precision))
+	}
+	// price returns the price of xp[k] in xp[0]
+	price := func(k int) *big.Float {
+		if k == 0 {
+			return big.NewFloat(1)
+		}
+		return p[k-1]
+	}
+	// dy = dx * scale(i) * price(i) / price(j) / scale(j) * (1 - fee)
+	spotPrice := new(big.Float).Quo(price(i), price(j))
+	spotPrice.Mul(spotPrice, scale(i))
+	spotPrice.Quo(spotPrice, scale(j))
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(new(big.Int).Sub(constant.TenPowInt(10), t.FeeCalc(xp))))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(constant.TenPowInt(10))), nil
+}
+
 func (t *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output := params.TokenAmountIn, params.TokenAmountOut
 	_, _, _, _ = t.Swap(input, output.Token)
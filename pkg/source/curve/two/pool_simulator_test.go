@@ -51,4 +51,6 @@ func TestCalcAmountOut(t *testing.T) {
 			assert.Equal(t, tc.out, out.TokenAmountOut.Token)
 		})
 	}
+
+	testutil.TestSpotPrice(t, p, 1e-6)
 }
@@ -2,7 +2,6 @@ package fxdx
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/KyberNetwork/blockchain-toolkit/integer"
 )
@@ -52,8 +51,8 @@ const (
 	fastPriceFeedMethodGetPriceData                  = "getPriceData"
 )
 
-func (pf *FastPriceFeed) GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int {
-	if new(big.Int).SetInt64(time.Now().Unix()).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.MaxPriceUpdateDelay)) > 0 {
+func (pf *FastPriceFeed) GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int {
+	if big.NewInt(timestamp).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.MaxPriceUpdateDelay)) > 0 {
 		if maximise {
 			return new(big.Int).Div(new(big.Int).Mul(refPrice, new(big.Int).Add(BasisPointsDivisor, pf.SpreadBasisPointsIfChainError)), BasisPointsDivisor)
 		}
@@ -61,7 +60,7 @@ func (pf *FastPriceFeed) GetPrice(token string, refPrice *big.Int, maximise bool
 		return new(big.Int).Div(new(big.Int).Mul(refPrice, new(big.Int).Sub(BasisPointsDivisor, pf.SpreadBasisPointsIfChainError)), BasisPointsDivisor)
 	}
 
-	if new(big.Int).SetInt64(time.Now().Unix()).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.PriceDuration)) > 0 {
+	if big.NewInt(timestamp).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.PriceDuration)) > 0 {
 		if maximise {
 			return new(big.Int).Div(new(big.Int).Mul(refPrice, new(big.Int).Add(BasisPointsDivisor, pf.SpreadBasisPointsIfInactive)), BasisPointsDivisor)
 		}
@@ -76,7 +76,7 @@ func (p *PoolSimulator) CalcAmountOut(
 		tokenAmountIn = param.TokenAmountIn
 		tokenOut      = param.TokenOut
 	)
-	amountOutAfterFees, feeAmount, err := p.getAmountOut(tokenAmountIn.Token, tokenOut, tokenAmountIn.Amount)
+	amountOutAfterFees, feeAmount, err := p.getAmountOut(tokenAmountIn.Token, tokenOut, tokenAmountIn.Amount, param.Now())
 	if err != nil {
 		return &pool.CalcAmountOutResult{}, err
 	}
@@ -108,7 +108,7 @@ func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output, fee := params.TokenAmountIn, params.TokenAmountOut, params.Fee
-	priceIn, err := p.vault.GetMinPrice(input.Token)
+	priceIn, err := p.vault.GetMinPrice(input.Token, params.Now())
 	if err != nil {
 		return
 	}
@@ -154,17 +154,17 @@ func (p *PoolSimulator) CanSwapTo(address string) []string {
 
 func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} { return nil }
 
-func (p *PoolSimulator) getAmountOut(tokenIn string, tokenOut string, amountIn *big.Int) (*big.Int, *big.Int, error) {
+func (p *PoolSimulator) getAmountOut(tokenIn string, tokenOut string, amountIn *big.Int, timestamp int64) (*big.Int, *big.Int, error) {
 	if !p.vault.IsSwapEnabled {
 		return nil, nil, ErrVaultSwapsNotEnabled
 	}
 
-	priceIn, err := p.vault.GetMinPrice(tokenIn)
+	priceIn, err := p.vault.GetMinPrice(tokenIn, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	priceOut, err := p.vault.GetMaxPrice(tokenOut)
+	priceOut, err := p.vault.GetMaxPrice(tokenOut, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
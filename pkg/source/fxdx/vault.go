@@ -75,12 +75,12 @@ const (
 	vaultMethodFeeUtils = "feeUtils"
 )
 
-func (v *Vault) GetMinPrice(token string) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, v.UseSwapPricing)
+func (v *Vault) GetMinPrice(token string, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, v.UseSwapPricing, timestamp)
 }
 
-func (v *Vault) GetMaxPrice(token string) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, v.UseSwapPricing)
+func (v *Vault) GetMaxPrice(token string, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, v.UseSwapPricing, timestamp)
 }
 
 func (v *Vault) AdjustForDecimals(amount *big.Int, tokenDiv string, tokenMul string) *big.Int {
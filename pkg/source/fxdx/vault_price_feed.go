@@ -84,19 +84,19 @@ const (
 	vaultPriceFeedMethodIsAdjustmentAdditive  = "isAdjustmentAdditive"
 )
 
-func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice bool, _ bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice bool, _ bool, timestamp int64) (*big.Int, error) {
 	var (
 		price *big.Int
 		err   error
 	)
 
 	if pf.UseV2Pricing {
-		price, err = pf.getPriceV2(token, maximise, includeAmmPrice)
+		price, err = pf.getPriceV2(token, maximise, includeAmmPrice, timestamp)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		price, err = pf.getPriceV1(token, maximise, includeAmmPrice)
+		price, err = pf.getPriceV1(token, maximise, includeAmmPrice, timestamp)
 		if err != nil {
 			return nil, err
 		}
@@ -129,7 +129,7 @@ func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice
 	return price, nil
 }
 
-func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPrice bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPrice bool, timestamp int64) (*big.Int, error) {
 	price, err := pf.getPrimaryPrice(token, maximise)
 	if err != nil {
 		return nil, err
@@ -148,7 +148,7 @@ func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPric
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -195,7 +195,7 @@ func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPric
 	), nil
 }
 
-func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPrice bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPrice bool, timestamp int64) (*big.Int, error) {
 	price, err := pf.getPrimaryPrice(token, maximise)
 	if err != nil {
 		return nil, err
@@ -206,7 +206,7 @@ func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPric
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -253,12 +253,12 @@ func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPric
 	), nil
 }
 
-func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool) *big.Int {
+func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool, timestamp int64) *big.Int {
 	if pf.SecondaryPriceFeed == nil {
 		return referencePrice
 	}
 
-	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise)
+	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise, timestamp)
 }
 
 func (pf *VaultPriceFeed) getAmmPriceV2(token string, maximise bool, primaryPrice *big.Int) *big.Int {
@@ -49,7 +49,7 @@ type IPancakePairReader interface {
 
 type IFastPriceFeed interface {
 	GetVersion() int
-	GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int
+	GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int
 }
 
 type IStrategy interface {
@@ -78,21 +78,21 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 	swapInfo := &gmxGlpSwapInfo{yearnTokenVaultModified: &YearnTokenVault{}}
 
 	if strings.EqualFold(tokenOut, p.yearnTokenVault.Address) {
-		amountOut, err = p.MintAndStakeGlp(swapInfo, tokenAmountIn.Token, tokenAmountIn.Amount)
+		amountOut, err = p.MintAndStakeGlp(swapInfo, tokenAmountIn.Token, tokenAmountIn.Amount, param.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
-		amountOut, err = p.yearnTokenVault.Deposit(amountOut)
+		amountOut, err = p.yearnTokenVault.Deposit(amountOut, param.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
 		swapInfo.calcAmountOutType = calcAmountOutTypeStake
 	} else if strings.EqualFold(tokenAmountIn.Token, p.yearnTokenVault.Address) {
-		amountOut, err = p.yearnTokenVault.Withdraw(tokenAmountIn.Amount, swapInfo.yearnTokenVaultModified)
+		amountOut, err = p.yearnTokenVault.Withdraw(tokenAmountIn.Amount, swapInfo.yearnTokenVaultModified, param.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
-		amountOut, err = p.UnstakeAndRedeemGlp(swapInfo, tokenOut, amountOut)
+		amountOut, err = p.UnstakeAndRedeemGlp(swapInfo, tokenOut, amountOut, param.Now())
 		if err != nil {
 			return &pool.CalcAmountOutResult{}, err
 		}
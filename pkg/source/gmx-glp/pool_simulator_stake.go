@@ -6,11 +6,11 @@ import (
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
-func (p *PoolSimulator) MintAndStakeGlp(swapInfo *gmxGlpSwapInfo, tokenIn string, amount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) MintAndStakeGlp(swapInfo *gmxGlpSwapInfo, tokenIn string, amount *big.Int, timestamp int64) (*big.Int, error) {
 	if amount.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrRewardRouterInvalidAmount
 	}
-	glpAmount, err := p.addLiquidityForAccount(swapInfo, tokenIn, amount)
+	glpAmount, err := p.addLiquidityForAccount(swapInfo, tokenIn, amount, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -18,7 +18,7 @@ func (p *PoolSimulator) MintAndStakeGlp(swapInfo *gmxGlpSwapInfo, tokenIn string
 	return glpAmount, nil
 }
 
-func (p *PoolSimulator) addLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenIn string, amount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) addLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenIn string, amount *big.Int, timestamp int64) (*big.Int, error) {
 	// _addLiquidity
 	if amount.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrGlpManagerInvalidAmount
@@ -28,7 +28,7 @@ func (p *PoolSimulator) addLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenIn
 	aumInUsdg := new(big.Int).Set(p.glpManager.MaximiseAumInUsdg)
 	glpSupply := new(big.Int).Set(p.glpManager.GlpTotalSupply)
 
-	usdgAmount, err := p.BuyUSDG(swapInfo, tokenIn, amount)
+	usdgAmount, err := p.BuyUSDG(swapInfo, tokenIn, amount, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +53,7 @@ func (p *PoolSimulator) addLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenIn
 	return mintAmount, nil
 }
 
-func (p *PoolSimulator) BuyUSDG(swapInfo *gmxGlpSwapInfo, token string, tokenAmount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) BuyUSDG(swapInfo *gmxGlpSwapInfo, token string, tokenAmount *big.Int, timestamp int64) (*big.Int, error) {
 	//_validate(whitelistedTokens[_token], 16);  // canSwapTo vaildated it
 	useSwapPricing := true
 
@@ -66,7 +66,7 @@ func (p *PoolSimulator) BuyUSDG(swapInfo *gmxGlpSwapInfo, token string, tokenAmo
 
 	// updateCumulativeFundingRate(_token, _token);
 
-	price, err := p.vault.GetMinPrice(token, useSwapPricing)
+	price, err := p.vault.GetMinPrice(token, useSwapPricing, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -6,12 +6,12 @@ import (
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
 
-func (p *PoolSimulator) UnstakeAndRedeemGlp(swapInfo *gmxGlpSwapInfo, tokenOut string, glpAmount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) UnstakeAndRedeemGlp(swapInfo *gmxGlpSwapInfo, tokenOut string, glpAmount *big.Int, timestamp int64) (*big.Int, error) {
 	if glpAmount.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrRewardRouterInvalidGlpAmount
 	}
 
-	amountOut, err := p.removeLiquidityForAccount(swapInfo, tokenOut, glpAmount)
+	amountOut, err := p.removeLiquidityForAccount(swapInfo, tokenOut, glpAmount, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -19,7 +19,7 @@ func (p *PoolSimulator) UnstakeAndRedeemGlp(swapInfo *gmxGlpSwapInfo, tokenOut s
 	return amountOut, nil
 }
 
-func (p *PoolSimulator) removeLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenOut string, glpAmount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) removeLiquidityForAccount(swapInfo *gmxGlpSwapInfo, tokenOut string, glpAmount *big.Int, timestamp int64) (*big.Int, error) {
 	if glpAmount.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrGlpManagerInvalidAmount
 	}
@@ -43,7 +43,7 @@ func (p *PoolSimulator) removeLiquidityForAccount(swapInfo *gmxGlpSwapInfo, toke
 	//IMintable(glp).burn(_account, _glpAmount);
 	//IERC20(usdg).transfer(address(vault), usdgAmount);
 
-	amountOut, err := p.SellUSDG(swapInfo, tokenOut, usdgAmount)
+	amountOut, err := p.SellUSDG(swapInfo, tokenOut, usdgAmount, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (p *PoolSimulator) removeLiquidityForAccount(swapInfo *gmxGlpSwapInfo, toke
 	return amountOut, nil
 }
 
-func (p *PoolSimulator) SellUSDG(swapInfo *gmxGlpSwapInfo, token string, usdgAmount *big.Int) (*big.Int, error) {
+func (p *PoolSimulator) SellUSDG(swapInfo *gmxGlpSwapInfo, token string, usdgAmount *big.Int, timestamp int64) (*big.Int, error) {
 	//_validate(whitelistedTokens[_token], 19);  // handled at canSwapTo
 	useSwapPricing := true
 
@@ -59,7 +59,7 @@ func (p *PoolSimulator) SellUSDG(swapInfo *gmxGlpSwapInfo, token string, usdgAmo
 		return nil, ErrVaultNegativeUsdgAmount
 	}
 
-	redemptionAmount, err := p.getRedemptionAmount(token, usdgAmount, useSwapPricing)
+	redemptionAmount, err := p.getRedemptionAmount(token, usdgAmount, useSwapPricing, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -88,8 +88,8 @@ func (p *PoolSimulator) SellUSDG(swapInfo *gmxGlpSwapInfo, token string, usdgAmo
 	return amountOut, nil
 }
 
-func (p *PoolSimulator) getRedemptionAmount(token string, usdgAmount *big.Int, useSwapPricing bool) (*big.Int, error) {
-	price, err := p.vault.GetMaxPrice(token, useSwapPricing)
+func (p *PoolSimulator) getRedemptionAmount(token string, usdgAmount *big.Int, useSwapPricing bool, timestamp int64) (*big.Int, error) {
+	price, err := p.vault.GetMaxPrice(token, useSwapPricing, timestamp)
 	if err != nil {
 		return nil, err
 	}
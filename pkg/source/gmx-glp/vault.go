@@ -77,12 +77,12 @@ const (
 	vaultMethodTokenWeights    = "tokenWeights"
 )
 
-func (v *Vault) GetMinPrice(token string, useSwapPricing bool) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, useSwapPricing)
+func (v *Vault) GetMinPrice(token string, useSwapPricing bool, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, useSwapPricing, timestamp)
 }
 
-func (v *Vault) GetMaxPrice(token string, useSwapPricing bool) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, useSwapPricing)
+func (v *Vault) GetMaxPrice(token string, useSwapPricing bool, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, useSwapPricing, timestamp)
 }
 
 func (v *Vault) GetTargetUSDGAmount(token string) *big.Int {
@@ -3,7 +3,6 @@ package gmxglp
 import (
 	"fmt"
 	"math/big"
-	"time"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
@@ -58,7 +57,7 @@ func (y *YearnTokenVault) GetStrategy(address string) (IStrategy, error) {
 	return nil, fmt.Errorf("not found strategy %v", address)
 }
 
-func (y *YearnTokenVault) Deposit(amount *big.Int) (*big.Int, error) {
+func (y *YearnTokenVault) Deposit(amount *big.Int, timestamp int64) (*big.Int, error) {
 	if new(big.Int).Add(y.TotalAsset, amount).Cmp(y.DepositLimit) > 0 {
 		return nil, ErrYearnTokenVaultDepositNotRespected
 	}
@@ -66,16 +65,16 @@ func (y *YearnTokenVault) Deposit(amount *big.Int) (*big.Int, error) {
 		return nil, ErrYearnTokenVaultDepositNothing
 	}
 
-	return y.issueSharesForAmount(amount), nil
+	return y.issueSharesForAmount(amount, timestamp), nil
 }
 
-func (y *YearnTokenVault) Withdraw(maxShares *big.Int, yModified *YearnTokenVault) (*big.Int, error) {
+func (y *YearnTokenVault) Withdraw(maxShares *big.Int, yModified *YearnTokenVault, timestamp int64) (*big.Int, error) {
 	shares := new(big.Int).Set(maxShares)
 	if shares.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrYearnTokenVaultWithdrawNothing
 	}
 
-	value := y.shareValue(shares)
+	value := y.shareValue(shares, timestamp)
 	var vaultBalance *big.Int
 	if yModified.TotalIdle != nil {
 		vaultBalance = new(big.Int).Set(yModified.TotalIdle)
@@ -132,21 +131,21 @@ func (y *YearnTokenVault) Withdraw(maxShares *big.Int, yModified *YearnTokenVaul
 	return value, nil
 }
 
-func (y *YearnTokenVault) issueSharesForAmount(amount *big.Int) *big.Int {
+func (y *YearnTokenVault) issueSharesForAmount(amount *big.Int, timestamp int64) *big.Int {
 	if y.TotalSupply.Cmp(bignumber.ZeroBI) > 0 {
-		return new(big.Int).Div(new(big.Int).Mul(amount, y.TotalSupply), y.freeFund())
+		return new(big.Int).Div(new(big.Int).Mul(amount, y.TotalSupply), y.freeFund(timestamp))
 	}
 
 	return new(big.Int).Set(amount)
 }
 
-func (y *YearnTokenVault) freeFund() *big.Int {
-	lockedProfit := y.calculateLockedProfit()
+func (y *YearnTokenVault) freeFund(timestamp int64) *big.Int {
+	lockedProfit := y.calculateLockedProfit(timestamp)
 	return new(big.Int).Sub(y.TotalAsset, lockedProfit)
 }
 
-func (y *YearnTokenVault) calculateLockedProfit() *big.Int {
-	blockTimestamp := big.NewInt(time.Now().Unix())
+func (y *YearnTokenVault) calculateLockedProfit(timestamp int64) *big.Int {
+	blockTimestamp := big.NewInt(timestamp)
 	lockedFundsRatio := new(big.Int).Mul(
 		new(big.Int).Sub(blockTimestamp, y.LastReport),
 		y.LockedProfitDegradation,
@@ -162,10 +161,10 @@ func (y *YearnTokenVault) calculateLockedProfit() *big.Int {
 	return big.NewInt(0)
 }
 
-func (y *YearnTokenVault) shareValue(shares *big.Int) *big.Int {
+func (y *YearnTokenVault) shareValue(shares *big.Int, timestamp int64) *big.Int {
 	if y.TotalSupply.Cmp(bignumber.ZeroBI) == 0 {
 		return shares
 	}
 
-	return new(big.Int).Div(new(big.Int).Mul(shares, y.freeFund()), y.TotalSupply)
+	return new(big.Int).Div(new(big.Int).Mul(shares, y.freeFund(timestamp)), y.TotalSupply)
 }
@@ -2,7 +2,6 @@ package gmx
 
 import (
 	"math/big"
-	"time"
 )
 
 type FastPriceFeedV1 struct {
@@ -37,8 +36,8 @@ const (
 	fastPriceFeedMethodV1VolBasisPoints            = "volBasisPoints"
 )
 
-func (pf *FastPriceFeedV1) GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int {
-	if time.Now().Unix() > pf.LastUpdatedAt.Int64()+pf.PriceDuration.Int64() {
+func (pf *FastPriceFeedV1) GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int {
+	if timestamp > pf.LastUpdatedAt.Int64()+pf.PriceDuration.Int64() {
 		return refPrice
 	}
 
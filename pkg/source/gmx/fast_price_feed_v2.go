@@ -2,7 +2,6 @@ package gmx
 
 import (
 	"math/big"
-	"time"
 )
 
 type FastPriceFeedV2 struct {
@@ -54,8 +53,8 @@ const (
 	fastPriceFeedMethodV2GetPriceData                  = "getPriceData"
 )
 
-func (pf *FastPriceFeedV2) GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int {
-	if time.Now().Unix() > pf.LastUpdatedAt.Int64()+pf.MaxPriceUpdateDelay.Int64() {
+func (pf *FastPriceFeedV2) GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int {
+	if timestamp > pf.LastUpdatedAt.Int64()+pf.MaxPriceUpdateDelay.Int64() {
 		if maximise {
 			price := new(big.Int).Add(BasisPointsDivisor, pf.SpreadBasisPointsIfChainError)
 			return price.Div(price.Mul(refPrice, price), BasisPointsDivisor)
@@ -65,7 +64,7 @@ func (pf *FastPriceFeedV2) GetPrice(token string, refPrice *big.Int, maximise bo
 		return price.Div(price.Mul(refPrice, price), BasisPointsDivisor)
 	}
 
-	if time.Now().Unix() > pf.LastUpdatedAt.Int64()+pf.PriceDuration.Int64() {
+	if timestamp > pf.LastUpdatedAt.Int64()+pf.PriceDuration.Int64() {
 		if maximise {
 			price := new(big.Int).Add(BasisPointsDivisor, pf.SpreadBasisPointsIfInactive)
 			return price.Div(price.Mul(refPrice, price), BasisPointsDivisor)
@@ -6,3 +6,10 @@ var ErrCannotFulfillAmountIn = errors.New("cannot fulfill amountIn")
 var ErrCannotFulfillAmountOut = errors.New("cannot fulfill amountOut")
 var InvalidSwapInfo = errors.New("invalid swap info")
 var ErrSameSenderMaker = errors.New("swap recipient is the same as order receiver")
+var ErrInvalidContractAddress = errors.New("invalid limit order contract address")
+var ErrInvalidSalt = errors.New("invalid salt")
+var ErrInvalidOrderSignature = errors.New("invalid order signature")
+var ErrInvalidAllowedSenders = errors.New("invalid order allowed senders")
+var ErrMissingChainID = errors.New("missing chain id to verify limit orders")
+var ErrMissingEthClient = errors.New("missing eth client to verify limit orders of maker contracts")
+var ErrNoDeadline = errors.New("context without deadline to verify limit orders of maker contracts")
@@ -0,0 +1,195 @@
+package limitorder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/KyberNetwork/logger"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/samber/lo"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/eth"
+)
+
+const (
+	// EIP-712 domain of the KyberSwap Limit Order contracts
+	limitOrderDomainName    = "Kyber Limit Order"
+	limitOrderDomainVersion = "1"
+)
+
+var (
+	// orderTypeHash is the type hash of orders with a maker token fee paid to a fee recipient.
+	orderTypeHash = crypto.Keccak256Hash([]byte("Order(uint256 salt,address makerAsset,address takerAsset," +
+		"address maker,address receiver,address allowedSender,uint256 makingAmount,uint256 takingAmount," +
+		"address feeRecipient,uint32 makerTokenFeePercent,bytes makerAssetData,bytes takerAssetData," +
+		"bytes getMakerAmount,bytes getTakerAmount,bytes predicate,bytes permit,bytes interaction)"))
+	// orderWithFeeConfigTypeHash is the type hash of orders with the fee packed in a fee config.
+	orderWithFeeConfigTypeHash = crypto.Keccak256Hash([]byte("Order(uint256 salt,address makerAsset," +
+		"address takerAsset,address maker,address receiver,address allowedSender,uint256 makingAmount," +
+		"uint256 takingAmount,uint256 feeConfig,bytes makerAssetData,bytes takerAssetData,bytes getMakerAmount," +
+		"bytes getTakerAmount,bytes predicate,bytes permit,bytes interaction)"))
+)
+
+// orderVerifier recomputes the EIP-712 hash of the orders returned by the order API and checks their maker signature,
+// so that altered orders are not routed.
+type orderVerifier struct {
+	domainSeparator common.Hash
+	caller          ethereum.ContractCaller
+}
+
+func newOrderVerifier(chainID uint64, contractAddress common.Address,
+	caller ethereum.ContractCaller) (*orderVerifier, error) {
+	domainSeparator, err := eth.EIP712DomainSeparator(limitOrderDomainName, limitOrderDomainVersion, chainID,
+		contractAddress)
+	if err != nil {
+		return nil, err
+	}
+	return &orderVerifier{domainSeparator: domainSeparator, caller: caller}, nil
+}
+
+// verifyECDSA checks offline that order was signed by its maker with ECDSA. It fails with ErrInvalidOrderSignature
+// otherwise, the maker being possibly a contract whose signature is checked with verifyERC1271.
+func (v *orderVerifier) verifyECDSA(order *order) error {
+	orderHashes, err := v.hashes(order)
+	if err != nil {
+		return err
+	}
+	maker, signature := common.HexToAddress(order.Maker), common.FromHex(order.Signature)
+	if lo.ContainsBy(orderHashes, func(orderHash common.Hash) bool {
+		return eth.IsValidECDSASignature(maker, orderHash, signature)
+	}) {
+		return nil
+	}
+	return ErrInvalidOrderSignature
+}
+
+// verifyERC1271 checks that the maker contract of order accepts its signature, calling its ERC-1271 isValidSignature.
+func (v *orderVerifier) verifyERC1271(ctx context.Context, order *order) error {
+	orderHashes, err := v.hashes(order)
+	if err != nil {
+		return err
+	}
+	maker, signature := common.HexToAddress(order.Maker), common.FromHex(order.Signature)
+	for _, orderHash := range orderHashes {
+		if ok, err := eth.IsValidERC1271Signature(ctx, v.caller, maker, orderHash, signature); err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+	}
+	return ErrInvalidOrderSignature
+}
+
+// hashes returns the EIP-712 hashes of order as its maker may have signed it. Orders are signed with a single
+// allowedSender, but the order API returns the allowed senders as a comma-separated list, so the order is hashed with
+// each of them, or with the zero address if there is none, and must be signed with one.
+func (v *orderVerifier) hashes(order *order) ([]common.Hash, error) {
+	allowedSenders, err := parseAllowedSenders(order.AllowedSenders)
+	if err != nil {
+		return nil, err
+	}
+	orderHashes := make([]common.Hash, len(allowedSenders))
+	for i, allowedSender := range allowedSenders {
+		if orderHashes[i], err = v.hash(order, allowedSender); err != nil {
+			return nil, err
+		}
+	}
+	return orderHashes, nil
+}
+
+// hash returns the EIP-712 hash of order signed with allowedSender.
+func (v *orderVerifier) hash(order *order, allowedSender common.Address) (common.Hash, error) {
+	salt, ok := new(big.Int).SetString(order.Salt, 10)
+	if !ok {
+		return common.Hash{}, ErrInvalidSalt
+	}
+
+	values := []any{
+		salt,
+		common.HexToAddress(order.MakerAsset),
+		common.HexToAddress(order.TakerAsset),
+		common.HexToAddress(order.Maker),
+		common.HexToAddress(order.Receiver),
+		allowedSender,
+		order.MakingAmount,
+		order.TakingAmount,
+	}
+	typeHash := orderTypeHash
+	if order.FeeConfig != nil {
+		typeHash = orderWithFeeConfigTypeHash
+		values = append(values, order.FeeConfig)
+	} else {
+		values = append(values, common.HexToAddress(order.FeeRecipient), order.MakerTokenFeePercent)
+	}
+	values = append(values,
+		common.FromHex(order.MakerAssetData),
+		common.FromHex(order.TakerAssetData),
+		common.FromHex(order.GetMakerAmount),
+		common.FromHex(order.GetTakerAmount),
+		common.FromHex(order.Predicate),
+		common.FromHex(order.Permit),
+		common.FromHex(order.Interaction),
+	)
+	structHash, err := eth.EIP712StructHash(typeHash, values...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return eth.EIP712Hash(v.domainSeparator, structHash), nil
+}
+
+// parseAllowedSenders parses the comma-separated allowed senders of an order, returning the zero address, which allows
+// any sender, if there is none.
+func parseAllowedSenders(allowedSenders string) ([]common.Address, error) {
+	var addresses []common.Address
+	for _, s := range strings.Split(allowedSenders, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		} else if !common.IsHexAddress(s) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAllowedSenders, s)
+		}
+		addresses = append(addresses, common.HexToAddress(s))
+	}
+	if len(addresses) == 0 {
+		return []common.Address{{}}, nil
+	}
+	return lo.Uniq(addresses), nil
+}
+
+// unverifiedOrders holds the orders of a PoolSimulator built by NewERC1271VerifiedPoolSimulator until their signature
+// is checked through ERC-1271 by VerifyContractSignatures.
+type unverifiedOrders struct {
+	verifier *orderVerifier
+	// all the orders of the pool, in the order of the order API, to route the verified ones in the same order
+	sellOrders, buyOrders []*order
+	// orders whose signature is not a valid ECDSA signature of their maker, to check through ERC-1271
+	pending []*order
+}
+
+// verifyOffline returns the orders whose hash and ECDSA signature are valid, adding those which may be signed by a
+// maker contract to the pending ones if their signature is checked through ERC-1271, and logging the others.
+func (u *unverifiedOrders) verifyOffline(orders []*order) []*order {
+	return lo.Filter(orders, func(order *order, _ int) bool {
+		err := u.verifier.verifyECDSA(order)
+		if errors.Is(err, ErrInvalidOrderSignature) && u.verifier.caller != nil {
+			u.pending = append(u.pending, order)
+			return false
+		} else if err != nil {
+			logSkippedOrder(order, err)
+			return false
+		}
+		return true
+	})
+}
+
+func logSkippedOrder(order *order, err error) {
+	logger.WithFields(logger.Fields{
+		"orderId": order.ID,
+		"maker":   order.Maker,
+		"error":   err,
+	}).Warn("skip limit order failing verification")
+}
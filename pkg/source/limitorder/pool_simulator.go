@@ -1,11 +1,15 @@
 package limitorder
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
 
 	"github.com/KyberNetwork/logger"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
@@ -31,12 +35,106 @@ type (
 		// store min(balance, allowance) for all unique pair of maker:makerAsset in this pool
 		// will be aggregated up by router-service to be a global value for all maker:makerAsset in LO
 		allMakersBalanceAllowance map[makerAndAsset]*big.Int
+
+		// orders of maker contracts to verify with VerifyContractSignatures before they are routed, not encoded
+		unverified *unverifiedOrders `msgpack:"-"`
 	}
 )
 
-var _ = pool.RegisterFactory0(DexTypeLimitOrder, NewPoolSimulator)
+var _ = pool.RegisterFactory(DexTypeLimitOrder, NewVerifiedPoolSimulator)
 
+// NewPoolSimulator returns a PoolSimulator trusting the orders of entityPool as returned by the order API.
 func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
+	return newPoolSimulator(entityPool, nil)
+}
+
+// NewVerifiedPoolSimulator returns a PoolSimulator with only the orders of params.EntityPool signed with ECDSA by
+// their maker for the limit order contract on params.ChainID, checked offline. The other orders, which may be signed
+// by a maker contract, are skipped: callers opt in to route those accepted by their maker contract by building pools
+// with NewERC1271VerifiedPoolSimulator instead.
+func NewVerifiedPoolSimulator(params pool.FactoryParams) (*PoolSimulator, error) {
+	return newVerifiedPoolSimulator(params, nil)
+}
+
+// NewERC1271VerifiedPoolSimulator returns a PoolSimulator verifying orders as NewVerifiedPoolSimulator, except that the
+// orders not signed with ECDSA by their maker are routed once accepted by VerifyContractSignatures, which calls their
+// maker contract with params.EthClient.
+func NewERC1271VerifiedPoolSimulator(params pool.FactoryParams) (*PoolSimulator, error) {
+	if params.EthClient == nil {
+		return nil, ErrMissingEthClient
+	}
+	return newVerifiedPoolSimulator(params, params.EthClient)
+}
+
+// newVerifiedPoolSimulator returns a PoolSimulator with the orders of params.EntityPool verified offline, checking the
+// other orders through ERC-1271 with caller, or skipping them if it is nil.
+func newVerifiedPoolSimulator(params pool.FactoryParams, caller ethereum.ContractCaller) (*PoolSimulator, error) {
+	if params.ChainID == 0 {
+		return nil, ErrMissingChainID
+	}
+	var staticExtra StaticExtra
+	if err := json.Unmarshal([]byte(params.EntityPool.StaticExtra), &staticExtra); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(staticExtra.ContractAddress) {
+		return nil, ErrInvalidContractAddress
+	}
+	verifier, err := newOrderVerifier(uint64(params.ChainID), common.HexToAddress(staticExtra.ContractAddress),
+		caller)
+	if err != nil {
+		return nil, err
+	}
+	return newPoolSimulator(params.EntityPool, verifier)
+}
+
+// VerifyContractSignatures checks through ERC-1271 the signatures of the orders that NewERC1271VerifiedPoolSimulator
+// could not verify offline, routing those accepted by their maker contract and dropping the others. ctx must have a
+// deadline, bounding the calls to the maker contracts. The orders whose check fails stay unrouted, and can be checked
+// again. It must be called before the PoolSimulator is cloned or used to route.
+func (p *PoolSimulator) VerifyContractSignatures(ctx context.Context) error {
+	if p.unverified == nil {
+		return nil
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		return ErrNoDeadline
+	}
+
+	var errs []error
+	p.unverified.pending = lo.Filter(p.unverified.pending, func(order *order, _ int) bool {
+		err := p.unverified.verifier.verifyERC1271(ctx, order)
+		switch {
+		case err == nil:
+			p.ordersMapping[order.ID] = order
+			if order.MakerBalanceAllowance != nil {
+				p.allMakersBalanceAllowance[NewMakerAndAsset(order.Maker, order.MakerAsset)] =
+					order.MakerBalanceAllowance
+			}
+			return false
+		case errors.Is(err, ErrInvalidOrderSignature):
+			logSkippedOrder(order, err)
+			return false
+		default:
+			errs = append(errs, fmt.Errorf("order %d: %w", order.ID, err))
+			return true
+		}
+	})
+
+	// route the orders in the order of the order API
+	routedOrderIDs := func(orders []*order) []int64 {
+		return lo.FilterMap(orders, func(order *order, _ int) (int64, bool) {
+			_, ok := p.ordersMapping[order.ID]
+			return order.ID, ok
+		})
+	}
+	p.sellOrderIDs = routedOrderIDs(p.unverified.sellOrders)
+	p.buyOrderIDs = routedOrderIDs(p.unverified.buyOrders)
+	if len(p.unverified.pending) == 0 {
+		p.unverified = nil
+	}
+	return errors.Join(errs...)
+}
+
+func newPoolSimulator(entityPool entity.Pool, verifier *orderVerifier) (*PoolSimulator, error) {
 	var numTokens = len(entityPool.Tokens)
 	var tokens = make([]string, numTokens)
 	var reserves = make([]*big.Int, numTokens)
@@ -62,6 +160,15 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 	if err != nil {
 		return nil, err
 	}
+	var unverified *unverifiedOrders
+	if verifier != nil {
+		unverified = &unverifiedOrders{verifier: verifier, sellOrders: extra.SellOrders, buyOrders: extra.BuyOrders}
+		extra.BuyOrders = unverified.verifyOffline(extra.BuyOrders)
+		extra.SellOrders = unverified.verifyOffline(extra.SellOrders)
+		if len(unverified.pending) == 0 {
+			unverified = nil
+		}
+	}
 	numOrders := len(extra.BuyOrders) + len(extra.SellOrders)
 	allMakersBalanceAllowance := make(map[makerAndAsset]*big.Int, numOrders)
 	ordersMapping := make(map[int64]*order, numOrders)
@@ -104,6 +211,8 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 		contractAddress: contractAddress,
 
 		allMakersBalanceAllowance: allMakersBalanceAllowance,
+
+		unverified: unverified,
 	}, nil
 }
 
@@ -122,6 +231,8 @@ func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 		}
 		return k, &c
 	})
+	// orders are verified before the pool is cloned
+	cloned.unverified = nil
 	return &cloned
 }
 
@@ -150,7 +261,7 @@ func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 		if params.SwapLimit != nil {
 			_, _, _ = params.SwapLimit.UpdateLimit(
 				NewMakerAndAsset(order.Maker, order.MakerAsset),
-				NewMakerAndAsset(order.Maker, order.TakerAsset),
+				NewMakerAndAsset(order.receiver(), order.TakerAsset),
 				filledMakingAmount,
 				filledTakingAmount,
 			)
@@ -487,6 +598,14 @@ func newFilledOrderInfo(order *order, filledTakingAmount, filledMakingAmount str
 
 // Inventory Limit
 
+// receiver returns the receiver of the taker asset of the order: its Receiver, or its maker if unset.
+func (o *order) receiver() string {
+	if len(o.Receiver) == 0 || strings.EqualFold(o.Receiver, valueobject.ZeroAddress) {
+		return o.Maker
+	}
+	return o.Receiver
+}
+
 type makerAndAsset = string
 
 func NewMakerAndAsset(maker, makerAsset string) makerAndAsset {
@@ -505,6 +624,29 @@ func (p *PoolSimulator) CalculateLimit() map[string]*big.Int {
 	return res
 }
 
+var _ swaplimit.MakerAssetPool = (*PoolSimulator)(nil)
+
+// MakerAssets returns the maker assets of the orders of the pool, spent by the limit order contract, whose balances
+// and allowances swaplimit.MakerBalanceTracker refreshes.
+func (p *PoolSimulator) MakerAssets() []swaplimit.MakerAsset {
+	seen := make(map[string]struct{}, len(p.allMakersBalanceAllowance))
+	res := make([]swaplimit.MakerAsset, 0, len(p.allMakersBalanceAllowance))
+	for _, orderID := range slices.Concat(p.buyOrderIDs, p.sellOrderIDs) {
+		order := p.ordersMapping[orderID]
+		key := swaplimit.MakerAssetKey(order.Maker, order.MakerAsset)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		res = append(res, swaplimit.MakerAsset{
+			Maker:    order.Maker,
+			Asset:    order.MakerAsset,
+			Spenders: []string{p.contractAddress},
+		})
+	}
+	return res
+}
+
 // Inventory is an alias for swaplimit.Inventory
 // Deprecated: directly use swaplimit.Inventory.
 type Inventory = swaplimit.Inventory
@@ -1,10 +1,17 @@
 package limitorder
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/goccy/go-json"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
@@ -1462,3 +1469,162 @@ func TestPool_FilterAllowedSenders(t *testing.T) {
 	})
 	assert.Equal(t, "300", res.TokenAmountOut.Amount.String())
 }
+
+type erc1271Caller struct {
+	contract common.Address
+	err      error
+}
+
+func (c *erc1271Caller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+// CallContract accepts any signature for c.contract, as a multisig maker contract approving all its orders would, or
+// fails with c.err if set.
+func (c *erc1271Caller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	result := make([]byte, common.HashLength)
+	if *msg.To == c.contract {
+		copy(result, msg.Data[:4])
+	}
+	return result, nil
+}
+
+func TestNewVerifiedPoolSimulator(t *testing.T) {
+	contractAddress := common.HexToAddress("0x227b0c196ea8db17a665ea6824d972a64202e936")
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	maker := crypto.PubkeyToAddress(key.PublicKey)
+	makerContract := common.HexToAddress("0x1234")
+	allowedSender := common.HexToAddress("0xf081470f5c6fbccf48cc4e5b82dd926409dcdd67")
+	verifier, err := newOrderVerifier(1, contractAddress, nil)
+	require.NoError(t, err)
+
+	newOrder := func(id int64, maker common.Address, feeConfig *big.Int, allowedSender common.Address) *order {
+		o := &order{
+			ID:                    id,
+			Salt:                  "185786982651412687203851465093295409688",
+			MakerAsset:            "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			TakerAsset:            "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			Maker:                 strings.ToLower(maker.Hex()),
+			Receiver:              strings.ToLower(maker.Hex()),
+			AllowedSenders:        strings.ToLower(allowedSender.Hex()),
+			MakingAmount:          big.NewInt(10000),
+			TakingAmount:          big.NewInt(10100),
+			FeeConfig:             feeConfig,
+			FilledMakingAmount:    big.NewInt(0),
+			FilledTakingAmount:    big.NewInt(0),
+			Predicate:             "0x",
+			Interaction:           "0x",
+			AvailableMakingAmount: big.NewInt(10000),
+			MakerBalanceAllowance: big.NewInt(10000),
+		}
+		orderHash, err := verifier.hash(o, allowedSender)
+		require.NoError(t, err)
+		sig, err := crypto.Sign(orderHash[:], key)
+		require.NoError(t, err)
+		o.Signature = hexutil.Encode(sig)
+		return o
+	}
+
+	valid := newOrder(1, maker, nil, common.Address{})
+	validWithFeeConfig := newOrder(2, maker, big.NewInt(100), common.Address{})
+	tamperedFeeConfig := newOrder(3, maker, big.NewInt(100), common.Address{})
+	tamperedFeeConfig.FeeConfig = big.NewInt(0)
+	tamperedAmount := newOrder(4, maker, nil, common.Address{})
+	tamperedAmount.TakingAmount = big.NewInt(1)
+	contractOrder := newOrder(5, makerContract, nil, common.Address{})
+	// the order API lists the allowed sender the order was signed with among others
+	listedAllowedSender := newOrder(6, maker, nil, allowedSender)
+	listedAllowedSender.AllowedSenders = "0x0000000000000000000000000000000000000001," + listedAllowedSender.AllowedSenders
+	tamperedAllowedSender := newOrder(7, maker, nil, allowedSender)
+	tamperedAllowedSender.AllowedSenders = "0x0000000000000000000000000000000000000001"
+	invalidAllowedSender := newOrder(8, maker, nil, common.Address{})
+	invalidAllowedSender.AllowedSenders = "0x1234,"
+
+	entityPool := entity.Pool{
+		Address:     "limit_order_pool_0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48_0xdac17f958d2ee523a2206206994597c13d831ec7",
+		Exchange:    "kyberswap-limit-order-v2",
+		Type:        DexTypeLimitOrder,
+		Tokens:      []*entity.PoolToken{{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}, {Address: "0xdac17f958d2ee523a2206206994597c13d831ec7"}},
+		Reserves:    entity.PoolReserves{"0", "0"},
+		StaticExtra: `{"ContractAddress":"` + contractAddress.Hex() + `"}`,
+		Extra: marshalPoolExtra(&Extra{
+			SellOrders: []*order{contractOrder, valid, tamperedAmount, listedAllowedSender, tamperedAllowedSender,
+				invalidAllowedSender},
+			BuyOrders: []*order{tamperedFeeConfig, validWithFeeConfig},
+		}),
+	}
+	ethClient := &erc1271Caller{contract: makerContract}
+
+	trusted, err := NewPoolSimulator(entityPool)
+	require.NoError(t, err)
+	assert.Len(t, trusted.ordersMapping, 8)
+
+	t.Run("offline", func(t *testing.T) {
+		// the registered factory verifies orders offline, skipping those not signed with ECDSA
+		poolSim, err := pool.Factory(DexTypeLimitOrder)(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		require.NoError(t, err)
+		verified, ok := poolSim.(*PoolSimulator)
+		require.True(t, ok)
+		assert.Equal(t, []int64{1, 6}, verified.sellOrderIDs)
+		assert.Equal(t, []int64{2}, verified.buyOrderIDs)
+		assert.Len(t, verified.ordersMapping, 3)
+		assert.Nil(t, verified.unverified)
+		assert.NoError(t, verified.VerifyContractSignatures(context.Background()))
+	})
+
+	t.Run("contract signatures", func(t *testing.T) {
+		verified, err := NewERC1271VerifiedPoolSimulator(pool.FactoryParams{
+			EntityPool: entityPool,
+			ChainID:    1,
+			EthClient:  ethClient,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1, 6}, verified.sellOrderIDs)
+		assert.ErrorIs(t, verified.VerifyContractSignatures(context.Background()), ErrNoDeadline)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		require.NoError(t, verified.VerifyContractSignatures(ctx))
+		assert.Equal(t, []int64{5, 1, 6}, verified.sellOrderIDs)
+		assert.Equal(t, []int64{2}, verified.buyOrderIDs)
+		assert.Len(t, verified.ordersMapping, 4)
+		assert.Nil(t, verified.unverified)
+	})
+
+	t.Run("failing contract calls", func(t *testing.T) {
+		failingClient := &erc1271Caller{contract: makerContract, err: context.DeadlineExceeded}
+		verified, err := NewERC1271VerifiedPoolSimulator(pool.FactoryParams{
+			EntityPool: entityPool,
+			ChainID:    1,
+			EthClient:  failingClient,
+		})
+		require.NoError(t, err)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		assert.ErrorIs(t, verified.VerifyContractSignatures(ctx), context.DeadlineExceeded)
+		assert.Equal(t, []int64{1, 6}, verified.sellOrderIDs)
+
+		// the orders whose check failed are checked again
+		failingClient.err = nil
+		require.NoError(t, verified.VerifyContractSignatures(ctx))
+		assert.Equal(t, []int64{5, 1, 6}, verified.sellOrderIDs)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		_, err := NewVerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool})
+		assert.ErrorIs(t, err, ErrMissingChainID)
+		_, err = NewERC1271VerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, EthClient: ethClient})
+		assert.ErrorIs(t, err, ErrMissingChainID)
+		_, err = NewERC1271VerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		assert.ErrorIs(t, err, ErrMissingEthClient)
+
+		entityPool := entityPool
+		entityPool.StaticExtra = `{"ContractAddress":""}`
+		_, err = NewVerifiedPoolSimulator(pool.FactoryParams{EntityPool: entityPool, ChainID: 1})
+		assert.ErrorIs(t, err, ErrInvalidContractAddress)
+	})
+}
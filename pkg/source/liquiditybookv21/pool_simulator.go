@@ -189,6 +189,49 @@ func (p *PoolSimulator) GetMetaInfo(_, _ string) interface{} {
 	return nil
 }
 
+// SpotPrice returns the price of the first bin holding the token out, from the active bin in the swap direction, net
+// of the total fee the swap would pay in it.
+func (p *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if err := p.validateTokens([]string{tokenIn, tokenOut}); err != nil {
+		return nil, err
+	}
+	swapForY := tokenIn == p.Info.Tokens[0]
+
+	params := p.copyParameters().updateReferences(p.blockTimestamp)
+	id := params.ActiveBinID
+	for {
+		binArrIdx, err := p.findBinArrIndex(id)
+		if err != nil {
+			return nil, err
+		}
+		if !p.bins[binArrIdx].isEmptyForSwap(!swapForY) {
+			break
+		}
+		if id, err = p.getNextNonEmptyBin(swapForY, id); err != nil {
+			return nil, ErrNotFoundBinID
+		}
+	}
+	totalFee := params.updateVolatilityAccumulator(id).getTotalFee(p.binStep)
+	if err := verifyFee(totalFee); err != nil {
+		return nil, err
+	}
+	price, err := getPriceFromID(id, p.binStep)
+	if err != nil {
+		return nil, err
+	}
+
+	// price is the 128.128 fixed point price of X in Y
+	spotPrice := new(big.Float).SetInt(price.ToBig())
+	scale := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), scaleOffset))
+	if swapForY {
+		spotPrice.Quo(spotPrice, scale)
+	} else {
+		spotPrice.Quo(scale, spotPrice)
+	}
+	spotPrice.Mul(spotPrice, new(big.Float).SetInt(new(uint256.Int).Sub(precision, totalFee).ToBig()))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetInt(precision.ToBig())), nil
+}
+
 // https://github.com/traderjoe-xyz/joe-v2/blob/main/src/LBPair.sol#L373
 /**
  * @notice Simulates a swap in.
@@ -6,6 +6,7 @@ import (
 
 	"github.com/KyberNetwork/blockchain-toolkit/integer"
 	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
@@ -330,3 +331,50 @@ func TestPoolSimulator_CalcAmountIn(t *testing.T) {
 		})
 	}
 }
+
+func TestPoolSimulator_SpotPrice(t *testing.T) {
+	const (
+		tokenX   = "0xx"
+		tokenY   = "0xy"
+		activeID = realIDShift + 100
+	)
+	reserve := uint256.MustFromDecimal("1000000000000000000000000")
+	// the active bin only holds X, swapping X for Y starts from the bin below it
+	simulator := &PoolSimulator{
+		Pool: pool.Pool{Info: pool.PoolInfo{
+			Tokens:   []string{tokenX, tokenY},
+			Reserves: []*big.Int{new(big.Int).Lsh(reserve.ToBig(), 1), reserve.ToBig()},
+		}},
+		staticFeeParams: staticFeeParams{BaseFactor: 5000},
+		activeBinID:     activeID,
+		binStep:         20,
+		bins: []BinU256{
+			{ID: activeID - 1, ReserveX: new(uint256.Int), ReserveY: reserve},
+			{ID: activeID, ReserveX: reserve, ReserveY: new(uint256.Int)},
+			{ID: activeID + 1, ReserveX: reserve, ReserveY: new(uint256.Int)},
+		},
+	}
+
+	amountIn := big.NewInt(1e18)
+	for _, tc := range []struct {
+		tokenIn, tokenOut string
+		binID             uint32
+	}{
+		{tokenX, tokenY, activeID - 1},
+		{tokenY, tokenX, activeID},
+	} {
+		spotPrice, err := simulator.SpotPrice(tc.tokenIn, tc.tokenOut)
+		assert.NoError(t, err)
+		result, err := simulator.CalcAmountOut(pool.CalcAmountOutParams{
+			TokenAmountIn: pool.TokenAmount{Token: tc.tokenIn, Amount: amountIn},
+			TokenOut:      tc.tokenOut,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, tc.binID, result.SwapInfo.(SwapInfo).NewActiveID)
+
+		spot, _ := spotPrice.Float64()
+		rate, _ := new(big.Float).Quo(new(big.Float).SetInt(result.TokenAmountOut.Amount),
+			new(big.Float).SetInt(amountIn)).Float64()
+		assert.InEpsilon(t, spot, rate, 1e-12)
+	}
+}
@@ -2,7 +2,6 @@ package madmex
 
 import (
 	"math/big"
-	"time"
 
 	constant "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
 )
@@ -39,8 +38,8 @@ const (
 	fastPriceFeedMethodV1VolBasisPoints            = "volBasisPoints"
 )
 
-func (pf *FastPriceFeedV1) GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int {
-	if new(big.Int).SetInt64(time.Now().Unix()).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.PriceDuration)) > 0 {
+func (pf *FastPriceFeedV1) GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int {
+	if big.NewInt(timestamp).Cmp(new(big.Int).Add(pf.LastUpdatedAt, pf.PriceDuration)) > 0 {
 		return refPrice
 	}
 
@@ -76,12 +76,12 @@ const (
 	VaultMethodTokenWeights    = "tokenWeights"
 )
 
-func (v *Vault) GetMinPrice(token string) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, v.UseSwapPricing)
+func (v *Vault) GetMinPrice(token string, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, false, v.IncludeAmmPrice, v.UseSwapPricing, timestamp)
 }
 
-func (v *Vault) GetMaxPrice(token string) (*big.Int, error) {
-	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, v.UseSwapPricing)
+func (v *Vault) GetMaxPrice(token string, timestamp int64) (*big.Int, error) {
+	return v.PriceFeed.GetPrice(token, true, v.IncludeAmmPrice, v.UseSwapPricing, timestamp)
 }
 
 func (v *Vault) GetTargetUSDGAmount(token string) *big.Int {
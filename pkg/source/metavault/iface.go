@@ -39,5 +39,5 @@ type IUSDMReader interface {
 
 type IFastPriceFeed interface {
 	GetVersion() int
-	GetPrice(token string, refPrice *big.Int, maximise bool) *big.Int
+	GetPrice(token string, refPrice *big.Int, maximise bool, timestamp int64) *big.Int
 }
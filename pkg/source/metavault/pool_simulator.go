@@ -69,7 +69,7 @@ func NewPoolSimulator(entityPool entity.Pool) (*PoolSimulator, error) {
 func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
 	tokenAmountIn := param.TokenAmountIn
 	tokenOut := param.TokenOut
-	amountOutAfterFees, feeAmount, err := p.getAmountOut(tokenAmountIn.Token, tokenOut, tokenAmountIn.Amount)
+	amountOutAfterFees, feeAmount, err := p.getAmountOut(tokenAmountIn.Token, tokenOut, tokenAmountIn.Amount, param.Now())
 	if err != nil {
 		return &pool.CalcAmountOutResult{}, err
 	}
@@ -103,7 +103,7 @@ func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 // https://github.com/gmx-io/gmx-contracts/blob/787d767e033c411f6d083f2725fb54b7fa956f7e/contracts/core/Vault.sol#L547-L548
 func (p *PoolSimulator) UpdateBalance(params pool.UpdateBalanceParams) {
 	input, output, fee := params.TokenAmountIn, params.TokenAmountOut, params.Fee
-	priceIn, err := p.vault.GetMinPrice(input.Token)
+	priceIn, err := p.vault.GetMinPrice(input.Token, params.Now())
 	if err != nil {
 		return
 	}
@@ -152,17 +152,17 @@ func (p *PoolSimulator) CanSwapTo(address string) []string {
 func (p *PoolSimulator) GetMetaInfo(_ string, _ string) interface{} { return nil }
 
 // getAmountOut returns amountOutAfterFees, feeAmount and error
-func (p *PoolSimulator) getAmountOut(tokenIn string, tokenOut string, amountIn *big.Int) (*big.Int, *big.Int, error) {
+func (p *PoolSimulator) getAmountOut(tokenIn string, tokenOut string, amountIn *big.Int, timestamp int64) (*big.Int, *big.Int, error) {
 	if !p.vault.IsSwapEnabled {
 		return nil, nil, ErrVaultSwapsNotEnabled
 	}
 
-	priceIn, err := p.vault.GetMinPrice(tokenIn)
+	priceIn, err := p.vault.GetMinPrice(tokenIn, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	priceOut, err := p.vault.GetMaxPrice(tokenOut)
+	priceOut, err := p.vault.GetMaxPrice(tokenOut, timestamp)
 	if err != nil {
 		return nil, nil, err
 	}
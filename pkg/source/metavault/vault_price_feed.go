@@ -116,8 +116,8 @@ func (pf *VaultPriceFeed) UnmarshalJSONSecondaryPriceFeed(data json.RawMessage)
 	return nil
 }
 
-func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool) (*big.Int, error) {
-	price, err := pf.getPriceV1(token, maximise)
+func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool, timestamp int64) (*big.Int, error) {
+	price, err := pf.getPriceV1(token, maximise, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -149,14 +149,14 @@ func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool)
 	return price, nil
 }
 
-func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, timestamp int64) (*big.Int, error) {
 	price, err := pf.getPrimaryPrice(token, maximise)
 	if err != nil {
 		return nil, err
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -254,10 +254,10 @@ func (pf *VaultPriceFeed) getPrimaryPrice(token string, maximise bool) (*big.Int
 	), nil
 }
 
-func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool) *big.Int {
+func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool, timestamp int64) *big.Int {
 	if pf.SecondaryPriceFeed == nil {
 		return referencePrice
 	}
 
-	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise)
+	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise, timestamp)
 }
@@ -0,0 +1,26 @@
+package pool
+
+import "time"
+
+// Now returns the unix timestamp time-dependent simulators must use instead of time.Now: the Timestamp of the
+// params when set, so that quotes can be reproduced as of a given block, and the current time otherwise.
+func (p *CalcAmountOutParams) Now() int64 {
+	return now(p.Timestamp)
+}
+
+// Now returns the unix timestamp to evaluate the swap at, see CalcAmountOutParams.Now.
+func (p *CalcAmountInParams) Now() int64 {
+	return now(p.Timestamp)
+}
+
+// Now returns the unix timestamp the swap happened at, see CalcAmountOutParams.Now.
+func (p *UpdateBalanceParams) Now() int64 {
+	return now(p.Timestamp)
+}
+
+func now(timestamp int64) int64 {
+	if timestamp > 0 {
+		return timestamp
+	}
+	return time.Now().Unix()
+}
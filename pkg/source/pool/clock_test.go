@@ -0,0 +1,19 @@
+package pool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamsNow(t *testing.T) {
+	assert.EqualValues(t, 1700000000, (&CalcAmountOutParams{Timestamp: 1700000000}).Now())
+	assert.EqualValues(t, 1700000000, (&CalcAmountInParams{Timestamp: 1700000000}).Now())
+	assert.EqualValues(t, 1700000000, (&UpdateBalanceParams{Timestamp: 1700000000}).Now())
+
+	before := time.Now().Unix()
+	now := (&CalcAmountOutParams{}).Now()
+	assert.GreaterOrEqual(t, now, before)
+	assert.LessOrEqual(t, now, time.Now().Unix())
+}
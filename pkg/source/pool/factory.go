@@ -22,14 +22,20 @@ var (
 	factoryMap = make(map[string]FactoryFn, 256) // map of pool types to factory functions
 )
 
-// RegisterFactory registers a factory function for a pool type with factoryParams
+// RegisterFactory registers a factory function for a pool type with factoryParams. The pools with tokens having an
+// entity.TransferTax are wrapped with NewTransferTaxPoolSimulator.
 func RegisterFactory[P IPoolSimulator](poolType string, factory func(FactoryParams) (P, error)) bool {
 	if factoryMap[poolType] != nil {
 		panic(poolType + " pool factory already registered")
 	}
 	factoryMap[poolType] = func(factoryParams FactoryParams) (IPoolSimulator, error) {
 		pool, err := factory(factoryParams)
-		return pool, errors.WithMessagef(err, "failed to init pool %s (%s/%s)",
+		if err != nil {
+			return pool, errors.WithMessagef(err, "failed to init pool %s (%s/%s)",
+				factoryParams.EntityPool.Address, factoryParams.EntityPool.Exchange, poolType)
+		}
+		sim, err := NewTransferTaxPoolSimulator(pool, factoryParams.EntityPool.Tokens)
+		return sim, errors.WithMessagef(err, "failed to init pool %s (%s/%s)",
 			factoryParams.EntityPool.Address, factoryParams.EntityPool.Exchange, poolType)
 	}
 	return true
@@ -0,0 +1,174 @@
+// Package gas estimates the cost of swapping through paths, including the L1 data fee charged by rollups for the
+// calldata of the transaction on top of its execution gas.
+package gas
+
+import (
+	"math/big"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+type FeeModel int
+
+const (
+	// FeeModelL1 only charges execution gas.
+	FeeModelL1 FeeModel = iota
+	// FeeModelOPStack charges the Ecotone L1 data fee of the OP Stack GasPriceOracle.
+	FeeModelOPStack
+	// FeeModelArbitrum charges the L1 calldata units priced by ArbGasInfo.
+	FeeModelArbitrum
+	// FeeModelScroll charges the Curie L1 data fee of the Scroll L1GasPriceOracle.
+	FeeModelScroll
+)
+
+var FeeModels = map[valueobject.ChainID]FeeModel{
+	valueobject.ChainIDOptimism:    FeeModelOPStack,
+	valueobject.ChainIDBase:        FeeModelOPStack,
+	valueobject.ChainIDBlast:       FeeModelOPStack,
+	valueobject.ChainIDArbitrumOne: FeeModelArbitrum,
+	valueobject.ChainIDScroll:      FeeModelScroll,
+}
+
+const (
+	// DefaultBaseCalldataBytes estimates the calldata of a router swap without hops: its selector, swap description,
+	// permit and client data.
+	DefaultBaseCalldataBytes = 516
+	// DefaultHopCalldataBytes estimates the calldata encoding a hop of a path: its executor, pool, tokens and extra data.
+	DefaultHopCalldataBytes = 192
+
+	// arbitrumUnitsPerByte is the number of L1 calldata units ArbOS charges per byte of compressed calldata.
+	arbitrumUnitsPerByte = 16
+	// ecotoneScalarDivisor is the divisor of the 16 times scaled Ecotone L1 data fee.
+	ecotoneScalarDivisor = 16 * 1e6
+	// curieScalarDivisor is the precision of the Curie scalars.
+	curieScalarDivisor = 1e9
+)
+
+// Params holds the gas prices of the block the cost is estimated at, as reported by the chain and its fee oracles.
+// Only the fields used by the fee model of the chain need to be set.
+type Params struct {
+	// GasPrice is the price of a unit of execution gas, in wei.
+	GasPrice *big.Int
+	// L1BaseFee and L1BlobBaseFee are the L1 fees known to the L1 fee oracle of OP Stack and Scroll chains, in wei.
+	L1BaseFee     *big.Int
+	L1BlobBaseFee *big.Int
+	// BaseFeeScalar and BlobBaseFeeScalar are the Ecotone scalars of OP Stack chains, or the commit and blob scalars of
+	// Scroll.
+	BaseFeeScalar     uint64
+	BlobBaseFeeScalar uint64
+	// L1PricePerUnit is the price of an L1 calldata unit on Arbitrum, in wei, see ArbGasInfo.getL1BaseFeeEstimate.
+	L1PricePerUnit *big.Int
+}
+
+// Model estimates the cost of swapping through paths on a chain.
+type Model struct {
+	FeeModel          FeeModel
+	BaseCalldataBytes int64
+	HopCalldataBytes  int64
+}
+
+// NewModel returns the model of chainID, estimating calldata with DefaultBaseCalldataBytes and
+// DefaultHopCalldataBytes.
+func NewModel(chainID valueobject.ChainID) *Model {
+	return &Model{
+		FeeModel:          FeeModels[chainID],
+		BaseCalldataBytes: DefaultBaseCalldataBytes,
+		HopCalldataBytes:  DefaultHopCalldataBytes,
+	}
+}
+
+// CalldataBytes estimates the calldata size of a swap through a single path of hops pools.
+func (m *Model) CalldataBytes(hops int) int64 {
+	return m.BaseCalldataBytes + int64(hops)*m.HopCalldataBytes
+}
+
+// L1Fee estimates the L1 data fee of a swap through a single path of hops pools, in wei. Calldata is assumed not to
+// compress, which overestimates the fee of chains compressing it before posting it to L1.
+func (m *Model) L1Fee(hops int, params Params) *big.Int {
+	fee := m.l1Fee(m.BaseCalldataBytes, true, params)
+	return fee.Add(fee, m.l1Fee(int64(hops)*m.HopCalldataBytes, false, params))
+}
+
+// l1Fee estimates the L1 data fee of size bytes of calldata, including the fixed fee of a transaction if tx is set.
+func (m *Model) l1Fee(size int64, tx bool, params Params) *big.Int {
+	switch m.FeeModel {
+	case FeeModelOPStack:
+		// (16 * baseFeeScalar * l1BaseFee + blobBaseFeeScalar * l1BlobBaseFee) * size / (16 * 1e6)
+		fee := new(big.Int).Mul(orZero(params.L1BaseFee), new(big.Int).SetUint64(16*params.BaseFeeScalar))
+		fee.Add(fee, new(big.Int).Mul(orZero(params.L1BlobBaseFee), new(big.Int).SetUint64(params.BlobBaseFeeScalar)))
+		fee.Mul(fee, big.NewInt(size))
+		return fee.Div(fee, big.NewInt(ecotoneScalarDivisor))
+	case FeeModelArbitrum:
+		fee := new(big.Int).Mul(orZero(params.L1PricePerUnit), big.NewInt(size))
+		return fee.Mul(fee, big.NewInt(arbitrumUnitsPerByte))
+	case FeeModelScroll:
+		// (commitScalar * l1BaseFee + blobScalar * size * l1BlobBaseFee) / 1e9, the commit fee being per transaction
+		fee := new(big.Int)
+		if tx {
+			fee.Mul(orZero(params.L1BaseFee), new(big.Int).SetUint64(params.BaseFeeScalar))
+		}
+		blobFee := new(big.Int).Mul(orZero(params.L1BlobBaseFee), new(big.Int).SetUint64(params.BlobBaseFeeScalar))
+		fee.Add(fee, blobFee.Mul(blobFee, big.NewInt(size)))
+		return fee.Div(fee, big.NewInt(curieScalarDivisor))
+	default:
+		return new(big.Int)
+	}
+}
+
+// Cost estimates the cost of a swap through a single path of hops pools using gas units of execution gas, in wei:
+// BaseCost plus PathCost.
+func (m *Model) Cost(gas int64, hops int, params Params) *big.Int {
+	cost := m.BaseCost(params)
+	return cost.Add(cost, m.PathCost(gas, hops, params))
+}
+
+// BaseCost estimates the cost every swap pays once whatever its paths, in wei: the L1 data fee of its
+// BaseCalldataBytes. The execution gas of the router itself is left to the gas of the pools.
+func (m *Model) BaseCost(params Params) *big.Int {
+	return m.l1Fee(m.BaseCalldataBytes, true, params)
+}
+
+// PathCost estimates the cost a path of hops pools using gas units of execution gas adds to a swap, in wei: its
+// execution gas and the L1 data fee of its HopCalldataBytes per hop. A swap split across several paths pays the
+// PathCost of each of them but BaseCost once.
+func (m *Model) PathCost(gas int64, hops int, params Params) *big.Int {
+	cost := new(big.Int).Mul(orZero(params.GasPrice), big.NewInt(gas))
+	return cost.Add(cost, m.l1Fee(int64(hops)*m.HopCalldataBytes, false, params))
+}
+
+// CostInToken converts Cost to token wei at rate, the amount of token wei worth a wei of the native token.
+func (m *Model) CostInToken(gas int64, hops int, params Params, rate *big.Float) *big.Int {
+	return inToken(m.Cost(gas, hops, params), rate)
+}
+
+// CostFunc returns PathCost bound to params and converted to token wei at rate, as expected by
+// pool.SplitAmountOutParams.GasCost and graph.FindPathsOptions.GasCost. BaseCost is left out, as every route pays
+// it once whatever its paths, so that opening another path of a split is only charged what it adds.
+func (m *Model) CostFunc(params Params, rate *big.Float) func(gas int64, hops int) *big.Int {
+	return func(gas int64, hops int) *big.Int {
+		return inToken(m.PathCost(gas, hops, params), rate)
+	}
+}
+
+func inToken(cost *big.Int, rate *big.Float) *big.Int {
+	res, _ := new(big.Float).Mul(new(big.Float).SetInt(cost), rate).Int(nil)
+	return res
+}
+
+// RateFromUsd returns the amount of token wei worth a wei of the native token, given their USD prices.
+func RateFromUsd(nativePriceUsd, tokenPriceUsd float64, tokenDecimals uint8) *big.Float {
+	if tokenPriceUsd <= 0 {
+		return new(big.Float)
+	}
+	rate := big.NewFloat(nativePriceUsd / tokenPriceUsd)
+	rate.Mul(rate, bignumber.TenPowDecimals(tokenDecimals))
+	return rate.Quo(rate, bignumber.TenPowDecimals(18))
+}
+
+func orZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
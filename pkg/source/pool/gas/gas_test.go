@@ -0,0 +1,105 @@
+package gas
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+func TestModel_L1Fee(t *testing.T) {
+	// 516 + 2 * 192 = 900 bytes
+	const hops = 2
+	assert.EqualValues(t, 900, NewModel(valueobject.ChainIDEthereum).CalldataBytes(hops))
+
+	testCases := []struct {
+		name     string
+		chainID  valueobject.ChainID
+		params   Params
+		expected string
+	}{
+		{
+			name:     "l1",
+			chainID:  valueobject.ChainIDEthereum,
+			params:   Params{L1BaseFee: big.NewInt(1e10), L1PricePerUnit: big.NewInt(1e9)},
+			expected: "0",
+		},
+		{
+			name:    "op stack",
+			chainID: valueobject.ChainIDBase,
+			params: Params{
+				L1BaseFee:         big.NewInt(1e10),
+				L1BlobBaseFee:     big.NewInt(1),
+				BaseFeeScalar:     1368,
+				BlobBaseFeeScalar: 810949,
+			},
+			expected: "12312000045", // (16 * 1368 * 1e10 + 810949 * 1) * 900 / 16e6
+		},
+		{
+			name:     "arbitrum",
+			chainID:  valueobject.ChainIDArbitrumOne,
+			params:   Params{L1PricePerUnit: big.NewInt(2e9)},
+			expected: "28800000000000", // 2e9 * 900 * 16
+		},
+		{
+			name:    "scroll",
+			chainID: valueobject.ChainIDScroll,
+			params: Params{
+				L1BaseFee:         big.NewInt(1e10),
+				L1BlobBaseFee:     big.NewInt(1),
+				BaseFeeScalar:     230759955285,
+				BlobBaseFeeScalar: 417565260,
+			},
+			expected: "2307599553225", // (230759955285 * 1e10 + 417565260 * 900 * 1) / 1e9
+		},
+		{
+			name:     "missing params",
+			chainID:  valueobject.ChainIDOptimism,
+			expected: "0",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NewModel(tc.chainID).L1Fee(hops, tc.params).String())
+		})
+	}
+}
+
+func TestModel_PathCost_Scroll(t *testing.T) {
+	model := NewModel(valueobject.ChainIDScroll)
+	params := Params{
+		GasPrice:          big.NewInt(1e6),
+		L1BaseFee:         big.NewInt(1e10),
+		L1BlobBaseFee:     big.NewInt(1),
+		BaseFeeScalar:     230759955285,
+		BlobBaseFeeScalar: 417565260,
+	}
+	// the commit fee is charged once per transaction, not per path
+	// 150000 * 1e6 + 417565260 * 192 * 1 / 1e9
+	assert.Equal(t, "150000000080", model.PathCost(150000, 1, params).String())
+	assert.Equal(t, model.Cost(150000, 1, params),
+		new(big.Int).Add(model.BaseCost(params), model.PathCost(150000, 1, params)))
+}
+
+func TestModel_Cost(t *testing.T) {
+	model := NewModel(valueobject.ChainIDArbitrumOne)
+	params := Params{GasPrice: big.NewInt(1e7), L1PricePerUnit: big.NewInt(2e9)}
+	// 150000 * 1e7 + 2e9 * (516 + 192) * 16
+	assert.Equal(t, "24156000000000", model.Cost(150000, 1, params).String())
+
+	// at 2000 USD per ETH, 2.4156e13 wei are worth 0.048312 USDC
+	rate := RateFromUsd(2000, 1, 6)
+	assert.InDelta(t, 48312, model.CostInToken(150000, 1, params, rate).Int64(), 1)
+
+	// the base calldata is paid once per swap, paths only adding their execution gas and hop calldata
+	// 2e9 * 516 * 16
+	assert.Equal(t, "16512000000000", model.BaseCost(params).String())
+	// 150000 * 1e7 + 2e9 * 192 * 16
+	assert.Equal(t, "7644000000000", model.PathCost(150000, 1, params).String())
+	// 7.644e12 wei are worth 0.015288 USDC
+	assert.InDelta(t, 15288, model.CostFunc(params, rate)(150000, 1).Int64(), 1)
+
+	assert.Zero(t, RateFromUsd(2000, 0, 6).Sign())
+}
@@ -0,0 +1,340 @@
+// Package graph maintains a directed token graph over pool simulators and enumerates candidate paths on it.
+package graph
+
+import (
+	"cmp"
+	"container/heap"
+	"context"
+	"math"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+const (
+	DefaultMaxHops         = 3
+	DefaultMaxPaths        = 10
+	DefaultMaxPoolsPerEdge = 5
+	DefaultMaxCandidates   = 50
+)
+
+type node struct {
+	sim        pool.IPoolSimulator
+	reserveUsd float64
+	edges      [][2]string
+}
+
+// Graph is a directed token graph: there is an edge from tokenIn to tokenOut for every pool able to swap tokenIn to
+// tokenOut according to CanSwapFrom. It is safe for concurrent use, pools being upserted as trackers emit new states.
+type Graph struct {
+	mu    sync.RWMutex
+	pools map[string]*node
+	// edges maps tokenIn then tokenOut to the addresses of the pools swapping them.
+	edges map[string]map[string]map[string]struct{}
+}
+
+func New() *Graph {
+	return &Graph{
+		pools: make(map[string]*node),
+		edges: make(map[string]map[string]map[string]struct{}),
+	}
+}
+
+// Upsert adds a pool to the graph, or replaces it if a pool with the same address is already there. reserveUsd is
+// used to filter and rank paths.
+func (g *Graph) Upsert(sim pool.IPoolSimulator, reserveUsd float64) {
+	address := sim.GetAddress()
+	n := &node{sim: sim, reserveUsd: reserveUsd}
+	for _, tokenIn := range sim.GetTokens() {
+		for _, tokenOut := range sim.CanSwapFrom(tokenIn) {
+			if tokenOut != tokenIn {
+				n.edges = append(n.edges, [2]string{tokenIn, tokenOut})
+			}
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remove(address)
+	g.pools[address] = n
+	for _, edge := range n.edges {
+		outs, ok := g.edges[edge[0]]
+		if !ok {
+			outs = make(map[string]map[string]struct{})
+			g.edges[edge[0]] = outs
+		}
+		pools, ok := outs[edge[1]]
+		if !ok {
+			pools = make(map[string]struct{})
+			outs[edge[1]] = pools
+		}
+		pools[address] = struct{}{}
+	}
+}
+
+// Remove removes a pool from the graph. It is a no-op for unknown pools.
+func (g *Graph) Remove(address string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remove(address)
+}
+
+func (g *Graph) remove(address string) {
+	n, ok := g.pools[address]
+	if !ok {
+		return
+	}
+	delete(g.pools, address)
+	for _, edge := range n.edges {
+		pools := g.edges[edge[0]][edge[1]]
+		delete(pools, address)
+		if len(pools) == 0 {
+			delete(g.edges[edge[0]], edge[1])
+			if len(g.edges[edge[0]]) == 0 {
+				delete(g.edges, edge[0])
+			}
+		}
+	}
+}
+
+// Pool returns the simulator of the pool at address.
+func (g *Graph) Pool(address string) (pool.IPoolSimulator, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	n, ok := g.pools[address]
+	if !ok {
+		return nil, false
+	}
+	return n.sim, true
+}
+
+// Len returns the number of pools in the graph.
+func (g *Graph) Len() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.pools)
+}
+
+// Snapshot returns the simulators of the pools used by paths, keyed by address, as expected by pool.CalcPathAmountOut
+// and pool.SplitAmountOut.
+func (g *Graph) Snapshot(paths ...entity.MinimalPath) map[string]pool.IPoolSimulator {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	pools := make(map[string]pool.IPoolSimulator)
+	for _, path := range paths {
+		for _, address := range path.Pools {
+			if n, ok := g.pools[address]; ok {
+				pools[address] = n.sim
+			}
+		}
+	}
+	return pools
+}
+
+type FindPathsOptions struct {
+	// MaxHops is the maximum number of pools in a path, DefaultMaxHops if not set.
+	MaxHops int
+	// MaxPaths is the number of paths to return, DefaultMaxPaths if not set.
+	MaxPaths int
+	// MaxPoolsPerEdge is the number of pools, by descending reserve, considered for each token pair. Defaults to
+	// DefaultMaxPoolsPerEdge.
+	MaxPoolsPerEdge int
+	// Exchanges, if not empty, is the list of exchanges paths can go through.
+	Exchanges []string
+	// ExcludedExchanges is the list of exchanges paths cannot go through.
+	ExcludedExchanges []string
+	// MinReserveUsd is the minimum reserve of the pools paths can go through.
+	MinReserveUsd float64
+	// AmountIn, if set, ranks paths by the amount out of swapping it through them with pool.CalcPathAmountOut, paths
+	// failing to swap it being dropped. Otherwise, paths are ranked by the smallest reserve of their pools.
+	AmountIn *big.Int
+	// MaxCandidates is, with AmountIn, the number of paths with the largest reserves simulated to rank them. Defaults
+	// to DefaultMaxCandidates, and is never less than MaxPaths.
+	MaxCandidates int
+	Limits        map[string]pool.SwapLimit
+	Timestamp     int64
+	// Ctx is passed to the simulations of AmountIn, see pool.CalcAmountOutParams.Ctx.
+	Ctx context.Context
+	// GasCost, if set with AmountIn, ranks paths by their amount out net of the cost it returns, in token out wei, for
+	// a path of hops pools using gas units of execution gas, see pool.SplitAmountOutParams.GasCost. The cost every
+	// route pays once, such as its base calldata, is the same for all paths and is left out of it.
+	GasCost func(gas int64, hops int) *big.Int
+}
+
+type Path struct {
+	entity.MinimalPath
+	// ReserveUsd is the smallest reserve of the path pools.
+	ReserveUsd float64
+	// Result is the outcome of swapping FindPathsOptions.AmountIn through the path, if set.
+	Result *pool.PathResult
+	// NetAmountOut is the amount out of Result net of FindPathsOptions.GasCost, if set, and so of the cost the path
+	// adds to a route, not of the base cost of the route.
+	NetAmountOut *big.Int
+}
+
+// FindPaths searches the paths from tokenIn to tokenOut that do not go through a token twice, and returns the best
+// ones. A path goes through at most one pool of each exchange allowing a single swap per route, see
+// valueobject.IsSingleSwapSource.
+//
+// Paths are searched best-first by their smallest pool reserve, so that only the paths that can still rank among the
+// MaxPaths best ones, or among the MaxCandidates ones simulated with AmountIn, are expanded.
+func (g *Graph) FindPaths(tokenIn, tokenOut string, opts FindPathsOptions) []Path {
+	maxHops := cmp.Or(opts.MaxHops, DefaultMaxHops)
+	maxPaths := cmp.Or(opts.MaxPaths, DefaultMaxPaths)
+	maxPoolsPerEdge := cmp.Or(opts.MaxPoolsPerEdge, DefaultMaxPoolsPerEdge)
+	limit := maxPaths
+	if opts.AmountIn != nil {
+		limit = max(cmp.Or(opts.MaxCandidates, DefaultMaxCandidates), maxPaths)
+	}
+
+	g.mu.RLock()
+	finder := &pathFinder{
+		graph:           g,
+		opts:            &opts,
+		tokenOut:        tokenOut,
+		maxHops:         maxHops,
+		maxPoolsPerEdge: maxPoolsPerEdge,
+	}
+	paths := finder.search(tokenIn, limit)
+	var pools map[string]pool.IPoolSimulator
+	if opts.AmountIn != nil {
+		pools = make(map[string]pool.IPoolSimulator)
+		for _, path := range paths {
+			for _, address := range path.Pools {
+				pools[address] = g.pools[address].sim
+			}
+		}
+	}
+	g.mu.RUnlock()
+
+	if opts.AmountIn == nil {
+		return paths
+	}
+
+	simulated := paths[:0]
+	for _, path := range paths {
+		res, err := pool.CalcPathAmountOut(pool.CalcPathAmountOutParams{
+			Path:      path.MinimalPath,
+			Pools:     pools,
+			AmountIn:  opts.AmountIn,
+			Limits:    opts.Limits,
+			Timestamp: opts.Timestamp,
+			Ctx:       opts.Ctx,
+		})
+		if err != nil {
+			continue
+		}
+		path.Result, path.NetAmountOut = res, res.TokenAmountOut.Amount
+		if opts.GasCost != nil {
+			path.NetAmountOut = new(big.Int).Sub(path.NetAmountOut, opts.GasCost(res.Gas, len(path.Pools)))
+		}
+		simulated = append(simulated, path)
+	}
+	slices.SortStableFunc(simulated, func(a, b Path) int {
+		return cmp.Or(b.NetAmountOut.Cmp(a.NetAmountOut),
+			cmp.Compare(a.Result.Gas, b.Result.Gas), slices.Compare(a.Pools, b.Pools))
+	})
+	return simulated[:min(len(simulated), maxPaths)]
+}
+
+type pathFinder struct {
+	graph           *Graph
+	opts            *FindPathsOptions
+	tokenOut        string
+	maxHops         int
+	maxPoolsPerEdge int
+}
+
+// search returns the limit best paths by descending smallest reserve, then ascending number of pools and pool
+// addresses. Extending a path never ranks it better, so the complete paths are popped from the queue of partial paths
+// in that order and the search stops at the limit-th one.
+func (f *pathFinder) search(tokenIn string, limit int) []Path {
+	var paths []Path
+	queue := pathQueue{{MinimalPath: entity.MinimalPath{Tokens: []string{tokenIn}}, ReserveUsd: math.MaxFloat64}}
+	for len(queue) > 0 && len(paths) < limit {
+		path := heap.Pop(&queue).(Path)
+		token := path.Tokens[len(path.Tokens)-1]
+		if token == f.tokenOut && len(path.Pools) > 0 {
+			paths = append(paths, path)
+			continue
+		}
+
+		lastHop := len(path.Pools)+1 == f.maxHops
+		for next, addresses := range f.graph.edges[token] {
+			if lastHop && next != f.tokenOut || slices.Contains(path.Tokens, next) {
+				continue
+			}
+			for _, address := range f.candidates(addresses) {
+				n := f.graph.pools[address]
+				if f.singleSwapUsed(path.Pools, n.sim.GetExchange()) {
+					continue
+				}
+				heap.Push(&queue, Path{
+					MinimalPath: entity.MinimalPath{
+						Pools:  append(slices.Clip(path.Pools), address),
+						Tokens: append(slices.Clip(path.Tokens), next),
+					},
+					ReserveUsd: min(path.ReserveUsd, n.reserveUsd),
+				})
+			}
+		}
+	}
+	return paths
+}
+
+// singleSwapUsed returns whether exchange only allows a single swap per route and one of pools is of that exchange.
+func (f *pathFinder) singleSwapUsed(pools []string, exchange string) bool {
+	if !valueobject.IsSingleSwapSource(valueobject.Exchange(exchange)) {
+		return false
+	}
+	return slices.ContainsFunc(pools, func(address string) bool {
+		return f.graph.pools[address].sim.GetExchange() == exchange
+	})
+}
+
+// candidates returns the pools of an edge allowed by the options, keeping the ones with the largest reserves.
+func (f *pathFinder) candidates(addresses map[string]struct{}) []string {
+	candidates := make([]string, 0, len(addresses))
+	for address := range addresses {
+		n := f.graph.pools[address]
+		if n.reserveUsd < f.opts.MinReserveUsd {
+			continue
+		}
+		exchange := n.sim.GetExchange()
+		if len(f.opts.Exchanges) > 0 && !slices.Contains(f.opts.Exchanges, exchange) ||
+			slices.Contains(f.opts.ExcludedExchanges, exchange) {
+			continue
+		}
+		candidates = append(candidates, address)
+	}
+	slices.SortFunc(candidates, func(a, b string) int {
+		return cmp.Or(cmp.Compare(f.graph.pools[b].reserveUsd, f.graph.pools[a].reserveUsd), cmp.Compare(a, b))
+	})
+	return candidates[:min(len(candidates), f.maxPoolsPerEdge)]
+}
+
+// pathQueue is a heap of paths ordered by descending smallest reserve, then ascending number of pools and pool
+// addresses.
+type pathQueue []Path
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(i, j int) bool {
+	return cmp.Or(cmp.Compare(q[j].ReserveUsd, q[i].ReserveUsd), cmp.Compare(len(q[i].Pools), len(q[j].Pools)),
+		slices.Compare(q[i].Pools, q[j].Pools)) < 0
+}
+
+func (q pathQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *pathQueue) Push(x any) { *q = append(*q, x.(Path)) }
+
+func (q *pathQueue) Pop() any {
+	old := *q
+	path := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return path
+}
@@ -0,0 +1,182 @@
+package graph
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+// testPool is a fee-less constant product pool, optionally only swapping its first token to the others.
+type testPool struct {
+	pool.Pool
+	oneWay bool
+}
+
+func newTestPool(address, exchange string, oneWay bool, tokens ...string) *testPool {
+	reserves := make([]*big.Int, len(tokens))
+	for i := range reserves {
+		reserves[i] = big.NewInt(1e9)
+	}
+	return &testPool{Pool: pool.Pool{Info: pool.PoolInfo{
+		Address:  address,
+		Exchange: exchange,
+		Tokens:   tokens,
+		Reserves: reserves,
+	}}, oneWay: oneWay}
+}
+
+func (p *testPool) CanSwapFrom(address string) []string {
+	if p.oneWay && address != p.Info.Tokens[0] {
+		return nil
+	}
+	return p.Pool.CanSwapFrom(address)
+}
+
+func (p *testPool) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	if err := params.Context().Err(); err != nil {
+		return nil, err
+	}
+	reserveIn := p.Info.Reserves[p.GetTokenIndex(params.TokenAmountIn.Token)]
+	reserveOut := p.Info.Reserves[p.GetTokenIndex(params.TokenOut)]
+	amountOut := new(big.Int).Mul(params.TokenAmountIn.Amount, reserveOut)
+	amountOut.Div(amountOut, new(big.Int).Add(reserveIn, params.TokenAmountIn.Amount))
+	return &pool.CalcAmountOutResult{
+		TokenAmountOut: &pool.TokenAmount{Token: params.TokenOut, Amount: amountOut},
+		Gas:            100,
+	}, nil
+}
+
+func (p *testPool) UpdateBalance(_ pool.UpdateBalanceParams) {}
+
+func (p *testPool) GetMetaInfo(_, _ string) any { return nil }
+
+func TestGraph_UpsertRemove(t *testing.T) {
+	g := New()
+	g.Upsert(newTestPool("ab", "x", false, "a", "b"), 100)
+	g.Upsert(newTestPool("bc", "x", true, "b", "c"), 100)
+
+	assert.Equal(t, 2, g.Len())
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{}), 1)
+	assert.Empty(t, g.FindPaths("c", "a", FindPathsOptions{}), "bc only swaps b to c")
+
+	// upserting replaces the edges of the pool
+	g.Upsert(newTestPool("bc", "x", true, "c", "b"), 100)
+	assert.Empty(t, g.FindPaths("a", "c", FindPathsOptions{}))
+	assert.Len(t, g.FindPaths("c", "a", FindPathsOptions{}), 1)
+
+	g.Remove("bc")
+	g.Remove("unknown")
+	assert.Equal(t, 1, g.Len())
+	assert.Empty(t, g.FindPaths("c", "a", FindPathsOptions{}))
+	assert.Empty(t, g.edges["c"])
+	_, ok := g.Pool("bc")
+	assert.False(t, ok)
+}
+
+func TestGraph_FindPaths(t *testing.T) {
+	g := New()
+	g.Upsert(newTestPool("ab", "x", false, "a", "b"), 1000)
+	g.Upsert(newTestPool("bc", "x", false, "b", "c"), 500)
+	g.Upsert(newTestPool("ac", "y", false, "a", "c"), 200)
+	g.Upsert(newTestPool("ad", "z", false, "a", "d"), 1000)
+	g.Upsert(newTestPool("dc", "z", false, "d", "c"), 10)
+
+	paths := g.FindPaths("a", "c", FindPathsOptions{})
+	require.Len(t, paths, 3)
+	assert.Equal(t, []string{"ab", "bc"}, paths[0].Pools)
+	assert.Equal(t, []string{"a", "b", "c"}, paths[0].Tokens)
+	assert.EqualValues(t, 500, paths[0].ReserveUsd)
+	assert.Equal(t, []string{"ac"}, paths[1].Pools)
+	assert.Equal(t, []string{"ad", "dc"}, paths[2].Pools)
+
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{MaxHops: 1}), 1)
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{MaxPaths: 2}), 2)
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{MinReserveUsd: 100}), 2)
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{Exchanges: []string{"x", "y"}}), 2)
+	assert.Len(t, g.FindPaths("a", "c", FindPathsOptions{ExcludedExchanges: []string{"x"}}), 2)
+
+	// the direct pool loses less to slippage than going through two pools
+	paths = g.FindPaths("a", "c", FindPathsOptions{AmountIn: big.NewInt(1e6)})
+	require.Len(t, paths, 3)
+	assert.Equal(t, []string{"ac"}, paths[0].Pools)
+	assert.Equal(t, 1, paths[0].Result.TokenAmountOut.Amount.Cmp(paths[1].Result.TokenAmountOut.Amount))
+	assert.Len(t, g.Snapshot(paths[0].MinimalPath, paths[1].MinimalPath), 3)
+
+	// paths are ranked by their amount out net of their gas cost
+	paths = g.FindPaths("a", "c", FindPathsOptions{
+		AmountIn: big.NewInt(1e6),
+		GasCost: func(gas int64, hops int) *big.Int {
+			return big.NewInt(gas * int64(hops) * 10)
+		},
+	})
+	require.Len(t, paths, 3)
+	assert.Equal(t, []string{"ac"}, paths[0].Pools)
+	for _, path := range paths {
+		cost := path.Result.Gas * int64(len(path.Pools)) * 10
+		assert.Equal(t, new(big.Int).Sub(path.Result.TokenAmountOut.Amount, big.NewInt(cost)), path.NetAmountOut)
+	}
+
+	// paths whose simulation is cancelled are dropped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Empty(t, g.FindPaths("a", "c", FindPathsOptions{AmountIn: big.NewInt(1e6), Ctx: ctx}))
+}
+
+func TestGraph_FindPaths_SingleSwapSource(t *testing.T) {
+	exchange := string(valueobject.ExchangeClipper)
+	g := New()
+	g.Upsert(newTestPool("ab", exchange, false, "a", "b"), 100)
+	g.Upsert(newTestPool("bc", exchange, false, "b", "c"), 100)
+	g.Upsert(newTestPool("bc2", "x", false, "b", "c"), 100)
+
+	paths := g.FindPaths("a", "c", FindPathsOptions{})
+	require.Len(t, paths, 1)
+	assert.Equal(t, []string{"ab", "bc2"}, paths[0].Pools)
+}
+
+func TestGraph_FindPaths_MaxPoolsPerEdge(t *testing.T) {
+	g := New()
+	g.Upsert(newTestPool("ab1", "x", false, "a", "b"), 100)
+	g.Upsert(newTestPool("ab2", "x", false, "a", "b"), 300)
+	g.Upsert(newTestPool("ab3", "x", false, "a", "b"), 200)
+
+	paths := g.FindPaths("a", "b", FindPathsOptions{MaxPoolsPerEdge: 2})
+	require.Len(t, paths, 2)
+	assert.Equal(t, []string{"ab2"}, paths[0].Pools)
+	assert.Equal(t, []string{"ab3"}, paths[1].Pools)
+}
+
+func TestGraph_FindPaths_BestFirst(t *testing.T) {
+	g := New()
+	tokens := []string{"a", "b", "c", "d", "e", "f"}
+	reserveUsd := 1.0
+	for i, tokenIn := range tokens {
+		for _, tokenOut := range tokens[i+1:] {
+			for _, exchange := range []string{"x", "y"} {
+				reserveUsd = float64(int(reserveUsd*7919) % 1009)
+				g.Upsert(newTestPool(tokenIn+tokenOut+exchange, exchange, false, tokenIn, tokenOut), reserveUsd)
+			}
+		}
+	}
+
+	all := g.FindPaths("a", "f", FindPathsOptions{MaxHops: 4, MaxPaths: 1 << 20})
+	require.Len(t, all, 2+2*4*2+2*4*3*2*2+2*4*3*2*2*2*2, "every path of at most 4 hops")
+	for i := 1; i < len(all); i++ {
+		assert.GreaterOrEqual(t, all[i-1].ReserveUsd, all[i].ReserveUsd)
+	}
+	assert.Equal(t, all[:7], g.FindPaths("a", "f", FindPathsOptions{MaxHops: 4, MaxPaths: 7}))
+
+	paths := g.FindPaths("a", "f", FindPathsOptions{MaxHops: 4, MaxPaths: 2, MaxCandidates: 7,
+		AmountIn: big.NewInt(1e6)})
+	require.Len(t, paths, 2)
+	for _, path := range paths {
+		assert.Contains(t, all[:7], Path{MinimalPath: path.MinimalPath, ReserveUsd: path.ReserveUsd},
+			"only the paths with the largest reserves are simulated")
+	}
+}
@@ -0,0 +1,325 @@
+package pool
+
+import (
+	"context"
+	"maps"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+)
+
+var (
+	ErrInvalidPath          = errors.New("invalid path")
+	ErrPathPoolNotFound     = errors.New("path pool not found")
+	ErrPathPoolNotCloneable = errors.New("path pool is used more than once but does not implement CloneState")
+	ErrPathHopInvalid       = errors.New("path hop returned an invalid amount")
+	ErrPathHopPartialFill   = errors.New("path hop did not consume the whole amount in")
+)
+
+// DefaultPathApproxMaxLoop is the secant iteration budget of CalcPathAmountIn hops when MaxLoop is not set.
+const DefaultPathApproxMaxLoop = 20
+
+type CalcPathAmountOutParams struct {
+	Path entity.MinimalPath
+	// Pools holds the simulators of the path pools keyed by address. They are never mutated.
+	Pools    map[string]IPoolSimulator
+	AmountIn *big.Int
+	// Limits holds the swap limits keyed by exchange. They are cloned before use and never mutated.
+	Limits map[string]SwapLimit
+	// Timestamp is the unix block timestamp to evaluate the swaps at, see CalcAmountOutParams.Now.
+	Timestamp int64
+	// Ctx is passed to every hop, see CalcAmountOutParams.Ctx.
+	Ctx context.Context
+}
+
+type CalcPathAmountInParams struct {
+	Path      entity.MinimalPath
+	Pools     map[string]IPoolSimulator
+	AmountOut *big.Int
+	Limits    map[string]SwapLimit
+	Timestamp int64
+	// Ctx is passed to every hop, see CalcAmountOutParams.Ctx.
+	Ctx context.Context
+	// MaxLoop and Threshold are passed to ApproxAmountIn for each hop. MaxLoop defaults to DefaultPathApproxMaxLoop
+	// and Threshold to 1 bps of the hop amount out.
+	MaxLoop   int
+	Threshold *big.Int
+}
+
+// PathHopResult is the outcome of a single hop of a path.
+type PathHopResult struct {
+	Pool           string
+	TokenAmountIn  TokenAmount
+	TokenAmountOut TokenAmount
+	Fee            *TokenAmount
+	Gas            int64
+	SwapInfo       any
+}
+
+type PathResult struct {
+	TokenAmountIn  TokenAmount
+	TokenAmountOut TokenAmount
+	Gas            int64
+	Hops           []PathHopResult
+}
+
+// CalcPathAmountOut swaps AmountIn of the first path token through every pool of the path in order. Each hop sees the
+// pool and limit states left by the previous hops: pools and limits are cloned on first use then updated with
+// UpdateBalance, so that the inputs are left untouched and a pool or limit shared by several hops is only consumed once.
+func CalcPathAmountOut(params CalcPathAmountOutParams) (*PathResult, error) {
+	if err := validatePath(params.Path, params.Pools); err != nil {
+		return nil, err
+	}
+	if params.AmountIn == nil || params.AmountIn.Sign() <= 0 {
+		return nil, ErrInvalidPath
+	}
+
+	state := newPathState(params.Pools, params.Limits)
+	state.ctx = params.Ctx
+	return state.swap(params.Path, params.AmountIn, params.Timestamp)
+}
+
+// CalcPathAmountIn finds the amount of the first path token needed to receive AmountOut of the last one. It walks the
+// path backward, using ApproxAmountIn (and so CalcAmountIn for pools supporting it) to get the amount in of each hop
+// from the amount out of the next one, then replays the path forward with CalcPathAmountOut so that the returned hops
+// account for pools and limits shared by several hops. The resulting amount out is only as close to AmountOut as the
+// hop approximations are.
+func CalcPathAmountIn(params CalcPathAmountInParams) (*PathResult, error) {
+	if err := validatePath(params.Path, params.Pools); err != nil {
+		return nil, err
+	}
+	if params.AmountOut == nil || params.AmountOut.Sign() <= 0 {
+		return nil, ErrInvalidPath
+	}
+
+	maxLoop := params.MaxLoop
+	if maxLoop <= 0 {
+		maxLoop = DefaultPathApproxMaxLoop
+	}
+
+	state := newPathState(params.Pools, params.Limits)
+	amountOut := params.AmountOut
+	for i := len(params.Path.Pools) - 1; i >= 0; i-- {
+		sim := params.Pools[params.Path.Pools[i]]
+		threshold := params.Threshold
+		if threshold == nil {
+			threshold = new(big.Int).Div(amountOut, big.NewInt(10000))
+			if threshold.Sign() == 0 {
+				threshold.SetInt64(1)
+			}
+		}
+
+		limit, err := state.limit(sim)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := ApproxAmountIn(sim, ApproxAmountInParams{
+			ExpectedTokenOut: TokenAmount{Token: params.Path.Tokens[i+1], Amount: amountOut},
+			TokenIn:          params.Path.Tokens[i],
+			Limit:            limit,
+			MaxLoop:          maxLoop,
+			Threshold:        threshold,
+			Timestamp:        params.Timestamp,
+			Ctx:              params.Ctx,
+		})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "hop %d (%s)", i, params.Path.Pools[i])
+		}
+		if res.TokenAmountIn == nil || res.TokenAmountIn.Amount == nil || res.TokenAmountIn.Amount.Sign() <= 0 {
+			return nil, errors.WithMessagef(ErrPathHopInvalid, "hop %d (%s)", i, params.Path.Pools[i])
+		}
+		amountOut = res.TokenAmountIn.Amount
+	}
+
+	return CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:      params.Path,
+		Pools:     params.Pools,
+		AmountIn:  amountOut,
+		Limits:    params.Limits,
+		Timestamp: params.Timestamp,
+		Ctx:       params.Ctx,
+	})
+}
+
+func validatePath(path entity.MinimalPath, pools map[string]IPoolSimulator) error {
+	if len(path.Pools) == 0 || len(path.Tokens) != len(path.Pools)+1 {
+		return ErrInvalidPath
+	}
+	for i, poolAddress := range path.Pools {
+		sim, ok := pools[poolAddress]
+		if !ok || sim == nil {
+			return errors.WithMessage(ErrPathPoolNotFound, poolAddress)
+		}
+		if sim.GetTokenIndex(path.Tokens[i]) < 0 || sim.GetTokenIndex(path.Tokens[i+1]) < 0 {
+			return errors.WithMessagef(ErrInvalidPath, "hop %d (%s) does not trade %s to %s", i, poolAddress,
+				path.Tokens[i], path.Tokens[i+1])
+		}
+	}
+	return nil
+}
+
+// pathState holds the pools and limits cloned for successive swaps, possibly through several paths. Pools and limits
+// are cloned on first use so that UpdateBalance can be applied to them without affecting the inputs.
+type pathState struct {
+	pools  map[string]IPoolSimulator
+	limits map[string]SwapLimit
+
+	clonedPools  map[string]IPoolSimulator
+	clonedLimits map[string]SwapLimit
+	// sharedClones maps the states of the SharedSwapLimit limits to their clones, so that the limits of exchanges
+	// sharing a state still share it once cloned.
+	sharedClones map[any]any
+	// stalePools and staleLimits are the pools not implementing CloneState that have been swapped through, and the
+	// limits they could not update. Using them again would ignore the previous swap.
+	stalePools  map[string]struct{}
+	staleLimits map[string]struct{}
+	// ctx is passed to the swaps, see CalcAmountOutParams.Ctx.
+	ctx context.Context
+}
+
+func newPathState(pools map[string]IPoolSimulator, limits map[string]SwapLimit) *pathState {
+	return &pathState{
+		pools:        pools,
+		limits:       limits,
+		clonedPools:  make(map[string]IPoolSimulator),
+		clonedLimits: make(map[string]SwapLimit),
+		sharedClones: make(map[any]any),
+		stalePools:   make(map[string]struct{}),
+		staleLimits:  make(map[string]struct{}),
+	}
+}
+
+// fork returns an independent copy of the state, so that a swap can be tried without committing it.
+func (s *pathState) fork() *pathState {
+	forked := newPathState(s.pools, s.limits)
+	forked.ctx = s.ctx
+	for address, sim := range s.clonedPools {
+		forked.clonedPools[address] = sim.CloneState()
+	}
+	for exchange, limit := range s.clonedLimits {
+		forked.clonedLimits[exchange] = forked.cloneLimit(limit)
+	}
+	maps.Copy(forked.stalePools, s.stalePools)
+	maps.Copy(forked.staleLimits, s.staleLimits)
+	return forked
+}
+
+// swap swaps amountIn of the first path token through every pool of the path, updating the state along the way.
+func (s *pathState) swap(path entity.MinimalPath, amountIn *big.Int, timestamp int64) (*PathResult, error) {
+	result := &PathResult{
+		TokenAmountIn: TokenAmount{Token: path.Tokens[0], Amount: amountIn},
+		Hops:          make([]PathHopResult, 0, len(path.Pools)),
+	}
+
+	for i, poolAddress := range path.Pools {
+		sim, updatable, err := s.pool(poolAddress)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := s.limit(sim)
+		if err != nil {
+			return nil, err
+		}
+
+		tokenAmountIn := TokenAmount{Token: path.Tokens[i], Amount: amountIn}
+		res, err := calcAmountOut(sim, CalcAmountOutParams{
+			TokenAmountIn: tokenAmountIn,
+			TokenOut:      path.Tokens[i+1],
+			Limit:         limit,
+			Timestamp:     timestamp,
+			Ctx:           s.ctx,
+		})
+		if err != nil {
+			return nil, errors.WithMessagef(err, "hop %d (%s)", i, poolAddress)
+		}
+		if res == nil || !res.IsValid() {
+			return nil, errors.WithMessagef(ErrPathHopInvalid, "hop %d (%s)", i, poolAddress)
+		}
+		if res.RemainingTokenAmountIn != nil && res.RemainingTokenAmountIn.Amount != nil &&
+			res.RemainingTokenAmountIn.Amount.Sign() > 0 {
+			return nil, errors.WithMessagef(ErrPathHopPartialFill, "hop %d (%s)", i, poolAddress)
+		}
+
+		if updatable {
+			sim.UpdateBalance(UpdateBalanceParams{
+				TokenAmountIn:  tokenAmountIn,
+				TokenAmountOut: *res.TokenAmountOut,
+				Fee:            derefTokenAmount(res.Fee),
+				SwapInfo:       res.SwapInfo,
+				SwapLimit:      limit,
+				Timestamp:      timestamp,
+			})
+		} else {
+			s.stalePools[poolAddress] = struct{}{}
+			if limit != nil {
+				s.staleLimits[sim.GetExchange()] = struct{}{}
+			}
+		}
+
+		result.Hops = append(result.Hops, PathHopResult{
+			Pool:           poolAddress,
+			TokenAmountIn:  tokenAmountIn,
+			TokenAmountOut: *res.TokenAmountOut,
+			Fee:            res.Fee,
+			Gas:            res.Gas,
+			SwapInfo:       res.SwapInfo,
+		})
+		result.Gas += res.Gas
+		amountIn = res.TokenAmountOut.Amount
+	}
+
+	result.TokenAmountOut = TokenAmount{Token: path.Tokens[len(path.Tokens)-1], Amount: amountIn}
+	return result, nil
+}
+
+// pool returns the simulator to use for the pool at address and whether UpdateBalance can be applied to it. Pools not
+// implementing CloneState are used as is, and only once.
+func (s *pathState) pool(address string) (IPoolSimulator, bool, error) {
+	if sim, ok := s.clonedPools[address]; ok {
+		return sim, true, nil
+	}
+	if _, ok := s.stalePools[address]; ok {
+		return nil, false, errors.WithMessage(ErrPathPoolNotCloneable, address)
+	}
+	sim := s.pools[address]
+	if cloned := sim.CloneState(); cloned != nil {
+		s.clonedPools[address] = cloned
+		return cloned, true, nil
+	}
+	return sim, false, nil
+}
+
+// limit returns the clone of the swap limit of the exchange of sim, if any.
+func (s *pathState) limit(sim IPoolSimulator) (SwapLimit, error) {
+	exchange := sim.GetExchange()
+	if _, ok := s.staleLimits[exchange]; ok {
+		return nil, errors.WithMessage(ErrPathPoolNotCloneable, exchange)
+	}
+	if limit, ok := s.clonedLimits[exchange]; ok {
+		return limit, nil
+	}
+	limit, ok := s.limits[exchange]
+	if !ok || limit == nil {
+		return nil, nil
+	}
+	limit = s.cloneLimit(limit)
+	s.clonedLimits[exchange] = limit
+	return limit, nil
+}
+
+func (s *pathState) cloneLimit(limit SwapLimit) SwapLimit {
+	if shared, ok := limit.(SharedSwapLimit); ok {
+		return shared.CloneShared(s.sharedClones)
+	}
+	return limit.Clone()
+}
+
+func derefTokenAmount(tokenAmount *TokenAmount) TokenAmount {
+	if tokenAmount == nil {
+		return TokenAmount{}
+	}
+	return *tokenAmount
+}
@@ -0,0 +1,279 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+)
+
+// constantProductPool is a fee-less x*y=k pool used to check path state handling.
+type constantProductPool struct {
+	Pool
+}
+
+func newConstantProductPool(address, exchange string, tokens [2]string, reserves [2]int64) *constantProductPool {
+	return &constantProductPool{Pool: Pool{Info: PoolInfo{
+		Address:  address,
+		Exchange: exchange,
+		Tokens:   tokens[:],
+		Reserves: []*big.Int{big.NewInt(reserves[0]), big.NewInt(reserves[1])},
+	}}}
+}
+
+func (p *constantProductPool) CalcAmountOut(params CalcAmountOutParams) (*CalcAmountOutResult, error) {
+	if err := params.Context().Err(); err != nil {
+		return nil, err
+	}
+	if params.Limit != nil && params.Limit.GetLimit("") != nil {
+		return nil, ErrNotEnoughInventory
+	}
+	in, out := p.GetTokenIndex(params.TokenAmountIn.Token), p.GetTokenIndex(params.TokenOut)
+	reserveIn, reserveOut := p.Info.Reserves[in], p.Info.Reserves[out]
+	amountOut := new(big.Int).Mul(params.TokenAmountIn.Amount, reserveOut)
+	amountOut.Div(amountOut, new(big.Int).Add(reserveIn, params.TokenAmountIn.Amount))
+	return &CalcAmountOutResult{
+		TokenAmountOut: &TokenAmount{Token: params.TokenOut, Amount: amountOut},
+		Fee:            &TokenAmount{Token: params.TokenAmountIn.Token, Amount: big.NewInt(0)},
+		Gas:            100,
+	}, nil
+}
+
+func (p *constantProductPool) CalcAmountIn(params CalcAmountInParams) (*CalcAmountInResult, error) {
+	in, out := p.GetTokenIndex(params.TokenIn), p.GetTokenIndex(params.TokenAmountOut.Token)
+	reserveIn, reserveOut := p.Info.Reserves[in], p.Info.Reserves[out]
+	amountIn := new(big.Int).Mul(reserveIn, params.TokenAmountOut.Amount)
+	amountIn.Div(amountIn, new(big.Int).Sub(reserveOut, params.TokenAmountOut.Amount))
+	amountIn.Add(amountIn, big.NewInt(1))
+	return &CalcAmountInResult{
+		TokenAmountIn: &TokenAmount{Token: params.TokenIn, Amount: amountIn},
+		Gas:           100,
+	}, nil
+}
+
+func (p *constantProductPool) CloneState() IPoolSimulator {
+	cloned := *p
+	cloned.Info.Reserves = slices.Clone(p.Info.Reserves)
+	return &cloned
+}
+
+func (p *constantProductPool) UpdateBalance(params UpdateBalanceParams) {
+	in, out := p.GetTokenIndex(params.TokenAmountIn.Token), p.GetTokenIndex(params.TokenAmountOut.Token)
+	p.Info.Reserves[in] = new(big.Int).Add(p.Info.Reserves[in], params.TokenAmountIn.Amount)
+	p.Info.Reserves[out] = new(big.Int).Sub(p.Info.Reserves[out], params.TokenAmountOut.Amount)
+	if params.SwapLimit != nil {
+		_, _, _ = params.SwapLimit.UpdateLimit("", "", nil, nil)
+	}
+}
+
+func (p *constantProductPool) GetMetaInfo(_, _ string) any { return nil }
+
+// singleSwapLimit only allows one swap per exchange.
+type singleSwapLimit struct{ swapped bool }
+
+func (l *singleSwapLimit) Clone() SwapLimit                { cloned := *l; return &cloned }
+func (l *singleSwapLimit) GetExchange() string             { return "single" }
+func (l *singleSwapLimit) GetSwapped() map[string]*big.Int { return nil }
+func (l *singleSwapLimit) GetAllowedSenders() string       { return "" }
+func (l *singleSwapLimit) GetLimit(_ string) *big.Int {
+	if l.swapped {
+		return big.NewInt(0)
+	}
+	return nil
+}
+func (l *singleSwapLimit) UpdateLimit(_, _ string, _, _ *big.Int) (*big.Int, *big.Int, error) {
+	l.swapped = true
+	return nil, nil, nil
+}
+
+// sharedSingleSwapLimit only allows one swap across the exchanges sharing swapped.
+type sharedSingleSwapLimit struct {
+	exchange string
+	swapped  *bool
+}
+
+func (l *sharedSingleSwapLimit) Clone() SwapLimit                { return l.CloneShared(map[any]any{}) }
+func (l *sharedSingleSwapLimit) GetExchange() string             { return l.exchange }
+func (l *sharedSingleSwapLimit) GetSwapped() map[string]*big.Int { return nil }
+func (l *sharedSingleSwapLimit) GetAllowedSenders() string       { return "" }
+func (l *sharedSingleSwapLimit) GetLimit(_ string) *big.Int {
+	if *l.swapped {
+		return big.NewInt(0)
+	}
+	return nil
+}
+func (l *sharedSingleSwapLimit) UpdateLimit(_, _ string, _, _ *big.Int) (*big.Int, *big.Int, error) {
+	*l.swapped = true
+	return nil, nil, nil
+}
+func (l *sharedSingleSwapLimit) CloneShared(clones map[any]any) SwapLimit {
+	swapped, ok := clones[l.swapped].(*bool)
+	if !ok {
+		swapped = new(bool)
+		*swapped = *l.swapped
+		clones[l.swapped] = swapped
+	}
+	return &sharedSingleSwapLimit{exchange: l.exchange, swapped: swapped}
+}
+
+func testPathPools(pools ...*constantProductPool) map[string]IPoolSimulator {
+	res := make(map[string]IPoolSimulator, len(pools))
+	for _, p := range pools {
+		res[p.GetAddress()] = p
+	}
+	return res
+}
+
+func TestCalcPathAmountOut(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 2e6})
+	poolBC := newConstantProductPool("bc", "cp", [2]string{"b", "c"}, [2]int64{4e6, 1e6})
+
+	res, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab", "bc"}, Tokens: []string{"a", "b", "c"}},
+		Pools:    testPathPools(poolAB, poolBC),
+		AmountIn: big.NewInt(1000),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, res.Hops, 2)
+	assert.Equal(t, "1998", res.Hops[0].TokenAmountOut.Amount.String()) // 1000*2e6/(1e6+1000)
+	assert.Equal(t, "499", res.Hops[1].TokenAmountOut.Amount.String())  // 1998*1e6/(4e6+1998)
+	assert.Equal(t, res.Hops[1].TokenAmountOut, res.TokenAmountOut)
+	assert.Equal(t, "c", res.TokenAmountOut.Token)
+	assert.EqualValues(t, 200, res.Gas)
+
+	assert.Equal(t, "1000000", poolAB.Info.Reserves[0].String(), "input pools must not be mutated")
+}
+
+func TestCalcPathAmountOut_ReusedPool(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	poolBC := newConstantProductPool("bc", "cp", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+	poolCB := newConstantProductPool("cb", "cp", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+
+	reused, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab", "bc", "bc"}, Tokens: []string{"a", "b", "c", "b"}},
+		Pools:    testPathPools(poolAB, poolBC),
+		AmountIn: big.NewInt(100000),
+	})
+	require.NoError(t, err)
+	fresh, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab", "bc", "cb"}, Tokens: []string{"a", "b", "c", "b"}},
+		Pools:    testPathPools(poolAB, poolBC, poolCB),
+		AmountIn: big.NewInt(100000),
+	})
+	require.NoError(t, err)
+
+	// going back through the same pool undoes the previous hop (up to rounding), a fresh pool charges slippage again
+	diff := new(big.Int).Sub(reused.Hops[0].TokenAmountOut.Amount, reused.TokenAmountOut.Amount)
+	assert.LessOrEqual(t, diff.CmpAbs(big.NewInt(1)), 0)
+	assert.Equal(t, 1, reused.TokenAmountOut.Amount.Cmp(fresh.TokenAmountOut.Amount))
+	assert.Equal(t, "1000000", poolBC.Info.Reserves[0].String())
+}
+
+func TestCalcPathAmountOut_SharedLimit(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "single", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	poolBC := newConstantProductPool("bc", "single", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+	limit := &singleSwapLimit{}
+
+	_, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab", "bc"}, Tokens: []string{"a", "b", "c"}},
+		Pools:    testPathPools(poolAB, poolBC),
+		AmountIn: big.NewInt(1000),
+		Limits:   map[string]SwapLimit{"single": limit},
+	})
+	assert.ErrorIs(t, err, ErrNotEnoughInventory)
+	assert.False(t, limit.swapped, "input limits must not be mutated")
+
+	_, err = CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab"}, Tokens: []string{"a", "b"}},
+		Pools:    testPathPools(poolAB),
+		AmountIn: big.NewInt(1000),
+		Limits:   map[string]SwapLimit{"single": limit},
+	})
+	assert.NoError(t, err)
+}
+
+func TestCalcPathAmountOut_SharedLimitAcrossExchanges(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "x", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	poolBC := newConstantProductPool("bc", "y", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+	swapped := new(bool)
+	limits := map[string]SwapLimit{
+		"x": &sharedSingleSwapLimit{exchange: "x", swapped: swapped},
+		"y": &sharedSingleSwapLimit{exchange: "y", swapped: swapped},
+	}
+
+	_, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab", "bc"}, Tokens: []string{"a", "b", "c"}},
+		Pools:    testPathPools(poolAB, poolBC),
+		AmountIn: big.NewInt(1000),
+		Limits:   limits,
+	})
+	assert.ErrorIs(t, err, ErrNotEnoughInventory)
+	assert.False(t, *swapped, "input limits must not be mutated")
+}
+
+func TestCalcPathAmountOut_InvalidPath(t *testing.T) {
+	pools := testPathPools(newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6}))
+	for name, tc := range map[string]struct {
+		path entity.MinimalPath
+		err  error
+	}{
+		"empty":          {entity.MinimalPath{Tokens: []string{"a"}}, ErrInvalidPath},
+		"length":         {entity.MinimalPath{Pools: []string{"ab"}, Tokens: []string{"a"}}, ErrInvalidPath},
+		"unknown pool":   {entity.MinimalPath{Pools: []string{"xy"}, Tokens: []string{"a", "b"}}, ErrPathPoolNotFound},
+		"unknown tokens": {entity.MinimalPath{Pools: []string{"ab"}, Tokens: []string{"a", "c"}}, ErrInvalidPath},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := CalcPathAmountOut(CalcPathAmountOutParams{Path: tc.path, Pools: pools, AmountIn: big.NewInt(1)})
+			assert.True(t, errors.Is(err, tc.err), err)
+		})
+	}
+}
+
+func TestCalcPathAmountIn(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 2e6})
+	poolBC := newConstantProductPool("bc", "cp", [2]string{"b", "c"}, [2]int64{4e6, 1e6})
+
+	res, err := CalcPathAmountIn(CalcPathAmountInParams{
+		Path:      entity.MinimalPath{Pools: []string{"ab", "bc"}, Tokens: []string{"a", "b", "c"}},
+		Pools:     testPathPools(poolAB, poolBC),
+		AmountOut: big.NewInt(5000),
+		Threshold: big.NewInt(2),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "a", res.TokenAmountIn.Token)
+	diff := new(big.Int).Sub(res.TokenAmountOut.Amount, big.NewInt(5000))
+	assert.LessOrEqual(t, diff.CmpAbs(big.NewInt(2)), 0, "amount out %s", res.TokenAmountOut.Amount)
+	assert.Equal(t, "1000000", poolAB.Info.Reserves[0].String())
+}
+
+func TestCalcPathAmount_Ctx(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 2e6})
+	poolBC := newConstantProductPool("bc", "cp", [2]string{"b", "c"}, [2]int64{4e6, 1e6})
+	path := entity.MinimalPath{Pools: []string{"ab", "bc"}, Tokens: []string{"a", "b", "c"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     path,
+		Pools:    testPathPools(poolAB, poolBC),
+		AmountIn: big.NewInt(5000),
+		Ctx:      ctx,
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = CalcPathAmountIn(CalcPathAmountInParams{
+		Path:      path,
+		Pools:     testPathPools(poolAB, poolBC),
+		AmountOut: big.NewInt(5000),
+		Ctx:       ctx,
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
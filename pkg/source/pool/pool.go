@@ -1,6 +1,7 @@
 package pool
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 
@@ -105,6 +106,9 @@ type UpdateBalanceParams struct {
 	// key is tokenAddress, balance is big.Float
 	// Must use reference (not copy)
 	SwapLimit SwapLimit
+
+	// Timestamp is the unix block timestamp the swap happened at, see Now.
+	Timestamp int64
 }
 
 type PoolInfo struct {
@@ -132,12 +136,26 @@ type CalcAmountOutParams struct {
 	TokenAmountIn TokenAmount
 	TokenOut      string
 	Limit         SwapLimit
+	// Timestamp is the unix block timestamp to evaluate the swap at, see Now.
+	Timestamp int64
+	// Ctx, if set, bounds the calls made by simulators quoting swaps with external services, see Context.
+	Ctx context.Context
+}
+
+// Context returns Ctx, or context.Background if not set.
+func (p *CalcAmountOutParams) Context() context.Context {
+	if p.Ctx != nil {
+		return p.Ctx
+	}
+	return context.Background()
 }
 
 type CalcAmountInParams struct {
 	TokenAmountOut TokenAmount
 	TokenIn        string
 	Limit          SwapLimit
+	// Timestamp is the unix block timestamp to evaluate the swap at, see Now.
+	Timestamp int64
 }
 
 type CalcAmountInResult struct {
@@ -149,7 +167,15 @@ type CalcAmountInResult struct {
 }
 
 // CalcAmountOut wraps pool.CalcAmountOut and catch panic
-func CalcAmountOut(pool IPoolSimulator, tokenAmountIn TokenAmount, tokenOut string, limit SwapLimit) (res *CalcAmountOutResult, err error) {
+func CalcAmountOut(pool IPoolSimulator, tokenAmountIn TokenAmount, tokenOut string, limit SwapLimit) (*CalcAmountOutResult, error) {
+	return calcAmountOut(pool, CalcAmountOutParams{
+		TokenAmountIn: tokenAmountIn,
+		TokenOut:      tokenOut,
+		Limit:         limit,
+	})
+}
+
+func calcAmountOut(pool IPoolSimulator, params CalcAmountOutParams) (res *CalcAmountOutResult, err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			err = errors.WithStack(ErrCalcAmountOutPanic)
@@ -161,12 +187,7 @@ func CalcAmountOut(pool IPoolSimulator, tokenAmountIn TokenAmount, tokenOut stri
 		}
 	}()
 
-	return pool.CalcAmountOut(
-		CalcAmountOutParams{
-			TokenAmountIn: tokenAmountIn,
-			TokenOut:      tokenOut,
-			Limit:         limit,
-		})
+	return pool.CalcAmountOut(params)
 }
 
 type ApproxAmountInParams struct {
@@ -175,6 +196,10 @@ type ApproxAmountInParams struct {
 	Limit            SwapLimit
 	MaxLoop          int
 	Threshold        *big.Int
+	// Timestamp is the unix block timestamp to evaluate the swaps at, see CalcAmountOutParams.Now.
+	Timestamp int64
+	// Ctx is passed to the swaps simulated with CalcAmountOut, see CalcAmountOutParams.Ctx.
+	Ctx context.Context
 }
 
 type ApproxAmountInResult struct {
@@ -205,13 +230,19 @@ func ApproxAmountIn(
 			TokenAmountOut: expectedTokenOut,
 			TokenIn:        param.TokenIn,
 			Limit:          param.Limit,
+			Timestamp:      param.Timestamp,
 		})
 		if err != nil {
 			return nil, err
 		}
 
 		// still need to check again to see if the calculated amountIn is good enough
-		resOut, err := CalcAmountOut(pool, *resIn.TokenAmountIn, expectedTokenOut.Token, nil)
+		resOut, err := calcAmountOut(pool, CalcAmountOutParams{
+			TokenAmountIn: *resIn.TokenAmountIn,
+			TokenOut:      expectedTokenOut.Token,
+			Timestamp:     param.Timestamp,
+			Ctx:           param.Ctx,
+		})
 		if err != nil {
 			return nil, err
 		}
@@ -237,7 +268,13 @@ func ApproxAmountIn(
 
 	// get 1st initial point by converting back expectedAmountOut to tokenIn
 	// this might yield error if the pool doesn't support that
-	x0res, err := CalcAmountOut(pool, expectedTokenOut, param.TokenIn, param.Limit)
+	x0res, err := calcAmountOut(pool, CalcAmountOutParams{
+		TokenAmountIn: expectedTokenOut,
+		TokenOut:      param.TokenIn,
+		Limit:         param.Limit,
+		Timestamp:     param.Timestamp,
+		Ctx:           param.Ctx,
+	})
 	if err != nil {
 		logger.Debugf("error getting 1st initial point %v", err)
 		return nil, err
@@ -246,7 +283,13 @@ func ApproxAmountIn(
 
 	// get the 2nd initial point:
 	// 	- convert x0 tokenIn to tokenOut
-	fx0Res, err := CalcAmountOut(pool, *x0res.TokenAmountOut, expectedTokenOut.Token, param.Limit)
+	fx0Res, err := calcAmountOut(pool, CalcAmountOutParams{
+		TokenAmountIn: *x0res.TokenAmountOut,
+		TokenOut:      expectedTokenOut.Token,
+		Limit:         param.Limit,
+		Timestamp:     param.Timestamp,
+		Ctx:           param.Ctx,
+	})
 	if err != nil {
 		logger.Debugf("error getting 2nd initial point %v", err)
 		return nil, err
@@ -267,7 +310,13 @@ func ApproxAmountIn(
 	loopCount := 0
 	for loopCount < param.MaxLoop {
 		// fpool_1(x1) = fpool(x1) - amountOut
-		fx1Res, err := CalcAmountOut(pool, TokenAmount{Token: param.TokenIn, Amount: x1}, expectedTokenOut.Token, param.Limit)
+		fx1Res, err := calcAmountOut(pool, CalcAmountOutParams{
+			TokenAmountIn: TokenAmount{Token: param.TokenIn, Amount: x1},
+			TokenOut:      expectedTokenOut.Token,
+			Limit:         param.Limit,
+			Timestamp:     param.Timestamp,
+			Ctx:           param.Ctx,
+		})
 		if err != nil {
 			logger.Debugf("error calculating fx1 %v", err)
 			return nil, err
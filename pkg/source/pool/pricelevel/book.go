@@ -0,0 +1,267 @@
+package pricelevel
+
+import (
+	"errors"
+	"math/big"
+	"slices"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+var (
+	ErrEmptyPriceLevels      = errors.New("empty price levels")
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	ErrBelowLowestLevel      = errors.New("amount in is less than lowest price level")
+)
+
+// Book is one side of the order book of a market maker: the price levels to swap a token in for a token out with, best
+// first. Amounts are in wei of the tokens. Consume and ConsumeOut replace the levels of the book without mutating them,
+// so that clones can share them.
+type Book struct {
+	levels  []Level
+	unitIn  *big.Int
+	unitOut *big.Int
+}
+
+// NewBook returns the Book of levels, sized in whole tokens in of decimalsIn, priced in whole tokens out of decimalsOut.
+func NewBook(levels []Level, decimalsIn, decimalsOut uint8) *Book {
+	return &Book{
+		levels:  levels,
+		unitIn:  bignumber.TenPowInt(decimalsIn),
+		unitOut: bignumber.TenPowInt(decimalsOut),
+	}
+}
+
+// Clone returns a copy of the book, to consume independently of it.
+func (b *Book) Clone() *Book {
+	cloned := *b
+	return &cloned
+}
+
+// Levels returns the levels left in the book. They must not be mutated.
+func (b *Book) Levels() []Level {
+	return b.levels
+}
+
+// Len returns the number of levels left in the book.
+func (b *Book) Len() int {
+	return len(b.levels)
+}
+
+// FirstLevelSize returns the size of the best level in wei of the token in, rounded up, or 0 if the book is empty.
+func (b *Book) FirstLevelSize() *big.Int {
+	if len(b.levels) == 0 {
+		return new(big.Int)
+	}
+	return toAmount(b.levels[0].Size, b.unitIn, true)
+}
+
+// SpotPrice returns the price of the best level in wei of the token out per wei of the token in.
+func (b *Book) SpotPrice() (*big.Rat, error) {
+	if len(b.levels) == 0 {
+		return nil, ErrEmptyPriceLevels
+	}
+	price := new(big.Rat).Mul(b.levels[0].Price, new(big.Rat).SetFrac(b.unitOut, b.unitIn))
+	return price, nil
+}
+
+// AmountOut walks the levels, best first, and returns the amount out of swapping amountIn, rounded down.
+func (b *Book) AmountOut(amountIn *big.Int) (*big.Int, error) {
+	if len(b.levels) == 0 {
+		return nil, ErrEmptyPriceLevels
+	}
+
+	left := toRat(amountIn, b.unitIn)
+	var amountOut, fill big.Rat
+	for _, level := range b.levels {
+		if left.Sign() <= 0 {
+			break
+		}
+		if level.Size.Cmp(left) < 0 {
+			fill.Set(level.Size)
+		} else {
+			fill.Set(left)
+		}
+		left.Sub(left, &fill)
+		amountOut.Add(&amountOut, fill.Mul(&fill, level.Price))
+	}
+	if left.Sign() > 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return toAmount(&amountOut, b.unitOut, false), nil
+}
+
+// AmountIn walks the levels, best first, and returns the amount in to swap for amountOut, rounded up.
+func (b *Book) AmountIn(amountOut *big.Int) (*big.Int, error) {
+	if len(b.levels) == 0 {
+		return nil, ErrEmptyPriceLevels
+	}
+
+	left := toRat(amountOut, b.unitOut)
+	var amountIn, fill, levelOut big.Rat
+	for _, level := range b.levels {
+		if left.Sign() <= 0 {
+			break
+		}
+		if level.Price.Sign() <= 0 {
+			continue
+		}
+		if levelOut.Mul(level.Size, level.Price).Cmp(left) < 0 {
+			left.Sub(left, &levelOut)
+			amountIn.Add(&amountIn, level.Size)
+			continue
+		}
+		amountIn.Add(&amountIn, fill.Quo(left, level.Price))
+		left.SetInt64(0)
+	}
+	if left.Sign() > 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return toAmount(&amountIn, b.unitIn, true), nil
+}
+
+// InterpolatedAmountOut returns the amount out of swapping amountIn, rounded down, at a single price interpolated
+// linearly between the prices of the levels around the cumulative size of amountIn, as dexalot quotes. It returns
+// ErrBelowLowestLevel if amountIn is less than the best level.
+func (b *Book) InterpolatedAmountOut(amountIn *big.Int) (*big.Int, error) {
+	if len(b.levels) == 0 {
+		return nil, ErrEmptyPriceLevels
+	}
+
+	x := toRat(amountIn, b.unitIn)
+	if x.Cmp(b.levels[0].Size) < 0 {
+		return nil, ErrBelowLowestLevel
+	}
+
+	var price *big.Rat
+	prevSize, size := new(big.Rat), new(big.Rat)
+	for i, level := range b.levels {
+		prevSize.Set(size)
+		size.Add(size, level.Size)
+		if x.Cmp(size) > 0 {
+			continue
+		}
+		if i == 0 || x.Cmp(size) == 0 {
+			price = level.Price
+			break
+		}
+		// prevPrice + (price - prevPrice) * (x - prevSize) / (size - prevSize)
+		prevPrice := b.levels[i-1].Price
+		price = new(big.Rat).Sub(level.Price, prevPrice)
+		price.Mul(price, new(big.Rat).Sub(x, prevSize))
+		price.Quo(price, level.Size)
+		price.Add(price, prevPrice)
+		break
+	}
+	if price == nil {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return toAmount(x.Mul(x, price), b.unitOut, false), nil
+}
+
+// Depth walks the levels, filling each one whole while the average price stays within impactBps of the best price,
+// and the part of the first one it does not where it reaches the impact.
+func (b *Book) Depth(impactBps int64) (amountIn, amountOut *big.Int, err error) {
+	if len(b.levels) == 0 {
+		return nil, nil, ErrEmptyPriceLevels
+	}
+
+	minPrice := new(big.Rat).Mul(b.levels[0].Price, big.NewRat(pool.BasisPoint-impactBps, pool.BasisPoint))
+
+	var in, out, fill, tmp big.Rat
+	for _, level := range b.levels {
+		fill.Set(level.Size)
+		below := level.Price.Cmp(minPrice) < 0
+		if below {
+			// (out + price * x) / (in + x) >= minPrice
+			// <=> x <= (out - minPrice * in) / (minPrice - price)
+			maxFill := new(big.Rat).Sub(&out, tmp.Mul(minPrice, &in))
+			maxFill.Quo(maxFill, tmp.Sub(minPrice, level.Price))
+			if maxFill.Cmp(&fill) < 0 {
+				fill.Set(maxFill)
+			}
+		}
+		in.Add(&in, &fill)
+		out.Add(&out, tmp.Mul(&fill, level.Price))
+		if below {
+			break
+		}
+	}
+
+	return toAmount(&in, b.unitIn, false), toAmount(&out, b.unitOut, false), nil
+}
+
+// InterpolatedDepth is Depth for books quoted with InterpolatedAmountOut: the amount in is the largest one whose
+// interpolated price is within impactBps of the best price, and never less than the best level.
+func (b *Book) InterpolatedDepth(impactBps int64) (amountIn, amountOut *big.Int, err error) {
+	if len(b.levels) == 0 {
+		return nil, nil, ErrEmptyPriceLevels
+	}
+
+	minPrice := new(big.Rat).Mul(b.levels[0].Price, big.NewRat(pool.BasisPoint-impactBps, pool.BasisPoint))
+
+	x, price := new(big.Rat).Set(b.levels[0].Size), b.levels[0].Price
+	for i, level := range b.levels[1:] {
+		if level.Price.Cmp(minPrice) >= 0 {
+			x.Add(x, level.Size)
+			price = level.Price
+			continue
+		}
+		// prevPrice + (price - prevPrice) * fill / size = minPrice
+		// <=> fill = (prevPrice - minPrice) * size / (prevPrice - price)
+		prevPrice := b.levels[i].Price
+		fill := new(big.Rat).Sub(prevPrice, minPrice)
+		fill.Mul(fill, level.Size)
+		fill.Quo(fill, new(big.Rat).Sub(prevPrice, level.Price))
+		x.Add(x, fill)
+		price = minPrice
+		break
+	}
+
+	return toAmount(x, b.unitIn, false), toAmount(new(big.Rat).Mul(x, price), b.unitOut, false), nil
+}
+
+// Consume removes amountIn from the levels, best first, as swapped in.
+func (b *Book) Consume(amountIn *big.Int) {
+	b.consume(toRat(amountIn, b.unitIn), false)
+}
+
+// ConsumeOut removes the amount in swapped for amountOut from the levels, best first.
+func (b *Book) ConsumeOut(amountOut *big.Int) {
+	b.consume(toRat(amountOut, b.unitOut), true)
+}
+
+func (b *Book) consume(left *big.Rat, isOut bool) {
+	if left.Sign() <= 0 {
+		return
+	}
+	var levelAmount big.Rat
+	for i, level := range b.levels {
+		levelAmount.Set(level.Size)
+		if isOut {
+			levelAmount.Mul(&levelAmount, level.Price)
+		}
+		if cmp := levelAmount.Cmp(left); cmp < 0 {
+			left.Sub(left, &levelAmount)
+			continue
+		} else if cmp == 0 {
+			b.levels = b.levels[i+1:]
+			return
+		}
+
+		// partially filled, cloned so as not to mutate the levels shared with clones
+		levels := slices.Clone(b.levels[i:])
+		size := levelAmount.Sub(&levelAmount, left)
+		if isOut {
+			size.Quo(size, level.Price)
+		}
+		levels[0].Size = new(big.Rat).Set(size)
+		b.levels = levels
+		return
+	}
+	b.levels = nil
+}
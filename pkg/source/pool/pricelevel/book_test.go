@@ -0,0 +1,255 @@
+package pricelevel
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func levels(priceSizes ...float64) []Level {
+	result := make([]Level, 0, len(priceSizes)/2)
+	for i := 0; i+1 < len(priceSizes); i += 2 {
+		result = append(result, NewLevel(priceSizes[i], priceSizes[i+1]))
+	}
+	return result
+}
+
+func floats(levels []Level) []float64 {
+	result := make([]float64, 0, 2*len(levels))
+	for _, level := range levels {
+		price, size := level.Float64()
+		result = append(result, price, size)
+	}
+	return result
+}
+
+func TestDecimal(t *testing.T) {
+	assert.Equal(t, "1/10", Decimal(0.1).String())
+	assert.Equal(t, "3766.876208555815", Decimal(3766.876208555815).FloatString(12))
+	assert.Equal(t, "1/100000", Decimal(1e-5).String())
+	assert.Equal(t, "0/1", Decimal(nan()).String())
+}
+
+func nan() float64 {
+	var zero float64
+	return zero / zero
+}
+
+func TestFromCumulative(t *testing.T) {
+	assert.Equal(t, []float64{100, 0, 99, 0.2, 98, 0.3},
+		floats(FromCumulative(levels(100, 0, 99, 0.2, 98, 0.5))))
+	assert.Equal(t, []float64{100, 1, 99, 2, 98, 0, 97, 3},
+		floats(FromCumulative(levels(100, 1, 99, 3, 98, 2, 97, 5))))
+}
+
+func TestBook_AmountOut(t *testing.T) {
+	testCases := []struct {
+		name      string
+		levels    []Level
+		amountIn  int64
+		amountOut string
+		err       error
+	}{
+		{
+			name:     "empty price levels",
+			amountIn: 1,
+			err:      ErrEmptyPriceLevels,
+		},
+		{
+			name:     "insufficient liquidity",
+			levels:   levels(100, 1, 99, 2),
+			amountIn: 4,
+			err:      ErrInsufficientLiquidity,
+		},
+		{
+			name:      "fully filled",
+			levels:    levels(100, 1),
+			amountIn:  1,
+			amountOut: "100",
+		},
+		{
+			name:      "partially filled",
+			levels:    levels(100, 1, 99, 2),
+			amountIn:  2,
+			amountOut: "199",
+		},
+		{
+			name:      "decimal prices",
+			levels:    levels(0.1, 3, 0.7, 1),
+			amountIn:  4,
+			amountOut: "1",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amountOut, err := NewBook(tc.levels, 0, 0).AmountOut(big.NewInt(tc.amountIn))
+			assert.ErrorIs(t, err, tc.err)
+			if tc.err == nil {
+				assert.Equal(t, tc.amountOut, amountOut.String())
+			}
+		})
+	}
+}
+
+func TestBook_AmountOut_Decimals(t *testing.T) {
+	// 0.1 + 0.2 in float64 is not 0.3
+	book := NewBook(levels(0.1, 1, 0.2, 1), 18, 6)
+	amountOut, err := book.AmountOut(big.NewInt(2e18))
+	require.NoError(t, err)
+	assert.Equal(t, "300000", amountOut.String())
+
+	// rounded down
+	amountOut, err = NewBook(levels(1.0/3, 10), 0, 6).AmountOut(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, "333333", amountOut.String())
+}
+
+func TestBook_AmountIn(t *testing.T) {
+	book := NewBook(levels(100, 1, 99, 2), 18, 6)
+
+	amountIn, err := book.AmountIn(big.NewInt(199e6))
+	require.NoError(t, err)
+	assert.Equal(t, "2000000000000000000", amountIn.String())
+
+	// rounded up
+	amountIn, err = book.AmountIn(big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, "10000000000", amountIn.String())
+	amountIn, err = NewBook(levels(3, 10), 0, 0).AmountIn(big.NewInt(4))
+	require.NoError(t, err)
+	assert.Equal(t, "2", amountIn.String())
+
+	_, err = book.AmountIn(big.NewInt(299e6))
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	_, err = NewBook(nil, 0, 0).AmountIn(big.NewInt(1))
+	assert.ErrorIs(t, err, ErrEmptyPriceLevels)
+}
+
+func TestBook_InterpolatedAmountOut(t *testing.T) {
+	// asks of 50, 80 and 100 for up to 120, 320 and 600 usdc
+	book := NewBook(FromCumulative(levels(0.02, 120, 0.0125, 320, 0.01, 600)), 6, 18)
+
+	for _, tc := range []struct {
+		amountIn  int64
+		amountOut string
+		err       error
+	}{
+		{amountIn: 120e6, amountOut: "2400000000000000000"},
+		{amountIn: 320e6, amountOut: "4000000000000000000"},
+		// (0.02 + (0.0125 - 0.02) * (200 - 120) / (320 - 120)) * 200
+		{amountIn: 200e6, amountOut: "3400000000000000000"},
+		{amountIn: 120e6 - 1, err: ErrBelowLowestLevel},
+		{amountIn: 600e6 + 1, err: ErrInsufficientLiquidity},
+	} {
+		amountOut, err := book.InterpolatedAmountOut(big.NewInt(tc.amountIn))
+		assert.ErrorIs(t, err, tc.err, tc.amountIn)
+		if tc.err == nil {
+			assert.Equal(t, tc.amountOut, amountOut.String(), tc.amountIn)
+		}
+	}
+}
+
+func TestBook_Consume(t *testing.T) {
+	testCases := []struct {
+		name     string
+		levels   []Level
+		amountIn int64
+		expected []float64
+	}{
+		{name: "empty price levels", amountIn: 1, expected: []float64{}},
+		{name: "fully filled", levels: levels(100, 1), amountIn: 1, expected: []float64{}},
+		{name: "more than the level", levels: levels(100, 1), amountIn: 2, expected: []float64{}},
+		{name: "more than all levels", levels: levels(100, 1, 99, 2), amountIn: 5, expected: []float64{}},
+		{name: "partially filled", levels: levels(100, 1, 99, 2), amountIn: 2, expected: []float64{99, 1}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			book := NewBook(tc.levels, 0, 0)
+			cloned := book.Clone()
+			book.Consume(big.NewInt(tc.amountIn))
+			assert.Equal(t, tc.expected, floats(book.Levels()))
+			assert.Equal(t, floats(tc.levels), floats(cloned.Levels()))
+		})
+	}
+}
+
+func TestBook_ConsumeOut(t *testing.T) {
+	testCases := []struct {
+		name      string
+		levels    []Level
+		amountOut int64
+		expected  []float64
+	}{
+		{name: "empty price levels", amountOut: 1, expected: []float64{}},
+		{name: "fully filled", levels: levels(100, 1), amountOut: 100, expected: []float64{}},
+		{name: "more than the level", levels: levels(100, 1), amountOut: 200, expected: []float64{}},
+		{name: "more than all levels", levels: levels(100, 1, 99, 2), amountOut: 500, expected: []float64{}},
+		{name: "partially filled", levels: levels(100, 1, 99, 2), amountOut: 199, expected: []float64{99, 1}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			book := NewBook(tc.levels, 0, 0)
+			cloned := book.Clone()
+			book.ConsumeOut(big.NewInt(tc.amountOut))
+			assert.Equal(t, tc.expected, floats(book.Levels()))
+			assert.Equal(t, floats(tc.levels), floats(cloned.Levels()))
+		})
+	}
+}
+
+func TestBook_Consume_Exact(t *testing.T) {
+	// float64 walking leaves dust of 0.1 + 0.2 - 0.3 in the book
+	book := NewBook(levels(1, 0.3), 18, 18)
+	book.Consume(big.NewInt(1e17))
+	book.Consume(big.NewInt(2e17))
+	assert.Zero(t, book.Len())
+}
+
+func TestBook_SpotPrice(t *testing.T) {
+	spotPrice, err := NewBook(levels(0.6, 10, 0.5, 10), 18, 6).SpotPrice()
+	require.NoError(t, err)
+	assert.Equal(t, "3/5000000000000", spotPrice.String())
+
+	_, err = NewBook(nil, 18, 6).SpotPrice()
+	assert.ErrorIs(t, err, ErrEmptyPriceLevels)
+}
+
+func TestBook_Depth(t *testing.T) {
+	book := NewBook(levels(0.6, 10, 0.5, 10), 18, 6)
+	// 1%: x of the 0.5 level such that (6 + 0.5x) / (10 + x) = 0.594, x = 0.06 / 0.094
+	amountIn, amountOut, err := book.Depth(100)
+	require.NoError(t, err)
+	assert.Equal(t, "10638297872340425531", amountIn.String())
+	assert.Equal(t, "6319148", amountOut.String())
+
+	// 10%: both levels, averaging 0.55
+	amountIn, amountOut, err = book.Depth(1000)
+	require.NoError(t, err)
+	assert.Equal(t, "20000000000000000000", amountIn.String())
+	assert.Equal(t, "11000000", amountOut.String())
+}
+
+func TestBook_InterpolatedDepth(t *testing.T) {
+	// asks of 50, 80 and 100 for up to 120, 320 and 600 usdc
+	book := NewBook(FromCumulative(levels(0.02, 120, 0.0125, 320, 0.01, 600)), 6, 18)
+
+	// 25%: 0.015 is reached at 120 + (0.02 - 0.015) * 200 / (0.02 - 0.0125) of the second level
+	amountIn, amountOut, err := book.InterpolatedDepth(2500)
+	require.NoError(t, err)
+	assert.Equal(t, "253333333", amountIn.String())
+	assert.Equal(t, "3800000000000000000", amountOut.String())
+
+	// 1%: 0.0198 is reached at 120 + 0.0002 * 200 / 0.0075
+	amountIn, amountOut, err = book.InterpolatedDepth(100)
+	require.NoError(t, err)
+	assert.Equal(t, "125333333", amountIn.String())
+	assert.Equal(t, "2481600000000000000", amountOut.String())
+
+	// 60%: every level
+	amountIn, amountOut, err = book.InterpolatedDepth(6000)
+	require.NoError(t, err)
+	assert.Equal(t, "600000000", amountIn.String())
+	assert.Equal(t, "6000000000000000000", amountOut.String())
+}
@@ -0,0 +1,69 @@
+// Package pricelevel simulates swaps against the price levels quoted by PMM and RFQ market makers, with exact rational
+// arithmetic.
+package pricelevel
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Level is a price level of a market maker: up to Size of the token in, in whole tokens, swaps at Price of the token
+// out per token in.
+type Level struct {
+	Price *big.Rat
+	Size  *big.Rat
+}
+
+// NewLevel returns the Level of a price and a size quoted as float64, see Decimal.
+func NewLevel(price, size float64) Level {
+	return Level{Price: Decimal(price), Size: Decimal(size)}
+}
+
+// Float64 returns the price and the size of the level, rounded to the nearest float64.
+func (l Level) Float64() (price, size float64) {
+	price, _ = l.Price.Float64()
+	size, _ = l.Size.Float64()
+	return price, size
+}
+
+// Decimal returns f as the shortest decimal formatting to it, that is the number the market maker sent rather than its
+// binary approximation, or 0 if f is not finite.
+func Decimal(f float64) *big.Rat {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return new(big.Rat)
+	}
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	return r
+}
+
+// FromCumulative returns the levels quoted by cumulative size, as swaap-v2 and dexalot do, sized by their own amount.
+// A level quoting less than the previous one is sized 0, and the next one is sized from its cumulative size as quoted,
+// as the makers compute them.
+func FromCumulative(levels []Level) []Level {
+	result := make([]Level, len(levels))
+	prevCumulative := new(big.Rat)
+	for i, level := range levels {
+		size := new(big.Rat).Sub(level.Size, prevCumulative)
+		if size.Sign() < 0 {
+			size.SetInt64(0)
+		}
+		result[i] = Level{Price: level.Price, Size: size}
+		prevCumulative = level.Size
+	}
+	return result
+}
+
+// toRat returns amount in the unit as a number of whole tokens.
+func toRat(amount, unit *big.Int) *big.Rat {
+	return new(big.Rat).SetFrac(amount, unit)
+}
+
+// toAmount returns x whole tokens as an amount in the unit, rounded down, or up if roundUp.
+func toAmount(x *big.Rat, unit *big.Int, roundUp bool) *big.Int {
+	num := new(big.Int).Mul(x.Num(), unit)
+	if roundUp {
+		num.Add(num, x.Denom()).Sub(num, big.NewInt(1))
+	}
+	return num.Quo(num, x.Denom())
+}
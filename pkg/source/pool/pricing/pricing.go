@@ -0,0 +1,338 @@
+// Package pricing derives token USD prices from pool simulators, starting from stable anchor tokens.
+package pricing
+
+import (
+	"cmp"
+	"container/heap"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+const (
+	DefaultMinLiquidityUsd = 1000
+	DefaultMaxDeviation    = 0.05
+	DefaultMinPools        = 2
+	// AmplifiedTvlImpactBps is the price impact at which the depth of pools is measured to compute their amplified TVL.
+	AmplifiedTvlImpactBps = 100
+)
+
+// DefaultAnchors holds the lowercase addresses of the USD stablecoins used as price anchors on each chain.
+var DefaultAnchors = map[valueobject.ChainID][]string{
+	valueobject.ChainIDEthereum: {
+		"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", // USDC
+		"0xdac17f958d2ee523a2206206994597c13d831ec7", // USDT
+		"0x6b175474e89094c44da98b954eedeac495271d0f", // DAI
+	},
+	valueobject.ChainIDArbitrumOne: {
+		"0xaf88d065e77c8cc2239327c5edb3a432268e5831", // USDC
+		"0xfd086bc7cd5c481dcc9c85ebe478a1c0b69fcbb9", // USDT
+	},
+	valueobject.ChainIDOptimism: {
+		"0x0b2c639c533813f4aa9d7837caf62653d097ff85", // USDC
+		"0x94b008aa00579c1307b0ef2c499ad98a8ce58e58", // USDT
+	},
+	valueobject.ChainIDBase: {
+		"0x833589fcd6edb6e08f4c7c32d4f71b54bda02913", // USDC
+	},
+	valueobject.ChainIDPolygon: {
+		"0x3c499c542cef5e3811e1192ce70d8cc03d5c3359", // USDC
+		"0xc2132d05d31c914a87c6611c10748aeb04b58e8f", // USDT
+	},
+	valueobject.ChainIDBSC: {
+		"0x55d398326f99059ff775485246999027b3197955", // USDT
+		"0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d", // USDC
+	},
+	valueobject.ChainIDAvalancheCChain: {
+		"0xb97ef9ef8734c71904d8002f8b6bc66dd9c48a6e", // USDC
+		"0x9702230a8ea53601f5cd2dc00fdbc13d4df4a8c7", // USDT
+	},
+}
+
+type Config struct {
+	// Anchors maps the lowercase addresses of the tokens prices are derived from to their USD price.
+	Anchors map[string]float64
+	// MinLiquidityUsd is the minimum USD value of the reserve of the priced token a pool must hold to price the
+	// other tokens, DefaultMinLiquidityUsd if not set.
+	MinLiquidityUsd float64
+	// MaxDeviation is the maximum relative deviation of the price quoted by a pool from the liquidity-weighted
+	// median of the prices quoted for the same token for the pool to be used, DefaultMaxDeviation if not set.
+	MaxDeviation float64
+	// MinPools is the minimum number of pools whose quotes must agree within MaxDeviation of their weighted median
+	// for a token to be priced, DefaultMinPools if not set, so that a single pool cannot set a price.
+	MinPools int
+	// ProbeSpotPrices makes pools not implementing pool.IPoolSpotPricer quote prices too, probing their spot prices
+	// with pool.SpotPrice. Probing runs up to 30 CalcAmountOut per swap direction, each of them calling the chain for
+	// the pools quoting over RPC, so only the pools pricing natively quote by default.
+	ProbeSpotPrices bool
+}
+
+// DefaultConfig returns the config pricing tokens from the DefaultAnchors of chainID, at 1 USD each.
+func DefaultConfig(chainID valueobject.ChainID) Config {
+	anchors := make(map[string]float64, len(DefaultAnchors[chainID]))
+	for _, anchor := range DefaultAnchors[chainID] {
+		anchors[anchor] = 1
+	}
+	return Config{Anchors: anchors}
+}
+
+// quote is the price of a token quoted by a pool against a token already priced.
+type quote struct {
+	pool      string
+	price     float64
+	liquidity float64
+}
+
+// Prices computes the USD prices of the tokens of sims whose decimals are known, keyed by address. Prices propagate
+// from the anchors through the token graph, the most liquid tokens being priced first: each pool holding a priced
+// token quotes the price of its other tokens at its mid spot price, weighted by the USD value of its reserve of the
+// priced token, capped at the liquidity of the priced token itself. Quotes below MinLiquidityUsd, or deviating from
+// the weighted median of the quotes of the token by more than MaxDeviation, are rejected, so that thin or
+// manipulated pools cannot move prices. Tokens not quoted by MinPools agreeing pools are not priced.
+func Prices(sims []pool.IPoolSimulator, decimals map[string]uint8, cfg Config) map[string]*entity.Price {
+	p := &pricer{
+		decimals:        decimals,
+		minLiquidityUsd: cmp.Or(cfg.MinLiquidityUsd, DefaultMinLiquidityUsd),
+		maxDeviation:    cmp.Or(cfg.MaxDeviation, DefaultMaxDeviation),
+		minPools:        cmp.Or(cfg.MinPools, DefaultMinPools),
+		probe:           cfg.ProbeSpotPrices,
+		poolsByToken:    make(map[string][]pool.IPoolSimulator),
+		prices:          make(map[string]*entity.Price),
+		liquidity:       make(map[string]float64),
+		quotes:          make(map[string]map[string]quote),
+		quotedLiquidity: make(map[string]float64),
+	}
+	for _, sim := range sims {
+		for _, token := range sim.GetTokens() {
+			p.poolsByToken[token] = append(p.poolsByToken[token], sim)
+		}
+	}
+
+	for anchor, price := range cfg.Anchors {
+		anchor = strings.ToLower(anchor)
+		if _, ok := decimals[anchor]; !ok {
+			continue
+		}
+		p.prices[anchor] = p.anchorPrice(anchor, price)
+		p.liquidity[anchor] = math.Inf(1)
+	}
+	for anchor := range p.prices {
+		p.propagate(anchor)
+	}
+
+	for p.queue.Len() > 0 {
+		item := heap.Pop(&p.queue).(queueItem)
+		if _, ok := p.prices[item.token]; ok || item.liquidity != p.quotedLiquidity[item.token] {
+			continue
+		}
+		if price := p.aggregate(item.token); price != nil {
+			p.prices[item.token] = price
+			p.liquidity[item.token] = price.Liquidity
+			p.propagate(item.token)
+		}
+	}
+	return p.prices
+}
+
+type pricer struct {
+	decimals        map[string]uint8
+	minLiquidityUsd float64
+	maxDeviation    float64
+	minPools        int
+	probe           bool
+
+	poolsByToken map[string][]pool.IPoolSimulator
+	prices       map[string]*entity.Price
+	// liquidity caps the weight of the quotes against a priced token, anchors being unbounded.
+	liquidity map[string]float64
+	// quotes maps unpriced tokens then pool addresses to the best quote of the pool for the token.
+	quotes map[string]map[string]quote
+	// quotedLiquidity is the total liquidity of the quotes of unpriced tokens, stale queue items not matching it.
+	quotedLiquidity map[string]float64
+	queue           priorityQueue
+}
+
+// anchorPrice returns the price of an anchor, its liquidity being its USD value held by all pools.
+func (p *pricer) anchorPrice(anchor string, price float64) *entity.Price {
+	res := &entity.Price{Address: anchor, Price: price, PreferPriceSource: entity.PriceSourceKyberswap}
+	var lpLiquidity float64
+	for _, sim := range p.poolsByToken[anchor] {
+		liquidity := reserveValue(sim, anchor, p.decimals[anchor], price)
+		res.Liquidity += liquidity
+		if liquidity > lpLiquidity {
+			res.LpAddress, lpLiquidity = sim.GetAddress(), liquidity
+		}
+	}
+	return res
+}
+
+// propagate adds the quotes of the pools holding the newly priced token to their unpriced tokens.
+func (p *pricer) propagate(token string) {
+	price, tokenDecimals := p.prices[token].Price, p.decimals[token]
+	for _, sim := range p.poolsByToken[token] {
+		liquidity := min(reserveValue(sim, token, tokenDecimals, price), p.liquidity[token])
+		if liquidity < p.minLiquidityUsd {
+			continue
+		}
+		for _, other := range sim.GetTokens() {
+			otherDecimals, ok := p.decimals[other]
+			if !ok || other == token {
+				continue
+			} else if _, ok = p.prices[other]; ok {
+				continue
+			}
+			rate, ok := p.midRate(sim, other, token)
+			if !ok {
+				continue
+			}
+			otherPrice := rate * price * math.Pow10(int(otherDecimals)-int(tokenDecimals))
+			if otherPrice <= 0 || otherPrice > entity.UpperLimitPrice || math.IsInf(otherPrice, 0) ||
+				math.IsNaN(otherPrice) {
+				continue
+			}
+
+			quotes, ok := p.quotes[other]
+			if !ok {
+				quotes = make(map[string]quote)
+				p.quotes[other] = quotes
+			}
+			// multi-token pools quote against their most liquid priced token only, not to count their liquidity twice
+			q, ok := quotes[sim.GetAddress()]
+			if ok && q.liquidity >= liquidity {
+				continue
+			}
+			quotes[sim.GetAddress()] = quote{pool: sim.GetAddress(), price: otherPrice, liquidity: liquidity}
+			p.quotedLiquidity[other] += liquidity - q.liquidity
+			heap.Push(&p.queue, queueItem{token: other, liquidity: p.quotedLiquidity[other]})
+		}
+	}
+}
+
+// aggregate returns the price of token from its quotes, dropping the ones deviating from their weighted median. It
+// returns nil if fewer than minPools quotes remain, keeping the quotes for the next ones to be added to them.
+func (p *pricer) aggregate(token string) *entity.Price {
+	if len(p.quotes[token]) < p.minPools {
+		return nil
+	}
+	quotes := make([]quote, 0, len(p.quotes[token]))
+	for _, q := range p.quotes[token] {
+		quotes = append(quotes, q)
+	}
+	slices.SortFunc(quotes, func(a, b quote) int {
+		return cmp.Or(cmp.Compare(a.price, b.price), cmp.Compare(a.pool, b.pool))
+	})
+
+	var half, cumulative, median float64
+	for _, q := range quotes {
+		half += q.liquidity / 2
+	}
+	for _, q := range quotes {
+		if cumulative += q.liquidity; cumulative >= half {
+			median = q.price
+			break
+		}
+	}
+
+	res := &entity.Price{Address: token, PreferPriceSource: entity.PriceSourceKyberswap}
+	var weightedPrice, lpLiquidity float64
+	var pools int
+	for _, q := range quotes {
+		if math.Abs(q.price/median-1) > p.maxDeviation {
+			continue
+		}
+		weightedPrice += q.price * q.liquidity
+		res.Liquidity += q.liquidity
+		if q.liquidity > lpLiquidity {
+			res.LpAddress, lpLiquidity = q.pool, q.liquidity
+		}
+		pools++
+	}
+	if pools < p.minPools || res.Liquidity == 0 {
+		return nil
+	}
+	delete(p.quotes, token)
+	delete(p.quotedLiquidity, token)
+	res.Price = weightedPrice / res.Liquidity
+	return res
+}
+
+// midRate returns the amount of tokenOut per amount of tokenIn, in wei, at the middle of the spot prices of both swap
+// directions, so that pool fees do not bias prices.
+func (p *pricer) midRate(sim pool.IPoolSimulator, tokenIn, tokenOut string) (float64, bool) {
+	var forward, backward float64
+	if spotPrice, err := p.spotPrice(sim, tokenIn, tokenOut); err == nil &&
+		slices.Contains(sim.CanSwapFrom(tokenIn), tokenOut) {
+		forward, _ = spotPrice.Float64()
+	}
+	if spotPrice, err := p.spotPrice(sim, tokenOut, tokenIn); err == nil &&
+		slices.Contains(sim.CanSwapFrom(tokenOut), tokenIn) {
+		if backward, _ = spotPrice.Float64(); backward > 0 {
+			backward = 1 / backward
+		}
+	}
+	switch {
+	case forward > 0 && backward > 0:
+		return math.Sqrt(forward * backward), true
+	case forward > 0:
+		return forward, true
+	case backward > 0:
+		return backward, true
+	default:
+		return 0, false
+	}
+}
+
+// spotPrice returns the spot price of sim natively, or probed if enabled, see Config.ProbeSpotPrices.
+func (p *pricer) spotPrice(sim pool.IPoolSimulator, tokenIn, tokenOut string) (*big.Float, error) {
+	if p.probe {
+		return pool.SpotPrice(sim, pool.SpotPriceParams{TokenIn: tokenIn, TokenOut: tokenOut})
+	}
+	pricer, ok := sim.(pool.IPoolSpotPricer)
+	if !ok {
+		return nil, pool.ErrSpotPriceUnsupported
+	}
+	return pricer.SpotPrice(tokenIn, tokenOut)
+}
+
+// reserveValue returns the USD value of the reserve of token held by sim.
+func reserveValue(sim pool.IPoolSimulator, token string, decimals uint8, price float64) float64 {
+	index, reserves := sim.GetTokenIndex(token), sim.GetReserves()
+	if index < 0 || index >= len(reserves) || reserves[index] == nil {
+		return 0
+	}
+	return amountUsd(reserves[index], decimals, price)
+}
+
+func amountUsd(amount *big.Int, decimals uint8, price float64) float64 {
+	value := new(big.Float).SetInt(amount)
+	value.Quo(value, bignumber.TenPowDecimals(decimals)).Mul(value, big.NewFloat(price))
+	f, _ := value.Float64()
+	return f
+}
+
+type queueItem struct {
+	token     string
+	liquidity float64
+}
+
+// priorityQueue pops the token with the most liquid quotes first.
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int { return len(q) }
+func (q priorityQueue) Less(i, j int) bool {
+	return cmp.Or(cmp.Compare(q[j].liquidity, q[i].liquidity), cmp.Compare(q[i].token, q[j].token)) < 0
+}
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *priorityQueue) Push(x any)   { *q = append(*q, x.(queueItem)) }
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
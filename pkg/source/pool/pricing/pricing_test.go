@@ -0,0 +1,160 @@
+package pricing
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+const (
+	usdc = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	weth = "weth"
+	x    = "x"
+	y    = "y"
+	z    = "z"
+)
+
+var decimals = map[string]uint8{usdc: 6, weth: 18, x: 18, y: 18, z: 18}
+
+// testPool is a fee-less constant product pool of 2 tokens, swapping as if its reserves were multiplied by
+// amplification, as concentrated liquidity pools do.
+type testPool struct {
+	pool.Pool
+	amplification int64
+}
+
+func newTestPool(address string, tokens [2]string, reserves [2]string, amplification int64) *testPool {
+	r0, _ := new(big.Int).SetString(reserves[0], 10)
+	r1, _ := new(big.Int).SetString(reserves[1], 10)
+	return &testPool{Pool: pool.Pool{Info: pool.PoolInfo{
+		Address:  address,
+		Tokens:   tokens[:],
+		Reserves: []*big.Int{r0, r1},
+	}}, amplification: amplification}
+}
+
+func (p *testPool) CalcAmountOut(params pool.CalcAmountOutParams) (*pool.CalcAmountOutResult, error) {
+	amplification := big.NewInt(p.amplification)
+	reserveIn := new(big.Int).Mul(p.Info.Reserves[p.GetTokenIndex(params.TokenAmountIn.Token)], amplification)
+	reserveOut := new(big.Int).Mul(p.Info.Reserves[p.GetTokenIndex(params.TokenOut)], amplification)
+	amountOut := new(big.Int).Mul(params.TokenAmountIn.Amount, reserveOut)
+	amountOut.Div(amountOut, new(big.Int).Add(reserveIn, params.TokenAmountIn.Amount))
+	return &pool.CalcAmountOutResult{
+		TokenAmountOut: &pool.TokenAmount{Token: params.TokenOut, Amount: amountOut},
+	}, nil
+}
+
+func (p *testPool) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	return new(big.Float).Quo(new(big.Float).SetInt(p.Info.Reserves[p.GetTokenIndex(tokenOut)]),
+		new(big.Float).SetInt(p.Info.Reserves[p.GetTokenIndex(tokenIn)])), nil
+}
+
+// probedPool hides the spot price of its pool, which can then only be probed.
+type probedPool struct {
+	pool.IPoolSimulator
+}
+
+func (p *testPool) UpdateBalance(_ pool.UpdateBalanceParams) {}
+
+func (p *testPool) GetMetaInfo(_, _ string) any { return nil }
+
+func TestPrices(t *testing.T) {
+	sims := []pool.IPoolSimulator{
+		// 2,000,000 USDC / 1000 WETH and 1,000,000 USDC / 500 WETH
+		newTestPool("usdc-weth", [2]string{usdc, weth}, [2]string{"2000000000000", "1000000000000000000000"}, 1),
+		newTestPool("usdc-weth-2", [2]string{usdc, weth}, [2]string{"1000000000000", "500000000000000000000"}, 1),
+		// 100 WETH / 1,000,000 X and 20,000 USDC / 100,000 X
+		newTestPool("weth-x", [2]string{weth, x}, [2]string{"100000000000000000000", "1000000000000000000000000"}, 1),
+		newTestPool("usdc-x", [2]string{usdc, x}, [2]string{"20000000000", "100000000000000000000000"}, 1),
+		// 10 USDC / 1 X, below the minimum liquidity
+		newTestPool("usdc-x-thin", [2]string{usdc, x}, [2]string{"10000000", "1000000000000000000"}, 1),
+		// 5000 USDC / 5000 X, deviating from the deeper pools
+		newTestPool("usdc-x-off", [2]string{usdc, x}, [2]string{"5000000000", "5000000000000000000000"}, 1),
+		// 1 X / 1 Y, Y being only reachable through thin pools
+		newTestPool("x-y", [2]string{x, y}, [2]string{"1000000000000000000", "1000000000000000000"}, 1),
+		// 10,000 USDC / 10,000 Z, Z being quoted by a single pool
+		newTestPool("usdc-z", [2]string{usdc, z}, [2]string{"10000000000", "10000000000000000000000"}, 1),
+	}
+
+	prices := Prices(sims, decimals, DefaultConfig(valueobject.ChainIDEthereum))
+	require.Len(t, prices, 3)
+
+	assert.Equal(t, 1.0, prices[usdc].Price)
+	assert.InEpsilon(t, 3_035_010, prices[usdc].Liquidity, 1e-9)
+	assert.Equal(t, "usdc-weth", prices[usdc].LpAddress)
+
+	assert.InEpsilon(t, 2000, prices[weth].Price, 1e-9)
+	assert.InEpsilon(t, 3_000_000, prices[weth].Liquidity, 1e-9)
+	assert.Equal(t, "usdc-weth", prices[weth].LpAddress)
+	assert.Equal(t, entity.PriceSourceKyberswap, prices[weth].PreferPriceSource)
+
+	assert.InEpsilon(t, 0.2, prices[x].Price, 1e-9)
+	assert.InEpsilon(t, 220_000, prices[x].Liquidity, 1e-9)
+	assert.Equal(t, "weth-x", prices[x].LpAddress)
+
+	assert.NotContains(t, prices, y)
+	assert.NotContains(t, prices, z)
+
+	cfg := DefaultConfig(valueobject.ChainIDEthereum)
+	cfg.MinPools = 1
+	prices = Prices(sims, decimals, cfg)
+	assert.InEpsilon(t, 1, prices[z].Price, 1e-9)
+}
+
+func TestPrices_LiquidityWeighted(t *testing.T) {
+	sims := []pool.IPoolSimulator{
+		// 30,000 USDC / 10,000 X and 10,000 USDC / 3,500 X: 3 and 2.857 USD
+		newTestPool("a", [2]string{usdc, x}, [2]string{"30000000000", "10000000000000000000000"}, 1),
+		newTestPool("b", [2]string{usdc, x}, [2]string{"10000000000", "3500000000000000000000"}, 1),
+	}
+
+	prices := Prices(sims, decimals, DefaultConfig(valueobject.ChainIDEthereum))
+	require.Contains(t, prices, x)
+	assert.InEpsilon(t, (3*30_000+10/3.5*10_000)/40_000, prices[x].Price, 1e-9)
+	assert.InEpsilon(t, 40_000, prices[x].Liquidity, 1e-9)
+	assert.Equal(t, "a", prices[x].LpAddress)
+}
+
+func TestPrices_ProbeSpotPrices(t *testing.T) {
+	sims := []pool.IPoolSimulator{
+		probedPool{newTestPool("a", [2]string{usdc, x}, [2]string{"30000000000", "10000000000000000000000"}, 1)},
+		probedPool{newTestPool("b", [2]string{usdc, x}, [2]string{"10000000000", "3500000000000000000000"}, 1)},
+	}
+
+	cfg := DefaultConfig(valueobject.ChainIDEthereum)
+	assert.NotContains(t, Prices(sims, decimals, cfg), x, "pools are only probed if enabled")
+
+	cfg.ProbeSpotPrices = true
+	prices := Prices(sims, decimals, cfg)
+	require.Contains(t, prices, x)
+	// the probed spot prices include the impact of probed amounts large enough to get 6 significant USDC digits
+	assert.InEpsilon(t, (3*30_000+10/3.5*10_000)/40_000, prices[x].Price, 1e-3)
+}
+
+func TestReserveUsd(t *testing.T) {
+	prices := map[string]*entity.Price{usdc: {Price: 1}, weth: {Price: 2000}}
+	p := &entity.Pool{
+		Tokens:   []*entity.PoolToken{{Address: usdc, Decimals: 6}, {Address: weth, Decimals: 18}, {Address: x}},
+		Reserves: entity.PoolReserves{"2000000000000", "1000000000000000000000", "1"},
+	}
+	assert.InEpsilon(t, 4_000_000, ReserveUsd(p, prices), 1e-9)
+}
+
+func TestAmplifiedTvl(t *testing.T) {
+	prices := map[string]*entity.Price{usdc: {Price: 1}, weth: {Price: 2000}}
+	reserves := [2]string{"2000000000000", "1000000000000000000000"}
+
+	sim := newTestPool("usdc-weth", [2]string{usdc, weth}, reserves, 1)
+	assert.InEpsilon(t, 4_000_000, AmplifiedTvl(sim, decimals, prices), 1e-3)
+
+	sim = newTestPool("usdc-weth", [2]string{usdc, weth}, reserves, 10)
+	assert.InEpsilon(t, 40_000_000, AmplifiedTvl(sim, decimals, prices), 1e-3)
+
+	assert.Zero(t, AmplifiedTvl(sim, decimals, map[string]*entity.Price{usdc: {Price: 1}}))
+}
@@ -0,0 +1,73 @@
+package pricing
+
+import (
+	"math/big"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+// ReserveUsd returns the USD value of the reserves of p, tokens without price counting for nothing.
+func ReserveUsd(p *entity.Pool, prices map[string]*entity.Price) float64 {
+	var reserveUsd float64
+	for i, token := range p.Tokens {
+		price, ok := prices[token.Address]
+		if !ok || i >= len(p.Reserves) {
+			continue
+		}
+		reserve, ok := new(big.Int).SetString(p.Reserves[i], 10)
+		if !ok {
+			continue
+		}
+		reserveUsd += amountUsd(reserve, token.Decimals, price.Price)
+	}
+	return reserveUsd
+}
+
+// AmplifiedTvl returns the TVL of the constant product pool as deep as sim: its USD reserves scaled by the ratio of
+// its depth at AmplifiedTvlImpactBps to the depth of a constant product pool holding the same reserve of the token
+// in, averaged over its priced token pairs. It is the USD reserves of constant product pools, and a multiple of them
+// for pools concentrating their liquidity, such as stable or concentrated liquidity pools.
+func AmplifiedTvl(sim pool.IPoolSimulator, decimals map[string]uint8, prices map[string]*entity.Price) float64 {
+	var reserveUsd float64
+	for _, token := range sim.GetTokens() {
+		if price, ok := prices[token]; ok {
+			reserveUsd += reserveValue(sim, token, decimals[token], price.Price)
+		}
+	}
+
+	// a constant product pool fills amountIn = reserveIn * impact / (1 - impact) within impact
+	cpDepthRatio := float64(AmplifiedTvlImpactBps) / float64(pool.BasisPoint-AmplifiedTvlImpactBps)
+	var amplification float64
+	var pairs int
+	for _, tokenIn := range sim.GetTokens() {
+		price, ok := prices[tokenIn]
+		if !ok {
+			continue
+		}
+		reserveInUsd := reserveValue(sim, tokenIn, decimals[tokenIn], price.Price)
+		if reserveInUsd == 0 {
+			continue
+		}
+		for _, tokenOut := range sim.CanSwapFrom(tokenIn) {
+			if _, ok := prices[tokenOut]; !ok || tokenOut == tokenIn {
+				continue
+			}
+			curve, err := pool.DepthCurve(sim, pool.DepthCurveParams{
+				TokenIn:    tokenIn,
+				TokenOut:   tokenOut,
+				ImpactsBps: []int64{AmplifiedTvlImpactBps},
+			})
+			if err != nil {
+				continue
+			}
+			depthUsd := amountUsd(curve[0].AmountIn, decimals[tokenIn], price.Price)
+			amplification += depthUsd / (reserveInUsd * cpDepthRatio)
+			pairs++
+		}
+	}
+	if pairs == 0 {
+		return 0
+	}
+	return reserveUsd * amplification / float64(pairs)
+}
@@ -16,13 +16,21 @@ type RFQParams struct {
 	Source       string // source client
 	RequestID    string // request id from getRoute
 	AlphaFee     string
+	// IndicativeAmountOut is the amount out simulated from the price levels the route was built on, to validate the
+	// firm quote against, see RFQValidation. Unchecked if nil.
+	IndicativeAmountOut *big.Int
 }
 
 type RFQResult struct {
 	NewAmountOut  *big.Int
 	AlphaFee      *big.Int
 	AlphaFeeAsset string
-	Extra         any
+	// Deadline is the unix time the firm quote expires at, 0 if the maker does not report it.
+	Deadline int64
+	// DeviationBps is the shortfall of NewAmountOut from RFQParams.IndicativeAmountOut in basis points, negative if
+	// the firm quote is better than indicated. It is set by RFQValidation.Validate.
+	DeviationBps int64
+	Extra        any
 }
 
 type RFQHandler struct{}
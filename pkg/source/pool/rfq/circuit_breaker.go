@@ -0,0 +1,109 @@
+package rfq
+
+import (
+	"sync"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+)
+
+const (
+	DefaultFailureThreshold = 5
+	DefaultOpenDuration     = 30 * time.Second
+)
+
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures marking a maker unhealthy, DefaultFailureThreshold if not
+	// set. A negative threshold disables the circuit breaker.
+	FailureThreshold int `mapstructure:"failure_threshold" json:"failure_threshold,omitempty"`
+	// OpenDuration is how long requests to an unhealthy maker are rejected before a trial request is let through,
+	// DefaultOpenDuration if not set.
+	OpenDuration durationjson.Duration `mapstructure:"open_duration" json:"open_duration,omitempty"`
+}
+
+// CircuitBreaker tracks the health of a maker from the outcome of its requests. It is closed while the maker is
+// healthy, opens after FailureThreshold consecutive failures, and lets a single trial request through once
+// OpenDuration has elapsed: the maker is healthy again if it succeeds, and unhealthy for another OpenDuration otherwise.
+type CircuitBreaker struct {
+	failureThreshold int
+	openDuration     time.Duration
+	now              func() time.Time
+
+	mu                  sync.Mutex
+	consecutiveFailures int
+	openUntil           time.Time
+	trialInFlight       bool
+}
+
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	failureThreshold := config.FailureThreshold
+	if failureThreshold == 0 {
+		failureThreshold = DefaultFailureThreshold
+	}
+	openDuration := config.OpenDuration.Duration
+	if openDuration <= 0 {
+		openDuration = DefaultOpenDuration
+	}
+	return &CircuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a request can be sent to the maker. Requests allowed after the breaker opened are trials,
+// whose outcome must be reported with Record.
+func (b *CircuitBreaker) Allow() bool {
+	if b.failureThreshold < 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.consecutiveFailures < b.failureThreshold {
+		return true
+	}
+	if b.trialInFlight || b.now().Before(b.openUntil) {
+		return false
+	}
+	b.trialInFlight = true
+	return true
+}
+
+// Record reports the outcome of an allowed request, and returns whether the maker health changed.
+func (b *CircuitBreaker) Record(success bool) (changed bool) {
+	if b.failureThreshold < 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasHealthy := b.consecutiveFailures < b.failureThreshold
+	b.trialInFlight = false
+	if success {
+		b.consecutiveFailures = 0
+		return !wasHealthy
+	}
+	if b.consecutiveFailures++; b.consecutiveFailures >= b.failureThreshold {
+		b.openUntil = b.now().Add(b.openDuration)
+	}
+	return wasHealthy && b.consecutiveFailures >= b.failureThreshold
+}
+
+// Cancel reports that an allowed request was canceled by the caller, telling nothing about the maker health.
+func (b *CircuitBreaker) Cancel() {
+	if b.failureThreshold < 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trialInFlight = false
+}
+
+// Healthy reports whether the maker is healthy, that is whether the breaker is closed.
+func (b *CircuitBreaker) Healthy() bool {
+	if b.failureThreshold < 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.consecutiveFailures < b.failureThreshold
+}
@@ -0,0 +1,50 @@
+package rfq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenDuration:     durationjson.Duration{Duration: time.Minute},
+	})
+	b.now = func() time.Time { return now }
+
+	assert.True(t, b.Allow())
+	assert.False(t, b.Record(false))
+	assert.True(t, b.Allow())
+	assert.True(t, b.Record(false))
+	assert.False(t, b.Healthy())
+	assert.False(t, b.Allow())
+
+	// a single trial is let through once open for OpenDuration, and reopens the breaker on failure
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	assert.False(t, b.Allow())
+	assert.False(t, b.Record(false))
+	assert.False(t, b.Allow())
+
+	// a canceled trial lets another through
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow())
+	b.Cancel()
+	assert.True(t, b.Allow())
+	assert.True(t, b.Record(true))
+	assert.True(t, b.Healthy())
+	assert.True(t, b.Allow())
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	b := NewCircuitBreaker(CircuitBreakerConfig{FailureThreshold: -1})
+	for range DefaultFailureThreshold {
+		assert.False(t, b.Record(false))
+	}
+	assert.True(t, b.Allow())
+	assert.True(t, b.Healthy())
+}
@@ -0,0 +1,202 @@
+// Package rfq is the HTTP transport shared by the clients of RFQ market makers. It bounds each request with the
+// timeouts of its maker, retries the idempotent requests failing with transport errors, rate limits and server errors
+// with jittered backoff, stops calling makers failing repeatedly with a circuit breaker, reports latencies and errors to
+// Metrics and classifies errors uniformly, see Error.
+package rfq
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/KyberNetwork/kutils/klog"
+	"github.com/go-resty/resty/v2"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util"
+)
+
+const maxLoggedBodyBytes = 256
+
+type Config struct {
+	// Maker names the market maker in logs and metrics, usually its DexType.
+	Maker   string
+	BaseURL string
+	// Timeout bounds each attempt of a request.
+	Timeout durationjson.Duration
+	// TotalTimeout bounds a request with all its attempts and the waits between them, Timeout if not set.
+	TotalTimeout durationjson.Duration
+	// RetryCount is the number of retries of the requests created with RIdempotent.
+	RetryCount int
+	// RetryWaitTime and RetryMaxWaitTime bound the jittered exponential backoff between retries, resty defaults if
+	// not set.
+	RetryWaitTime    durationjson.Duration
+	RetryMaxWaitTime durationjson.Duration
+	CircuitBreaker   CircuitBreakerConfig
+	// RequestIDHeader is the response header holding the request id of the maker, logged on failures if set.
+	RequestIDHeader string
+}
+
+// Metrics receives the measurements of RFQ requests.
+type Metrics interface {
+	// ObserveRequest is called once per request, with its latency including retries and its error, nil on success.
+	ObserveRequest(maker, path string, latency time.Duration, attempts int, err error)
+	// SetMakerHealthy is called when the circuit breaker of maker opens or closes.
+	SetMakerHealthy(maker string, healthy bool)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(string, string, time.Duration, int, error) {}
+
+func (nopMetrics) SetMakerHealthy(string, bool) {}
+
+type Option func(*Client)
+
+// WithMetrics reports the measurements of requests to metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(c *Client) {
+		c.metrics = metrics
+	}
+}
+
+// WithHeader sets a header on all requests.
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.client.SetHeader(key, value)
+	}
+}
+
+// WithHeaderVerbatim sets a header on all requests without canonicalizing its key.
+func WithHeaderVerbatim(key, value string) Option {
+	return func(c *Client) {
+		c.client.SetHeaderVerbatim(key, value)
+	}
+}
+
+type Client struct {
+	config  Config
+	client  *resty.Client
+	breaker *CircuitBreaker
+	metrics Metrics
+}
+
+func NewClient(config Config, opts ...Option) *Client {
+	client := resty.New().
+		SetBaseURL(config.BaseURL).
+		SetTimeout(config.Timeout.Duration).
+		SetRetryCount(config.RetryCount).
+		// without retry conditions, resty retries every transport error: only retry the requests adding retryable
+		AddRetryCondition(func(*resty.Response, error) bool { return false })
+	if config.RetryWaitTime.Duration > 0 {
+		client.SetRetryWaitTime(config.RetryWaitTime.Duration)
+	}
+	if config.RetryMaxWaitTime.Duration > 0 {
+		client.SetRetryMaxWaitTime(config.RetryMaxWaitTime.Duration)
+	}
+
+	c := &Client{
+		config:  config,
+		client:  client,
+		breaker: NewCircuitBreaker(config.CircuitBreaker),
+		metrics: nopMetrics{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// R returns a new request bound to ctx, to be sent with Execute. It is never retried: firm quotes are not idempotent,
+// the maker may have issued the quote of a request failing with a transport error, and issuing another one could
+// commit its inventory twice.
+func (c *Client) R(ctx context.Context) *resty.Request {
+	return c.client.R().SetContext(ctx)
+}
+
+// RIdempotent returns a new request bound to ctx, to be sent with Execute, that can safely be sent again, such as a
+// read or an indicative quote. It is retried up to RetryCount times on transport errors, rate limits and server errors.
+func (c *Client) RIdempotent(ctx context.Context) *resty.Request {
+	return c.R(ctx).AddRetryCondition(retryable)
+}
+
+func retryable(resp *resty.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp != nil && (resp.StatusCode() == http.StatusTooManyRequests ||
+		resp.StatusCode() >= http.StatusInternalServerError)
+}
+
+// Healthy reports whether the maker is healthy, that is whether its circuit breaker is closed.
+func (c *Client) Healthy() bool {
+	return c.breaker.Healthy()
+}
+
+// Execute sends req with method to path, retrying it if created with RIdempotent, all attempts being bounded by
+// TotalTimeout. It returns an Error of class ErrMakerUnhealthy without sending req while the circuit breaker of the
+// maker is open, and of class ErrTimeout or ErrTransport if no response was received. Unsuccessful responses are
+// returned without error, for the caller to parse the domain error of the maker and report it with Fail.
+func (c *Client) Execute(req *resty.Request, method, path string) (*resty.Response, error) {
+	if !c.breaker.Allow() {
+		err := &Error{Class: ErrMakerUnhealthy}
+		c.metrics.ObserveRequest(c.config.Maker, path, 0, 0, err)
+		return nil, err
+	}
+
+	if totalTimeout := cmp.Or(c.config.TotalTimeout.Duration, c.config.Timeout.Duration); totalTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), totalTimeout)
+		defer cancel()
+		req.SetContext(ctx)
+	}
+
+	start := time.Now()
+	resp, err := req.Execute(method, path)
+	latency := time.Since(start)
+
+	attempts := req.Attempt
+	var class error
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			c.breaker.Cancel()
+			c.metrics.ObserveRequest(c.config.Maker, path, latency, attempts, err)
+			return resp, err
+		}
+		class = classifyTransport(err)
+		err = &Error{Class: class, Err: err}
+	} else if !resp.IsSuccess() {
+		class = classifyStatus(resp.StatusCode())
+	}
+
+	if c.breaker.Record(!isMakerFailure(class)) {
+		c.metrics.SetMakerHealthy(c.config.Maker, c.breaker.Healthy())
+	}
+	observedErr := err
+	if err == nil && class != nil {
+		observedErr = &Error{Class: class, StatusCode: resp.StatusCode()}
+	}
+	c.metrics.ObserveRequest(c.config.Maker, path, latency, attempts, observedErr)
+	return resp, err
+}
+
+// Fail logs the failure of a request with msg, and returns domainErr, the error parsed from resp, classified by the
+// status of resp: ErrRateLimited, ErrServer or ErrBadRequest if unsuccessful, ErrRejected otherwise.
+func (c *Client) Fail(ctx context.Context, resp *resty.Response, msg string, domainErr error) error {
+	fields := klog.Fields{
+		"rfq.client": c.config.Maker,
+		"rfq.resp":   util.MaxBytesToString(resp.Body(), maxLoggedBodyBytes),
+		"rfq.status": resp.StatusCode(),
+	}
+	if c.config.RequestIDHeader != "" {
+		fields["rfq.request_id"] = resp.Header().Get(c.config.RequestIDHeader)
+	}
+	klog.WithFields(ctx, fields).Error(msg)
+
+	class := ErrRejected
+	if !resp.IsSuccess() {
+		class = classifyStatus(resp.StatusCode())
+	}
+	return &Error{Class: class, StatusCode: resp.StatusCode(), Err: domainErr}
+}
@@ -0,0 +1,171 @@
+package rfq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testMetrics struct {
+	attempts []int
+	errs     []error
+	healthy  []bool
+}
+
+func (m *testMetrics) ObserveRequest(_, _ string, _ time.Duration, attempts int, err error) {
+	m.attempts = append(m.attempts, attempts)
+	m.errs = append(m.errs, err)
+}
+
+func (m *testMetrics) SetMakerHealthy(_ string, healthy bool) {
+	m.healthy = append(m.healthy, healthy)
+}
+
+// newTestServer returns a server answering with the statuses in turn, then with the last one.
+func newTestServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		call := int(calls.Add(1)) - 1
+		w.WriteHeader(statuses[min(call, len(statuses)-1)])
+		_, _ = w.Write([]byte(`{"error":"insufficient liquidity"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestClient(server *httptest.Server, metrics Metrics) *Client {
+	return NewClient(Config{
+		Maker:            "maker",
+		BaseURL:          server.URL,
+		Timeout:          durationjson.Duration{Duration: time.Second},
+		RetryCount:       2,
+		RetryWaitTime:    durationjson.Duration{Duration: time.Millisecond},
+		RetryMaxWaitTime: durationjson.Duration{Duration: time.Millisecond},
+		CircuitBreaker:   CircuitBreakerConfig{FailureThreshold: 2, OpenDuration: durationjson.Duration{Duration: time.Hour}},
+	}, WithMetrics(metrics), WithHeader("x-api-key", "key"))
+}
+
+func TestClient_Execute_Retries(t *testing.T) {
+	server, calls := newTestServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	metrics := &testMetrics{}
+	c := newTestClient(server, metrics)
+
+	resp, err := c.Execute(c.RIdempotent(context.Background()), http.MethodGet, "/quote")
+	require.NoError(t, err)
+	assert.True(t, resp.IsSuccess())
+	assert.EqualValues(t, 3, calls.Load())
+	assert.Equal(t, []int{3}, metrics.attempts)
+	assert.Equal(t, []error{nil}, metrics.errs)
+	assert.True(t, c.Healthy())
+}
+
+func TestClient_Execute_NotIdempotent(t *testing.T) {
+	server, calls := newTestServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	c := newTestClient(server, &testMetrics{})
+
+	// a firm quote the maker failed to answer is not requested again
+	resp, err := c.Execute(c.R(context.Background()), http.MethodPost, "/firm")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode())
+	assert.EqualValues(t, 1, calls.Load())
+
+	// nor on transport errors
+	server.CloseClientConnections()
+	server.Close()
+	_, err = c.Execute(c.R(context.Background()), http.MethodPost, "/firm")
+	assert.ErrorIs(t, err, ErrTransport)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestClient_Execute_CircuitBreaker(t *testing.T) {
+	server, calls := newTestServer(t, http.StatusInternalServerError)
+	metrics := &testMetrics{}
+	c := newTestClient(server, metrics)
+
+	for range 2 {
+		resp, err := c.Execute(c.RIdempotent(context.Background()), http.MethodGet, "/quote")
+		require.NoError(t, err)
+		assert.ErrorIs(t, c.Fail(context.Background(), resp, "quote failed", errors.New("failed")), ErrServer)
+	}
+	assert.EqualValues(t, 6, calls.Load())
+	assert.Equal(t, []bool{false}, metrics.healthy)
+	assert.False(t, c.Healthy())
+	assert.ErrorIs(t, metrics.errs[0], ErrServer)
+
+	_, err := c.Execute(c.R(context.Background()), http.MethodGet, "/quote")
+	assert.ErrorIs(t, err, ErrMakerUnhealthy)
+	assert.Equal(t, ErrMakerUnhealthy, Class(err))
+	assert.EqualValues(t, 6, calls.Load())
+}
+
+func TestClient_Fail(t *testing.T) {
+	errInsufficientLiquidity := errors.New("insufficient liquidity")
+	testCases := []struct {
+		name   string
+		status int
+		class  error
+	}{
+		{name: "bad request", status: http.StatusBadRequest, class: ErrBadRequest},
+		{name: "rejected", status: http.StatusOK, class: ErrRejected},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server, calls := newTestServer(t, tc.status)
+			c := newTestClient(server, &testMetrics{})
+
+			resp, err := c.Execute(c.R(context.Background()), http.MethodPost, "/quote")
+			require.NoError(t, err)
+			err = c.Fail(context.Background(), resp, "quote failed", errInsufficientLiquidity)
+			assert.ErrorIs(t, err, errInsufficientLiquidity)
+			assert.ErrorIs(t, err, tc.class)
+			assert.Equal(t, "insufficient liquidity", err.Error())
+			assert.EqualValues(t, 1, calls.Load())
+			assert.True(t, c.Healthy())
+		})
+	}
+}
+
+func TestClient_Execute_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(Config{Maker: "maker", BaseURL: server.URL, Timeout: durationjson.Duration{Duration: 10 * time.Millisecond}})
+
+	_, err := c.Execute(c.R(context.Background()), http.MethodGet, "/quote")
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func TestClient_Execute_TotalTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+	}))
+	t.Cleanup(server.Close)
+	c := NewClient(Config{
+		Maker:            "maker",
+		BaseURL:          server.URL,
+		Timeout:          durationjson.Duration{Duration: 40 * time.Millisecond},
+		TotalTimeout:     durationjson.Duration{Duration: 100 * time.Millisecond},
+		RetryCount:       10,
+		RetryWaitTime:    durationjson.Duration{Duration: time.Millisecond},
+		RetryMaxWaitTime: durationjson.Duration{Duration: time.Millisecond},
+	})
+
+	// each attempt times out after 40ms, the retries stopping at the 100ms total timeout
+	start := time.Now()
+	_, err := c.Execute(c.RIdempotent(context.Background()), http.MethodGet, "/quote")
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+	assert.LessOrEqual(t, calls.Load(), int32(3))
+}
@@ -0,0 +1,86 @@
+package rfq
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// Error classes of RFQ requests, matched with errors.Is against the errors returned by Client.
+var (
+	ErrTimeout        = errors.New("rfq: timeout")
+	ErrTransport      = errors.New("rfq: transport error")
+	ErrRateLimited    = errors.New("rfq: rate limited")
+	ErrServer         = errors.New("rfq: server error")
+	ErrBadRequest     = errors.New("rfq: bad request")
+	ErrRejected       = errors.New("rfq: rejected")
+	ErrMakerUnhealthy = errors.New("rfq: maker unhealthy")
+)
+
+// Error is an error of an RFQ request, classified by Class, one of the error classes of this package. It keeps the
+// message of Err, the error of the transport or the domain error parsed from the response of the maker, so that both
+// Err and Class match with errors.Is.
+type Error struct {
+	Class      error
+	StatusCode int
+	Err        error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Class.Error()
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Class}
+	}
+	return []error{e.Err, e.Class}
+}
+
+// Class returns the error class of err, nil if err is not an RFQ error.
+func Class(err error) error {
+	var rfqErr *Error
+	if errors.As(err, &rfqErr) {
+		return rfqErr.Class
+	}
+	return nil
+}
+
+// classifyStatus returns the error class of an unsuccessful response, or ErrRejected for a successful response
+// carrying a domain error.
+func classifyStatus(statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode >= http.StatusInternalServerError:
+		return ErrServer
+	case statusCode >= http.StatusBadRequest:
+		return ErrBadRequest
+	default:
+		return ErrRejected
+	}
+}
+
+// classifyTransport returns the error class of a request that failed without a response.
+func classifyTransport(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrTimeout
+	}
+	return ErrTransport
+}
+
+// isMakerFailure reports whether an error class is attributed to the maker, counting towards its circuit breaker.
+// Bad requests and rejections are answers of a healthy maker.
+func isMakerFailure(class error) bool {
+	switch class {
+	case ErrTimeout, ErrTransport, ErrRateLimited, ErrServer:
+		return true
+	default:
+		return false
+	}
+}
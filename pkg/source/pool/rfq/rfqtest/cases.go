@@ -0,0 +1,127 @@
+package rfqtest
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/rfq"
+)
+
+// Case is an end-to-end test case of an RFQ handler: the quote scripted on the Server and the expected result.
+type Case struct {
+	Name  string
+	Quote Quote
+	// IndicativeAmountOut is the RFQParams.IndicativeAmountOut of the request, unchecked if nil.
+	IndicativeAmountOut *big.Int
+	// ErrIs are the errors expected in the chain of the returned error. If empty, the RFQ must succeed with AmountOut,
+	// Deadline if set and DeviationBps, then pass Check if set.
+	ErrIs        []error
+	AmountOut    string
+	Deadline     int64
+	DeviationBps int64
+	Check        func(t *testing.T, res *pool.RFQResult)
+}
+
+// latency is the delay of the latency case, beyond the timeout of the clients under test.
+const latency = time.Second
+
+// Cases returns the cases shared by every maker, for a swap of amountIn quoted amountOut by the price levels:
+//   - a quote of amountOut;
+//   - a requote within the default RFQValidation tolerance, setting the deviation, and one beyond it, rejected;
+//   - for makers reporting the filled amount, a partial fill of half amountIn, rejected;
+//   - for makers reporting the expiry, an expired quote, rejected;
+//   - a reply slower than the client timeout, which must be below a second.
+func (m Maker) Cases(amountIn, amountOut string) []Case {
+	in, out := mustBig(amountIn), mustBig(amountOut)
+	// mulDiv returns x * num / den
+	mulDiv := func(x *big.Int, num, den int64) string {
+		return new(big.Int).Div(new(big.Int).Mul(x, big.NewInt(num)), big.NewInt(den)).String()
+	}
+
+	cases := []Case{
+		{
+			Name:                "quote",
+			Quote:               Quote{AmountOut: amountOut, Expiry: 2e9},
+			IndicativeAmountOut: out,
+			AmountOut:           amountOut,
+		},
+		{
+			Name:                "requote within tolerance",
+			Quote:               Quote{AmountOut: mulDiv(out, 9950, 10000)},
+			IndicativeAmountOut: out,
+			AmountOut:           mulDiv(out, 9950, 10000),
+			DeviationBps:        50,
+		},
+		{
+			Name:                "requote beyond tolerance",
+			Quote:               Quote{AmountOut: mulDiv(out, 9800, 10000)},
+			IndicativeAmountOut: out,
+			ErrIs:               []error{pool.ErrRFQAmountOutDeviates},
+		},
+		{
+			Name:  "latency",
+			Quote: Quote{AmountOut: amountOut, Delay: latency},
+			ErrIs: []error{rfq.ErrTimeout},
+		},
+	}
+	if m.reportsExpiry {
+		cases[0].Deadline = 2e9
+		cases = append(cases, Case{
+			Name:  "expired quote",
+			Quote: Quote{AmountOut: amountOut, Expiry: 1},
+			ErrIs: []error{pool.ErrRFQQuoteExpiring},
+		})
+	}
+	if m.reportsFill {
+		cases = append(cases, Case{
+			Name:                "partial fill",
+			Quote:               Quote{AmountIn: mulDiv(in, 1, 2), AmountOut: mulDiv(out, 1, 2)},
+			IndicativeAmountOut: out,
+			ErrIs:               []error{pool.ErrRFQPartialFill},
+		})
+	}
+	return cases
+}
+
+// Run runs cases as subtests of t, rfq requesting a firm quote with indicative amount out from a Server of maker
+// scripted with the quote of the case.
+func Run(t *testing.T, maker Maker, cases []Case,
+	rfq func(t *testing.T, server *Server, indicative *big.Int) (*pool.RFQResult, error)) {
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			server := NewServer(t, maker, tc.Quote)
+			res, err := rfq(t, server, tc.IndicativeAmountOut)
+			if len(tc.ErrIs) > 0 {
+				for _, target := range tc.ErrIs {
+					assert.ErrorIs(t, err, target)
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			if tc.AmountOut != "" {
+				assert.Equal(t, tc.AmountOut, res.NewAmountOut.String())
+			}
+			if tc.Deadline != 0 {
+				assert.Equal(t, tc.Deadline, res.Deadline)
+			}
+			assert.Equal(t, tc.DeviationBps, res.DeviationBps)
+			if tc.Check != nil {
+				tc.Check(t, res)
+			}
+		})
+	}
+}
+
+func mustBig(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("rfqtest: invalid amount " + s)
+	}
+	return x
+}
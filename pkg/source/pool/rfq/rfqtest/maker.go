@@ -0,0 +1,356 @@
+package rfqtest
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Maker is the firm quote protocol of a market maker: the routes of its API and the encoding of its quotes and errors.
+type Maker struct {
+	Name string
+	// reportsFill and reportsExpiry are whether the quotes of the maker report the filled amount in and their expiry,
+	// so that partial fills and expired quotes can be scripted.
+	reportsFill   bool
+	reportsExpiry bool
+	routes        map[string]func(s *Server, req Request) reply
+}
+
+const (
+	mockMaker     = "0x1111111111111111111111111111111111111111"
+	mockSignature = "0x" + "ab" + "00000000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000000" + "1b"
+	mockTarget = "0x2222222222222222222222222222222222222222"
+)
+
+// errorReply answers with the error of q, at the status of q or the error status of the maker.
+func errorReply(q Quote, status int, body any) reply {
+	if q.Status != 0 {
+		status = q.Status
+	}
+	return reply{status: status, body: body, delay: q.Delay}
+}
+
+// failed reports whether q is answered with an error rather than a quote.
+func (q Quote) failed() bool {
+	return q.Error != "" || q.Status >= http.StatusBadRequest
+}
+
+// KyberPMM answers batches of firm quotes, an order per scripted quote. An error of the first order fails the batch,
+// errors of later orders only fail their order.
+var KyberPMM = Maker{
+	Name:          "kyber-pmm",
+	reportsFill:   true,
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"POST /kyberswap/v1/firm-batch": func(s *Server, req Request) reply {
+			var params struct {
+				RequestID   string `json:"request_id"`
+				UserAddress string `json:"user_address"`
+				RFQSender   string `json:"rfq_sender"`
+				Orders      []struct {
+					MakerAsset  string `json:"maker_asset"`
+					TakerAsset  string `json:"taker_asset"`
+					TakerAmount string `json:"taker_amount"`
+				} `json:"orders"`
+			}
+			if err := req.Decode(&params); err != nil || len(params.Orders) == 0 {
+				return reply{status: http.StatusBadRequest, body: map[string]any{"error": "invalid_params"}}
+			}
+
+			first := s.next()
+			if first.failed() {
+				return errorReply(first, http.StatusOK, map[string]any{"error": first.Error})
+			}
+			orders := make([]map[string]any, 0, len(params.Orders))
+			for i, order := range params.Orders {
+				q := first
+				if i > 0 {
+					q = s.next()
+				}
+				if q.Error != "" {
+					orders = append(orders, map[string]any{"error": q.Error})
+					continue
+				}
+				orders = append(orders, map[string]any{
+					"info":         fmt.Sprintf("%s-%d", params.RequestID, i),
+					"maker_asset":  order.MakerAsset,
+					"taker_asset":  order.TakerAsset,
+					"maker_amount": q.AmountOut,
+					"taker_amount": q.amountIn(order.TakerAmount),
+					"fee_amount":   "0",
+					"signature":    mockSignature,
+				})
+			}
+			return reply{
+				body: map[string]any{
+					"orders":         orders,
+					"expiry":         first.expiry(),
+					"maker":          mockMaker,
+					"taker":          params.RFQSender,
+					"allowed_sender": params.RFQSender,
+				},
+				delay: first.Delay,
+			}
+		},
+	},
+}
+
+// Bebop answers single order quotes. Error is its numeric error code.
+var Bebop = Maker{
+	Name:          "bebop",
+	reportsFill:   true,
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"GET /v3/quote": func(s *Server, req Request) reply {
+			q := s.next()
+			if q.failed() {
+				code, _ := strconv.Atoi(q.Error)
+				return errorReply(q, http.StatusBadRequest, map[string]any{
+					"error": map[string]any{"errorCode": code, "message": q.Error},
+				})
+			}
+			expiry := q.expiry()
+			return reply{
+				body: map[string]any{
+					"status":           "QUOTE_SUCCESS",
+					"quoteId":          fmt.Sprintf("quote-%d", s.served),
+					"expiry":           expiry,
+					"taker":            req.Query.Get("taker_address"),
+					"receiver":         req.Query.Get("receiver_address"),
+					"onchainOrderType": "SingleOrder",
+					"toSign": map[string]any{
+						"expiry":        expiry,
+						"taker_address": req.Query.Get("taker_address"),
+						"maker_address": mockMaker,
+						"maker_nonce":   strconv.Itoa(s.served),
+						"taker_token":   req.Query.Get("sell_tokens"),
+						"maker_token":   req.Query.Get("buy_tokens"),
+						"taker_amount":  q.amountIn(req.Query.Get("sell_amounts")),
+						"maker_amount":  q.AmountOut,
+						"receiver":      req.Query.Get("receiver_address"),
+					},
+				},
+				delay: q.Delay,
+			}
+		},
+	},
+}
+
+// Clipper answers quotes, then signs them. Quote errors are answered to the sign request, where Clipper rejects
+// quotes conflicting with its latest prices.
+var Clipper = Maker{
+	Name:          "clipper",
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"POST /rfq/quote": func(s *Server, req Request) reply {
+			q := s.next()
+			id := fmt.Sprintf("quote-%d", s.served)
+			s.issue(id, q)
+			return reply{
+				body: map[string]any{
+					"id":            id,
+					"good_until":    q.expiry(),
+					"output_amount": q.AmountOut,
+				},
+				delay: q.Delay,
+			}
+		},
+		"POST /rfq/sign": func(s *Server, req Request) reply {
+			var params struct {
+				QuoteID string `json:"quote_id"`
+			}
+			_ = req.Decode(&params)
+			q, ok := s.lookup(params.QuoteID)
+			if !ok {
+				q = Quote{Error: "Quote not found"}
+			}
+			if q.failed() {
+				return errorReply(q, http.StatusBadRequest, map[string]any{
+					"errorMessage": q.Error,
+					"errorType":    "QuoteError",
+				})
+			}
+			return reply{body: map[string]any{
+				"output_amount": q.AmountOut,
+				"good_until":    strconv.FormatInt(q.expiry(), 10),
+				"signature": map[string]any{
+					"v": 27,
+					"r": "0x" + fmt.Sprintf("%064x", 1),
+					"s": "0x" + fmt.Sprintf("%064x", 2),
+				},
+			}}
+		},
+	},
+}
+
+// Dexalot answers firm quotes. Error is its reason code.
+var Dexalot = Maker{
+	Name:          "dexalot",
+	reportsFill:   true,
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"POST /api/rfq/firm": func(s *Server, req Request) reply {
+			var params struct {
+				TakerAsset  string `json:"takerAsset"`
+				MakerAsset  string `json:"makerAsset"`
+				TakerAmount string `json:"takerAmount"`
+				UserAddress string `json:"userAddress"`
+				Executor    string `json:"executor"`
+			}
+			_ = req.Decode(&params)
+			q := s.next()
+			if q.failed() {
+				return errorReply(q, http.StatusBadRequest, map[string]any{
+					"Success":    false,
+					"ReasonCode": q.Error,
+					"Reason":     q.Error,
+				})
+			}
+			return reply{
+				body: map[string]any{
+					"order": map[string]any{
+						"nonceAndMeta": fmt.Sprintf("0x%064x", s.served),
+						"expiry":       q.expiry(),
+						"makerAsset":   params.MakerAsset,
+						"takerAsset":   params.TakerAsset,
+						"maker":        mockMaker,
+						"taker":        params.Executor,
+						"makerAmount":  q.AmountOut,
+						"takerAmount":  q.amountIn(params.TakerAmount),
+					},
+					"signature": mockSignature,
+					"tx":        map[string]any{"to": mockTarget, "data": "0x", "gasLimit": 200000},
+				},
+				delay: q.Delay,
+			}
+		},
+	},
+}
+
+// HashflowV3 answers batches of RFQs, a quote per scripted quote. An error of any RFQ fails the batch, with its
+// message.
+var HashflowV3 = Maker{
+	Name:          "hashflow-v3",
+	reportsFill:   true,
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"POST /taker/v3/rfq": func(s *Server, req Request) reply {
+			var params struct {
+				BaseChain  map[string]any `json:"baseChain"`
+				QuoteChain map[string]any `json:"quoteChain"`
+				RFQs       []struct {
+					BaseToken       string `json:"baseToken"`
+					QuoteToken      string `json:"quoteToken"`
+					BaseTokenAmount string `json:"baseTokenAmount"`
+					Trader          string `json:"trader"`
+					EffectiveTrader string `json:"effectiveTrader"`
+				} `json:"rfqs"`
+			}
+			_ = req.Decode(&params)
+			quotes := make([]map[string]any, 0, len(params.RFQs))
+			var delay time.Duration
+			for i, rfq := range params.RFQs {
+				q := s.next()
+				delay = max(delay, q.Delay)
+				if q.failed() {
+					return errorReply(q, http.StatusOK, map[string]any{
+						"status": "fail",
+						"error":  map[string]any{"code": 400, "message": q.Error},
+					})
+				}
+				quotes = append(quotes, map[string]any{
+					"quoteData": map[string]any{
+						"baseChain":        params.BaseChain,
+						"quoteChain":       params.QuoteChain,
+						"baseToken":        rfq.BaseToken,
+						"baseTokenAmount":  q.amountIn(rfq.BaseTokenAmount),
+						"quoteToken":       rfq.QuoteToken,
+						"quoteTokenAmount": q.AmountOut,
+						"trader":           rfq.Trader,
+						"effectiveTrader":  rfq.EffectiveTrader,
+						"txid":             fmt.Sprintf("0x%064x", i),
+						"pool":             mockMaker,
+						"quoteExpiry":      q.expiry(),
+						"nonce":            s.served,
+					},
+					"signature": mockSignature,
+				})
+			}
+			return reply{
+				body:  map[string]any{"status": "success", "rfqId": "rfq", "quotes": quotes},
+				delay: delay,
+			}
+		},
+	},
+}
+
+// NativeV1 answers firm quotes as router calldata, which does not expose fill amounts nor expiries. Error is its
+// error message.
+var NativeV1 = Maker{
+	Name: "native-v1",
+	routes: map[string]func(s *Server, req Request) reply{
+		"GET /v1/firm-quote": func(s *Server, req Request) reply {
+			q := s.next()
+			if q.failed() {
+				rep := errorReply(q, http.StatusBadRequest, nil)
+				rep.body = map[string]any{"statusCode": rep.status, "message": q.Error}
+				return rep
+			}
+			return reply{
+				body: map[string]any{
+					"txRequest":              map[string]any{"target": mockTarget, "calldata": "0x"},
+					"amountOut":              q.AmountOut,
+					"amountInOffset":         4,
+					"amountOutMinimumOffset": 36,
+				},
+				delay: q.Delay,
+			}
+		},
+	},
+}
+
+// MXTrading answers 1inch limit orders, expiring at the expiration of their maker traits. Error is its error message.
+var MXTrading = Maker{
+	Name:          "mx-trading",
+	reportsFill:   true,
+	reportsExpiry: true,
+	routes: map[string]func(s *Server, req Request) reply{
+		"POST /order": func(s *Server, req Request) reply {
+			var params struct {
+				BaseToken  string `json:"baseToken"`
+				QuoteToken string `json:"quoteToken"`
+				Amount     string `json:"amount"`
+				Taker      string `json:"taker"`
+			}
+			_ = req.Decode(&params)
+			q := s.next()
+			if q.failed() {
+				return errorReply(q, http.StatusBadRequest, q.Error)
+			}
+			return reply{
+				body: map[string]any{
+					"order": map[string]any{
+						"makerAsset":   params.QuoteToken,
+						"takerAsset":   params.BaseToken,
+						"makingAmount": q.AmountOut,
+						"takingAmount": q.amountIn(params.Amount),
+						"maker":        mockMaker,
+						"salt":         strconv.Itoa(s.served),
+						"receiver":     "0x0000000000000000000000000000000000000000",
+						"makerTraits":  MakerTraitsExpiring(q.expiry()).String(),
+					},
+					"signature": mockSignature,
+				},
+				delay: q.Delay,
+			}
+		},
+	},
+}
+
+// MakerTraitsExpiring returns the 1inch maker traits of an order expiring at expiry, in bits 80 to 119.
+func MakerTraitsExpiring(expiry int64) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(expiry), 80)
+}
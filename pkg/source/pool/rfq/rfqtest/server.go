@@ -0,0 +1,165 @@
+// Package rfqtest provides an in-process mock of the firm quote APIs of RFQ market makers, to test RFQ handlers end to
+// end without calling live makers. A Server speaks the protocol of a Maker and answers its requests with scripted
+// quotes: partial fills, requotes, expired quotes, errors of the maker and latency.
+package rfqtest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+)
+
+// Quote scripts the answer of the maker to a quote request, or to an order of a batch request.
+type Quote struct {
+	// AmountIn is the filled amount of the taker token, the requested amount if empty. Partial fills are quoted with
+	// less than requested, for makers reporting the filled amount.
+	AmountIn string
+	// AmountOut is the firm amount of the maker token. Requotes are quoted with another amount than indicated by the
+	// price levels of the pool.
+	AmountOut string
+	// Expiry is the unix time the quote expires at, DefaultTTL from now if zero. Expired quotes are quoted with an
+	// Expiry in the past.
+	Expiry int64
+	// Error is the error code or message of the maker, replied instead of the quote.
+	Error string
+	// Status is the HTTP status of the reply, 200 for quotes and a maker specific error status for errors if not set.
+	Status int
+	// Delay is the latency of the reply.
+	Delay time.Duration
+}
+
+// DefaultTTL is the lifetime of quotes without an Expiry.
+const DefaultTTL = time.Hour
+
+func (q Quote) expiry() int64 {
+	if q.Expiry != 0 {
+		return q.Expiry
+	}
+	return time.Now().Add(DefaultTTL).Unix()
+}
+
+func (q Quote) amountIn(requested string) string {
+	if q.AmountIn != "" {
+		return q.AmountIn
+	}
+	return requested
+}
+
+// Request is a request received by a Server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Decode decodes the JSON body of the request into v.
+func (r Request) Decode(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// reply is the answer to a request: a JSON encoded body with status after delay.
+type reply struct {
+	status int
+	body   any
+	delay  time.Duration
+}
+
+type Server struct {
+	*httptest.Server
+	maker Maker
+
+	mu       sync.Mutex
+	quotes   []Quote
+	served   int
+	issued   map[string]Quote
+	requests []Request
+}
+
+// NewServer starts a Server speaking the protocol of maker, answering with quotes in turn and then with the last
+// one, closed with the test.
+func NewServer(t testing.TB, maker Maker, quotes ...Quote) *Server {
+	s := &Server{maker: maker, quotes: quotes, issued: make(map[string]Quote)}
+	s.Server = httptest.NewServer(s)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Script replaces the quotes of the server, answered in turn and then the last one.
+func (s *Server) Script(quotes ...Quote) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotes, s.served = quotes, 0
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// next returns the next scripted quote, an empty Quote if none is scripted.
+func (s *Server) next() Quote {
+	if len(s.quotes) == 0 {
+		return Quote{}
+	}
+	q := s.quotes[min(s.served, len(s.quotes)-1)]
+	s.served++
+	return q
+}
+
+// issue records q as the quote of id, for makers signing quotes in a second request.
+func (s *Server) issue(id string, q Quote) {
+	s.issued[id] = q
+}
+
+func (s *Server) lookup(id string) (Quote, bool) {
+	q, ok := s.issued[id]
+	return q, ok
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := Request{
+		Method: r.Method,
+		Path:   "/" + strings.TrimPrefix(r.URL.Path, "/"),
+		Query:  r.URL.Query(),
+		Header: r.Header.Clone(),
+	}
+	req.Body, _ = io.ReadAll(r.Body)
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	serve, ok := s.maker.routes[req.Method+" "+req.Path]
+	var rep reply
+	if ok {
+		rep = serve(s, req)
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if rep.delay > 0 {
+		select {
+		case <-time.After(rep.delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if rep.status == 0 {
+		rep.status = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rep.status)
+	_ = json.NewEncoder(w).Encode(rep.body)
+}
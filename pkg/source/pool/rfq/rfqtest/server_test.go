@@ -0,0 +1,45 @@
+package rfqtest
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	s := NewServer(t, MXTrading, Quote{AmountOut: "1", Expiry: 2}, Quote{Error: "order is too small"})
+
+	post := func() (int, map[string]any) {
+		resp, err := http.Post(s.URL+"/order", "application/json", strings.NewReader(`{"amount":"10"}`))
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		var body any
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		m, _ := body.(map[string]any)
+		return resp.StatusCode, m
+	}
+
+	status, body := post()
+	assert.Equal(t, http.StatusOK, status)
+	order := body["order"].(map[string]any)
+	assert.Equal(t, "1", order["makingAmount"])
+	assert.Equal(t, "10", order["takingAmount"])
+	assert.Equal(t, MakerTraitsExpiring(2).String(), order["makerTraits"])
+
+	// the last quote is repeated
+	for range 2 {
+		status, _ = post()
+		assert.Equal(t, http.StatusBadRequest, status)
+	}
+	require.Len(t, s.Requests(), 3)
+	assert.Equal(t, "/order", s.Requests()[0].Path)
+
+	resp, err := http.Get(s.URL + "/unknown")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,96 @@
+package pool
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/pkg/errors"
+)
+
+const DefaultRFQMaxDeviationBps = 100
+
+var (
+	ErrRFQQuoteExpiring     = errors.New("rfq: quote expires before min ttl")
+	ErrRFQAmountOutDeviates = errors.New("rfq: amount out deviates from indicative amount out")
+	ErrRFQPartialFill       = errors.New("rfq: quote fills less than the amount in")
+)
+
+// RFQValidation rejects firm quotes doomed to fail on chain: quotes expiring before the transaction can be mined, and
+// requotes below the amount out the route was built on by more than a tolerance. Deadlines are checked against the
+// wall clock of the host, which must be kept in sync with the chain, not against a block timestamp.
+type RFQValidation struct {
+	// MaxDeviationBps is the tolerated shortfall of the firm amount out from RFQParams.IndicativeAmountOut, in basis
+	// points, DefaultRFQMaxDeviationBps if not set. A negative tolerance disables the check.
+	MaxDeviationBps int64 `mapstructure:"max_deviation_bps" json:"max_deviation_bps,omitempty"`
+	// MinTTL is the minimum time to live of firm quotes reporting their deadline. If not set, only quotes already
+	// expired are rejected. A negative MinTTL disables the check.
+	MinTTL durationjson.Duration `mapstructure:"min_ttl" json:"min_ttl,omitempty"`
+}
+
+// Validate sets the DeviationBps of result, and returns ErrRFQQuoteExpiring or ErrRFQAmountOutDeviates if it expires
+// within MinTTL or falls short of the indicative amount out by more than MaxDeviationBps.
+func (v RFQValidation) Validate(params RFQParams, result *RFQResult) error {
+	if result == nil {
+		return nil
+	}
+
+	if minTTL := v.MinTTL.Duration; result.Deadline > 0 && minTTL >= 0 {
+		if ttl := time.Until(time.Unix(result.Deadline, 0)); ttl < minTTL {
+			return errors.WithMessagef(ErrRFQQuoteExpiring, "ttl %v, min %v", ttl.Truncate(time.Second), minTTL)
+		}
+	}
+
+	indicative := params.IndicativeAmountOut
+	if result.NewAmountOut == nil || indicative == nil || indicative.Sign() <= 0 {
+		return nil
+	}
+	shortfall := new(big.Int).Sub(indicative, result.NewAmountOut)
+	deviationBps := new(big.Int).Mul(shortfall, big.NewInt(BasisPoint))
+	result.DeviationBps = deviationBps.Quo(deviationBps, indicative).Int64()
+
+	maxDeviationBps := v.MaxDeviationBps
+	if maxDeviationBps == 0 {
+		maxDeviationBps = DefaultRFQMaxDeviationBps
+	}
+	if maxDeviationBps < 0 {
+		return nil
+	}
+	// shortfall / indicative > maxDeviationBps / BasisPoint, without rounding
+	if shortfall.Mul(shortfall, big.NewInt(BasisPoint)).Cmp(
+		new(big.Int).Mul(indicative, big.NewInt(maxDeviationBps))) > 0 {
+		return errors.WithMessagef(ErrRFQAmountOutDeviates, "firm %s, indicative %s", result.NewAmountOut,
+			indicative)
+	}
+	return nil
+}
+
+// ValidateBatch validates the results of a batch of RFQs, see Validate.
+func (v RFQValidation) ValidateBatch(paramsSlice []RFQParams, results []*RFQResult) error {
+	for i, result := range results {
+		if i >= len(paramsSlice) {
+			break
+		}
+		if err := v.Validate(paramsSlice[i], result); err != nil {
+			return errors.WithMessagef(err, "rfq %d", i)
+		}
+	}
+	return nil
+}
+
+// ValidateRFQFill returns ErrRFQPartialFill if filled, the taker amount of a firm quote reported by the maker, is less
+// than amountIn, the amount in of the swap: the route would leave the rest unswapped.
+func ValidateRFQFill(amountIn, filled string) error {
+	in, ok := new(big.Int).SetString(amountIn, 10)
+	if !ok {
+		return errors.Errorf("rfq: invalid amount in %q", amountIn)
+	}
+	out, ok := new(big.Int).SetString(filled, 10)
+	if !ok {
+		return errors.Errorf("rfq: invalid filled amount %q", filled)
+	}
+	if out.Cmp(in) < 0 {
+		return errors.WithMessagef(ErrRFQPartialFill, "filled %s of %s", filled, amountIn)
+	}
+	return nil
+}
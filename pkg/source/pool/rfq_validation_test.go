@@ -0,0 +1,124 @@
+package pool
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/KyberNetwork/blockchain-toolkit/time/durationjson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRFQValidation_Validate(t *testing.T) {
+	now := time.Now().Unix()
+	testCases := []struct {
+		name         string
+		validation   RFQValidation
+		indicative   *big.Int
+		result       *RFQResult
+		deviationBps int64
+		errIs        error
+	}{
+		{
+			name:       "nil result",
+			indicative: big.NewInt(1000),
+		},
+		{
+			name:         "within tolerance",
+			indicative:   big.NewInt(10000),
+			result:       &RFQResult{NewAmountOut: big.NewInt(9900), Deadline: now + 60},
+			deviationBps: 100,
+		},
+		{
+			name:         "better than indicated",
+			indicative:   big.NewInt(10000),
+			result:       &RFQResult{NewAmountOut: big.NewInt(10050)},
+			deviationBps: -50,
+		},
+		{
+			name:         "deviates",
+			indicative:   big.NewInt(10000),
+			result:       &RFQResult{NewAmountOut: big.NewInt(9899)},
+			deviationBps: 101,
+			errIs:        ErrRFQAmountOutDeviates,
+		},
+		{
+			name:         "configured tolerance",
+			validation:   RFQValidation{MaxDeviationBps: 300},
+			indicative:   big.NewInt(10000),
+			result:       &RFQResult{NewAmountOut: big.NewInt(9750)},
+			deviationBps: 250,
+		},
+		{
+			name:         "tolerance disabled",
+			validation:   RFQValidation{MaxDeviationBps: -1},
+			indicative:   big.NewInt(10000),
+			result:       &RFQResult{NewAmountOut: big.NewInt(5000)},
+			deviationBps: 5000,
+		},
+		{
+			name:   "no indicative amount",
+			result: &RFQResult{NewAmountOut: big.NewInt(5000)},
+		},
+		{
+			name:   "expired",
+			result: &RFQResult{NewAmountOut: big.NewInt(10000), Deadline: 1},
+			errIs:  ErrRFQQuoteExpiring,
+		},
+		{
+			name:   "expiring without min ttl",
+			result: &RFQResult{NewAmountOut: big.NewInt(10000), Deadline: now + 2},
+		},
+		{
+			name:       "expiring before min ttl",
+			validation: RFQValidation{MinTTL: durationjson.Duration{Duration: 5 * time.Second}},
+			result:     &RFQResult{NewAmountOut: big.NewInt(10000), Deadline: now + 2},
+			errIs:      ErrRFQQuoteExpiring,
+		},
+		{
+			name:       "configured min ttl",
+			validation: RFQValidation{MinTTL: durationjson.Duration{Duration: time.Second}},
+			result:     &RFQResult{NewAmountOut: big.NewInt(10000), Deadline: now + 3},
+		},
+		{
+			name:       "min ttl disabled",
+			validation: RFQValidation{MinTTL: durationjson.Duration{Duration: -1}},
+			result:     &RFQResult{NewAmountOut: big.NewInt(10000), Deadline: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.validation.Validate(RFQParams{IndicativeAmountOut: tc.indicative}, tc.result)
+			if tc.errIs != nil {
+				assert.ErrorIs(t, err, tc.errIs)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tc.result != nil {
+				assert.Equal(t, tc.deviationBps, tc.result.DeviationBps)
+			}
+		})
+	}
+}
+
+func TestRFQValidation_ValidateBatch(t *testing.T) {
+	var validation RFQValidation
+	params := []RFQParams{{IndicativeAmountOut: big.NewInt(100)}, {IndicativeAmountOut: big.NewInt(100)}}
+
+	assert.NoError(t, validation.ValidateBatch(params, []*RFQResult{
+		{NewAmountOut: big.NewInt(100)}, {NewAmountOut: big.NewInt(99)},
+	}))
+	err := validation.ValidateBatch(params, []*RFQResult{
+		{NewAmountOut: big.NewInt(100)}, {NewAmountOut: big.NewInt(98)},
+	})
+	assert.ErrorIs(t, err, ErrRFQAmountOutDeviates)
+	assert.ErrorContains(t, err, "rfq 1")
+}
+
+func TestValidateRFQFill(t *testing.T) {
+	assert.NoError(t, ValidateRFQFill("1000", "1000"))
+	assert.NoError(t, ValidateRFQFill("1000", "1100"))
+	assert.ErrorIs(t, ValidateRFQFill("1000", "999"), ErrRFQPartialFill)
+	assert.Error(t, ValidateRFQFill("1000", ""))
+	assert.Error(t, ValidateRFQFill("", "1000"))
+}
@@ -0,0 +1,147 @@
+package pool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+)
+
+var (
+	ErrSplitNoPath        = errors.New("no path can swap the amount")
+	ErrSplitTokenMismatch = errors.New("paths do not share the same token in and token out")
+)
+
+// DefaultSplitChunks is the number of chunks the amount in is split into when Chunks is not set.
+const DefaultSplitChunks = 10
+
+type SplitAmountOutParams struct {
+	// Paths are the candidate paths. They must all swap the same token in to the same token out.
+	Paths []entity.MinimalPath
+	// Pools holds the simulators of the path pools keyed by address. They are never mutated.
+	Pools    map[string]IPoolSimulator
+	AmountIn *big.Int
+	// Chunks is the number of equal chunks AmountIn is split into, the last one taking the remainder.
+	Chunks int
+	// Limits holds the swap limits keyed by exchange. They are cloned before use and never mutated.
+	Limits map[string]SwapLimit
+	// GasPrice is the price of one unit of gas in token out wei. When set, the first chunk allocated to a path is
+	// charged for the gas of the path, so that a path is only opened when it is worth its gas.
+	GasPrice *big.Int
+	// GasCost, if set, is used instead of GasPrice to charge the first chunk allocated to a path. It returns the cost a
+	// path of hops pools using gas units of execution gas adds to the route, in token out wei, see gas.Model.CostFunc.
+	// The cost every route pays once whatever its paths, such as its base calldata, must be left out of it so that
+	// opening another path is only charged what it adds.
+	GasCost func(gas int64, hops int) *big.Int
+	// Timestamp is the unix block timestamp to evaluate the swaps at, see CalcAmountOutParams.Now.
+	Timestamp int64
+	// Ctx is passed to every swap, see CalcAmountOutParams.Ctx.
+	Ctx context.Context
+}
+
+// SplitPath is the share of the amount in allocated to a path.
+type SplitPath struct {
+	Path entity.MinimalPath
+	*PathResult
+}
+
+type SplitResult struct {
+	TokenAmountIn  TokenAmount
+	TokenAmountOut TokenAmount
+	// Gas is the sum of the gas of the used paths, each path being swapped once with its whole share.
+	Gas   int64
+	Paths []SplitPath
+}
+
+// SplitAmountOut greedily splits AmountIn across Paths: each chunk goes to the path giving the best marginal amount out
+// given the chunks already allocated. Chunks are swapped on a state shared by all paths, so that pools and swap limits
+// used by several paths account for the chunks other paths took from them. Paths that cannot swap a chunk, for
+// instance because a pool not implementing CloneState would be used twice, are skipped for that chunk.
+//
+// The allocated paths are then swapped once each with their whole share, in the order they were first picked, to get
+// the returned amounts and gas.
+func SplitAmountOut(params SplitAmountOutParams) (*SplitResult, error) {
+	if len(params.Paths) == 0 || params.AmountIn == nil || params.AmountIn.Sign() <= 0 {
+		return nil, ErrInvalidPath
+	}
+	tokenIn, tokenOut := params.Paths[0].Tokens[0], params.Paths[0].Tokens[len(params.Paths[0].Tokens)-1]
+	for _, path := range params.Paths {
+		if err := validatePath(path, params.Pools); err != nil {
+			return nil, err
+		}
+		if path.Tokens[0] != tokenIn || path.Tokens[len(path.Tokens)-1] != tokenOut {
+			return nil, ErrSplitTokenMismatch
+		}
+	}
+
+	chunks := params.Chunks
+	if chunks <= 0 {
+		chunks = DefaultSplitChunks
+	}
+	chunk := new(big.Int).Div(params.AmountIn, big.NewInt(int64(chunks)))
+	if chunk.Sign() == 0 {
+		chunk, chunks = params.AmountIn, 1
+	}
+
+	state := newPathState(params.Pools, params.Limits)
+	state.ctx = params.Ctx
+	allocated := make([]*big.Int, len(params.Paths))
+	var order []int
+	remaining := new(big.Int).Set(params.AmountIn)
+	for n := 0; n < chunks; n++ {
+		amountIn := chunk
+		if n == chunks-1 {
+			amountIn = remaining
+		}
+
+		best, bestState, bestValue := -1, (*pathState)(nil), (*big.Int)(nil)
+		for i, path := range params.Paths {
+			trial := state.fork()
+			res, err := trial.swap(path, amountIn, params.Timestamp)
+			if err != nil {
+				continue
+			}
+			value := res.TokenAmountOut.Amount
+			if allocated[i] == nil && params.GasCost != nil {
+				value = new(big.Int).Sub(value, params.GasCost(res.Gas, len(path.Pools)))
+			} else if allocated[i] == nil && params.GasPrice != nil {
+				value = new(big.Int).Sub(value, new(big.Int).Mul(params.GasPrice, big.NewInt(res.Gas)))
+			}
+			if bestValue == nil || value.Cmp(bestValue) > 0 {
+				best, bestState, bestValue = i, trial, value
+			}
+		}
+		if best < 0 {
+			return nil, ErrSplitNoPath
+		}
+
+		state = bestState
+		if allocated[best] == nil {
+			allocated[best] = new(big.Int)
+			order = append(order, best)
+		}
+		allocated[best].Add(allocated[best], amountIn)
+		remaining = new(big.Int).Sub(remaining, amountIn)
+	}
+
+	state = newPathState(params.Pools, params.Limits)
+	state.ctx = params.Ctx
+	result := &SplitResult{
+		TokenAmountIn:  TokenAmount{Token: tokenIn, Amount: params.AmountIn},
+		TokenAmountOut: TokenAmount{Token: tokenOut, Amount: new(big.Int)},
+		Paths:          make([]SplitPath, 0, len(order)),
+	}
+	for _, i := range order {
+		res, err := state.swap(params.Paths[i], allocated[i], params.Timestamp)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "path %d", i)
+		}
+		result.TokenAmountOut.Amount.Add(result.TokenAmountOut.Amount, res.TokenAmountOut.Amount)
+		result.Gas += res.Gas
+		result.Paths = append(result.Paths, SplitPath{Path: params.Paths[i], PathResult: res})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,207 @@
+package pool
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/gas"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/valueobject"
+)
+
+func TestSplitAmountOut(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	pool2 := newConstantProductPool("ab2", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	paths := []entity.MinimalPath{
+		{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+		{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+	}
+
+	res, err := SplitAmountOut(SplitAmountOutParams{
+		Paths:    paths,
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, res.Paths, 2)
+	assert.Equal(t, "100000", res.Paths[0].TokenAmountIn.Amount.String())
+	assert.Equal(t, "100000", res.Paths[1].TokenAmountIn.Amount.String())
+	assert.Equal(t, "181818", res.TokenAmountOut.Amount.String()) // 2 * 1e5*1e6/(1e6+1e5)
+	assert.EqualValues(t, 200, res.Gas)
+
+	single, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     paths[0],
+		Pools:    testPathPools(pool1),
+		AmountIn: big.NewInt(200000),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.TokenAmountOut.Amount.Cmp(single.TokenAmountOut.Amount))
+	assert.Equal(t, "1000000", pool1.Info.Reserves[0].String(), "input pools must not be mutated")
+}
+
+func TestSplitAmountOut_Ctx(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := SplitAmountOut(SplitAmountOutParams{
+		Paths:    []entity.MinimalPath{{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}}},
+		Pools:    testPathPools(pool1),
+		AmountIn: big.NewInt(200000),
+		Ctx:      ctx,
+	})
+	assert.ErrorIs(t, err, ErrSplitNoPath)
+}
+
+func TestSplitAmountOut_SharedPool(t *testing.T) {
+	poolAB := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	poolBC1 := newConstantProductPool("bc1", "cp", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+	poolBC2 := newConstantProductPool("bc2", "cp", [2]string{"b", "c"}, [2]int64{1e6, 1e6})
+
+	res, err := SplitAmountOut(SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab", "bc1"}, Tokens: []string{"a", "b", "c"}},
+			{Pools: []string{"ab", "bc2"}, Tokens: []string{"a", "b", "c"}},
+		},
+		Pools:    testPathPools(poolAB, poolBC1, poolBC2),
+		AmountIn: big.NewInt(200000),
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Paths, 2)
+
+	// both paths go through ab: together they get what a single swap of the whole amount through ab gives
+	viaAB := new(big.Int)
+	for _, path := range res.Paths {
+		viaAB.Add(viaAB, path.Hops[0].TokenAmountOut.Amount)
+	}
+	whole, err := CalcPathAmountOut(CalcPathAmountOutParams{
+		Path:     entity.MinimalPath{Pools: []string{"ab"}, Tokens: []string{"a", "b"}},
+		Pools:    testPathPools(poolAB),
+		AmountIn: big.NewInt(200000),
+	})
+	require.NoError(t, err)
+	diff := new(big.Int).Sub(viaAB, whole.TokenAmountOut.Amount)
+	assert.LessOrEqual(t, diff.CmpAbs(big.NewInt(2)), 0, "%s vs %s", viaAB, whole.TokenAmountOut.Amount)
+}
+
+func TestSplitAmountOut_SharedLimit(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "single", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	pool2 := newConstantProductPool("ab2", "single", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	limit := &singleSwapLimit{}
+
+	_, err := SplitAmountOut(SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+			{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+		},
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+		Limits:   map[string]SwapLimit{"single": limit},
+	})
+	assert.ErrorIs(t, err, ErrSplitNoPath)
+	assert.False(t, limit.swapped)
+
+	res, err := SplitAmountOut(SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+			{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+		},
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+		Chunks:   1,
+		Limits:   map[string]SwapLimit{"single": limit},
+	})
+	require.NoError(t, err)
+	assert.Len(t, res.Paths, 1)
+}
+
+func TestSplitAmountOut_GasPrice(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	pool2 := newConstantProductPool("ab2", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+
+	res, err := SplitAmountOut(SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+			{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+		},
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+		GasPrice: big.NewInt(1000),
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Paths, 1)
+	assert.Equal(t, "200000", res.Paths[0].TokenAmountIn.Amount.String())
+}
+
+func TestSplitAmountOut_GasCost(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	pool2 := newConstantProductPool("ab2", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	paths := []entity.MinimalPath{
+		{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+		{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+	}
+
+	// GasCost takes precedence over GasPrice, opening a second path costing more than splitting saves
+	var charged []int
+	res, err := SplitAmountOut(SplitAmountOutParams{
+		Paths:    paths,
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+		GasPrice: big.NewInt(0),
+		GasCost: func(gas int64, hops int) *big.Int {
+			charged = append(charged, hops)
+			return big.NewInt(int64(hops) * 20000)
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Paths, 1)
+	assert.Equal(t, "200000", res.Paths[0].TokenAmountIn.Amount.String())
+	assert.NotEmpty(t, charged)
+	assert.Equal(t, 1, charged[0])
+}
+
+func TestSplitAmountOut_GasModel(t *testing.T) {
+	pool1 := newConstantProductPool("ab1", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	pool2 := newConstantProductPool("ab2", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	params := SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab1"}, Tokens: []string{"a", "b"}},
+			{Pools: []string{"ab2"}, Tokens: []string{"a", "b"}},
+		},
+		Pools:    testPathPools(pool1, pool2),
+		AmountIn: big.NewInt(200000),
+	}
+	model, gasParams, rate := gas.NewModel(valueobject.ChainIDArbitrumOne), gas.Params{L1PricePerUnit: big.NewInt(1)},
+		big.NewFloat(0.5)
+
+	// the second path only adds its hop calldata, 192 * 16 / 2 = 1536, less than splitting saves
+	params.GasCost = model.CostFunc(gasParams, rate)
+	res, err := SplitAmountOut(params)
+	require.NoError(t, err)
+	assert.Len(t, res.Paths, 2)
+
+	// charging it the base calldata too, (516 + 192) * 16 / 2 = 5664, would keep a single path
+	params.GasCost = func(gasUsed int64, hops int) *big.Int {
+		return model.CostInToken(gasUsed, hops, gasParams, rate)
+	}
+	res, err = SplitAmountOut(params)
+	require.NoError(t, err)
+	assert.Len(t, res.Paths, 1)
+}
+
+func TestSplitAmountOut_TokenMismatch(t *testing.T) {
+	_, err := SplitAmountOut(SplitAmountOutParams{
+		Paths: []entity.MinimalPath{
+			{Pools: []string{"ab"}, Tokens: []string{"a", "b"}},
+			{Pools: []string{"ab"}, Tokens: []string{"b", "a"}},
+		},
+		Pools:    testPathPools(newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})),
+		AmountIn: big.NewInt(1000),
+	})
+	assert.ErrorIs(t, err, ErrSplitTokenMismatch)
+}
@@ -0,0 +1,243 @@
+package pool
+
+import (
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrSpotPriceUnsupported is returned by IPoolSpotPricer and IPoolDepthCalculator implementations for states they
+	// cannot price natively, SpotPrice and DepthCurve then probe the pool with CalcAmountOut instead.
+	ErrSpotPriceUnsupported = errors.New("spot price is not supported for this pool state")
+	ErrSpotPriceUnavailable = errors.New("spot price could not be probed")
+	ErrInvalidImpact        = errors.New("price impact must be between 0 and 10000 bps")
+)
+
+const (
+	// BasisPoint is the number of basis points in 1.
+	BasisPoint = 10000
+
+	// spotPriceProbeDivisor sets the first probed amount to this fraction of the pool reserve of the token in.
+	spotPriceProbeDivisor = 1_000_000
+	// spotPriceProbeMinAmountOut is the smallest amount out a probe must get for its rate to have enough significant
+	// digits.
+	spotPriceProbeMinAmountOut = 1_000_000
+	// spotPriceProbeMaxSteps bounds the number of times the probed amount is multiplied by 10.
+	spotPriceProbeMaxSteps = 30
+	// depthMaxSteps bounds the number of times the probed amount is doubled, then halved, to search for the depth.
+	depthMaxSteps = 256
+	// spotPricePrec is the precision of the big.Float rates.
+	spotPricePrec = 256
+)
+
+// IPoolSpotPricer is implemented by pool simulators able to compute their marginal exchange rate from their state,
+// without probing CalcAmountOut with arbitrary amounts.
+type IPoolSpotPricer interface {
+	// SpotPrice returns the amount of tokenOut received per unit of tokenIn, both in wei, for an infinitesimal swap,
+	// fees included. It returns ErrSpotPriceUnsupported for states it cannot price.
+	SpotPrice(tokenIn, tokenOut string) (*big.Float, error)
+}
+
+// IPoolDepthCalculator is implemented by pool simulators able to compute their depth without searching it with
+// CalcAmountOut, typically in closed form.
+type IPoolDepthCalculator interface {
+	// Depth returns the largest swap of tokenIn to tokenOut whose price impact is at most impactBps, see DepthPoint. It
+	// returns ErrSpotPriceUnsupported for states it cannot compute the depth of.
+	Depth(tokenIn, tokenOut string, impactBps int64) (*DepthPoint, error)
+}
+
+type SpotPriceParams struct {
+	TokenIn  string
+	TokenOut string
+	// Limit and Timestamp are passed to CalcAmountOut when the pool has to be probed.
+	Limit     SwapLimit
+	Timestamp int64
+}
+
+type DepthCurveParams struct {
+	TokenIn  string
+	TokenOut string
+	// ImpactsBps holds the price impacts, in basis points, to compute the depth at.
+	ImpactsBps []int64
+	Limit      SwapLimit
+	Timestamp  int64
+}
+
+// DepthPoint is the largest swap of a token pair whose price impact is at most ImpactBps. The price impact of a swap
+// is 1 - (AmountOut / AmountIn) / spot price: the loss of its average rate against the marginal one. AmountIn is zero
+// if the pool cannot fill any amount within the impact, and is the largest fillable amount when the pool liquidity is
+// exhausted before the impact is reached.
+type DepthPoint struct {
+	ImpactBps int64
+	AmountIn  *big.Int
+	AmountOut *big.Int
+}
+
+// SpotPrice returns the marginal exchange rate of the pool, see IPoolSpotPricer. Pools not implementing it, or
+// returning ErrSpotPriceUnsupported, are probed with CalcAmountOut: starting from a millionth of the reserve of the
+// token in, the amount is multiplied by 10 until the swap succeeds with enough precision, so that minimum swap sizes
+// are skipped. The probed rate includes the price impact of the probed amount.
+func SpotPrice(sim IPoolSimulator, params SpotPriceParams) (*big.Float, error) {
+	if sim.GetTokenIndex(params.TokenIn) < 0 || sim.GetTokenIndex(params.TokenOut) < 0 {
+		return nil, ErrTokenNotAvailable
+	}
+	if pricer, ok := sim.(IPoolSpotPricer); ok {
+		spotPrice, err := pricer.SpotPrice(params.TokenIn, params.TokenOut)
+		if !errors.Is(err, ErrSpotPriceUnsupported) {
+			return spotPrice, err
+		}
+	}
+	return probeSpotPrice(sim, params)
+}
+
+// DepthCurve returns the depth of the pool at each of params.ImpactsBps, see DepthPoint. Pools not implementing
+// IPoolDepthCalculator are searched with CalcAmountOut, by doubling the amount in until the impact is exceeded, then
+// bisecting to 1 bps of the amount in.
+func DepthCurve(sim IPoolSimulator, params DepthCurveParams) ([]DepthPoint, error) {
+	for _, impactBps := range params.ImpactsBps {
+		if impactBps <= 0 || impactBps >= BasisPoint {
+			return nil, errors.WithMessagef(ErrInvalidImpact, "%d", impactBps)
+		}
+	}
+
+	var spotPrice *big.Float
+	curve := make([]DepthPoint, 0, len(params.ImpactsBps))
+	for _, impactBps := range params.ImpactsBps {
+		if calculator, ok := sim.(IPoolDepthCalculator); ok {
+			point, err := calculator.Depth(params.TokenIn, params.TokenOut, impactBps)
+			if err == nil {
+				curve = append(curve, *point)
+				continue
+			} else if !errors.Is(err, ErrSpotPriceUnsupported) {
+				return nil, err
+			}
+		}
+
+		if spotPrice == nil {
+			var err error
+			if spotPrice, err = SpotPrice(sim, SpotPriceParams{
+				TokenIn:   params.TokenIn,
+				TokenOut:  params.TokenOut,
+				Limit:     params.Limit,
+				Timestamp: params.Timestamp,
+			}); err != nil {
+				return nil, err
+			}
+		}
+		curve = append(curve, searchDepth(sim, params, spotPrice, impactBps))
+	}
+	return curve, nil
+}
+
+// probeStartAmount returns the first amount of tokenIn to probe sim with.
+func probeStartAmount(sim IPoolSimulator, tokenIn string) *big.Int {
+	amount := big.NewInt(1)
+	if reserves, index := sim.GetReserves(), sim.GetTokenIndex(tokenIn); index < len(reserves) &&
+		reserves[index] != nil {
+		amount.Div(reserves[index], big.NewInt(spotPriceProbeDivisor))
+	}
+	if amount.Sign() <= 0 {
+		amount.SetInt64(1)
+	}
+	return amount
+}
+
+func probeSpotPrice(sim IPoolSimulator, params SpotPriceParams) (*big.Float, error) {
+	var spotPrice *big.Float
+	amountIn := probeStartAmount(sim, params.TokenIn)
+	for range spotPriceProbeMaxSteps {
+		if amountOut, ok := probeAmountOut(sim, params.TokenIn, params.TokenOut, amountIn, params.Limit,
+			params.Timestamp); ok {
+			spotPrice = rate(amountIn, amountOut)
+			if amountOut.Cmp(big.NewInt(spotPriceProbeMinAmountOut)) >= 0 {
+				return spotPrice, nil
+			}
+		} else if spotPrice != nil {
+			// the pool cannot fill larger amounts, keep the less precise rate
+			return spotPrice, nil
+		}
+		amountIn = new(big.Int).Mul(amountIn, big.NewInt(10))
+	}
+	if spotPrice == nil {
+		return nil, ErrSpotPriceUnavailable
+	}
+	return spotPrice, nil
+}
+
+// searchDepth searches the largest amount in whose price impact against spotPrice is at most impactBps.
+func searchDepth(sim IPoolSimulator, params DepthCurveParams, spotPrice *big.Float, impactBps int64) DepthPoint {
+	minRate := new(big.Float).SetPrec(spotPricePrec).Mul(spotPrice, big.NewFloat(float64(BasisPoint-impactBps)))
+	minRate.Quo(minRate, big.NewFloat(BasisPoint))
+	// within reports whether amountIn fills within the impact, and whether it fills at all
+	within := func(amountIn *big.Int) (*big.Int, bool, bool) {
+		amountOut, ok := probeAmountOut(sim, params.TokenIn, params.TokenOut, amountIn, params.Limit,
+			params.Timestamp)
+		if !ok {
+			return nil, false, false
+		}
+		return amountOut, rate(amountIn, amountOut).Cmp(minRate) >= 0, true
+	}
+
+	point := DepthPoint{ImpactBps: impactBps, AmountIn: new(big.Int), AmountOut: new(big.Int)}
+	lo, hi := new(big.Int), (*big.Int)(nil)
+	amountIn := probeStartAmount(sim, params.TokenIn)
+	for range depthMaxSteps {
+		amountOut, ok, filled := within(amountIn)
+		if ok {
+			lo, point.AmountIn, point.AmountOut = amountIn, amountIn, amountOut
+		} else if filled || lo.Sign() > 0 {
+			// the impact is exceeded, or the liquidity exhausted
+			hi = amountIn
+			break
+		}
+		// below the minimum swap size, or within the impact
+		amountIn = new(big.Int).Lsh(amountIn, 1)
+	}
+	if hi == nil {
+		return point
+	}
+
+	var gap, precision big.Int
+	for range depthMaxSteps {
+		gap.Sub(hi, lo)
+		precision.Div(lo, big.NewInt(BasisPoint))
+		if gap.Cmp(big.NewInt(1)) <= 0 || gap.Cmp(&precision) <= 0 {
+			break
+		}
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if amountOut, ok, _ := within(mid); ok {
+			lo, point.AmountIn, point.AmountOut = mid, mid, amountOut
+		} else {
+			hi = mid
+		}
+	}
+	return point
+}
+
+// probeAmountOut returns the amount out of swapping amountIn, and whether the swap is fully filled.
+func probeAmountOut(sim IPoolSimulator, tokenIn, tokenOut string, amountIn *big.Int, limit SwapLimit,
+	timestamp int64) (*big.Int, bool) {
+	res, err := calcAmountOut(sim, CalcAmountOutParams{
+		TokenAmountIn: TokenAmount{Token: tokenIn, Amount: amountIn},
+		TokenOut:      tokenOut,
+		Limit:         limit,
+		Timestamp:     timestamp,
+	})
+	if err != nil || res == nil || !res.IsValid() {
+		return nil, false
+	}
+	if res.RemainingTokenAmountIn != nil && res.RemainingTokenAmountIn.Amount != nil &&
+		res.RemainingTokenAmountIn.Amount.Sign() > 0 {
+		return nil, false
+	}
+	return res.TokenAmountOut.Amount, true
+}
+
+func rate(amountIn, amountOut *big.Int) *big.Float {
+	return new(big.Float).SetPrec(spotPricePrec).Quo(
+		new(big.Float).SetPrec(spotPricePrec).SetInt(amountOut),
+		new(big.Float).SetPrec(spotPricePrec).SetInt(amountIn),
+	)
+}
@@ -0,0 +1,135 @@
+package pool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// boundedPool is a constantProductPool only filling amounts in between minAmountIn and maxAmountIn.
+type boundedPool struct {
+	*constantProductPool
+	minAmountIn, maxAmountIn *big.Int
+}
+
+func (p *boundedPool) CalcAmountOut(params CalcAmountOutParams) (*CalcAmountOutResult, error) {
+	if p.minAmountIn != nil && params.TokenAmountIn.Amount.Cmp(p.minAmountIn) < 0 ||
+		p.maxAmountIn != nil && params.TokenAmountIn.Amount.Cmp(p.maxAmountIn) > 0 {
+		return nil, errors.New("out of bounds")
+	}
+	return p.constantProductPool.CalcAmountOut(params)
+}
+
+// spotPricedPool is a constantProductPool implementing IPoolSpotPricer.
+type spotPricedPool struct {
+	*constantProductPool
+	err error
+}
+
+func (p *spotPricedPool) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	in, out := p.GetTokenIndex(tokenIn), p.GetTokenIndex(tokenOut)
+	return new(big.Float).Quo(new(big.Float).SetInt(p.Info.Reserves[out]),
+		new(big.Float).SetInt(p.Info.Reserves[in])), nil
+}
+
+func assertRelativelyEqual(t *testing.T, expected, actual float64, tolerance float64) {
+	t.Helper()
+	assert.InEpsilon(t, expected, actual, tolerance, "expected %v, got %v", expected, actual)
+}
+
+func TestSpotPrice(t *testing.T) {
+	cp := newConstantProductPool("p", "ex", [2]string{"a", "b"}, [2]int64{1e12, 2e12})
+
+	t.Run("probed", func(t *testing.T) {
+		spotPrice, err := SpotPrice(cp, SpotPriceParams{TokenIn: "a", TokenOut: "b"})
+		require.NoError(t, err)
+		f, _ := spotPrice.Float64()
+		assertRelativelyEqual(t, 2, f, 1e-5)
+	})
+
+	t.Run("probed above minimum size", func(t *testing.T) {
+		bounded := &boundedPool{constantProductPool: cp, minAmountIn: big.NewInt(1e8)}
+		spotPrice, err := SpotPrice(bounded, SpotPriceParams{TokenIn: "b", TokenOut: "a"})
+		require.NoError(t, err)
+		f, _ := spotPrice.Float64()
+		assertRelativelyEqual(t, 0.5, f, 1e-3)
+	})
+
+	t.Run("native", func(t *testing.T) {
+		spotPrice, err := SpotPrice(&spotPricedPool{constantProductPool: cp},
+			SpotPriceParams{TokenIn: "a", TokenOut: "b"})
+		require.NoError(t, err)
+		assert.Zero(t, spotPrice.Cmp(big.NewFloat(2)))
+	})
+
+	t.Run("native unsupported", func(t *testing.T) {
+		spotPrice, err := SpotPrice(&spotPricedPool{constantProductPool: cp, err: ErrSpotPriceUnsupported},
+			SpotPriceParams{TokenIn: "a", TokenOut: "b"})
+		require.NoError(t, err)
+		f, _ := spotPrice.Float64()
+		assertRelativelyEqual(t, 2, f, 1e-5)
+	})
+
+	t.Run("native error", func(t *testing.T) {
+		errNative := errors.New("native")
+		_, err := SpotPrice(&spotPricedPool{constantProductPool: cp, err: errNative},
+			SpotPriceParams{TokenIn: "a", TokenOut: "b"})
+		assert.ErrorIs(t, err, errNative)
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		bounded := &boundedPool{constantProductPool: cp, maxAmountIn: big.NewInt(0)}
+		_, err := SpotPrice(bounded, SpotPriceParams{TokenIn: "a", TokenOut: "b"})
+		assert.ErrorIs(t, err, ErrSpotPriceUnavailable)
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		_, err := SpotPrice(cp, SpotPriceParams{TokenIn: "a", TokenOut: "c"})
+		assert.ErrorIs(t, err, ErrTokenNotAvailable)
+	})
+}
+
+func TestDepthCurve(t *testing.T) {
+	cp := newConstantProductPool("p", "ex", [2]string{"a", "b"}, [2]int64{1e12, 2e12})
+	native := &spotPricedPool{constantProductPool: cp}
+
+	// the price impact of a fee-less constant product swap is amountIn / (reserveIn + amountIn)
+	curve, err := DepthCurve(native, DepthCurveParams{TokenIn: "a", TokenOut: "b", ImpactsBps: []int64{10, 100, 5000}})
+	require.NoError(t, err)
+	require.Len(t, curve, 3)
+	for _, point := range curve {
+		impact := float64(point.ImpactBps) / BasisPoint
+		expected := 1e12 * impact / (1 - impact)
+		f, _ := new(big.Float).SetInt(point.AmountIn).Float64()
+		assertRelativelyEqual(t, expected, f, 2e-4)
+
+		res, err := cp.CalcAmountOut(CalcAmountOutParams{
+			TokenAmountIn: TokenAmount{Token: "a", Amount: point.AmountIn},
+			TokenOut:      "b",
+		})
+		require.NoError(t, err)
+		assert.Equal(t, res.TokenAmountOut.Amount, point.AmountOut)
+	}
+
+	t.Run("exhausted", func(t *testing.T) {
+		bounded := &boundedPool{constantProductPool: cp, maxAmountIn: big.NewInt(1e9)}
+		curve, err := DepthCurve(bounded, DepthCurveParams{TokenIn: "a", TokenOut: "b", ImpactsBps: []int64{100}})
+		require.NoError(t, err)
+		f, _ := new(big.Float).SetInt(curve[0].AmountIn).Float64()
+		assertRelativelyEqual(t, 1e9, f, 2e-4)
+		assert.LessOrEqual(t, curve[0].AmountIn.Cmp(big.NewInt(1e9)), 0)
+	})
+
+	t.Run("invalid impact", func(t *testing.T) {
+		for _, impactBps := range []int64{0, -1, BasisPoint} {
+			_, err := DepthCurve(cp, DepthCurveParams{TokenIn: "a", TokenOut: "b", ImpactsBps: []int64{impactBps}})
+			assert.ErrorIs(t, err, ErrInvalidImpact)
+		}
+	})
+}
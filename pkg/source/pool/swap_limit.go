@@ -20,3 +20,12 @@ type SwapLimit interface {
 	// It returns the new limits for other purposes
 	UpdateLimit(decreaseKey, increasedKey string, decreasedDelta, increasedDelta *big.Int) (increasedLimitAfter *big.Int, decreasedLimitAfter *big.Int, err error)
 }
+
+// SharedSwapLimit is implemented by swap limits whose state is shared with the swap limits of other exchanges, such as
+// the balances of makers having limit orders on several exchanges.
+type SharedSwapLimit interface {
+	SwapLimit
+	// CloneShared returns a clone of SwapLimit, like Clone, whose state is shared with the limits cloned with the same
+	// clones. clones maps the shared states already cloned to their clones, and is updated with the new ones.
+	CloneShared(clones map[any]any) SwapLimit
+}
@@ -0,0 +1,101 @@
+package replay
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/goccy/go-json"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
+)
+
+const (
+	fixtureFile = "fixture.json"
+	blocksDir   = "blocks"
+)
+
+// Fixture is a recorded pool history. It is stored in a directory holding fixture.json, with the tracker to replay
+// and the initial pool, and one blocks/<blockNumber>.json file per Step.
+type Fixture struct {
+	PoolType   string               `json:"poolType"`
+	Exchange   string               `json:"exchange"`
+	Properties pooltrack.Properties `json:"properties,omitempty"`
+	// IgnoredFields are the entity.Pool fields not compared, in addition to DefaultIgnoredFields
+	IgnoredFields []string    `json:"ignoredFields,omitempty"`
+	Pool          entity.Pool `json:"pool"`
+	Steps         []Step      `json:"-"`
+}
+
+// Step holds the logs emitted at a block and the pool snapshot recorded after that block.
+type Step struct {
+	BlockNumber uint64      `json:"blockNumber"`
+	Logs        []types.Log `json:"logs"`
+	Pool        entity.Pool `json:"pool"`
+}
+
+// LoadFixture reads the fixture stored in dir, with its steps sorted by block number.
+func LoadFixture(dir string) (*Fixture, error) {
+	var fixture Fixture
+	if err := readJSON(filepath.Join(dir, fixtureFile), &fixture); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, blocksDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		var step Step
+		if err := readJSON(filepath.Join(dir, blocksDir, entry.Name()), &step); err != nil {
+			return nil, err
+		}
+		fixture.Steps = append(fixture.Steps, step)
+	}
+	slices.SortFunc(fixture.Steps, func(a, b Step) int { return cmp.Compare(a.BlockNumber, b.BlockNumber) })
+
+	return &fixture, nil
+}
+
+// Save writes the fixture to dir, replacing the files of steps recorded at the same blocks.
+func (f *Fixture) Save(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, blocksDir), 0o755); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, fixtureFile), f); err != nil {
+		return err
+	}
+	for _, step := range f.Steps {
+		if err := writeJSON(filepath.Join(dir, blocksDir, fmt.Sprintf("%d.json", step.BlockNumber)), step); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,161 @@
+// Package replay drives pool trackers over recorded logs block by block and compares the pools they return with
+// recorded snapshots, so that log decoders can be regression tested offline.
+package replay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
+)
+
+var (
+	ErrTrackerNotFound = errors.New("pool tracker not found")
+	ErrTrackerPanicked = errors.New("pool tracker panicked")
+)
+
+// DefaultIgnoredFields are the entity.Pool json fields never compared, as trackers set them from the wall clock.
+var DefaultIgnoredFields = []string{"timestamp"}
+
+// MismatchError is returned when the pool returned by the tracker differs from the recorded snapshot.
+type MismatchError struct {
+	BlockNumber uint64
+	Diffs       []string
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("block %d: pool mismatch:\n%s", e.BlockNumber, strings.Join(e.Diffs, "\n"))
+}
+
+type Replayer struct {
+	tracker       pool.IPoolTracker
+	ignoredFields []string
+}
+
+func New(tracker pool.IPoolTracker, ignoredFields ...string) *Replayer {
+	return &Replayer{
+		tracker:       tracker,
+		ignoredFields: append(slices.Clone(DefaultIgnoredFields), ignoredFields...),
+	}
+}
+
+// NewFromFixture creates the registered tracker of the fixture pool type. Dependencies are usually left empty to
+// replay offline: trackers then have to rely on the recorded logs only.
+func NewFromFixture(fixture *Fixture, dependencies pooltrack.Dependencies) (*Replayer, error) {
+	factory := pooltrack.Factory(fixture.PoolType)
+	if factory == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTrackerNotFound, fixture.PoolType)
+	}
+	tracker, err := factory(fixture.Exchange, pooltrack.FactoryParams{
+		// the factory sets DexID in the properties, don't modify the fixture
+		Properties:   maps.Clone(fixture.Properties),
+		Dependencies: dependencies,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return New(tracker, fixture.IgnoredFields...), nil
+}
+
+// ReplayDir loads the fixture stored in dir and replays it with its registered tracker.
+func ReplayDir(ctx context.Context, dir string, dependencies pooltrack.Dependencies) error {
+	fixture, err := LoadFixture(dir)
+	if err != nil {
+		return err
+	}
+	replayer, err := NewFromFixture(fixture, dependencies)
+	if err != nil {
+		return err
+	}
+	_, err = replayer.Replay(ctx, fixture.Pool, fixture.Steps)
+	return err
+}
+
+// Replay feeds the logs of each step to the tracker, starting from initial, and stops at the first step whose
+// resulting pool differs from the recorded one with a *MismatchError. It returns the last pool built.
+func (r *Replayer) Replay(ctx context.Context, initial entity.Pool, steps []Step) (entity.Pool, error) {
+	p := initial
+	for _, step := range steps {
+		next, err := r.step(ctx, p, step)
+		if err != nil {
+			return p, fmt.Errorf("block %d: %w", step.BlockNumber, err)
+		}
+		if diffs := Diff(step.Pool, next, r.ignoredFields...); len(diffs) > 0 {
+			return next, &MismatchError{BlockNumber: step.BlockNumber, Diffs: diffs}
+		}
+		p = next
+	}
+	return p, nil
+}
+
+// step runs the tracker on a single block. Trackers without RPC client usually panic when the logs are not enough
+// to build the new state, which is reported as an error.
+func (r *Replayer) step(ctx context.Context, p entity.Pool, step Step) (next entity.Pool, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("%w: %v", ErrTrackerPanicked, rec)
+		}
+	}()
+	return r.tracker.GetNewPoolState(ctx, p, pool.GetNewPoolStateParams{Logs: step.Logs})
+}
+
+// Diff lists the differences between two pools, field by field. Extra and StaticExtra are compared as json values
+// so that key order does not matter.
+func Diff(expected, actual entity.Pool, ignoredFields ...string) []string {
+	expectedFields, actualFields := poolFields(expected), poolFields(actual)
+
+	keys := slices.Concat(slices.Collect(maps.Keys(expectedFields)), slices.Collect(maps.Keys(actualFields)))
+	slices.Sort(keys)
+	keys = slices.Compact(keys)
+
+	var diffs []string
+	for _, key := range keys {
+		if slices.Contains(ignoredFields, key) {
+			continue
+		}
+		if e, a := expectedFields[key], actualFields[key]; !reflect.DeepEqual(e, a) {
+			diffs = append(diffs, fmt.Sprintf("%s: expected %s, got %s", key, jsonString(e), jsonString(a)))
+		}
+	}
+	return diffs
+}
+
+func poolFields(p entity.Pool) map[string]any {
+	data, _ := json.Marshal(p)
+	var fields map[string]any
+	_ = decodeJSON(data, &fields)
+	for _, key := range []string{"extra", "staticExtra"} {
+		if s, ok := fields[key].(string); ok {
+			var v any
+			if decodeJSON([]byte(s), &v) == nil {
+				fields[key] = v
+			}
+		}
+	}
+	return fields
+}
+
+// decodeJSON keeps numbers as json.Number, as extras commonly hold integers that do not fit in a float64.
+func decodeJSON(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func jsonString(v any) string {
+	if v == nil {
+		return "<nil>"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
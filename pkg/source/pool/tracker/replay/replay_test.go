@@ -0,0 +1,90 @@
+package replay
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	_ "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/uniswap-v2"
+	pooltrack "github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool/tracker"
+)
+
+func TestReplayDir(t *testing.T) {
+	require.NoError(t, ReplayDir(context.Background(), "testdata/uniswap-v2", pooltrack.Dependencies{}))
+}
+
+func TestReplayer_Replay(t *testing.T) {
+	fixture, err := LoadFixture("testdata/uniswap-v2")
+	require.NoError(t, err)
+	require.Len(t, fixture.Steps, 3)
+	replayer, err := NewFromFixture(fixture, pooltrack.Dependencies{})
+	require.NoError(t, err)
+	assert.NotContains(t, fixture.Properties, "DexID")
+
+	t.Run("final pool", func(t *testing.T) {
+		got, err := replayer.Replay(context.Background(), fixture.Pool, fixture.Steps)
+		require.NoError(t, err)
+		assert.Equal(t, fixture.Steps[2].Pool.Reserves, got.Reserves)
+		assert.Equal(t, fixture.Steps[2].BlockNumber, got.BlockNumber)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		steps := append([]Step(nil), fixture.Steps...)
+		steps[1].Pool.Reserves = entity.PoolReserves{"1", "2"}
+
+		_, err := replayer.Replay(context.Background(), fixture.Pool, steps)
+		var mismatch *MismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, steps[1].BlockNumber, mismatch.BlockNumber)
+		assert.Len(t, mismatch.Diffs, 1)
+		assert.Contains(t, mismatch.Diffs[0], "reserves")
+	})
+
+	t.Run("missing logs", func(t *testing.T) {
+		steps := []Step{{BlockNumber: fixture.Steps[0].BlockNumber, Pool: fixture.Steps[0].Pool}}
+
+		_, err := replayer.Replay(context.Background(), fixture.Pool, steps)
+		assert.ErrorIs(t, err, ErrTrackerPanicked)
+	})
+}
+
+func TestNewFromFixture_UnknownPoolType(t *testing.T) {
+	_, err := NewFromFixture(&Fixture{PoolType: "unknown"}, pooltrack.Dependencies{})
+	assert.ErrorIs(t, err, ErrTrackerNotFound)
+}
+
+func TestFixture_Save(t *testing.T) {
+	fixture, err := LoadFixture("testdata/uniswap-v2")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, fixture.Save(dir))
+	saved, err := LoadFixture(dir)
+	require.NoError(t, err)
+	assert.Equal(t, fixture, saved)
+}
+
+func TestDiff(t *testing.T) {
+	expected := entity.Pool{
+		Address:   "0x1",
+		Reserves:  entity.PoolReserves{"1", "2"},
+		Extra:     `{"a":1,"liquidity":22401613683762852555294}`,
+		Timestamp: 1,
+	}
+
+	actual := expected
+	actual.Extra = `{"liquidity":22401613683762852555294,"a":1}`
+	actual.Timestamp = 2
+	assert.Empty(t, Diff(expected, actual, DefaultIgnoredFields...))
+
+	actual.Extra = `{"liquidity":22401613683762852555295,"a":1}`
+	actual.SwapFee = 0.3
+	assert.Equal(t, []string{
+		`extra: expected {"a":1,"liquidity":22401613683762852555294}, got {"a":1,"liquidity":22401613683762852555295}`,
+		"swapFee: expected <nil>, got 0.3",
+		"timestamp: expected 1, got 2",
+	}, Diff(expected, actual))
+}
@@ -0,0 +1,333 @@
+package pool
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/pkg/errors"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+)
+
+var ErrInvalidTransferTax = errors.New("invalid transfer tax")
+
+// transferTaxRoundingTolerance is the shortfall of a simulated transfer attributed to rounding rather than to a tax.
+const transferTaxRoundingTolerance = 2
+
+// transferTax is the parsed form of entity.TransferTax.
+type transferTax struct {
+	buyTaxBps, sellTaxBps    int64
+	totalShares, totalSupply *big.Int
+}
+
+func newTransferTax(tax *entity.TransferTax) (*transferTax, error) {
+	if tax.BuyTaxBps < 0 || tax.BuyTaxBps >= BasisPoint || tax.SellTaxBps < 0 || tax.SellTaxBps >= BasisPoint {
+		return nil, errors.WithMessagef(ErrInvalidTransferTax, "buy %d bps, sell %d bps", tax.BuyTaxBps,
+			tax.SellTaxBps)
+	}
+	res := &transferTax{buyTaxBps: tax.BuyTaxBps, sellTaxBps: tax.SellTaxBps}
+	if tax.TotalShares == "" && tax.TotalSupply == "" {
+		return res, nil
+	}
+	var ok bool
+	if res.totalShares, ok = new(big.Int).SetString(tax.TotalShares, 10); !ok || res.totalShares.Sign() <= 0 {
+		return nil, errors.WithMessagef(ErrInvalidTransferTax, "total shares %q", tax.TotalShares)
+	}
+	if res.totalSupply, ok = new(big.Int).SetString(tax.TotalSupply, 10); !ok || res.totalSupply.Sign() <= 0 {
+		return nil, errors.WithMessagef(ErrInvalidTransferTax, "total supply %q", tax.TotalSupply)
+	}
+	return res, nil
+}
+
+// entity returns the entity.TransferTax t was parsed from.
+func (t *transferTax) entity() *entity.TransferTax {
+	res := &entity.TransferTax{BuyTaxBps: t.buyTaxBps, SellTaxBps: t.sellTaxBps}
+	if t.totalShares != nil {
+		res.TotalShares, res.TotalSupply = t.totalShares.String(), t.totalSupply.String()
+	}
+	return res
+}
+
+// received returns the amount received for a transfer of amount taxed at taxBps.
+func (t *transferTax) received(amount *big.Int, taxBps int64) *big.Int {
+	res := new(big.Int).Mul(amount, big.NewInt(taxBps))
+	res.Sub(amount, res.Div(res, big.NewInt(BasisPoint)))
+	if t.totalShares != nil {
+		res.Mul(res, t.totalShares).Div(res, t.totalSupply)
+		res.Mul(res, t.totalSupply).Div(res, t.totalShares)
+	}
+	return res
+}
+
+// sent returns the smallest amount to transfer for amount to be received, taxed at taxBps.
+func (t *transferTax) sent(amount *big.Int, taxBps int64) *big.Int {
+	res := new(big.Int).Set(amount)
+	if t.totalShares != nil {
+		res = ceilDiv(res.Mul(res, t.totalShares), t.totalSupply)
+		res = ceilDiv(res.Mul(res, t.totalSupply), t.totalShares)
+	}
+	return ceilDiv(res.Mul(res, big.NewInt(BasisPoint)), big.NewInt(BasisPoint-taxBps))
+}
+
+func ceilDiv(x, y *big.Int) *big.Int {
+	res, mod := new(big.Int).DivMod(x, y, new(big.Int))
+	if mod.Sign() > 0 {
+		res.Add(res, bOne)
+	}
+	return res
+}
+
+var bOne = big.NewInt(1)
+
+// TransferTaxSwapInfo is the SwapInfo of swaps through a TransferTaxPoolSimulator: the SwapInfo of the wrapped
+// simulator with the amounts the pool actually received and sent.
+type TransferTaxSwapInfo struct {
+	SwapInfo      any
+	PoolAmountIn  *big.Int
+	PoolAmountOut *big.Int
+}
+
+// TransferTaxPoolSimulator wraps a simulator of a pool holding fee-on-transfer or rebasing tokens: the amount in is
+// taxed before reaching the pool and the amount out before reaching the user, and UpdateBalance updates the wrapped
+// simulator with the amounts the pool actually received and sent. It implements IPoolSpotPricer and
+// IPoolDepthCalculator whether the wrapped simulator does or not, returning ErrSpotPriceUnsupported in the latter case
+// for the pool to be probed instead. Pool factories registered with RegisterFactory wrap the simulators of pools with
+// taxed tokens.
+type TransferTaxPoolSimulator struct {
+	IPoolSimulator
+	taxes map[string]*transferTax
+}
+
+// transferTaxExactOutPoolSimulator is a TransferTaxPoolSimulator of an IPoolExactOutSimulator.
+type transferTaxExactOutPoolSimulator struct {
+	*TransferTaxPoolSimulator
+}
+
+// NewTransferTaxPoolSimulator wraps sim with the transfer taxes of tokens, the tokens of its entity.Pool. It returns
+// sim itself if none of tokens has a TransferTax. The wrapper implements IPoolExactOutSimulator if sim does.
+func NewTransferTaxPoolSimulator(sim IPoolSimulator, tokens []*entity.PoolToken) (IPoolSimulator, error) {
+	taxes := make(map[string]*transferTax)
+	for _, token := range tokens {
+		if token == nil || token.TransferTax == nil {
+			continue
+		}
+		tax, err := newTransferTax(token.TransferTax)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "token %s", token.Address)
+		}
+		taxes[token.Address] = tax
+	}
+	if len(taxes) == 0 {
+		return sim, nil
+	}
+	return wrapTransferTax(sim, taxes), nil
+}
+
+func wrapTransferTax(sim IPoolSimulator, taxes map[string]*transferTax) IPoolSimulator {
+	wrapped := &TransferTaxPoolSimulator{IPoolSimulator: sim, taxes: taxes}
+	if _, ok := sim.(IPoolExactOutSimulator); ok {
+		return &transferTaxExactOutPoolSimulator{TransferTaxPoolSimulator: wrapped}
+	}
+	return wrapped
+}
+
+// Unwrap returns the wrapped simulator.
+func (p *TransferTaxPoolSimulator) Unwrap() IPoolSimulator {
+	return p.IPoolSimulator
+}
+
+// TransferTaxes returns the transfer taxes of the taxed tokens, keyed by address, so that the wrapper can be built
+// again with NewTransferTaxPoolSimulator.
+func (p *TransferTaxPoolSimulator) TransferTaxes() map[string]*entity.TransferTax {
+	taxes := make(map[string]*entity.TransferTax, len(p.taxes))
+	for token, tax := range p.taxes {
+		taxes[token] = tax.entity()
+	}
+	return taxes
+}
+
+// sellReceived returns the amount of token the pool receives when sent amount.
+func (p *TransferTaxPoolSimulator) sellReceived(token string, amount *big.Int) *big.Int {
+	if tax, ok := p.taxes[token]; ok {
+		return tax.received(amount, tax.sellTaxBps)
+	}
+	return amount
+}
+
+// buyReceived returns the amount of token the user receives when the pool sends amount.
+func (p *TransferTaxPoolSimulator) buyReceived(token string, amount *big.Int) *big.Int {
+	if tax, ok := p.taxes[token]; ok {
+		return tax.received(amount, tax.buyTaxBps)
+	}
+	return amount
+}
+
+func (p *TransferTaxPoolSimulator) CalcAmountOut(params CalcAmountOutParams) (*CalcAmountOutResult, error) {
+	poolAmountIn := p.sellReceived(params.TokenAmountIn.Token, params.TokenAmountIn.Amount)
+	if poolAmountIn.Sign() <= 0 {
+		return nil, ErrInsufficientAmount
+	}
+	params.TokenAmountIn = TokenAmount{
+		Token:     params.TokenAmountIn.Token,
+		Amount:    poolAmountIn,
+		AmountUsd: params.TokenAmountIn.AmountUsd,
+	}
+	res, err := p.IPoolSimulator.CalcAmountOut(params)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.TokenAmountOut == nil || res.TokenAmountOut.Amount == nil {
+		return res, nil
+	}
+
+	poolAmountOut := res.TokenAmountOut.Amount
+	res.TokenAmountOut = &TokenAmount{
+		Token:  res.TokenAmountOut.Token,
+		Amount: p.buyReceived(res.TokenAmountOut.Token, poolAmountOut),
+	}
+	res.SwapInfo = TransferTaxSwapInfo{
+		SwapInfo:      res.SwapInfo,
+		PoolAmountIn:  poolAmountIn,
+		PoolAmountOut: poolAmountOut,
+	}
+	return res, nil
+}
+
+func (p *transferTaxExactOutPoolSimulator) CalcAmountIn(params CalcAmountInParams) (*CalcAmountInResult, error) {
+	tokenOut := params.TokenAmountOut.Token
+	poolAmountOut := params.TokenAmountOut.Amount
+	if tax, ok := p.taxes[tokenOut]; ok {
+		poolAmountOut = tax.sent(poolAmountOut, tax.buyTaxBps)
+	}
+	params.TokenAmountOut = TokenAmount{Token: tokenOut, Amount: poolAmountOut}
+	res, err := p.IPoolSimulator.(IPoolExactOutSimulator).CalcAmountIn(params)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil || res.TokenAmountIn == nil || res.TokenAmountIn.Amount == nil {
+		return res, nil
+	}
+
+	poolAmountIn, amountIn := res.TokenAmountIn.Amount, res.TokenAmountIn.Amount
+	if tax, ok := p.taxes[res.TokenAmountIn.Token]; ok {
+		amountIn = tax.sent(poolAmountIn, tax.sellTaxBps)
+	}
+	res.TokenAmountIn = &TokenAmount{Token: res.TokenAmountIn.Token, Amount: amountIn}
+	res.SwapInfo = TransferTaxSwapInfo{
+		SwapInfo:      res.SwapInfo,
+		PoolAmountIn:  poolAmountIn,
+		PoolAmountOut: poolAmountOut,
+	}
+	return res, nil
+}
+
+// SpotPrice returns the spot price of the wrapped simulator net of the sell tax of tokenIn and the buy tax of tokenOut.
+// The rounding of rebasing tokens is left out.
+func (p *TransferTaxPoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	pricer, ok := p.IPoolSimulator.(IPoolSpotPricer)
+	if !ok {
+		return nil, ErrSpotPriceUnsupported
+	}
+	spotPrice, err := pricer.SpotPrice(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	res := new(big.Float).Set(spotPrice)
+	if tax, ok := p.taxes[tokenIn]; ok {
+		res.Mul(res, big.NewFloat(float64(BasisPoint-tax.sellTaxBps)/BasisPoint))
+	}
+	if tax, ok := p.taxes[tokenOut]; ok {
+		res.Mul(res, big.NewFloat(float64(BasisPoint-tax.buyTaxBps)/BasisPoint))
+	}
+	return res, nil
+}
+
+// Depth returns the depth of the wrapped simulator, its amount in being what must be sent for the pool to receive its
+// own, and its amount out what the user receives. Taxes scale the rate of every swap and the spot price alike, so
+// they leave the price impact unchanged.
+func (p *TransferTaxPoolSimulator) Depth(tokenIn, tokenOut string, impactBps int64) (*DepthPoint, error) {
+	calculator, ok := p.IPoolSimulator.(IPoolDepthCalculator)
+	if !ok {
+		return nil, ErrSpotPriceUnsupported
+	}
+	point, err := calculator.Depth(tokenIn, tokenOut, impactBps)
+	if err != nil {
+		return nil, err
+	}
+	res := &DepthPoint{ImpactBps: point.ImpactBps, AmountIn: point.AmountIn, AmountOut: point.AmountOut}
+	if tax, ok := p.taxes[tokenIn]; ok && res.AmountIn.Sign() > 0 {
+		res.AmountIn = tax.sent(res.AmountIn, tax.sellTaxBps)
+	}
+	res.AmountOut = p.buyReceived(tokenOut, res.AmountOut)
+	return res, nil
+}
+
+// UpdateBalance updates the wrapped simulator with the amounts of the TransferTaxSwapInfo of the swap. Without it, the
+// pool is assumed to have received the taxed amount in and sent the amount out.
+func (p *TransferTaxPoolSimulator) UpdateBalance(params UpdateBalanceParams) {
+	if swapInfo, ok := params.SwapInfo.(TransferTaxSwapInfo); ok {
+		params.SwapInfo = swapInfo.SwapInfo
+		params.TokenAmountIn.Amount = swapInfo.PoolAmountIn
+		params.TokenAmountOut.Amount = swapInfo.PoolAmountOut
+	} else if params.TokenAmountIn.Amount != nil {
+		params.TokenAmountIn.Amount = p.sellReceived(params.TokenAmountIn.Token, params.TokenAmountIn.Amount)
+	}
+	p.IPoolSimulator.UpdateBalance(params)
+}
+
+func (p *TransferTaxPoolSimulator) CloneState() IPoolSimulator {
+	cloned := p.IPoolSimulator.CloneState()
+	if cloned == nil {
+		return nil
+	}
+	return wrapTransferTax(cloned, p.taxes)
+}
+
+// TransferSimulator simulates token transfers, for instance with eth_call state overrides or a local EVM.
+type TransferSimulator interface {
+	// SimulateTransfer simulates a transfer of amount of token from `from` to `to`, and returns the amount `to`
+	// received: the increase of its balance.
+	SimulateTransfer(ctx context.Context, token, from, to string, amount *big.Int) (*big.Int, error)
+}
+
+// DetectTransferTax infers the transfer tax of token by simulating the transfer of amount from user to poolAddress
+// (a sell) and from poolAddress to user (a buy). Shortfalls within rounding are not taxes, and are left to the
+// rebasing fields of TransferTax, which cannot be inferred from transfers. It returns nil for tokens transferring
+// exact amounts.
+func DetectTransferTax(ctx context.Context, sim TransferSimulator, token, poolAddress, user string,
+	amount *big.Int) (*entity.TransferTax, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, ErrInsufficientAmount
+	}
+	sellTaxBps, err := detectTaxBps(ctx, sim, token, user, poolAddress, amount)
+	if err != nil {
+		return nil, errors.WithMessage(err, "sell")
+	}
+	buyTaxBps, err := detectTaxBps(ctx, sim, token, poolAddress, user, amount)
+	if err != nil {
+		return nil, errors.WithMessage(err, "buy")
+	}
+	if buyTaxBps == 0 && sellTaxBps == 0 {
+		return nil, nil
+	}
+	return &entity.TransferTax{BuyTaxBps: buyTaxBps, SellTaxBps: sellTaxBps}, nil
+}
+
+// detectTaxBps returns the tax of a simulated transfer, rounded to the nearest basis point.
+func detectTaxBps(ctx context.Context, sim TransferSimulator, token, from, to string, amount *big.Int) (int64,
+	error) {
+	received, err := sim.SimulateTransfer(ctx, token, from, to, amount)
+	if err != nil {
+		return 0, err
+	}
+	shortfall := new(big.Int).Sub(amount, received)
+	if shortfall.Cmp(big.NewInt(transferTaxRoundingTolerance)) <= 0 {
+		return 0, nil
+	}
+	taxBps := shortfall.Mul(shortfall, big.NewInt(2*BasisPoint))
+	taxBps.Add(taxBps, amount).Div(taxBps, new(big.Int).Lsh(amount, 1))
+	if !taxBps.IsInt64() || taxBps.Int64() >= BasisPoint {
+		return 0, errors.WithMessagef(ErrInvalidTransferTax, "received %s of %s", received, amount)
+	}
+	return taxBps.Int64(), nil
+}
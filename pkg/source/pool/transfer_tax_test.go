@@ -0,0 +1,196 @@
+package pool
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+)
+
+func newTransferTaxTestPool(t *testing.T) (*constantProductPool, IPoolSimulator) {
+	cp := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	sim, err := NewTransferTaxPoolSimulator(cp, []*entity.PoolToken{
+		{Address: "a", TransferTax: &entity.TransferTax{BuyTaxBps: 1000, SellTaxBps: 500}},
+		{Address: "b", TransferTax: &entity.TransferTax{TotalShares: "3", TotalSupply: "4"}},
+	})
+	require.NoError(t, err)
+	return cp, sim
+}
+
+func TestTransferTaxPoolSimulator_CalcAmountOut(t *testing.T) {
+	cp, sim := newTransferTaxTestPool(t)
+
+	// the pool receives 1000 - 5% = 950 a and sends 950 * 1e6 / (1e6 + 950) = 949 b, worth 711 shares, received as
+	// 711 * 4 / 3 = 948 b
+	res, err := sim.CalcAmountOut(CalcAmountOutParams{
+		TokenAmountIn: TokenAmount{Token: "a", Amount: big.NewInt(1000)},
+		TokenOut:      "b",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "948", res.TokenAmountOut.Amount.String())
+	swapInfo, ok := res.SwapInfo.(TransferTaxSwapInfo)
+	require.True(t, ok)
+	assert.Equal(t, "950", swapInfo.PoolAmountIn.String())
+	assert.Equal(t, "949", swapInfo.PoolAmountOut.String())
+
+	cloned := sim.CloneState()
+	require.IsType(t, sim, cloned)
+	sim.UpdateBalance(UpdateBalanceParams{
+		TokenAmountIn:  TokenAmount{Token: "a", Amount: big.NewInt(1000)},
+		TokenAmountOut: *res.TokenAmountOut,
+		SwapInfo:       res.SwapInfo,
+	})
+	assert.Equal(t, "1000950", cp.Info.Reserves[0].String())
+	assert.Equal(t, "999051", cp.Info.Reserves[1].String())
+	assert.Equal(t, "1000000", cloned.GetReserves()[0].String())
+
+	// the pool receives 1000 b and sends 1000 * 1e6 / (1e6 + 1000) = 999 a, taxed 10%
+	res, err = cloned.CalcAmountOut(CalcAmountOutParams{
+		TokenAmountIn: TokenAmount{Token: "b", Amount: big.NewInt(1000)},
+		TokenOut:      "a",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "900", res.TokenAmountOut.Amount.String())
+}
+
+func TestTransferTaxPoolSimulator_CalcAmountIn(t *testing.T) {
+	_, sim := newTransferTaxTestPool(t)
+	exactOutSim, ok := sim.(IPoolExactOutSimulator)
+	require.True(t, ok)
+
+	// 948 b are received from 711 shares, worth 948 b sent by the pool, which needs 949 a, received from 999 a sent
+	res, err := exactOutSim.CalcAmountIn(CalcAmountInParams{
+		TokenAmountOut: TokenAmount{Token: "b", Amount: big.NewInt(948)},
+		TokenIn:        "a",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "999", res.TokenAmountIn.Amount.String())
+
+	resOut, err := sim.CalcAmountOut(CalcAmountOutParams{TokenAmountIn: *res.TokenAmountIn, TokenOut: "b"})
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, resOut.TokenAmountOut.Amount.Int64(), int64(948))
+}
+
+func TestNewTransferTaxPoolSimulator(t *testing.T) {
+	cp := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 1e6})
+	sim, err := NewTransferTaxPoolSimulator(cp, []*entity.PoolToken{{Address: "a"}, {Address: "b"}})
+	require.NoError(t, err)
+	assert.Same(t, cp, sim)
+
+	for _, tax := range []*entity.TransferTax{
+		{BuyTaxBps: BasisPoint},
+		{SellTaxBps: -1},
+		{TotalShares: "1"},
+		{TotalShares: "0", TotalSupply: "1"},
+	} {
+		_, err = NewTransferTaxPoolSimulator(cp, []*entity.PoolToken{{Address: "a", TransferTax: tax}})
+		assert.ErrorIs(t, err, ErrInvalidTransferTax)
+	}
+}
+
+func TestTransferTaxPoolSimulator_SpotPrice(t *testing.T) {
+	cp := newConstantProductPool("ab", "cp", [2]string{"a", "b"}, [2]int64{1e6, 2e6})
+	tokens := []*entity.PoolToken{
+		{Address: "a", TransferTax: &entity.TransferTax{BuyTaxBps: 1000, SellTaxBps: 500}},
+		{Address: "b"},
+	}
+	sim, err := NewTransferTaxPoolSimulator(cp, tokens)
+	require.NoError(t, err)
+	_, err = sim.(IPoolSpotPricer).SpotPrice("a", "b")
+	assert.ErrorIs(t, err, ErrSpotPriceUnsupported, "the wrapped pool does not price natively")
+
+	sim, err = NewTransferTaxPoolSimulator(&spotPricedPool{constantProductPool: cp}, tokens)
+	require.NoError(t, err)
+	// 2 b per a, of which 95% reach the pool
+	spotPrice, err := sim.(IPoolSpotPricer).SpotPrice("a", "b")
+	require.NoError(t, err)
+	f, _ := spotPrice.Float64()
+	assert.InEpsilon(t, 1.9, f, 1e-12)
+	// 0.5 a per b, of which 90% reach the user
+	spotPrice, err = sim.(IPoolSpotPricer).SpotPrice("b", "a")
+	require.NoError(t, err)
+	f, _ = spotPrice.Float64()
+	assert.InEpsilon(t, 0.45, f, 1e-12)
+
+	sim, err = NewTransferTaxPoolSimulator(&depthPool{spotPricedPool: &spotPricedPool{constantProductPool: cp}}, tokens)
+	require.NoError(t, err)
+	// the pool receiving 1000 a takes 1000 / 95% sent, its 2000 b out are untaxed
+	point, err := sim.(IPoolDepthCalculator).Depth("a", "b", 100)
+	require.NoError(t, err)
+	assert.Equal(t, "1053", point.AmountIn.String())
+	assert.Equal(t, "2000", point.AmountOut.String())
+}
+
+// depthPool is a spotPricedPool whose depth is always 1000 in for 2000 out.
+type depthPool struct {
+	*spotPricedPool
+}
+
+func (p *depthPool) Depth(_, _ string, impactBps int64) (*DepthPoint, error) {
+	return &DepthPoint{ImpactBps: impactBps, AmountIn: big.NewInt(1000), AmountOut: big.NewInt(2000)}, nil
+}
+
+func TestRegisterFactory_TransferTax(t *testing.T) {
+	const poolType = "transfer-tax-test"
+	RegisterFactory0(poolType, func(entityPool entity.Pool) (*constantProductPool, error) {
+		return newConstantProductPool(entityPool.Address, entityPool.Exchange, [2]string{"a", "b"},
+			[2]int64{1e6, 1e6}), nil
+	})
+
+	entityPool := entity.Pool{Address: "ab", Exchange: "cp", Type: poolType, Tokens: []*entity.PoolToken{
+		{Address: "a", TransferTax: &entity.TransferTax{SellTaxBps: 500}},
+		{Address: "b"},
+	}}
+	sim, err := Factory(poolType)(FactoryParams{EntityPool: entityPool})
+	require.NoError(t, err)
+	require.IsType(t, &transferTaxExactOutPoolSimulator{}, sim)
+	res, err := sim.CalcAmountOut(CalcAmountOutParams{
+		TokenAmountIn: TokenAmount{Token: "a", Amount: big.NewInt(1000)},
+		TokenOut:      "b",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "949", res.TokenAmountOut.Amount.String())
+
+	entityPool.Tokens[0].TransferTax = nil
+	sim, err = Factory(poolType)(FactoryParams{EntityPool: entityPool})
+	require.NoError(t, err)
+	assert.IsType(t, &constantProductPool{}, sim)
+
+	entityPool.Tokens[0].TransferTax = &entity.TransferTax{SellTaxBps: BasisPoint}
+	_, err = Factory(poolType)(FactoryParams{EntityPool: entityPool})
+	assert.ErrorIs(t, err, ErrInvalidTransferTax)
+}
+
+// transferSimulator taxes transfers to the pool at sellTaxBps and from the pool at buyTaxBps, less 1 wei of rounding.
+type transferSimulator struct {
+	pool                  string
+	buyTaxBps, sellTaxBps int64
+}
+
+func (s *transferSimulator) SimulateTransfer(_ context.Context, _, from, to string, amount *big.Int) (*big.Int,
+	error) {
+	taxBps := s.buyTaxBps
+	if to == s.pool {
+		taxBps = s.sellTaxBps
+	}
+	tax := new(big.Int).Mul(amount, big.NewInt(taxBps))
+	tax.Div(tax, big.NewInt(BasisPoint))
+	return tax.Sub(amount, tax).Sub(tax, big.NewInt(1)), nil
+}
+
+func TestDetectTransferTax(t *testing.T) {
+	amount := big.NewInt(1e18)
+
+	tax, err := DetectTransferTax(context.Background(), &transferSimulator{pool: "p", buyTaxBps: 300, sellTaxBps: 500},
+		"t", "p", "u", amount)
+	require.NoError(t, err)
+	assert.Equal(t, &entity.TransferTax{BuyTaxBps: 300, SellTaxBps: 500}, tax)
+
+	tax, err = DetectTransferTax(context.Background(), &transferSimulator{pool: "p"}, "t", "p", "u", amount)
+	require.NoError(t, err)
+	assert.Nil(t, tax)
+}
@@ -2,7 +2,6 @@ package quickperps
 
 import (
 	"math/big"
-	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/goccy/go-json"
@@ -129,11 +128,11 @@ func (pf *VaultPriceFeed) UnmarshalJSONSecondaryPriceFeed(bytes []byte) error {
 	return nil
 }
 
-func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool, timestamp int64) (*big.Int, error) {
 	var price *big.Int
 	var err error
 
-	price, err = pf.getPriceV1(token, maximise)
+	price, err = pf.getPriceV1(token, maximise, timestamp)
 	if err != nil {
 		return nil, err
 	}
@@ -163,14 +162,14 @@ func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, _ bool, _ bool)
 	return price, nil
 }
 
-func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool) (*big.Int, error) {
-	price, err := pf.getPrimaryPrice(token)
+func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, timestamp int64) (*big.Int, error) {
+	price, err := pf.getPrimaryPrice(token, timestamp)
 	if err != nil {
 		return nil, err
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -217,7 +216,7 @@ func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool) (*big.Int, err
 	), nil
 }
 
-func (pf *VaultPriceFeed) getPrimaryPrice(token string) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPrimaryPrice(token string, timestamp int64) (*big.Int, error) {
 	priceFeed, ok := pf.PriceFeedProxies[token]
 	if !ok {
 		return nil, ErrVaultPriceFeedInvalidPriceFeed
@@ -227,8 +226,8 @@ func (pf *VaultPriceFeed) getPrimaryPrice(token string) (*big.Int, error) {
 	if price.Cmp(bignumber.ZeroBI) <= 0 {
 		return nil, ErrVaultPriceFeedInvalidPrice
 	}
-	timestamp := big.NewInt(int64(priceFeed.Timestamp))
-	if new(big.Int).Add(timestamp, pf.ExpireTimeForPriceFeed).Cmp(big.NewInt(time.Now().Unix())) <= 0 {
+	updatedAt := big.NewInt(int64(priceFeed.Timestamp))
+	if new(big.Int).Add(updatedAt, pf.ExpireTimeForPriceFeed).Cmp(big.NewInt(timestamp)) <= 0 {
 		return nil, ErrVaultPriceFeedExpired
 	}
 
@@ -241,10 +240,10 @@ func (pf *VaultPriceFeed) getPrimaryPrice(token string) (*big.Int, error) {
 	return price, nil
 }
 
-func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool) *big.Int {
+func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool, timestamp int64) *big.Int {
 	if pf.SecondaryPriceFeed == nil {
 		return referencePrice
 	}
 
-	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise)
+	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise, timestamp)
 }
@@ -162,17 +162,17 @@ func (pf *VaultPriceFeed) UnmarshalJSONSecondaryPriceFeed(bytes []byte) error {
 	return nil
 }
 
-func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice bool, _ bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice bool, _ bool, timestamp int64) (*big.Int, error) {
 	var price *big.Int
 	var err error
 
 	if pf.UseV2Pricing {
-		price, err = pf.getPriceV2(token, maximise, includeAmmPrice)
+		price, err = pf.getPriceV2(token, maximise, includeAmmPrice, timestamp)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		price, err = pf.getPriceV1(token, maximise, includeAmmPrice)
+		price, err = pf.getPriceV1(token, maximise, includeAmmPrice, timestamp)
 		if err != nil {
 			return nil, err
 		}
@@ -205,7 +205,7 @@ func (pf *VaultPriceFeed) GetPrice(token string, maximise bool, includeAmmPrice
 	return price, nil
 }
 
-func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPrice bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPrice bool, timestamp int64) (*big.Int, error) {
 	price, err := pf.getPrimaryPrice(token, maximise)
 	if err != nil {
 		return nil, err
@@ -224,7 +224,7 @@ func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPric
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -271,7 +271,7 @@ func (pf *VaultPriceFeed) getPriceV1(token string, maximise bool, includeAmmPric
 	), nil
 }
 
-func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPrice bool) (*big.Int, error) {
+func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPrice bool, timestamp int64) (*big.Int, error) {
 	price, err := pf.getPrimaryPrice(token, maximise)
 	if err != nil {
 		return nil, err
@@ -282,7 +282,7 @@ func (pf *VaultPriceFeed) getPriceV2(token string, maximise bool, includeAmmPric
 	}
 
 	if pf.IsSecondaryPriceEnabled {
-		price = pf.getSecondaryPrice(token, price, maximise)
+		price = pf.getSecondaryPrice(token, price, maximise, timestamp)
 	}
 
 	if pf.StrictStableTokens[token] {
@@ -388,12 +388,12 @@ func (pf *VaultPriceFeed) getPrimaryPrice(token string, maximise bool) (*big.Int
 	), nil
 }
 
-func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool) *big.Int {
+func (pf *VaultPriceFeed) getSecondaryPrice(token string, referencePrice *big.Int, maximise bool, timestamp int64) *big.Int {
 	if pf.SecondaryPriceFeed == nil {
 		return referencePrice
 	}
 
-	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise)
+	return pf.SecondaryPriceFeed.GetPrice(token, referencePrice, maximise, timestamp)
 }
 
 func (pf *VaultPriceFeed) getAmmPrice(token string) *big.Int {
@@ -271,6 +271,29 @@ func (p *PoolSimulator) CalcAmountOut(param pool.CalcAmountOutParams) (*pool.Cal
 		tokenOutIndex)
 }
 
+// SpotPrice returns the pool price at its current sqrt price, net of the swap fee. Pools without liquidity in range
+// are probed, as the first swapped wei crosses ticks.
+func (p *PoolSimulator) SpotPrice(tokenIn, tokenOut string) (*big.Float, error) {
+	if p.GetTokenIndex(tokenIn) < 0 || p.GetTokenIndex(tokenOut) < 0 {
+		return nil, fmt.Errorf("tokenIn %v or tokenOut %v is not correct", tokenIn, tokenOut)
+	}
+	if p.V3Pool.Liquidity.IsZero() {
+		return nil, pool.ErrSpotPriceUnsupported
+	}
+	zeroForOne := !strings.EqualFold(tokenOut, hexutil.Encode(p.V3Pool.Token0.Address[:]))
+
+	// price of token0 in token1 = (sqrtPriceX96 / 2^96)^2
+	sqrtPrice := new(big.Float).SetInt(p.V3Pool.SqrtRatioX96.ToBig())
+	spotPrice := new(big.Float).Mul(sqrtPrice, sqrtPrice)
+	if q192 := new(big.Float).SetInt(constants.Q192); zeroForOne {
+		spotPrice.Quo(spotPrice, q192)
+	} else {
+		spotPrice.Quo(q192, spotPrice)
+	}
+	spotPrice.Mul(spotPrice, new(big.Float).SetUint64(uint64(constants.FeeMax-p.V3Pool.Fee)))
+	return spotPrice.Quo(spotPrice, new(big.Float).SetUint64(uint64(constants.FeeMax))), nil
+}
+
 func (p *PoolSimulator) CloneState() pool.IPoolSimulator {
 	cloned := *p
 	v3Pool := *p.V3Pool
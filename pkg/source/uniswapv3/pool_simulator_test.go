@@ -137,3 +137,12 @@ func TestCloneState(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedAmountOut, result.TokenAmountOut.Amount.String())
 }
+
+func TestPoolSimulator_SpotPrice(t *testing.T) {
+	poolEntity := new(entity.Pool)
+	require.NoError(t, json.Unmarshal([]byte(poolEncoded), poolEntity))
+	poolSim, err := NewPoolSimulator(*poolEntity, valueobject.ChainIDEthereum)
+	require.NoError(t, err)
+
+	testutil.TestSpotPrice(t, poolSim, 1e-6)
+}
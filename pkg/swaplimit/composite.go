@@ -0,0 +1,376 @@
+package swaplimit
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+var (
+	ErrNoExchangeLimit = errors.New("no swap limit for exchange")
+	ErrStaleSnapshot   = errors.New("stale snapshot")
+)
+
+// LimitUpdate is an UpdateLimit of the swap limit of Exchange.
+type LimitUpdate struct {
+	Exchange      string
+	DecreaseKey   string
+	IncreaseKey   string
+	DecreaseDelta *big.Int
+	IncreaseDelta *big.Int
+}
+
+// Snapshot identifies a state of a Composite to restore, see Composite.Snapshot. Snapshots are unique across all
+// Composites, so that a snapshot can only restore or release the state it was taken for.
+type Snapshot uint64
+
+// lastSnapshot is the last Snapshot taken by any Composite.
+var lastSnapshot atomic.Uint64
+
+// Composite multiplexes the swap limits of several exchanges, such as Inventory, SingleSwapLimit or
+// MakerBalanceLimit, by exchange and key. It updates several limits atomically, and snapshots their state so that
+// swaps can be tried then reverted. The limits are updated in place, the snapshots journaling the values of the keys
+// updated after them to restore them.
+type Composite struct {
+	lock   *sync.RWMutex
+	limits map[string]pool.SwapLimit
+	// frames are the open snapshots, the last one being the innermost
+	frames []*compositeFrame
+}
+
+// compositeFrame journals the updates made after snapshot was taken. The limits of this package are journaled by key
+// in undo. The other limits cannot be, and are copied on their first update after the snapshot: saved keeps the
+// original, and the Composite updates a clone of it.
+type compositeFrame struct {
+	snapshot Snapshot
+	// undo restores the values of the keys updated in the frame, to be called in reverse order
+	undo  []func()
+	saved map[string]pool.SwapLimit
+	// clones are the shared states cloned in this frame, see pool.SharedSwapLimit
+	clones map[any]any
+}
+
+// NewComposite creates a Composite of limits, keyed by their exchange.
+func NewComposite(limits ...pool.SwapLimit) *Composite {
+	c := &Composite{
+		lock:   &sync.RWMutex{},
+		limits: make(map[string]pool.SwapLimit, len(limits)),
+	}
+	for _, limit := range limits {
+		c.limits[limit.GetExchange()] = limit
+	}
+	return c
+}
+
+// Clone clones the Composite and all its limits, without its snapshots.
+func (c *Composite) Clone() *Composite {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.clone(make(map[any]any))
+}
+
+func (c *Composite) clone(clones map[any]any) *Composite {
+	cloned := &Composite{
+		lock:   &sync.RWMutex{},
+		limits: make(map[string]pool.SwapLimit, len(c.limits)),
+	}
+	for exchange, limit := range c.limits {
+		cloned.limits[exchange] = cloneLimit(limit, clones)
+	}
+	clones[c] = cloned
+	return cloned
+}
+
+// Limit returns the swap limit of exchange, backed by the Composite: its updates are journaled by the snapshots of
+// the Composite. It returns nil if the Composite has no limit for exchange.
+func (c *Composite) Limit(exchange string) pool.SwapLimit {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if _, ok := c.limits[exchange]; !ok {
+		return nil
+	}
+	return &compositeLimit{composite: c, exchange: exchange}
+}
+
+// Limits returns the swap limits of all exchanges, backed by the Composite, to be used as the limits keyed by
+// exchange of route simulations.
+func (c *Composite) Limits() map[string]pool.SwapLimit {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	limits := make(map[string]pool.SwapLimit, len(c.limits))
+	for exchange := range c.limits {
+		limits[exchange] = &compositeLimit{composite: c, exchange: exchange}
+	}
+	return limits
+}
+
+// GetLimit returns the limit of key for exchange, nil if the Composite has no limit for exchange.
+func (c *Composite) GetLimit(exchange, key string) *big.Int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	limit, ok := c.limits[exchange]
+	if !ok {
+		return nil
+	}
+	return limit.GetLimit(key)
+}
+
+// UpdateLimits applies updates in order, all or none: if an update fails, the previous ones are rolled back and the
+// error of the failed update is returned.
+func (c *Composite) UpdateLimits(updates ...LimitUpdate) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.snapshot()
+	frame := len(c.frames) - 1
+	for i, update := range updates {
+		if _, _, err := c.updateLimit(update); err != nil {
+			c.restore(frame)
+			return fmt.Errorf("update %d of %s: %w", i, update.Exchange, err)
+		}
+	}
+	c.release(frame)
+	return nil
+}
+
+// Snapshot records the current state of the limits, to be either restored with Restore or released with Release.
+// Snapshots nest: restoring or releasing a snapshot also restores or releases those taken after it, which become
+// stale. Taking a snapshot is free, and each update after it journals the previous values of the keys it updates, so
+// that restoring or releasing it costs O(keys updated). Limits of other packages cannot be journaled by key: they are
+// cloned on their first update after each snapshot.
+func (c *Composite) Snapshot() Snapshot {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.snapshot()
+}
+
+// Restore reverts the limits to their state at snapshot, and releases it. It returns ErrStaleSnapshot, leaving the
+// limits as they are, if snapshot is not an open snapshot of the Composite: already restored or released, directly
+// or through a snapshot taken before it, or taken by another Composite.
+func (c *Composite) Restore(snapshot Snapshot) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	frame, ok := c.frame(snapshot)
+	if !ok {
+		return ErrStaleSnapshot
+	}
+	c.restore(frame)
+	return nil
+}
+
+// Release keeps the updates made since snapshot, which can no longer be restored. The updates can still be restored
+// with the snapshots taken before it. It returns ErrStaleSnapshot if snapshot is not an open snapshot of the
+// Composite, see Restore.
+func (c *Composite) Release(snapshot Snapshot) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	frame, ok := c.frame(snapshot)
+	if !ok {
+		return ErrStaleSnapshot
+	}
+	c.release(frame)
+	return nil
+}
+
+func (c *Composite) snapshot() Snapshot {
+	snapshot := Snapshot(lastSnapshot.Add(1))
+	c.frames = append(c.frames, &compositeFrame{
+		snapshot: snapshot,
+		saved:    make(map[string]pool.SwapLimit),
+		clones:   make(map[any]any),
+	})
+	return snapshot
+}
+
+// frame returns the index of the frame of snapshot, if it is open.
+func (c *Composite) frame(snapshot Snapshot) (int, bool) {
+	for i := len(c.frames) - 1; i >= 0; i-- {
+		if c.frames[i].snapshot == snapshot {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// restore reverts the limits to their state at the frame at index frame, and releases it.
+func (c *Composite) restore(frame int) {
+	for i := len(c.frames) - 1; i >= frame; i-- {
+		undo := c.frames[i].undo
+		for j := len(undo) - 1; j >= 0; j-- {
+			undo[j]()
+		}
+		for exchange, limit := range c.frames[i].saved {
+			c.limits[exchange] = limit
+		}
+	}
+	c.frames = c.frames[:frame]
+}
+
+// release releases the frame at index frame and the frames after it.
+func (c *Composite) release(frame int) {
+	if frame > 0 {
+		// the outer snapshot must still restore the updates journaled by the released ones
+		outer := c.frames[frame-1]
+		for _, released := range c.frames[frame:] {
+			outer.undo = append(outer.undo, released.undo...)
+			for exchange, limit := range released.saved {
+				if _, ok := outer.saved[exchange]; !ok {
+					outer.saved[exchange] = limit
+				}
+			}
+		}
+	}
+	c.frames = c.frames[:frame]
+}
+
+func (c *Composite) updateLimit(update LimitUpdate) (*big.Int, *big.Int, error) {
+	limit, ok := c.limits[update.Exchange]
+	if !ok {
+		return nil, nil, ErrNoExchangeLimit
+	}
+	if len(c.frames) > 0 {
+		frame := c.frames[len(c.frames)-1]
+		if journaled, ok := limit.(journaledLimit); ok {
+			frame.undo = append(frame.undo, journaled.undoUpdate(update.DecreaseKey, update.IncreaseKey))
+		} else {
+			limit = c.copyOnWrite(frame, update.Exchange, limit)
+		}
+	}
+	return limit.UpdateLimit(update.DecreaseKey, update.IncreaseKey, update.DecreaseDelta, update.IncreaseDelta)
+}
+
+// journaledLimit is implemented by the limits whose updates a Composite journals by key.
+type journaledLimit interface {
+	// undoUpdate returns a function restoring the values of the keys an UpdateLimit of decreaseKey and increaseKey
+	// changes, to be called before the update.
+	undoUpdate(decreaseKey, increaseKey string) func()
+}
+
+// saveValues returns a function restoring the values of keys in m, deleting those that were not set. The values must
+// be replaced on update, not mutated.
+func saveValues(m map[string]*big.Int, keys ...string) func() {
+	values := make([]*big.Int, len(keys))
+	set := make([]bool, len(keys))
+	for i, key := range keys {
+		values[i], set[i] = m[key]
+	}
+	return func() {
+		for i, key := range keys {
+			if set[i] {
+				m[key] = values[i]
+			} else {
+				delete(m, key)
+			}
+		}
+	}
+}
+
+// copyOnWrite saves limit, the limit of exchange that cannot be journaled, in frame before its first update in the
+// frame, and returns the clone to update instead. The state of shared limits being updated through any of the limits
+// sharing it, all the shared limits that cannot be journaled are saved, and their state cloned once, together.
+func (c *Composite) copyOnWrite(frame *compositeFrame, exchange string, limit pool.SwapLimit) pool.SwapLimit {
+	if _, ok := frame.saved[exchange]; ok {
+		return limit
+	}
+	if _, ok := limit.(pool.SharedSwapLimit); ok {
+		for otherExchange, other := range c.limits {
+			if _, ok := other.(pool.SharedSwapLimit); !ok || otherExchange == exchange {
+				continue
+			} else if _, ok := other.(journaledLimit); ok {
+				continue
+			} else if _, ok := frame.saved[otherExchange]; ok {
+				continue
+			}
+			frame.saved[otherExchange] = other
+			c.limits[otherExchange] = cloneLimit(other, frame.clones)
+		}
+	}
+	frame.saved[exchange] = limit
+	cloned := cloneLimit(limit, frame.clones)
+	c.limits[exchange] = cloned
+	return cloned
+}
+
+func cloneLimit(limit pool.SwapLimit, clones map[any]any) pool.SwapLimit {
+	if shared, ok := limit.(pool.SharedSwapLimit); ok {
+		return shared.CloneShared(clones)
+	}
+	return limit.Clone()
+}
+
+// compositeLimit is the swap limit of an exchange of a Composite.
+type compositeLimit struct {
+	composite *Composite
+	exchange  string
+}
+
+var _ pool.SharedSwapLimit = (*compositeLimit)(nil)
+
+// Clone clones the Composite, and returns the limit of the exchange of the clone.
+func (l *compositeLimit) Clone() pool.SwapLimit {
+	return l.CloneShared(make(map[any]any))
+}
+
+// CloneShared clones the Composite once for all the limits cloned with clones, so that they still share it.
+func (l *compositeLimit) CloneShared(clones map[any]any) pool.SwapLimit {
+	composite, ok := clones[l.composite].(*Composite)
+	if !ok {
+		l.composite.lock.RLock()
+		composite = l.composite.clone(clones)
+		l.composite.lock.RUnlock()
+	}
+	return &compositeLimit{composite: composite, exchange: l.exchange}
+}
+
+func (l *compositeLimit) GetExchange() string {
+	return l.exchange
+}
+
+func (l *compositeLimit) GetLimit(key string) *big.Int {
+	return l.composite.GetLimit(l.exchange, key)
+}
+
+func (l *compositeLimit) GetSwapped() map[string]*big.Int {
+	l.composite.lock.RLock()
+	defer l.composite.lock.RUnlock()
+
+	if limit, ok := l.composite.limits[l.exchange]; ok {
+		return limit.GetSwapped()
+	}
+	return nil
+}
+
+func (l *compositeLimit) GetAllowedSenders() string {
+	l.composite.lock.RLock()
+	defer l.composite.lock.RUnlock()
+
+	if limit, ok := l.composite.limits[l.exchange]; ok {
+		return limit.GetAllowedSenders()
+	}
+	return ""
+}
+
+func (l *compositeLimit) UpdateLimit(decreaseKey, increaseKey string,
+	decreaseDelta, increaseDelta *big.Int) (*big.Int, *big.Int, error) {
+	l.composite.lock.Lock()
+	defer l.composite.lock.Unlock()
+
+	return l.composite.updateLimit(LimitUpdate{
+		Exchange:      l.exchange,
+		DecreaseKey:   decreaseKey,
+		IncreaseKey:   increaseKey,
+		DecreaseDelta: decreaseDelta,
+		IncreaseDelta: increaseDelta,
+	})
+}
@@ -0,0 +1,173 @@
+package swaplimit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+func newTestComposite() *Composite {
+	return NewComposite(
+		NewInventory("pmm", map[string]*big.Int{"x": big.NewInt(10), "y": big.NewInt(0)}),
+		NewInventory("rfq", map[string]*big.Int{"y": big.NewInt(5)}),
+		NewSingleSwapLimit("single"),
+	)
+}
+
+func TestComposite_UpdateLimits(t *testing.T) {
+	c := newTestComposite()
+
+	require.NoError(t, c.UpdateLimits(
+		LimitUpdate{Exchange: "pmm", DecreaseKey: "x", IncreaseKey: "y", DecreaseDelta: big.NewInt(4),
+			IncreaseDelta: big.NewInt(3)},
+		LimitUpdate{Exchange: "rfq", DecreaseKey: "y", IncreaseKey: "x", DecreaseDelta: big.NewInt(3),
+			IncreaseDelta: big.NewInt(4)},
+	))
+	assert.Equal(t, big.NewInt(6), c.GetLimit("pmm", "x"))
+	assert.Equal(t, big.NewInt(2), c.GetLimit("rfq", "y"))
+
+	err := c.UpdateLimits(
+		LimitUpdate{Exchange: "single"},
+		LimitUpdate{Exchange: "pmm", DecreaseKey: "x", IncreaseKey: "y", DecreaseDelta: big.NewInt(6),
+			IncreaseDelta: big.NewInt(6)},
+		LimitUpdate{Exchange: "rfq", DecreaseKey: "y", IncreaseKey: "x", DecreaseDelta: big.NewInt(3),
+			IncreaseDelta: big.NewInt(3)},
+	)
+	assert.ErrorIs(t, err, pool.ErrNotEnoughInventory)
+	assert.Nil(t, c.GetLimit("single", ""), "the failed update must roll back the previous ones")
+	assert.Equal(t, big.NewInt(6), c.GetLimit("pmm", "x"))
+	assert.Equal(t, big.NewInt(3), c.GetLimit("pmm", "y"))
+
+	err = c.UpdateLimits(LimitUpdate{Exchange: "unknown"})
+	assert.ErrorIs(t, err, ErrNoExchangeLimit)
+	assert.Nil(t, c.Limit("unknown"))
+}
+
+func TestComposite_Snapshot(t *testing.T) {
+	pmm := NewInventory("pmm", map[string]*big.Int{"x": big.NewInt(10)})
+	c := NewComposite(pmm, NewSingleSwapLimit("single"))
+	limits := c.Limits()
+
+	outer := c.Snapshot()
+	_, _, err := limits["pmm"].UpdateLimit("x", "y", big.NewInt(1), big.NewInt(1))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(9), limits["pmm"].GetLimit("x"))
+	assert.Equal(t, big.NewInt(9), pmm.GetLimit("x"), "the limits of the composite are updated in place")
+
+	inner := c.Snapshot()
+	_, _, err = limits["pmm"].UpdateLimit("x", "y", big.NewInt(2), big.NewInt(2))
+	require.NoError(t, err)
+	_, _, _ = limits["single"].UpdateLimit("", "", nil, nil)
+	require.NoError(t, c.Restore(inner))
+	assert.Equal(t, big.NewInt(9), limits["pmm"].GetLimit("x"))
+	assert.Nil(t, limits["single"].GetLimit(""))
+
+	inner = c.Snapshot()
+	_, _, err = limits["pmm"].UpdateLimit("x", "y", big.NewInt(3), big.NewInt(3))
+	require.NoError(t, err)
+	_, _, _ = limits["single"].UpdateLimit("", "", nil, nil)
+	require.NoError(t, c.Release(inner))
+	assert.Equal(t, big.NewInt(6), limits["pmm"].GetLimit("x"))
+
+	require.NoError(t, c.Restore(outer))
+	assert.Equal(t, big.NewInt(10), pmm.GetLimit("x"))
+	assert.Nil(t, pmm.GetLimit("y"), "keys set after the snapshot must be deleted")
+	assert.Nil(t, limits["single"].GetLimit(""), "released updates must be restored by the outer snapshot")
+}
+
+// unjournaledLimit is a limit of another package, that the Composite cannot journal by key.
+type unjournaledLimit struct {
+	pool.SwapLimit
+}
+
+func (l unjournaledLimit) Clone() pool.SwapLimit {
+	return unjournaledLimit{l.SwapLimit.Clone()}
+}
+
+func TestComposite_Journal(t *testing.T) {
+	swapped := NewSwappedInventory("swapped", map[string]*big.Int{"x": big.NewInt(10)})
+	unjournaled := unjournaledLimit{NewInventory("other", map[string]*big.Int{"x": big.NewInt(10)})}
+	c := NewComposite(swapped, unjournaled)
+
+	snapshot := c.Snapshot()
+	require.NoError(t, c.UpdateLimits(
+		LimitUpdate{Exchange: "swapped", DecreaseKey: "x", IncreaseKey: "y", DecreaseDelta: big.NewInt(4),
+			IncreaseDelta: big.NewInt(3)},
+		LimitUpdate{Exchange: "other", DecreaseKey: "x", IncreaseKey: "y", DecreaseDelta: big.NewInt(4),
+			IncreaseDelta: big.NewInt(3)},
+	))
+	require.NoError(t, c.UpdateLimits(LimitUpdate{Exchange: "swapped", DecreaseKey: "x", IncreaseKey: "y",
+		DecreaseDelta: big.NewInt(1), IncreaseDelta: big.NewInt(2)}))
+	assert.Equal(t, big.NewInt(5), swapped.GetLimit("x"))
+	assert.Equal(t, map[string]*big.Int{"y": big.NewInt(5)}, swapped.GetSwapped())
+	assert.Same(t, swapped, c.limits["swapped"], "journaled limits must not be cloned")
+	assert.Equal(t, big.NewInt(6), c.GetLimit("other", "x"))
+	assert.Equal(t, big.NewInt(10), unjournaled.GetLimit("x"), "other limits must be copied on write")
+
+	require.NoError(t, c.Restore(snapshot))
+	assert.Equal(t, big.NewInt(10), swapped.GetLimit("x"))
+	assert.Nil(t, swapped.GetLimit("y"))
+	assert.Empty(t, swapped.GetSwapped())
+	assert.Equal(t, big.NewInt(10), c.GetLimit("other", "x"))
+}
+
+func TestComposite_StaleSnapshot(t *testing.T) {
+	c := NewComposite(NewInventory("pmm", map[string]*big.Int{"x": big.NewInt(10)}))
+	limit := c.Limit("pmm")
+
+	outer := c.Snapshot()
+	restored := c.Snapshot()
+	require.NoError(t, c.Restore(restored))
+	// a snapshot taken in place of a restored one does not revive it
+	inner := c.Snapshot()
+	_, _, err := limit.UpdateLimit("x", "y", big.NewInt(1), big.NewInt(1))
+	require.NoError(t, err)
+	assert.ErrorIs(t, c.Restore(restored), ErrStaleSnapshot)
+	assert.ErrorIs(t, c.Release(restored), ErrStaleSnapshot)
+	assert.Equal(t, big.NewInt(9), limit.GetLimit("x"))
+
+	// snapshots of other composites and the zero snapshot are stale
+	other := NewComposite(NewInventory("pmm", map[string]*big.Int{}))
+	assert.ErrorIs(t, c.Restore(other.Snapshot()), ErrStaleSnapshot)
+	assert.ErrorIs(t, other.Restore(inner), ErrStaleSnapshot)
+	assert.ErrorIs(t, c.Restore(0), ErrStaleSnapshot)
+	assert.Equal(t, big.NewInt(9), limit.GetLimit("x"))
+
+	// restoring a snapshot releases the snapshots taken after it
+	require.NoError(t, c.Restore(outer))
+	assert.ErrorIs(t, c.Restore(inner), ErrStaleSnapshot)
+	assert.ErrorIs(t, c.Release(outer), ErrStaleSnapshot)
+	assert.Equal(t, big.NewInt(10), limit.GetLimit("x"))
+}
+
+func TestComposite_SharedLimits(t *testing.T) {
+	balances := NewMakerBalances()
+	balances.SetBalance(maker, usdt, big.NewInt(100))
+	c := NewComposite(balances.Limit("lo1inch", router), balances.Limit("kyberswap-limit-order", limitOrder))
+	usdtKey, usdcKey := maker+":"+usdt, maker+":"+usdc
+
+	snapshot := c.Snapshot()
+	_, _, err := c.Limit("lo1inch").UpdateLimit(usdtKey, usdcKey, big.NewInt(40), big.NewInt(40))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(60), c.GetLimit("kyberswap-limit-order", usdtKey))
+	assert.Equal(t, big.NewInt(60), balances.Limit("", "").GetLimit(usdtKey))
+
+	require.NoError(t, c.Restore(snapshot))
+	assert.Equal(t, big.NewInt(100), c.GetLimit("kyberswap-limit-order", usdtKey))
+	assert.Equal(t, big.NewInt(100), balances.Limit("", "").GetLimit(usdtKey))
+	assert.Nil(t, balances.Limit("", "").GetLimit(usdcKey))
+
+	// clones of the limits of a composite share the clone of the composite
+	clones := make(map[any]any)
+	limits := c.Limits()
+	clonedLO1inch := limits["lo1inch"].(pool.SharedSwapLimit).CloneShared(clones)
+	clonedLimitOrder := limits["kyberswap-limit-order"].(pool.SharedSwapLimit).CloneShared(clones)
+	_, _, err = clonedLimitOrder.UpdateLimit(usdtKey, usdcKey, big.NewInt(30), big.NewInt(30))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(70), clonedLO1inch.GetLimit(usdtKey))
+	assert.Equal(t, big.NewInt(100), c.GetLimit("lo1inch", usdtKey))
+}
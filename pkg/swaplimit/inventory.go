@@ -108,3 +108,16 @@ func (i *Inventory) updateLimit(decreaseTokenAddress, increaseTokenAddress strin
 
 	return decreasedTokenBalance, increasedTokenBalance, nil
 }
+
+var _ journaledLimit = (*Inventory)(nil)
+
+func (i *Inventory) undoUpdate(decreaseTokenAddress, increaseTokenAddress string) func() {
+	i.lock.RLock()
+	restore := saveValues(i.balance, decreaseTokenAddress, increaseTokenAddress)
+	i.lock.RUnlock()
+	return func() {
+		i.lock.Lock()
+		defer i.lock.Unlock()
+		restore()
+	}
+}
@@ -0,0 +1,225 @@
+package swaplimit
+
+import (
+	"maps"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+// MakerBalances holds the token balances of limit order makers and their allowances to the limit order contracts of
+// several exchanges. The limit order pools of all these exchanges share it through the MakerBalanceLimit of their
+// exchange, so that the balance a maker spends on an order filled in a pair is no longer available to their orders in
+// other pairs and exchanges. Makers and assets are keyed by MakerAssetKey.
+// The balances are stored WITHOUT decimals.
+type MakerBalances struct {
+	lock     *sync.RWMutex
+	balances map[string]*big.Int
+	// allowances are keyed by spender, then by MakerAssetKey
+	allowances map[string]map[string]*big.Int
+}
+
+// NewMakerBalances creates empty MakerBalances.
+func NewMakerBalances() *MakerBalances {
+	return &MakerBalances{
+		lock:       &sync.RWMutex{},
+		balances:   make(map[string]*big.Int),
+		allowances: make(map[string]map[string]*big.Int),
+	}
+}
+
+// MakerAssetKey returns the key of the balance of asset of maker: "<maker>:<asset>" in lowercase, like the keys of the
+// limit order pools.
+func MakerAssetKey(maker, asset string) string {
+	return strings.ToLower(maker) + ":" + strings.ToLower(asset)
+}
+
+// SetBalance sets the balance of asset of maker.
+func (b *MakerBalances) SetBalance(maker, asset string, balance *big.Int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.balances[MakerAssetKey(maker, asset)] = balance
+}
+
+// SetAllowance sets the allowance of asset of maker to spender.
+func (b *MakerBalances) SetAllowance(maker, asset, spender string, allowance *big.Int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.setAllowance(MakerAssetKey(maker, asset), spender, allowance)
+}
+
+// AddBalances sets the balances of the "<maker>:<asset>" keys of limits that are not known yet, such as the limits
+// computed by CalculateLimit of limit order pools from the balances and allowances returned by the order APIs, until
+// they are refreshed on-chain.
+func (b *MakerBalances) AddBalances(limits map[string]*big.Int) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	for key, limit := range limits {
+		key = strings.ToLower(key)
+		if _, ok := b.balances[key]; !ok {
+			b.balances[key] = limit
+		}
+	}
+}
+
+func (b *MakerBalances) setAllowance(key, spender string, allowance *big.Int) {
+	spender = strings.ToLower(spender)
+	allowances, ok := b.allowances[spender]
+	if !ok {
+		allowances = make(map[string]*big.Int)
+		b.allowances[spender] = allowances
+	}
+	allowances[key] = allowance
+}
+
+// Limit returns the MakerBalanceLimit of the limit order pools of exchange, whose contract spender spends the makers
+// tokens.
+func (b *MakerBalances) Limit(exchange, spender string) *MakerBalanceLimit {
+	return &MakerBalanceLimit{
+		exchange: exchange,
+		spender:  strings.ToLower(spender),
+		balances: b,
+	}
+}
+
+// clone clones MakerBalances. Values are replaced on update, so only maps are copied.
+func (b *MakerBalances) clone() *MakerBalances {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
+	allowances := make(map[string]map[string]*big.Int, len(b.allowances))
+	for spender, spenderAllowances := range b.allowances {
+		allowances[spender] = maps.Clone(spenderAllowances)
+	}
+	return &MakerBalances{
+		lock:       &sync.RWMutex{},
+		balances:   maps.Clone(b.balances),
+		allowances: allowances,
+	}
+}
+
+// MakerBalanceLimit is the SwapLimit of the limit order pools of an exchange over MakerBalances: the limit of a
+// "<maker>:<asset>" key is the min of the balance of the maker and of their allowance to the exchange contract.
+// Unknown allowances do not limit the balance.
+type MakerBalanceLimit struct {
+	exchange string
+	spender  string
+	balances *MakerBalances
+}
+
+var _ pool.SharedSwapLimit = (*MakerBalanceLimit)(nil)
+
+// Clone clones MakerBalanceLimit with its MakerBalances, no longer shared with the limits of other exchanges. Use
+// CloneShared to clone the limits of several exchanges together.
+func (l *MakerBalanceLimit) Clone() pool.SwapLimit {
+	return l.CloneShared(make(map[any]any))
+}
+
+// CloneShared clones MakerBalanceLimit, its MakerBalances being cloned once for all the limits cloned with clones.
+func (l *MakerBalanceLimit) CloneShared(clones map[any]any) pool.SwapLimit {
+	balances, ok := clones[l.balances].(*MakerBalances)
+	if !ok {
+		balances = l.balances.clone()
+		clones[l.balances] = balances
+	}
+	return &MakerBalanceLimit{
+		exchange: l.exchange,
+		spender:  l.spender,
+		balances: balances,
+	}
+}
+
+// GetExchange returns the exchange name.
+func (l *MakerBalanceLimit) GetExchange() string {
+	return l.exchange
+}
+
+// GetLimit returns the min of the balance and allowance of the "<maker>:<asset>" key, nil if the balance is unknown.
+// Do not modify the result.
+func (l *MakerBalanceLimit) GetLimit(key string) *big.Int {
+	l.balances.lock.RLock()
+	defer l.balances.lock.RUnlock()
+
+	return l.getLimit(strings.ToLower(key))
+}
+
+func (l *MakerBalanceLimit) getLimit(key string) *big.Int {
+	balance, ok := l.balances.balances[key]
+	if !ok {
+		return nil
+	}
+	if allowance, ok := l.balances.allowances[l.spender][key]; ok && allowance.Cmp(balance) < 0 {
+		return allowance
+	}
+	return balance
+}
+
+func (l *MakerBalanceLimit) GetSwapped() map[string]*big.Int {
+	return nil
+}
+
+func (l *MakerBalanceLimit) GetAllowedSenders() string {
+	return ""
+}
+
+// UpdateLimit updates the balances and allowance to reflect the fill of an order: the maker of the decreaseKey spends
+// decreaseDelta of its asset, from their balance and allowance to the exchange contract, and the receiver of the order,
+// the owner of the increaseKey, receives increaseDelta of its asset. It returns the limits of the increaseKey and of
+// the decreaseKey after the update, in the order of pool.SwapLimit.
+func (l *MakerBalanceLimit) UpdateLimit(decreaseKey, increaseKey string,
+	decreaseDelta, increaseDelta *big.Int) (increasedLimitAfter, decreasedLimitAfter *big.Int, err error) {
+	decreaseKey, increaseKey = strings.ToLower(decreaseKey), strings.ToLower(increaseKey)
+
+	l.balances.lock.Lock()
+	defer l.balances.lock.Unlock()
+
+	limit := l.getLimit(decreaseKey)
+	if limit == nil {
+		return bignumber.ZeroBI, bignumber.ZeroBI, pool.ErrTokenNotAvailable
+	} else if limit.Cmp(decreaseDelta) < 0 {
+		return bignumber.ZeroBI, bignumber.ZeroBI, pool.ErrNotEnoughInventory
+	}
+
+	balances := l.balances.balances
+	balances[decreaseKey] = new(big.Int).Sub(balances[decreaseKey], decreaseDelta)
+	if allowance, ok := l.balances.allowances[l.spender][decreaseKey]; ok {
+		l.balances.allowances[l.spender][decreaseKey] = new(big.Int).Sub(allowance, decreaseDelta)
+	}
+
+	if balance, ok := balances[increaseKey]; ok {
+		balances[increaseKey] = new(big.Int).Add(balance, increaseDelta)
+	} else {
+		balances[increaseKey] = new(big.Int).Set(increaseDelta)
+	}
+
+	return l.getLimit(increaseKey), l.getLimit(decreaseKey), nil
+}
+
+var _ journaledLimit = (*MakerBalanceLimit)(nil)
+
+func (l *MakerBalanceLimit) undoUpdate(decreaseKey, increaseKey string) func() {
+	decreaseKey, increaseKey = strings.ToLower(decreaseKey), strings.ToLower(increaseKey)
+
+	l.balances.lock.RLock()
+	restoreBalances := saveValues(l.balances.balances, decreaseKey, increaseKey)
+	allowances := l.balances.allowances[l.spender]
+	var restoreAllowance func()
+	if allowances != nil {
+		restoreAllowance = saveValues(allowances, decreaseKey)
+	}
+	l.balances.lock.RUnlock()
+	return func() {
+		l.balances.lock.Lock()
+		defer l.balances.lock.Unlock()
+		restoreBalances()
+		if restoreAllowance != nil {
+			restoreAllowance()
+		}
+	}
+}
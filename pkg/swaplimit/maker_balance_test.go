@@ -0,0 +1,70 @@
+package swaplimit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+const (
+	maker      = "0xDF4039a454d58868dfd43f076ee46c92a35fdfd9"
+	usdt       = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	usdc       = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	router     = "0x111111125421ca6dc452d289314280a0f8842a65"
+	limitOrder = "0x227b0c196ea8db17a665ea6824d972a64202e936"
+)
+
+func TestMakerBalanceLimit(t *testing.T) {
+	balances := NewMakerBalances()
+	balances.SetBalance(maker, usdt, big.NewInt(100))
+	balances.SetAllowance(maker, usdt, router, big.NewInt(80))
+	balances.SetAllowance(maker, usdt, limitOrder, big.NewInt(1000))
+	lo1inchLimit := balances.Limit("lo1inch", router)
+	limitOrderLimit := balances.Limit("kyberswap-limit-order", limitOrder)
+
+	usdtKey, usdcKey := maker+":"+usdt, maker+":"+usdc
+	assert.Equal(t, big.NewInt(80), lo1inchLimit.GetLimit(usdtKey))
+	assert.Equal(t, big.NewInt(100), limitOrderLimit.GetLimit(usdtKey))
+	assert.Nil(t, lo1inchLimit.GetLimit(usdcKey))
+
+	// a fill on one exchange spends the balance available to the other
+	increased, decreased, err := lo1inchLimit.UpdateLimit(usdtKey, usdcKey, big.NewInt(30), big.NewInt(29))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(50), decreased)
+	assert.Equal(t, big.NewInt(29), increased)
+	assert.Equal(t, big.NewInt(70), limitOrderLimit.GetLimit(usdtKey))
+	assert.Equal(t, big.NewInt(29), limitOrderLimit.GetLimit(usdcKey))
+
+	_, _, err = limitOrderLimit.UpdateLimit(usdtKey, usdcKey, big.NewInt(71), big.NewInt(70))
+	assert.ErrorIs(t, err, pool.ErrNotEnoughInventory)
+	_, _, err = limitOrderLimit.UpdateLimit(maker+":0x1234", usdcKey, big.NewInt(1), big.NewInt(1))
+	assert.ErrorIs(t, err, pool.ErrTokenNotAvailable)
+
+	t.Run("clone", func(t *testing.T) {
+		cloned := lo1inchLimit.Clone()
+		_, _, err := cloned.UpdateLimit(usdtKey, usdcKey, big.NewInt(10), big.NewInt(10))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(40), cloned.GetLimit(usdtKey))
+		assert.Equal(t, big.NewInt(50), lo1inchLimit.GetLimit(usdtKey))
+	})
+
+	t.Run("clone shared", func(t *testing.T) {
+		clones := make(map[any]any)
+		clonedLO1inch := lo1inchLimit.CloneShared(clones)
+		clonedLimitOrder := limitOrderLimit.CloneShared(clones)
+		_, _, err := clonedLimitOrder.UpdateLimit(usdtKey, usdcKey, big.NewInt(60), big.NewInt(60))
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(10), clonedLO1inch.GetLimit(usdtKey))
+		assert.Equal(t, big.NewInt(50), lo1inchLimit.GetLimit(usdtKey))
+	})
+
+	t.Run("add balances", func(t *testing.T) {
+		balances.AddBalances(map[string]*big.Int{usdtKey: big.NewInt(1000), "0xabc:" + usdt: big.NewInt(5)})
+		assert.Equal(t, big.NewInt(70), limitOrderLimit.GetLimit(usdtKey))
+		assert.Equal(t, big.NewInt(5), limitOrderLimit.GetLimit("0xABC:"+usdt))
+	})
+}
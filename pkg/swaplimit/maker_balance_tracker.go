@@ -0,0 +1,142 @@
+package swaplimit
+
+import (
+	"context"
+	"math/big"
+	"slices"
+
+	"github.com/KyberNetwork/ethrpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/samber/lo"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+// defaultMakerBalanceBatchSize is the number of balanceOf and allowance calls aggregated in a multicall.
+const defaultMakerBalanceBatchSize = 500
+
+// MakerAsset is a token of a limit order maker whose balance is tracked, with the limit order contracts whose
+// allowances are tracked.
+type MakerAsset struct {
+	Maker    string
+	Asset    string
+	Spenders []string
+}
+
+// MakerAssetPool is a limit order pool whose makers balances are tracked by MakerBalanceTracker.
+type MakerAssetPool interface {
+	// CalculateLimit returns the limits of the "<maker>:<asset>" keys of the pool, as returned by its order API
+	CalculateLimit() map[string]*big.Int
+	// MakerAssets returns the maker assets of the orders of the pool, with the contracts spending them
+	MakerAssets() []MakerAsset
+}
+
+// MakerBalanceTracker refreshes MakerBalances on-chain, reading the balances of makers and their allowances to the
+// limit order contracts by multicall, rather than trusting those returned by the order APIs.
+type MakerBalanceTracker struct {
+	ethrpcClient *ethrpc.Client
+	batchSize    int
+}
+
+// NewMakerBalanceTracker creates a MakerBalanceTracker reading the chain with ethrpcClient.
+func NewMakerBalanceTracker(ethrpcClient *ethrpc.Client) *MakerBalanceTracker {
+	return &MakerBalanceTracker{
+		ethrpcClient: ethrpcClient,
+		batchSize:    defaultMakerBalanceBatchSize,
+	}
+}
+
+// RefreshPools adds the limits of pools to balances, see MakerBalances.AddBalances, then refreshes the balances and
+// allowances of their maker assets with Refresh. The pools that are not MakerAssetPool are ignored.
+func (t *MakerBalanceTracker) RefreshPools(ctx context.Context, balances *MakerBalances,
+	pools []pool.IPoolSimulator) error {
+	var makerAssets []MakerAsset
+	indexes := make(map[string]int)
+	for _, p := range pools {
+		makerAssetPool, ok := p.(MakerAssetPool)
+		if !ok {
+			continue
+		}
+		balances.AddBalances(makerAssetPool.CalculateLimit())
+		for _, makerAsset := range makerAssetPool.MakerAssets() {
+			key := MakerAssetKey(makerAsset.Maker, makerAsset.Asset)
+			if i, ok := indexes[key]; ok {
+				makerAssets[i].Spenders = lo.Union(makerAssets[i].Spenders, makerAsset.Spenders)
+				continue
+			}
+			indexes[key] = len(makerAssets)
+			makerAssets = append(makerAssets, MakerAsset{
+				Maker:    makerAsset.Maker,
+				Asset:    makerAsset.Asset,
+				Spenders: slices.Clone(makerAsset.Spenders),
+			})
+		}
+	}
+	return t.Refresh(ctx, balances, makerAssets)
+}
+
+// makerBalanceCall is a balanceOf call, or an allowance call if spender is set, and its result.
+type makerBalanceCall struct {
+	makerAsset MakerAsset
+	spender    string
+	result     *big.Int
+	ok         bool
+}
+
+// Refresh reads the balances and allowances of makerAssets at the latest block and sets them in balances. Nothing is
+// set if a multicall fails, and the values whose call reverted are left as they were.
+func (t *MakerBalanceTracker) Refresh(ctx context.Context, balances *MakerBalances, makerAssets []MakerAsset) error {
+	var calls []*makerBalanceCall
+	for _, makerAsset := range makerAssets {
+		calls = append(calls, &makerBalanceCall{makerAsset: makerAsset})
+		for _, spender := range makerAsset.Spenders {
+			calls = append(calls, &makerBalanceCall{makerAsset: makerAsset, spender: spender})
+		}
+	}
+
+	for start := 0; start < len(calls); start += t.batchSize {
+		batch := calls[start:min(start+t.batchSize, len(calls))]
+		req := t.ethrpcClient.NewRequest().SetContext(ctx)
+		for _, call := range batch {
+			maker := common.HexToAddress(call.makerAsset.Maker)
+			if call.spender == "" {
+				req.AddCall(&ethrpc.Call{
+					ABI:    abi.Erc20ABI,
+					Target: call.makerAsset.Asset,
+					Method: "balanceOf",
+					Params: []any{maker},
+				}, []any{&call.result})
+			} else {
+				req.AddCall(&ethrpc.Call{
+					ABI:    abi.Erc20ABI,
+					Target: call.makerAsset.Asset,
+					Method: "allowance",
+					Params: []any{maker, common.HexToAddress(call.spender)},
+				}, []any{&call.result})
+			}
+		}
+		resp, err := req.TryAggregate()
+		if err != nil {
+			return err
+		}
+		for i, call := range batch {
+			call.ok = i < len(resp.Result) && resp.Result[i] && call.result != nil
+		}
+	}
+
+	balances.lock.Lock()
+	defer balances.lock.Unlock()
+	for _, call := range calls {
+		if !call.ok {
+			continue
+		}
+		key := MakerAssetKey(call.makerAsset.Maker, call.makerAsset.Asset)
+		if call.spender == "" {
+			balances.balances[key] = call.result
+		} else {
+			balances.setAllowance(key, call.spender, call.result)
+		}
+	}
+	return nil
+}
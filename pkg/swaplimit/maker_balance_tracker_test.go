@@ -0,0 +1,199 @@
+package swaplimit
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/KyberNetwork/ethrpc"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+const tryAggregateABIJson = `[{"name":"tryAggregate","type":"function","stateMutability":"nonpayable",
+"inputs":[{"name":"requireSuccess","type":"bool"},{"name":"calls","type":"tuple[]",
+"components":[{"name":"target","type":"address"},{"name":"callData","type":"bytes"}]}],
+"outputs":[{"name":"returnData","type":"tuple[]",
+"components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`
+
+// erc20Node is a JSON-RPC node serving the tryAggregate multicalls of balanceOf and allowance calls from values, keyed
+// by "<token>:<owner>" and "<token>:<owner>:<spender>" in lowercase. The calls of missing keys revert.
+type erc20Node struct {
+	values     map[string]*big.Int
+	multicalls int
+	fail       bool
+}
+
+func (n *erc20Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Params []json.RawMessage `json:"params"`
+	}
+	var msg struct {
+		Input hexutil.Bytes `json:"input"`
+		Data  hexutil.Bytes `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) == 0 {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	} else if err = json.Unmarshal(req.Params[0], &msg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n.multicalls++
+	if n.fail {
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID,
+			"error": map[string]any{"code": -32000, "message": "execution reverted"}})
+		return
+	}
+
+	tryAggregateABI, _ := abi.JSON(strings.NewReader(tryAggregateABIJson))
+	tryAggregate := tryAggregateABI.Methods["tryAggregate"]
+	data := msg.Input
+	if len(data) == 0 {
+		data = msg.Data
+	}
+	values, err := tryAggregate.Inputs.Unpack(data[4:])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var args struct {
+		RequireSuccess bool
+		Calls          []struct {
+			Target   common.Address
+			CallData []byte
+		}
+	}
+	if err = tryAggregate.Inputs.Copy(&args, values); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	type result struct {
+		Success    bool
+		ReturnData []byte
+	}
+	results := make([]result, len(args.Calls))
+	for i, call := range args.Calls {
+		method, err := abiutil.Erc20ABI.MethodById(call.CallData)
+		if err != nil {
+			continue
+		}
+		params, err := method.Inputs.Unpack(call.CallData[4:])
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(call.Target.Hex())
+		for _, param := range params {
+			key += ":" + strings.ToLower(param.(common.Address).Hex())
+		}
+		if value, ok := n.values[key]; ok {
+			returnData, _ := method.Outputs.Pack(value)
+			results[i] = result{Success: true, ReturnData: returnData}
+		}
+	}
+	returnData, err := tryAggregate.Outputs.Pack(results)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID,
+		"result": hexutil.Encode(returnData)})
+}
+
+func newTestMakerBalanceTracker(t *testing.T, node *erc20Node) *MakerBalanceTracker {
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	client := ethrpc.New(server.URL).SetMulticallContract(common.HexToAddress("0xca11"))
+	return NewMakerBalanceTracker(client)
+}
+
+// makerAssetPool is a MakerAssetPool, the other methods of pool.IPoolSimulator being unused.
+type makerAssetPool struct {
+	pool.IPoolSimulator
+	limits      map[string]*big.Int
+	makerAssets []MakerAsset
+}
+
+func (p *makerAssetPool) CalculateLimit() map[string]*big.Int { return p.limits }
+
+func (p *makerAssetPool) MakerAssets() []MakerAsset { return p.makerAssets }
+
+func TestMakerBalanceTracker_Refresh(t *testing.T) {
+	usdtKey, usdcKey := MakerAssetKey(maker, usdt), MakerAssetKey(maker, usdc)
+	lowerMaker := strings.ToLower(maker)
+	node := &erc20Node{values: map[string]*big.Int{
+		usdt + ":" + lowerMaker:                    big.NewInt(100),
+		usdt + ":" + lowerMaker + ":" + router:     big.NewInt(80),
+		usdt + ":" + lowerMaker + ":" + limitOrder: big.NewInt(1000),
+		usdc + ":" + lowerMaker + ":" + router:     big.NewInt(5),
+	}}
+	tracker := newTestMakerBalanceTracker(t, node)
+	tracker.batchSize = 2
+
+	balances := NewMakerBalances()
+	balances.SetBalance(maker, usdc, big.NewInt(30))
+	lo1inchLimit, limitOrderLimit := balances.Limit("lo1inch", router), balances.Limit("limit-order", limitOrder)
+
+	err := tracker.Refresh(context.Background(), balances, []MakerAsset{
+		{Maker: maker, Asset: usdt, Spenders: []string{router, limitOrder}},
+		{Maker: maker, Asset: usdc, Spenders: []string{router}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, node.multicalls)
+	assert.Equal(t, big.NewInt(80), lo1inchLimit.GetLimit(usdtKey))
+	assert.Equal(t, big.NewInt(100), limitOrderLimit.GetLimit(usdtKey))
+	// the usdc balanceOf call reverted
+	assert.Equal(t, big.NewInt(5), lo1inchLimit.GetLimit(usdcKey))
+	assert.Equal(t, big.NewInt(30), limitOrderLimit.GetLimit(usdcKey))
+
+	t.Run("failing multicall", func(t *testing.T) {
+		node.fail = true
+		defer func() { node.fail = false }()
+		node.values[usdt+":"+lowerMaker] = big.NewInt(10)
+
+		err := tracker.Refresh(context.Background(), balances, []MakerAsset{{Maker: maker, Asset: usdt}})
+		assert.Error(t, err)
+		assert.Equal(t, big.NewInt(100), limitOrderLimit.GetLimit(usdtKey))
+	})
+
+	t.Run("pools", func(t *testing.T) {
+		otherMaker := "0x0000000000000000000000000000000000005678"
+		node.values[usdc+":"+lowerMaker] = big.NewInt(50)
+		node.values[usdc+":"+lowerMaker+":"+limitOrder] = big.NewInt(40)
+		node.multicalls = 0
+		tracker.batchSize = defaultMakerBalanceBatchSize
+
+		err := tracker.RefreshPools(context.Background(), balances, []pool.IPoolSimulator{
+			&makerAssetPool{
+				limits:      map[string]*big.Int{usdcKey: big.NewInt(1)},
+				makerAssets: []MakerAsset{{Maker: maker, Asset: usdc, Spenders: []string{router}}},
+			},
+			&makerAssetPool{
+				limits: map[string]*big.Int{MakerAssetKey(otherMaker, usdc): big.NewInt(7)},
+				makerAssets: []MakerAsset{
+					{Maker: maker, Asset: usdc, Spenders: []string{limitOrder}},
+					{Maker: otherMaker, Asset: usdc, Spenders: []string{limitOrder}},
+				},
+			},
+			nil,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, node.multicalls)
+		assert.Equal(t, big.NewInt(5), lo1inchLimit.GetLimit(usdcKey))
+		assert.Equal(t, big.NewInt(40), limitOrderLimit.GetLimit(usdcKey))
+		// the limits of the pools are kept until refreshed
+		assert.Equal(t, big.NewInt(7), limitOrderLimit.GetLimit(MakerAssetKey(otherMaker, usdc)))
+	})
+}
@@ -57,3 +57,10 @@ func (l *SingleSwapLimit) UpdateLimit(_, _ string, _, _ *big.Int) (*big.Int, *bi
 	l.swapped.Store(true)
 	return nil, nil, nil
 }
+
+var _ journaledLimit = (*SingleSwapLimit)(nil)
+
+func (l *SingleSwapLimit) undoUpdate(_, _ string) func() {
+	swapped := l.swapped.Load()
+	return func() { l.swapped.Store(swapped) }
+}
@@ -67,3 +67,26 @@ func (k *SwappedInventory) Clone() pool.SwapLimit {
 		swapped: maps.Clone(k.swapped),
 	}
 }
+
+var _ journaledLimit = (*SwappedInventory)(nil)
+
+func (k *SwappedInventory) undoUpdate(decreaseTokenAddress, increaseTokenAddress string) func() {
+	k.lock.RLock()
+	restoreBalance := saveValues(k.balance, decreaseTokenAddress, increaseTokenAddress)
+	// swapped values are added to in place
+	swappedIn, ok := k.swapped[increaseTokenAddress]
+	if ok {
+		swappedIn = new(big.Int).Set(swappedIn)
+	}
+	k.lock.RUnlock()
+	return func() {
+		k.lock.Lock()
+		defer k.lock.Unlock()
+		restoreBalance()
+		if ok {
+			k.swapped[increaseTokenAddress] = swappedIn
+		} else {
+			delete(k.swapped, increaseTokenAddress)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/holiman/uint256"
+)
+
+var ErrUnsupportedEIP712Value = errors.New("unsupported EIP-712 value type")
+
+var eip712DomainTypeHash = crypto.Keccak256Hash(
+	[]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+
+// EIP712DomainSeparator returns the hash of the EIP-712 domain of a contract verifying typed data signed for a chain.
+func EIP712DomainSeparator(name, version string, chainID uint64,
+	verifyingContract common.Address) (common.Hash, error) {
+	return EIP712StructHash(eip712DomainTypeHash,
+		[]byte(name), []byte(version), new(big.Int).SetUint64(chainID), verifyingContract)
+}
+
+// EIP712StructHash returns the hash of a struct of type typeHash with the given member values, in declaration order.
+// Values can be *big.Int, *uint256.Int, uint32, uint64, common.Address or common.Hash, encoded as a word, or []byte,
+// encoded as its hash like dynamic bytes and strings. Values of other types fail with ErrUnsupportedEIP712Value.
+func EIP712StructHash(typeHash common.Hash, values ...any) (common.Hash, error) {
+	encoded := make([]byte, 0, common.HashLength*(len(values)+1))
+	encoded = append(encoded, typeHash[:]...)
+	for i, value := range values {
+		word, err := eip712Word(value)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("value %d: %w", i, err)
+		}
+		encoded = append(encoded, word...)
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// EIP712Hash returns the digest signed for a struct hash in a domain.
+func EIP712Hash(domainSeparator, structHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash([]byte{0x19, 0x01}, domainSeparator[:], structHash[:])
+}
+
+func eip712Word(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case *big.Int:
+		if v == nil {
+			return make([]byte, common.HashLength), nil
+		}
+		return math.U256Bytes(new(big.Int).Set(v)), nil
+	case *uint256.Int:
+		if v == nil {
+			return make([]byte, common.HashLength), nil
+		}
+		word := v.Bytes32()
+		return word[:], nil
+	case uint32:
+		return common.LeftPadBytes(new(big.Int).SetUint64(uint64(v)).Bytes(), common.HashLength), nil
+	case uint64:
+		return common.LeftPadBytes(new(big.Int).SetUint64(v).Bytes(), common.HashLength), nil
+	case common.Address:
+		return common.LeftPadBytes(v[:], common.HashLength), nil
+	case common.Hash:
+		return v[:], nil
+	case []byte:
+		return crypto.Keccak256(v), nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedEIP712Value, value)
+	}
+}
@@ -0,0 +1,26 @@
+package eth
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEIP712StructHash(t *testing.T) {
+	typeHash := crypto.Keccak256Hash([]byte("Mail(address to,uint256 amount,bytes data)"))
+	to := common.HexToAddress("0xcd2a3d9f938e13cd947ec05abc7fe734df8dd826")
+
+	hash, err := EIP712StructHash(typeHash, to, big.NewInt(1), []byte("hello"))
+	require.NoError(t, err)
+	expected := crypto.Keccak256Hash(typeHash[:], common.LeftPadBytes(to[:], 32), common.LeftPadBytes([]byte{1}, 32),
+		crypto.Keccak256([]byte("hello")))
+	assert.Equal(t, expected, hash)
+
+	_, err = EIP712StructHash(typeHash, to, 1, []byte("hello"))
+	assert.ErrorIs(t, err, ErrUnsupportedEIP712Value)
+	assert.ErrorContains(t, err, "value 1")
+}
@@ -0,0 +1,102 @@
+package eth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrInvalidSignatureLength = errors.New("invalid signature length")
+	ErrInvalidSignatureV      = errors.New("invalid signature v")
+	ErrInvalidSignatureValues = errors.New("invalid signature values")
+	ErrNoContractCaller       = errors.New("no contract caller to verify ERC-1271 signature")
+)
+
+// erc1271MagicValue is the selector of isValidSignature(bytes32,bytes), returned by ERC-1271 contracts for valid
+// signatures.
+var erc1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+var erc1271Arguments = func() abi.Arguments {
+	bytes32Ty, _ := abi.NewType("bytes32", "", nil)
+	bytesTy, _ := abi.NewType("bytes", "", nil)
+	return abi.Arguments{{Type: bytes32Ty}, {Type: bytesTy}}
+}()
+
+// RecoverSigner returns the address that signed hash, from either a 65 bytes [R || S || V] signature, V being 0, 1,
+// 27 or 28, or a 64 bytes EIP-2098 compact [R || yParityAndS] signature.
+func RecoverSigner(hash common.Hash, signature []byte) (common.Address, error) {
+	sig := make([]byte, crypto.SignatureLength)
+	switch len(signature) {
+	case crypto.SignatureLength:
+		copy(sig, signature)
+		if sig[64] >= 27 {
+			sig[64] -= 27
+		}
+		if sig[64] > 1 {
+			return common.Address{}, ErrInvalidSignatureV
+		}
+	case crypto.SignatureLength - 1:
+		copy(sig, signature)
+		sig[64] = sig[32] >> 7
+		sig[32] &= 0x7f
+	default:
+		return common.Address{}, ErrInvalidSignatureLength
+	}
+	// reject malleable signatures with s in the upper half of the curve order, as OpenZeppelin's ECDSA does
+	if !crypto.ValidateSignatureValues(sig[64], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]),
+		true) {
+		return common.Address{}, ErrInvalidSignatureValues
+	}
+
+	pubKey, err := crypto.SigToPub(hash[:], sig)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubKey), nil
+}
+
+// IsValidECDSASignature returns whether signature of hash was signed by signer.
+func IsValidECDSASignature(signer common.Address, hash common.Hash, signature []byte) bool {
+	recovered, err := RecoverSigner(hash, signature)
+	return err == nil && recovered == signer
+}
+
+// IsValidERC1271Signature returns whether the signer contract accepts signature of hash, by calling its ERC-1271
+// isValidSignature(bytes32,bytes) with caller.
+func IsValidERC1271Signature(ctx context.Context, caller ethereum.ContractCaller, signer common.Address,
+	hash common.Hash, signature []byte) (bool, error) {
+	if caller == nil {
+		return false, ErrNoContractCaller
+	}
+	args, err := erc1271Arguments.Pack(hash, signature)
+	if err != nil {
+		return false, err
+	}
+	result, err := caller.CallContract(ctx, ethereum.CallMsg{
+		To:   &signer,
+		Data: append(bytes.Clone(erc1271MagicValue), args...),
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+	return len(result) == common.HashLength && bytes.HasPrefix(result, erc1271MagicValue), nil
+}
+
+// IsValidSignature returns whether signature of hash is valid for signer, either as an ECDSA signature or, like
+// OpenZeppelin's SignatureChecker, as an ERC-1271 signature of a signer contract when caller is not nil.
+func IsValidSignature(ctx context.Context, caller ethereum.ContractCaller, signer common.Address,
+	hash common.Hash, signature []byte) (bool, error) {
+	if IsValidECDSASignature(signer, hash, signature) {
+		return true, nil
+	} else if caller == nil {
+		return false, nil
+	}
+	return IsValidERC1271Signature(ctx, caller, signer, hash, signature)
+}
@@ -0,0 +1,115 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type erc1271Caller struct {
+	signer    common.Address
+	hash      common.Hash
+	signature []byte
+}
+
+func (c *erc1271Caller) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (c *erc1271Caller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	args, err := erc1271Arguments.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	result := make([]byte, common.HashLength)
+	if *msg.To == c.signer && args[0].([32]byte) == c.hash && string(args[1].([]byte)) == string(c.signature) {
+		copy(result, erc1271MagicValue)
+	}
+	return result, nil
+}
+
+func TestRecoverSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	hash := crypto.Keccak256Hash([]byte("order"))
+	sig, err := crypto.Sign(hash[:], key)
+	require.NoError(t, err)
+
+	t.Run("65 bytes", func(t *testing.T) {
+		got, err := RecoverSigner(hash, sig)
+		require.NoError(t, err)
+		assert.Equal(t, signer, got)
+	})
+
+	t.Run("65 bytes with v 27 or 28", func(t *testing.T) {
+		sig := append(common.CopyBytes(sig[:64]), sig[64]+27)
+		got, err := RecoverSigner(hash, sig)
+		require.NoError(t, err)
+		assert.Equal(t, signer, got)
+	})
+
+	t.Run("64 bytes compact", func(t *testing.T) {
+		compact := common.CopyBytes(sig[:64])
+		compact[32] |= sig[64] << 7
+		got, err := RecoverSigner(hash, compact)
+		require.NoError(t, err)
+		assert.Equal(t, signer, got)
+	})
+
+	t.Run("invalid v", func(t *testing.T) {
+		_, err := RecoverSigner(hash, append(common.CopyBytes(sig[:64]), 2))
+		assert.ErrorIs(t, err, ErrInvalidSignatureV)
+	})
+
+	t.Run("malleable s", func(t *testing.T) {
+		s := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(sig[32:64]))
+		malleable := append(common.CopyBytes(sig[:32]), common.LeftPadBytes(s.Bytes(), 32)...)
+		_, err := RecoverSigner(hash, append(malleable, 1-sig[64]))
+		assert.ErrorIs(t, err, ErrInvalidSignatureValues)
+	})
+
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := RecoverSigner(hash, sig[:63])
+		assert.ErrorIs(t, err, ErrInvalidSignatureLength)
+	})
+}
+
+func TestIsValidSignature(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := crypto.PubkeyToAddress(key.PublicKey)
+	hash := crypto.Keccak256Hash([]byte("order"))
+	sig, err := crypto.Sign(hash[:], key)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	ok, err := IsValidSignature(ctx, nil, signer, hash, sig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = IsValidSignature(ctx, nil, signer, crypto.Keccak256Hash([]byte("other")), sig)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	contract := common.HexToAddress("0x1234")
+	contractSig := []byte("contract signature")
+	caller := &erc1271Caller{signer: contract, hash: hash, signature: contractSig}
+
+	ok, err = IsValidSignature(ctx, caller, contract, hash, contractSig)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = IsValidSignature(ctx, caller, contract, hash, []byte("forged"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = IsValidERC1271Signature(ctx, nil, contract, hash, contractSig)
+	assert.ErrorIs(t, err, ErrNoContractCaller)
+}
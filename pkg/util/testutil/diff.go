@@ -0,0 +1,137 @@
+package testutil
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/bignumber"
+)
+
+const (
+	defaultDiffRuns = 32
+	defaultDiffSeed = 1
+)
+
+// EVMQuoter executes the on-chain quote of swapping amountIn of tokenIn to tokenOut and returns the amount out.
+type EVMQuoter func(evm *EVM, tokenIn, tokenOut string, amountIn *big.Int) (*big.Int, error)
+
+type DiffOptions struct {
+	// Runs is the number of random amounts tried for each token pair, defaults to 32.
+	Runs int
+	// Seed seeds the random amounts, so that mismatches can be reproduced. Defaults to 1.
+	Seed uint64
+	// MaxAmountIn returns the largest amount in tried for a token. Defaults to half the pool reserve of the token, or
+	// 1e24 if the pool has no reserve for it. Amounts are log-uniformly distributed between 1 and MaxAmountIn.
+	MaxAmountIn func(token string) *big.Int
+	// Tolerance is the absolute difference in wei allowed between the simulated and the on-chain amounts out.
+	Tolerance *big.Int
+	// Timestamp is passed to CalcAmountOut, it should be the timestamp of the EVM fixture.
+	Timestamp int64
+}
+
+// Mismatch is an amount in for which the simulator and the chain disagree: either their amounts out differ by more
+// than the tolerance, or only one of them fails.
+type Mismatch struct {
+	TokenIn, TokenOut string
+	AmountIn          *big.Int
+	// Expected is the on-chain amount out, EVMErr the on-chain error.
+	Expected *big.Int
+	EVMErr   error
+	// Actual is the simulated amount out, Err the simulation error.
+	Actual *big.Int
+	Err    error
+}
+
+func (m Mismatch) String() string {
+	prefix := fmt.Sprintf("%s -> %s amountIn %s:", m.TokenIn, m.TokenOut, m.AmountIn)
+	switch {
+	case m.EVMErr != nil:
+		return fmt.Sprintf("%s evm failed (%v), simulator returned %s", prefix, m.EVMErr, m.Actual)
+	case m.Err != nil:
+		return fmt.Sprintf("%s simulator failed (%v), evm returned %s", prefix, m.Err, m.Expected)
+	default:
+		return fmt.Sprintf("%s evm returned %s, simulator returned %s (diff %s wei)", prefix, m.Expected, m.Actual,
+			new(big.Int).Sub(m.Actual, m.Expected))
+	}
+}
+
+// DiffCalcAmountOut compares poolSim.CalcAmountOut to the on-chain quotes of quote for random amounts in, for every
+// token pair the simulator can swap, and returns the mismatches.
+func DiffCalcAmountOut(poolSim pool.IPoolSimulator, evm *EVM, quote EVMQuoter, opts DiffOptions) []Mismatch {
+	runs := opts.Runs
+	if runs <= 0 {
+		runs = defaultDiffRuns
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = defaultDiffSeed
+	}
+	maxAmountIn := opts.MaxAmountIn
+	if maxAmountIn == nil {
+		maxAmountIn = func(token string) *big.Int {
+			reserves := poolSim.GetReserves()
+			if i := poolSim.GetTokenIndex(token); i >= 0 && i < len(reserves) && reserves[i] != nil &&
+				reserves[i].Sign() > 0 {
+				return new(big.Int).Rsh(reserves[i], 1)
+			}
+			return bignumber.TenPowInt(24)
+		}
+	}
+	tolerance := opts.Tolerance
+	if tolerance == nil {
+		tolerance = bignumber.ZeroBI
+	}
+
+	rng := rand.New(rand.NewPCG(seed, seed))
+	var mismatches []Mismatch
+	for _, tokenIn := range poolSim.GetTokens() {
+		maxExp := log10(maxAmountIn(tokenIn))
+		for _, tokenOut := range poolSim.CanSwapFrom(tokenIn) {
+			for range runs {
+				amountIn, _ := new(big.Float).SetFloat64(math.Pow(10, rng.Float64()*maxExp)).Int(nil)
+				if amountIn.Sign() <= 0 {
+					amountIn.SetInt64(1)
+				}
+
+				mismatch := Mismatch{TokenIn: tokenIn, TokenOut: tokenOut, AmountIn: amountIn}
+				mismatch.Expected, mismatch.EVMErr = quote(evm, tokenIn, tokenOut, new(big.Int).Set(amountIn))
+				res, err := poolSim.CalcAmountOut(pool.CalcAmountOutParams{
+					TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: new(big.Int).Set(amountIn)},
+					TokenOut:      tokenOut,
+					Timestamp:     opts.Timestamp,
+				})
+				if err == nil {
+					mismatch.Actual = res.TokenAmountOut.Amount
+				}
+				mismatch.Err = err
+
+				switch {
+				case mismatch.EVMErr != nil && mismatch.Err != nil:
+				case mismatch.EVMErr != nil || mismatch.Err != nil:
+					mismatches = append(mismatches, mismatch)
+				case new(big.Int).Sub(mismatch.Actual, mismatch.Expected).CmpAbs(tolerance) > 0:
+					mismatches = append(mismatches, mismatch)
+				}
+			}
+		}
+	}
+	return mismatches
+}
+
+// TestDiffCalcAmountOut runs DiffCalcAmountOut and reports every mismatch as a test error.
+func TestDiffCalcAmountOut(t *testing.T, poolSim pool.IPoolSimulator, evm *EVM, quote EVMQuoter, opts DiffOptions) {
+	t.Helper()
+	mismatches := DiffCalcAmountOut(poolSim, evm, quote, opts)
+	for _, mismatch := range mismatches {
+		t.Error(mismatch.String())
+	}
+}
+
+func log10(amount *big.Int) float64 {
+	f, _ := new(big.Float).SetInt(amount).Float64()
+	return math.Log10(max(f, 1))
+}
@@ -0,0 +1,265 @@
+package testutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/goccy/go-json"
+	"github.com/holiman/uint256"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	abiutil "github.com/KyberNetwork/kyberswap-dex-lib/pkg/util/abi"
+)
+
+// evmCallGas is the gas given to each call executed by EVM.
+const evmCallGas = 50_000_000
+
+// EVMFixture is the chain state needed to execute calls at a block: the code, storage and balance of the accounts the
+// calls touch. Accounts use the layout of the geth prestateTracer, so that a fixture can be recorded with
+// RecordEVMFixture.
+type EVMFixture struct {
+	ChainID     uint64                        `json:"chainId,omitempty"`
+	BlockNumber uint64                        `json:"blockNumber"`
+	Timestamp   uint64                        `json:"timestamp"`
+	Accounts    map[common.Address]EVMAccount `json:"accounts"`
+}
+
+type EVMAccount struct {
+	Balance *hexutil.Big                `json:"balance,omitempty"`
+	Nonce   uint64                      `json:"nonce,omitempty"`
+	Code    hexutil.Bytes               `json:"code,omitempty"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// DiffFixture pairs a pool tracked at a block with the EVM state needed to quote it on-chain at that block, for
+// differential tests with DiffCalcAmountOut.
+type DiffFixture struct {
+	Pool entity.Pool `json:"pool"`
+	EVM  EVMFixture  `json:"evm"`
+	// Quoter is the contract quoting the swaps of pools not quoted by their own contracts, such as a lens contract.
+	Quoter common.Address `json:"quoter,omitempty"`
+}
+
+// LoadDiffFixture reads the fixture at path, failing the test if it has not been recorded: fixtures are committed
+// along with the differential tests using them, see RecordEVMFixture.
+func LoadDiffFixture(t testing.TB, path string) *DiffFixture {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("%s not recorded: record it with RecordEVMFixture and commit it", path)
+	}
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	var fixture DiffFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return &fixture
+}
+
+// Save writes the fixture to path.
+func (f *DiffFixture) Save(path string) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// RecordEVMFixture records the state touched by calls at blockNumber with debug_traceCall and the prestateTracer. The
+// calls should exercise the code paths of the quotes the fixture is used for: storage they do not read is zero in the
+// recorded state.
+func RecordEVMFixture(ctx context.Context, client *rpc.Client, blockNumber uint64,
+	calls ...ethereum.CallMsg) (*EVMFixture, error) {
+	ethClient := ethclient.NewClient(client)
+	chainID, err := ethClient.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	header, err := ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &EVMFixture{
+		ChainID:     chainID.Uint64(),
+		BlockNumber: blockNumber,
+		Timestamp:   header.Time,
+		Accounts:    make(map[common.Address]EVMAccount),
+	}
+	for _, call := range calls {
+		var prestate map[common.Address]EVMAccount
+		if err := client.CallContext(ctx, &prestate, "debug_traceCall", map[string]any{
+			"from": call.From,
+			"to":   call.To,
+			"data": hexutil.Bytes(call.Data),
+		}, hexutil.Uint64(blockNumber), map[string]any{"tracer": "prestateTracer"}); err != nil {
+			return nil, err
+		}
+		for address, account := range prestate {
+			merged, ok := fixture.Accounts[address]
+			if !ok {
+				fixture.Accounts[address] = account
+				continue
+			}
+			if merged.Storage == nil {
+				merged.Storage = make(map[common.Hash]common.Hash, len(account.Storage))
+			}
+			for slot, value := range account.Storage {
+				merged.Storage[slot] = value
+			}
+			fixture.Accounts[address] = merged
+		}
+	}
+
+	return fixture, nil
+}
+
+// EVM executes calls against the state of an EVMFixture with go-ethereum's interpreter. Calls are reverted after
+// execution, so the state is the fixture one for every call.
+type EVM struct {
+	stateDB *state.StateDB
+	evm     *vm.EVM
+}
+
+func NewEVM(fixture *EVMFixture) (*EVM, error) {
+	stateDB, err := state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+	if err != nil {
+		return nil, err
+	}
+	for address, account := range fixture.Accounts {
+		if account.Balance != nil {
+			balance, overflow := uint256.FromBig(account.Balance.ToInt())
+			if overflow {
+				return nil, fmt.Errorf("balance of %s overflows", address)
+			}
+			stateDB.SetBalance(address, balance, tracing.BalanceChangeUnspecified)
+		}
+		stateDB.SetNonce(address, account.Nonce)
+		stateDB.SetCode(address, account.Code)
+		for slot, value := range account.Storage {
+			stateDB.SetState(address, slot, value)
+		}
+	}
+	stateDB.Finalise(false)
+
+	chainConfig := *params.AllDevChainProtocolChanges
+	chainConfig.PragueTime = nil
+	if fixture.ChainID != 0 {
+		chainConfig.ChainID = new(big.Int).SetUint64(fixture.ChainID)
+	}
+	blockContext := vm.BlockContext{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		GasLimit:    evmCallGas,
+		BlockNumber: new(big.Int).SetUint64(fixture.BlockNumber),
+		Time:        fixture.Timestamp,
+		Difficulty:  new(big.Int),
+		BaseFee:     new(big.Int),
+		BlobBaseFee: new(big.Int),
+		Random:      &common.Hash{},
+	}
+
+	return &EVM{
+		stateDB: stateDB,
+		evm: vm.NewEVM(blockContext, vm.TxContext{GasPrice: new(big.Int)}, stateDB, &chainConfig,
+			vm.Config{NoBaseFee: true}),
+	}, nil
+}
+
+// Call executes a call to `to` with input as calldata and returns its output. A reverted call returns
+// vm.ErrExecutionReverted wrapped with the revert reason if any.
+func (e *EVM) Call(to common.Address, input []byte) ([]byte, error) {
+	snapshot := e.stateDB.Snapshot()
+	defer e.stateDB.RevertToSnapshot(snapshot)
+
+	output, _, err := e.evm.Call(vm.AccountRef(common.Address{}), to, input, evmCallGas, new(uint256.Int))
+	if err != nil {
+		if reason, unpackErr := abi.UnpackRevert(output); unpackErr == nil {
+			return nil, fmt.Errorf("%w: %s", err, reason)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// CallContract executes msg with Call, ignoring blockNumber as the EVM is at the fixture block. It implements
+// ethereum.ContractCaller for simulators calling contracts.
+func (e *EVM) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	if msg.To == nil {
+		return nil, errors.New("contract creation is not supported")
+	}
+	return e.Call(*msg.To, msg.Data)
+}
+
+// CallMethod packs a call to method of contractABI, executes it with Call and unpacks its outputs.
+func (e *EVM) CallMethod(to common.Address, contractABI abi.ABI, method string, args ...any) ([]any, error) {
+	input, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	output, err := e.Call(to, input)
+	if err != nil {
+		return nil, err
+	}
+	return contractABI.Unpack(method, output)
+}
+
+// SimulateTransfer executes a transfer of amount of token from `from` to `to`, and returns the increase of the balance
+// of `to`. The transfer is reverted afterwards. It implements pool.TransferSimulator for pool.DetectTransferTax.
+func (e *EVM) SimulateTransfer(_ context.Context, token, from, to string, amount *big.Int) (*big.Int, error) {
+	snapshot := e.stateDB.Snapshot()
+	defer e.stateDB.RevertToSnapshot(snapshot)
+
+	tokenAddress, recipient := common.HexToAddress(token), common.HexToAddress(to)
+	balanceBefore, err := e.balanceOf(tokenAddress, recipient)
+	if err != nil {
+		return nil, err
+	}
+	input, err := abiutil.Erc20ABI.Pack("transfer", recipient, amount)
+	if err != nil {
+		return nil, err
+	}
+	if output, _, err := e.evm.Call(vm.AccountRef(common.HexToAddress(from)), tokenAddress, input, evmCallGas,
+		new(uint256.Int)); err != nil {
+		if reason, unpackErr := abi.UnpackRevert(output); unpackErr == nil {
+			return nil, fmt.Errorf("%w: %s", err, reason)
+		}
+		return nil, err
+	}
+	balanceAfter, err := e.balanceOf(tokenAddress, recipient)
+	if err != nil {
+		return nil, err
+	}
+	return balanceAfter.Sub(balanceAfter, balanceBefore), nil
+}
+
+func (e *EVM) balanceOf(token, owner common.Address) (*big.Int, error) {
+	outputs, err := e.CallMethod(token, abiutil.Erc20ABI, "balanceOf", owner)
+	if err != nil {
+		return nil, err
+	}
+	return outputs[0].(*big.Int), nil
+}
@@ -0,0 +1,161 @@
+package testutil
+
+import (
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/entity"
+	uniswapv2 "github.com/KyberNetwork/kyberswap-dex-lib/pkg/liquidity-source/uniswap-v2"
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+var pairAddress = common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+// pairCode returns amountIn*997*reserveOut / (reserveIn*1000 + amountIn*997) for calldata
+// selector|amountIn|reserveInSlot|reserveOutSlot, the reserves being read from storage.
+var pairCode = hexutil.MustDecode("0x6004356103e50280602435546103e802019060443554020460005260206000f3")
+
+func pairFixture() *DiffFixture {
+	return &DiffFixture{
+		Pool: entity.Pool{
+			Address:  pairAddress.Hex(),
+			Exchange: "uniswap",
+			Type:     uniswapv2.DexType,
+			Reserves: entity.PoolReserves{"123456789000000000", "987654321000000"},
+			Tokens:   []*entity.PoolToken{{Address: "token0"}, {Address: "token1"}},
+			Extra:    `{"fee":3,"feePrecision":1000}`,
+		},
+		EVM: EVMFixture{
+			BlockNumber: 100,
+			Timestamp:   1700000000,
+			Accounts: map[common.Address]EVMAccount{
+				pairAddress: {
+					Code: pairCode,
+					Storage: map[common.Hash]common.Hash{
+						common.BigToHash(big.NewInt(0)): common.BigToHash(big.NewInt(123456789000000000)),
+						common.BigToHash(big.NewInt(1)): common.BigToHash(big.NewInt(987654321000000)),
+					},
+				},
+			},
+		},
+	}
+}
+
+func quotePair(evm *EVM, tokenIn, _ string, amountIn *big.Int) (*big.Int, error) {
+	slotIn, slotOut := int64(0), int64(1)
+	if tokenIn == "token1" {
+		slotIn, slotOut = 1, 0
+	}
+	input := make([]byte, 4, 100)
+	input = append(input, common.BigToHash(amountIn).Bytes()...)
+	input = append(input, common.BigToHash(big.NewInt(slotIn)).Bytes()...)
+	input = append(input, common.BigToHash(big.NewInt(slotOut)).Bytes()...)
+	output, err := evm.Call(pairAddress, input)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(output), nil
+}
+
+func TestEVM_Call(t *testing.T) {
+	evm, err := NewEVM(&pairFixture().EVM)
+	require.NoError(t, err)
+
+	amountOut, err := quotePair(evm, "token0", "token1", big.NewInt(1e18))
+	require.NoError(t, err)
+	// 1e18*997*987654321e6 / (123456789e9*1000 + 1e18*997)
+	assert.Equal(t, "878830283955734", amountOut.String())
+
+	output, err := evm.Call(common.HexToAddress("0x2000000000000000000000000000000000000002"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, output)
+}
+
+func TestDiffCalcAmountOut_Pair(t *testing.T) {
+	fixture := pairFixture()
+	evm, err := NewEVM(&fixture.EVM)
+	require.NoError(t, err)
+
+	t.Run("match", func(t *testing.T) {
+		poolSim, err := uniswapv2.NewPoolSimulator(fixture.Pool)
+		require.NoError(t, err)
+		TestDiffCalcAmountOut(t, poolSim, evm, quotePair, DiffOptions{Timestamp: int64(fixture.EVM.Timestamp)})
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		entityPool := fixture.Pool
+		entityPool.Extra = `{"fee":2,"feePrecision":1000}`
+		poolSim, err := uniswapv2.NewPoolSimulator(entityPool)
+		require.NoError(t, err)
+
+		mismatches := DiffCalcAmountOut(poolSim, evm, quotePair, DiffOptions{Runs: 8})
+		require.NotEmpty(t, mismatches)
+		for _, mismatch := range mismatches {
+			assert.NoError(t, mismatch.Err)
+			assert.NoError(t, mismatch.EVMErr)
+			assert.Equal(t, 1, mismatch.Actual.Cmp(mismatch.Expected), mismatch.String())
+		}
+
+		tolerance := new(big.Int)
+		for _, mismatch := range mismatches {
+			if diff := new(big.Int).Sub(mismatch.Actual, mismatch.Expected); diff.Cmp(tolerance) > 0 {
+				tolerance = diff
+			}
+		}
+		for _, mismatch := range DiffCalcAmountOut(poolSim, evm, quotePair, DiffOptions{Runs: 8, Tolerance: tolerance}) {
+			assert.Fail(t, "unexpected mismatch", mismatch.String())
+		}
+	})
+}
+
+func TestDiffFixture_Save(t *testing.T) {
+	fixture := pairFixture()
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	require.NoError(t, fixture.Save(path))
+
+	loaded := LoadDiffFixture(t, path)
+	want, err := json.Marshal(fixture)
+	require.NoError(t, err)
+	got, err := json.Marshal(loaded)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(want), string(got))
+}
+
+var taxedTokenAddress = common.HexToAddress("0x1000000000000000000000000000000000000002")
+
+// taxedTokenCode stores balances at the slot of their owner, and credits transfers with 95% of their value without
+// debiting the sender.
+var taxedTokenCode = hexutil.MustDecode("0x60003560e01c806370a0823114601d5763a9059cbb14602a57600080fd" +
+	"5b6004355460005260206000f3" + // balanceOf
+	"5b60043580546064602435605f0204019055600160005260206000f3") // transfer
+
+func TestEVM_SimulateTransfer(t *testing.T) {
+	poolAddress := common.HexToAddress("0x1000000000000000000000000000000000000003")
+	evm, err := NewEVM(&EVMFixture{Accounts: map[common.Address]EVMAccount{
+		taxedTokenAddress: {
+			Code: taxedTokenCode,
+			Storage: map[common.Hash]common.Hash{
+				common.BytesToHash(poolAddress.Bytes()): common.BigToHash(big.NewInt(1e18)),
+			},
+		},
+	}})
+	require.NoError(t, err)
+
+	received, err := evm.SimulateTransfer(context.Background(), taxedTokenAddress.Hex(), "0x01", poolAddress.Hex(),
+		big.NewInt(1000))
+	require.NoError(t, err)
+	assert.Equal(t, "950", received.String())
+
+	tax, err := pool.DetectTransferTax(context.Background(), evm, taxedTokenAddress.Hex(), poolAddress.Hex(), "0x01",
+		big.NewInt(1e18))
+	require.NoError(t, err)
+	assert.Equal(t, &entity.TransferTax{BuyTaxBps: 500, SellTaxBps: 500}, tax)
+}
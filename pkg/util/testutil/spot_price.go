@@ -0,0 +1,76 @@
+package testutil
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/KyberNetwork/kyberswap-dex-lib/pkg/source/pool"
+)
+
+// spotPriceProbeDivisor sets the amount swapped to check spot prices to this fraction of the pool reserve.
+const spotPriceProbeDivisor = 100_000_000
+
+// TestSpotPrice tests that the spot price of every token pair of poolSim is the rate of a swap of a small fraction of
+// the reserve of the token in, within tolerance, and is never worse than it.
+func TestSpotPrice(t *testing.T, poolSim interface {
+	pool.IPoolSimulator
+	pool.IPoolSpotPricer
+}, tolerance float64) {
+	t.Helper()
+	reserves := poolSim.GetReserves()
+	for inIdx, tokenIn := range poolSim.GetTokens() {
+		amountIn := new(big.Int).Div(reserves[inIdx], big.NewInt(spotPriceProbeDivisor))
+		if amountIn.Sign() <= 0 {
+			continue
+		}
+		for _, tokenOut := range poolSim.CanSwapFrom(tokenIn) {
+			spotPrice, err := poolSim.SpotPrice(tokenIn, tokenOut)
+			require.NoError(t, err, "%s -> %s", tokenIn, tokenOut)
+			res, err := poolSim.CalcAmountOut(pool.CalcAmountOutParams{
+				TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: amountIn},
+				TokenOut:      tokenOut,
+			})
+			require.NoError(t, err, "%s -> %s", tokenIn, tokenOut)
+
+			spot, _ := spotPrice.Float64()
+			rate, _ := new(big.Float).Quo(new(big.Float).SetInt(res.TokenAmountOut.Amount),
+				new(big.Float).SetInt(amountIn)).Float64()
+			assert.InEpsilon(t, spot, rate, tolerance, "%s -> %s: spot %v, rate %v", tokenIn, tokenOut, spot, rate)
+			assert.LessOrEqual(t, rate, spot*(1+1e-9), "%s -> %s", tokenIn, tokenOut)
+		}
+	}
+}
+
+// TestDepth tests that the depth of poolSim at impactBps is what CalcAmountOut quotes for its amount in, within
+// tolerance, and that the rate of that swap is within impactBps of the spot price.
+func TestDepth(t *testing.T, poolSim interface {
+	pool.IPoolSimulator
+	pool.IPoolSpotPricer
+	pool.IPoolDepthCalculator
+}, limit pool.SwapLimit, tokenIn, tokenOut string, impactBps int64, tolerance float64) {
+	t.Helper()
+	point, err := poolSim.Depth(tokenIn, tokenOut, impactBps)
+	require.NoError(t, err)
+	require.Positive(t, point.AmountIn.Sign(), "%d bps", impactBps)
+
+	res, err := poolSim.CalcAmountOut(pool.CalcAmountOutParams{
+		TokenAmountIn: pool.TokenAmount{Token: tokenIn, Amount: point.AmountIn},
+		TokenOut:      tokenOut,
+		Limit:         limit,
+	})
+	require.NoError(t, err, "%d bps", impactBps)
+	amountOut, _ := new(big.Float).SetInt(res.TokenAmountOut.Amount).Float64()
+	expected, _ := new(big.Float).SetInt(point.AmountOut).Float64()
+	assert.InEpsilon(t, expected, amountOut, tolerance, "%d bps", impactBps)
+
+	spotPrice, err := poolSim.SpotPrice(tokenIn, tokenOut)
+	require.NoError(t, err)
+	spot, _ := spotPrice.Float64()
+	amountIn, _ := new(big.Float).SetInt(point.AmountIn).Float64()
+	assert.GreaterOrEqual(t, amountOut/amountIn, spot*float64(pool.BasisPoint-impactBps)/pool.BasisPoint*(1-tolerance),
+		"%d bps", impactBps)
+	assert.LessOrEqual(t, amountOut/amountIn, spot*(1+1e-9), "%d bps", impactBps)
+}